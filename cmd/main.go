@@ -11,8 +11,13 @@ import (
 	"time"
 
 	"container-manager/internal/application"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
 	containerruntime "container-manager/internal/infrastructure/container_runtime"
+	keymanager "container-manager/internal/infrastructure/key_manager"
+	"container-manager/internal/infrastructure/oidc"
 	"container-manager/internal/infrastructure/repository"
+	"container-manager/internal/infrastructure/s3"
 	"container-manager/internal/server"
 	"container-manager/internal/server/handler"
 	"container-manager/internal/server/middleware"
@@ -58,7 +63,10 @@ func main() {
 	}
 
 	// Infrastructure Layer - File Storage
-	fileStorage := repository.NewLocalFileStorage(cfg.Storage.BasePath)
+	fileStorage, err := newFileStorage(cfg.Storage)
+	if err != nil {
+		log.Fatalf("failed to create file storage: %v", err)
+	}
 
 	// ID Generation
 	idNode, err := snowflake.NewNode(cfg.Snowflake.MachineID)
@@ -70,27 +78,153 @@ func main() {
 	userRepo := repository.NewUserRepository(db)
 	containerUserRepo := repository.NewContainerUserRepository(db)
 	jobRepo := repository.NewJobRepository(db)
+	quotaRepo := repository.NewQuotaRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	revokedTokenRepo := repository.NewRevokedTokenRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	oidcLoginStateRepo := repository.NewOIDCLoginStateRepository(db)
+	userIdentityRepo := repository.NewUserIdentityRepository(db)
+	mfaRepo := repository.NewMFARepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	loginFailureRepo := repository.NewLoginFailureRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	uploadRepo := repository.NewUploadRepository(db)
+	fileRepo := repository.NewFileRepository(db)
+	fileShareRepo := repository.NewFileShareRepository(db)
+
+	// Password Policy
+	passwordPolicy := entity.PasswordPolicy{
+		MinLength:           cfg.Password.MinLength,
+		MinCharacterClasses: cfg.Password.MinCharacterClasses,
+	}
+	if cfg.Password.BreachedListFile != "" {
+		passwordPolicy.Breached, err = repository.LoadBreachedPasswords(cfg.Password.BreachedListFile)
+		if err != nil {
+			log.Fatalf("failed to load breached password list: %v", err)
+		}
+	}
+
+	// Infrastructure Layer - Token Signing Keys
+	keyFiles := make([]keymanager.KeyFile, 0, len(cfg.JWT.Keys))
+	for _, key := range cfg.JWT.Keys {
+		keyFiles = append(keyFiles, keymanager.KeyFile{ID: key.ID, PrivateKeyFile: key.PrivateKeyFile, PublicKeyFile: key.PublicKeyFile})
+	}
+	var acceptLegacyHS256Until time.Time
+	if cfg.JWT.AcceptLegacyHS256Until != "" {
+		acceptLegacyHS256Until, err = time.Parse(time.RFC3339, cfg.JWT.AcceptLegacyHS256Until)
+		if err != nil {
+			log.Fatalf("invalid jwt.accept_legacy_hs256_until: %v", err)
+		}
+	}
+	keyManager, err := keymanager.NewKeyManager(keymanager.Options{
+		SigningKeyID:           cfg.JWT.SigningKeyID,
+		Keys:                   keyFiles,
+		HMACSecret:             cfg.Server.JWTSecret,
+		AcceptLegacyHS256Until: acceptLegacyHS256Until,
+	})
+	if err != nil {
+		log.Fatalf("failed to load jwt signing keys: %v", err)
+	}
 
 	// Application Layer
-	userService := application.NewUserService(userRepo, idNode, cfg.Server.JWTSecret)
-	fileService := application.NewFileService(fileStorage)
-	containerService := application.NewContainerService(runtime, containerUserRepo, jobRepo)
-	jobService := application.NewJobService(jobRepo)
+	loginThrottle := application.NewLoginThrottle(loginFailureRepo, application.LoginThrottleOptions{
+		Account: loginThrottlePolicy(cfg.Login.Account),
+		IP:      loginThrottlePolicy(cfg.Login.IP),
+	})
+	userService := application.NewUserService(userRepo, refreshTokenRepo, revokedTokenRepo, mfaRepo, keyManager, idNode, application.TokenOptions{
+		AccessTokenTTL:  cfg.Server.AccessTokenTTL,
+		RefreshTokenTTL: cfg.Server.RefreshTokenTTL,
+	}, passwordPolicy, loginThrottle)
+	quotaService := application.NewQuotaService(quotaRepo, application.QuotaOptions{
+		DefaultLimits: entity.QuotaResources{
+			Containers:        cfg.Quota.Containers,
+			RunningContainers: cfg.Quota.RunningContainers,
+			MemoryBytes:       cfg.Quota.MemoryBytes,
+			NanoCPUs:          cfg.Quota.NanoCPUs,
+			StorageBytes:      cfg.Quota.StorageBytes,
+		},
+		ContainerMemoryBytes: cfg.Quota.ContainerMemoryBytes,
+		ContainerNanoCPUs:    cfg.Quota.ContainerNanoCPUs,
+	})
+	authorizer := application.NewAuthorizer(teamRepo)
+	uploadPolicy := entity.UploadPolicy{
+		MaxFileSize:  cfg.Upload.MaxFileSize,
+		AllowedTypes: cfg.Upload.AllowedTypes,
+		DeniedTypes:  cfg.Upload.DeniedTypes,
+	}
+	fileService := application.NewFileService(fileStorage, fileRepo, quotaService, authorizer, uploadPolicy)
+	uploadService := application.NewUploadService(uploadRepo, fileStorage, fileRepo, quotaService, authorizer, uploadPolicy, cfg.Upload.Expiration)
+	containerService := application.NewContainerService(runtime, containerUserRepo, jobRepo, quotaService, authorizer)
+	containerFileService := application.NewContainerFileService(containerService, fileService)
+	extractService := application.NewExtractService(fileService, jobRepo, entity.ExtractLimits{
+		MaxFiles: cfg.Upload.ExtractMaxFiles,
+		MaxSize:  cfg.Upload.ExtractMaxSize,
+		MaxRatio: cfg.Upload.ExtractMaxRatio,
+	})
+	if cfg.Share.Secret == "" {
+		log.Fatal("share.secret must be set to sign share links")
+	}
+	fileShareService := application.NewFileShareService(fileService, fileShareRepo, userRepo, application.FileShareOptions{
+		Secret:     []byte(cfg.Share.Secret),
+		DefaultTTL: cfg.Share.DefaultTTL,
+		MaxTTL:     cfg.Share.MaxTTL,
+	})
+	jobService := application.NewJobService(jobRepo, authorizer)
+	teamService := application.NewTeamService(teamRepo, userRepo, fileShareRepo, idNode)
+	accountService := application.NewAccountService(userRepo, refreshTokenRepo, teamRepo, jobRepo, containerService, fileStorage, uploadRepo)
+	auditService := application.NewAuditService(auditRepo)
+	apiKeyService := application.NewAPIKeyService(apiKeyRepo)
+	mfaService := application.NewMFAService(mfaRepo, userRepo, cfg.MFA.Issuer)
+	oidcProviders := map[string]application.OIDCProviderOptions{}
+	for _, provider := range cfg.OIDC.Providers {
+		oidcProviders[provider.Name] = application.OIDCProviderOptions{
+			Provider: oidc.NewProvider(oidc.Options{
+				Issuer:       provider.Issuer,
+				ClientID:     provider.ClientID,
+				ClientSecret: provider.ClientSecret,
+				RedirectURL:  provider.RedirectURL,
+				Scopes:       provider.Scopes,
+			}),
+			UsernameClaims:    provider.UsernameClaims,
+			LinkExistingUsers: provider.LinkExistingUsers,
+		}
+	}
+	oidcService := application.NewOIDCService(oidcProviders, oidcLoginStateRepo, userIdentityRepo, userRepo, userService, idNode)
 
 	// Handler Layer
-	authMiddleware := middleware.NewAuthMiddleware(cfg.Server.JWTSecret)
+	authMiddleware := middleware.NewAuthMiddleware(keyManager, revokedTokenRepo, apiKeyService, userRepo)
+	auditMiddleware := middleware.NewAuditMiddleware(auditService)
 	userHandler := handler.NewUserHandler(userService)
 	containerHandler := handler.NewContainerHandler(containerService)
-	fileHandler := handler.NewFileHandler(fileService)
+	containerFileHandler := handler.NewContainerFileHandler(containerService, containerFileService)
+	fileHandler := handler.NewFileHandler(fileService, extractService)
+	fileShareHandler := handler.NewFileShareHandler(fileShareService, cfg.Share.BaseURL)
 	jobHandler := handler.NewJobHandler(jobService)
+	quotaHandler := handler.NewQuotaHandler(quotaService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	jwksHandler := handler.NewJWKSHandler(keyManager)
+	oidcHandler := handler.NewOIDCHandler(oidcService)
+	mfaHandler := handler.NewMFAHandler(mfaService)
+	teamHandler := handler.NewTeamHandler(teamService)
+	accountHandler := handler.NewAccountHandler(accountService)
+	auditHandler := handler.NewAuditHandler(auditService)
+	uploadHandler := handler.NewUploadHandler(uploadService)
 
 	// 2. Setup router and inject handlers
 	r := gin.Default()
+	// The client IP throttles failed logins, so X-Forwarded-For is only believed from known proxies.
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("invalid trusted proxies: %v", err)
+	}
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowAllOrigins = true
-	corsConfig.AllowHeaders = []string{"Authorization", "Content-Type", "Accept"}
+	// The tus headers let browser clients resume uploads.
+	corsConfig.AllowHeaders = []string{"Authorization", "Content-Type", "Accept", "X-Request-ID",
+		"Tus-Resumable", "Upload-Length", "Upload-Metadata", "Upload-Offset", "Upload-Checksum"}
+	corsConfig.ExposeHeaders = []string{"X-Request-ID", "Location", "Tus-Resumable", "Tus-Version", "Tus-Extension",
+		"Tus-Checksum-Algorithm", "Upload-Offset", "Upload-Length", "Upload-Metadata", "Upload-Expires"}
 	r.Use(cors.New(corsConfig))
-	server.RegisterRoutes(r, userHandler, containerHandler, fileHandler, jobHandler, authMiddleware)
+	server.RegisterRoutes(r, userHandler, containerHandler, containerFileHandler, fileHandler, fileShareHandler, jobHandler, quotaHandler, apiKeyHandler, jwksHandler, oidcHandler, mfaHandler, teamHandler, accountHandler, auditHandler, uploadHandler, authMiddleware, auditMiddleware)
 
 	// 3. Start the server with graceful shutdown
 	address := fmt.Sprintf(":%s", cfg.Server.Port)
@@ -106,6 +240,30 @@ func main() {
 		}
 	}()
 
+	// Incomplete uploads that expired are removed, and their reservations given back.
+	go func() {
+		ticker := time.NewTicker(cfg.Upload.CleanupInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := uploadService.ExpireUploads(context.Background()); err != nil {
+				log.Printf("failed to remove expired uploads: %v", err)
+			}
+		}
+	}()
+
+	// Stored content that no file uses any more, and temporary files left by a crash, are removed.
+	if cfg.Storage.GCInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(cfg.Storage.GCInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := fileStorage.CollectGarbage(); err != nil {
+					log.Printf("failed to collect storage garbage: %v", err)
+				}
+			}
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -119,3 +277,37 @@ func main() {
 
 	log.Println("Server exiting")
 }
+
+func loginThrottlePolicy(cfg config.LoginThrottleConfig) entity.LoginThrottlePolicy {
+	return entity.LoginThrottlePolicy{
+		FreeFailures:    cfg.FreeFailures,
+		BaseDelay:       cfg.BaseDelay,
+		MaxDelay:        cfg.MaxDelay,
+		LockoutFailures: cfg.LockoutFailures,
+		Lockout:         cfg.Lockout,
+		ResetAfter:      cfg.ResetAfter,
+	}
+}
+
+// newFileStorage creates the file storage backend selected by the configuration.
+func newFileStorage(cfg config.StorageConfig) (infrastructure.FileStorage, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return repository.NewLocalFileStorage(cfg.BasePath), nil
+	case "s3":
+		client, err := s3.NewClient(s3.Options{
+			Endpoint:        cfg.S3.Endpoint,
+			Region:          cfg.S3.Region,
+			Bucket:          cfg.S3.Bucket,
+			AccessKeyID:     cfg.S3.AccessKeyID,
+			SecretAccessKey: cfg.S3.SecretAccessKey,
+			UsePathStyle:    cfg.S3.UsePathStyle,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return repository.NewS3FileStorage(client, cfg.S3.Prefix, cfg.S3.PartSize)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
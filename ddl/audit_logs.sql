@@ -0,0 +1,28 @@
+CREATE TABLE audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    actor_id BIGINT,
+    api_key_id CHAR(36),
+    action VARCHAR(64) NOT NULL,
+    target_type VARCHAR(64) NOT NULL,
+    target_id VARCHAR(255) NOT NULL,
+    source_ip VARCHAR(45) NOT NULL,
+    outcome VARCHAR(16) NOT NULL,
+    error TEXT,
+    request_id VARCHAR(128) NOT NULL
+);
+
+CREATE INDEX audit_logs_created_at_idx ON audit_logs (created_at);
+CREATE INDEX audit_logs_actor_id_idx ON audit_logs (actor_id, id);
+CREATE INDEX audit_logs_target_idx ON audit_logs (target_type, target_id, id);
+
+-- The audit log is append-only, rows can neither be changed nor deleted.
+CREATE FUNCTION audit_logs_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_append_only
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only();
\ No newline at end of file
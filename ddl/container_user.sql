@@ -1,4 +1,14 @@
 CREATE TABLE container_user (
 	container_id CHAR(64) NOT NULL PRIMARY KEY,
-	user_id BIGINT NOT NULL
-);
\ No newline at end of file
+	user_id BIGINT NOT NULL,
+	team_id BIGINT,
+	name VARCHAR(63),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	memory_bytes BIGINT NOT NULL DEFAULT 0,
+	nano_cpus BIGINT NOT NULL DEFAULT 0,
+	running BOOLEAN NOT NULL DEFAULT FALSE,
+	UNIQUE (user_id, name)
+);
+
+CREATE INDEX container_user_user_id_created_at_idx ON container_user (user_id, created_at, container_id);
+CREATE INDEX container_user_team_id_created_at_idx ON container_user (team_id, created_at, container_id);
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE file_shares (
+	id CHAR(36) NOT NULL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	team_id BIGINT,
+	path VARCHAR(1024) NOT NULL,
+	max_downloads INT NOT NULL DEFAULT 0,
+	downloads INT NOT NULL DEFAULT 0,
+	expires_at TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX file_shares_user_id_idx ON file_shares (user_id);
+
+CREATE INDEX file_shares_team_id_idx ON file_shares (team_id);
\ No newline at end of file
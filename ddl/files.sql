@@ -0,0 +1,17 @@
+CREATE TABLE files (
+	user_id BIGINT,
+	team_id BIGINT,
+	-- The user whose storage quota the file counts against.
+	uploaded_by BIGINT,
+	path VARCHAR(1024) NOT NULL,
+	sha256 CHAR(64) NOT NULL,
+	size BIGINT NOT NULL,
+	content_type VARCHAR(255) NOT NULL,
+	uploaded_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	-- A file belongs either to a user or to a team.
+	CHECK ((user_id IS NULL) <> (team_id IS NULL))
+);
+
+CREATE UNIQUE INDEX files_user_path_idx ON files (user_id, path) WHERE team_id IS NULL;
+CREATE UNIQUE INDEX files_team_path_idx ON files (team_id, path) WHERE team_id IS NOT NULL;
+CREATE INDEX files_sha256_idx ON files (sha256);
\ No newline at end of file
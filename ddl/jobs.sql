@@ -5,7 +5,12 @@ CREATE TABLE jobs (
     payload JSON,
     result JSON,
     error TEXT,
+    progress JSON,
     user_id BIGINT NOT NULL,
+    team_id BIGINT,
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMP NOT NULL DEFAULT NOW()
 );
+
+
+CREATE INDEX jobs_user_id_idx ON jobs (user_id, created_at);
\ No newline at end of file
@@ -0,0 +1,5 @@
+CREATE TABLE login_failures (
+	key VARCHAR(255) PRIMARY KEY,
+	failures INTEGER NOT NULL,
+	last_failed_at TIMESTAMP NOT NULL
+);
\ No newline at end of file
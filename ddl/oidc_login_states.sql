@@ -0,0 +1,7 @@
+CREATE TABLE oidc_login_states (
+	state CHAR(43) NOT NULL PRIMARY KEY,
+	provider VARCHAR(64) NOT NULL,
+	code_verifier CHAR(43) NOT NULL,
+	nonce CHAR(43) NOT NULL,
+	expires_at TIMESTAMP NOT NULL
+);
\ No newline at end of file
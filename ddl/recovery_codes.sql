@@ -0,0 +1,7 @@
+CREATE TABLE recovery_codes (
+	user_id BIGINT NOT NULL,
+	code_hash CHAR(64) NOT NULL,
+	used_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (user_id, code_hash)
+);
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE refresh_tokens (
+	id CHAR(36) NOT NULL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	family_id CHAR(36) NOT NULL,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	expires_at TIMESTAMP NOT NULL,
+	rotated_at TIMESTAMP,
+	revoked_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
\ No newline at end of file
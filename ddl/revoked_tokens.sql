@@ -0,0 +1,4 @@
+CREATE TABLE revoked_tokens (
+	jti CHAR(36) NOT NULL PRIMARY KEY,
+	expires_at TIMESTAMP NOT NULL
+);
\ No newline at end of file
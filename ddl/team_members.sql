@@ -0,0 +1,9 @@
+CREATE TABLE team_members (
+	team_id BIGINT NOT NULL,
+	user_id BIGINT NOT NULL,
+	role VARCHAR(16) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (team_id, user_id)
+);
+
+CREATE INDEX team_members_user_id_idx ON team_members (user_id);
\ No newline at end of file
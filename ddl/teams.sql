@@ -0,0 +1,11 @@
+CREATE TABLE teams (
+	id BIGINT NOT NULL PRIMARY KEY,
+	name VARCHAR(63) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- The scripts run in the order of their names, so the tables of earlier scripts get their
+-- references to teams here.
+ALTER TABLE container_user ADD FOREIGN KEY (team_id) REFERENCES teams (id);
+ALTER TABLE team_members ADD FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE CASCADE;
+ALTER TABLE files ADD FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE CASCADE;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE uploads (
+	id CHAR(32) NOT NULL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	team_id BIGINT,
+	filename VARCHAR(1024) NOT NULL,
+	length BIGINT NOT NULL,
+	upload_offset BIGINT NOT NULL DEFAULT 0,
+	metadata TEXT NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX uploads_expires_at_idx ON uploads (expires_at);
+CREATE INDEX uploads_user_id_idx ON uploads (user_id);
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE user_quotas (
+	user_id BIGINT NOT NULL PRIMARY KEY,
+	containers BIGINT NOT NULL DEFAULT 0,
+	running_containers BIGINT NOT NULL DEFAULT 0,
+	memory_bytes BIGINT NOT NULL DEFAULT 0,
+	nano_cpus BIGINT NOT NULL DEFAULT 0,
+	storage_bytes BIGINT NOT NULL DEFAULT 0
+);
\ No newline at end of file
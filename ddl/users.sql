@@ -2,5 +2,12 @@ CREATE TABLE users (
 	id BIGINT NOT NULL PRIMARY KEY,
 	username VARCHAR(255) NOT NULL UNIQUE,
 	password CHAR(60) NOT NULL,
+	role VARCHAR(16) NOT NULL DEFAULT 'member',
+	sessions_valid_after TIMESTAMP,
 	created_at TIMESTAMP DEFAULT NOW() NOT NULL
-);
\ No newline at end of file
+);
+
+-- The scripts run in the order of their names, so the tables of earlier scripts get their
+-- references to users here.
+ALTER TABLE files ADD FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE files ADD FOREIGN KEY (uploaded_by) REFERENCES users (id) ON DELETE SET NULL;
\ No newline at end of file
@@ -0,0 +1,128 @@
+package integration_tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountAPI_Integration(t *testing.T) {
+	setupTestDB(t)
+
+	r := setupServer(t, nil)
+
+	serve := func(method, path, token string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			_ = json.NewEncoder(&body).Encode(payload)
+		}
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	token := registerAndLogin(t, r, "alice", "password123")
+
+	// 1. Profile
+	w := serve("GET", "/users/me", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var profile struct {
+		Username   string `json:"username"`
+		Role       string `json:"role"`
+		MFAEnabled bool   `json:"mfa_enabled"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &profile))
+	assert.Equal(t, "alice", profile.Username)
+	assert.Equal(t, "member", profile.Role)
+	assert.False(t, profile.MFAEnabled)
+
+	// 2. Change the password. Sessions end with a resolution of one second.
+	time.Sleep(time.Second)
+	w = serve("PATCH", "/users/me/password", token, map[string]string{"old_password": "wrong", "new_password": "password456"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serve("PATCH", "/users/me/password", token, map[string]string{"old_password": "password123", "new_password": "password456"})
+	require.Equal(t, http.StatusOK, w.Code)
+	var tokens struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+
+	w = serve("GET", "/users/me", token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "the old session should have ended")
+
+	w = serve("POST", "/users/login", "", map[string]string{"username": "alice", "password": "password123"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	token = "Bearer " + tokens.Token
+	w = serve("GET", "/users/me", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// 3. Leave a file, a share link and an incomplete upload behind
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "data.csv")
+	require.NoError(t, err)
+	_, _ = part.Write([]byte("id,name\n1,alice\n"))
+	require.NoError(t, writer.Close())
+	req, _ := http.NewRequest("POST", "/files", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", token)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = serve("POST", "/files/share/data.csv", token, nil)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var shareResp struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &shareResp))
+
+	req, _ = http.NewRequest("POST", "/uploads", nil)
+	req.Header.Set("Authorization", token)
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	req.Header.Set("Upload-Length", "10")
+	req.Header.Set("Upload-Metadata", "filename ZGF0YS5jc3Y=")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	// 4. Delete the account and follow the job
+	w = serve("DELETE", "/users/me", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var deleteResp struct {
+		JobID string `json:"job_id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &deleteResp))
+	require.NotEmpty(t, deleteResp.JobID)
+
+	// Once the account is gone, its token no longer authenticates.
+	require.Eventually(t, func() bool {
+		return serve("GET", "/users/me", token, nil).Code == http.StatusUnauthorized
+	}, 5*time.Second, 50*time.Millisecond)
+
+	w = serve("POST", "/users/login", "", map[string]string{"username": "alice", "password": "password456"})
+	assert.NotEqual(t, http.StatusOK, w.Code)
+
+	// Nothing of the user is left behind, and the share link no longer serves the file.
+	for _, table := range []string{"files", "uploads", "file_shares"} {
+		var count int
+		require.NoError(t, testDB.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&count))
+		assert.Zero(t, count, table)
+	}
+	w = serve("GET", "/shared/"+shareResp.Token, "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,90 @@
+package integration_tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminAPI_Integration(t *testing.T) {
+	setupTestDB(t)
+
+	r := setupServer(t, nil)
+
+	serve := func(method, path, token string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			_ = json.NewEncoder(&body).Encode(payload)
+		}
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	memberToken := registerAndLogin(t, r, "member", "password123")
+	_ = registerAndLogin(t, r, "admin", "password123")
+
+	// 1. Members cannot use the admin routes
+	w := serve("GET", "/admin/users", memberToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 2. The first admin is promoted in the database and signs in again
+	_, err := testDB.ExecContext(context.Background(), "UPDATE users SET role = 'admin' WHERE username = 'admin'")
+	require.NoError(t, err)
+	w = serve("POST", "/users/login", "", map[string]string{"username": "admin", "password": "password123"})
+	require.Equal(t, http.StatusOK, w.Code)
+	var loginResp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &loginResp))
+	assert.Equal(t, "admin", loginResp["role"])
+	adminToken := "Bearer " + loginResp["token"].(string)
+
+	w = serve("GET", "/admin/users", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var usersResp struct {
+		Users []struct {
+			ID       string `json:"id"`
+			Username string `json:"username"`
+			Role     string `json:"role"`
+		} `json:"users"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &usersResp))
+	require.Len(t, usersResp.Users, 2)
+	var memberID string
+	for _, user := range usersResp.Users {
+		if user.Username == "member" {
+			memberID = user.ID
+			assert.Equal(t, "member", user.Role)
+		}
+	}
+	require.NotEmpty(t, memberID)
+
+	w = serve("GET", "/admin/users/"+memberID+"/jobs", adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"jobs":[]}`, w.Body.String())
+
+	// 3. Demoting the member invalidates its token, the refreshed role is read-only
+	w = serve("PATCH", "/admin/users/"+memberID+"/role", adminToken, map[string]string{"role": "read_only"})
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = serve("GET", "/jobs", memberToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serve("PATCH", "/admin/users/"+memberID+"/role", adminToken, map[string]string{"role": "owner"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	unknownID, _ := strconv.ParseInt(memberID, 10, 64)
+	w = serve("PATCH", "/admin/users/"+strconv.FormatInt(unknownID+1, 10)+"/role", adminToken, map[string]string{"role": "member"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
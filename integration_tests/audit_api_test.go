@@ -0,0 +1,107 @@
+package integration_tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditAPI_Integration(t *testing.T) {
+	setupTestDB(t)
+
+	r := setupServer(t, nil)
+
+	serve := func(method, path, token string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			_ = json.NewEncoder(&body).Encode(payload)
+		}
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Request-ID", "audit-test")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// 1. Actions are recorded with their outcome
+	memberToken := registerAndLogin(t, r, "member", "password123")
+	w := serve("POST", "/users/login", "", map[string]string{"username": "member", "password": "wrong-password"})
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	w = serve("DELETE", "/containers/missing", memberToken, nil)
+	require.Equal(t, http.StatusNotFound, w.Code)
+	w = serve("POST", "/teams", memberToken, map[string]string{"name": "platform"})
+	require.Equal(t, http.StatusOK, w.Code)
+	// Without a login the route is recorded in place of the action.
+	w = serve("POST", "/users/me/api-keys", "", map[string]string{"name": "ci"})
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 2. Only admins can read the audit log
+	w = serve("GET", "/audit", memberToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	_ = registerAndLogin(t, r, "admin", "password123")
+	_, err := testDB.ExecContext(context.Background(), "UPDATE users SET role = 'admin' WHERE username = 'admin'")
+	require.NoError(t, err)
+	w = serve("POST", "/users/login", "", map[string]string{"username": "admin", "password": "password123"})
+	require.Equal(t, http.StatusOK, w.Code)
+	var loginResp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &loginResp))
+	adminToken := "Bearer " + loginResp["token"].(string)
+
+	// 3. Filter the failed logins
+	w = serve("GET", "/audit?action=user.login&outcome=failure", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var listResp struct {
+		Entries []map[string]any `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listResp))
+	require.Len(t, listResp.Entries, 1)
+	assert.Equal(t, "member", listResp.Entries[0]["target_id"])
+	assert.Equal(t, "audit-test", listResp.Entries[0]["request_id"])
+	assert.NotEmpty(t, listResp.Entries[0]["source_ip"])
+
+	w = serve("GET", "/audit?action=container.remove", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listResp))
+	require.Len(t, listResp.Entries, 1)
+	assert.Equal(t, "missing", listResp.Entries[0]["target_id"])
+	assert.Equal(t, "failure", listResp.Entries[0]["outcome"])
+	assert.Equal(t, "container not found", listResp.Entries[0]["error"])
+	assert.NotEmpty(t, listResp.Entries[0]["actor_id"])
+
+	w = serve("GET", "/audit?action=team.create", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listResp))
+	require.Len(t, listResp.Entries, 1)
+	assert.Equal(t, "success", listResp.Entries[0]["outcome"])
+	assert.NotEmpty(t, listResp.Entries[0]["target_id"])
+
+	w = serve("GET", "/audit?action=POST+/users/me/api-keys", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listResp))
+	require.Len(t, listResp.Entries, 1)
+	assert.Equal(t, "failure", listResp.Entries[0]["outcome"])
+
+	// 4. Export everything as JSON lines: two sign-ups, four logins, the removal, the team and the
+	// API key
+	w = serve("GET", "/audit/export", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 9)
+
+	// 5. Entries cannot be changed
+	_, err = testDB.ExecContext(context.Background(), "DELETE FROM audit_logs")
+	assert.Error(t, err)
+}
@@ -7,9 +7,13 @@ import (
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"container-manager/internal/application"
+	"container-manager/internal/domain/entity"
 	"container-manager/internal/domain/infrastructure"
+	keymanager "container-manager/internal/infrastructure/key_manager"
+	"container-manager/internal/infrastructure/oidc"
 	"container-manager/internal/infrastructure/repository"
 	"container-manager/internal/server"
 	"container-manager/internal/server/handler"
@@ -60,7 +64,7 @@ func setupTestDB(t *testing.T) {
 func truncateTables(t *testing.T) {
 	t.Helper()
 	ctx := context.Background()
-	tables := []string{"jobs", "container_user", "users"}
+	tables := []string{"jobs", "container_user", "users", "user_quotas", "quota_usage", "refresh_tokens", "revoked_tokens", "api_keys", "oidc_login_states", "user_identities", "user_mfa", "recovery_codes", "teams", "team_members", "login_failures", "audit_logs", "uploads", "files", "file_shares"}
 
 	for _, table := range tables {
 		_, err := testDB.ExecContext(ctx, fmt.Sprintf("TRUNCATE TABLE %s CASCADE", table))
@@ -79,18 +83,93 @@ func setupServer(t *testing.T, runtime infrastructure.ContainerRuntime) *gin.Eng
 	userRepo := repository.NewUserRepository(testDB)
 	containerUserRepo := repository.NewContainerUserRepository(testDB)
 	jobRepo := repository.NewJobRepository(testDB)
+	quotaRepo := repository.NewQuotaRepository(testDB)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(testDB)
+	revokedTokenRepo := repository.NewRevokedTokenRepository(testDB)
+	apiKeyRepo := repository.NewAPIKeyRepository(testDB)
+	oidcLoginStateRepo := repository.NewOIDCLoginStateRepository(testDB)
+	userIdentityRepo := repository.NewUserIdentityRepository(testDB)
+	mfaRepo := repository.NewMFARepository(testDB)
+	teamRepo := repository.NewTeamRepository(testDB)
+	loginFailureRepo := repository.NewLoginFailureRepository(testDB)
+	auditRepo := repository.NewAuditRepository(testDB)
+	uploadRepo := repository.NewUploadRepository(testDB)
+	fileRepo := repository.NewFileRepository(testDB)
+	fileShareRepo := repository.NewFileShareRepository(testDB)
+
+	keyManager, err := keymanager.NewKeyManager(keymanager.Options{HMACSecret: cfg.Server.JWTSecret})
+	require.NoError(t, err)
 
-	jwtSecret := cfg.Server.JWTSecret
-	userService := application.NewUserService(userRepo, idNode, jwtSecret)
-	fileService := application.NewFileService(fileStorage)
-	containerService := application.NewContainerService(runtime, containerUserRepo, jobRepo)
-	jobService := application.NewJobService(jobRepo)
+	// Logins of an account are locked after a few failures, and the client IP is not throttled.
+	loginThrottle := application.NewLoginThrottle(loginFailureRepo, application.LoginThrottleOptions{
+		Account: entity.LoginThrottlePolicy{LockoutFailures: 3, Lockout: time.Minute, ResetAfter: time.Hour},
+	})
+	passwordPolicy := entity.PasswordPolicy{
+		MinLength:           8,
+		MinCharacterClasses: 2,
+		Breached:            map[string]struct{}{"password1": {}},
+	}
+	userService := application.NewUserService(userRepo, refreshTokenRepo, revokedTokenRepo, mfaRepo, keyManager, idNode, application.TokenOptions{
+		AccessTokenTTL:  cfg.Server.AccessTokenTTL,
+		RefreshTokenTTL: cfg.Server.RefreshTokenTTL,
+	}, passwordPolicy, loginThrottle)
+	quotaService := application.NewQuotaService(quotaRepo, application.QuotaOptions{
+		DefaultLimits: entity.QuotaResources{
+			Containers:        cfg.Quota.Containers,
+			RunningContainers: cfg.Quota.RunningContainers,
+			MemoryBytes:       cfg.Quota.MemoryBytes,
+			NanoCPUs:          cfg.Quota.NanoCPUs,
+			StorageBytes:      cfg.Quota.StorageBytes,
+		},
+		ContainerMemoryBytes: cfg.Quota.ContainerMemoryBytes,
+		ContainerNanoCPUs:    cfg.Quota.ContainerNanoCPUs,
+	})
+	authorizer := application.NewAuthorizer(teamRepo)
+	fileService := application.NewFileService(fileStorage, fileRepo, quotaService, authorizer, entity.UploadPolicy{})
+	uploadService := application.NewUploadService(uploadRepo, fileStorage, fileRepo, quotaService, authorizer, entity.UploadPolicy{}, time.Hour)
+	containerService := application.NewContainerService(runtime, containerUserRepo, jobRepo, quotaService, authorizer)
+	containerFileService := application.NewContainerFileService(containerService, fileService)
+	extractService := application.NewExtractService(fileService, jobRepo, entity.ExtractLimits{MaxFiles: 100, MaxSize: 1 << 20, MaxRatio: 100})
+	fileShareService := application.NewFileShareService(fileService, fileShareRepo, userRepo, application.FileShareOptions{Secret: []byte("test-share-secret"), DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour})
+	jobService := application.NewJobService(jobRepo, authorizer)
+	teamService := application.NewTeamService(teamRepo, userRepo, fileShareRepo, idNode)
+	accountService := application.NewAccountService(userRepo, refreshTokenRepo, teamRepo, jobRepo, containerService, fileStorage, uploadRepo)
+	auditService := application.NewAuditService(auditRepo)
+	apiKeyService := application.NewAPIKeyService(apiKeyRepo)
+	mfaService := application.NewMFAService(mfaRepo, userRepo, cfg.MFA.Issuer)
+	oidcProviders := map[string]application.OIDCProviderOptions{}
+	for _, provider := range cfg.OIDC.Providers {
+		oidcProviders[provider.Name] = application.OIDCProviderOptions{
+			Provider: oidc.NewProvider(oidc.Options{
+				Issuer:       provider.Issuer,
+				ClientID:     provider.ClientID,
+				ClientSecret: provider.ClientSecret,
+				RedirectURL:  provider.RedirectURL,
+				Scopes:       provider.Scopes,
+			}),
+			UsernameClaims:    provider.UsernameClaims,
+			LinkExistingUsers: provider.LinkExistingUsers,
+		}
+	}
+	oidcService := application.NewOIDCService(oidcProviders, oidcLoginStateRepo, userIdentityRepo, userRepo, userService, idNode)
 
-	authMiddleware := middleware.NewAuthMiddleware(jwtSecret)
+	authMiddleware := middleware.NewAuthMiddleware(keyManager, revokedTokenRepo, apiKeyService, userRepo)
+	auditMiddleware := middleware.NewAuditMiddleware(auditService)
 	userHandler := handler.NewUserHandler(userService)
 	containerHandler := handler.NewContainerHandler(containerService)
-	fileHandler := handler.NewFileHandler(fileService)
+	containerFileHandler := handler.NewContainerFileHandler(containerService, containerFileService)
+	fileHandler := handler.NewFileHandler(fileService, extractService)
+	fileShareHandler := handler.NewFileShareHandler(fileShareService, "")
 	jobHandler := handler.NewJobHandler(jobService)
+	quotaHandler := handler.NewQuotaHandler(quotaService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	jwksHandler := handler.NewJWKSHandler(keyManager)
+	oidcHandler := handler.NewOIDCHandler(oidcService)
+	mfaHandler := handler.NewMFAHandler(mfaService)
+	teamHandler := handler.NewTeamHandler(teamService)
+	accountHandler := handler.NewAccountHandler(accountService)
+	auditHandler := handler.NewAuditHandler(auditService)
+	uploadHandler := handler.NewUploadHandler(uploadService)
 
 	r := gin.Default()
 	gin.DisableConsoleColor()
@@ -98,10 +177,11 @@ func setupServer(t *testing.T, runtime infrastructure.ContainerRuntime) *gin.Eng
 
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowAllOrigins = true
-	corsConfig.AllowHeaders = []string{"Authorization", "Content-Type", "Accept"}
+	corsConfig.AllowHeaders = []string{"Authorization", "Content-Type", "Accept", "X-Request-ID"}
+	corsConfig.ExposeHeaders = []string{"X-Request-ID"}
 	r.Use(cors.New(corsConfig))
 
-	server.RegisterRoutes(r, userHandler, containerHandler, fileHandler, jobHandler, authMiddleware)
+	server.RegisterRoutes(r, userHandler, containerHandler, containerFileHandler, fileHandler, fileShareHandler, jobHandler, quotaHandler, apiKeyHandler, jwksHandler, oidcHandler, mfaHandler, teamHandler, accountHandler, auditHandler, uploadHandler, authMiddleware, auditMiddleware)
 
 	return r
 }
@@ -1,10 +1,12 @@
 package integration_tests
 
 import (
+	"archive/tar"
 	"bytes"
 	containerruntime "container-manager/internal/infrastructure/container_runtime"
 	"context"
 	"encoding/json"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -98,13 +100,15 @@ func TestContainerAPI_Integration(t *testing.T) {
 		r.ServeHTTP(wList, reqList)
 
 		require.Equal(t, http.StatusOK, wList.Code)
-		var containers []map[string]interface{}
-		err = json.Unmarshal(wList.Body.Bytes(), &containers)
+		var listResp struct {
+			Containers []map[string]interface{} `json:"containers"`
+		}
+		err = json.Unmarshal(wList.Body.Bytes(), &listResp)
 		require.NoError(t, err)
 
-		assert.NotEmpty(t, containers)
+		assert.NotEmpty(t, listResp.Containers)
 		found := false
-		for _, c := range containers {
+		for _, c := range listResp.Containers {
 			if c["id"] == containerID {
 				found = true
 				break
@@ -112,7 +116,55 @@ func TestContainerAPI_Integration(t *testing.T) {
 		}
 		assert.True(t, found, "Created container should be in list")
 
-		// 5. Cleanup
+		// 5. Copy a stored file into the container and save it back under another name
+		upload := new(bytes.Buffer)
+		writer := multipart.NewWriter(upload)
+		part, err := writer.CreateFormFile("file", "data.csv")
+		require.NoError(t, err)
+		_, _ = part.Write([]byte("id,name\n1,alice\n"))
+		require.NoError(t, writer.Close())
+		reqUpload, _ := http.NewRequest("POST", "/files", upload)
+		reqUpload.Header.Set("Content-Type", writer.FormDataContentType())
+		reqUpload.Header.Set("Authorization", authHeader)
+		wUpload := httptest.NewRecorder()
+		r.ServeHTTP(wUpload, reqUpload)
+		require.Equal(t, http.StatusOK, wUpload.Code)
+
+		copyFile := func(path, body string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("POST", "/containers/"+containerID+path, bytes.NewBufferString(body))
+			req.Header.Set("Authorization", authHeader)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			return w
+		}
+		wImport := copyFile("/files/import", `{"file": "data.csv", "path": "/tmp"}`)
+		require.Equal(t, http.StatusNoContent, wImport.Code, wImport.Body.String())
+
+		reqArchive, _ := http.NewRequest("GET", "/containers/"+containerID+"/archive?path=/tmp/data.csv", nil)
+		reqArchive.Header.Set("Authorization", authHeader)
+		wArchive := httptest.NewRecorder()
+		r.ServeHTTP(wArchive, reqArchive)
+		require.Equal(t, http.StatusOK, wArchive.Code)
+		archive := tar.NewReader(wArchive.Body)
+		header, err := archive.Next()
+		require.NoError(t, err)
+		assert.Equal(t, "data.csv", header.Name)
+
+		wExport := copyFile("/files/export", `{"path": "/tmp/data.csv", "file": "copy.csv"}`)
+		require.Equal(t, http.StatusCreated, wExport.Code, wExport.Body.String())
+
+		reqDownload, _ := http.NewRequest("GET", "/files/copy.csv", nil)
+		reqDownload.Header.Set("Authorization", authHeader)
+		wDownload := httptest.NewRecorder()
+		r.ServeHTTP(wDownload, reqDownload)
+		require.Equal(t, http.StatusOK, wDownload.Code)
+		assert.Equal(t, "id,name\n1,alice\n", wDownload.Body.String())
+
+		wMissing := copyFile("/files/export", `{"path": "/tmp/missing.csv", "file": "missing.csv"}`)
+		assert.Equal(t, http.StatusNotFound, wMissing.Code)
+
+		// 6. Cleanup
 		_ = runtime.Remove(context.Background(), containerID)
 	})
 }
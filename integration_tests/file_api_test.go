@@ -0,0 +1,198 @@
+package integration_tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileAPI_Integration(t *testing.T) {
+	setupTestDB(t)
+
+	r := setupServer(t, nil)
+	token := registerAndLogin(t, r, "fileuser", "password123")
+
+	serve := func(method, path string, header http.Header) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		req.Header.Set("Authorization", token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// 1. Upload a file
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "data.csv")
+	require.NoError(t, err)
+	_, _ = part.Write([]byte("id,name\n1,alice\n"))
+	require.NoError(t, writer.Close())
+	req, _ := http.NewRequest("POST", "/files", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var uploadResp struct {
+		Path   string `json:"path"`
+		SHA256 string `json:"sha256"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &uploadResp))
+	sum := sha256.Sum256([]byte("id,name\n1,alice\n"))
+	assert.Equal(t, "data.csv", uploadResp.Path)
+	assert.Equal(t, hex.EncodeToString(sum[:]), uploadResp.SHA256)
+
+	var recorded string
+	require.NoError(t, testDB.QueryRow("SELECT sha256 FROM files WHERE path = 'data.csv'").Scan(&recorded))
+	assert.Equal(t, uploadResp.SHA256, recorded)
+
+	// 2. List it
+	w = serve("GET", "/files", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var listResp struct {
+		Files []struct {
+			Name        string `json:"name"`
+			Size        int64  `json:"size"`
+			ContentType string `json:"content_type"`
+		} `json:"files"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listResp))
+	require.Len(t, listResp.Files, 1)
+	assert.Equal(t, "data.csv", listResp.Files[0].Name)
+	assert.Equal(t, int64(16), listResp.Files[0].Size)
+	assert.Contains(t, listResp.Files[0].ContentType, "text/csv")
+
+	// 3. Download it, in part and conditionally
+	w = serve("GET", "/files/data.csv", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "id,name\n1,alice\n", w.Body.String())
+	etag := w.Header().Get("ETag")
+
+	w = serve("GET", "/files/data.csv", http.Header{"Range": {"bytes=8-"}})
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "1,alice\n", w.Body.String())
+
+	w = serve("GET", "/files/data.csv", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	// 4. Move it into a folder and back
+	serveJSON := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	w = serveJSON("POST", "/files/move", `{"from":"data.csv","to":"archive/2024/data.csv"}`)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = serve("GET", "/files?recursive=true", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listResp))
+	names := make([]string, 0, len(listResp.Files))
+	for _, file := range listResp.Files {
+		names = append(names, file.Name)
+	}
+	assert.Equal(t, []string{"archive", "archive/2024", "archive/2024/data.csv"}, names)
+
+	w = serveJSON("POST", "/files/move", `{"from":"archive/2024/data.csv","to":"data.csv"}`)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = serve("DELETE", "/files/archive", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = serve("DELETE", "/files/archive?recursive=true", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// 5. Delete it
+	w = serve("DELETE", "/files/data.csv", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = serve("GET", "/files/data.csv", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serve("GET", "/files", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"files":[]}`, w.Body.String())
+
+	// 6. Upload an archive to be extracted, and wait for the job
+	var archive bytes.Buffer
+	archiveWriter := zip.NewWriter(&archive)
+	entry, err := archiveWriter.Create("site/index.html")
+	require.NoError(t, err)
+	_, _ = entry.Write([]byte("<html></html>"))
+	require.NoError(t, archiveWriter.Close())
+
+	body = new(bytes.Buffer)
+	writer = multipart.NewWriter(body)
+	require.NoError(t, writer.WriteField("extract", "true"))
+	part, err = writer.CreateFormFile("file", "bundle.zip")
+	require.NoError(t, err)
+	_, _ = part.Write(archive.Bytes())
+	require.NoError(t, writer.Close())
+	req, _ = http.NewRequest("POST", "/files", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", token)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var extractResp struct {
+		JobID string `json:"job_id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &extractResp))
+	require.NotEmpty(t, extractResp.JobID)
+
+	assert.Eventually(t, func() bool {
+		w := serve("GET", "/jobs/"+extractResp.JobID, nil)
+		var job struct {
+			Status string `json:"status"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &job)
+		return job.Status == "completed"
+	}, 10*time.Second, 100*time.Millisecond)
+
+	w = serve("GET", "/files/bundle/site/index.html", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<html></html>", w.Body.String())
+	w = serve("GET", "/files/bundle.zip", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 7. Share a file, download it without logging in, and revoke the link
+	w = serveJSON("POST", "/files/share/bundle/site/index.html", `{"max_downloads":2}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var shareResp struct {
+		ID    string `json:"id"`
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &shareResp))
+
+	req, _ = http.NewRequest("GET", "/shared/"+shareResp.Token, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<html></html>", w.Body.String())
+
+	w = serve("GET", "/shares", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"downloads":1`)
+
+	w = serve("DELETE", "/shares/"+shareResp.ID, nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	req, _ = http.NewRequest("GET", "/shared/"+shareResp.Token, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusGone, w.Code)
+}
@@ -0,0 +1,58 @@
+package integration_tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginAPI_Integration(t *testing.T) {
+	setupTestDB(t)
+
+	r := setupServer(t, nil)
+
+	serve := func(method, path string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		_ = json.NewEncoder(&body).Encode(payload)
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// 1. Registration applies the username and password policy
+	for _, tt := range []struct {
+		username, password, message string
+	}{
+		{"a b", "password123", "invalid username"},
+		{"alice", "pass1", "password is too short"},
+		{"alice", "onlyletters", "password needs more kinds of characters"},
+		{"alice", "Password1", "password appears in a list of breached passwords"},
+	} {
+		w := serve("POST", "/users", map[string]string{"username": tt.username, "password": tt.password})
+		assert.Equal(t, http.StatusBadRequest, w.Code, tt.password)
+		assert.Contains(t, w.Body.String(), tt.message)
+	}
+
+	w := serve("POST", "/users", map[string]string{"username": "alice", "password": "password123"})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// 2. The account is locked after three wrong passwords, also for the right one
+	for range 3 {
+		w = serve("POST", "/users/login", map[string]string{"username": "alice", "password": "wrong-password"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	w = serve("POST", "/users/login", map[string]string{"username": "alice", "password": "password123"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// Usernames are not case-sensitive for throttling
+	w = serve("POST", "/users/login", map[string]string{"username": "ALICE", "password": "password123"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
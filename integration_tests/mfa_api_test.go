@@ -0,0 +1,91 @@
+package integration_tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"container-manager/internal/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMFAAPI_Integration(t *testing.T) {
+	setupTestDB(t)
+
+	r := setupServer(t, nil)
+
+	serve := func(method, path, token string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			_ = json.NewEncoder(&body).Encode(payload)
+		}
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	username, password := "mfauser", "password123"
+	token := registerAndLogin(t, r, username, password)
+
+	// 1. Enroll and confirm with a first code
+	w := serve("POST", "/users/me/mfa/totp", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var enrollResp map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enrollResp))
+	secret := enrollResp["secret"]
+	require.NotEmpty(t, secret)
+
+	code, err := entity.TOTPCode(secret, entity.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	w = serve("POST", "/users/me/mfa/totp/confirm", token, map[string]string{"code": code})
+	require.Equal(t, http.StatusOK, w.Code)
+	var confirmResp map[string][]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &confirmResp))
+	recoveryCodes := confirmResp["recovery_codes"]
+	require.Len(t, recoveryCodes, entity.RecoveryCodeCount)
+
+	// 2. The password alone only yields a challenge
+	w = serve("POST", "/users/login", "", map[string]string{"username": username, "password": password})
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var challenge map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &challenge))
+	assert.Equal(t, true, challenge["mfa_required"])
+	assert.Nil(t, challenge["token"])
+	mfaToken := challenge["mfa_token"].(string)
+
+	w = serve("GET", "/users/me/quota", "Bearer "+mfaToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 3. The code of the enrollment cannot be replayed, a recovery code completes the login once
+	w = serve("POST", "/users/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": code})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serve("POST", "/users/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": recoveryCodes[0]})
+	require.Equal(t, http.StatusOK, w.Code)
+	var loginResp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &loginResp))
+	assert.NotEmpty(t, loginResp["token"])
+
+	w = serve("POST", "/users/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": recoveryCodes[1]})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 4. Disabling requires a code, used recovery codes are rejected
+	w = serve("DELETE", "/users/me/mfa/totp", token, map[string]string{"code": recoveryCodes[0]})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serve("DELETE", "/users/me/mfa/totp", token, map[string]string{"code": recoveryCodes[1]})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = serve("POST", "/users/login", "", map[string]string{"username": username, "password": password})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
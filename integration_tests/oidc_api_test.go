@@ -0,0 +1,76 @@
+package integration_tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"container-manager/internal/infrastructure/oidc/oidctest"
+	"container-manager/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCAPI_Integration(t *testing.T) {
+	setupTestDB(t)
+
+	issuer, err := oidctest.NewIssuer("container-manager", "secret")
+	require.NoError(t, err)
+	defer issuer.Close()
+
+	providers := cfg.OIDC.Providers
+	cfg.OIDC.Providers = []config.OIDCProviderConfig{{
+		Name:           "mock",
+		Issuer:         issuer.URL,
+		ClientID:       "container-manager",
+		ClientSecret:   "secret",
+		RedirectURL:    "http://localhost:8080/users/oidc/mock/callback",
+		UsernameClaims: []string{"preferred_username", "email"},
+	}}
+	defer func() { cfg.OIDC.Providers = providers }()
+
+	r := setupServer(t, nil)
+
+	login := func(t *testing.T) (int, map[string]interface{}) {
+		req, _ := http.NewRequest("GET", "/users/oidc/mock/login", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusFound, w.Code)
+
+		code, state, err := issuer.Authorize(w.Header().Get("Location"))
+		require.NoError(t, err)
+
+		req, _ = http.NewRequest("GET", "/users/oidc/mock/callback?"+url.Values{"code": {code}, "state": {state}}.Encode(), nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+
+	t.Run("first login provisions a user", func(t *testing.T) {
+		issuer.SetUser(map[string]any{"sub": "subject-1", "email": "oidcuser@example.com"})
+
+		status, first := login(t)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "oidcuser@example.com", first["username"])
+		assert.NotEmpty(t, first["token"])
+		assert.NotEmpty(t, first["refresh_token"])
+
+		status, second := login(t)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, first["id"], second["id"])
+	})
+
+	t.Run("username of a local user", func(t *testing.T) {
+		registerAndLogin(t, r, "localuser", "password123")
+		issuer.SetUser(map[string]any{"sub": "subject-2", "preferred_username": "localuser"})
+
+		status, _ := login(t)
+		assert.Equal(t, http.StatusConflict, status)
+	})
+}
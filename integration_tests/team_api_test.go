@@ -0,0 +1,154 @@
+package integration_tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamAPI_Integration(t *testing.T) {
+	setupTestDB(t)
+
+	r := setupServer(t, nil)
+
+	serve := func(method, path, token string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			_ = json.NewEncoder(&body).Encode(payload)
+		}
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	ownerToken := registerAndLogin(t, r, "owner", "password123")
+	viewerToken := registerAndLogin(t, r, "viewer", "password123")
+	outsiderToken := registerAndLogin(t, r, "outsider", "password123")
+
+	// 1. Create a team, the creator becomes its owner
+	w := serve("POST", "/teams", ownerToken, map[string]string{"name": "platform"})
+	require.Equal(t, http.StatusOK, w.Code)
+	var team struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Role string `json:"role"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &team))
+	assert.Equal(t, "platform", team.Name)
+	assert.Equal(t, "owner", team.Role)
+
+	// 2. Add a viewer
+	w = serve("POST", "/teams/"+team.ID+"/members", ownerToken, map[string]string{"username": "viewer", "role": "viewer"})
+	require.Equal(t, http.StatusOK, w.Code)
+	var viewer struct {
+		UserID string `json:"user_id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &viewer))
+
+	w = serve("GET", "/teams", viewerToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"role":"viewer"`)
+
+	w = serve("GET", "/teams/"+team.ID+"/members", viewerToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var membersResp struct {
+		Members []map[string]string `json:"members"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &membersResp))
+	assert.Len(t, membersResp.Members, 2)
+
+	// 3. Viewers see the team containers but cannot manage the team
+	w = serve("GET", "/teams/"+team.ID+"/containers", viewerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve("POST", "/teams/"+team.ID+"/members", viewerToken, map[string]string{"username": "outsider", "role": "member"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 4. Users outside the team have no access
+	w = serve("GET", "/teams/"+team.ID+"/containers", outsiderToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serve("GET", "/teams/"+team.ID+"/members", outsiderToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 5. The last owner can neither be demoted nor leave
+	w = serve("POST", "/teams/"+team.ID+"/members", ownerToken, map[string]string{"username": "owner", "role": "member"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 6. The viewer leaves the team
+	w = serve("DELETE", "/teams/"+team.ID+"/members/"+viewer.UserID, viewerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve("GET", "/teams/"+team.ID+"/containers", viewerToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 7. A member shares a team file twice
+	w = serve("POST", "/teams/"+team.ID+"/members", ownerToken, map[string]string{"username": "outsider", "role": "member"})
+	require.Equal(t, http.StatusOK, w.Code)
+	var member struct {
+		UserID string `json:"user_id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &member))
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	require.NoError(t, writer.WriteField("team_id", team.ID))
+	part, err := writer.CreateFormFile("file", "plan.csv")
+	require.NoError(t, err)
+	_, _ = part.Write([]byte("id,name\n1,alice\n"))
+	require.NoError(t, writer.Close())
+	req, _ := http.NewRequest("POST", "/files", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", outsiderToken)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	share := func() (id, token string) {
+		w := serve("POST", "/files/share/plan.csv", outsiderToken, map[string]string{"team_id": team.ID})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var shareResp struct {
+			ID    string `json:"id"`
+			Token string `json:"token"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &shareResp))
+		return shareResp.ID, shareResp.Token
+	}
+	download := func(token string) int {
+		req, _ := http.NewRequest("GET", "/shared/"+token, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	revokedID, revokedToken := share()
+	_, leftToken := share()
+	assert.Equal(t, http.StatusOK, download(leftToken))
+
+	// 8. The owner lists the links to team files and revokes one of them
+	w = serve("GET", "/shares?team_id="+team.ID, ownerToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"user_id":"`+member.UserID+`"`)
+
+	w = serve("GET", "/shares?team_id="+team.ID, viewerToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serve("DELETE", "/shares/"+revokedID, ownerToken, nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, http.StatusGone, download(revokedToken))
+
+	// 9. Once the member leaves, their links to team files stop working
+	w = serve("DELETE", "/teams/"+team.ID+"/members/"+member.UserID, outsiderToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusGone, download(leftToken))
+}
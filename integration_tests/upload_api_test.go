@@ -0,0 +1,109 @@
+package integration_tests
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadAPI_Integration(t *testing.T) {
+	setupTestDB(t)
+
+	r := setupServer(t, nil)
+	token := registerAndLogin(t, r, "uploaduser", "password123")
+
+	serve := func(method, path string, header http.Header, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		req.Header.Set("Authorization", token)
+		req.Header.Set("Tus-Resumable", "1.0.0")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	encode := func(value string) string {
+		return base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	patch := func(location string, offset, chunk, checksum string) *httptest.ResponseRecorder {
+		header := http.Header{"Content-Type": {"application/offset+octet-stream"}, "Upload-Offset": {offset}}
+		if checksum != "" {
+			header.Set("Upload-Checksum", checksum)
+		}
+		return serve("PATCH", location, header, chunk)
+	}
+	content := "id,name\n1,alice\n2,bob\n"
+
+	// 1. Discover the server
+	w := serve("OPTIONS", "/uploads", nil, "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Contains(t, w.Header().Get("Tus-Extension"), "checksum")
+
+	// 2. Start an upload into a folder
+	w = serve("POST", "/uploads", http.Header{
+		"Upload-Length":   {"22"},
+		"Upload-Metadata": {"filename " + encode("data.csv") + ",path " + encode("input")},
+	}, "")
+	require.Equal(t, http.StatusCreated, w.Code)
+	location := w.Header().Get("Location")
+	require.True(t, strings.HasPrefix(location, "/uploads/"))
+	assert.NotEmpty(t, w.Header().Get("Upload-Expires"))
+
+	// 3. Send the first chunk, then a chunk with a bad checksum that is discarded
+	w = patch(location, "0", content[:8], "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "8", w.Header().Get("Upload-Offset"))
+
+	w = patch(location, "8", content[8:], "sha256 "+encode("not the checksum"))
+	assert.Equal(t, 460, w.Code)
+
+	w = patch(location, "0", content, "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 4. Resume from the offset the server reports
+	w = serve("HEAD", location, nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "8", w.Header().Get("Upload-Offset"))
+	assert.Equal(t, "22", w.Header().Get("Upload-Length"))
+
+	sum := sha256.Sum256([]byte(content[8:]))
+	w = patch(location, "8", content[8:], "sha256 "+base64.StdEncoding.EncodeToString(sum[:]))
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "22", w.Header().Get("Upload-Offset"))
+
+	// 5. The file is saved, and the upload gone
+	w = serve("GET", "/files/input/data.csv", nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, content, w.Body.String())
+
+	w = serve("HEAD", location, nil, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 6. Cancel another upload
+	w = serve("POST", "/uploads", http.Header{
+		"Upload-Length":   {"100"},
+		"Upload-Metadata": {"filename " + encode("later.csv")},
+	}, "")
+	require.Equal(t, http.StatusCreated, w.Code)
+	location = w.Header().Get("Location")
+
+	w = serve("DELETE", location, nil, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = serve("HEAD", location, nil, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 7. Requests without the protocol version are rejected
+	req, _ := http.NewRequest("POST", "/uploads", nil)
+	req.Header.Set("Authorization", token)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, "1.0.0", w.Header().Get("Tus-Version"))
+}
@@ -0,0 +1,212 @@
+package application
+
+import (
+	"container-manager/internal/errors"
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// The steps of an account deletion job, in the order they run.
+const (
+	accountDeletionStepContainers = "containers"
+	accountDeletionStepFiles      = "files"
+	accountDeletionStepJobs       = "jobs"
+	accountDeletionStepUser       = "user"
+)
+
+// AccountService deletes the accounts of users together with everything they own.
+type AccountService struct {
+	userRepo         infrastructure.UserRepository
+	refreshTokenRepo infrastructure.RefreshTokenRepository
+	teamRepo         infrastructure.TeamRepository
+	jobRepo          infrastructure.JobRepository
+	containerService *ContainerService
+	fileStorage      infrastructure.FileStorage
+	uploadRepo       infrastructure.UploadRepository
+
+	// deleting holds the IDs of the users whose account deletion job is running.
+	deleting sync.Map
+}
+
+func NewAccountService(userRepo infrastructure.UserRepository, refreshTokenRepo infrastructure.RefreshTokenRepository, teamRepo infrastructure.TeamRepository, jobRepo infrastructure.JobRepository, containerService *ContainerService, fileStorage infrastructure.FileStorage, uploadRepo infrastructure.UploadRepository) *AccountService {
+	return &AccountService{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		teamRepo:         teamRepo,
+		jobRepo:          jobRepo,
+		containerService: containerService,
+		fileStorage:      fileStorage,
+		uploadRepo:       uploadRepo,
+	}
+}
+
+// DeleteAccount enqueues the deletion of the caller's account and returns the ID of the job. The
+// job removes the personal containers and files of the user, purges the jobs of the user and then
+// the user itself. Teams stay with their other owners, so the sole owner of a team has to hand it
+// over first. Refresh tokens are revoked right away, and a failed job can be retried.
+func (s *AccountService) DeleteAccount(ctx context.Context, caller Caller) (string, error) {
+	userID := caller.UserID
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", errors.UserNotFound
+	}
+	if err := s.checkTeamOwnership(ctx, userID); err != nil {
+		return "", err
+	}
+
+	if _, running := s.deleting.LoadOrStore(userID, struct{}{}); running {
+		return "", errors.AccountDeletionInProgress
+	}
+
+	if err := s.refreshTokenRepo.RevokeByUserID(ctx, userID); err != nil {
+		s.deleting.Delete(userID)
+		return "", err
+	}
+
+	job := &entity.Job{
+		ID:        uuid.New().String(),
+		Type:      entity.JobTypeAccountDeletion,
+		Status:    entity.JobStatusPending,
+		Payload:   json.RawMessage("{}"),
+		UserID:    userID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.jobRepo.Create(context.Background(), job); err != nil {
+		s.deleting.Delete(userID)
+		return "", err
+	}
+
+	go s.runDeleteAccountJob(job, userID)
+
+	return job.ID, nil
+}
+
+// checkTeamOwnership fails with errors.LastTeamOwner if the user is the only owner of a team.
+func (s *AccountService) checkTeamOwnership(ctx context.Context, userID int64) error {
+	memberships, err := s.teamRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, membership := range memberships {
+		if membership.Role != entity.TeamRoleOwner {
+			continue
+		}
+		members, err := s.teamRepo.ListMembers(ctx, membership.Team.ID)
+		if err != nil {
+			return err
+		}
+		owners := 0
+		for _, member := range members {
+			if member.Role == entity.TeamRoleOwner {
+				owners++
+			}
+		}
+		if owners < 2 {
+			return errors.LastTeamOwner
+		}
+	}
+	return nil
+}
+
+func (s *AccountService) runDeleteAccountJob(job *entity.Job, userID int64) {
+	defer s.deleting.Delete(userID)
+	ctx := context.Background()
+
+	job.Status = entity.JobStatusRunning
+	job.Progress = &entity.JobProgress{Step: accountDeletionStepContainers}
+	if !s.updateJob(ctx, job) {
+		return
+	}
+
+	containersRemoved, err := s.containerService.RemoveUserContainers(ctx, userID, func(done, total int) {
+		job.Progress = &entity.JobProgress{Step: accountDeletionStepContainers, Done: done, Total: total}
+		s.updateJob(ctx, job)
+	})
+	if err != nil {
+		s.failJob(ctx, job, err)
+		return
+	}
+
+	job.Progress = &entity.JobProgress{Step: accountDeletionStepFiles, Total: 1}
+	s.updateJob(ctx, job)
+	if err := s.removeFiles(ctx, userID); err != nil {
+		s.failJob(ctx, job, err)
+		return
+	}
+
+	job.Progress = &entity.JobProgress{Step: accountDeletionStepJobs, Total: 1}
+	s.updateJob(ctx, job)
+	// The job itself is kept, so that its outcome can still be looked up.
+	jobsRemoved, err := s.jobRepo.DeleteByUserID(ctx, userID, job.ID)
+	if err != nil {
+		s.failJob(ctx, job, err)
+		return
+	}
+
+	job.Progress = &entity.JobProgress{Step: accountDeletionStepUser, Total: 1}
+	s.updateJob(ctx, job)
+	if err := s.userRepo.Delete(ctx, userID); err != nil {
+		s.failJob(ctx, job, err)
+		return
+	}
+
+	result, err := json.Marshal(map[string]int64{
+		"containers_removed": int64(containersRemoved),
+		"jobs_removed":       jobsRemoved,
+	})
+	if err != nil {
+		s.failJob(ctx, job, err)
+		return
+	}
+	job.Status = entity.JobStatusCompleted
+	job.Progress.Done = job.Progress.Total
+	job.Result = result
+	s.updateJob(ctx, job)
+}
+
+// removeFiles removes the personal files of the user, and the incomplete uploads the user started
+// to the files of teams. Incomplete personal uploads are kept with the personal files.
+func (s *AccountService) removeFiles(ctx context.Context, userID int64) error {
+	uploads, err := s.uploadRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, upload := range uploads {
+		if upload.TeamID == 0 {
+			continue
+		}
+		if err := s.fileStorage.DeleteUpload(upload.Owner(), upload.ID); err != nil {
+			return err
+		}
+	}
+	return s.fileStorage.RemoveAll(entity.Owner{UserID: userID})
+}
+
+func (s *AccountService) failJob(ctx context.Context, job *entity.Job, err error) {
+	log.Printf("account deletion job %s of user %d failed at %s: %v", job.ID, job.UserID, job.Progress.Step, err)
+	job.Status = entity.JobStatusFailed
+	job.Error = err.Error()
+	s.updateJob(ctx, job)
+}
+
+// updateJob stores the job and reports whether that succeeded.
+func (s *AccountService) updateJob(ctx context.Context, job *entity.Job) bool {
+	job.UpdatedAt = time.Now()
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		log.Printf("failed to update job %s to %s: %v", job.ID, job.Status, err)
+		return false
+	}
+	return true
+}
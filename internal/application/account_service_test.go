@@ -0,0 +1,189 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	internalErrors "container-manager/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type accountServiceMocks struct {
+	userRepo          *mocks.MockUserRepository
+	refreshTokenRepo  *mocks.MockRefreshTokenRepository
+	teamRepo          *mocks.MockTeamRepository
+	jobRepo           *mocks.MockJobRepository
+	runtime           *mocks.MockContainerRuntime
+	containerUserRepo *mocks.MockContainerUserRepository
+	quotaRepo         *mocks.MockQuotaRepository
+	fileStorage       *mocks.MockFileStorage
+	uploadRepo        *mocks.MockUploadRepository
+}
+
+func newAccountServiceForTest(ctrl *gomock.Controller) (*AccountService, *accountServiceMocks) {
+	m := &accountServiceMocks{
+		userRepo:          mocks.NewMockUserRepository(ctrl),
+		refreshTokenRepo:  mocks.NewMockRefreshTokenRepository(ctrl),
+		teamRepo:          mocks.NewMockTeamRepository(ctrl),
+		jobRepo:           mocks.NewMockJobRepository(ctrl),
+		runtime:           mocks.NewMockContainerRuntime(ctrl),
+		containerUserRepo: mocks.NewMockContainerUserRepository(ctrl),
+		quotaRepo:         mocks.NewMockQuotaRepository(ctrl),
+		fileStorage:       mocks.NewMockFileStorage(ctrl),
+		uploadRepo:        mocks.NewMockUploadRepository(ctrl),
+	}
+	containerService := NewContainerService(m.runtime, m.containerUserRepo, m.jobRepo, NewQuotaService(m.quotaRepo, QuotaOptions{}), NewAuthorizer(m.teamRepo))
+	return NewAccountService(m.userRepo, m.refreshTokenRepo, m.teamRepo, m.jobRepo, containerService, m.fileStorage, m.uploadRepo), m
+}
+
+func TestAccountService_DeleteAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service, m := newAccountServiceForTest(ctrl)
+	ctx := context.Background()
+	userID := int64(1)
+	user := &entity.User{ID: userID, Username: "alice", Role: entity.RoleMember}
+
+	t.Run("enqueues the job", func(t *testing.T) {
+		m.userRepo.EXPECT().FindByID(ctx, userID).Return(user, nil)
+		m.teamRepo.EXPECT().ListByUserID(ctx, userID).Return(nil, nil)
+		m.refreshTokenRepo.EXPECT().RevokeByUserID(ctx, userID).Return(nil)
+		m.jobRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, job *entity.Job) error {
+			assert.Equal(t, entity.JobTypeAccountDeletion, job.Type)
+			assert.Equal(t, userID, job.UserID)
+			return nil
+		})
+		// The job fails right away, which also lets a deletion be retried.
+		m.jobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(errors.New("database error"))
+
+		jobID, err := service.DeleteAccount(ctx, member(userID))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, jobID)
+		assert.Eventually(t, func() bool {
+			_, running := service.deleting.Load(userID)
+			return !running
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("deletion in progress", func(t *testing.T) {
+		service.deleting.Store(userID, struct{}{})
+		defer service.deleting.Delete(userID)
+		m.userRepo.EXPECT().FindByID(ctx, userID).Return(user, nil)
+		m.teamRepo.EXPECT().ListByUserID(ctx, userID).Return(nil, nil)
+
+		_, err := service.DeleteAccount(ctx, member(userID))
+		assert.Equal(t, internalErrors.AccountDeletionInProgress, err)
+	})
+
+	t.Run("sole owner of a team", func(t *testing.T) {
+		m.userRepo.EXPECT().FindByID(ctx, userID).Return(user, nil)
+		m.teamRepo.EXPECT().ListByUserID(ctx, userID).Return([]*entity.TeamMembership{
+			{Team: &entity.Team{ID: 7, Name: "platform"}, Role: entity.TeamRoleOwner},
+		}, nil)
+		m.teamRepo.EXPECT().ListMembers(ctx, int64(7)).Return([]*entity.TeamMember{
+			{TeamID: 7, UserID: userID, Role: entity.TeamRoleOwner},
+			{TeamID: 7, UserID: 2, Role: entity.TeamRoleMember},
+		}, nil)
+
+		_, err := service.DeleteAccount(ctx, member(userID))
+		assert.Equal(t, internalErrors.LastTeamOwner, err)
+	})
+
+	t.Run("one of several owners", func(t *testing.T) {
+		m.userRepo.EXPECT().FindByID(ctx, userID).Return(user, nil)
+		m.teamRepo.EXPECT().ListByUserID(ctx, userID).Return([]*entity.TeamMembership{
+			{Team: &entity.Team{ID: 7, Name: "platform"}, Role: entity.TeamRoleOwner},
+		}, nil)
+		m.teamRepo.EXPECT().ListMembers(ctx, int64(7)).Return([]*entity.TeamMember{
+			{TeamID: 7, UserID: userID, Role: entity.TeamRoleOwner},
+			{TeamID: 7, UserID: 2, Role: entity.TeamRoleOwner},
+		}, nil)
+		m.refreshTokenRepo.EXPECT().RevokeByUserID(ctx, userID).Return(errors.New("database error"))
+
+		_, err := service.DeleteAccount(ctx, member(userID))
+		assert.EqualError(t, err, "database error")
+		_, running := service.deleting.Load(userID)
+		assert.False(t, running)
+	})
+}
+
+func TestAccountService_runDeleteAccountJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service, m := newAccountServiceForTest(ctrl)
+	userID := int64(1)
+	owner := entity.Owner{UserID: userID}
+	newJob := func() *entity.Job {
+		return &entity.Job{ID: "job-1", Type: entity.JobTypeAccountDeletion, Status: entity.JobStatusPending, UserID: userID, CreatedAt: time.Now()}
+	}
+	expectProgress := func(status entity.JobStatus, step string, done, total int) *gomock.Call {
+		return m.jobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, job *entity.Job) error {
+			assert.Equal(t, status, job.Status)
+			assert.Equal(t, &entity.JobProgress{Step: step, Done: done, Total: total}, job.Progress)
+			return nil
+		})
+	}
+
+	t.Run("removes everything of the user", func(t *testing.T) {
+		gomock.InOrder(
+			expectProgress(entity.JobStatusRunning, "containers", 0, 0),
+			m.containerUserRepo.EXPECT().GetByOwner(gomock.Any(), owner).Return([]*entity.ContainerUser{{ContainerID: "container-1", UserID: userID}}, nil),
+			m.runtime.EXPECT().Remove(gomock.Any(), "container-1").Return(nil),
+			m.containerUserRepo.EXPECT().SetRunning(gomock.Any(), "container-1", false).Return(false, nil),
+			m.containerUserRepo.EXPECT().Delete(gomock.Any(), "container-1").Return(nil),
+			m.quotaRepo.EXPECT().Release(gomock.Any(), userID, entity.QuotaResources{Containers: 1}).Return(nil),
+			expectProgress(entity.JobStatusRunning, "containers", 1, 1),
+			expectProgress(entity.JobStatusRunning, "files", 0, 1),
+			m.uploadRepo.EXPECT().ListByUserID(gomock.Any(), userID).Return([]*entity.Upload{
+				{ID: "upload-1", UserID: userID},
+				{ID: "upload-2", UserID: userID, TeamID: 7},
+			}, nil),
+			// The personal upload goes with the personal files.
+			m.fileStorage.EXPECT().DeleteUpload(entity.Owner{UserID: userID, TeamID: 7}, "upload-2").Return(nil),
+			m.fileStorage.EXPECT().RemoveAll(owner).Return(nil),
+			expectProgress(entity.JobStatusRunning, "jobs", 0, 1),
+			m.jobRepo.EXPECT().DeleteByUserID(gomock.Any(), userID, "job-1").Return(int64(3), nil),
+			expectProgress(entity.JobStatusRunning, "user", 0, 1),
+			m.userRepo.EXPECT().Delete(gomock.Any(), userID).Return(nil),
+			m.jobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, job *entity.Job) error {
+				assert.Equal(t, entity.JobStatusCompleted, job.Status)
+				var result map[string]int64
+				assert.NoError(t, json.Unmarshal(job.Result, &result))
+				assert.Equal(t, map[string]int64{"containers_removed": 1, "jobs_removed": 3}, result)
+				return nil
+			}),
+		)
+
+		service.runDeleteAccountJob(newJob(), userID)
+	})
+
+	t.Run("stops at the failing step", func(t *testing.T) {
+		service.deleting.Store(userID, struct{}{})
+		gomock.InOrder(
+			expectProgress(entity.JobStatusRunning, "containers", 0, 0),
+			m.containerUserRepo.EXPECT().GetByOwner(gomock.Any(), owner).Return(nil, nil),
+			expectProgress(entity.JobStatusRunning, "files", 0, 1),
+			m.uploadRepo.EXPECT().ListByUserID(gomock.Any(), userID).Return(nil, nil),
+			m.fileStorage.EXPECT().RemoveAll(owner).Return(errors.New("permission denied")),
+			m.jobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, job *entity.Job) error {
+				assert.Equal(t, entity.JobStatusFailed, job.Status)
+				assert.Equal(t, "permission denied", job.Error)
+				assert.Equal(t, "files", job.Progress.Step)
+				return nil
+			}),
+		)
+
+		service.runDeleteAccountJob(newJob(), userID)
+		_, running := service.deleting.Load(userID)
+		assert.False(t, running)
+	})
+}
@@ -0,0 +1,99 @@
+package application
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// lastUsedInterval limits how often the last use of a key is written, so that a busy
+// script does not cause a write on every request.
+const lastUsedInterval = time.Minute
+
+type APIKeyService struct {
+	apiKeyRepo infrastructure.APIKeyRepository
+}
+
+func NewAPIKeyService(apiKeyRepo infrastructure.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{apiKeyRepo: apiKeyRepo}
+}
+
+// CreateAPIKey creates a key with the given scopes. The key itself is only returned here,
+// as only its hash is stored.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, userID int64, name string, scopes []string, expiresAt *time.Time) (*entity.APIKey, string, error) {
+	if len(scopes) == 0 {
+		return nil, "", errors.InvalidScope.New("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !entity.ValidScope(scope) {
+			return nil, "", errors.InvalidScope.New("unknown scope " + scope)
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", errors.InvalidExpiry.New("expires_at must be in the future")
+	}
+
+	value, prefix, hash, err := entity.NewAPIKeyValue()
+	if err != nil {
+		return nil, "", err
+	}
+
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	key := &entity.APIKey{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    slices.Compact(scopes),
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	return key, value, nil
+}
+
+func (s *APIKeyService) ListAPIKeys(ctx context.Context, userID int64) ([]*entity.APIKey, error) {
+	return s.apiKeyRepo.ListByUserID(ctx, userID)
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, userID int64, id string) error {
+	revoked, err := s.apiKeyRepo.Revoke(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.APIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate returns the key matching the given value if it can still be used, and records its use.
+func (s *APIKeyService) Authenticate(ctx context.Context, value string) (*entity.APIKey, error) {
+	key, err := s.apiKeyRepo.GetByHash(ctx, entity.HashAPIKey(value))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if key == nil || !key.Usable(now) {
+		return nil, errors.InvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedInterval {
+		if err := s.apiKeyRepo.UpdateLastUsed(ctx, key.ID, now); err != nil {
+			log.Printf("failed to update last use of api key %s: %v", key.ID, err)
+		}
+	}
+
+	return key, nil
+}
@@ -0,0 +1,135 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	internalErrors "container-manager/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAPIKeyRepo := mocks.NewMockAPIKeyRepository(ctrl)
+	service := NewAPIKeyService(mockAPIKeyRepo)
+	ctx := context.Background()
+	userID := int64(1234)
+
+	t.Run("success", func(t *testing.T) {
+		var stored *entity.APIKey
+		mockAPIKeyRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, key *entity.APIKey) error {
+			stored = key
+			return nil
+		})
+
+		key, value, err := service.CreateAPIKey(ctx, userID, "ci", []string{entity.ScopeJobsRead, entity.ScopeContainersRead, entity.ScopeJobsRead}, nil)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(value, entity.APIKeyPrefix))
+		assert.Equal(t, entity.HashAPIKey(value), stored.KeyHash)
+		assert.True(t, strings.HasPrefix(value, key.Prefix))
+		assert.Equal(t, []string{entity.ScopeContainersRead, entity.ScopeJobsRead}, key.Scopes)
+		assert.Equal(t, userID, key.UserID)
+	})
+
+	t.Run("unknown scope", func(t *testing.T) {
+		_, _, err := service.CreateAPIKey(ctx, userID, "ci", []string{"admin"}, nil)
+		var customErr *internalErrors.CustomError
+		assert.ErrorAs(t, err, &customErr)
+		assert.Equal(t, internalErrors.InvalidScope.Message, customErr.Message)
+	})
+
+	t.Run("no scopes", func(t *testing.T) {
+		_, _, err := service.CreateAPIKey(ctx, userID, "ci", nil, nil)
+		var customErr *internalErrors.CustomError
+		assert.ErrorAs(t, err, &customErr)
+		assert.Equal(t, internalErrors.InvalidScope.Message, customErr.Message)
+	})
+
+	t.Run("expiry in the past", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour)
+		_, _, err := service.CreateAPIKey(ctx, userID, "ci", []string{entity.ScopeJobsRead}, &expiresAt)
+		var customErr *internalErrors.CustomError
+		assert.ErrorAs(t, err, &customErr)
+		assert.Equal(t, internalErrors.InvalidExpiry.Message, customErr.Message)
+	})
+}
+
+func TestAPIKeyService_RevokeAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAPIKeyRepo := mocks.NewMockAPIKeyRepository(ctrl)
+	service := NewAPIKeyService(mockAPIKeyRepo)
+	ctx := context.Background()
+
+	t.Run("revoked", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().Revoke(ctx, int64(1234), "key-id").Return(true, nil)
+		assert.NoError(t, service.RevokeAPIKey(ctx, 1234, "key-id"))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().Revoke(ctx, int64(1234), "key-id").Return(false, nil)
+		assert.Equal(t, internalErrors.APIKeyNotFound, service.RevokeAPIKey(ctx, 1234, "key-id"))
+	})
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAPIKeyRepo := mocks.NewMockAPIKeyRepository(ctrl)
+	service := NewAPIKeyService(mockAPIKeyRepo)
+	ctx := context.Background()
+	value := "cm_abcdefgh"
+	keyHash := entity.HashAPIKey(value)
+
+	t.Run("records first use", func(t *testing.T) {
+		key := &entity.APIKey{ID: "key-id", UserID: 1234}
+		mockAPIKeyRepo.EXPECT().GetByHash(ctx, keyHash).Return(key, nil)
+		mockAPIKeyRepo.EXPECT().UpdateLastUsed(ctx, "key-id", gomock.Any()).Return(nil)
+
+		authenticated, err := service.Authenticate(ctx, value)
+		assert.NoError(t, err)
+		assert.Equal(t, key, authenticated)
+	})
+
+	t.Run("recently used", func(t *testing.T) {
+		lastUsedAt := time.Now().Add(-time.Second)
+		mockAPIKeyRepo.EXPECT().GetByHash(ctx, keyHash).Return(&entity.APIKey{ID: "key-id", LastUsedAt: &lastUsedAt}, nil)
+
+		_, err := service.Authenticate(ctx, value)
+		assert.NoError(t, err)
+	})
+
+	t.Run("last use not recorded", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().GetByHash(ctx, keyHash).Return(&entity.APIKey{ID: "key-id"}, nil)
+		mockAPIKeyRepo.EXPECT().UpdateLastUsed(ctx, "key-id", gomock.Any()).Return(errors.New("database error"))
+
+		_, err := service.Authenticate(ctx, value)
+		assert.NoError(t, err)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Second)
+		mockAPIKeyRepo.EXPECT().GetByHash(ctx, keyHash).Return(&entity.APIKey{ID: "key-id", ExpiresAt: &expiresAt}, nil)
+
+		_, err := service.Authenticate(ctx, value)
+		assert.Equal(t, internalErrors.InvalidAPIKey, err)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().GetByHash(ctx, keyHash).Return(nil, nil)
+
+		_, err := service.Authenticate(ctx, value)
+		assert.Equal(t, internalErrors.InvalidAPIKey, err)
+	})
+}
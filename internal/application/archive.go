@@ -0,0 +1,193 @@
+package application
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"container-manager/internal/errors"
+	stderrors "errors"
+	"io"
+	"io/fs"
+	"math"
+	"sync"
+)
+
+// archiveFormat is the format of an archive to extract.
+type archiveFormat int
+
+const (
+	archiveFormatTar archiveFormat = iota + 1
+	archiveFormatTarGzip
+	archiveFormatZip
+)
+
+// tarMagicOffset is where the magic "ustar" of POSIX and GNU tar headers starts.
+const tarMagicOffset = 257
+
+// archiveEntry is a file, a folder or another kind of entry of an archive, like a symbolic link.
+type archiveEntry struct {
+	name    string
+	isDir   bool
+	regular bool
+	// link is set for symbolic and hard links.
+	link bool
+	// size is the size of the content of an entry other than a folder or a link, as the archive
+	// declares it.
+	size int64
+	// open opens the content of a regular file.
+	open func() (io.ReadCloser, error)
+}
+
+// archiveReader iterates the entries of an archive, and returns io.EOF after the last one. The
+// content of an entry can only be read until the next one is requested.
+type archiveReader interface {
+	next() (*archiveEntry, error)
+}
+
+// detectArchiveFormat tells the format of an archive from its content, and rewinds it. It fails
+// with errors.UnsupportedArchive for anything else, including gzip files that hold no tar archive.
+func detectArchiveFormat(file io.ReadSeeker) (archiveFormat, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return 0, err
+	}
+	head = head[:n]
+
+	var format archiveFormat
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		format = archiveFormatZip
+	case bytes.HasPrefix(head, []byte("\x1f\x8b")):
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+		content, err := gzip.NewReader(file)
+		if err != nil {
+			return 0, errors.UnsupportedArchive
+		}
+		n, _ := io.ReadFull(content, head[:cap(head)])
+		if !isTarHeader(head[:n]) {
+			return 0, errors.UnsupportedArchive
+		}
+		format = archiveFormatTarGzip
+	case isTarHeader(head):
+		format = archiveFormatTar
+	default:
+		return 0, errors.UnsupportedArchive
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return format, nil
+}
+
+// isTarHeader reports whether head starts with a POSIX or GNU tar header. Older tar formats,
+// without the magic, are not recognised.
+func isTarHeader(head []byte) bool {
+	return len(head) >= tarMagicOffset+5 && string(head[tarMagicOffset:tarMagicOffset+5]) == "ustar"
+}
+
+// openArchive reads the entries of an archive of the given format and size.
+func openArchive(format archiveFormat, file io.ReadSeeker, size int64) (archiveReader, error) {
+	switch format {
+	case archiveFormatZip:
+		readerAt, ok := file.(io.ReaderAt)
+		if !ok {
+			readerAt = &seekingReaderAt{file: file}
+		}
+		archive, err := zip.NewReader(readerAt, size)
+		if err != nil && !stderrors.Is(err, zip.ErrInsecurePath) {
+			return nil, errors.UnsupportedArchive.Wrap(err)
+		}
+		// Insecure names are rejected like any other name that leaves the target folder.
+		return &zipArchiveReader{files: archive.File}, nil
+	case archiveFormatTarGzip:
+		content, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, errors.UnsupportedArchive.Wrap(err)
+		}
+		return &tarArchiveReader{archive: tar.NewReader(content)}, nil
+	default:
+		return &tarArchiveReader{archive: tar.NewReader(file)}, nil
+	}
+}
+
+type tarArchiveReader struct {
+	archive *tar.Reader
+}
+
+func (r *tarArchiveReader) next() (*archiveEntry, error) {
+	header, err := r.archive.Next()
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil && !stderrors.Is(err, tar.ErrInsecurePath) {
+		return nil, errors.InvalidArchiveEntry.Wrap(err)
+	}
+	entry := &archiveEntry{
+		name:    header.Name,
+		isDir:   header.Typeflag == tar.TypeDir,
+		regular: header.Typeflag == tar.TypeReg,
+		link:    header.Typeflag == tar.TypeSymlink || header.Typeflag == tar.TypeLink,
+	}
+	// The content of the entries that are skipped is still read past.
+	if !entry.isDir && !entry.link {
+		entry.size = header.Size
+	}
+	if entry.regular {
+		entry.open = func() (io.ReadCloser, error) {
+			return io.NopCloser(r.archive), nil
+		}
+	}
+	return entry, nil
+}
+
+type zipArchiveReader struct {
+	files []*zip.File
+}
+
+func (r *zipArchiveReader) next() (*archiveEntry, error) {
+	if len(r.files) == 0 {
+		return nil, io.EOF
+	}
+	file := r.files[0]
+	r.files = r.files[1:]
+
+	mode := file.Mode()
+	entry := &archiveEntry{
+		name:    file.Name,
+		isDir:   mode.IsDir(),
+		regular: mode.IsRegular(),
+		link:    mode&fs.ModeSymlink != 0,
+	}
+	if !entry.isDir && !entry.link {
+		// The declared size is checked against what is read by zip.File.Open.
+		entry.size = int64(min(file.UncompressedSize64, math.MaxInt64))
+	}
+	if entry.regular {
+		entry.open = file.Open
+	}
+	return entry, nil
+}
+
+// seekingReaderAt reads at offsets of a file that can only seek, for storages that offer no more.
+type seekingReaderAt struct {
+	mu   sync.Mutex
+	file io.ReadSeeker
+}
+
+func (r *seekingReaderAt) ReadAt(p []byte, offset int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.file.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(r.file, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
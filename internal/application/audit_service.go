@@ -0,0 +1,73 @@
+package application
+
+import (
+	"container-manager/internal/errors"
+	"context"
+	"log"
+
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+)
+
+const (
+	DefaultAuditListLimit = 50
+	MaxAuditListLimit     = 500
+)
+
+// auditExportPageSize is the number of entries ExportAudit reads from the database at a time.
+const auditExportPageSize = 500
+
+// AuditService writes the audit log and lets admins read it.
+type AuditService struct {
+	auditRepo infrastructure.AuditRepository
+}
+
+func NewAuditService(auditRepo infrastructure.AuditRepository) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+// Record appends an entry to the audit log. The action it records already happened, so a failure
+// to store the entry is logged instead of returned.
+func (s *AuditService) Record(ctx context.Context, entry *entity.AuditEntry) {
+	if err := s.auditRepo.Append(ctx, entry); err != nil {
+		log.Printf("failed to record audit entry %s %s:%s of user %d (request %s): %v", entry.Action, entry.TargetType, entry.TargetID, entry.ActorID, entry.RequestID, err)
+	}
+}
+
+// ListAudit returns up to limit entries matching the filter, newest first, for admins. Limit
+// defaults to DefaultAuditListLimit when zero.
+func (s *AuditService) ListAudit(ctx context.Context, caller Caller, filter infrastructure.AuditFilter, limit int) ([]*entity.AuditEntry, error) {
+	if caller.Role != entity.RoleAdmin {
+		return nil, errors.PermissionDenied
+	}
+	if limit == 0 {
+		limit = DefaultAuditListLimit
+	}
+	if limit < 1 || limit > MaxAuditListLimit {
+		return nil, errors.InvalidAuditFilter.New("limit must be between 1 and 500")
+	}
+	return s.auditRepo.List(ctx, filter, limit)
+}
+
+// ExportAudit passes every entry matching the filter to write, newest first, for admins. It stops
+// at the first error of write.
+func (s *AuditService) ExportAudit(ctx context.Context, caller Caller, filter infrastructure.AuditFilter, write func(*entity.AuditEntry) error) error {
+	if caller.Role != entity.RoleAdmin {
+		return errors.PermissionDenied
+	}
+	for {
+		entries, err := s.auditRepo.List(ctx, filter, auditExportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := write(entry); err != nil {
+				return err
+			}
+		}
+		if len(entries) < auditExportPageSize {
+			return nil
+		}
+		filter.BeforeID = entries[len(entries)-1].ID
+	}
+}
@@ -0,0 +1,110 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	internalErrors "container-manager/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuditService_Record(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuditRepo := mocks.NewMockAuditRepository(ctrl)
+	service := NewAuditService(mockAuditRepo)
+	entry := &entity.AuditEntry{Action: entity.AuditActionContainerStart, Outcome: entity.AuditOutcomeSuccess}
+
+	mockAuditRepo.EXPECT().Append(gomock.Any(), entry).Return(nil)
+	service.Record(context.Background(), entry)
+
+	// A failure to store the entry does not reach the caller.
+	mockAuditRepo.EXPECT().Append(gomock.Any(), entry).Return(errors.New("database error"))
+	service.Record(context.Background(), entry)
+}
+
+func TestAuditService_ListAudit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuditRepo := mocks.NewMockAuditRepository(ctrl)
+	service := NewAuditService(mockAuditRepo)
+	ctx := context.Background()
+	admin := Caller{UserID: 1, Role: entity.RoleAdmin}
+	filter := infrastructure.AuditFilter{Action: entity.AuditActionUserLogin}
+
+	t.Run("default limit", func(t *testing.T) {
+		entries := []*entity.AuditEntry{{ID: 1}}
+		mockAuditRepo.EXPECT().List(ctx, filter, DefaultAuditListLimit).Return(entries, nil)
+
+		got, err := service.ListAudit(ctx, admin, filter, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, entries, got)
+	})
+
+	t.Run("limit too large", func(t *testing.T) {
+		_, err := service.ListAudit(ctx, admin, filter, MaxAuditListLimit+1)
+		var customErr *internalErrors.CustomError
+		assert.ErrorAs(t, err, &customErr)
+		assert.Equal(t, internalErrors.InvalidAuditFilter.Message, customErr.Message)
+	})
+
+	t.Run("not an admin", func(t *testing.T) {
+		_, err := service.ListAudit(ctx, member(1), filter, 0)
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+}
+
+func TestAuditService_ExportAudit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuditRepo := mocks.NewMockAuditRepository(ctrl)
+	service := NewAuditService(mockAuditRepo)
+	ctx := context.Background()
+	admin := Caller{UserID: 1, Role: entity.RoleAdmin}
+
+	t.Run("pages through the log", func(t *testing.T) {
+		firstPage := make([]*entity.AuditEntry, auditExportPageSize)
+		for i := range firstPage {
+			firstPage[i] = &entity.AuditEntry{ID: int64(1000 - i)}
+		}
+		gomock.InOrder(
+			mockAuditRepo.EXPECT().List(ctx, infrastructure.AuditFilter{ActorID: 2}, auditExportPageSize).Return(firstPage, nil),
+			mockAuditRepo.EXPECT().List(ctx, infrastructure.AuditFilter{ActorID: 2, BeforeID: 501}, auditExportPageSize).Return([]*entity.AuditEntry{{ID: 500}}, nil),
+		)
+
+		var ids []int64
+		err := service.ExportAudit(ctx, admin, infrastructure.AuditFilter{ActorID: 2}, func(entry *entity.AuditEntry) error {
+			ids = append(ids, entry.ID)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Len(t, ids, auditExportPageSize+1)
+		assert.Equal(t, int64(500), ids[len(ids)-1])
+	})
+
+	t.Run("write fails", func(t *testing.T) {
+		mockAuditRepo.EXPECT().List(ctx, infrastructure.AuditFilter{}, auditExportPageSize).Return([]*entity.AuditEntry{{ID: 2}, {ID: 1}}, nil)
+
+		calls := 0
+		err := service.ExportAudit(ctx, admin, infrastructure.AuditFilter{}, func(*entity.AuditEntry) error {
+			calls++
+			return errors.New("broken pipe")
+		})
+		assert.EqualError(t, err, "broken pipe")
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("not an admin", func(t *testing.T) {
+		err := service.ExportAudit(ctx, member(1), infrastructure.AuditFilter{}, nil)
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+}
@@ -0,0 +1,50 @@
+package application
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
+	"context"
+)
+
+// Caller is the authenticated user a service method acts for. Role is the role stored in the
+// database, which the AuthMiddleware compares with the role claim of the token.
+type Caller struct {
+	UserID int64
+	Role   entity.Role
+}
+
+// Authorizer is the permission check shared by the services. The role of the caller decides
+// which actions are possible at all. Users then act on their personal resources, members of a
+// team on the resources of the team as far as their team role allows, and admins on everything.
+type Authorizer struct {
+	teamRepo infrastructure.TeamRepository
+}
+
+func NewAuthorizer(teamRepo infrastructure.TeamRepository) *Authorizer {
+	return &Authorizer{teamRepo: teamRepo}
+}
+
+func (a *Authorizer) authorize(ctx context.Context, caller Caller, action entity.Action, owner entity.Owner) error {
+	if !caller.Role.Allows(action) {
+		return errors.PermissionDenied
+	}
+	if caller.Role == entity.RoleAdmin {
+		return nil
+	}
+	if owner.TeamID == 0 {
+		if owner.UserID != caller.UserID {
+			return errors.PermissionDenied
+		}
+		return nil
+	}
+
+	member, err := a.teamRepo.GetMember(ctx, owner.TeamID, caller.UserID)
+	if err != nil {
+		return err
+	}
+	if member == nil || !member.Role.Allows(action) {
+		return errors.PermissionDenied
+	}
+	return nil
+}
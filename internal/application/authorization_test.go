@@ -0,0 +1,69 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	internalErrors "container-manager/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuthorizer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+	authorizer := NewAuthorizer(mockTeamRepo)
+	ctx := context.Background()
+
+	personal := func(userID int64) entity.Owner { return entity.Owner{UserID: userID} }
+	team := entity.Owner{UserID: 2, TeamID: 10}
+
+	tests := []struct {
+		name string
+		// lookup is set when the membership of the caller in team 10 is looked up, teamRole is then
+		// the team role of the caller, or empty if the caller is not a member.
+		lookup   bool
+		teamRole entity.TeamRole
+		caller   Caller
+		action   entity.Action
+		owner    entity.Owner
+		allowed  bool
+	}{
+		{name: "member writes own", caller: Caller{UserID: 1, Role: entity.RoleMember}, action: entity.ActionWrite, owner: personal(1), allowed: true},
+		{name: "member reads other", caller: Caller{UserID: 1, Role: entity.RoleMember}, action: entity.ActionRead, owner: personal(2)},
+		{name: "read-only reads own", caller: Caller{UserID: 1, Role: entity.RoleReadOnly}, action: entity.ActionRead, owner: personal(1), allowed: true},
+		{name: "read-only writes own", caller: Caller{UserID: 1, Role: entity.RoleReadOnly}, action: entity.ActionWrite, owner: personal(1)},
+		{name: "admin reads other", caller: Caller{UserID: 1, Role: entity.RoleAdmin}, action: entity.ActionRead, owner: personal(2), allowed: true},
+		{name: "admin writes team", caller: Caller{UserID: 1, Role: entity.RoleAdmin}, action: entity.ActionWrite, owner: team, allowed: true},
+		{name: "no role", caller: Caller{UserID: 1}, action: entity.ActionRead, owner: personal(1)},
+		{name: "team member writes", lookup: true, teamRole: entity.TeamRoleMember, caller: Caller{UserID: 1, Role: entity.RoleMember}, action: entity.ActionWrite, owner: team, allowed: true},
+		{name: "team viewer reads", lookup: true, teamRole: entity.TeamRoleViewer, caller: Caller{UserID: 1, Role: entity.RoleMember}, action: entity.ActionRead, owner: team, allowed: true},
+		{name: "team viewer writes", lookup: true, teamRole: entity.TeamRoleViewer, caller: Caller{UserID: 1, Role: entity.RoleMember}, action: entity.ActionWrite, owner: team},
+		{name: "read-only team owner writes", caller: Caller{UserID: 1, Role: entity.RoleReadOnly}, action: entity.ActionWrite, owner: team},
+		{name: "not a team member", lookup: true, caller: Caller{UserID: 1, Role: entity.RoleMember}, action: entity.ActionRead, owner: team},
+		{name: "creator left the team", lookup: true, caller: Caller{UserID: 2, Role: entity.RoleMember}, action: entity.ActionRead, owner: team},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.lookup {
+				var member *entity.TeamMember
+				if tt.teamRole != "" {
+					member = &entity.TeamMember{TeamID: 10, UserID: tt.caller.UserID, Role: tt.teamRole}
+				}
+				mockTeamRepo.EXPECT().GetMember(ctx, int64(10), tt.caller.UserID).Return(member, nil)
+			}
+
+			err := authorizer.authorize(ctx, tt.caller, tt.action, tt.owner)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, internalErrors.PermissionDenied, err)
+			}
+		})
+	}
+}
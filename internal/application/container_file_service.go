@@ -0,0 +1,89 @@
+package application
+
+import (
+	"archive/tar"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/errors"
+	"context"
+	"io"
+	"path"
+)
+
+// ContainerFileService copies files between the file storage and containers.
+type ContainerFileService struct {
+	containerService *ContainerService
+	fileService      *FileService
+}
+
+// NewContainerFileService creates a new instance of ContainerFileService.
+func NewContainerFileService(containerService *ContainerService, fileService *FileService) *ContainerFileService {
+	return &ContainerFileService{
+		containerService: containerService,
+		fileService:      fileService,
+	}
+}
+
+// CopyFileToContainer copies a file of the caller, or of a team of the caller when teamID is not
+// zero, into a folder of a container. The file keeps its name, and replaces a file of that name in
+// the folder.
+func (s *ContainerFileService) CopyFileToContainer(ctx context.Context, caller Caller, idOrName string, teamID int64, filename string, dir string) error {
+	if err := entity.ValidateContainerPath(dir); err != nil {
+		return err
+	}
+
+	file, info, err := s.fileService.OpenFile(ctx, caller, teamID, filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// The archive is written while the runtime reads it, so the file is never held in memory.
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeTarFile(writer, path.Base(info.Name), info, file))
+	}()
+	err = s.containerService.CopyToContainer(ctx, caller, idOrName, dir, reader)
+	// Stops the writer if the runtime gave up before reading all of the archive.
+	reader.Close()
+	return err
+}
+
+// SaveFileFromContainer saves a file of a container as a file of the caller, or of a team of the
+// caller when teamID is not zero, like an upload: the storage quota and the upload policy apply.
+func (s *ContainerFileService) SaveFileFromContainer(ctx context.Context, caller Caller, idOrName string, containerPath string, teamID int64, filename string) (*entity.FileRecord, error) {
+	archive, err := s.containerService.CopyFromContainer(ctx, caller, idOrName, containerPath)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	// The archive of a file holds a single entry, the archive of a folder starts with the folder.
+	content := tar.NewReader(archive)
+	header, err := content.Next()
+	if err != nil {
+		return nil, err
+	}
+	if header.Typeflag != tar.TypeReg {
+		return nil, errors.NotRegularFile
+	}
+	return s.fileService.UploadFile(ctx, caller, teamID, filename, header.Size, content)
+}
+
+// writeTarFile writes a tar archive holding a single file.
+func writeTarFile(w io.Writer, name string, info *entity.FileInfo, content io.Reader) error {
+	archive := tar.NewWriter(w)
+	err := archive.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0o644,
+		Size:     info.Size,
+		ModTime:  info.ModTime,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(archive, content); err != nil {
+		return err
+	}
+	return archive.Close()
+}
@@ -0,0 +1,164 @@
+package application
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	internalErrors "container-manager/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// tarArchive returns a tar archive holding the given headers, with content for regular files.
+func tarArchive(t *testing.T, headers []*tar.Header, content string) []byte {
+	var buf bytes.Buffer
+	archive := tar.NewWriter(&buf)
+	for _, header := range headers {
+		require.NoError(t, archive.WriteHeader(header))
+		if header.Typeflag == tar.TypeReg {
+			_, err := archive.Write([]byte(content))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func TestContainerFileService_CopyFileToContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockFileStorage := mocks.NewMockFileStorage(ctrl)
+	authorizer := NewAuthorizer(nil)
+	service := NewContainerFileService(
+		NewContainerService(mockRuntime, mockContainerUserRepo, nil, nil, authorizer),
+		NewFileService(mockFileStorage, nil, nil, authorizer, entity.UploadPolicy{}),
+	)
+
+	ctx := context.Background()
+	userID := int64(1000)
+	owner := entity.Owner{UserID: userID}
+	containerID := "container-123"
+	modTime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		info := &entity.FileInfo{Name: "input/data.csv", Size: 5, ModTime: modTime}
+		mockFileStorage.EXPECT().OpenFile(owner, "input/data.csv").Return(nopReadSeekCloser{bytes.NewReader([]byte("hello"))}, info, nil)
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: userID}, nil)
+		mockRuntime.EXPECT().CopyTo(ctx, containerID, "/app", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ string, archive io.Reader) error {
+			// The archive holds the file under its name, without its folders.
+			content := tar.NewReader(archive)
+			header, err := content.Next()
+			require.NoError(t, err)
+			assert.Equal(t, "data.csv", header.Name)
+			assert.Equal(t, int64(5), header.Size)
+			assert.True(t, modTime.Equal(header.ModTime))
+			data, err := io.ReadAll(content)
+			require.NoError(t, err)
+			assert.Equal(t, "hello", string(data))
+			_, err = content.Next()
+			assert.Equal(t, io.EOF, err)
+			return nil
+		})
+
+		err := service.CopyFileToContainer(ctx, member(userID), containerID, 0, "input/data.csv", "/app")
+		assert.NoError(t, err)
+	})
+
+	t.Run("runtime fails before reading the archive", func(t *testing.T) {
+		info := &entity.FileInfo{Name: "data.csv", Size: 5}
+		mockFileStorage.EXPECT().OpenFile(owner, "data.csv").Return(nopReadSeekCloser{bytes.NewReader([]byte("hello"))}, info, nil)
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: userID}, nil)
+		mockRuntime.EXPECT().CopyTo(ctx, containerID, "/missing", gomock.Any()).Return(internalErrors.ContainerPathNotFound)
+
+		err := service.CopyFileToContainer(ctx, member(userID), containerID, 0, "data.csv", "/missing")
+		assert.Equal(t, internalErrors.ContainerPathNotFound, err)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		mockFileStorage.EXPECT().OpenFile(owner, "missing.csv").Return(nil, nil, nil)
+
+		err := service.CopyFileToContainer(ctx, member(userID), containerID, 0, "missing.csv", "/app")
+		assert.Equal(t, internalErrors.FileNotFound, err)
+	})
+
+	t.Run("relative path", func(t *testing.T) {
+		err := service.CopyFileToContainer(ctx, member(userID), containerID, 0, "data.csv", "app")
+		assert.Equal(t, internalErrors.InvalidContainerPath, err)
+	})
+}
+
+func TestContainerFileService_SaveFileFromContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockFileStorage := mocks.NewMockFileStorage(ctrl)
+	mockFileRepo := mocks.NewMockFileRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	authorizer := NewAuthorizer(nil)
+	limits := entity.QuotaResources{StorageBytes: 100}
+	service := NewContainerFileService(
+		NewContainerService(mockRuntime, mockContainerUserRepo, nil, nil, authorizer),
+		NewFileService(mockFileStorage, mockFileRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{DefaultLimits: limits}), authorizer, entity.UploadPolicy{}),
+	)
+
+	ctx := context.Background()
+	userID := int64(1000)
+	owner := entity.Owner{UserID: userID}
+	containerID := "container-123"
+
+	t.Run("success", func(t *testing.T) {
+		archive := tarArchive(t, []*tar.Header{{Typeflag: tar.TypeReg, Name: "out.csv", Size: 5, Mode: 0o644}}, "hello")
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: userID}, nil)
+		mockRuntime.EXPECT().CopyFrom(ctx, containerID, "/app/out.csv").Return(io.NopCloser(bytes.NewReader(archive)), nil)
+		// The size of the file in the archive is reserved, as for an upload of known length.
+		mockFileStorage.EXPECT().FileSize(owner, "results/out.csv").Return(int64(0), nil)
+		mockQuotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil)
+		mockQuotaRepo.EXPECT().Reserve(ctx, userID, entity.QuotaResources{StorageBytes: 5}, limits).Return(nil)
+		mockFileStorage.EXPECT().SaveFile(owner, "results/out.csv", gomock.Any()).DoAndReturn(func(_ entity.Owner, name string, content io.Reader) (*entity.FileInfo, error) {
+			data, err := io.ReadAll(content)
+			require.NoError(t, err)
+			assert.Equal(t, "hello", string(data))
+			return &entity.FileInfo{Name: name, Size: int64(len(data))}, nil
+		})
+		mockFileRepo.EXPECT().Save(ctx, gomock.Any()).Return(nil)
+
+		record, err := service.SaveFileFromContainer(ctx, member(userID), containerID, "/app/out.csv", 0, "results/out.csv")
+		require.NoError(t, err)
+		assert.Equal(t, "results/out.csv", record.Path)
+		assert.Equal(t, int64(5), record.Size)
+	})
+
+	t.Run("folder", func(t *testing.T) {
+		archive := tarArchive(t, []*tar.Header{
+			{Typeflag: tar.TypeDir, Name: "app/", Mode: 0o755},
+			{Typeflag: tar.TypeReg, Name: "app/out.csv", Size: 5, Mode: 0o644},
+		}, "hello")
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: userID}, nil)
+		mockRuntime.EXPECT().CopyFrom(ctx, containerID, "/app").Return(io.NopCloser(bytes.NewReader(archive)), nil)
+
+		_, err := service.SaveFileFromContainer(ctx, member(userID), containerID, "/app", 0, "app")
+		assert.Equal(t, internalErrors.NotRegularFile, err)
+	})
+
+	t.Run("symbolic link", func(t *testing.T) {
+		archive := tarArchive(t, []*tar.Header{{Typeflag: tar.TypeSymlink, Name: "passwd", Linkname: "/etc/passwd"}}, "")
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: userID}, nil)
+		mockRuntime.EXPECT().CopyFrom(ctx, containerID, "/app/passwd").Return(io.NopCloser(bytes.NewReader(archive)), nil)
+
+		_, err := service.SaveFileFromContainer(ctx, member(userID), containerID, "/app/passwd", 0, "passwd")
+		assert.Equal(t, internalErrors.NotRegularFile, err)
+	})
+}
@@ -0,0 +1,325 @@
+package application
+
+import (
+	"container-manager/internal/errors"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"sort"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+const (
+	DefaultContainerListLimit = 50
+	MaxContainerListLimit     = 200
+)
+
+// ContainerListQuery describes which page of the containers of a user or team to list.
+type ContainerListQuery struct {
+	Filter infrastructure.ContainerFilter
+	// Sort is one of infrastructure.ContainerSortCreatedAt, ContainerSortName or ContainerSortStatus.
+	// Containers are sorted by creation time when empty.
+	Sort       string
+	Descending bool
+	// Limit is the page size. DefaultContainerListLimit is used when zero.
+	Limit int
+	// Cursor is the NextCursor of the previous page, or empty for the first page.
+	Cursor string
+}
+
+// ContainerList is one page of a container listing.
+// Containers that could not be inspected are reported in Failed instead of being dropped.
+type ContainerList struct {
+	Containers []*entity.Container
+	Failed     []*ContainerFailure
+	// Total is the number of containers matching the filter, across all pages.
+	Total int
+	// NextCursor points to the next page, and is empty on the last page.
+	NextCursor string
+}
+
+// ContainerFailure describes a container owned by the user that could not be inspected.
+type ContainerFailure struct {
+	ID   string
+	Name string
+	Err  error
+}
+
+// containerCursor is the position of the last container of a page. It is handed to
+// clients as an opaque base64 string.
+type containerCursor struct {
+	Sort       string    `json:"s"`
+	Descending bool      `json:"d,omitempty"`
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"c,omitempty"`
+	Name       string    `json:"n,omitempty"`
+	Status     string    `json:"st,omitempty"`
+}
+
+// ListContainers lists the personal containers of the caller.
+func (s *ContainerService) ListContainers(ctx context.Context, caller Caller, query ContainerListQuery) (*ContainerList, error) {
+	return s.ListUserContainers(ctx, caller, caller.UserID, query)
+}
+
+// ListUserContainers lists the personal containers of the given user, which only admins can do for other users.
+func (s *ContainerService) ListUserContainers(ctx context.Context, caller Caller, userID int64, query ContainerListQuery) (*ContainerList, error) {
+	return s.listContainers(ctx, caller, entity.Owner{UserID: userID}, query)
+}
+
+// ListTeamContainers lists the containers of a team, for its members.
+func (s *ContainerService) ListTeamContainers(ctx context.Context, caller Caller, teamID int64, query ContainerListQuery) (*ContainerList, error) {
+	return s.listContainers(ctx, caller, entity.Owner{TeamID: teamID}, query)
+}
+
+func (s *ContainerService) listContainers(ctx context.Context, caller Caller, owner entity.Owner, query ContainerListQuery) (*ContainerList, error) {
+	if err := s.authorizer.authorize(ctx, caller, entity.ActionRead, owner); err != nil {
+		return nil, err
+	}
+	if query.Sort == "" {
+		query.Sort = infrastructure.ContainerSortCreatedAt
+	}
+	if query.Limit == 0 {
+		query.Limit = DefaultContainerListLimit
+	}
+	if err := validateContainerListQuery(query); err != nil {
+		return nil, err
+	}
+	cursor, err := decodeContainerCursor(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.Sort == infrastructure.ContainerSortStatus {
+		return s.listContainersByStatus(ctx, owner, query, cursor)
+	}
+
+	// ids restricts the listing to the containers matching the filter, nil meaning no restriction.
+	var ids []string
+	filter := query.Filter
+	if len(filter.Labels) > 0 || filter.Status != "" || filter.Image != "" {
+		ownedIDs, err := s.containerUserRepo.GetContainerIDsByOwner(ctx, owner)
+		if err != nil {
+			return nil, err
+		}
+		ids = []string{}
+		if len(ownedIDs) > 0 {
+			// Let the runtime do the filtering in one call, restricted to the containers of the owner.
+			filter.IDs = ownedIDs
+			ids, err = s.runtime.ListIDs(ctx, filter)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(ids) == 0 {
+			return &ContainerList{Containers: []*entity.Container{}}, nil
+		}
+	}
+
+	total, err := s.containerUserRepo.CountByOwner(ctx, owner, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	page := infrastructure.ContainerUserPage{
+		IDs:        ids,
+		Sort:       query.Sort,
+		Descending: query.Descending,
+		// Fetch one extra row to know whether there is a next page.
+		Limit: query.Limit + 1,
+	}
+	if cursor != nil {
+		page.After = &entity.ContainerUser{ContainerID: cursor.ID, CreatedAt: cursor.CreatedAt, Name: cursor.Name}
+	}
+	containerUsers, err := s.containerUserRepo.GetPageByOwner(ctx, owner, page)
+	if err != nil {
+		return nil, err
+	}
+
+	var next *containerCursor
+	if len(containerUsers) > query.Limit {
+		containerUsers = containerUsers[:query.Limit]
+		last := containerUsers[len(containerUsers)-1]
+		next = &containerCursor{ID: last.ContainerID, CreatedAt: last.CreatedAt, Name: last.Name}
+	}
+
+	list := s.inspectContainers(ctx, containerUsers)
+	list.Total = total
+	list.NextCursor = encodeContainerCursor(query, next)
+	return list, nil
+}
+
+// listContainersByStatus sorts by the runtime status. The statuses of all of the owner's
+// containers that match the filter come from a single runtime call, and only the containers of
+// the page are inspected. Statuses are read again for every page, so the order is only stable
+// while they do not change: a container that starts or stops while a client pages through may be
+// skipped or listed twice.
+func (s *ContainerService) listContainersByStatus(ctx context.Context, owner entity.Owner, query ContainerListQuery, cursor *containerCursor) (*ContainerList, error) {
+	containerUsers, err := s.containerUserRepo.GetByOwner(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if len(containerUsers) == 0 {
+		return &ContainerList{Containers: []*entity.Container{}}, nil
+	}
+
+	filter := query.Filter
+	filter.IDs = make([]string, 0, len(containerUsers))
+	for _, containerUser := range containerUsers {
+		filter.IDs = append(filter.IDs, containerUser.ContainerID)
+	}
+	statuses, err := s.runtime.ListStatuses(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	filtered := len(query.Filter.Labels) > 0 || query.Filter.Status != "" || query.Filter.Image != ""
+
+	// Containers the runtime does not list have no status and sort before all others, unless a
+	// filter leaves them out. Inspecting them then reports why.
+	type item struct {
+		status        string
+		containerUser *entity.ContainerUser
+	}
+	items := make([]item, 0, len(containerUsers))
+	for _, containerUser := range containerUsers {
+		status, ok := statuses[containerUser.ContainerID]
+		if !ok && filtered {
+			continue
+		}
+		items = append(items, item{status: string(status), containerUser: containerUser})
+	}
+	less := func(a, b item) bool {
+		if a.status != b.status {
+			return a.status < b.status
+		}
+		return a.containerUser.ContainerID < b.containerUser.ContainerID
+	}
+	if query.Descending {
+		less = func(a, b item) bool {
+			if a.status != b.status {
+				return a.status > b.status
+			}
+			return a.containerUser.ContainerID > b.containerUser.ContainerID
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return less(items[i], items[j]) })
+
+	start := 0
+	if cursor != nil {
+		after := item{status: cursor.Status, containerUser: &entity.ContainerUser{ContainerID: cursor.ID}}
+		start = sort.Search(len(items), func(i int) bool { return less(after, items[i]) })
+	}
+	end := min(start+query.Limit, len(items))
+
+	page := make([]*entity.ContainerUser, 0, end-start)
+	for _, it := range items[start:end] {
+		page = append(page, it.containerUser)
+	}
+	list := s.inspectContainers(ctx, page)
+	list.Total = len(items)
+	if end < len(items) {
+		last := items[end-1]
+		list.NextCursor = encodeContainerCursor(query, &containerCursor{ID: last.containerUser.ContainerID, Status: last.status})
+	}
+	return list, nil
+}
+
+// inspectContainers inspects the given containers with bounded concurrency, keeping their order.
+// Containers recorded as running that exited by themselves give back their running quota.
+func (s *ContainerService) inspectContainers(ctx context.Context, containerUsers []*entity.ContainerUser) *ContainerList {
+	containers := make([]*entity.Container, len(containerUsers))
+	failures := make([]error, len(containerUsers))
+
+	var group errgroup.Group
+	group.SetLimit(inspectConcurrency)
+	for i, containerUser := range containerUsers {
+		id := containerUser.ContainerID
+		if cached, ok := s.inspectCache.get(id); ok {
+			containers[i] = cached
+			continue
+		}
+		group.Go(func() error {
+			container, err := s.runtime.Inspect(ctx, id)
+			if err != nil {
+				log.Printf("failed to inspect container %s: %v", id, err)
+				failures[i] = err
+				return nil
+			}
+			s.inspectCache.set(id, container)
+			containers[i] = container
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	list := &ContainerList{Containers: make([]*entity.Container, 0, len(containerUsers))}
+	for i, containerUser := range containerUsers {
+		if failures[i] != nil {
+			list.Failed = append(list.Failed, &ContainerFailure{ID: containerUser.ContainerID, Name: containerUser.Name, Err: failures[i]})
+			continue
+		}
+		if containerUser.Running && !countsAsRunning(containers[i].Status) {
+			// The container exited by itself, so it no longer holds a running slot.
+			s.reconcileStopped(ctx, containerUser)
+		}
+		containers[i].Name = containerUser.Name
+		list.Containers = append(list.Containers, containers[i])
+	}
+	return list
+}
+
+func validateContainerListQuery(query ContainerListQuery) error {
+	switch query.Sort {
+	case infrastructure.ContainerSortCreatedAt, infrastructure.ContainerSortName, infrastructure.ContainerSortStatus:
+	default:
+		return errors.InvalidContainerFilter.New("sort must be one of created_at, name or status")
+	}
+	if query.Limit < 1 || query.Limit > MaxContainerListLimit {
+		return errors.InvalidContainerFilter.New("limit must be between 1 and 200")
+	}
+	if query.Filter.Status != "" {
+		if err := container.ValidateContainerState(container.ContainerState(query.Filter.Status)); err != nil {
+			return errors.InvalidContainerFilter.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func encodeContainerCursor(query ContainerListQuery, cursor *containerCursor) string {
+	if cursor == nil {
+		return ""
+	}
+	cursor.Sort = query.Sort
+	cursor.Descending = query.Descending
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeContainerCursor decodes the cursor of the query, and checks that it was issued for the same sort order.
+func decodeContainerCursor(query ContainerListQuery) (*containerCursor, error) {
+	if query.Cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err != nil {
+		return nil, errors.InvalidCursor.Wrap(err)
+	}
+	cursor := &containerCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return nil, errors.InvalidCursor.Wrap(err)
+	}
+	if cursor.ID == "" || cursor.Sort != query.Sort || cursor.Descending != query.Descending {
+		return nil, errors.InvalidCursor
+	}
+	return cursor, nil
+}
@@ -4,7 +4,9 @@ import (
 	"container-manager/internal/errors"
 	"context"
 	"encoding/json"
+	"io"
 	"log"
+	"strconv"
 	"sync"
 	"time"
 
@@ -14,42 +16,96 @@ import (
 	"container-manager/internal/domain/infrastructure"
 
 	"github.com/google/uuid"
+	"github.com/moby/moby/api/types/container"
+)
+
+const (
+	// inspectConcurrency bounds the number of concurrent runtime inspections when listing containers.
+	inspectConcurrency = 16
+	// inspectCacheTTL is how long an inspection result is reused by container listings.
+	inspectCacheTTL = 2 * time.Second
+
+	DefaultContainerLogTail = 100
+	MaxContainerLogTail     = 10000
 )
 
 type ContainerService struct {
 	runtime           infrastructure.ContainerRuntime
 	containerUserRepo infrastructure.ContainerUserRepository
 	jobRepo           infrastructure.JobRepository
+	quotaService      *QuotaService
+	authorizer        *Authorizer
 
 	singleflightGroup singleflight.Group
 	mutexMap          sync.Map
+	inspectCache      *inspectCache
 }
 
-func NewContainerService(runtime infrastructure.ContainerRuntime, containerUserRepo infrastructure.ContainerUserRepository, jobRepo infrastructure.JobRepository) *ContainerService {
+func NewContainerService(runtime infrastructure.ContainerRuntime, containerUserRepo infrastructure.ContainerUserRepository, jobRepo infrastructure.JobRepository, quotaService *QuotaService, authorizer *Authorizer) *ContainerService {
 	return &ContainerService{
 		runtime:           runtime,
 		containerUserRepo: containerUserRepo,
 		jobRepo:           jobRepo,
+		quotaService:      quotaService,
+		authorizer:        authorizer,
+		inspectCache:      newInspectCache(inspectCacheTTL),
 	}
 }
 
-func (s *ContainerService) CreateContainer(ctx context.Context, userID int64, options infrastructure.ContainerCreateOptions) (string, error) {
+// CreateContainer enqueues the creation of a container for the caller, or for a team of the caller
+// when options.TeamID is set. Either way the container counts against the quota of the caller.
+func (s *ContainerService) CreateContainer(ctx context.Context, caller Caller, options infrastructure.ContainerCreateOptions) (string, error) {
+	if err := s.authorizer.authorize(ctx, caller, entity.ActionWrite, entity.Owner{UserID: caller.UserID, TeamID: options.TeamID}); err != nil {
+		return "", err
+	}
+	userID := caller.UserID
+	if err := entity.ValidateLabels(options.Labels); err != nil {
+		return "", err
+	}
+	if options.MemoryBytes < 0 || options.NanoCPUs < 0 {
+		return "", errors.InvalidResourceLimit
+	}
+	options = s.quotaService.containerResources(options)
+	if options.Name != "" {
+		if err := entity.ValidateContainerName(options.Name); err != nil {
+			return "", err
+		}
+		// Check early so that the caller gets the conflict instead of a failed job.
+		// The unique constraint on container_user still guards against races.
+		_, err := s.containerUserRepo.FindByIDOrName(ctx, userID, options.Name)
+		if err == nil {
+			return "", errors.ContainerNameConflict
+		}
+		if !errors.ContainerNotFound.Is(err) {
+			return "", err
+		}
+	}
+
 	payload, err := json.Marshal(options)
 	if err != nil {
 		return "", err
 	}
 
+	// Reserve before enqueuing the job, so that concurrent requests cannot create more containers
+	// than allowed. The reservation is released if the job fails.
+	reservation := containerReservation(options)
+	if err := s.quotaService.reserve(ctx, userID, reservation); err != nil {
+		return "", err
+	}
+
 	job := &entity.Job{
 		ID:        uuid.New().String(),
-		Type:      "container_creation",
+		Type:      entity.JobTypeContainerCreation,
 		Status:    entity.JobStatusPending,
 		Payload:   payload,
 		UserID:    userID,
+		TeamID:    options.TeamID,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
 	if err := s.jobRepo.Create(context.Background(), job); err != nil {
+		s.quotaService.release(ctx, userID, reservation)
 		return "", err
 	}
 
@@ -68,8 +124,9 @@ func (s *ContainerService) runCreateContainerJob(job *entity.Job, userID int64,
 		return
 	}
 
-	containerID, err := s.runtime.Create(ctx, options)
+	containerID, err := s.runtime.Create(ctx, withSystemLabels(options, userID, job.ID))
 	if err != nil {
+		s.quotaService.release(ctx, userID, containerReservation(options))
 		job.Status = entity.JobStatusFailed
 		job.Error = err.Error()
 		job.UpdatedAt = time.Now()
@@ -79,9 +136,17 @@ func (s *ContainerService) runCreateContainerJob(job *entity.Job, userID int64,
 		return
 	}
 
-	err = s.containerUserRepo.Create(ctx, containerID, userID)
+	err = s.containerUserRepo.Create(ctx, &entity.ContainerUser{
+		ContainerID: containerID,
+		UserID:      userID,
+		TeamID:      options.TeamID,
+		Name:        options.Name,
+		MemoryBytes: options.MemoryBytes,
+		NanoCPUs:    options.NanoCPUs,
+	})
 	if err != nil {
 		s.runtime.Remove(ctx, containerID)
+		s.quotaService.release(ctx, userID, containerReservation(options))
 		job.Status = entity.JobStatusFailed
 		job.Error = err.Error()
 		job.UpdatedAt = time.Now()
@@ -107,49 +172,104 @@ func (s *ContainerService) runCreateContainerJob(job *entity.Job, userID int64,
 	}
 }
 
-func (s *ContainerService) StartContainer(ctx context.Context, userID int64, id string) error {
-	_, err, _ := s.singleflightGroup.Do("start:"+id, func() (any, error) {
+func (s *ContainerService) StartContainer(ctx context.Context, caller Caller, idOrName string) error {
+	containerUser, err := s.resolveContainer(ctx, caller, entity.ActionWrite, idOrName)
+	if err != nil {
+		return err
+	}
+	// Quota is accounted to the creator, also when an admin or another team member starts the container.
+	id, userID := containerUser.ContainerID, containerUser.UserID
+
+	_, err, _ = s.singleflightGroup.Do("start:"+id, func() (any, error) {
 		mutex := s.getMutex(id)
 		if !mutex.TryLock() {
 			return nil, errors.ConflictContainerOperation
 		}
 		defer mutex.Unlock()
 
-		containerUserID, err := s.containerUserRepo.GetUserIDByContainerID(ctx, id)
+		// The running quota follows the running flag of container_user, which only changes
+		// atomically, so a container is never counted twice.
+		changed, err := s.containerUserRepo.SetRunning(ctx, id, true)
 		if err != nil {
 			return nil, err
 		}
-		if containerUserID != userID {
-			return nil, errors.PermissionDenied
+		if changed {
+			err := s.quotaService.reserve(ctx, userID, entity.QuotaResources{RunningContainers: 1})
+			if err != nil && errors.QuotaExceeded.Is(err) && s.reconcileRunning(ctx, userID) {
+				// Containers of the user that exited by themselves were still holding running slots.
+				err = s.quotaService.reserve(ctx, userID, entity.QuotaResources{RunningContainers: 1})
+			}
+			if err != nil {
+				s.setStopped(ctx, userID, id, false)
+				return nil, err
+			}
 		}
+
 		err = s.runtime.Start(ctx, id)
+		s.inspectCache.invalidate(id)
+		if err != nil && changed {
+			s.setStopped(ctx, userID, id, true)
+		}
 		return nil, err
 	})
 	return err
 }
 
-func (s *ContainerService) StopContainer(ctx context.Context, userID int64, id string) error {
-	_, err, _ := s.singleflightGroup.Do("stop:"+id, func() (any, error) {
+func (s *ContainerService) StopContainer(ctx context.Context, caller Caller, idOrName string) error {
+	containerUser, err := s.resolveContainer(ctx, caller, entity.ActionWrite, idOrName)
+	if err != nil {
+		return err
+	}
+	id, userID := containerUser.ContainerID, containerUser.UserID
+
+	_, err, _ = s.singleflightGroup.Do("stop:"+id, func() (any, error) {
 		mutex := s.getMutex(id)
 		if !mutex.TryLock() {
 			return nil, errors.ConflictContainerOperation
 		}
 		defer mutex.Unlock()
 
-		containerUserID, err := s.containerUserRepo.GetUserIDByContainerID(ctx, id)
+		err := s.runtime.Stop(ctx, id)
+		s.inspectCache.invalidate(id)
 		if err != nil {
 			return nil, err
 		}
-		if containerUserID != userID {
-			return nil, errors.PermissionDenied
-		}
-		err = s.runtime.Stop(ctx, id)
-		return nil, err
+		s.setStopped(ctx, userID, id, true)
+		return nil, nil
 	})
 	return err
 }
 
-func (s *ContainerService) RemoveContainer(ctx context.Context, userID int64, id string) error {
+func (s *ContainerService) RemoveContainer(ctx context.Context, caller Caller, idOrName string) error {
+	containerUser, err := s.resolveContainer(ctx, caller, entity.ActionWrite, idOrName)
+	if err != nil {
+		return err
+	}
+	return s.removeContainer(ctx, containerUser)
+}
+
+// RemoveUserContainers removes the personal containers of a user, without checking permissions,
+// for the deletion of the account. Containers of teams are left to the team. onProgress is called
+// after each container with the number removed so far and the total. It returns the number of
+// removed containers, and stops at the first container that cannot be removed.
+func (s *ContainerService) RemoveUserContainers(ctx context.Context, userID int64, onProgress func(done, total int)) (int, error) {
+	containerUsers, err := s.containerUserRepo.GetByOwner(ctx, entity.Owner{UserID: userID})
+	if err != nil {
+		return 0, err
+	}
+	for i, containerUser := range containerUsers {
+		if err := s.removeContainer(ctx, containerUser); err != nil {
+			return i, err
+		}
+		onProgress(i+1, len(containerUsers))
+	}
+	return len(containerUsers), nil
+}
+
+// removeContainer removes a container from the runtime and gives back its quota.
+func (s *ContainerService) removeContainer(ctx context.Context, containerUser *entity.ContainerUser) error {
+	id, userID := containerUser.ContainerID, containerUser.UserID
+
 	_, err, _ := s.singleflightGroup.Do("remove:"+id, func() (any, error) {
 		mutex := s.getMutex(id)
 		if !mutex.TryLock() {
@@ -157,42 +277,186 @@ func (s *ContainerService) RemoveContainer(ctx context.Context, userID int64, id
 		}
 		defer s.mutexMap.Delete(id)
 
-		containerUserID, err := s.containerUserRepo.GetUserIDByContainerID(ctx, id)
+		err := s.runtime.Remove(ctx, id)
+		s.inspectCache.invalidate(id)
 		if err != nil {
 			return nil, err
 		}
-		if containerUserID != userID {
-			return nil, errors.PermissionDenied
-		}
-		err = s.runtime.Remove(ctx, id)
-		if err != nil {
+		s.setStopped(ctx, userID, id, true)
+		if err := s.containerUserRepo.Delete(ctx, id); err != nil {
 			return nil, err
 		}
-		err = s.containerUserRepo.Delete(ctx, id)
-		return nil, err
+		s.quotaService.release(ctx, userID, entity.QuotaResources{
+			Containers:  1,
+			MemoryBytes: containerUser.MemoryBytes,
+			NanoCPUs:    containerUser.NanoCPUs,
+		})
+		return nil, nil
 	})
 	return err
 }
 
-func (s *ContainerService) ListContainers(ctx context.Context, userID int64) ([]*entity.Container, error) {
-	containerIDs, err := s.containerUserRepo.GetContainerIDsByUserID(ctx, userID)
+// RenameContainer changes the name the user gave to a container.
+func (s *ContainerService) RenameContainer(ctx context.Context, caller Caller, idOrName string, name string) error {
+	if err := entity.ValidateContainerName(name); err != nil {
+		return err
+	}
+
+	containerUser, err := s.resolveContainer(ctx, caller, entity.ActionWrite, idOrName)
+	if err != nil {
+		return err
+	}
+
+	return s.containerUserRepo.UpdateName(ctx, containerUser.ContainerID, name)
+}
+
+// ContainerLogs returns the most recent output of a container.
+func (s *ContainerService) ContainerLogs(ctx context.Context, caller Caller, idOrName string, options infrastructure.ContainerLogsOptions) ([]byte, error) {
+	if options.Tail == 0 {
+		options.Tail = DefaultContainerLogTail
+	}
+	if options.Tail < 0 || options.Tail > MaxContainerLogTail {
+		return nil, errors.BadRequest.New("tail must be between 1 and 10000")
+	}
+
+	containerUser, err := s.resolveContainer(ctx, caller, entity.ActionRead, idOrName)
 	if err != nil {
 		return nil, err
 	}
+	return s.runtime.Logs(ctx, containerUser.ContainerID, options)
+}
+
+// CopyToContainer extracts a tar archive into a folder of a container, which may also be stopped.
+// Existing files are overwritten, but a folder is never replaced by a file or the other way around.
+func (s *ContainerService) CopyToContainer(ctx context.Context, caller Caller, idOrName string, dir string, archive io.Reader) error {
+	if err := entity.ValidateContainerPath(dir); err != nil {
+		return err
+	}
 
-	var containers []*entity.Container
-	for _, id := range containerIDs {
-		container, err := s.runtime.Inspect(ctx, id)
-		if err != nil {
-			// Log error and continue, or return partial list?
-			// For now, let's just log and continue, assuming the container might have been removed manually
-			log.Printf("failed to inspect container %s: %v", id, err)
-			continue
+	containerUser, err := s.resolveContainer(ctx, caller, entity.ActionWrite, idOrName)
+	if err != nil {
+		return err
+	}
+	return s.runtime.CopyTo(ctx, containerUser.ContainerID, dir, archive)
+}
+
+// CopyFromContainer returns a tar archive of a file or folder of a container. The caller has to
+// close it.
+func (s *ContainerService) CopyFromContainer(ctx context.Context, caller Caller, idOrName string, path string) (io.ReadCloser, error) {
+	if err := entity.ValidateContainerPath(path); err != nil {
+		return nil, err
+	}
+
+	containerUser, err := s.resolveContainer(ctx, caller, entity.ActionRead, idOrName)
+	if err != nil {
+		return nil, err
+	}
+	return s.runtime.CopyFrom(ctx, containerUser.ContainerID, path)
+}
+
+// resolveContainer resolves a container ID or name, and checks that the caller may perform the action on it.
+// Names are resolved among the containers the caller created, other containers, like the containers
+// of a team created by another member, are addressed by ID.
+func (s *ContainerService) resolveContainer(ctx context.Context, caller Caller, action entity.Action, idOrName string) (*entity.ContainerUser, error) {
+	containerUser, err := s.containerUserRepo.FindByIDOrName(ctx, caller.UserID, idOrName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizer.authorize(ctx, caller, action, containerUser.Owner()); err != nil {
+		return nil, err
+	}
+	return containerUser, nil
+}
+
+// setStopped records the container as not running, and gives back its running quota if it was
+// counted and release is set. It reports whether the quota was given back. Failures are only
+// logged, as the runtime operation already happened.
+func (s *ContainerService) setStopped(ctx context.Context, userID int64, id string, release bool) bool {
+	changed, err := s.containerUserRepo.SetRunning(ctx, id, false)
+	if err != nil {
+		log.Printf("failed to record container %s as stopped: %v", id, err)
+		return false
+	}
+	if changed && release {
+		s.quotaService.release(ctx, userID, entity.QuotaResources{RunningContainers: 1})
+		return true
+	}
+	return false
+}
+
+// reconcileRunning clears the running flag of the user's containers that are no longer running,
+// and reports whether any running quota was given back.
+func (s *ContainerService) reconcileRunning(ctx context.Context, userID int64) bool {
+	containerUsers, err := s.containerUserRepo.GetRunningByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("failed to get the running containers of user %d: %v", userID, err)
+		return false
+	}
+	released := false
+	for _, containerUser := range containerUsers {
+		if s.reconcileStopped(ctx, containerUser) {
+			released = true
 		}
-		containers = append(containers, container)
 	}
+	return released
+}
+
+// reconcileStopped clears the running flag of a container that exited by itself, for example
+// when its process ended or it was killed, and gives back its running quota. The container is
+// inspected again under its mutex, so a start or stop in progress is never undone, and
+// containers busy with another operation are skipped.
+func (s *ContainerService) reconcileStopped(ctx context.Context, containerUser *entity.ContainerUser) bool {
+	id := containerUser.ContainerID
+	mutex := s.getMutex(id)
+	if !mutex.TryLock() {
+		return false
+	}
+	defer mutex.Unlock()
+
+	inspected, err := s.runtime.Inspect(ctx, id)
+	if err != nil {
+		log.Printf("failed to inspect container %s: %v", id, err)
+		return false
+	}
+	s.inspectCache.set(id, inspected)
+	if countsAsRunning(inspected.Status) {
+		return false
+	}
+	return s.setStopped(ctx, containerUser.UserID, id, true)
+}
+
+// countsAsRunning reports whether a container in the given state holds a running slot.
+func countsAsRunning(status container.ContainerState) bool {
+	switch status {
+	case container.StateRunning, container.StatePaused, container.StateRestarting:
+		return true
+	default:
+		return false
+	}
+}
+
+// containerReservation is the quota reserved by a container created with the given options.
+func containerReservation(options infrastructure.ContainerCreateOptions) entity.QuotaResources {
+	return entity.QuotaResources{
+		Containers:  1,
+		MemoryBytes: options.MemoryBytes,
+		NanoCPUs:    options.NanoCPUs,
+	}
+}
 
-	return containers, nil
+// withSystemLabels returns a copy of options with the reserved system labels applied.
+func withSystemLabels(options infrastructure.ContainerCreateOptions, userID int64, jobID string) infrastructure.ContainerCreateOptions {
+	labels := make(map[string]string, len(options.Labels)+3)
+	for key, value := range options.Labels {
+		labels[key] = value
+	}
+	labels[entity.LabelOwner] = strconv.FormatInt(userID, 10)
+	if options.TeamID != 0 {
+		labels[entity.LabelTeam] = strconv.FormatInt(options.TeamID, 10)
+	}
+	labels[entity.LabelJobID] = jobID
+	options.Labels = labels
+	return options
 }
 
 func (s *ContainerService) getMutex(id string) *sync.Mutex {
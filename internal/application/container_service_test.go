@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -11,21 +14,34 @@ import (
 	"container-manager/internal/application/mocks"
 	"container-manager/internal/domain/entity"
 	"container-manager/internal/domain/infrastructure"
+	internalErrors "container-manager/internal/errors"
 
 	"github.com/google/uuid"
+	"github.com/moby/moby/api/types/container"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 )
 
+// member is a caller with the default role.
+func member(userID int64) Caller {
+	return Caller{UserID: userID, Role: entity.RoleMember}
+}
+
 func TestContainerService_CreateContainer(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 	mockJobRepo := mocks.NewMockJobRepository(ctrl)
 
-	service := NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo)
+	quotaOptions := QuotaOptions{
+		DefaultLimits:        entity.QuotaResources{Containers: 10},
+		ContainerMemoryBytes: 512,
+		ContainerNanoCPUs:    1000,
+	}
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo, NewQuotaService(mockQuotaRepo, quotaOptions), NewAuthorizer(nil))
 
 	ctx := context.Background()
 	userID := int64(1)
@@ -36,6 +52,10 @@ func TestContainerService_CreateContainer(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(1)
 
+	// The default resources are applied and reserved before the job is enqueued.
+	mockQuotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil)
+	mockQuotaRepo.EXPECT().Reserve(ctx, userID, entity.QuotaResources{Containers: 1, MemoryBytes: 512, NanoCPUs: 1000}, quotaOptions.DefaultLimits).Return(nil)
+
 	mockJobRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, job *entity.Job) error {
 		assert.Equal(t, "container_creation", job.Type)
 		assert.Equal(t, entity.JobStatusPending, job.Status)
@@ -46,30 +66,62 @@ func TestContainerService_CreateContainer(t *testing.T) {
 	// Expectations for the goroutine
 	gomock.InOrder(
 		mockJobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil),
-		mockRuntime.EXPECT().Create(gomock.Any(), options).Return("container-123", nil),
-		mockContainerUserRepo.EXPECT().Create(gomock.Any(), "container-123", userID).Return(nil),
+		mockRuntime.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, opts infrastructure.ContainerCreateOptions) (string, error) {
+			assert.Equal(t, options.Image, opts.Image)
+			assert.Equal(t, int64(512), opts.MemoryBytes)
+			assert.Equal(t, int64(1000), opts.NanoCPUs)
+			assert.Equal(t, "1", opts.Labels[entity.LabelOwner])
+			assert.NotEmpty(t, opts.Labels[entity.LabelJobID])
+			return "container-123", nil
+		}),
+		mockContainerUserRepo.EXPECT().Create(gomock.Any(), &entity.ContainerUser{ContainerID: "container-123", UserID: userID, MemoryBytes: 512, NanoCPUs: 1000}).Return(nil),
 		mockJobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *entity.Job) error {
 			wg.Done()
 			return nil
 		}),
 	)
 
-	jobID, err := service.CreateContainer(ctx, userID, options)
+	jobID, err := service.CreateContainer(ctx, member(userID), options)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, jobID)
 
 	wg.Wait() // Wait for the goroutine to finish
 }
 
+func TestContainerService_CreateContainer_ReservedLabel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	mockJobRepo := mocks.NewMockJobRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	options := infrastructure.ContainerCreateOptions{
+		Image:  "test-image",
+		Labels: map[string]string{entity.LabelOwner: "2"},
+	}
+
+	jobID, err := service.CreateContainer(context.Background(), member(1), options)
+	assert.Error(t, err)
+	var customErr *internalErrors.CustomError
+	assert.ErrorAs(t, err, &customErr)
+	assert.Equal(t, internalErrors.InvalidLabel.Message, customErr.Message)
+	assert.Empty(t, jobID)
+}
+
 func TestContainerService_runCreateContainerJob_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 	mockJobRepo := mocks.NewMockJobRepository(ctrl)
 
-	service := NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo)
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
 
 	userID := int64(1)
 	options := infrastructure.ContainerCreateOptions{
@@ -92,8 +144,8 @@ func TestContainerService_runCreateContainerJob_Success(t *testing.T) {
 			assert.Equal(t, entity.JobStatusRunning, updatedJob.Status)
 			return nil
 		}),
-		mockRuntime.EXPECT().Create(gomock.Any(), options).Return(containerID, nil),
-		mockContainerUserRepo.EXPECT().Create(gomock.Any(), containerID, userID).Return(nil),
+		mockRuntime.EXPECT().Create(gomock.Any(), withSystemLabels(options, userID, job.ID)).Return(containerID, nil),
+		mockContainerUserRepo.EXPECT().Create(gomock.Any(), &entity.ContainerUser{ContainerID: containerID, UserID: userID}).Return(nil),
 		mockJobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, updatedJob *entity.Job) error {
 			assert.Equal(t, entity.JobStatusCompleted, updatedJob.Status)
 			var result map[string]string
@@ -113,9 +165,10 @@ func TestContainerService_runCreateContainerJob_RuntimeCreateFails(t *testing.T)
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 	mockJobRepo := mocks.NewMockJobRepository(ctrl)
 
-	service := NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo)
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
 
 	userID := int64(1)
 	options := infrastructure.ContainerCreateOptions{
@@ -131,7 +184,8 @@ func TestContainerService_runCreateContainerJob_RuntimeCreateFails(t *testing.T)
 
 	gomock.InOrder(
 		mockJobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil),
-		mockRuntime.EXPECT().Create(gomock.Any(), options).Return("", createErr),
+		mockRuntime.EXPECT().Create(gomock.Any(), withSystemLabels(options, userID, job.ID)).Return("", createErr),
+		mockQuotaRepo.EXPECT().Release(gomock.Any(), userID, entity.QuotaResources{Containers: 1}).Return(nil),
 		mockJobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, updatedJob *entity.Job) error {
 			assert.Equal(t, entity.JobStatusFailed, updatedJob.Status)
 			assert.Equal(t, createErr.Error(), updatedJob.Error)
@@ -148,9 +202,10 @@ func TestContainerService_runCreateContainerJob_UserRepoCreateFails(t *testing.T
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 	mockJobRepo := mocks.NewMockJobRepository(ctrl)
 
-	service := NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo)
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
 
 	userID := int64(1)
 	options := infrastructure.ContainerCreateOptions{
@@ -167,9 +222,10 @@ func TestContainerService_runCreateContainerJob_UserRepoCreateFails(t *testing.T
 
 	gomock.InOrder(
 		mockJobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil),
-		mockRuntime.EXPECT().Create(gomock.Any(), options).Return(containerID, nil),
-		mockContainerUserRepo.EXPECT().Create(gomock.Any(), containerID, userID).Return(repoErr),
+		mockRuntime.EXPECT().Create(gomock.Any(), withSystemLabels(options, userID, job.ID)).Return(containerID, nil),
+		mockContainerUserRepo.EXPECT().Create(gomock.Any(), &entity.ContainerUser{ContainerID: containerID, UserID: userID}).Return(repoErr),
 		mockRuntime.EXPECT().Remove(gomock.Any(), containerID).Return(nil), // Rollback
+		mockQuotaRepo.EXPECT().Release(gomock.Any(), userID, entity.QuotaResources{Containers: 1}).Return(nil),
 		mockJobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, updatedJob *entity.Job) error {
 			assert.Equal(t, entity.JobStatusFailed, updatedJob.Status)
 			assert.Equal(t, repoErr.Error(), updatedJob.Error)
@@ -186,17 +242,116 @@ func TestContainerService_StartContainer(t *testing.T) {
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	userID := int64(1)
+	containerID := "container-123"
+
+	mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: userID}, nil)
+	mockContainerUserRepo.EXPECT().SetRunning(ctx, containerID, true).Return(true, nil)
+	mockQuotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil)
+	mockQuotaRepo.EXPECT().Reserve(ctx, userID, entity.QuotaResources{RunningContainers: 1}, entity.QuotaResources{}).Return(nil)
+	mockRuntime.EXPECT().Start(ctx, containerID).Return(nil)
+
+	err := service.StartContainer(ctx, member(userID), containerID)
+	assert.NoError(t, err)
+}
+
+func TestContainerService_StartContainer_AlreadyRunning(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 
-	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil)
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
 
 	ctx := context.Background()
 	userID := int64(1)
 	containerID := "container-123"
 
-	mockContainerUserRepo.EXPECT().GetUserIDByContainerID(ctx, containerID).Return(userID, nil)
+	// A container already counted as running is not reserved again.
+	mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: userID, Running: true}, nil)
+	mockContainerUserRepo.EXPECT().SetRunning(ctx, containerID, true).Return(false, nil)
 	mockRuntime.EXPECT().Start(ctx, containerID).Return(nil)
 
-	err := service.StartContainer(ctx, userID, containerID)
+	err := service.StartContainer(ctx, member(userID), containerID)
+	assert.NoError(t, err)
+}
+
+func TestContainerService_StartContainer_QuotaExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	limits := entity.QuotaResources{RunningContainers: 2}
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{DefaultLimits: limits}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	userID := int64(1)
+	containerID := "container-123"
+
+	mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: userID}, nil)
+	gomock.InOrder(
+		mockContainerUserRepo.EXPECT().SetRunning(ctx, containerID, true).Return(true, nil),
+		mockQuotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil),
+		mockQuotaRepo.EXPECT().Reserve(ctx, userID, entity.QuotaResources{RunningContainers: 1}, limits).Return(internalErrors.QuotaExceeded),
+		mockQuotaRepo.EXPECT().GetUsage(ctx, userID).Return(&entity.QuotaResources{RunningContainers: 2}, nil),
+		// The other container is still running and the one being started is skipped, so no running
+		// slot is freed and the start is not retried.
+		mockContainerUserRepo.EXPECT().GetRunningByUserID(ctx, userID).Return([]*entity.ContainerUser{{ContainerID: "container-running", UserID: userID, Running: true}, {ContainerID: containerID, UserID: userID, Running: true}}, nil),
+		mockRuntime.EXPECT().Inspect(ctx, "container-running").Return(&entity.Container{ID: "container-running", Status: container.StateRunning}, nil),
+		// The running flag is reverted without giving back a reservation that was never made.
+		mockContainerUserRepo.EXPECT().SetRunning(ctx, containerID, false).Return(true, nil),
+	)
+
+	err := service.StartContainer(ctx, member(userID), containerID)
+	var customErr *internalErrors.CustomError
+	assert.ErrorAs(t, err, &customErr)
+	assert.Equal(t, internalErrors.QuotaExceeded.Message, customErr.Message)
+	assert.EqualError(t, err, "running_containers quota exceeded")
+}
+
+func TestContainerService_StartContainer_ExitedContainerFreesSlot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	limits := entity.QuotaResources{RunningContainers: 1}
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{DefaultLimits: limits}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	userID := int64(1)
+	exitedID := "container-exited"
+	containerID := "container-123"
+
+	mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: userID}, nil)
+	gomock.InOrder(
+		mockContainerUserRepo.EXPECT().SetRunning(ctx, containerID, true).Return(true, nil),
+		mockQuotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil),
+		mockQuotaRepo.EXPECT().Reserve(ctx, userID, entity.QuotaResources{RunningContainers: 1}, limits).Return(internalErrors.QuotaExceeded),
+		mockQuotaRepo.EXPECT().GetUsage(ctx, userID).Return(&entity.QuotaResources{RunningContainers: 1}, nil),
+		// The container holding the slot exited by itself, so its slot is given back and the start retried.
+		mockContainerUserRepo.EXPECT().GetRunningByUserID(ctx, userID).Return([]*entity.ContainerUser{{ContainerID: exitedID, UserID: userID, Running: true}}, nil),
+		mockRuntime.EXPECT().Inspect(ctx, exitedID).Return(&entity.Container{ID: exitedID, Status: container.StateExited}, nil),
+		mockContainerUserRepo.EXPECT().SetRunning(ctx, exitedID, false).Return(true, nil),
+		mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{RunningContainers: 1}).Return(nil),
+		mockQuotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil),
+		mockQuotaRepo.EXPECT().Reserve(ctx, userID, entity.QuotaResources{RunningContainers: 1}, limits).Return(nil),
+		mockRuntime.EXPECT().Start(ctx, containerID).Return(nil),
+	)
+
+	err := service.StartContainer(ctx, member(userID), containerID)
 	assert.NoError(t, err)
 }
 
@@ -206,37 +361,360 @@ func TestContainerService_StartContainer_PermissionDenied(t *testing.T) {
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 
-	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil)
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
 
 	ctx := context.Background()
 	userID := int64(1)
 	otherUserID := int64(2)
 	containerID := "container-123"
 
-	mockContainerUserRepo.EXPECT().GetUserIDByContainerID(ctx, containerID).Return(otherUserID, nil)
+	mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: otherUserID}, nil)
 
-	err := service.StartContainer(ctx, userID, containerID)
+	err := service.StartContainer(ctx, member(userID), containerID)
 	assert.EqualError(t, err, "permission denied")
 }
 
+func TestContainerService_StartContainer_Admin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	adminID := int64(1)
+	ownerID := int64(2)
+	containerID := "container-123"
+
+	// The running container is accounted to the owner, not to the admin.
+	mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, adminID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: ownerID}, nil)
+	mockContainerUserRepo.EXPECT().SetRunning(ctx, containerID, true).Return(true, nil)
+	mockQuotaRepo.EXPECT().GetLimits(ctx, ownerID).Return(nil, nil)
+	mockQuotaRepo.EXPECT().Reserve(ctx, ownerID, entity.QuotaResources{RunningContainers: 1}, entity.QuotaResources{}).Return(nil)
+	mockRuntime.EXPECT().Start(ctx, containerID).Return(nil)
+
+	err := service.StartContainer(ctx, Caller{UserID: adminID, Role: entity.RoleAdmin}, containerID)
+	assert.NoError(t, err)
+}
+
+func TestContainerService_ReadOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	caller := Caller{UserID: 1, Role: entity.RoleReadOnly}
+	containerID := "container-123"
+
+	t.Run("cannot create", func(t *testing.T) {
+		_, err := service.CreateContainer(ctx, caller, infrastructure.ContainerCreateOptions{Image: "alpine"})
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+
+	t.Run("cannot stop own container", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, caller.UserID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: caller.UserID}, nil)
+
+		err := service.StopContainer(ctx, caller, containerID)
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+
+	t.Run("can list own containers", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().CountByOwner(ctx, entity.Owner{UserID: caller.UserID}, nil).Return(0, nil)
+		mockContainerUserRepo.EXPECT().GetPageByOwner(ctx, entity.Owner{UserID: caller.UserID}, gomock.Any()).Return(nil, nil)
+
+		list, err := service.ListContainers(ctx, caller, ContainerListQuery{})
+		assert.NoError(t, err)
+		assert.Empty(t, list.Containers)
+	})
+}
+
+func TestContainerService_ListUserContainers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, nil, NewAuthorizer(nil))
+	ctx := context.Background()
+
+	t.Run("admin", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().CountByOwner(ctx, entity.Owner{UserID: int64(2)}, nil).Return(0, nil)
+		mockContainerUserRepo.EXPECT().GetPageByOwner(ctx, entity.Owner{UserID: int64(2)}, gomock.Any()).Return(nil, nil)
+
+		_, err := service.ListUserContainers(ctx, Caller{UserID: 1, Role: entity.RoleAdmin}, 2, ContainerListQuery{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("member", func(t *testing.T) {
+		_, err := service.ListUserContainers(ctx, member(1), 2, ContainerListQuery{})
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+}
+
+func TestContainerService_TeamContainers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(mockTeamRepo))
+
+	ctx := context.Background()
+	creatorID := int64(1)
+	teamID := int64(7)
+	containerID := "container-123"
+	teamContainer := &entity.ContainerUser{ContainerID: containerID, UserID: creatorID, TeamID: teamID}
+
+	t.Run("members start team containers on the creator's quota", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, int64(2), containerID).Return(teamContainer, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, teamID, int64(2)).Return(&entity.TeamMember{TeamID: teamID, UserID: 2, Role: entity.TeamRoleMember}, nil)
+		mockContainerUserRepo.EXPECT().SetRunning(ctx, containerID, true).Return(true, nil)
+		mockQuotaRepo.EXPECT().GetLimits(ctx, creatorID).Return(nil, nil)
+		mockQuotaRepo.EXPECT().Reserve(ctx, creatorID, entity.QuotaResources{RunningContainers: 1}, entity.QuotaResources{}).Return(nil)
+		mockRuntime.EXPECT().Start(ctx, containerID).Return(nil)
+
+		err := service.StartContainer(ctx, member(2), containerID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("viewers cannot stop team containers", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, int64(3), containerID).Return(teamContainer, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, teamID, int64(3)).Return(&entity.TeamMember{TeamID: teamID, UserID: 3, Role: entity.TeamRoleViewer}, nil)
+
+		err := service.StopContainer(ctx, member(3), containerID)
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+
+	t.Run("the creator loses access after leaving the team", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, creatorID, containerID).Return(teamContainer, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, teamID, creatorID).Return(nil, nil)
+
+		err := service.StopContainer(ctx, member(creatorID), containerID)
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+
+	t.Run("viewers list team containers", func(t *testing.T) {
+		mockTeamRepo.EXPECT().GetMember(ctx, teamID, int64(3)).Return(&entity.TeamMember{TeamID: teamID, UserID: 3, Role: entity.TeamRoleViewer}, nil)
+		mockContainerUserRepo.EXPECT().CountByOwner(ctx, entity.Owner{TeamID: teamID}, nil).Return(0, nil)
+		mockContainerUserRepo.EXPECT().GetPageByOwner(ctx, entity.Owner{TeamID: teamID}, gomock.Any()).Return(nil, nil)
+
+		list, err := service.ListTeamContainers(ctx, member(3), teamID, ContainerListQuery{})
+		assert.NoError(t, err)
+		assert.Empty(t, list.Containers)
+	})
+
+	t.Run("non-members cannot list team containers", func(t *testing.T) {
+		mockTeamRepo.EXPECT().GetMember(ctx, teamID, int64(4)).Return(nil, nil)
+
+		_, err := service.ListTeamContainers(ctx, member(4), teamID, ContainerListQuery{})
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+}
+
+func TestContainerService_CreateContainer_Team(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	mockJobRepo := mocks.NewMockJobRepository(ctrl)
+	mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(mockTeamRepo))
+	ctx := context.Background()
+
+	t.Run("viewers cannot create team containers", func(t *testing.T) {
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(1)).Return(&entity.TeamMember{TeamID: 7, UserID: 1, Role: entity.TeamRoleViewer}, nil)
+
+		_, err := service.CreateContainer(ctx, member(1), infrastructure.ContainerCreateOptions{Image: "alpine", TeamID: 7})
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+
+	t.Run("the job and the container belong to the team", func(t *testing.T) {
+		var wg sync.WaitGroup
+		wg.Add(1)
+
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(1)).Return(&entity.TeamMember{TeamID: 7, UserID: 1, Role: entity.TeamRoleMember}, nil)
+		mockQuotaRepo.EXPECT().GetLimits(ctx, int64(1)).Return(nil, nil)
+		mockQuotaRepo.EXPECT().Reserve(ctx, int64(1), entity.QuotaResources{Containers: 1}, entity.QuotaResources{}).Return(nil)
+		mockJobRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, job *entity.Job) error {
+			assert.Equal(t, entity.Owner{UserID: 1, TeamID: 7}, job.Owner())
+			return nil
+		})
+		gomock.InOrder(
+			mockJobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil),
+			mockRuntime.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, opts infrastructure.ContainerCreateOptions) (string, error) {
+				assert.Equal(t, "7", opts.Labels[entity.LabelTeam])
+				return "container-123", nil
+			}),
+			mockContainerUserRepo.EXPECT().Create(gomock.Any(), &entity.ContainerUser{ContainerID: "container-123", UserID: 1, TeamID: 7}).Return(nil),
+			mockJobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *entity.Job) error {
+				wg.Done()
+				return nil
+			}),
+		)
+
+		_, err := service.CreateContainer(ctx, member(1), infrastructure.ContainerCreateOptions{Image: "alpine", TeamID: 7})
+		assert.NoError(t, err)
+		wg.Wait()
+	})
+}
+
+func TestContainerService_ContainerLogs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, nil, NewAuthorizer(mockTeamRepo))
+
+	ctx := context.Background()
+	containerID := "container-123"
+
+	t.Run("default tail", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, int64(1), containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: 1}, nil)
+		mockRuntime.EXPECT().Logs(ctx, containerID, infrastructure.ContainerLogsOptions{Tail: DefaultContainerLogTail}).Return([]byte("hello\n"), nil)
+
+		logs, err := service.ContainerLogs(ctx, member(1), containerID, infrastructure.ContainerLogsOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "hello\n", string(logs))
+	})
+
+	t.Run("team viewers read logs", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, int64(2), containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: 1, TeamID: 7}, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(2)).Return(&entity.TeamMember{TeamID: 7, UserID: 2, Role: entity.TeamRoleViewer}, nil)
+		mockRuntime.EXPECT().Logs(ctx, containerID, infrastructure.ContainerLogsOptions{Tail: 10, Timestamps: true}).Return(nil, nil)
+
+		_, err := service.ContainerLogs(ctx, member(2), containerID, infrastructure.ContainerLogsOptions{Tail: 10, Timestamps: true})
+		assert.NoError(t, err)
+	})
+
+	t.Run("other users", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, int64(2), containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: 1}, nil)
+
+		_, err := service.ContainerLogs(ctx, member(2), containerID, infrastructure.ContainerLogsOptions{})
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+
+	t.Run("tail out of range", func(t *testing.T) {
+		_, err := service.ContainerLogs(ctx, member(1), containerID, infrastructure.ContainerLogsOptions{Tail: MaxContainerLogTail + 1})
+		var customErr *internalErrors.CustomError
+		assert.ErrorAs(t, err, &customErr)
+		assert.Equal(t, internalErrors.BadRequest.Message, customErr.Message)
+	})
+}
+
+func TestContainerService_CopyToContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, nil, NewAuthorizer(mockTeamRepo))
+
+	ctx := context.Background()
+	containerID := "container-123"
+	archive := strings.NewReader("archive")
+
+	t.Run("success", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, int64(1), "web").Return(&entity.ContainerUser{ContainerID: containerID, UserID: 1}, nil)
+		mockRuntime.EXPECT().CopyTo(ctx, containerID, "/app", archive).Return(nil)
+
+		err := service.CopyToContainer(ctx, member(1), "web", "/app", archive)
+		assert.NoError(t, err)
+	})
+
+	t.Run("team viewers cannot copy files in", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, int64(2), containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: 1, TeamID: 7}, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(2)).Return(&entity.TeamMember{TeamID: 7, UserID: 2, Role: entity.TeamRoleViewer}, nil)
+
+		err := service.CopyToContainer(ctx, member(2), containerID, "/app", archive)
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+
+	t.Run("relative path", func(t *testing.T) {
+		err := service.CopyToContainer(ctx, member(1), containerID, "app", archive)
+		assert.Equal(t, internalErrors.InvalidContainerPath, err)
+	})
+}
+
+func TestContainerService_CopyFromContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, nil, NewAuthorizer(mockTeamRepo))
+
+	ctx := context.Background()
+	containerID := "container-123"
+
+	t.Run("team viewers copy files out", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, int64(2), containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: 1, TeamID: 7}, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(2)).Return(&entity.TeamMember{TeamID: 7, UserID: 2, Role: entity.TeamRoleViewer}, nil)
+		mockRuntime.EXPECT().CopyFrom(ctx, containerID, "/app/out.csv").Return(io.NopCloser(strings.NewReader("archive")), nil)
+
+		archive, err := service.CopyFromContainer(ctx, member(2), containerID, "/app/out.csv")
+		assert.NoError(t, err)
+		content, _ := io.ReadAll(archive)
+		assert.Equal(t, "archive", string(content))
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, int64(1), containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: 1}, nil)
+		mockRuntime.EXPECT().CopyFrom(ctx, containerID, "/missing").Return(nil, internalErrors.ContainerPathNotFound)
+
+		_, err := service.CopyFromContainer(ctx, member(1), containerID, "/missing")
+		assert.Equal(t, internalErrors.ContainerPathNotFound, err)
+	})
+
+	t.Run("relative path", func(t *testing.T) {
+		_, err := service.CopyFromContainer(ctx, member(1), containerID, "out.csv")
+		assert.Equal(t, internalErrors.InvalidContainerPath, err)
+	})
+}
+
 func TestContainerService_StopContainer(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 
-	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil)
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
 
 	ctx := context.Background()
 	userID := int64(1)
 	containerID := "container-123"
 
-	mockContainerUserRepo.EXPECT().GetUserIDByContainerID(ctx, containerID).Return(userID, nil)
+	mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: userID, Running: true}, nil)
 	mockRuntime.EXPECT().Stop(ctx, containerID).Return(nil)
+	mockContainerUserRepo.EXPECT().SetRunning(ctx, containerID, false).Return(true, nil)
+	mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{RunningContainers: 1}).Return(nil)
 
-	err := service.StopContainer(ctx, userID, containerID)
+	err := service.StopContainer(ctx, member(userID), containerID)
 	assert.NoError(t, err)
 }
 
@@ -246,17 +724,18 @@ func TestContainerService_StopContainer_PermissionDenied(t *testing.T) {
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 
-	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil)
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
 
 	ctx := context.Background()
 	userID := int64(1)
 	otherUserID := int64(2)
 	containerID := "container-123"
 
-	mockContainerUserRepo.EXPECT().GetUserIDByContainerID(ctx, containerID).Return(otherUserID, nil)
+	mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: otherUserID}, nil)
 
-	err := service.StopContainer(ctx, userID, containerID)
+	err := service.StopContainer(ctx, member(userID), containerID)
 	assert.EqualError(t, err, "permission denied")
 }
 
@@ -266,18 +745,22 @@ func TestContainerService_RemoveContainer(t *testing.T) {
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 
-	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil)
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
 
 	ctx := context.Background()
 	userID := int64(1)
 	containerID := "container-123"
 
-	mockContainerUserRepo.EXPECT().GetUserIDByContainerID(ctx, containerID).Return(userID, nil)
-	mockContainerUserRepo.EXPECT().Delete(ctx, containerID).Return(nil)
+	mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: userID, MemoryBytes: 512, NanoCPUs: 1000, Running: true}, nil)
 	mockRuntime.EXPECT().Remove(ctx, containerID).Return(nil)
+	mockContainerUserRepo.EXPECT().SetRunning(ctx, containerID, false).Return(true, nil)
+	mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{RunningContainers: 1}).Return(nil)
+	mockContainerUserRepo.EXPECT().Delete(ctx, containerID).Return(nil)
+	mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{Containers: 1, MemoryBytes: 512, NanoCPUs: 1000}).Return(nil)
 
-	err := service.RemoveContainer(ctx, userID, containerID)
+	err := service.RemoveContainer(ctx, member(userID), containerID)
 	assert.NoError(t, err)
 }
 
@@ -287,28 +770,63 @@ func TestContainerService_RemoveContainer_PermissionDenied(t *testing.T) {
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 
-	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil)
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
 
 	ctx := context.Background()
 	userID := int64(1)
 	otherUserID := int64(2)
 	containerID := "container-123"
 
-	mockContainerUserRepo.EXPECT().GetUserIDByContainerID(ctx, containerID).Return(otherUserID, nil)
+	mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: otherUserID}, nil)
 
-	err := service.RemoveContainer(ctx, userID, containerID)
+	err := service.RemoveContainer(ctx, member(userID), containerID)
 	assert.EqualError(t, err, "permission denied")
 }
 
+func TestContainerService_RemoveUserContainers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	userID := int64(1)
+
+	mockContainerUserRepo.EXPECT().GetByOwner(ctx, entity.Owner{UserID: userID}).Return([]*entity.ContainerUser{
+		{ContainerID: "container-1", UserID: userID},
+		{ContainerID: "container-2", UserID: userID},
+	}, nil)
+	mockRuntime.EXPECT().Remove(ctx, "container-1").Return(nil)
+	mockContainerUserRepo.EXPECT().SetRunning(ctx, "container-1", false).Return(false, nil)
+	mockContainerUserRepo.EXPECT().Delete(ctx, "container-1").Return(nil)
+	mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{Containers: 1}).Return(nil)
+	mockRuntime.EXPECT().Remove(ctx, "container-2").Return(errors.New("runtime error"))
+
+	var progress []int
+	removed, err := service.RemoveUserContainers(ctx, userID, func(done, total int) {
+		assert.Equal(t, 2, total)
+		progress = append(progress, done)
+	})
+	assert.EqualError(t, err, "runtime error")
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, []int{1}, progress)
+}
+
 func TestContainerService_ListContainers(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 
-	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil)
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
 
 	ctx := context.Background()
 	userID := int64(1)
@@ -318,15 +836,50 @@ func TestContainerService_ListContainers(t *testing.T) {
 	expectedContainer1 := &entity.Container{ID: containerID1, Image: "test-image-1"}
 	expectedContainer2 := &entity.Container{ID: containerID2, Image: "test-image-2"}
 
-	mockContainerUserRepo.EXPECT().GetContainerIDsByUserID(ctx, userID).Return([]string{containerID1, containerID2}, nil)
+	mockContainerUserRepo.EXPECT().CountByOwner(ctx, entity.Owner{UserID: userID}, nil).Return(2, nil)
+	mockContainerUserRepo.EXPECT().GetPageByOwner(ctx, entity.Owner{UserID: userID}, infrastructure.ContainerUserPage{Sort: infrastructure.ContainerSortCreatedAt, Limit: DefaultContainerListLimit + 1}).Return([]*entity.ContainerUser{{ContainerID: containerID1, UserID: userID}, {ContainerID: containerID2, UserID: userID}}, nil)
 	mockRuntime.EXPECT().Inspect(ctx, containerID1).Return(expectedContainer1, nil)
 	mockRuntime.EXPECT().Inspect(ctx, containerID2).Return(expectedContainer2, nil)
 
-	containers, err := service.ListContainers(ctx, userID)
+	list, err := service.ListContainers(ctx, member(userID), ContainerListQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, list.Total)
+	assert.Empty(t, list.NextCursor)
+	assert.Len(t, list.Containers, 2)
+	assert.Equal(t, expectedContainer1, list.Containers[0])
+	assert.Equal(t, expectedContainer2, list.Containers[1])
+	assert.Empty(t, list.Failed)
+}
+
+func TestContainerService_ListContainers_ReconcilesExited(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	userID := int64(1)
+	exited := &entity.Container{ID: "container-1", Status: container.StateExited}
+	running := &entity.Container{ID: "container-2", Status: container.StateRunning}
+
+	mockContainerUserRepo.EXPECT().CountByOwner(ctx, entity.Owner{UserID: userID}, nil).Return(2, nil)
+	mockContainerUserRepo.EXPECT().GetPageByOwner(ctx, entity.Owner{UserID: userID}, gomock.Any()).Return([]*entity.ContainerUser{
+		{ContainerID: "container-1", UserID: userID, Running: true},
+		{ContainerID: "container-2", UserID: userID, Running: true},
+	}, nil)
+	// The exited container is inspected again before its running flag is cleared.
+	mockRuntime.EXPECT().Inspect(ctx, "container-1").Return(exited, nil).Times(2)
+	mockRuntime.EXPECT().Inspect(ctx, "container-2").Return(running, nil)
+	mockContainerUserRepo.EXPECT().SetRunning(ctx, "container-1", false).Return(true, nil)
+	mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{RunningContainers: 1}).Return(nil)
+
+	list, err := service.ListContainers(ctx, member(userID), ContainerListQuery{})
 	assert.NoError(t, err)
-	assert.Len(t, containers, 2)
-	assert.Equal(t, expectedContainer1, containers[0])
-	assert.Equal(t, expectedContainer2, containers[1])
+	assert.Equal(t, []*entity.Container{exited, running}, list.Containers)
 }
 
 func TestContainerService_ListContainers_RepoError(t *testing.T) {
@@ -335,19 +888,20 @@ func TestContainerService_ListContainers_RepoError(t *testing.T) {
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 
-	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil)
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
 
 	ctx := context.Background()
 	userID := int64(1)
 	repoErr := errors.New("repo error")
 
-	mockContainerUserRepo.EXPECT().GetContainerIDsByUserID(ctx, userID).Return(nil, repoErr)
+	mockContainerUserRepo.EXPECT().CountByOwner(ctx, entity.Owner{UserID: userID}, nil).Return(0, repoErr)
 
-	containers, err := service.ListContainers(ctx, userID)
+	list, err := service.ListContainers(ctx, member(userID), ContainerListQuery{})
 	assert.Error(t, err)
 	assert.Equal(t, repoErr, err)
-	assert.Nil(t, containers)
+	assert.Nil(t, list)
 }
 
 func TestContainerService_ListContainers_InspectError(t *testing.T) {
@@ -356,8 +910,9 @@ func TestContainerService_ListContainers_InspectError(t *testing.T) {
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 
-	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil)
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
 
 	ctx := context.Background()
 	userID := int64(1)
@@ -367,12 +922,423 @@ func TestContainerService_ListContainers_InspectError(t *testing.T) {
 	expectedContainer1 := &entity.Container{ID: containerID1, Image: "test-image-1"}
 	inspectErr := errors.New("inspect error")
 
-	mockContainerUserRepo.EXPECT().GetContainerIDsByUserID(ctx, userID).Return([]string{containerID1, containerID2}, nil)
+	mockContainerUserRepo.EXPECT().CountByOwner(ctx, entity.Owner{UserID: userID}, nil).Return(2, nil)
+	mockContainerUserRepo.EXPECT().GetPageByOwner(ctx, entity.Owner{UserID: userID}, infrastructure.ContainerUserPage{Sort: infrastructure.ContainerSortCreatedAt, Limit: DefaultContainerListLimit + 1}).Return([]*entity.ContainerUser{{ContainerID: containerID1, UserID: userID}, {ContainerID: containerID2, UserID: userID}}, nil)
 	mockRuntime.EXPECT().Inspect(ctx, containerID1).Return(expectedContainer1, nil)
 	mockRuntime.EXPECT().Inspect(ctx, containerID2).Return(nil, inspectErr)
 
-	containers, err := service.ListContainers(ctx, userID)
+	list, err := service.ListContainers(ctx, member(userID), ContainerListQuery{})
 	assert.NoError(t, err)
-	assert.Len(t, containers, 1)
-	assert.Equal(t, expectedContainer1, containers[0])
+	assert.Len(t, list.Containers, 1)
+	assert.Equal(t, expectedContainer1, list.Containers[0])
+	assert.Equal(t, []*ContainerFailure{{ID: containerID2, Err: inspectErr}}, list.Failed)
+}
+
+func TestContainerService_ListContainers_Filter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	userID := int64(1)
+	filter := infrastructure.ContainerFilter{
+		Labels: map[string]string{"env": "prod"},
+		Status: "running",
+	}
+	expectedContainer := &entity.Container{ID: "container-2", Labels: map[string]string{"env": "prod"}}
+
+	mockContainerUserRepo.EXPECT().GetContainerIDsByOwner(ctx, entity.Owner{UserID: userID}).Return([]string{"container-1", "container-2"}, nil)
+	mockRuntime.EXPECT().ListIDs(ctx, infrastructure.ContainerFilter{
+		IDs:    []string{"container-1", "container-2"},
+		Labels: filter.Labels,
+		Status: filter.Status,
+	}).Return([]string{"container-2"}, nil)
+	mockContainerUserRepo.EXPECT().CountByOwner(ctx, entity.Owner{UserID: userID}, []string{"container-2"}).Return(1, nil)
+	mockContainerUserRepo.EXPECT().GetPageByOwner(ctx, entity.Owner{UserID: userID}, infrastructure.ContainerUserPage{
+		IDs:   []string{"container-2"},
+		Sort:  infrastructure.ContainerSortCreatedAt,
+		Limit: DefaultContainerListLimit + 1,
+	}).Return([]*entity.ContainerUser{{ContainerID: "container-2", UserID: userID}}, nil)
+	mockRuntime.EXPECT().Inspect(ctx, "container-2").Return(expectedContainer, nil)
+
+	list, err := service.ListContainers(ctx, member(userID), ContainerListQuery{Filter: filter})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, list.Total)
+	assert.Equal(t, []*entity.Container{expectedContainer}, list.Containers)
+}
+
+func TestContainerService_ListContainers_FilterNoContainers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	userID := int64(1)
+
+	// Without owned containers the runtime must not be asked, since an empty ID filter matches everything.
+	mockContainerUserRepo.EXPECT().GetContainerIDsByOwner(ctx, entity.Owner{UserID: userID}).Return(nil, nil)
+
+	list, err := service.ListContainers(ctx, member(userID), ContainerListQuery{Filter: infrastructure.ContainerFilter{Image: "alpine"}})
+	assert.NoError(t, err)
+	assert.Empty(t, list.Containers)
+	assert.Zero(t, list.Total)
+}
+
+func TestContainerService_ListContainers_InvalidStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	list, err := service.ListContainers(context.Background(), member(1), ContainerListQuery{Filter: infrastructure.ContainerFilter{Status: "sleeping"}})
+	assert.Error(t, err)
+	var customErr *internalErrors.CustomError
+	assert.ErrorAs(t, err, &customErr)
+	assert.Equal(t, internalErrors.InvalidContainerFilter.Message, customErr.Message)
+	assert.Nil(t, list)
+}
+
+func TestContainerService_ListContainers_Paginate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	userID := int64(1)
+	web := &entity.ContainerUser{ContainerID: "container-1", UserID: userID, Name: "web", CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	db := &entity.ContainerUser{ContainerID: "container-2", UserID: userID, Name: "db", CreatedAt: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)}
+
+	mockContainerUserRepo.EXPECT().CountByOwner(ctx, entity.Owner{UserID: userID}, nil).Return(2, nil).Times(2)
+	mockContainerUserRepo.EXPECT().GetPageByOwner(ctx, entity.Owner{UserID: userID}, infrastructure.ContainerUserPage{
+		Sort:       infrastructure.ContainerSortName,
+		Descending: true,
+		Limit:      2,
+	}).Return([]*entity.ContainerUser{web, db}, nil)
+	mockContainerUserRepo.EXPECT().GetPageByOwner(ctx, entity.Owner{UserID: userID}, infrastructure.ContainerUserPage{
+		Sort:       infrastructure.ContainerSortName,
+		Descending: true,
+		Limit:      2,
+		After:      &entity.ContainerUser{ContainerID: web.ContainerID, Name: web.Name, CreatedAt: web.CreatedAt},
+	}).Return([]*entity.ContainerUser{db}, nil)
+	mockRuntime.EXPECT().Inspect(ctx, web.ContainerID).Return(&entity.Container{ID: web.ContainerID}, nil)
+	mockRuntime.EXPECT().Inspect(ctx, db.ContainerID).Return(&entity.Container{ID: db.ContainerID}, nil)
+
+	query := ContainerListQuery{Sort: infrastructure.ContainerSortName, Descending: true, Limit: 1}
+	list, err := service.ListContainers(ctx, member(userID), query)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, list.Total)
+	assert.Len(t, list.Containers, 1)
+	assert.Equal(t, "web", list.Containers[0].Name)
+	assert.NotEmpty(t, list.NextCursor)
+
+	query.Cursor = list.NextCursor
+	list, err = service.ListContainers(ctx, member(userID), query)
+	assert.NoError(t, err)
+	assert.Len(t, list.Containers, 1)
+	assert.Equal(t, "db", list.Containers[0].Name)
+	assert.Empty(t, list.NextCursor)
+}
+
+func TestContainerService_ListContainers_SortByStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	userID := int64(1)
+	inspectErr := errors.New("inspect error")
+
+	containerUsers := []*entity.ContainerUser{
+		{ContainerID: "container-1", UserID: userID},
+		{ContainerID: "container-2", UserID: userID},
+		{ContainerID: "container-3", UserID: userID},
+	}
+	mockContainerUserRepo.EXPECT().GetByOwner(ctx, entity.Owner{UserID: userID}).Return(containerUsers, nil).Times(2)
+	// The statuses come from one runtime call per page. The runtime no longer knows container-3.
+	mockRuntime.EXPECT().ListStatuses(ctx, infrastructure.ContainerFilter{IDs: []string{"container-1", "container-2", "container-3"}}).Return(map[string]container.ContainerState{
+		"container-1": container.StateRunning,
+		"container-2": container.StateExited,
+	}, nil).Times(2)
+	// Only the containers of each page are inspected.
+	mockRuntime.EXPECT().Inspect(ctx, "container-3").Return(nil, inspectErr)
+	mockRuntime.EXPECT().Inspect(ctx, "container-2").Return(&entity.Container{ID: "container-2", Status: container.StateExited}, nil)
+	mockRuntime.EXPECT().Inspect(ctx, "container-1").Return(&entity.Container{ID: "container-1", Status: container.StateRunning}, nil)
+
+	query := ContainerListQuery{Sort: infrastructure.ContainerSortStatus, Limit: 2}
+	list, err := service.ListContainers(ctx, member(userID), query)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, list.Total)
+	assert.Equal(t, []*ContainerFailure{{ID: "container-3", Err: inspectErr}}, list.Failed)
+	assert.Len(t, list.Containers, 1)
+	assert.Equal(t, "container-2", list.Containers[0].ID)
+	assert.NotEmpty(t, list.NextCursor)
+
+	query.Cursor = list.NextCursor
+	list, err = service.ListContainers(ctx, member(userID), query)
+	assert.NoError(t, err)
+	assert.Empty(t, list.Failed)
+	assert.Len(t, list.Containers, 1)
+	assert.Equal(t, "container-1", list.Containers[0].ID)
+	assert.Empty(t, list.NextCursor)
+}
+
+func TestContainerService_ListContainers_SortByStatusFiltered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	userID := int64(1)
+
+	mockContainerUserRepo.EXPECT().GetByOwner(ctx, entity.Owner{UserID: userID}).Return([]*entity.ContainerUser{
+		{ContainerID: "container-1", UserID: userID},
+		{ContainerID: "container-2", UserID: userID},
+	}, nil)
+	// The runtime filters and reports the statuses in the same call.
+	mockRuntime.EXPECT().ListStatuses(ctx, infrastructure.ContainerFilter{IDs: []string{"container-1", "container-2"}, Image: "nginx"}).Return(map[string]container.ContainerState{
+		"container-2": container.StateRunning,
+	}, nil)
+	mockRuntime.EXPECT().Inspect(ctx, "container-2").Return(&entity.Container{ID: "container-2", Status: container.StateRunning}, nil)
+
+	list, err := service.ListContainers(ctx, member(userID), ContainerListQuery{Sort: infrastructure.ContainerSortStatus, Filter: infrastructure.ContainerFilter{Image: "nginx"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, list.Total)
+	assert.Empty(t, list.Failed)
+	assert.Len(t, list.Containers, 1)
+	assert.Equal(t, "container-2", list.Containers[0].ID)
+	assert.Empty(t, list.NextCursor)
+}
+
+func TestContainerService_ListContainers_InvalidQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	nameCursor := encodeContainerCursor(ContainerListQuery{Sort: infrastructure.ContainerSortName}, &containerCursor{ID: "container-1", Name: "web"})
+
+	tests := []struct {
+		name  string
+		query ContainerListQuery
+		err   *internalErrors.CustomError
+	}{
+		{name: "unknown sort", query: ContainerListQuery{Sort: "image"}, err: internalErrors.InvalidContainerFilter},
+		{name: "limit too large", query: ContainerListQuery{Limit: MaxContainerListLimit + 1}, err: internalErrors.InvalidContainerFilter},
+		{name: "malformed cursor", query: ContainerListQuery{Cursor: "not a cursor"}, err: internalErrors.InvalidCursor},
+		{name: "cursor of another sort", query: ContainerListQuery{Cursor: nameCursor}, err: internalErrors.InvalidCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := service.ListContainers(context.Background(), member(1), tt.query)
+			var customErr *internalErrors.CustomError
+			assert.ErrorAs(t, err, &customErr)
+			assert.Equal(t, tt.err.Message, customErr.Message)
+			assert.Nil(t, list)
+		})
+	}
+}
+
+func TestContainerService_CreateContainer_NameConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	mockJobRepo := mocks.NewMockJobRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	userID := int64(1)
+	options := infrastructure.ContainerCreateOptions{
+		Image: "test-image",
+		Name:  "web",
+	}
+
+	mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, "web").Return(&entity.ContainerUser{ContainerID: "container-1", UserID: userID, Name: "web"}, nil)
+
+	jobID, err := service.CreateContainer(ctx, member(userID), options)
+	assert.Equal(t, internalErrors.ContainerNameConflict, err)
+	assert.Empty(t, jobID)
+}
+
+func TestContainerService_StartContainer_ByName(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	userID := int64(1)
+
+	mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, "web").Return(&entity.ContainerUser{ContainerID: "container-123", UserID: userID, Name: "web", Running: true}, nil)
+	mockContainerUserRepo.EXPECT().SetRunning(ctx, "container-123", true).Return(false, nil)
+	mockRuntime.EXPECT().Start(ctx, "container-123").Return(nil)
+
+	err := service.StartContainer(ctx, member(userID), "web")
+	assert.NoError(t, err)
+}
+
+func TestContainerService_RenameContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	userID := int64(1)
+	containerID := "container-123"
+
+	t.Run("success", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: userID}, nil)
+		mockContainerUserRepo.EXPECT().UpdateName(ctx, containerID, "api").Return(nil)
+
+		err := service.RenameContainer(ctx, member(userID), containerID, "api")
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		err := service.RenameContainer(ctx, member(userID), containerID, "../api")
+		assert.Equal(t, internalErrors.InvalidContainerName, err)
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: 2}, nil)
+
+		err := service.RenameContainer(ctx, member(userID), containerID, "api")
+		assert.EqualError(t, err, "permission denied")
+	})
+
+	t.Run("name conflict", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: userID}, nil)
+		mockContainerUserRepo.EXPECT().UpdateName(ctx, containerID, "api").Return(internalErrors.ContainerNameConflict)
+
+		err := service.RenameContainer(ctx, member(userID), containerID, "api")
+		assert.Equal(t, internalErrors.ContainerNameConflict, err)
+	})
+}
+
+func TestContainerService_ListContainers_Cached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+
+	ctx := context.Background()
+	userID := int64(1)
+	containerID := "container-1"
+
+	mockContainerUserRepo.EXPECT().CountByOwner(ctx, entity.Owner{UserID: userID}, nil).Return(1, nil).Times(3)
+	mockContainerUserRepo.EXPECT().GetPageByOwner(ctx, entity.Owner{UserID: userID}, gomock.Any()).Return([]*entity.ContainerUser{{ContainerID: containerID, UserID: userID, Name: "web"}}, nil).Times(3)
+	mockContainerUserRepo.EXPECT().FindByIDOrName(ctx, userID, containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: userID}, nil)
+	// The second listing is served from the cache, and stopping the container invalidates it.
+	mockRuntime.EXPECT().Inspect(ctx, containerID).Return(&entity.Container{ID: containerID, Status: "running"}, nil)
+	mockRuntime.EXPECT().Stop(ctx, containerID).Return(nil)
+	mockContainerUserRepo.EXPECT().SetRunning(ctx, containerID, false).Return(false, nil)
+	mockRuntime.EXPECT().Inspect(ctx, containerID).Return(&entity.Container{ID: containerID, Status: "exited"}, nil)
+
+	list, err := service.ListContainers(ctx, member(userID), ContainerListQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, "web", list.Containers[0].Name)
+
+	list, err = service.ListContainers(ctx, member(userID), ContainerListQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, container.StateRunning, list.Containers[0].Status)
+
+	assert.NoError(t, service.StopContainer(ctx, member(userID), containerID))
+
+	list, err = service.ListContainers(ctx, member(userID), ContainerListQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, container.StateExited, list.Containers[0].Status)
+}
+
+func BenchmarkContainerService_ListContainers(b *testing.B) {
+	const containerCount = 200
+
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+
+	userID := int64(1)
+	containerUsers := make([]*entity.ContainerUser, 0, containerCount)
+	for i := 0; i < containerCount; i++ {
+		containerUsers = append(containerUsers, &entity.ContainerUser{ContainerID: fmt.Sprintf("container-%d", i), UserID: userID})
+	}
+
+	mockContainerUserRepo.EXPECT().CountByOwner(gomock.Any(), entity.Owner{UserID: userID}, nil).Return(containerCount, nil).AnyTimes()
+	mockContainerUserRepo.EXPECT().GetPageByOwner(gomock.Any(), entity.Owner{UserID: userID}, gomock.Any()).Return(containerUsers, nil).AnyTimes()
+	// Simulate the latency of a round trip to the Docker daemon.
+	mockRuntime.EXPECT().Inspect(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id string) (*entity.Container, error) {
+		time.Sleep(time.Millisecond)
+		return &entity.Container{ID: id, Status: container.StateRunning}, nil
+	}).AnyTimes()
+
+	ctx := context.Background()
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+			if _, err := service.ListContainers(ctx, member(userID), ContainerListQuery{Limit: MaxContainerListLimit}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		service := NewContainerService(mockRuntime, mockContainerUserRepo, nil, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil))
+		for i := 0; i < b.N; i++ {
+			if _, err := service.ListContainers(ctx, member(userID), ContainerListQuery{Limit: MaxContainerListLimit}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
 }
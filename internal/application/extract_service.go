@@ -0,0 +1,405 @@
+package application
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The steps of an archive extraction job, in the order they run.
+const (
+	archiveExtractionStepScan    = "scan"
+	archiveExtractionStepExtract = "extract"
+)
+
+// extractProgressInterval is how often a running extraction job stores its progress.
+const extractProgressInterval = time.Second
+
+// archiveExtraction is the payload of an archive extraction job.
+type archiveExtraction struct {
+	Path          string `json:"path"`
+	To            string `json:"to"`
+	TeamID        int64  `json:"team_id,omitempty"`
+	RemoveArchive bool   `json:"remove_archive,omitempty"`
+}
+
+// archiveExtractionResult is the result of an archive extraction job.
+type archiveExtractionResult struct {
+	Files   int   `json:"files"`
+	Folders int   `json:"folders"`
+	Size    int64 `json:"size"`
+	// Skipped counts the entries that are neither files, folders nor links, like named pipes.
+	Skipped int `json:"skipped"`
+}
+
+// ExtractService extracts archives among the stored files into folders, in jobs.
+type ExtractService struct {
+	fileService *FileService
+	jobRepo     infrastructure.JobRepository
+	limits      entity.ExtractLimits
+
+	// extracting holds the target folders of the running jobs, by owner.
+	extracting sync.Map
+}
+
+// NewExtractService creates a new instance of ExtractService.
+func NewExtractService(fileService *FileService, jobRepo infrastructure.JobRepository, limits entity.ExtractLimits) *ExtractService {
+	return &ExtractService{
+		fileService: fileService,
+		jobRepo:     jobRepo,
+		limits:      limits,
+	}
+}
+
+// ExtractArchive enqueues the extraction of a tar, tar.gz or zip archive of the caller, or of a
+// team of the caller when teamID is not zero, and returns the ID of the job. The archive is
+// extracted into the folder to, which must not exist yet, or next to the archive into a folder
+// named after it if to is empty. With removeArchive set, the archive is deleted once extracted.
+//
+// The job first scans the whole archive, and fails before writing anything if an entry would
+// leave the folder or is a link, or if the archive holds more files or expands to more than the
+// limits allow. The files are then written like uploads, against the caller's storage quota and
+// the upload policy. Other special entries, like named pipes, are skipped. If the job fails, the
+// folder is removed again.
+func (s *ExtractService) ExtractArchive(ctx context.Context, caller Caller, teamID int64, archivePath, to string, removeArchive bool) (string, error) {
+	archivePath, err := entity.CleanFilePath(archivePath)
+	if err != nil {
+		return "", err
+	}
+	if to == "" {
+		to = entity.ArchiveFolder(archivePath)
+	}
+	to, err = entity.CleanFilePath(to)
+	if err != nil {
+		return "", err
+	}
+	owner := entity.Owner{UserID: caller.UserID, TeamID: teamID}
+	if err := s.fileService.authorizer.authorize(ctx, caller, entity.ActionWrite, owner); err != nil {
+		return "", err
+	}
+
+	// The format is checked right away, so that the caller does not have to wait for the job to
+	// learn that the file is no archive.
+	file, _, err := s.fileService.OpenFile(ctx, caller, teamID, archivePath)
+	if err != nil {
+		return "", err
+	}
+	_, err = detectArchiveFormat(file)
+	file.Close()
+	if err != nil {
+		return "", err
+	}
+
+	// Since the folder is new, everything in it can be removed if the job fails.
+	if info, err := s.fileService.fileStorage.StatFile(owner, to); err != nil {
+		return "", err
+	} else if info != nil {
+		return "", errors.FileExists
+	}
+	if files, err := s.fileService.fileStorage.ListFiles(owner, to, false); err != nil {
+		return "", err
+	} else if files != nil {
+		return "", errors.FileExists
+	}
+
+	key := extractionKey(owner, to)
+	if _, running := s.extracting.LoadOrStore(key, struct{}{}); running {
+		return "", errors.ExtractionInProgress
+	}
+
+	extraction := archiveExtraction{Path: archivePath, To: to, TeamID: teamID, RemoveArchive: removeArchive}
+	payload, err := json.Marshal(extraction)
+	if err != nil {
+		s.extracting.Delete(key)
+		return "", err
+	}
+	job := &entity.Job{
+		ID:        uuid.New().String(),
+		Type:      entity.JobTypeArchiveExtraction,
+		Status:    entity.JobStatusPending,
+		Payload:   payload,
+		UserID:    caller.UserID,
+		TeamID:    teamID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.jobRepo.Create(context.Background(), job); err != nil {
+		s.extracting.Delete(key)
+		return "", err
+	}
+
+	go s.runExtractArchiveJob(job, caller, extraction)
+
+	return job.ID, nil
+}
+
+// ExtractUpload enqueues the extraction of an archive that was just uploaded, like ExtractArchive
+// into the default folder and removing the archive once extracted. If the extraction cannot be
+// enqueued, the archive is deleted right away, so that nothing of the upload is left.
+func (s *ExtractService) ExtractUpload(ctx context.Context, caller Caller, teamID int64, archivePath string) (string, error) {
+	jobID, err := s.ExtractArchive(ctx, caller, teamID, archivePath, "", true)
+	if err != nil {
+		if deleteErr := s.fileService.DeleteFile(ctx, caller, teamID, archivePath, false); deleteErr != nil {
+			log.Printf("failed to delete the archive %s that cannot be extracted: %v", archivePath, deleteErr)
+		}
+		return "", err
+	}
+	return jobID, nil
+}
+
+func (s *ExtractService) runExtractArchiveJob(job *entity.Job, caller Caller, extraction archiveExtraction) {
+	defer s.extracting.Delete(extractionKey(job.Owner(), extraction.To))
+	ctx := context.Background()
+
+	job.Status = entity.JobStatusRunning
+	job.Progress = &entity.JobProgress{Step: archiveExtractionStepScan}
+	if !s.updateJob(ctx, job) {
+		return
+	}
+
+	total, err := s.scanArchive(ctx, caller, job, extraction)
+	if err != nil {
+		s.failJob(ctx, job, err)
+		return
+	}
+
+	job.Progress = &entity.JobProgress{Step: archiveExtractionStepExtract, Total: total}
+	s.updateJob(ctx, job)
+	result, err := s.extractArchive(ctx, caller, job, extraction)
+	if err != nil {
+		if deleteErr := s.fileService.DeleteFile(ctx, caller, extraction.TeamID, extraction.To, true); deleteErr != nil && !errors.FileNotFound.Is(deleteErr) {
+			log.Printf("failed to remove %s after extraction job %s failed: %v", extraction.To, job.ID, deleteErr)
+		}
+		s.failJob(ctx, job, err)
+		return
+	}
+	if extraction.RemoveArchive {
+		if err := s.fileService.DeleteFile(ctx, caller, extraction.TeamID, extraction.Path, false); err != nil {
+			log.Printf("failed to delete the archive %s after extraction job %s: %v", extraction.Path, job.ID, err)
+		}
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		s.failJob(ctx, job, err)
+		return
+	}
+	job.Status = entity.JobStatusCompleted
+	job.Progress.Done = job.Progress.Total
+	job.Result = resultJSON
+	s.updateJob(ctx, job)
+}
+
+// scanArchive checks every entry of an archive before anything is written, and returns how many
+// files and folders there are to extract.
+func (s *ExtractService) scanArchive(ctx context.Context, caller Caller, job *entity.Job, extraction archiveExtraction) (int, error) {
+	archive, file, budget, err := s.openArchive(ctx, caller, extraction)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	count := 0
+	lastProgress := time.Now()
+	for {
+		entry, err := archive.next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if _, err := archiveEntryPath(extraction.To, entry.name); err != nil {
+			return 0, err
+		}
+		// Limits are checked entry by entry, so that the content of a file too large is never
+		// decompressed, not even to skip it.
+		if err := budget.add(entry); err != nil {
+			return 0, err
+		}
+		if entry.isDir || entry.regular {
+			count++
+		}
+		if time.Since(lastProgress) >= extractProgressInterval {
+			lastProgress = time.Now()
+			job.Progress.Done = count
+			s.updateJob(ctx, job)
+		}
+	}
+}
+
+// extractArchive writes the files and folders of an archive. The limits are checked again, as
+// the archive may have been replaced since it was scanned.
+func (s *ExtractService) extractArchive(ctx context.Context, caller Caller, job *entity.Job, extraction archiveExtraction) (*archiveExtractionResult, error) {
+	archive, file, budget, err := s.openArchive(ctx, caller, extraction)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if err := s.fileService.CreateFolder(ctx, caller, extraction.TeamID, extraction.To); err != nil {
+		return nil, err
+	}
+
+	result := &archiveExtractionResult{}
+	lastProgress := time.Now()
+	for {
+		entry, err := archive.next()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		path, err := archiveEntryPath(extraction.To, entry.name)
+		if err != nil {
+			return nil, err
+		}
+		if err := budget.add(entry); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case entry.isDir:
+			if err := s.fileService.CreateFolder(ctx, caller, extraction.TeamID, path); err != nil {
+				return nil, archiveEntryError(entry.name, err)
+			}
+			result.Folders++
+		case entry.regular:
+			content, err := entry.open()
+			if err != nil {
+				return nil, archiveEntryError(entry.name, err)
+			}
+			record, err := s.fileService.UploadFile(ctx, caller, extraction.TeamID, path, entry.size, content)
+			content.Close()
+			if err != nil {
+				return nil, archiveEntryError(entry.name, err)
+			}
+			result.Files++
+			result.Size += record.Size
+		default:
+			result.Skipped++
+			continue
+		}
+
+		if time.Since(lastProgress) >= extractProgressInterval {
+			lastProgress = time.Now()
+			job.Progress.Done = result.Files + result.Folders
+			s.updateJob(ctx, job)
+		}
+	}
+}
+
+// openArchive opens the archive of an extraction, along with the budget its entries are checked
+// against. The caller has to close the returned file.
+func (s *ExtractService) openArchive(ctx context.Context, caller Caller, extraction archiveExtraction) (archiveReader, io.Closer, *extractBudget, error) {
+	file, info, err := s.fileService.OpenFile(ctx, caller, extraction.TeamID, extraction.Path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	format, err := detectArchiveFormat(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, nil, err
+	}
+	archive, err := openArchive(format, file, info.Size)
+	if err != nil {
+		file.Close()
+		return nil, nil, nil, err
+	}
+	budget := &extractBudget{limits: s.limits, policy: s.fileService.policy, archiveSize: info.Size}
+	return archive, file, budget, nil
+}
+
+func (s *ExtractService) failJob(ctx context.Context, job *entity.Job, err error) {
+	log.Printf("archive extraction job %s of user %d failed at %s: %v", job.ID, job.UserID, job.Progress.Step, err)
+	job.Status = entity.JobStatusFailed
+	job.Error = err.Error()
+	s.updateJob(ctx, job)
+}
+
+// updateJob stores the job and reports whether that succeeded.
+func (s *ExtractService) updateJob(ctx context.Context, job *entity.Job) bool {
+	job.UpdatedAt = time.Now()
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		log.Printf("failed to update job %s to %s: %v", job.ID, job.Status, err)
+		return false
+	}
+	return true
+}
+
+// extractBudget counts the entries of an archive and the size of their content against the
+// limits. Every entry counts, also those that are skipped, as their content is still read past.
+// Links are rejected rather than skipped, since the files would not extract as the archive meant.
+type extractBudget struct {
+	limits      entity.ExtractLimits
+	policy      entity.UploadPolicy
+	archiveSize int64
+	entries     int
+	size        int64
+}
+
+func (b *extractBudget) add(entry *archiveEntry) error {
+	b.entries++
+	if err := b.limits.CheckFiles(b.entries); err != nil {
+		return err
+	}
+	if entry.link {
+		return errors.InvalidArchiveEntry.New("archive entry " + strconv.Quote(entry.name) + " is a link")
+	}
+	if entry.regular {
+		if err := b.policy.CheckSize(entry.size); err != nil {
+			return archiveEntryError(entry.name, err)
+		}
+	}
+	if entry.size > math.MaxInt64-b.size {
+		return errors.ArchiveTooLarge
+	}
+	b.size += entry.size
+	return b.limits.CheckSize(b.size, b.archiveSize)
+}
+
+// archiveEntryPath returns the path an entry of an archive is extracted to in the folder to. It
+// fails with errors.InvalidArchiveEntry for names that would leave the folder, like "../x" or
+// "/etc/x", whatever the folders or links extracted before. Entries naming the folder itself,
+// like "./", are extracted to the folder.
+func archiveEntryPath(to, name string) (string, error) {
+	if strings.HasPrefix(name, "/") {
+		return "", errors.InvalidArchiveEntry.New("archive entry " + strconv.Quote(name) + " has an absolute path")
+	}
+	path, err := entity.CleanFilePath(to + "/" + name)
+	if err != nil {
+		return "", errors.InvalidArchiveEntry.New("archive entry " + strconv.Quote(name) + ": " + err.Error())
+	}
+	return path, nil
+}
+
+// archiveEntryError names the entry of an archive an error is about, keeping the kind of error.
+func archiveEntryError(name string, err error) error {
+	var customErr *errors.CustomError
+	if stderrors.As(err, &customErr) {
+		return customErr.New(name + ": " + err.Error())
+	}
+	return err
+}
+
+// extractionKey identifies the target folder of an extraction among those of all owners. The
+// folders of a team are shared by its members.
+func extractionKey(owner entity.Owner, to string) string {
+	if owner.TeamID != 0 {
+		return "team:" + strconv.FormatInt(owner.TeamID, 10) + ":" + to
+	}
+	return "user:" + strconv.FormatInt(owner.UserID, 10) + ":" + to
+}
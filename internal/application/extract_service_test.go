@@ -0,0 +1,274 @@
+package application
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	internalErrors "container-manager/internal/errors"
+	"container-manager/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// tarGzArchive returns a gzip compressed tar archive holding the given headers, where regular
+// files hold content.
+func tarGzArchive(t *testing.T, headers []*tar.Header, content string) []byte {
+	var buf bytes.Buffer
+	compressed := gzip.NewWriter(&buf)
+	_, err := compressed.Write(tarArchive(t, headers, content))
+	require.NoError(t, err)
+	require.NoError(t, compressed.Close())
+	return buf.Bytes()
+}
+
+// zipArchive returns a zip archive holding files by name, and a folder for names ending in "/".
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func TestExtractService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tempDir := t.TempDir()
+	mockJobRepo := mocks.NewMockJobRepository(ctrl)
+	mockFileRepo := mocks.NewMockFileRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	fileStorage := repository.NewLocalFileStorage(tempDir)
+	limits := entity.ExtractLimits{MaxFiles: 10, MaxSize: 1 << 20, MaxRatio: 50}
+	fileService := NewFileService(fileStorage, mockFileRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil), entity.UploadPolicy{})
+	service := NewExtractService(fileService, mockJobRepo, limits)
+
+	ctx := context.Background()
+	userID := int64(1000)
+	owner := entity.Owner{UserID: userID}
+	userDir := filepath.Join(tempDir, "1000")
+
+	// The bookkeeping of the extracted files is not what these tests are about.
+	mockQuotaRepo.EXPECT().GetLimits(gomock.Any(), userID).Return(nil, nil).AnyTimes()
+	mockQuotaRepo.EXPECT().Reserve(gomock.Any(), userID, gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockQuotaRepo.EXPECT().Release(gomock.Any(), userID, gomock.Any()).Return(nil).AnyTimes()
+	mockFileRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockFileRepo.EXPECT().Delete(gomock.Any(), owner, gomock.Any()).Return(nil, nil).AnyTimes()
+
+	store := func(t *testing.T, name string, content []byte) {
+		_, err := fileStorage.SaveFile(owner, name, bytes.NewReader(content))
+		require.NoError(t, err)
+	}
+	// The job being run, which runJob waits for.
+	var created *entity.Job
+	var done chan entity.Job
+	var progress []entity.JobProgress
+	mockJobRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, job *entity.Job) error {
+		created = job
+		return nil
+	}).AnyTimes()
+	mockJobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, job *entity.Job) error {
+		progress = append(progress, *job.Progress)
+		if job.Status == entity.JobStatusCompleted || job.Status == entity.JobStatusFailed {
+			done <- *job
+		}
+		return nil
+	}).AnyTimes()
+
+	// runJob enqueues a job, and returns it once it finished along with the progress it reported.
+	runJob := func(t *testing.T, enqueue func() (string, error)) (*entity.Job, []entity.JobProgress) {
+		created, done, progress = nil, make(chan entity.Job, 1), nil
+
+		jobID, err := enqueue()
+		require.NoError(t, err)
+		require.Equal(t, created.ID, jobID)
+		select {
+		case job := <-done:
+			return &job, progress
+		case <-time.After(5 * time.Second):
+			t.Fatal("the job did not finish")
+			return nil, nil
+		}
+	}
+
+	t.Run("tar.gz upload", func(t *testing.T) {
+		store(t, "input/bundle.tar.gz", tarGzArchive(t, []*tar.Header{
+			{Typeflag: tar.TypeDir, Name: "./", Mode: 0o755},
+			{Typeflag: tar.TypeDir, Name: "./src/", Mode: 0o755},
+			{Typeflag: tar.TypeReg, Name: "./src/main.go", Size: 5, Mode: 0o644},
+			{Typeflag: tar.TypeReg, Name: "README", Size: 5, Mode: 0o644},
+			{Typeflag: tar.TypeFifo, Name: "src/pipe", Mode: 0o644},
+			{Typeflag: tar.TypeDir, Name: "empty/", Mode: 0o755},
+		}, "hello"))
+
+		job, progress := runJob(t, func() (string, error) {
+			return service.ExtractUpload(ctx, member(userID), 0, "input/bundle.tar.gz")
+		})
+		require.Equal(t, entity.JobStatusCompleted, job.Status, job.Error)
+		assert.Equal(t, entity.JobTypeArchiveExtraction, job.Type)
+		var result archiveExtractionResult
+		require.NoError(t, json.Unmarshal(job.Result, &result))
+		assert.Equal(t, archiveExtractionResult{Files: 2, Folders: 3, Size: 10, Skipped: 1}, result)
+		assert.Equal(t, archiveExtractionStepScan, progress[0].Step)
+		assert.Equal(t, entity.JobProgress{Step: archiveExtractionStepExtract, Done: 5, Total: 5}, progress[len(progress)-1])
+
+		content, err := os.ReadFile(filepath.Join(userDir, "input", "bundle", "src", "main.go"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(content))
+		assert.DirExists(t, filepath.Join(userDir, "input", "bundle", "empty"))
+		assert.NoFileExists(t, filepath.Join(userDir, "input", "bundle", "src", "pipe"))
+		// The uploaded archive is removed once extracted.
+		assert.NoFileExists(t, filepath.Join(userDir, "input", "bundle.tar.gz"))
+	})
+
+	t.Run("zip into a folder", func(t *testing.T) {
+		store(t, "site.zip", zipArchive(t, map[string]string{"index.html": "<html></html>", "css/": ""}))
+
+		job, _ := runJob(t, func() (string, error) {
+			return service.ExtractArchive(ctx, member(userID), 0, "site.zip", "www/site", false)
+		})
+		require.Equal(t, entity.JobStatusCompleted, job.Status, job.Error)
+
+		content, err := os.ReadFile(filepath.Join(userDir, "www", "site", "index.html"))
+		require.NoError(t, err)
+		assert.Equal(t, "<html></html>", string(content))
+		assert.DirExists(t, filepath.Join(userDir, "www", "site", "css"))
+		assert.FileExists(t, filepath.Join(userDir, "site.zip"))
+	})
+
+	t.Run("unsafe entries", func(t *testing.T) {
+		for name, archive := range map[string][]byte{
+			"zip-slip.zip":  zipArchive(t, map[string]string{"ok.txt": "ok", "../../escape.txt": "escape"}),
+			"absolute.tgz":  tarGzArchive(t, []*tar.Header{{Typeflag: tar.TypeReg, Name: "/etc/cron.d/job", Size: 5, Mode: 0o644}}, "hello"),
+			"backslash.zip": zipArchive(t, map[string]string{`..\escape.txt`: "escape"}),
+		} {
+			store(t, name, archive)
+
+			job, _ := runJob(t, func() (string, error) {
+				return service.ExtractArchive(ctx, member(userID), 0, name, "", false)
+			})
+			assert.Equal(t, entity.JobStatusFailed, job.Status, name)
+			assert.Contains(t, job.Error, "archive entry", name)
+			// Nothing is written, as the archive is scanned first.
+			assert.NoDirExists(t, filepath.Join(userDir, entity.ArchiveFolder(name)), name)
+		}
+		assert.NoFileExists(t, filepath.Join(tempDir, "escape.txt"))
+	})
+
+	t.Run("links", func(t *testing.T) {
+		var zipLink bytes.Buffer
+		archive := zip.NewWriter(&zipLink)
+		header := &zip.FileHeader{Name: "passwd"}
+		header.SetMode(os.ModeSymlink | 0o777)
+		w, err := archive.CreateHeader(header)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("/etc/passwd"))
+		require.NoError(t, err)
+		require.NoError(t, archive.Close())
+
+		for name, archive := range map[string][]byte{
+			// The same content many times over, as hard links to one file.
+			"hardlinks.tgz": tarGzArchive(t, []*tar.Header{
+				{Typeflag: tar.TypeReg, Name: "data", Size: 5, Mode: 0o644},
+				{Typeflag: tar.TypeLink, Name: "data1", Linkname: "data"},
+				{Typeflag: tar.TypeLink, Name: "data2", Linkname: "data"},
+			}, "hello"),
+			// A link the next entry is written through.
+			"symlink.tar": tarArchive(t, []*tar.Header{
+				{Typeflag: tar.TypeSymlink, Name: "etc", Linkname: "/etc"},
+				{Typeflag: tar.TypeReg, Name: "etc/cron.d/job", Size: 5, Mode: 0o644},
+			}, "hello"),
+			"symlink.zip": zipLink.Bytes(),
+		} {
+			store(t, name, archive)
+
+			job, _ := runJob(t, func() (string, error) {
+				return service.ExtractArchive(ctx, member(userID), 0, name, "", false)
+			})
+			assert.Equal(t, entity.JobStatusFailed, job.Status, name)
+			assert.Contains(t, job.Error, "is a link", name)
+			assert.NoDirExists(t, filepath.Join(userDir, entity.ArchiveFolder(name)), name)
+		}
+	})
+
+	t.Run("too many files", func(t *testing.T) {
+		files := map[string]string{}
+		for i := range 11 {
+			files["file"+strings.Repeat("x", i)] = "x"
+		}
+		store(t, "many.zip", zipArchive(t, files))
+
+		job, _ := runJob(t, func() (string, error) {
+			return service.ExtractArchive(ctx, member(userID), 0, "many.zip", "", false)
+		})
+		assert.Equal(t, entity.JobStatusFailed, job.Status)
+		assert.Equal(t, internalErrors.ArchiveTooManyFiles.Message, job.Error)
+	})
+
+	t.Run("decompression bomb", func(t *testing.T) {
+		zeros := strings.Repeat("\x00", 512<<10)
+		store(t, "bomb.tar.gz", tarGzArchive(t, []*tar.Header{{Typeflag: tar.TypeReg, Name: "zeros", Size: int64(len(zeros)), Mode: 0o644}}, zeros))
+
+		job, _ := runJob(t, func() (string, error) {
+			return service.ExtractArchive(ctx, member(userID), 0, "bomb.tar.gz", "", false)
+		})
+		assert.Equal(t, entity.JobStatusFailed, job.Status)
+		assert.Equal(t, "archive expands to more than 50 times its size", job.Error)
+		assert.NoDirExists(t, filepath.Join(userDir, "bomb"))
+	})
+
+	t.Run("a failing file removes the folder", func(t *testing.T) {
+		// The folder "a" cannot be created once a file of that name was extracted.
+		store(t, "conflict.tar", tarArchive(t, []*tar.Header{
+			{Typeflag: tar.TypeReg, Name: "a", Size: 5, Mode: 0o644},
+			{Typeflag: tar.TypeReg, Name: "a/b", Size: 5, Mode: 0o644},
+		}, "hello"))
+
+		job, _ := runJob(t, func() (string, error) {
+			return service.ExtractArchive(ctx, member(userID), 0, "conflict.tar", "", false)
+		})
+		assert.Equal(t, entity.JobStatusFailed, job.Status)
+		assert.True(t, strings.HasPrefix(job.Error, "a/b: "), job.Error)
+		assert.NoDirExists(t, filepath.Join(userDir, "conflict"))
+	})
+
+	t.Run("not an archive", func(t *testing.T) {
+		store(t, "notes.txt", []byte("just some notes"))
+
+		_, err := service.ExtractUpload(ctx, member(userID), 0, "notes.txt")
+		assert.Equal(t, internalErrors.UnsupportedArchive, err)
+		// The upload is not kept if it cannot be extracted.
+		assert.NoFileExists(t, filepath.Join(userDir, "notes.txt"))
+	})
+
+	t.Run("existing folder", func(t *testing.T) {
+		store(t, "site2.zip", zipArchive(t, map[string]string{"index.html": ""}))
+		require.NoError(t, fileStorage.MakeDir(owner, "site2"))
+
+		_, err := service.ExtractArchive(ctx, member(userID), 0, "site2.zip", "", false)
+		assert.Equal(t, internalErrors.FileExists, err)
+	})
+
+	t.Run("read only users", func(t *testing.T) {
+		_, err := service.ExtractArchive(ctx, Caller{UserID: userID, Role: entity.RoleReadOnly}, 0, "site.zip", "copy", false)
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+}
@@ -0,0 +1,53 @@
+package application
+
+import (
+	"container-manager/internal/domain/entity"
+	"strconv"
+	"sync"
+)
+
+// fileLocks serializes the writes to each file path, across the services that write files. A
+// write reads the size of the file it replaces or deletes to give it back to the storage quota,
+// and two concurrent writes to the same path would otherwise both give back the same file. Paths
+// are only locked within this process.
+var fileLocks = &pathLocks{held: map[string]*pathLock{}}
+
+type pathLocks struct {
+	mu   sync.Mutex
+	held map[string]*pathLock
+}
+
+type pathLock struct {
+	mu sync.Mutex
+	// waiters counts the holder and those waiting for the lock, which is dropped once none are left.
+	waiters int
+}
+
+// lock locks a path of the owner, and returns the function that unlocks it.
+func (l *pathLocks) lock(owner entity.Owner, path string) func() {
+	// Every member of a team writes the same team files.
+	key := "user/" + strconv.FormatInt(owner.UserID, 10) + "/" + path
+	if owner.TeamID != 0 {
+		key = "team/" + strconv.FormatInt(owner.TeamID, 10) + "/" + path
+	}
+
+	l.mu.Lock()
+	lock, ok := l.held[key]
+	if !ok {
+		lock = &pathLock{}
+		l.held[key] = lock
+	}
+	lock.waiters++
+	l.mu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		lock.waiters--
+		if lock.waiters == 0 {
+			delete(l.held, key)
+		}
+	}
+}
@@ -1,28 +1,363 @@
 package application
 
 import (
+	"bufio"
+	"container-manager/internal/domain/entity"
 	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
+	"context"
 	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
 )
 
+// sniffLength is how many bytes of a file http.DetectContentType looks at.
+const sniffLength = 512
+
 // FileService handles file-related business logic.
 type FileService struct {
-	fileStorage infrastructure.FileStorage
+	fileStorage  infrastructure.FileStorage
+	fileRepo     infrastructure.FileRepository
+	quotaService *QuotaService
+	authorizer   *Authorizer
+	policy       entity.UploadPolicy
 }
 
 // NewFileService creates a new instance of FileService.
-func NewFileService(fs infrastructure.FileStorage) *FileService {
+func NewFileService(fs infrastructure.FileStorage, fileRepo infrastructure.FileRepository, quotaService *QuotaService, authorizer *Authorizer, policy entity.UploadPolicy) *FileService {
 	return &FileService{
-		fileStorage: fs,
+		fileStorage:  fs,
+		fileRepo:     fileRepo,
+		quotaService: quotaService,
+		authorizer:   authorizer,
+		policy:       policy,
+	}
+}
+
+// MaxFileSize returns the size of the largest file that can be uploaded, unlimited if zero.
+func (s *FileService) MaxFileSize() int64 {
+	return s.policy.MaxFileSize
+}
+
+// UploadFile uploads a file for the caller, or for a team of the caller when teamID is not zero.
+// The filename may include folders, which are created as needed. The file is streamed from
+// fileContent, so its size is only known once all of it is read: maxSize bounds it, typically by
+// the length of the request, and is -1 if not known. The bound, or the largest file the policy
+// allows if that is smaller, is reserved against the caller's storage quota before anything is
+// written, and what the file does not use is given back afterwards. Without a bound no more than
+// the quota has left is reserved, and a file larger than that fails with errors.QuotaExceeded.
+// The type of the file is sniffed from its first bytes and checked against the policy before
+// anything is written as well. The returned record holds the checksum of what was written.
+func (s *FileService) UploadFile(ctx context.Context, caller Caller, teamID int64, filename string, maxSize int64, fileContent io.Reader) (*entity.FileRecord, error) {
+	filename, err := entity.CleanFilePath(filename)
+	if err != nil {
+		return nil, err
+	}
+	owner := entity.Owner{UserID: caller.UserID, TeamID: teamID}
+	if err := s.authorizer.authorize(ctx, caller, entity.ActionWrite, owner); err != nil {
+		return nil, err
+	}
+	userID := caller.UserID
+
+	limit := maxSize
+	if s.policy.MaxFileSize > 0 && (limit < 0 || limit > s.policy.MaxFileSize) {
+		limit = s.policy.MaxFileSize
+	}
+	if limit < 0 {
+		return nil, errors.ContentLengthRequired
+	}
+
+	// An existing file of the same name is replaced, so its size is given back once the upload
+	// succeeds. The path stays locked until then, so that no other write replaces it meanwhile.
+	unlock := fileLocks.lock(owner, filename)
+	defer unlock()
+	previousSize, err := s.fileStorage.FileSize(owner, filename)
+	if err != nil {
+		return nil, err
+	}
+	// The size of a team file counts against the member who uploaded it, possibly another one than
+	// the caller, so team files are not replaced.
+	if teamID != 0 && previousSize > 0 {
+		return nil, errors.FileExists
+	}
+
+	limited := &sizeLimitedReader{reader: fileContent, remaining: limit}
+	content := bufio.NewReaderSize(limited, sniffLength)
+	head, err := content.Peek(sniffLength)
+	if limited.exceeded {
+		return nil, errors.FileTooLarge
+	}
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	// An empty file has no type to check.
+	if len(head) > 0 {
+		if err := s.policy.CheckContentType(http.DetectContentType(head)); err != nil {
+			return nil, err
+		}
+	}
+
+	reserved := limit
+	if maxSize < 0 {
+		// Without a length the largest file allowed would be reserved, often more than the quota has
+		// left although the file fits. Only what is left is reserved then, and the file may not be
+		// any larger.
+		reserved, err = s.quotaService.reserveStorage(ctx, userID, limit)
+		if err != nil {
+			return nil, err
+		}
+		limited.remaining -= limit - reserved
+	} else if err := s.quotaService.reserve(ctx, userID, entity.QuotaResources{StorageBytes: limit}); err != nil {
+		return nil, err
+	}
+	// The quota is exceeded rather than the maximum size if the quota bounds the file.
+	var tooLarge error = errors.FileTooLarge
+	if reserved < limit {
+		tooLarge = errors.QuotaExceeded.New("storage quota exceeded")
+	}
+	if limited.remaining < 0 {
+		// What was read to sniff the type is larger already.
+		s.quotaService.release(ctx, userID, entity.QuotaResources{StorageBytes: reserved})
+		return nil, tooLarge
+	}
+
+	info, err := s.fileStorage.SaveFile(owner, filename, content)
+	if err != nil {
+		s.quotaService.release(ctx, userID, entity.QuotaResources{StorageBytes: reserved})
+		// The storage may wrap the error of the reader.
+		if limited.exceeded {
+			return nil, tooLarge
+		}
+		return nil, err
+	}
+
+	if unused := reserved - info.Size + previousSize; unused > 0 {
+		s.quotaService.release(ctx, userID, entity.QuotaResources{StorageBytes: unused})
+	}
+	return recordFile(ctx, s.fileRepo, owner, userID, info), nil
+}
+
+// sizeLimitedReader reads at most remaining bytes, and fails with errors.FileTooLarge if there
+// are more.
+type sizeLimitedReader struct {
+	reader    io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (r *sizeLimitedReader) Read(p []byte) (int, error) {
+	// One byte more than allowed is read, to tell a file of exactly the limit from a larger one.
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err := r.reader.Read(p)
+	if int64(n) > r.remaining {
+		r.exceeded = true
+		n = int(r.remaining)
+		r.remaining = 0
+		return n, errors.FileTooLarge
+	}
+	r.remaining -= int64(n)
+	return n, err
+}
+
+// recordFile records a file that was just written by uploadedBy. The file is stored by then, so a
+// failure to record it is only logged.
+func recordFile(ctx context.Context, fileRepo infrastructure.FileRepository, owner entity.Owner, uploadedBy int64, info *entity.FileInfo) *entity.FileRecord {
+	record := &entity.FileRecord{
+		Owner:       owner,
+		UploadedBy:  uploadedBy,
+		Path:        info.Name,
+		SHA256:      info.SHA256,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		UploadedAt:  time.Now(),
+	}
+	if err := fileRepo.Save(ctx, record); err != nil {
+		log.Printf("failed to record file %s: %v", record.Path, err)
+	}
+	return record
+}
+
+// ListFiles lists the files and folders in a folder of the caller, or of a team of the caller
+// when teamID is not zero. An empty dir lists the top folder, and recursive includes the content
+// of every folder below.
+func (s *FileService) ListFiles(ctx context.Context, caller Caller, teamID int64, dir string, recursive bool) ([]*entity.FileInfo, error) {
+	if dir != "" {
+		var err error
+		if dir, err = entity.CleanFilePath(dir); err != nil {
+			return nil, err
+		}
+	}
+	owner := entity.Owner{UserID: caller.UserID, TeamID: teamID}
+	if err := s.authorizer.authorize(ctx, caller, entity.ActionRead, owner); err != nil {
+		return nil, err
+	}
+
+	files, err := s.fileStorage.ListFiles(owner, dir, recursive)
+	if err != nil {
+		return nil, err
+	}
+	if files == nil {
+		return nil, errors.FolderNotFound
+	}
+	return files, nil
+}
+
+// OpenFile opens a file of the caller, or of a team of the caller, for download. The caller has
+// to close the returned file.
+func (s *FileService) OpenFile(ctx context.Context, caller Caller, teamID int64, filename string) (io.ReadSeekCloser, *entity.FileInfo, error) {
+	filename, err := entity.CleanFilePath(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	owner := entity.Owner{UserID: caller.UserID, TeamID: teamID}
+	if err := s.authorizer.authorize(ctx, caller, entity.ActionRead, owner); err != nil {
+		return nil, nil, err
+	}
+
+	file, info, err := s.fileStorage.OpenFile(owner, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	if file == nil {
+		return nil, nil, errors.FileNotFound
 	}
+	return file, info, nil
 }
 
-// UploadFile uploads a file for a specific user.
-func (s *FileService) UploadFile(userID int64, filename string, fileContent io.Reader) error {
-	_, err := s.fileStorage.SaveFile(userID, filename, fileContent)
+// DeleteFile deletes a file or a folder of the caller, or of a team of the caller. A folder has to
+// be empty unless recursive is set. The size of personal files is given back to the caller's
+// storage quota, and that of team files to the members who uploaded them, as recorded.
+func (s *FileService) DeleteFile(ctx context.Context, caller Caller, teamID int64, filename string, recursive bool) error {
+	filename, err := entity.CleanFilePath(filename)
 	if err != nil {
 		return err
 	}
+	owner := entity.Owner{UserID: caller.UserID, TeamID: teamID}
+	if err := s.authorizer.authorize(ctx, caller, entity.ActionWrite, owner); err != nil {
+		return err
+	}
+
+	unlock := fileLocks.lock(owner, filename)
+	defer unlock()
+	info, err := s.fileStorage.StatFile(owner, filename)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		return s.deleteFolder(ctx, caller, owner, filename, recursive)
+	}
+	if err := s.fileStorage.DeleteFile(owner, filename); err != nil {
+		return err
+	}
+	records := s.forgetFiles(ctx, owner, filename)
+
+	if teamID != 0 {
+		s.releaseTeamFiles(ctx, records)
+	} else if info.Size > 0 {
+		s.quotaService.release(ctx, caller.UserID, entity.QuotaResources{StorageBytes: info.Size})
+	}
+	return nil
+}
+
+// deleteFolder deletes a folder for DeleteFile. The files in it are listed before, to know the
+// storage to give back.
+func (s *FileService) deleteFolder(ctx context.Context, caller Caller, owner entity.Owner, dir string, recursive bool) error {
+	files, err := s.fileStorage.ListFiles(owner, dir, recursive)
+	if err != nil {
+		return err
+	}
+	if files == nil {
+		return errors.FileNotFound
+	}
+	if len(files) > 0 && !recursive {
+		return errors.FolderNotEmpty
+	}
+	if err := s.fileStorage.DeleteDir(owner, dir, recursive); err != nil {
+		return err
+	}
+	records := s.forgetFiles(ctx, owner, dir)
+
+	if owner.TeamID != 0 {
+		s.releaseTeamFiles(ctx, records)
+		return nil
+	}
+	var size int64
+	for _, file := range files {
+		size += file.Size
+	}
+	if size > 0 {
+		s.quotaService.release(ctx, caller.UserID, entity.QuotaResources{StorageBytes: size})
+	}
+	return nil
+}
 
+// releaseTeamFiles gives the size of deleted team files back to the members who uploaded them.
+// Files of members who deleted their account have no one to give their size back to.
+func (s *FileService) releaseTeamFiles(ctx context.Context, records []*entity.FileRecord) {
+	sizes := map[int64]int64{}
+	for _, record := range records {
+		if record.UploadedBy != 0 {
+			sizes[record.UploadedBy] += record.Size
+		}
+	}
+	for userID, size := range sizes {
+		if size > 0 {
+			s.quotaService.release(ctx, userID, entity.QuotaResources{StorageBytes: size})
+		}
+	}
+}
+
+// CreateFolder creates a folder, along with its parents, for the caller or for a team of the
+// caller.
+func (s *FileService) CreateFolder(ctx context.Context, caller Caller, teamID int64, dir string) error {
+	dir, err := entity.CleanFilePath(dir)
+	if err != nil {
+		return err
+	}
+	owner := entity.Owner{UserID: caller.UserID, TeamID: teamID}
+	if err := s.authorizer.authorize(ctx, caller, entity.ActionWrite, owner); err != nil {
+		return err
+	}
+	return s.fileStorage.MakeDir(owner, dir)
+}
+
+// MoveFile moves or renames a file or a folder of the caller, or of a team of the caller. Nothing
+// is replaced at the new path. Moved files keep counting against the same storage quota.
+func (s *FileService) MoveFile(ctx context.Context, caller Caller, teamID int64, from, to string) error {
+	from, err := entity.CleanFilePath(from)
+	if err != nil {
+		return err
+	}
+	to, err = entity.CleanFilePath(to)
+	if err != nil {
+		return err
+	}
+	if to == from || strings.HasPrefix(to, from+"/") {
+		return errors.InvalidFilename.New("the new path is the old one or inside of it")
+	}
+	owner := entity.Owner{UserID: caller.UserID, TeamID: teamID}
+	if err := s.authorizer.authorize(ctx, caller, entity.ActionWrite, owner); err != nil {
+		return err
+	}
+	if err := s.fileStorage.Move(owner, from, to); err != nil {
+		return err
+	}
+	if err := s.fileRepo.Move(ctx, owner, from, to); err != nil {
+		log.Printf("failed to move the records of %s to %s: %v", from, to, err)
+	}
 	return nil
 }
+
+// forgetFiles deletes the records of a deleted file, or of the files in a deleted folder, and
+// returns them. The files are gone by then, so a failure is only logged.
+func (s *FileService) forgetFiles(ctx context.Context, owner entity.Owner, path string) []*entity.FileRecord {
+	records, err := s.fileRepo.Delete(ctx, owner, path)
+	if err != nil {
+		log.Printf("failed to delete the records of %s: %v", path, err)
+	}
+	return records
+}
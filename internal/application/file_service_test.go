@@ -2,11 +2,21 @@ package application
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	internalErrors "container-manager/internal/errors"
+	"container-manager/internal/infrastructure/repository"
 
 	"go.uber.org/mock/gomock"
 )
@@ -16,28 +26,67 @@ func TestFileService_UploadFile(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockFileStorage := mocks.NewMockFileStorage(ctrl)
-	fileService := NewFileService(mockFileStorage)
+	mockFileRepo := mocks.NewMockFileRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	limits := entity.QuotaResources{StorageBytes: 100}
+	fileService := NewFileService(mockFileStorage, mockFileRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{DefaultLimits: limits}), NewAuthorizer(nil), entity.UploadPolicy{})
 
+	ctx := context.Background()
 	userID := int64(1000)
+	owner := entity.Owner{UserID: userID}
 	filename := "test_file.txt"
 	fileContent := "hello world"
+	size := int64(len(fileContent))
 	reader := bytes.NewBufferString(fileContent)
-	expectedPath := "/path/to/uploaded/file"
+	saved := &entity.FileInfo{
+		Name:        filename,
+		Size:        size,
+		ContentType: "text/plain; charset=utf-8",
+		SHA256:      "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+	}
+
+	expectReserve := func() *gomock.Call {
+		mockFileStorage.EXPECT().FileSize(owner, filename).Return(int64(0), nil)
+		mockQuotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil)
+		return mockQuotaRepo.EXPECT().Reserve(ctx, userID, entity.QuotaResources{StorageBytes: size}, limits)
+	}
 
 	t.Run("success", func(t *testing.T) {
-		mockFileStorage.EXPECT().SaveFile(userID, filename, gomock.Any()).Return(expectedPath, nil)
+		expectReserve().Return(nil)
+		mockFileStorage.EXPECT().SaveFile(owner, filename, gomock.Any()).Return(saved, nil)
+		var recorded *entity.FileRecord
+		mockFileRepo.EXPECT().Save(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, record *entity.FileRecord) error {
+			recorded = record
+			return nil
+		})
 
-		err := fileService.UploadFile(userID, filename, reader)
+		record, err := fileService.UploadFile(ctx, member(userID), 0, filename, size, reader)
 		if err != nil {
-			t.Errorf("UploadFile returned an error: %v", err)
+			t.Fatalf("UploadFile returned an error: %v", err)
+		}
+		if record != recorded || record.Owner != owner || record.UploadedBy != userID || record.Path != filename || record.Size != size || record.SHA256 != saved.SHA256 || record.ContentType != saved.ContentType || record.UploadedAt.IsZero() {
+			t.Errorf("UploadFile returned %+v, recorded %+v", record, recorded)
+		}
+	})
+
+	t.Run("failing to record the file is not an error", func(t *testing.T) {
+		expectReserve().Return(nil)
+		mockFileStorage.EXPECT().SaveFile(owner, filename, gomock.Any()).Return(saved, nil)
+		mockFileRepo.EXPECT().Save(ctx, gomock.Any()).Return(errors.New("connection refused"))
+
+		record, err := fileService.UploadFile(ctx, member(userID), 0, filename, size, bytes.NewBufferString(fileContent))
+		if err != nil || record.SHA256 != saved.SHA256 {
+			t.Errorf("UploadFile returned %+v, %v", record, err)
 		}
 	})
 
 	t.Run("fileStorage SaveFile error", func(t *testing.T) {
 		mockError := errors.New("storage error")
-		mockFileStorage.EXPECT().SaveFile(userID, filename, gomock.Any()).Return("", mockError)
+		expectReserve().Return(nil)
+		mockFileStorage.EXPECT().SaveFile(owner, filename, gomock.Any()).Return(nil, mockError)
+		mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{StorageBytes: size}).Return(nil)
 
-		err := fileService.UploadFile(userID, filename, reader)
+		_, err := fileService.UploadFile(ctx, member(userID), 0, filename, size, reader)
 		if err == nil {
 			t.Error("UploadFile did not return an error when SaveFile fails")
 		}
@@ -50,20 +99,634 @@ func TestFileService_UploadFile(t *testing.T) {
 		// Ensure gomock.Any() correctly matches io.Reader.
 		// A new reader is needed because the previous one might have been consumed.
 		newReader := bytes.NewBufferString(fileContent)
-		mockFileStorage.EXPECT().SaveFile(userID, filename, gomock.Any()).DoAndReturn(func(_ int64, _ string, r io.Reader) (string, error) {
+		expectReserve().Return(nil)
+		mockFileStorage.EXPECT().SaveFile(owner, filename, gomock.Any()).DoAndReturn(func(_ entity.Owner, _ string, r io.Reader) (*entity.FileInfo, error) {
 			readBytes, err := io.ReadAll(r)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 			if string(readBytes) != fileContent {
 				t.Errorf("mock received wrong content: got %q, want %q", string(readBytes), fileContent)
 			}
-			return expectedPath, nil
+			return saved, nil
 		})
+		mockFileRepo.EXPECT().Save(ctx, gomock.Any()).Return(nil)
+
+		_, err := fileService.UploadFile(ctx, member(userID), 0, filename, size, newReader)
+		if err != nil {
+			t.Errorf("UploadFile returned an error: %v", err)
+		}
+	})
+
+	t.Run("storage quota exceeded", func(t *testing.T) {
+		expectReserve().Return(internalErrors.QuotaExceeded)
+		mockQuotaRepo.EXPECT().GetUsage(ctx, userID).Return(&entity.QuotaResources{StorageBytes: 95}, nil)
+
+		_, err := fileService.UploadFile(ctx, member(userID), 0, filename, size, bytes.NewBufferString(fileContent))
+		if err == nil || err.Error() != "storage_bytes quota exceeded" {
+			t.Errorf("UploadFile returned wrong error when the quota is exceeded: got %v", err)
+		}
+	})
+
+	t.Run("replacing a file gives back its size", func(t *testing.T) {
+		mockFileStorage.EXPECT().FileSize(owner, filename).Return(int64(40), nil)
+		mockQuotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil)
+		mockQuotaRepo.EXPECT().Reserve(ctx, userID, entity.QuotaResources{StorageBytes: size}, limits).Return(nil)
+		mockFileStorage.EXPECT().SaveFile(owner, filename, gomock.Any()).Return(saved, nil)
+		mockFileRepo.EXPECT().Save(ctx, gomock.Any()).Return(nil)
+		mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{StorageBytes: 40}).Return(nil)
+
+		_, err := fileService.UploadFile(ctx, member(userID), 0, filename, size, bytes.NewBufferString(fileContent))
+		if err != nil {
+			t.Errorf("UploadFile returned an error: %v", err)
+		}
+	})
+
+	t.Run("team members upload to the team folder", func(t *testing.T) {
+		mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+		teamService := NewFileService(mockFileStorage, mockFileRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{DefaultLimits: limits}), NewAuthorizer(mockTeamRepo), entity.UploadPolicy{})
+		teamOwner := entity.Owner{UserID: userID, TeamID: 7}
 
-		err := fileService.UploadFile(userID, filename, newReader)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), userID).Return(&entity.TeamMember{TeamID: 7, UserID: userID, Role: entity.TeamRoleMember}, nil)
+		mockFileStorage.EXPECT().FileSize(teamOwner, filename).Return(int64(0), nil)
+		mockQuotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil)
+		mockQuotaRepo.EXPECT().Reserve(ctx, userID, entity.QuotaResources{StorageBytes: size}, limits).Return(nil)
+		mockFileStorage.EXPECT().SaveFile(teamOwner, filename, gomock.Any()).Return(saved, nil)
+		mockFileRepo.EXPECT().Save(ctx, gomock.Any()).Return(nil)
+
+		_, err := teamService.UploadFile(ctx, member(userID), 7, filename, size, bytes.NewBufferString(fileContent))
 		if err != nil {
 			t.Errorf("UploadFile returned an error: %v", err)
 		}
 	})
+
+	t.Run("team files are not replaced", func(t *testing.T) {
+		mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+		teamService := NewFileService(mockFileStorage, mockFileRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{DefaultLimits: limits}), NewAuthorizer(mockTeamRepo), entity.UploadPolicy{})
+
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), userID).Return(&entity.TeamMember{TeamID: 7, UserID: userID, Role: entity.TeamRoleOwner}, nil)
+		mockFileStorage.EXPECT().FileSize(entity.Owner{UserID: userID, TeamID: 7}, filename).Return(int64(40), nil)
+
+		_, err := teamService.UploadFile(ctx, member(userID), 7, filename, size, bytes.NewBufferString(fileContent))
+		if err != internalErrors.FileExists {
+			t.Errorf("expected %v, got %v", internalErrors.FileExists, err)
+		}
+	})
+
+	t.Run("team viewers cannot upload", func(t *testing.T) {
+		mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+		teamService := NewFileService(mockFileStorage, mockFileRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{DefaultLimits: limits}), NewAuthorizer(mockTeamRepo), entity.UploadPolicy{})
+
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), userID).Return(&entity.TeamMember{TeamID: 7, UserID: userID, Role: entity.TeamRoleViewer}, nil)
+
+		_, err := teamService.UploadFile(ctx, member(userID), 7, filename, size, bytes.NewBufferString(fileContent))
+		if err != internalErrors.PermissionDenied {
+			t.Errorf("expected %v, got %v", internalErrors.PermissionDenied, err)
+		}
+	})
+
+	t.Run("invalid filename", func(t *testing.T) {
+		_, err := fileService.UploadFile(ctx, member(userID), 0, "../1001/"+filename, size, bytes.NewBufferString(fileContent))
+		var customErr *internalErrors.CustomError
+		if !errors.As(err, &customErr) || customErr.Message != internalErrors.InvalidFilename.Message {
+			t.Errorf("expected %v, got %v", internalErrors.InvalidFilename, err)
+		}
+	})
+
+	t.Run("names are normalised", func(t *testing.T) {
+		// "é" as "e" followed by a combining acute accent, as macOS writes it
+		decomposed, composed := "cafe\u0301.txt", "caf\u00e9.txt"
+		mockFileStorage.EXPECT().FileSize(owner, composed).Return(int64(0), nil)
+		mockQuotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil)
+		mockQuotaRepo.EXPECT().Reserve(ctx, userID, entity.QuotaResources{StorageBytes: size}, limits).Return(nil)
+		mockFileStorage.EXPECT().SaveFile(owner, composed, gomock.Any()).Return(saved, nil)
+		mockFileRepo.EXPECT().Save(ctx, gomock.Any()).Return(nil)
+
+		if _, err := fileService.UploadFile(ctx, member(userID), 0, decomposed, size, bytes.NewBufferString(fileContent)); err != nil {
+			t.Errorf("UploadFile returned an error: %v", err)
+		}
+	})
+
+	t.Run("read-only users cannot upload", func(t *testing.T) {
+		_, err := fileService.UploadFile(ctx, Caller{UserID: userID, Role: entity.RoleReadOnly}, 0, filename, size, bytes.NewBufferString(fileContent))
+		if err != internalErrors.PermissionDenied {
+			t.Errorf("expected %v, got %v", internalErrors.PermissionDenied, err)
+		}
+	})
+}
+
+func TestFileService_UploadFile_ConcurrentReplacements(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tempDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tempDir, "1000"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "1000", "data.csv"), []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mockFileRepo := mocks.NewMockFileRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	fileService := NewFileService(repository.NewLocalFileStorage(tempDir), mockFileRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(nil), entity.UploadPolicy{})
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var released int64
+	mockQuotaRepo.EXPECT().GetLimits(ctx, int64(1000)).Return(nil, nil).AnyTimes()
+	mockQuotaRepo.EXPECT().Reserve(ctx, int64(1000), entity.QuotaResources{StorageBytes: 5}, gomock.Any()).Return(nil).AnyTimes()
+	mockQuotaRepo.EXPECT().Release(ctx, int64(1000), gomock.Any()).DoAndReturn(func(_ context.Context, _ int64, delta entity.QuotaResources) error {
+		mu.Lock()
+		defer mu.Unlock()
+		released += delta.StorageBytes
+		return nil
+	}).AnyTimes()
+	mockFileRepo.EXPECT().Save(ctx, gomock.Any()).Return(nil).AnyTimes()
+
+	const uploads = 8
+	var wg sync.WaitGroup
+	for range uploads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Slow uploads overlap, unless they wait for each other.
+			content := io.MultiReader(slowReader{20 * time.Millisecond}, strings.NewReader("abcde"))
+			if _, err := fileService.UploadFile(ctx, member(1000), 0, "data.csv", 5, content); err != nil {
+				t.Errorf("UploadFile returned an error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Each upload replaces the file before it, and only the original file is 10 bytes.
+	if want := int64(10 + 5*(uploads-1)); released != want {
+		t.Errorf("released %d bytes, want %d", released, want)
+	}
+}
+
+// slowReader reads nothing after a delay.
+type slowReader struct {
+	delay time.Duration
+}
+
+func (r slowReader) Read([]byte) (int, error) {
+	time.Sleep(r.delay)
+	return 0, io.EOF
+}
+
+func TestFileService_UploadFile_Policy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFileStorage := mocks.NewMockFileStorage(ctrl)
+	mockFileRepo := mocks.NewMockFileRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	limits := entity.QuotaResources{StorageBytes: 10000}
+	policy := entity.UploadPolicy{MaxFileSize: 1000, AllowedTypes: []string{"text/*"}}
+	fileService := NewFileService(mockFileStorage, mockFileRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{DefaultLimits: limits}), NewAuthorizer(nil), policy)
+
+	ctx := context.Background()
+	userID := int64(1000)
+	owner := entity.Owner{UserID: userID}
+	expectReserve := func(size int64) {
+		mockFileStorage.EXPECT().FileSize(owner, "a.txt").Return(int64(0), nil)
+		mockQuotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil)
+		mockQuotaRepo.EXPECT().Reserve(ctx, userID, entity.QuotaResources{StorageBytes: size}, limits).Return(nil)
+	}
+	saveAll := func(_ entity.Owner, name string, r io.Reader) (*entity.FileInfo, error) {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return &entity.FileInfo{Name: name, Size: int64(len(content))}, nil
+	}
+
+	t.Run("what the file does not use is given back", func(t *testing.T) {
+		// The size of the request bounds the file
+		expectReserve(500)
+		mockFileStorage.EXPECT().SaveFile(owner, "a.txt", gomock.Any()).DoAndReturn(saveAll)
+		mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{StorageBytes: 489}).Return(nil)
+		mockFileRepo.EXPECT().Save(ctx, gomock.Any()).Return(nil)
+
+		record, err := fileService.UploadFile(ctx, member(userID), 0, "a.txt", 500, strings.NewReader("hello world"))
+		if err != nil || record.Size != 11 {
+			t.Errorf("UploadFile returned %+v, %v", record, err)
+		}
+	})
+
+	t.Run("unknown size reserves the largest file", func(t *testing.T) {
+		mockQuotaRepo.EXPECT().GetUsage(ctx, userID).Return(&entity.QuotaResources{StorageBytes: 5000}, nil)
+		expectReserve(1000)
+		mockFileStorage.EXPECT().SaveFile(owner, "a.txt", gomock.Any()).DoAndReturn(saveAll)
+		mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{StorageBytes: 989}).Return(nil)
+		mockFileRepo.EXPECT().Save(ctx, gomock.Any()).Return(nil)
+
+		if _, err := fileService.UploadFile(ctx, member(userID), 0, "a.txt", -1, strings.NewReader("hello world")); err != nil {
+			t.Errorf("UploadFile returned an error: %v", err)
+		}
+	})
+
+	t.Run("unknown size reserves what the quota has left", func(t *testing.T) {
+		mockQuotaRepo.EXPECT().GetUsage(ctx, userID).Return(&entity.QuotaResources{StorageBytes: 9980}, nil)
+		expectReserve(20)
+		mockFileStorage.EXPECT().SaveFile(owner, "a.txt", gomock.Any()).DoAndReturn(saveAll)
+		mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{StorageBytes: 9}).Return(nil)
+		mockFileRepo.EXPECT().Save(ctx, gomock.Any()).Return(nil)
+
+		if _, err := fileService.UploadFile(ctx, member(userID), 0, "a.txt", -1, strings.NewReader("hello world")); err != nil {
+			t.Errorf("UploadFile returned an error: %v", err)
+		}
+	})
+
+	t.Run("unknown size larger than the quota has left", func(t *testing.T) {
+		mockQuotaRepo.EXPECT().GetUsage(ctx, userID).Return(&entity.QuotaResources{StorageBytes: 9400}, nil)
+		expectReserve(600)
+		mockFileStorage.EXPECT().SaveFile(owner, "a.txt", gomock.Any()).DoAndReturn(saveAll)
+		mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{StorageBytes: 600}).Return(nil)
+
+		_, err := fileService.UploadFile(ctx, member(userID), 0, "a.txt", -1, strings.NewReader(strings.Repeat("a", 700)))
+		var customErr *internalErrors.CustomError
+		if !errors.As(err, &customErr) || customErr.Message != internalErrors.QuotaExceeded.Message {
+			t.Errorf("expected %v, got %v", internalErrors.QuotaExceeded, err)
+		}
+	})
+
+	t.Run("unknown size with no quota left", func(t *testing.T) {
+		mockQuotaRepo.EXPECT().GetUsage(ctx, userID).Return(&entity.QuotaResources{StorageBytes: 10000}, nil)
+		expectReserve(0)
+		mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{}).Return(nil)
+
+		// What is read to sniff the type is too large already.
+		_, err := fileService.UploadFile(ctx, member(userID), 0, "a.txt", -1, strings.NewReader("hello world"))
+		var customErr *internalErrors.CustomError
+		if !errors.As(err, &customErr) || customErr.Message != internalErrors.QuotaExceeded.Message {
+			t.Errorf("expected %v, got %v", internalErrors.QuotaExceeded, err)
+		}
+	})
+
+	t.Run("file of exactly the maximum size", func(t *testing.T) {
+		expectReserve(1000)
+		mockFileStorage.EXPECT().SaveFile(owner, "a.txt", gomock.Any()).DoAndReturn(saveAll)
+		mockFileRepo.EXPECT().Save(ctx, gomock.Any()).Return(nil)
+
+		if _, err := fileService.UploadFile(ctx, member(userID), 0, "a.txt", 2000, strings.NewReader(strings.Repeat("a", 1000))); err != nil {
+			t.Errorf("UploadFile returned an error: %v", err)
+		}
+	})
+
+	t.Run("too large while writing", func(t *testing.T) {
+		expectReserve(1000)
+		mockFileStorage.EXPECT().SaveFile(owner, "a.txt", gomock.Any()).DoAndReturn(func(_ entity.Owner, _ string, r io.Reader) (*entity.FileInfo, error) {
+			_, err := io.ReadAll(r)
+			return nil, fmt.Errorf("failed to write file: %v", err)
+		})
+		mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{StorageBytes: 1000}).Return(nil)
+
+		_, err := fileService.UploadFile(ctx, member(userID), 0, "a.txt", 2000, strings.NewReader(strings.Repeat("a", 1001)))
+		if err != internalErrors.FileTooLarge {
+			t.Errorf("expected %v, got %v", internalErrors.FileTooLarge, err)
+		}
+	})
+
+	t.Run("too large before anything is reserved", func(t *testing.T) {
+		policy := entity.UploadPolicy{MaxFileSize: 5}
+		fileService := NewFileService(mockFileStorage, mockFileRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{DefaultLimits: limits}), NewAuthorizer(nil), policy)
+		mockFileStorage.EXPECT().FileSize(owner, "a.txt").Return(int64(0), nil)
+
+		_, err := fileService.UploadFile(ctx, member(userID), 0, "a.txt", -1, strings.NewReader("hello world"))
+		if err != internalErrors.FileTooLarge {
+			t.Errorf("expected %v, got %v", internalErrors.FileTooLarge, err)
+		}
+	})
+
+	t.Run("type not allowed", func(t *testing.T) {
+		mockFileStorage.EXPECT().FileSize(owner, "a.txt").Return(int64(0), nil)
+
+		_, err := fileService.UploadFile(ctx, member(userID), 0, "a.txt", 100, strings.NewReader("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"))
+		var customErr *internalErrors.CustomError
+		if !errors.As(err, &customErr) || customErr.Message != internalErrors.FileTypeNotAllowed.Message {
+			t.Errorf("expected %v, got %v", internalErrors.FileTypeNotAllowed, err)
+		}
+	})
+
+	t.Run("unknown size without a maximum", func(t *testing.T) {
+		fileService := NewFileService(mockFileStorage, mockFileRepo, nil, NewAuthorizer(nil), entity.UploadPolicy{})
+
+		_, err := fileService.UploadFile(ctx, member(userID), 0, "a.txt", -1, strings.NewReader("hello world"))
+		if err != internalErrors.ContentLengthRequired {
+			t.Errorf("expected %v, got %v", internalErrors.ContentLengthRequired, err)
+		}
+	})
+}
+
+func TestFileService_ListFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFileStorage := mocks.NewMockFileStorage(ctrl)
+	mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+	fileService := NewFileService(mockFileStorage, nil, nil, NewAuthorizer(mockTeamRepo), entity.UploadPolicy{})
+	ctx := context.Background()
+	userID := int64(1000)
+
+	t.Run("personal files", func(t *testing.T) {
+		files := []*entity.FileInfo{{Name: "a.txt", Size: 5}}
+		mockFileStorage.EXPECT().ListFiles(entity.Owner{UserID: userID}, "", false).Return(files, nil)
+
+		got, err := fileService.ListFiles(ctx, member(userID), 0, "", false)
+		if err != nil || len(got) != 1 || got[0] != files[0] {
+			t.Errorf("ListFiles returned %v, %v", got, err)
+		}
+	})
+
+	t.Run("team viewers can list", func(t *testing.T) {
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), userID).Return(&entity.TeamMember{TeamID: 7, UserID: userID, Role: entity.TeamRoleViewer}, nil)
+		mockFileStorage.EXPECT().ListFiles(entity.Owner{UserID: userID, TeamID: 7}, "", false).Return([]*entity.FileInfo{}, nil)
+
+		if _, err := fileService.ListFiles(ctx, member(userID), 7, "", false); err != nil {
+			t.Errorf("ListFiles returned an error: %v", err)
+		}
+	})
+
+	t.Run("folder", func(t *testing.T) {
+		files := []*entity.FileInfo{{Name: "docs/a", IsDir: true}, {Name: "docs/a/b.txt", Size: 5}}
+		mockFileStorage.EXPECT().ListFiles(entity.Owner{UserID: userID}, "docs", true).Return(files, nil)
+
+		got, err := fileService.ListFiles(ctx, member(userID), 0, "docs/", true)
+		if err != nil || len(got) != 2 {
+			t.Errorf("ListFiles returned %v, %v", got, err)
+		}
+	})
+
+	t.Run("missing folder", func(t *testing.T) {
+		mockFileStorage.EXPECT().ListFiles(entity.Owner{UserID: userID}, "missing", false).Return(nil, nil)
+
+		_, err := fileService.ListFiles(ctx, member(userID), 0, "missing", false)
+		if err != internalErrors.FolderNotFound {
+			t.Errorf("expected %v, got %v", internalErrors.FolderNotFound, err)
+		}
+	})
+
+	t.Run("invalid folder", func(t *testing.T) {
+		_, err := fileService.ListFiles(ctx, member(userID), 0, "../1001", false)
+		var customErr *internalErrors.CustomError
+		if !errors.As(err, &customErr) || customErr.Message != internalErrors.InvalidFilename.Message {
+			t.Errorf("expected %v, got %v", internalErrors.InvalidFilename, err)
+		}
+	})
+
+	t.Run("not a team member", func(t *testing.T) {
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), userID).Return(nil, nil)
+
+		_, err := fileService.ListFiles(ctx, member(userID), 7, "", false)
+		if err != internalErrors.PermissionDenied {
+			t.Errorf("expected %v, got %v", internalErrors.PermissionDenied, err)
+		}
+	})
 }
+
+func TestFileService_OpenFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFileStorage := mocks.NewMockFileStorage(ctrl)
+	fileService := NewFileService(mockFileStorage, nil, nil, NewAuthorizer(nil), entity.UploadPolicy{})
+	ctx := context.Background()
+	userID := int64(1000)
+	owner := entity.Owner{UserID: userID}
+
+	t.Run("success", func(t *testing.T) {
+		info := &entity.FileInfo{Name: "a.txt", Size: 5}
+		mockFileStorage.EXPECT().OpenFile(owner, "a.txt").Return(nopReadSeekCloser{bytes.NewReader([]byte("hello"))}, info, nil)
+
+		file, got, err := fileService.OpenFile(ctx, member(userID), 0, "a.txt")
+		if err != nil || file == nil || got != info {
+			t.Errorf("OpenFile returned %v, %v, %v", file, got, err)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		mockFileStorage.EXPECT().OpenFile(owner, "missing.txt").Return(nil, nil, nil)
+
+		_, _, err := fileService.OpenFile(ctx, member(userID), 0, "missing.txt")
+		if err != internalErrors.FileNotFound {
+			t.Errorf("expected %v, got %v", internalErrors.FileNotFound, err)
+		}
+	})
+
+	t.Run("invalid filenames", func(t *testing.T) {
+		for _, filename := range []string{"", ".", "..", "../1001/a.txt", "dir/../../a.txt", `..\a.txt`, "a\x00.txt"} {
+			_, _, err := fileService.OpenFile(ctx, member(userID), 0, filename)
+			var customErr *internalErrors.CustomError
+			if !errors.As(err, &customErr) || customErr.Message != internalErrors.InvalidFilename.Message {
+				t.Errorf("OpenFile(%q): expected %v, got %v", filename, internalErrors.InvalidFilename, err)
+			}
+		}
+	})
+}
+
+func TestFileService_DeleteFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFileStorage := mocks.NewMockFileStorage(ctrl)
+	mockFileRepo := mocks.NewMockFileRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+	fileService := NewFileService(mockFileStorage, mockFileRepo, NewQuotaService(mockQuotaRepo, QuotaOptions{}), NewAuthorizer(mockTeamRepo), entity.UploadPolicy{})
+	ctx := context.Background()
+	userID := int64(1000)
+	owner := entity.Owner{UserID: userID}
+
+	t.Run("gives back the size", func(t *testing.T) {
+		mockFileStorage.EXPECT().StatFile(owner, "a.txt").Return(&entity.FileInfo{Name: "a.txt", Size: 40}, nil)
+		mockFileStorage.EXPECT().DeleteFile(owner, "a.txt").Return(nil)
+		mockFileRepo.EXPECT().Delete(ctx, owner, "a.txt").Return(nil, nil)
+		mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{StorageBytes: 40}).Return(nil)
+
+		if err := fileService.DeleteFile(ctx, member(userID), 0, "a.txt", false); err != nil {
+			t.Errorf("DeleteFile returned an error: %v", err)
+		}
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		mockFileStorage.EXPECT().StatFile(owner, "a.txt").Return(&entity.FileInfo{Name: "a.txt", Size: 40}, nil)
+		mockFileStorage.EXPECT().DeleteFile(owner, "a.txt").Return(errors.New("permission denied"))
+
+		if err := fileService.DeleteFile(ctx, member(userID), 0, "a.txt", false); err == nil || err.Error() != "permission denied" {
+			t.Errorf("DeleteFile returned wrong error: %v", err)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		mockFileStorage.EXPECT().StatFile(owner, "missing.txt").Return(nil, nil)
+		mockFileStorage.EXPECT().ListFiles(owner, "missing.txt", false).Return(nil, nil)
+
+		if err := fileService.DeleteFile(ctx, member(userID), 0, "missing.txt", false); err != internalErrors.FileNotFound {
+			t.Errorf("expected %v, got %v", internalErrors.FileNotFound, err)
+		}
+	})
+
+	t.Run("team file gives back the size to the member who uploaded it", func(t *testing.T) {
+		teamOwner := entity.Owner{UserID: userID, TeamID: 7}
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), userID).Return(&entity.TeamMember{TeamID: 7, UserID: userID, Role: entity.TeamRoleMember}, nil)
+		mockFileStorage.EXPECT().StatFile(teamOwner, "a.txt").Return(&entity.FileInfo{Name: "a.txt", Size: 40}, nil)
+		mockFileStorage.EXPECT().DeleteFile(teamOwner, "a.txt").Return(nil)
+		mockFileRepo.EXPECT().Delete(ctx, teamOwner, "a.txt").Return([]*entity.FileRecord{{Owner: teamOwner, UploadedBy: 2000, Path: "a.txt", Size: 40}}, nil)
+		mockQuotaRepo.EXPECT().Release(ctx, int64(2000), entity.QuotaResources{StorageBytes: 40}).Return(nil)
+
+		if err := fileService.DeleteFile(ctx, member(userID), 7, "a.txt", false); err != nil {
+			t.Errorf("DeleteFile returned an error: %v", err)
+		}
+	})
+
+	t.Run("team folder gives back the size of every file to its uploader", func(t *testing.T) {
+		teamOwner := entity.Owner{UserID: userID, TeamID: 7}
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), userID).Return(&entity.TeamMember{TeamID: 7, UserID: userID, Role: entity.TeamRoleMember}, nil)
+		mockFileStorage.EXPECT().StatFile(teamOwner, "docs").Return(nil, nil)
+		mockFileStorage.EXPECT().ListFiles(teamOwner, "docs", true).Return([]*entity.FileInfo{{Name: "docs/a.txt", Size: 40}, {Name: "docs/b.txt", Size: 2}}, nil)
+		mockFileStorage.EXPECT().DeleteDir(teamOwner, "docs", true).Return(nil)
+		mockFileRepo.EXPECT().Delete(ctx, teamOwner, "docs").Return([]*entity.FileRecord{
+			{Owner: teamOwner, UploadedBy: userID, Path: "docs/a.txt", Size: 30},
+			{Owner: teamOwner, UploadedBy: 2000, Path: "docs/b.txt", Size: 2},
+			{Owner: teamOwner, UploadedBy: userID, Path: "docs/c.txt", Size: 10},
+			// Uploaded by a member who deleted their account.
+			{Owner: teamOwner, Path: "docs/d.txt", Size: 5},
+		}, nil)
+		mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{StorageBytes: 40}).Return(nil)
+		mockQuotaRepo.EXPECT().Release(ctx, int64(2000), entity.QuotaResources{StorageBytes: 2}).Return(nil)
+
+		if err := fileService.DeleteFile(ctx, member(userID), 7, "docs", true); err != nil {
+			t.Errorf("DeleteFile returned an error: %v", err)
+		}
+	})
+
+	t.Run("empty folder", func(t *testing.T) {
+		mockFileStorage.EXPECT().StatFile(owner, "docs").Return(nil, nil)
+		mockFileStorage.EXPECT().ListFiles(owner, "docs", false).Return([]*entity.FileInfo{}, nil)
+		mockFileStorage.EXPECT().DeleteDir(owner, "docs", false).Return(nil)
+		mockFileRepo.EXPECT().Delete(ctx, owner, "docs").Return(nil, nil)
+
+		if err := fileService.DeleteFile(ctx, member(userID), 0, "docs", false); err != nil {
+			t.Errorf("DeleteFile returned an error: %v", err)
+		}
+	})
+
+	t.Run("folder not empty", func(t *testing.T) {
+		mockFileStorage.EXPECT().StatFile(owner, "docs").Return(nil, nil)
+		mockFileStorage.EXPECT().ListFiles(owner, "docs", false).Return([]*entity.FileInfo{{Name: "docs/a.txt", Size: 40}}, nil)
+
+		if err := fileService.DeleteFile(ctx, member(userID), 0, "docs", false); err != internalErrors.FolderNotEmpty {
+			t.Errorf("expected %v, got %v", internalErrors.FolderNotEmpty, err)
+		}
+	})
+
+	t.Run("recursive delete gives back the size of every file", func(t *testing.T) {
+		mockFileStorage.EXPECT().StatFile(owner, "docs").Return(nil, nil)
+		mockFileStorage.EXPECT().ListFiles(owner, "docs", true).Return([]*entity.FileInfo{
+			{Name: "docs/a.txt", Size: 40},
+			{Name: "docs/sub", IsDir: true},
+			{Name: "docs/sub/b.txt", Size: 2},
+		}, nil)
+		mockFileStorage.EXPECT().DeleteDir(owner, "docs", true).Return(nil)
+		mockFileRepo.EXPECT().Delete(ctx, owner, "docs").Return(nil, errors.New("connection refused"))
+		mockQuotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{StorageBytes: 42}).Return(nil)
+
+		if err := fileService.DeleteFile(ctx, member(userID), 0, "docs", true); err != nil {
+			t.Errorf("DeleteFile returned an error: %v", err)
+		}
+	})
+
+	t.Run("read-only users cannot delete", func(t *testing.T) {
+		err := fileService.DeleteFile(ctx, Caller{UserID: userID, Role: entity.RoleReadOnly}, 0, "a.txt", false)
+		if err != internalErrors.PermissionDenied {
+			t.Errorf("expected %v, got %v", internalErrors.PermissionDenied, err)
+		}
+	})
+}
+
+func TestFileService_CreateFolder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFileStorage := mocks.NewMockFileStorage(ctrl)
+	fileService := NewFileService(mockFileStorage, nil, nil, NewAuthorizer(nil), entity.UploadPolicy{})
+	ctx := context.Background()
+	userID := int64(1000)
+
+	t.Run("success", func(t *testing.T) {
+		mockFileStorage.EXPECT().MakeDir(entity.Owner{UserID: userID}, "reports/2024").Return(nil)
+
+		if err := fileService.CreateFolder(ctx, member(userID), 0, "reports//2024/"); err != nil {
+			t.Errorf("CreateFolder returned an error: %v", err)
+		}
+	})
+
+	t.Run("a file is in the way", func(t *testing.T) {
+		mockFileStorage.EXPECT().MakeDir(entity.Owner{UserID: userID}, "a.txt/b").Return(internalErrors.NotAFolder)
+
+		if err := fileService.CreateFolder(ctx, member(userID), 0, "a.txt/b"); err != internalErrors.NotAFolder {
+			t.Errorf("expected %v, got %v", internalErrors.NotAFolder, err)
+		}
+	})
+
+	t.Run("read-only users cannot create folders", func(t *testing.T) {
+		err := fileService.CreateFolder(ctx, Caller{UserID: userID, Role: entity.RoleReadOnly}, 0, "reports")
+		if err != internalErrors.PermissionDenied {
+			t.Errorf("expected %v, got %v", internalErrors.PermissionDenied, err)
+		}
+	})
+}
+
+func TestFileService_MoveFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFileStorage := mocks.NewMockFileStorage(ctrl)
+	mockFileRepo := mocks.NewMockFileRepository(ctrl)
+	fileService := NewFileService(mockFileStorage, mockFileRepo, nil, NewAuthorizer(nil), entity.UploadPolicy{})
+	ctx := context.Background()
+	userID := int64(1000)
+	owner := entity.Owner{UserID: userID}
+
+	t.Run("success", func(t *testing.T) {
+		mockFileStorage.EXPECT().Move(owner, "report.csv", "reports/2024/report.csv").Return(nil)
+		mockFileRepo.EXPECT().Move(ctx, owner, "report.csv", "reports/2024/report.csv").Return(nil)
+
+		if err := fileService.MoveFile(ctx, member(userID), 0, "report.csv", "reports/2024/report.csv"); err != nil {
+			t.Errorf("MoveFile returned an error: %v", err)
+		}
+	})
+
+	t.Run("new path taken", func(t *testing.T) {
+		mockFileStorage.EXPECT().Move(owner, "a.txt", "b.txt").Return(internalErrors.FileExists)
+
+		if err := fileService.MoveFile(ctx, member(userID), 0, "a.txt", "b.txt"); err != internalErrors.FileExists {
+			t.Errorf("expected %v, got %v", internalErrors.FileExists, err)
+		}
+	})
+
+	t.Run("into itself", func(t *testing.T) {
+		for _, to := range []string{"reports", "reports/", "reports/old"} {
+			err := fileService.MoveFile(ctx, member(userID), 0, "reports", to)
+			var customErr *internalErrors.CustomError
+			if !errors.As(err, &customErr) || customErr.Message != internalErrors.InvalidFilename.Message {
+				t.Errorf("MoveFile to %q: expected %v, got %v", to, internalErrors.InvalidFilename, err)
+			}
+		}
+	})
+
+	t.Run("out of the folder", func(t *testing.T) {
+		err := fileService.MoveFile(ctx, member(userID), 0, "a.txt", "../1001/a.txt")
+		var customErr *internalErrors.CustomError
+		if !errors.As(err, &customErr) || customErr.Message != internalErrors.InvalidFilename.Message {
+			t.Errorf("expected %v, got %v", internalErrors.InvalidFilename, err)
+		}
+	})
+}
+
+type nopReadSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopReadSeekCloser) Close() error { return nil }
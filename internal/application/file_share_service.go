@@ -0,0 +1,200 @@
+package application
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
+	"context"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileShareOptions configures share links.
+type FileShareOptions struct {
+	// Secret signs the tokens of the links.
+	Secret []byte
+	// DefaultTTL is how long a link is valid if no expiry is given, and MaxTTL how long it can be
+	// valid at most, unlimited if zero.
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+}
+
+// FileShareService creates share links, which let anyone who has one download a file without
+// logging in, and serves the files they name.
+type FileShareService struct {
+	fileService *FileService
+	shareRepo   infrastructure.FileShareRepository
+	userRepo    infrastructure.UserRepository
+	options     FileShareOptions
+}
+
+// NewFileShareService creates a new instance of FileShareService.
+func NewFileShareService(fileService *FileService, shareRepo infrastructure.FileShareRepository, userRepo infrastructure.UserRepository, options FileShareOptions) *FileShareService {
+	return &FileShareService{
+		fileService: fileService,
+		shareRepo:   shareRepo,
+		userRepo:    userRepo,
+		options:     options,
+	}
+}
+
+// CreateShare creates a link to a file of the caller, or of a team of the caller, and returns it
+// with its token. The link expires at expiresAt, or after the default time if it is nil, and can
+// be used maxDownloads times, or any number of times if it is zero. Sharing a file makes it public
+// to whoever gets the link, so it takes the permission to write the files.
+func (s *FileShareService) CreateShare(ctx context.Context, caller Caller, teamID int64, filename string, expiresAt *time.Time, maxDownloads int) (*entity.FileShare, string, error) {
+	filename, err := entity.CleanFilePath(filename)
+	if err != nil {
+		return nil, "", err
+	}
+	if maxDownloads < 0 {
+		return nil, "", errors.InvalidMaxDownloads
+	}
+	now := time.Now()
+	expiry := now.Add(s.options.DefaultTTL)
+	if expiresAt != nil {
+		expiry = *expiresAt
+	}
+	// The token carries the expiry in seconds.
+	expiry = expiry.Truncate(time.Second)
+	if !expiry.After(now) {
+		return nil, "", errors.InvalidExpiry.New("expires_at must be in the future")
+	}
+	if s.options.MaxTTL > 0 && expiry.After(now.Add(s.options.MaxTTL)) {
+		return nil, "", errors.InvalidExpiry.New("expires_at can be at most " + s.options.MaxTTL.String() + " from now")
+	}
+
+	owner := entity.Owner{UserID: caller.UserID, TeamID: teamID}
+	if err := s.fileService.authorizer.authorize(ctx, caller, entity.ActionWrite, owner); err != nil {
+		return nil, "", err
+	}
+	// Folders cannot be shared, and StatFile does not describe them.
+	info, err := s.fileService.fileStorage.StatFile(owner, filename)
+	if err != nil {
+		return nil, "", err
+	}
+	if info == nil {
+		return nil, "", errors.FileNotFound
+	}
+
+	share := &entity.FileShare{
+		ID:           uuid.NewString(),
+		UserID:       caller.UserID,
+		TeamID:       teamID,
+		Path:         filename,
+		MaxDownloads: maxDownloads,
+		ExpiresAt:    expiry,
+		CreatedAt:    now,
+	}
+	token, err := entity.NewShareToken(share).Sign(s.options.Secret)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.shareRepo.Create(ctx, share); err != nil {
+		return nil, "", err
+	}
+	return share, token, nil
+}
+
+// ListShares returns the links the caller created that can still be used, newest first, or those
+// to the files of a team when teamID is not zero. The links of a team are listed to those who may
+// share its files, so that they can revoke them.
+func (s *FileShareService) ListShares(ctx context.Context, caller Caller, teamID int64) ([]*entity.FileShare, error) {
+	if teamID == 0 {
+		return s.shareRepo.ListByUserID(ctx, caller.UserID, time.Now())
+	}
+	if err := s.fileService.authorizer.authorize(ctx, caller, entity.ActionWrite, entity.Owner{UserID: caller.UserID, TeamID: teamID}); err != nil {
+		return nil, err
+	}
+	return s.shareRepo.ListByTeamID(ctx, teamID, time.Now())
+}
+
+// RevokeShare revokes a link, which cannot be used from then on. Users revoke the links they
+// created, and whoever may share the files a link names revokes it as well, like the owners of a
+// team and admins.
+func (s *FileShareService) RevokeShare(ctx context.Context, caller Caller, id string) error {
+	share, err := s.shareRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if share == nil || share.RevokedAt != nil {
+		return errors.ShareLinkNotFound
+	}
+	if share.UserID != caller.UserID {
+		if err := s.fileService.authorizer.authorize(ctx, caller, entity.ActionWrite, share.Owner()); err != nil {
+			return err
+		}
+	}
+
+	revoked, err := s.shareRepo.Revoke(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.ShareLinkNotFound
+	}
+	return nil
+}
+
+// OpenSharedFile opens the file a link names, given its token, and counts the download. Every
+// request counts, including those for a range of the file. The token is verified before the share
+// is looked up, so that forged tokens cost no query.
+func (s *FileShareService) OpenSharedFile(ctx context.Context, token string) (io.ReadSeekCloser, *entity.FileInfo, error) {
+	now := time.Now()
+	claims, err := entity.ParseShareToken(token, s.options.Secret, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	share, err := s.shareRepo.GetByID(ctx, claims.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if share == nil || !claims.Matches(share) {
+		return nil, nil, errors.ShareLinkNotFound
+	}
+	if err := share.Usable(now); err != nil {
+		return nil, nil, err
+	}
+	if err := s.authorizeCreator(ctx, share); err != nil {
+		return nil, nil, err
+	}
+
+	file, info, err := s.fileService.fileStorage.OpenFile(share.Owner(), share.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if file == nil {
+		return nil, nil, errors.FileNotFound
+	}
+	// The download is counted once the file is known to exist. Counting checks the share again, so
+	// that concurrent downloads cannot exceed the maximum.
+	counted, err := s.shareRepo.CountDownload(ctx, share.ID, now)
+	if err != nil || !counted {
+		file.Close()
+		if err == nil {
+			err = errors.ShareLinkExhausted
+		}
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+// authorizeCreator checks that the creator of a link may still read the file it names. A link
+// grants no more than its creator has, who may have left the team of the file or lost the role
+// since the link was created. Such links count as revoked.
+func (s *FileShareService) authorizeCreator(ctx context.Context, share *entity.FileShare) error {
+	creator, err := s.userRepo.FindByID(ctx, share.UserID)
+	if err != nil {
+		return err
+	}
+	if creator == nil {
+		return errors.ShareLinkNotFound
+	}
+	err = s.fileService.authorizer.authorize(ctx, Caller{UserID: creator.ID, Role: creator.Role}, entity.ActionRead, share.Owner())
+	if err != nil && errors.PermissionDenied.Is(err) {
+		return errors.ShareLinkRevoked
+	}
+	return err
+}
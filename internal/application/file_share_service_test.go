@@ -0,0 +1,269 @@
+package application
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	internalErrors "container-manager/internal/errors"
+	"container-manager/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFileShareService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tempDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "1234", "reports"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "1234", "reports", "q1.csv"), []byte("a,b\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "teams", "7"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "teams", "7", "plan.csv"), []byte("c,d\n"), 0o644))
+
+	mockShareRepo := mocks.NewMockFileShareRepository(ctrl)
+	mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	fileService := NewFileService(repository.NewLocalFileStorage(tempDir), nil, nil, NewAuthorizer(mockTeamRepo), entity.UploadPolicy{})
+	secret := []byte("secret")
+	service := NewFileShareService(fileService, mockShareRepo, mockUserRepo, FileShareOptions{Secret: secret, DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour})
+	ctx := context.Background()
+	caller := member(1234)
+
+	// Downloads check that the creator of the link may still read the file.
+	mockUserRepo.EXPECT().FindByID(ctx, int64(1234)).Return(&entity.User{ID: 1234, Role: entity.RoleMember}, nil).AnyTimes()
+	mockUserRepo.EXPECT().FindByID(ctx, int64(5678)).Return(&entity.User{ID: 5678, Role: entity.RoleMember}, nil).AnyTimes()
+	// teamShare returns a link another member created to plan.csv of team 7, with its token.
+	teamShare := func(t *testing.T) (*entity.FileShare, string) {
+		share := &entity.FileShare{ID: "team-share-id", UserID: 5678, TeamID: 7, Path: "plan.csv", ExpiresAt: time.Now().Add(time.Hour).Truncate(time.Second)}
+		token, err := entity.NewShareToken(share).Sign(secret)
+		require.NoError(t, err)
+		return share, token
+	}
+
+	// share creates a link to reports/q1.csv, and returns it with its token.
+	share := func(t *testing.T, maxDownloads int) (*entity.FileShare, string) {
+		mockShareRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+		share, token, err := service.CreateShare(ctx, caller, 0, "reports/q1.csv", nil, maxDownloads)
+		require.NoError(t, err)
+		return share, token
+	}
+
+	t.Run("create", func(t *testing.T) {
+		var stored *entity.FileShare
+		mockShareRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, share *entity.FileShare) error {
+			stored = share
+			return nil
+		})
+
+		share, token, err := service.CreateShare(ctx, caller, 0, "reports/q1.csv", nil, 3)
+		require.NoError(t, err)
+		assert.Equal(t, stored, share)
+		assert.Equal(t, entity.Owner{UserID: 1234}, share.Owner())
+		assert.Equal(t, 3, share.MaxDownloads)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), share.ExpiresAt, 2*time.Second)
+
+		claims, err := entity.ParseShareToken(token, secret, time.Now())
+		require.NoError(t, err)
+		assert.True(t, claims.Matches(share))
+	})
+
+	t.Run("create for a missing file or a folder", func(t *testing.T) {
+		for _, name := range []string{"missing.csv", "reports"} {
+			_, _, err := service.CreateShare(ctx, caller, 0, name, nil, 0)
+			assert.Equal(t, internalErrors.FileNotFound, err, name)
+		}
+	})
+
+	t.Run("create with an invalid expiry", func(t *testing.T) {
+		for _, expiresAt := range []time.Time{time.Now().Add(-time.Minute), time.Now().Add(48 * time.Hour)} {
+			_, _, err := service.CreateShare(ctx, caller, 0, "reports/q1.csv", &expiresAt, 0)
+			var customErr *internalErrors.CustomError
+			assert.ErrorAs(t, err, &customErr)
+			assert.Equal(t, internalErrors.InvalidExpiry.Message, customErr.Message)
+		}
+	})
+
+	t.Run("create with negative downloads", func(t *testing.T) {
+		_, _, err := service.CreateShare(ctx, caller, 0, "reports/q1.csv", nil, -1)
+		assert.Equal(t, internalErrors.InvalidMaxDownloads, err)
+	})
+
+	t.Run("create for a team as a viewer", func(t *testing.T) {
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(1234)).Return(&entity.TeamMember{TeamID: 7, UserID: 1234, Role: entity.TeamRoleViewer}, nil)
+
+		_, _, err := service.CreateShare(ctx, caller, 7, "reports/q1.csv", nil, 0)
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+
+	t.Run("download", func(t *testing.T) {
+		share, token := share(t, 0)
+		mockShareRepo.EXPECT().GetByID(ctx, share.ID).Return(share, nil)
+		mockShareRepo.EXPECT().CountDownload(ctx, share.ID, gomock.Any()).Return(true, nil)
+
+		file, info, err := service.OpenSharedFile(ctx, token)
+		require.NoError(t, err)
+		defer file.Close()
+		content, _ := io.ReadAll(file)
+		assert.Equal(t, "a,b\n", string(content))
+		assert.Equal(t, "reports/q1.csv", info.Name)
+	})
+
+	t.Run("download with a forged token", func(t *testing.T) {
+		share, _ := share(t, 0)
+		forged, err := entity.NewShareToken(share).Sign([]byte("guessed"))
+		require.NoError(t, err)
+
+		_, _, err = service.OpenSharedFile(ctx, forged)
+		assert.Equal(t, internalErrors.ShareLinkNotFound, err)
+	})
+
+	t.Run("download with a revoked link", func(t *testing.T) {
+		share, token := share(t, 0)
+		revoked := *share
+		now := time.Now()
+		revoked.RevokedAt = &now
+		mockShareRepo.EXPECT().GetByID(ctx, share.ID).Return(&revoked, nil)
+
+		_, _, err := service.OpenSharedFile(ctx, token)
+		assert.Equal(t, internalErrors.ShareLinkRevoked, err)
+	})
+
+	t.Run("download with a used up link", func(t *testing.T) {
+		share, token := share(t, 1)
+		mockShareRepo.EXPECT().GetByID(ctx, share.ID).Return(share, nil)
+		// Another download counted it first.
+		mockShareRepo.EXPECT().CountDownload(ctx, share.ID, gomock.Any()).Return(false, nil)
+
+		_, _, err := service.OpenSharedFile(ctx, token)
+		assert.Equal(t, internalErrors.ShareLinkExhausted, err)
+	})
+
+	t.Run("download with a token for another path", func(t *testing.T) {
+		share, _ := share(t, 0)
+		other := *share
+		other.Path = "other.csv"
+		token, err := entity.NewShareToken(&other).Sign(secret)
+		require.NoError(t, err)
+		mockShareRepo.EXPECT().GetByID(ctx, share.ID).Return(share, nil)
+
+		_, _, err = service.OpenSharedFile(ctx, token)
+		assert.Equal(t, internalErrors.ShareLinkNotFound, err)
+	})
+
+	t.Run("download of a deleted file", func(t *testing.T) {
+		share, token := share(t, 0)
+		require.NoError(t, os.Remove(filepath.Join(tempDir, "1234", "reports", "q1.csv")))
+		mockShareRepo.EXPECT().GetByID(ctx, share.ID).Return(share, nil)
+
+		_, _, err := service.OpenSharedFile(ctx, token)
+		assert.Equal(t, internalErrors.FileNotFound, err)
+	})
+
+	t.Run("download of a team file", func(t *testing.T) {
+		share, token := teamShare(t)
+		mockShareRepo.EXPECT().GetByID(ctx, share.ID).Return(share, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(5678)).Return(&entity.TeamMember{TeamID: 7, UserID: 5678, Role: entity.TeamRoleViewer}, nil)
+		mockShareRepo.EXPECT().CountDownload(ctx, share.ID, gomock.Any()).Return(true, nil)
+
+		file, _, err := service.OpenSharedFile(ctx, token)
+		require.NoError(t, err)
+		defer file.Close()
+		content, _ := io.ReadAll(file)
+		assert.Equal(t, "c,d\n", string(content))
+	})
+
+	t.Run("download of a team file after the creator left", func(t *testing.T) {
+		share, token := teamShare(t)
+		mockShareRepo.EXPECT().GetByID(ctx, share.ID).Return(share, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(5678)).Return(nil, nil)
+
+		_, _, err := service.OpenSharedFile(ctx, token)
+		assert.Equal(t, internalErrors.ShareLinkRevoked, err)
+	})
+
+	t.Run("download after the creator was deleted", func(t *testing.T) {
+		share := &entity.FileShare{ID: "gone-share-id", UserID: 9999, Path: "q1.csv", ExpiresAt: time.Now().Add(time.Hour).Truncate(time.Second)}
+		token, err := entity.NewShareToken(share).Sign(secret)
+		require.NoError(t, err)
+		mockShareRepo.EXPECT().GetByID(ctx, share.ID).Return(share, nil)
+		mockUserRepo.EXPECT().FindByID(ctx, int64(9999)).Return(nil, nil)
+
+		_, _, err = service.OpenSharedFile(ctx, token)
+		assert.Equal(t, internalErrors.ShareLinkNotFound, err)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		mockShareRepo.EXPECT().ListByUserID(ctx, int64(1234), gomock.Any()).Return([]*entity.FileShare{{ID: "share-id"}}, nil)
+
+		shares, err := service.ListShares(ctx, caller, 0)
+		assert.NoError(t, err)
+		assert.Len(t, shares, 1)
+	})
+
+	t.Run("list for a team", func(t *testing.T) {
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(1234)).Return(&entity.TeamMember{TeamID: 7, UserID: 1234, Role: entity.TeamRoleOwner}, nil)
+		mockShareRepo.EXPECT().ListByTeamID(ctx, int64(7), gomock.Any()).Return([]*entity.FileShare{{ID: "team-share-id", UserID: 5678, TeamID: 7}}, nil)
+
+		shares, err := service.ListShares(ctx, caller, 7)
+		assert.NoError(t, err)
+		assert.Len(t, shares, 1)
+	})
+
+	t.Run("list for a team as a viewer", func(t *testing.T) {
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(1234)).Return(&entity.TeamMember{TeamID: 7, UserID: 1234, Role: entity.TeamRoleViewer}, nil)
+
+		_, err := service.ListShares(ctx, caller, 7)
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		mockShareRepo.EXPECT().GetByID(ctx, "share-id").Return(&entity.FileShare{ID: "share-id", UserID: 1234}, nil)
+		mockShareRepo.EXPECT().Revoke(ctx, "share-id").Return(true, nil)
+		assert.NoError(t, service.RevokeShare(ctx, caller, "share-id"))
+
+		mockShareRepo.EXPECT().GetByID(ctx, "other-id").Return(nil, nil)
+		assert.Equal(t, internalErrors.ShareLinkNotFound, service.RevokeShare(ctx, caller, "other-id"))
+	})
+
+	t.Run("revoke a revoked link", func(t *testing.T) {
+		now := time.Now()
+		mockShareRepo.EXPECT().GetByID(ctx, "share-id").Return(&entity.FileShare{ID: "share-id", UserID: 1234, RevokedAt: &now}, nil)
+
+		assert.Equal(t, internalErrors.ShareLinkNotFound, service.RevokeShare(ctx, caller, "share-id"))
+	})
+
+	t.Run("revoke a link of another team member as owner", func(t *testing.T) {
+		share, _ := teamShare(t)
+		mockShareRepo.EXPECT().GetByID(ctx, share.ID).Return(share, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(1234)).Return(&entity.TeamMember{TeamID: 7, UserID: 1234, Role: entity.TeamRoleOwner}, nil)
+		mockShareRepo.EXPECT().Revoke(ctx, share.ID).Return(true, nil)
+
+		assert.NoError(t, service.RevokeShare(ctx, caller, share.ID))
+	})
+
+	t.Run("revoke a link of another team member as viewer", func(t *testing.T) {
+		share, _ := teamShare(t)
+		mockShareRepo.EXPECT().GetByID(ctx, share.ID).Return(share, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(1234)).Return(&entity.TeamMember{TeamID: 7, UserID: 1234, Role: entity.TeamRoleViewer}, nil)
+
+		assert.Equal(t, internalErrors.PermissionDenied, service.RevokeShare(ctx, caller, share.ID))
+	})
+
+	t.Run("revoke a personal link of another user", func(t *testing.T) {
+		mockShareRepo.EXPECT().GetByID(ctx, "share-id").Return(&entity.FileShare{ID: "share-id", UserID: 5678}, nil)
+		assert.Equal(t, internalErrors.PermissionDenied, service.RevokeShare(ctx, caller, "share-id"))
+
+		// Admins revoke any link.
+		mockShareRepo.EXPECT().GetByID(ctx, "share-id").Return(&entity.FileShare{ID: "share-id", UserID: 5678}, nil)
+		mockShareRepo.EXPECT().Revoke(ctx, "share-id").Return(true, nil)
+		assert.NoError(t, service.RevokeShare(ctx, Caller{UserID: 1, Role: entity.RoleAdmin}, "share-id"))
+	})
+}
@@ -0,0 +1,86 @@
+package application
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"container-manager/internal/domain/entity"
+)
+
+// inspectCache keeps recent container inspection results for a short time, so that
+// repeated listings do not hit the container runtime for every container again.
+type inspectCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]inspectCacheEntry
+	// sweptAt is when expired entries were last removed.
+	sweptAt time.Time
+}
+
+type inspectCacheEntry struct {
+	container *entity.Container
+	expiresAt time.Time
+}
+
+func newInspectCache(ttl time.Duration) *inspectCache {
+	return &inspectCache{
+		ttl:     ttl,
+		entries: make(map[string]inspectCacheEntry),
+	}
+}
+
+// get returns a copy of the cached container, so callers are free to modify it.
+func (c *inspectCache) get(id string) (*entity.Container, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, id)
+		return nil, false
+	}
+	return cloneContainer(entry.container), true
+}
+
+// set caches a container. Expired entries are removed along the way, at most once per ttl, as
+// those of containers that are not listed again, like removed ones, would otherwise stay.
+func (c *inspectCache) set(id string, container *entity.Container) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.sweptAt) >= c.ttl {
+		for id, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, id)
+			}
+		}
+		c.sweptAt = now
+	}
+	c.entries[id] = inspectCacheEntry{
+		container: cloneContainer(container),
+		expiresAt: now.Add(c.ttl),
+	}
+}
+
+func (c *inspectCache) invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, id)
+}
+
+// cloneContainer copies the container along with its labels, command and environment, which
+// would otherwise be shared with the cache and with every other caller.
+func cloneContainer(container *entity.Container) *entity.Container {
+	clone := *container
+	clone.Labels = maps.Clone(container.Labels)
+	clone.Cmd = slices.Clone(container.Cmd)
+	clone.Env = slices.Clone(container.Env)
+	return &clone
+}
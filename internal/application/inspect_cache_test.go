@@ -0,0 +1,39 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"container-manager/internal/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspectCache_CopiesContainers(t *testing.T) {
+	cache := newInspectCache(time.Minute)
+	stored := &entity.Container{ID: "abc", Labels: map[string]string{"env": "prod"}, Env: []string{"A=1"}}
+	cache.set("abc", stored)
+	stored.Labels["env"] = "changed"
+
+	first, ok := cache.get("abc")
+	assert.True(t, ok)
+	first.Labels["env"] = "modified"
+	first.Env[0] = "A=2"
+
+	second, ok := cache.get("abc")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"env": "prod"}, second.Labels)
+	assert.Equal(t, []string{"A=1"}, second.Env)
+}
+
+func TestInspectCache_SweepsExpiredEntries(t *testing.T) {
+	cache := newInspectCache(10 * time.Millisecond)
+	cache.set("removed", &entity.Container{ID: "removed"})
+	time.Sleep(20 * time.Millisecond)
+
+	// The expired entry is never read again, but goes once another one is cached.
+	cache.set("abc", &entity.Container{ID: "abc"})
+	assert.Len(t, cache.entries, 1)
+	_, ok := cache.get("abc")
+	assert.True(t, ok)
+}
@@ -8,21 +8,28 @@ import (
 	"container-manager/internal/domain/infrastructure"
 )
 
+// jobListLimit is the number of most recent jobs ListJobs returns.
+const jobListLimit = 50
+
 type JobService interface {
-	GetJob(ctx context.Context, userID int64, id string) (*entity.Job, error)
+	GetJob(ctx context.Context, caller Caller, id string) (*entity.Job, error)
+	// ListJobs returns the most recent jobs a user started, which only admins can do for other users.
+	ListJobs(ctx context.Context, caller Caller, userID int64) ([]*entity.Job, error)
 }
 
 type jobService struct {
-	jobRepo infrastructure.JobRepository
+	jobRepo    infrastructure.JobRepository
+	authorizer *Authorizer
 }
 
-func NewJobService(jobRepo infrastructure.JobRepository) JobService {
+func NewJobService(jobRepo infrastructure.JobRepository, authorizer *Authorizer) JobService {
 	return &jobService{
-		jobRepo: jobRepo,
+		jobRepo:    jobRepo,
+		authorizer: authorizer,
 	}
 }
 
-func (s *jobService) GetJob(ctx context.Context, userID int64, id string) (*entity.Job, error) {
+func (s *jobService) GetJob(ctx context.Context, caller Caller, id string) (*entity.Job, error) {
 	job, err := s.jobRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -30,8 +37,16 @@ func (s *jobService) GetJob(ctx context.Context, userID int64, id string) (*enti
 	if job == nil {
 		return nil, errors.JobNotFound
 	}
-	if job.UserID != userID {
-		return nil, errors.PermissionDenied
+	// Jobs of a team container can be followed by every member of the team.
+	if err := s.authorizer.authorize(ctx, caller, entity.ActionRead, job.Owner()); err != nil {
+		return nil, err
 	}
 	return job, nil
 }
+
+func (s *jobService) ListJobs(ctx context.Context, caller Caller, userID int64) ([]*entity.Job, error) {
+	if err := s.authorizer.authorize(ctx, caller, entity.ActionRead, entity.Owner{UserID: userID}); err != nil {
+		return nil, err
+	}
+	return s.jobRepo.ListByUserID(ctx, userID, jobListLimit)
+}
@@ -0,0 +1,114 @@
+package application
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
+	"context"
+	"time"
+)
+
+// LoginThrottleOptions configures how failed logins are throttled, per account and per client IP.
+type LoginThrottleOptions struct {
+	Account entity.LoginThrottlePolicy
+	IP      entity.LoginThrottlePolicy
+}
+
+// LoginThrottle slows down password guessing. Failed logins are counted in the database, so the
+// limits hold across instances. A nil LoginThrottle does not throttle.
+type LoginThrottle struct {
+	repo    infrastructure.LoginFailureRepository
+	options LoginThrottleOptions
+}
+
+func NewLoginThrottle(repo infrastructure.LoginFailureRepository, options LoginThrottleOptions) *LoginThrottle {
+	return &LoginThrottle{repo: repo, options: options}
+}
+
+// reserve counts the login attempt as failed for the account and the client IP before the password
+// is checked, and fails with errors.TooManyLoginAttempts while either has to wait. Concurrent
+// attempts are counted one after the other, each checked against the failures counted before it,
+// so that parallel guesses cannot slip through the same check. The IP is empty when it is not known.
+func (t *LoginThrottle) reserve(ctx context.Context, username, ip string) error {
+	if t == nil {
+		return nil
+	}
+	// Every key is checked before any is counted, so that a rejected attempt is not counted.
+	keys := t.keys(username, ip)
+	seen := make([]*entity.LoginFailures, len(keys))
+	for i, key := range keys {
+		failures, err := t.check(ctx, key)
+		if err != nil {
+			return err
+		}
+		seen[i] = failures
+	}
+	for i, key := range keys {
+		failures := seen[i]
+		for {
+			reserved, err := t.repo.Reserve(ctx, key.name, failures, key.resetBefore(time.Now()))
+			if err != nil {
+				return err
+			}
+			if reserved {
+				break
+			}
+			// Another attempt was counted in between, so this one is checked again.
+			if failures, err = t.check(ctx, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// check returns the failures of a key, and fails with errors.TooManyLoginAttempts while it has to wait.
+func (t *LoginThrottle) check(ctx context.Context, key loginKey) (*entity.LoginFailures, error) {
+	failures, err := t.repo.Get(ctx, key.name)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if key.policy.RetryAt(failures, now).After(now) {
+		return nil, errors.TooManyLoginAttempts
+	}
+	return failures, nil
+}
+
+// recordSuccess forgets the failed logins of the account, and takes back the attempt reserved for
+// the client IP. The other failures of the IP are kept, so that signing in to an own account does
+// not lift the limit on guessing the passwords of others.
+func (t *LoginThrottle) recordSuccess(ctx context.Context, username, ip string) error {
+	if t == nil {
+		return nil
+	}
+	if err := t.repo.Reset(ctx, entity.AccountLoginKey(username)); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return t.repo.Forgive(ctx, entity.IPLoginKey(ip))
+}
+
+type loginKey struct {
+	name   string
+	policy entity.LoginThrottlePolicy
+}
+
+// resetBefore returns before when failures of the key are forgotten. Without ResetAfter failures
+// are only forgotten after a successful login.
+func (k loginKey) resetBefore(now time.Time) time.Time {
+	if k.policy.ResetAfter > 0 {
+		return now.Add(-k.policy.ResetAfter)
+	}
+	return time.Time{}
+}
+
+func (t *LoginThrottle) keys(username, ip string) []loginKey {
+	keys := []loginKey{{name: entity.AccountLoginKey(username), policy: t.options.Account}}
+	if ip != "" {
+		keys = append(keys, loginKey{name: entity.IPLoginKey(ip), policy: t.options.IP})
+	}
+	return keys
+}
@@ -0,0 +1,163 @@
+package application
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
+	"context"
+	"strings"
+	"time"
+)
+
+const defaultMFAIssuer = "Container Manager"
+
+// TOTPEnrollment is what a user needs to set up an authenticator app.
+type TOTPEnrollment struct {
+	Secret     string
+	OTPAuthURI string
+}
+
+// MFAService manages the TOTP second factor of users. Logins are verified by the UserService.
+type MFAService struct {
+	mfaRepo  infrastructure.MFARepository
+	userRepo infrastructure.UserRepository
+	// issuer names the service in authenticator apps.
+	issuer string
+}
+
+func NewMFAService(mfaRepo infrastructure.MFARepository, userRepo infrastructure.UserRepository, issuer string) *MFAService {
+	if issuer == "" {
+		issuer = defaultMFAIssuer
+	}
+	return &MFAService{mfaRepo: mfaRepo, userRepo: userRepo, issuer: issuer}
+}
+
+// EnrollTOTP generates a new TOTP secret. It only takes effect once confirmed with ConfirmTOTP.
+func (s *MFAService) EnrollTOTP(ctx context.Context, userID int64) (*TOTPEnrollment, error) {
+	mfa, err := s.mfaRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa != nil && mfa.Enabled() {
+		return nil, errors.MFAAlreadyEnabled
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.UserNotFound
+	}
+
+	secret, err := entity.NewTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.SavePending(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret:     secret,
+		OTPAuthURI: entity.OTPAuthURI(s.issuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables the pending second factor with a first code from the authenticator app and
+// returns the recovery codes. They are only stored hashed, so this is the only time they are shown.
+func (s *MFAService) ConfirmTOTP(ctx context.Context, userID int64, code string) ([]string, error) {
+	mfa, err := s.mfaRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, errors.MFANotEnrolled
+	}
+	if mfa.Enabled() {
+		return nil, errors.MFAAlreadyEnabled
+	}
+
+	step, ok := mfa.MatchTOTP(strings.TrimSpace(code), time.Now())
+	if !ok {
+		return nil, errors.InvalidMFACode
+	}
+
+	codes, hashes, err := entity.NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := s.mfaRepo.Enable(ctx, userID, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, errors.MFAAlreadyEnabled
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP removes the second factor. It takes a current code or a recovery code, so that a
+// stolen session alone cannot turn it off.
+func (s *MFAService) DisableTOTP(ctx context.Context, userID int64, code string) error {
+	mfa, err := s.mfaRepo.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if mfa == nil || !mfa.Enabled() {
+		return errors.MFANotEnabled
+	}
+
+	if err := verifyMFACode(ctx, s.mfaRepo, mfa, code); err != nil {
+		return err
+	}
+	return s.mfaRepo.Delete(ctx, userID)
+}
+
+// verifyMFACode accepts a TOTP code, or a recovery code for anything that does not look like one.
+// Wrong codes count towards the lockout of TOTP verification.
+func verifyMFACode(ctx context.Context, mfaRepo infrastructure.MFARepository, mfa *entity.UserMFA, code string) error {
+	code = strings.TrimSpace(code)
+	now := time.Now()
+
+	if isTOTPCode(code) {
+		if mfa.Locked(now) {
+			return errors.TooManyMFAAttempts
+		}
+		if step, ok := mfa.MatchTOTP(code, now); ok {
+			used, err := mfaRepo.UseStep(ctx, mfa.UserID, step)
+			if err != nil {
+				return err
+			}
+			if used {
+				return nil
+			}
+		}
+	} else if code != "" {
+		used, err := mfaRepo.UseRecoveryCode(ctx, mfa.UserID, entity.HashRecoveryCode(code))
+		if err != nil {
+			return err
+		}
+		if used {
+			return nil
+		}
+	}
+
+	if err := mfaRepo.RecordFailure(ctx, mfa.UserID); err != nil {
+		return err
+	}
+	return errors.InvalidMFACode
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != entity.TOTPDigits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
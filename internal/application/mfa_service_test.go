@@ -0,0 +1,162 @@
+package application
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	internalErrors "container-manager/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func currentTOTPCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := entity.TOTPCode(secret, entity.TOTPStep(time.Now()))
+	assert.NoError(t, err)
+	return code
+}
+
+func TestMFAService_EnrollTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	service := NewMFAService(mockMFARepo, mockUserRepo, "")
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		mockMFARepo.EXPECT().Get(ctx, int64(1)).Return(nil, nil)
+		mockUserRepo.EXPECT().FindByID(ctx, int64(1)).Return(&entity.User{ID: 1, Username: "alice"}, nil)
+		var stored string
+		mockMFARepo.EXPECT().SavePending(ctx, int64(1), gomock.Any()).DoAndReturn(func(_ context.Context, _ int64, secret string) error {
+			stored = secret
+			return nil
+		})
+
+		enrollment, err := service.EnrollTOTP(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, stored, enrollment.Secret)
+		uri, err := url.Parse(enrollment.OTPAuthURI)
+		assert.NoError(t, err)
+		assert.Equal(t, "/"+defaultMFAIssuer+":alice", uri.Path)
+		assert.Equal(t, stored, uri.Query().Get("secret"))
+	})
+
+	t.Run("already enabled", func(t *testing.T) {
+		now := time.Now()
+		mockMFARepo.EXPECT().Get(ctx, int64(1)).Return(&entity.UserMFA{UserID: 1, EnabledAt: &now}, nil)
+
+		_, err := service.EnrollTOTP(ctx, 1)
+		var customErr *internalErrors.CustomError
+		assert.ErrorAs(t, err, &customErr)
+		assert.Equal(t, internalErrors.MFAAlreadyEnabled.Message, customErr.Message)
+	})
+}
+
+func TestMFAService_ConfirmTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	service := NewMFAService(mockMFARepo, nil, "")
+	ctx := context.Background()
+	secret, _ := entity.NewTOTPSecret()
+
+	t.Run("success", func(t *testing.T) {
+		mockMFARepo.EXPECT().Get(ctx, int64(1)).Return(&entity.UserMFA{UserID: 1, Secret: secret}, nil)
+		var storedHashes []string
+		mockMFARepo.EXPECT().Enable(ctx, int64(1), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ int64, _ int64, hashes []string) (bool, error) {
+			storedHashes = hashes
+			return true, nil
+		})
+
+		codes, err := service.ConfirmTOTP(ctx, 1, currentTOTPCode(t, secret))
+		assert.NoError(t, err)
+		assert.Len(t, codes, entity.RecoveryCodeCount)
+		assert.Equal(t, entity.HashRecoveryCode(codes[0]), storedHashes[0])
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		mockMFARepo.EXPECT().Get(ctx, int64(1)).Return(&entity.UserMFA{UserID: 1, Secret: secret}, nil)
+
+		_, err := service.ConfirmTOTP(ctx, 1, "000000x")
+		var customErr *internalErrors.CustomError
+		assert.ErrorAs(t, err, &customErr)
+		assert.Equal(t, internalErrors.InvalidMFACode.Message, customErr.Message)
+	})
+
+	t.Run("not enrolled", func(t *testing.T) {
+		mockMFARepo.EXPECT().Get(ctx, int64(1)).Return(nil, nil)
+
+		_, err := service.ConfirmTOTP(ctx, 1, "123456")
+		var customErr *internalErrors.CustomError
+		assert.ErrorAs(t, err, &customErr)
+		assert.Equal(t, internalErrors.MFANotEnrolled.Message, customErr.Message)
+	})
+}
+
+func TestMFAService_DisableTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	service := NewMFAService(mockMFARepo, nil, "")
+	ctx := context.Background()
+	secret, _ := entity.NewTOTPSecret()
+	now := time.Now()
+	enabled := &entity.UserMFA{UserID: 1, Secret: secret, EnabledAt: &now}
+
+	t.Run("with totp code", func(t *testing.T) {
+		mockMFARepo.EXPECT().Get(ctx, int64(1)).Return(enabled, nil)
+		mockMFARepo.EXPECT().UseStep(ctx, int64(1), gomock.Any()).Return(true, nil)
+		mockMFARepo.EXPECT().Delete(ctx, int64(1)).Return(nil)
+
+		assert.NoError(t, service.DisableTOTP(ctx, 1, currentTOTPCode(t, secret)))
+	})
+
+	t.Run("with recovery code", func(t *testing.T) {
+		mockMFARepo.EXPECT().Get(ctx, int64(1)).Return(enabled, nil)
+		mockMFARepo.EXPECT().UseRecoveryCode(ctx, int64(1), entity.HashRecoveryCode("abcde-fghij")).Return(true, nil)
+		mockMFARepo.EXPECT().Delete(ctx, int64(1)).Return(nil)
+
+		assert.NoError(t, service.DisableTOTP(ctx, 1, "ABCDE-FGHIJ"))
+	})
+
+	t.Run("used recovery code", func(t *testing.T) {
+		mockMFARepo.EXPECT().Get(ctx, int64(1)).Return(enabled, nil)
+		mockMFARepo.EXPECT().UseRecoveryCode(ctx, int64(1), gomock.Any()).Return(false, nil)
+		mockMFARepo.EXPECT().RecordFailure(ctx, int64(1)).Return(nil)
+
+		err := service.DisableTOTP(ctx, 1, "abcde-fghij")
+		var customErr *internalErrors.CustomError
+		assert.ErrorAs(t, err, &customErr)
+		assert.Equal(t, internalErrors.InvalidMFACode.Message, customErr.Message)
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		locked := *enabled
+		locked.FailedAttempts = entity.MaxMFAFailures
+		locked.LastFailedAt = &now
+		mockMFARepo.EXPECT().Get(ctx, int64(1)).Return(&locked, nil)
+
+		err := service.DisableTOTP(ctx, 1, currentTOTPCode(t, secret))
+		var customErr *internalErrors.CustomError
+		assert.ErrorAs(t, err, &customErr)
+		assert.Equal(t, internalErrors.TooManyMFAAttempts.Message, customErr.Message)
+	})
+
+	t.Run("not enabled", func(t *testing.T) {
+		mockMFARepo.EXPECT().Get(ctx, int64(1)).Return(&entity.UserMFA{UserID: 1, Secret: secret}, nil)
+
+		err := service.DisableTOTP(ctx, 1, "123456")
+		var customErr *internalErrors.CustomError
+		assert.ErrorAs(t, err, &customErr)
+		assert.Equal(t, internalErrors.MFANotEnabled.Message, customErr.Message)
+	})
+}
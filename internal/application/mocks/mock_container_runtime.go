@@ -13,8 +13,10 @@ import (
 	entity "container-manager/internal/domain/entity"
 	infrastructure "container-manager/internal/domain/infrastructure"
 	context "context"
+	io "io"
 	reflect "reflect"
 
+	container "github.com/moby/moby/api/types/container"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -42,6 +44,35 @@ func (m *MockContainerRuntime) EXPECT() *MockContainerRuntimeMockRecorder {
 	return m.recorder
 }
 
+// CopyFrom mocks base method.
+func (m *MockContainerRuntime) CopyFrom(ctx context.Context, id, path string) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyFrom", ctx, id, path)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CopyFrom indicates an expected call of CopyFrom.
+func (mr *MockContainerRuntimeMockRecorder) CopyFrom(ctx, id, path any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyFrom", reflect.TypeOf((*MockContainerRuntime)(nil).CopyFrom), ctx, id, path)
+}
+
+// CopyTo mocks base method.
+func (m *MockContainerRuntime) CopyTo(ctx context.Context, id, dir string, archive io.Reader) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyTo", ctx, id, dir, archive)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyTo indicates an expected call of CopyTo.
+func (mr *MockContainerRuntimeMockRecorder) CopyTo(ctx, id, dir, archive any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyTo", reflect.TypeOf((*MockContainerRuntime)(nil).CopyTo), ctx, id, dir, archive)
+}
+
 // Create mocks base method.
 func (m *MockContainerRuntime) Create(ctx context.Context, options infrastructure.ContainerCreateOptions) (string, error) {
 	m.ctrl.T.Helper()
@@ -72,6 +103,51 @@ func (mr *MockContainerRuntimeMockRecorder) Inspect(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Inspect", reflect.TypeOf((*MockContainerRuntime)(nil).Inspect), ctx, id)
 }
 
+// ListIDs mocks base method.
+func (m *MockContainerRuntime) ListIDs(ctx context.Context, filter infrastructure.ContainerFilter) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIDs", ctx, filter)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListIDs indicates an expected call of ListIDs.
+func (mr *MockContainerRuntimeMockRecorder) ListIDs(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIDs", reflect.TypeOf((*MockContainerRuntime)(nil).ListIDs), ctx, filter)
+}
+
+// ListStatuses mocks base method.
+func (m *MockContainerRuntime) ListStatuses(ctx context.Context, filter infrastructure.ContainerFilter) (map[string]container.ContainerState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStatuses", ctx, filter)
+	ret0, _ := ret[0].(map[string]container.ContainerState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStatuses indicates an expected call of ListStatuses.
+func (mr *MockContainerRuntimeMockRecorder) ListStatuses(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStatuses", reflect.TypeOf((*MockContainerRuntime)(nil).ListStatuses), ctx, filter)
+}
+
+// Logs mocks base method.
+func (m *MockContainerRuntime) Logs(ctx context.Context, id string, options infrastructure.ContainerLogsOptions) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logs", ctx, id, options)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Logs indicates an expected call of Logs.
+func (mr *MockContainerRuntimeMockRecorder) Logs(ctx, id, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logs", reflect.TypeOf((*MockContainerRuntime)(nil).Logs), ctx, id, options)
+}
+
 // Remove mocks base method.
 func (m *MockContainerRuntime) Remove(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
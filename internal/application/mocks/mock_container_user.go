@@ -10,6 +10,8 @@
 package mocks
 
 import (
+	entity "container-manager/internal/domain/entity"
+	infrastructure "container-manager/internal/domain/infrastructure"
 	context "context"
 	reflect "reflect"
 
@@ -40,18 +42,33 @@ func (m *MockContainerUserRepository) EXPECT() *MockContainerUserRepositoryMockR
 	return m.recorder
 }
 
+// CountByOwner mocks base method.
+func (m *MockContainerUserRepository) CountByOwner(ctx context.Context, owner entity.Owner, ids []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByOwner", ctx, owner, ids)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByOwner indicates an expected call of CountByOwner.
+func (mr *MockContainerUserRepositoryMockRecorder) CountByOwner(ctx, owner, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByOwner", reflect.TypeOf((*MockContainerUserRepository)(nil).CountByOwner), ctx, owner, ids)
+}
+
 // Create mocks base method.
-func (m *MockContainerUserRepository) Create(ctx context.Context, containerID string, userID int64) error {
+func (m *MockContainerUserRepository) Create(ctx context.Context, containerUser *entity.ContainerUser) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, containerID, userID)
+	ret := m.ctrl.Call(m, "Create", ctx, containerUser)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockContainerUserRepositoryMockRecorder) Create(ctx, containerID, userID any) *gomock.Call {
+func (mr *MockContainerUserRepositoryMockRecorder) Create(ctx, containerUser any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockContainerUserRepository)(nil).Create), ctx, containerID, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockContainerUserRepository)(nil).Create), ctx, containerUser)
 }
 
 // Delete mocks base method.
@@ -68,19 +85,79 @@ func (mr *MockContainerUserRepositoryMockRecorder) Delete(ctx, containerID any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockContainerUserRepository)(nil).Delete), ctx, containerID)
 }
 
-// GetContainerIDsByUserID mocks base method.
-func (m *MockContainerUserRepository) GetContainerIDsByUserID(ctx context.Context, userID int64) ([]string, error) {
+// FindByIDOrName mocks base method.
+func (m *MockContainerUserRepository) FindByIDOrName(ctx context.Context, userID int64, idOrName string) (*entity.ContainerUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByIDOrName", ctx, userID, idOrName)
+	ret0, _ := ret[0].(*entity.ContainerUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByIDOrName indicates an expected call of FindByIDOrName.
+func (mr *MockContainerUserRepositoryMockRecorder) FindByIDOrName(ctx, userID, idOrName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIDOrName", reflect.TypeOf((*MockContainerUserRepository)(nil).FindByIDOrName), ctx, userID, idOrName)
+}
+
+// GetByOwner mocks base method.
+func (m *MockContainerUserRepository) GetByOwner(ctx context.Context, owner entity.Owner) ([]*entity.ContainerUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByOwner", ctx, owner)
+	ret0, _ := ret[0].([]*entity.ContainerUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByOwner indicates an expected call of GetByOwner.
+func (mr *MockContainerUserRepositoryMockRecorder) GetByOwner(ctx, owner any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByOwner", reflect.TypeOf((*MockContainerUserRepository)(nil).GetByOwner), ctx, owner)
+}
+
+// GetContainerIDsByOwner mocks base method.
+func (m *MockContainerUserRepository) GetContainerIDsByOwner(ctx context.Context, owner entity.Owner) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetContainerIDsByUserID", ctx, userID)
+	ret := m.ctrl.Call(m, "GetContainerIDsByOwner", ctx, owner)
 	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetContainerIDsByUserID indicates an expected call of GetContainerIDsByUserID.
-func (mr *MockContainerUserRepositoryMockRecorder) GetContainerIDsByUserID(ctx, userID any) *gomock.Call {
+// GetContainerIDsByOwner indicates an expected call of GetContainerIDsByOwner.
+func (mr *MockContainerUserRepositoryMockRecorder) GetContainerIDsByOwner(ctx, owner any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContainerIDsByOwner", reflect.TypeOf((*MockContainerUserRepository)(nil).GetContainerIDsByOwner), ctx, owner)
+}
+
+// GetPageByOwner mocks base method.
+func (m *MockContainerUserRepository) GetPageByOwner(ctx context.Context, owner entity.Owner, page infrastructure.ContainerUserPage) ([]*entity.ContainerUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPageByOwner", ctx, owner, page)
+	ret0, _ := ret[0].([]*entity.ContainerUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPageByOwner indicates an expected call of GetPageByOwner.
+func (mr *MockContainerUserRepositoryMockRecorder) GetPageByOwner(ctx, owner, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPageByOwner", reflect.TypeOf((*MockContainerUserRepository)(nil).GetPageByOwner), ctx, owner, page)
+}
+
+// GetRunningByUserID mocks base method.
+func (m *MockContainerUserRepository) GetRunningByUserID(ctx context.Context, userID int64) ([]*entity.ContainerUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRunningByUserID", ctx, userID)
+	ret0, _ := ret[0].([]*entity.ContainerUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRunningByUserID indicates an expected call of GetRunningByUserID.
+func (mr *MockContainerUserRepositoryMockRecorder) GetRunningByUserID(ctx, userID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContainerIDsByUserID", reflect.TypeOf((*MockContainerUserRepository)(nil).GetContainerIDsByUserID), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRunningByUserID", reflect.TypeOf((*MockContainerUserRepository)(nil).GetRunningByUserID), ctx, userID)
 }
 
 // GetUserIDByContainerID mocks base method.
@@ -97,3 +174,32 @@ func (mr *MockContainerUserRepositoryMockRecorder) GetUserIDByContainerID(ctx, c
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIDByContainerID", reflect.TypeOf((*MockContainerUserRepository)(nil).GetUserIDByContainerID), ctx, containerID)
 }
+
+// SetRunning mocks base method.
+func (m *MockContainerUserRepository) SetRunning(ctx context.Context, containerID string, running bool) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRunning", ctx, containerID, running)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetRunning indicates an expected call of SetRunning.
+func (mr *MockContainerUserRepositoryMockRecorder) SetRunning(ctx, containerID, running any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRunning", reflect.TypeOf((*MockContainerUserRepository)(nil).SetRunning), ctx, containerID, running)
+}
+
+// UpdateName mocks base method.
+func (m *MockContainerUserRepository) UpdateName(ctx context.Context, containerID, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateName", ctx, containerID, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateName indicates an expected call of UpdateName.
+func (mr *MockContainerUserRepositoryMockRecorder) UpdateName(ctx, containerID, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateName", reflect.TypeOf((*MockContainerUserRepository)(nil).UpdateName), ctx, containerID, name)
+}
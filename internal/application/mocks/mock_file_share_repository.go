@@ -0,0 +1,146 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/domain/infrastructure/file_share.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/domain/infrastructure/file_share.go -destination=internal/application/mocks/mock_file_share_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	entity "container-manager/internal/domain/entity"
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFileShareRepository is a mock of FileShareRepository interface.
+type MockFileShareRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileShareRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockFileShareRepositoryMockRecorder is the mock recorder for MockFileShareRepository.
+type MockFileShareRepositoryMockRecorder struct {
+	mock *MockFileShareRepository
+}
+
+// NewMockFileShareRepository creates a new mock instance.
+func NewMockFileShareRepository(ctrl *gomock.Controller) *MockFileShareRepository {
+	mock := &MockFileShareRepository{ctrl: ctrl}
+	mock.recorder = &MockFileShareRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileShareRepository) EXPECT() *MockFileShareRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CountDownload mocks base method.
+func (m *MockFileShareRepository) CountDownload(ctx context.Context, id string, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountDownload", ctx, id, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountDownload indicates an expected call of CountDownload.
+func (mr *MockFileShareRepositoryMockRecorder) CountDownload(ctx, id, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountDownload", reflect.TypeOf((*MockFileShareRepository)(nil).CountDownload), ctx, id, now)
+}
+
+// Create mocks base method.
+func (m *MockFileShareRepository) Create(ctx context.Context, share *entity.FileShare) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, share)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockFileShareRepositoryMockRecorder) Create(ctx, share any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockFileShareRepository)(nil).Create), ctx, share)
+}
+
+// GetByID mocks base method.
+func (m *MockFileShareRepository) GetByID(ctx context.Context, id string) (*entity.FileShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*entity.FileShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockFileShareRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockFileShareRepository)(nil).GetByID), ctx, id)
+}
+
+// ListByTeamID mocks base method.
+func (m *MockFileShareRepository) ListByTeamID(ctx context.Context, teamID int64, now time.Time) ([]*entity.FileShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByTeamID", ctx, teamID, now)
+	ret0, _ := ret[0].([]*entity.FileShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByTeamID indicates an expected call of ListByTeamID.
+func (mr *MockFileShareRepositoryMockRecorder) ListByTeamID(ctx, teamID, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByTeamID", reflect.TypeOf((*MockFileShareRepository)(nil).ListByTeamID), ctx, teamID, now)
+}
+
+// ListByUserID mocks base method.
+func (m *MockFileShareRepository) ListByUserID(ctx context.Context, userID int64, now time.Time) ([]*entity.FileShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUserID", ctx, userID, now)
+	ret0, _ := ret[0].([]*entity.FileShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUserID indicates an expected call of ListByUserID.
+func (mr *MockFileShareRepositoryMockRecorder) ListByUserID(ctx, userID, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUserID", reflect.TypeOf((*MockFileShareRepository)(nil).ListByUserID), ctx, userID, now)
+}
+
+// Revoke mocks base method.
+func (m *MockFileShareRepository) Revoke(ctx context.Context, id string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockFileShareRepositoryMockRecorder) Revoke(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockFileShareRepository)(nil).Revoke), ctx, id)
+}
+
+// RevokeByMember mocks base method.
+func (m *MockFileShareRepository) RevokeByMember(ctx context.Context, teamID, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeByMember", ctx, teamID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeByMember indicates an expected call of RevokeByMember.
+func (mr *MockFileShareRepositoryMockRecorder) RevokeByMember(ctx, teamID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeByMember", reflect.TypeOf((*MockFileShareRepository)(nil).RevokeByMember), ctx, teamID, userID)
+}
@@ -10,6 +10,7 @@
 package mocks
 
 import (
+	entity "container-manager/internal/domain/entity"
 	io "io"
 	reflect "reflect"
 
@@ -40,17 +41,206 @@ func (m *MockFileStorage) EXPECT() *MockFileStorageMockRecorder {
 	return m.recorder
 }
 
+// CollectGarbage mocks base method.
+func (m *MockFileStorage) CollectGarbage() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CollectGarbage")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CollectGarbage indicates an expected call of CollectGarbage.
+func (mr *MockFileStorageMockRecorder) CollectGarbage() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CollectGarbage", reflect.TypeOf((*MockFileStorage)(nil).CollectGarbage))
+}
+
+// CommitUpload mocks base method.
+func (m *MockFileStorage) CommitUpload(owner entity.Owner, id, filename string) (*entity.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitUpload", owner, id, filename)
+	ret0, _ := ret[0].(*entity.FileInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CommitUpload indicates an expected call of CommitUpload.
+func (mr *MockFileStorageMockRecorder) CommitUpload(owner, id, filename any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitUpload", reflect.TypeOf((*MockFileStorage)(nil).CommitUpload), owner, id, filename)
+}
+
+// DeleteDir mocks base method.
+func (m *MockFileStorage) DeleteDir(owner entity.Owner, dir string, recursive bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDir", owner, dir, recursive)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDir indicates an expected call of DeleteDir.
+func (mr *MockFileStorageMockRecorder) DeleteDir(owner, dir, recursive any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDir", reflect.TypeOf((*MockFileStorage)(nil).DeleteDir), owner, dir, recursive)
+}
+
+// DeleteFile mocks base method.
+func (m *MockFileStorage) DeleteFile(owner entity.Owner, filename string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFile", owner, filename)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFile indicates an expected call of DeleteFile.
+func (mr *MockFileStorageMockRecorder) DeleteFile(owner, filename any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*MockFileStorage)(nil).DeleteFile), owner, filename)
+}
+
+// DeleteUpload mocks base method.
+func (m *MockFileStorage) DeleteUpload(owner entity.Owner, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUpload", owner, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUpload indicates an expected call of DeleteUpload.
+func (mr *MockFileStorageMockRecorder) DeleteUpload(owner, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUpload", reflect.TypeOf((*MockFileStorage)(nil).DeleteUpload), owner, id)
+}
+
+// FileSize mocks base method.
+func (m *MockFileStorage) FileSize(owner entity.Owner, filename string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FileSize", owner, filename)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FileSize indicates an expected call of FileSize.
+func (mr *MockFileStorageMockRecorder) FileSize(owner, filename any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FileSize", reflect.TypeOf((*MockFileStorage)(nil).FileSize), owner, filename)
+}
+
+// ListFiles mocks base method.
+func (m *MockFileStorage) ListFiles(owner entity.Owner, dir string, recursive bool) ([]*entity.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFiles", owner, dir, recursive)
+	ret0, _ := ret[0].([]*entity.FileInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFiles indicates an expected call of ListFiles.
+func (mr *MockFileStorageMockRecorder) ListFiles(owner, dir, recursive any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFiles", reflect.TypeOf((*MockFileStorage)(nil).ListFiles), owner, dir, recursive)
+}
+
+// MakeDir mocks base method.
+func (m *MockFileStorage) MakeDir(owner entity.Owner, dir string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MakeDir", owner, dir)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MakeDir indicates an expected call of MakeDir.
+func (mr *MockFileStorageMockRecorder) MakeDir(owner, dir any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MakeDir", reflect.TypeOf((*MockFileStorage)(nil).MakeDir), owner, dir)
+}
+
+// Move mocks base method.
+func (m *MockFileStorage) Move(owner entity.Owner, from, to string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Move", owner, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Move indicates an expected call of Move.
+func (mr *MockFileStorageMockRecorder) Move(owner, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Move", reflect.TypeOf((*MockFileStorage)(nil).Move), owner, from, to)
+}
+
+// OpenFile mocks base method.
+func (m *MockFileStorage) OpenFile(owner entity.Owner, filename string) (io.ReadSeekCloser, *entity.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenFile", owner, filename)
+	ret0, _ := ret[0].(io.ReadSeekCloser)
+	ret1, _ := ret[1].(*entity.FileInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// OpenFile indicates an expected call of OpenFile.
+func (mr *MockFileStorageMockRecorder) OpenFile(owner, filename any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenFile", reflect.TypeOf((*MockFileStorage)(nil).OpenFile), owner, filename)
+}
+
+// RemoveAll mocks base method.
+func (m *MockFileStorage) RemoveAll(owner entity.Owner) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveAll", owner)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveAll indicates an expected call of RemoveAll.
+func (mr *MockFileStorageMockRecorder) RemoveAll(owner any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAll", reflect.TypeOf((*MockFileStorage)(nil).RemoveAll), owner)
+}
+
 // SaveFile mocks base method.
-func (m *MockFileStorage) SaveFile(userID int64, filename string, fileContent io.Reader) (string, error) {
+func (m *MockFileStorage) SaveFile(owner entity.Owner, filename string, fileContent io.Reader) (*entity.FileInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveFile", userID, filename, fileContent)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "SaveFile", owner, filename, fileContent)
+	ret0, _ := ret[0].(*entity.FileInfo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SaveFile indicates an expected call of SaveFile.
-func (mr *MockFileStorageMockRecorder) SaveFile(userID, filename, fileContent any) *gomock.Call {
+func (mr *MockFileStorageMockRecorder) SaveFile(owner, filename, fileContent any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFile", reflect.TypeOf((*MockFileStorage)(nil).SaveFile), owner, filename, fileContent)
+}
+
+// StatFile mocks base method.
+func (m *MockFileStorage) StatFile(owner entity.Owner, filename string) (*entity.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatFile", owner, filename)
+	ret0, _ := ret[0].(*entity.FileInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StatFile indicates an expected call of StatFile.
+func (mr *MockFileStorageMockRecorder) StatFile(owner, filename any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatFile", reflect.TypeOf((*MockFileStorage)(nil).StatFile), owner, filename)
+}
+
+// WriteUpload mocks base method.
+func (m *MockFileStorage) WriteUpload(owner entity.Owner, id string, offset int64, content io.Reader) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteUpload", owner, id, offset, content)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WriteUpload indicates an expected call of WriteUpload.
+func (mr *MockFileStorageMockRecorder) WriteUpload(owner, id, offset, content any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFile", reflect.TypeOf((*MockFileStorage)(nil).SaveFile), userID, filename, fileContent)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteUpload", reflect.TypeOf((*MockFileStorage)(nil).WriteUpload), owner, id, offset, content)
 }
@@ -55,6 +55,21 @@ func (mr *MockJobRepositoryMockRecorder) Create(ctx, job any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockJobRepository)(nil).Create), ctx, job)
 }
 
+// DeleteByUserID mocks base method.
+func (m *MockJobRepository) DeleteByUserID(ctx context.Context, userID int64, exceptID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUserID", ctx, userID, exceptID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByUserID indicates an expected call of DeleteByUserID.
+func (mr *MockJobRepositoryMockRecorder) DeleteByUserID(ctx, userID, exceptID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUserID", reflect.TypeOf((*MockJobRepository)(nil).DeleteByUserID), ctx, userID, exceptID)
+}
+
 // GetByID mocks base method.
 func (m *MockJobRepository) GetByID(ctx context.Context, id string) (*entity.Job, error) {
 	m.ctrl.T.Helper()
@@ -70,6 +85,21 @@ func (mr *MockJobRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockJobRepository)(nil).GetByID), ctx, id)
 }
 
+// ListByUserID mocks base method.
+func (m *MockJobRepository) ListByUserID(ctx context.Context, userID int64, limit int) ([]*entity.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUserID", ctx, userID, limit)
+	ret0, _ := ret[0].([]*entity.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUserID indicates an expected call of ListByUserID.
+func (mr *MockJobRepositoryMockRecorder) ListByUserID(ctx, userID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUserID", reflect.TypeOf((*MockJobRepository)(nil).ListByUserID), ctx, userID, limit)
+}
+
 // Update mocks base method.
 func (m *MockJobRepository) Update(ctx context.Context, job *entity.Job) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/domain/infrastructure/login.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/domain/infrastructure/login.go -destination=internal/application/mocks/mock_login_failure_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	entity "container-manager/internal/domain/entity"
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLoginFailureRepository is a mock of LoginFailureRepository interface.
+type MockLoginFailureRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginFailureRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockLoginFailureRepositoryMockRecorder is the mock recorder for MockLoginFailureRepository.
+type MockLoginFailureRepositoryMockRecorder struct {
+	mock *MockLoginFailureRepository
+}
+
+// NewMockLoginFailureRepository creates a new mock instance.
+func NewMockLoginFailureRepository(ctrl *gomock.Controller) *MockLoginFailureRepository {
+	mock := &MockLoginFailureRepository{ctrl: ctrl}
+	mock.recorder = &MockLoginFailureRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginFailureRepository) EXPECT() *MockLoginFailureRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Forgive mocks base method.
+func (m *MockLoginFailureRepository) Forgive(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Forgive", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Forgive indicates an expected call of Forgive.
+func (mr *MockLoginFailureRepositoryMockRecorder) Forgive(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Forgive", reflect.TypeOf((*MockLoginFailureRepository)(nil).Forgive), ctx, key)
+}
+
+// Get mocks base method.
+func (m *MockLoginFailureRepository) Get(ctx context.Context, key string) (*entity.LoginFailures, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(*entity.LoginFailures)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockLoginFailureRepositoryMockRecorder) Get(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockLoginFailureRepository)(nil).Get), ctx, key)
+}
+
+// Reserve mocks base method.
+func (m *MockLoginFailureRepository) Reserve(ctx context.Context, key string, seen *entity.LoginFailures, resetBefore time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", ctx, key, seen, resetBefore)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reserve indicates an expected call of Reserve.
+func (mr *MockLoginFailureRepositoryMockRecorder) Reserve(ctx, key, seen, resetBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockLoginFailureRepository)(nil).Reserve), ctx, key, seen, resetBefore)
+}
+
+// Reset mocks base method.
+func (m *MockLoginFailureRepository) Reset(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockLoginFailureRepositoryMockRecorder) Reset(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockLoginFailureRepository)(nil).Reset), ctx, key)
+}
@@ -0,0 +1,160 @@
+package application
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
+	"context"
+	"crypto/rand"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// oidcLoginTTL is how long a user has to sign in at the identity provider.
+const oidcLoginTTL = 10 * time.Minute
+
+// OIDCProviderOptions configures how the accounts of an identity provider map to local users.
+type OIDCProviderOptions struct {
+	Provider infrastructure.OIDCProvider
+	// UsernameClaims are tried in order, the first one that is set becomes the username of a new user.
+	UsernameClaims []string
+	// LinkExistingUsers links a first login to the local user with the same username instead of
+	// refusing it. Only enable this for providers that control the usernames they hand out.
+	LinkExistingUsers bool
+}
+
+// OIDCService signs users in with OpenID Connect identity providers.
+type OIDCService struct {
+	providers      map[string]OIDCProviderOptions
+	loginStateRepo infrastructure.OIDCLoginStateRepository
+	identityRepo   infrastructure.UserIdentityRepository
+	userRepo       infrastructure.UserRepository
+	userService    *UserService
+	idNode         *snowflake.Node
+}
+
+func NewOIDCService(providers map[string]OIDCProviderOptions, loginStateRepo infrastructure.OIDCLoginStateRepository, identityRepo infrastructure.UserIdentityRepository, userRepo infrastructure.UserRepository, userService *UserService, idNode *snowflake.Node) *OIDCService {
+	return &OIDCService{
+		providers:      providers,
+		loginStateRepo: loginStateRepo,
+		identityRepo:   identityRepo,
+		userRepo:       userRepo,
+		userService:    userService,
+		idNode:         idNode,
+	}
+}
+
+// AuthorizationURL starts a login and returns the URL of the provider the user signs in at.
+func (s *OIDCService) AuthorizationURL(ctx context.Context, providerName string) (string, error) {
+	options, ok := s.providers[providerName]
+	if !ok {
+		return "", errors.OIDCProviderNotFound
+	}
+
+	loginState, err := entity.NewOIDCLoginState(providerName, oidcLoginTTL)
+	if err != nil {
+		return "", err
+	}
+	if err := s.loginStateRepo.Create(ctx, loginState); err != nil {
+		return "", err
+	}
+
+	return options.Provider.AuthCodeURL(ctx, loginState.State, loginState.Nonce, loginState.CodeChallenge())
+}
+
+// Callback completes a login with the code the provider redirected back with. The first login
+// of a provider account provisions a local user, or links an existing one.
+func (s *OIDCService) Callback(ctx context.Context, providerName, code, state string) (*entity.User, *TokenPair, error) {
+	options, ok := s.providers[providerName]
+	if !ok {
+		return nil, nil, errors.OIDCProviderNotFound
+	}
+
+	loginState, err := s.loginStateRepo.Take(ctx, state)
+	if err != nil {
+		return nil, nil, err
+	}
+	if loginState == nil || loginState.Provider != providerName || time.Now().After(loginState.ExpiresAt) {
+		return nil, nil, errors.InvalidOIDCState
+	}
+
+	claims, err := options.Provider.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.Printf("login at identity provider %s failed: %v", providerName, err)
+		return nil, nil, errors.OIDCLoginFailed.Wrap(err)
+	}
+	subject, _ := claims["sub"].(string)
+
+	user, err := s.findOrProvisionUser(ctx, providerName, subject, options, claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.userService.StartSession(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+func (s *OIDCService) findOrProvisionUser(ctx context.Context, providerName, subject string, options OIDCProviderOptions, claims map[string]any) (*entity.User, error) {
+	identity, err := s.identityRepo.Find(ctx, providerName, subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		user, err := s.userRepo.FindByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errors.UserNotFound
+		}
+		return user, nil
+	}
+
+	username := usernameFromClaims(claims, options.UsernameClaims)
+	if username == "" {
+		return nil, errors.OIDCLoginFailed.New("the identity provider returned none of the username claims")
+	}
+
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil && !options.LinkExistingUsers {
+		return nil, errors.UsernameTaken
+	}
+	if user == nil {
+		// Users of identity providers do not sign in with a password, they get one nobody knows.
+		user, err = entity.NewUser(s.idNode.Generate().Int64(), username, rand.Text())
+		if err != nil {
+			return nil, err
+		}
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.identityRepo.Create(ctx, &entity.UserIdentity{
+		Provider:  providerName,
+		Subject:   subject,
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func usernameFromClaims(claims map[string]any, names []string) string {
+	for _, name := range names {
+		if value, ok := claims[name].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
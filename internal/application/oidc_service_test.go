@@ -0,0 +1,197 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	internalErrors "container-manager/internal/errors"
+	keymanager "container-manager/internal/infrastructure/key_manager"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type oidcServiceMocks struct {
+	provider       *mocks.MockOIDCProvider
+	loginStateRepo *mocks.MockOIDCLoginStateRepository
+	identityRepo   *mocks.MockUserIdentityRepository
+	userRepo       *mocks.MockUserRepository
+	refreshRepo    *mocks.MockRefreshTokenRepository
+}
+
+func newOIDCService(t *testing.T, linkExistingUsers bool) (*OIDCService, oidcServiceMocks) {
+	ctrl := gomock.NewController(t)
+	m := oidcServiceMocks{
+		provider:       mocks.NewMockOIDCProvider(ctrl),
+		loginStateRepo: mocks.NewMockOIDCLoginStateRepository(ctrl),
+		identityRepo:   mocks.NewMockUserIdentityRepository(ctrl),
+		userRepo:       mocks.NewMockUserRepository(ctrl),
+		refreshRepo:    mocks.NewMockRefreshTokenRepository(ctrl),
+	}
+	idNode, _ := snowflake.NewNode(1)
+	keyManager, err := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "test_secret"})
+	require.NoError(t, err)
+	userService := NewUserService(m.userRepo, m.refreshRepo, nil, nil, keyManager, idNode, TokenOptions{}, entity.PasswordPolicy{}, nil)
+
+	service := NewOIDCService(map[string]OIDCProviderOptions{
+		"company": {Provider: m.provider, UsernameClaims: []string{"preferred_username", "email"}, LinkExistingUsers: linkExistingUsers},
+	}, m.loginStateRepo, m.identityRepo, m.userRepo, userService, idNode)
+	return service, m
+}
+
+func TestOIDCService_AuthorizationURL(t *testing.T) {
+	service, m := newOIDCService(t, false)
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		var stored *entity.OIDCLoginState
+		m.loginStateRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, state *entity.OIDCLoginState) error {
+			stored = state
+			return nil
+		})
+		m.provider.EXPECT().AuthCodeURL(ctx, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, state, nonce, challenge string) (string, error) {
+			assert.Equal(t, stored.State, state)
+			assert.Equal(t, stored.Nonce, nonce)
+			assert.Equal(t, stored.CodeChallenge(), challenge)
+			return "https://idp.example.com/authorize?" + url.Values{"state": {state}}.Encode(), nil
+		})
+
+		authURL, err := service.AuthorizationURL(ctx, "company")
+		assert.NoError(t, err)
+		assert.Contains(t, authURL, stored.State)
+		assert.Equal(t, "company", stored.Provider)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		_, err := service.AuthorizationURL(ctx, "other")
+		assert.Equal(t, internalErrors.OIDCProviderNotFound, err)
+	})
+}
+
+func TestOIDCService_Callback(t *testing.T) {
+	ctx := context.Background()
+	loginState := func() *entity.OIDCLoginState {
+		return &entity.OIDCLoginState{State: "state", Provider: "company", CodeVerifier: "verifier", Nonce: "nonce", ExpiresAt: time.Now().Add(time.Minute)}
+	}
+
+	t.Run("known identity", func(t *testing.T) {
+		service, m := newOIDCService(t, false)
+		user := &entity.User{ID: 1234, Username: "alice"}
+		m.loginStateRepo.EXPECT().Take(ctx, "state").Return(loginState(), nil)
+		m.provider.EXPECT().Exchange(ctx, "code", "verifier", "nonce").Return(map[string]any{"sub": "subject"}, nil)
+		m.identityRepo.EXPECT().Find(ctx, "company", "subject").Return(&entity.UserIdentity{Provider: "company", Subject: "subject", UserID: 1234}, nil)
+		m.userRepo.EXPECT().FindByID(ctx, int64(1234)).Return(user, nil)
+		m.refreshRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+
+		signedIn, tokens, err := service.Callback(ctx, "company", "code", "state")
+		assert.NoError(t, err)
+		assert.Equal(t, user, signedIn)
+		assert.NotEmpty(t, tokens.AccessToken)
+	})
+
+	t.Run("first login provisions a user", func(t *testing.T) {
+		service, m := newOIDCService(t, false)
+		m.loginStateRepo.EXPECT().Take(ctx, "state").Return(loginState(), nil)
+		m.provider.EXPECT().Exchange(ctx, "code", "verifier", "nonce").Return(map[string]any{"sub": "subject", "email": "alice@example.com"}, nil)
+		m.identityRepo.EXPECT().Find(ctx, "company", "subject").Return(nil, nil)
+		m.userRepo.EXPECT().FindByUsername(ctx, "alice@example.com").Return(nil, nil)
+		var created *entity.User
+		m.userRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, user *entity.User) error {
+			created = user
+			return nil
+		})
+		m.identityRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, identity *entity.UserIdentity) error {
+			assert.Equal(t, created.ID, identity.UserID)
+			assert.Equal(t, "subject", identity.Subject)
+			return nil
+		})
+		m.refreshRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+
+		user, _, err := service.Callback(ctx, "company", "code", "state")
+		assert.NoError(t, err)
+		assert.Equal(t, "alice@example.com", user.Username)
+	})
+
+	t.Run("username of a local user", func(t *testing.T) {
+		service, m := newOIDCService(t, false)
+		m.loginStateRepo.EXPECT().Take(ctx, "state").Return(loginState(), nil)
+		m.provider.EXPECT().Exchange(ctx, "code", "verifier", "nonce").Return(map[string]any{"sub": "subject", "preferred_username": "alice"}, nil)
+		m.identityRepo.EXPECT().Find(ctx, "company", "subject").Return(nil, nil)
+		m.userRepo.EXPECT().FindByUsername(ctx, "alice").Return(&entity.User{ID: 1234, Username: "alice"}, nil)
+
+		_, _, err := service.Callback(ctx, "company", "code", "state")
+		assert.Equal(t, internalErrors.UsernameTaken, err)
+	})
+
+	t.Run("links a local user", func(t *testing.T) {
+		service, m := newOIDCService(t, true)
+		m.loginStateRepo.EXPECT().Take(ctx, "state").Return(loginState(), nil)
+		m.provider.EXPECT().Exchange(ctx, "code", "verifier", "nonce").Return(map[string]any{"sub": "subject", "preferred_username": "alice"}, nil)
+		m.identityRepo.EXPECT().Find(ctx, "company", "subject").Return(nil, nil)
+		m.userRepo.EXPECT().FindByUsername(ctx, "alice").Return(&entity.User{ID: 1234, Username: "alice"}, nil)
+		m.identityRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+		m.refreshRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+
+		user, _, err := service.Callback(ctx, "company", "code", "state")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1234), user.ID)
+	})
+
+	t.Run("no username claim", func(t *testing.T) {
+		service, m := newOIDCService(t, false)
+		m.loginStateRepo.EXPECT().Take(ctx, "state").Return(loginState(), nil)
+		m.provider.EXPECT().Exchange(ctx, "code", "verifier", "nonce").Return(map[string]any{"sub": "subject"}, nil)
+		m.identityRepo.EXPECT().Find(ctx, "company", "subject").Return(nil, nil)
+
+		_, _, err := service.Callback(ctx, "company", "code", "state")
+		var customErr *internalErrors.CustomError
+		assert.ErrorAs(t, err, &customErr)
+		assert.Equal(t, internalErrors.OIDCLoginFailed.Message, customErr.Message)
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		service, m := newOIDCService(t, false)
+		m.loginStateRepo.EXPECT().Take(ctx, "state").Return(nil, nil)
+
+		_, _, err := service.Callback(ctx, "company", "code", "state")
+		assert.Equal(t, internalErrors.InvalidOIDCState, err)
+	})
+
+	t.Run("expired state", func(t *testing.T) {
+		service, m := newOIDCService(t, false)
+		state := loginState()
+		state.ExpiresAt = time.Now().Add(-time.Second)
+		m.loginStateRepo.EXPECT().Take(ctx, "state").Return(state, nil)
+
+		_, _, err := service.Callback(ctx, "company", "code", "state")
+		assert.Equal(t, internalErrors.InvalidOIDCState, err)
+	})
+
+	t.Run("state of another provider", func(t *testing.T) {
+		service, m := newOIDCService(t, false)
+		state := loginState()
+		state.Provider = "other"
+		m.loginStateRepo.EXPECT().Take(ctx, "state").Return(state, nil)
+
+		_, _, err := service.Callback(ctx, "company", "code", "state")
+		assert.Equal(t, internalErrors.InvalidOIDCState, err)
+	})
+
+	t.Run("exchange failed", func(t *testing.T) {
+		service, m := newOIDCService(t, false)
+		m.loginStateRepo.EXPECT().Take(ctx, "state").Return(loginState(), nil)
+		m.provider.EXPECT().Exchange(ctx, "code", "verifier", "nonce").Return(nil, errors.New("invalid_grant"))
+
+		_, _, err := service.Callback(ctx, "company", "code", "state")
+		var customErr *internalErrors.CustomError
+		assert.ErrorAs(t, err, &customErr)
+		assert.Equal(t, internalErrors.OIDCLoginFailed.Message, customErr.Message)
+	})
+}
@@ -0,0 +1,115 @@
+package application
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
+	"context"
+	"log"
+)
+
+// QuotaOptions configures the QuotaService.
+type QuotaOptions struct {
+	// DefaultLimits apply to users without custom limits. Zero means unlimited.
+	DefaultLimits entity.QuotaResources
+	// ContainerMemoryBytes and ContainerNanoCPUs are reserved for containers created without explicit limits.
+	ContainerMemoryBytes int64
+	ContainerNanoCPUs    int64
+}
+
+// QuotaService keeps track of the resources reserved by each user, and refuses
+// reservations that would go over the user's limits.
+type QuotaService struct {
+	quotaRepo infrastructure.QuotaRepository
+	options   QuotaOptions
+}
+
+func NewQuotaService(quotaRepo infrastructure.QuotaRepository, options QuotaOptions) *QuotaService {
+	return &QuotaService{quotaRepo: quotaRepo, options: options}
+}
+
+// GetQuota returns the limits of the user together with the current usage.
+func (s *QuotaService) GetQuota(ctx context.Context, userID int64) (*entity.Quota, error) {
+	limits, err := s.limits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.quotaRepo.GetUsage(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.Quota{Limits: limits, Usage: *usage}, nil
+}
+
+func (s *QuotaService) limits(ctx context.Context, userID int64) (entity.QuotaResources, error) {
+	limits, err := s.quotaRepo.GetLimits(ctx, userID)
+	if err != nil {
+		return entity.QuotaResources{}, err
+	}
+	if limits == nil {
+		return s.options.DefaultLimits, nil
+	}
+	return *limits, nil
+}
+
+// reserve reserves delta for the user, or returns errors.QuotaExceeded naming the exhausted resource.
+func (s *QuotaService) reserve(ctx context.Context, userID int64, delta entity.QuotaResources) error {
+	limits, err := s.limits(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.reserveWithin(ctx, userID, delta, limits)
+}
+
+// reserveStorage reserves up to size bytes of storage for the user, no more than the storage quota
+// has left, and returns how much it reserved.
+func (s *QuotaService) reserveStorage(ctx context.Context, userID int64, size int64) (int64, error) {
+	limits, err := s.limits(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if limits.StorageBytes > 0 {
+		usage, err := s.quotaRepo.GetUsage(ctx, userID)
+		if err != nil {
+			return 0, err
+		}
+		size = min(size, max(limits.StorageBytes-usage.StorageBytes, 0))
+	}
+	return size, s.reserveWithin(ctx, userID, entity.QuotaResources{StorageBytes: size}, limits)
+}
+
+func (s *QuotaService) reserveWithin(ctx context.Context, userID int64, delta entity.QuotaResources, limits entity.QuotaResources) error {
+	err := s.quotaRepo.Reserve(ctx, userID, delta, limits)
+	if err == nil || !errors.QuotaExceeded.Is(err) {
+		return err
+	}
+
+	// The reservation is already refused, the usage is only read to tell which resource ran out.
+	usage, usageErr := s.quotaRepo.GetUsage(ctx, userID)
+	if usageErr != nil {
+		return err
+	}
+	if resource := limits.Exceeded(*usage, delta); resource != "" {
+		return errors.QuotaExceeded.New(resource + " quota exceeded")
+	}
+	return err
+}
+
+// release gives back resources reserved by the user. Failures are only logged, since
+// the operation that freed the resources has already succeeded.
+func (s *QuotaService) release(ctx context.Context, userID int64, delta entity.QuotaResources) {
+	if err := s.quotaRepo.Release(ctx, userID, delta); err != nil {
+		log.Printf("failed to release quota of user %d: %v", userID, err)
+	}
+}
+
+// containerResources applies the default resource limits to options.
+func (s *QuotaService) containerResources(options infrastructure.ContainerCreateOptions) infrastructure.ContainerCreateOptions {
+	if options.MemoryBytes == 0 {
+		options.MemoryBytes = s.options.ContainerMemoryBytes
+	}
+	if options.NanoCPUs == 0 {
+		options.NanoCPUs = s.options.ContainerNanoCPUs
+	}
+	return options
+}
@@ -0,0 +1,54 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestQuotaService_GetQuota(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	defaults := entity.QuotaResources{Containers: 20, StorageBytes: 1024}
+	service := NewQuotaService(mockQuotaRepo, QuotaOptions{DefaultLimits: defaults})
+
+	ctx := context.Background()
+	userID := int64(1)
+	usage := &entity.QuotaResources{Containers: 3, StorageBytes: 10}
+
+	t.Run("default limits", func(t *testing.T) {
+		mockQuotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil)
+		mockQuotaRepo.EXPECT().GetUsage(ctx, userID).Return(usage, nil)
+
+		quota, err := service.GetQuota(ctx, userID)
+		assert.NoError(t, err)
+		assert.Equal(t, &entity.Quota{Limits: defaults, Usage: *usage}, quota)
+	})
+
+	t.Run("custom limits", func(t *testing.T) {
+		custom := &entity.QuotaResources{Containers: 100}
+		mockQuotaRepo.EXPECT().GetLimits(ctx, userID).Return(custom, nil)
+		mockQuotaRepo.EXPECT().GetUsage(ctx, userID).Return(usage, nil)
+
+		quota, err := service.GetQuota(ctx, userID)
+		assert.NoError(t, err)
+		assert.Equal(t, *custom, quota.Limits)
+	})
+
+	t.Run("repo error", func(t *testing.T) {
+		repoErr := errors.New("repo error")
+		mockQuotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, repoErr)
+
+		quota, err := service.GetQuota(ctx, userID)
+		assert.Equal(t, repoErr, err)
+		assert.Nil(t, quota)
+	})
+}
@@ -0,0 +1,163 @@
+package application
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
+	"context"
+	"log"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// TeamService manages teams and their members. Containers, jobs and files of a team are
+// handled by their own services, see Authorizer.
+type TeamService struct {
+	teamRepo  infrastructure.TeamRepository
+	userRepo  infrastructure.UserRepository
+	shareRepo infrastructure.FileShareRepository
+	idNode    *snowflake.Node
+}
+
+func NewTeamService(teamRepo infrastructure.TeamRepository, userRepo infrastructure.UserRepository, shareRepo infrastructure.FileShareRepository, idNode *snowflake.Node) *TeamService {
+	return &TeamService{teamRepo: teamRepo, userRepo: userRepo, shareRepo: shareRepo, idNode: idNode}
+}
+
+// CreateTeam creates a team with the caller as its owner.
+func (s *TeamService) CreateTeam(ctx context.Context, caller Caller, name string) (*entity.Team, error) {
+	if !caller.Role.Allows(entity.ActionWrite) {
+		return nil, errors.PermissionDenied
+	}
+	if err := entity.ValidateTeamName(name); err != nil {
+		return nil, err
+	}
+
+	team := &entity.Team{ID: s.idNode.Generate().Int64(), Name: name}
+	owner := &entity.TeamMember{TeamID: team.ID, UserID: caller.UserID, Role: entity.TeamRoleOwner}
+	if err := s.teamRepo.Create(ctx, team, owner); err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// ListTeams returns the teams of the caller.
+func (s *TeamService) ListTeams(ctx context.Context, caller Caller) ([]*entity.TeamMembership, error) {
+	return s.teamRepo.ListByUserID(ctx, caller.UserID)
+}
+
+// ListMembers returns the members of a team, for its members and admins.
+func (s *TeamService) ListMembers(ctx context.Context, caller Caller, teamID int64) ([]*entity.TeamMember, error) {
+	if err := s.authorizeTeam(ctx, caller, teamID, false); err != nil {
+		return nil, err
+	}
+	return s.teamRepo.ListMembers(ctx, teamID)
+}
+
+// SetMember adds a user to a team or changes the role of a member. Only owners of the team and
+// admins manage members, and the last owner of a team cannot be demoted.
+func (s *TeamService) SetMember(ctx context.Context, caller Caller, teamID int64, username string, role entity.TeamRole) (*entity.TeamMember, error) {
+	if !entity.ValidTeamRole(string(role)) {
+		return nil, errors.InvalidTeamRole
+	}
+	if err := s.authorizeTeam(ctx, caller, teamID, true); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.UserNotFound
+	}
+
+	if role != entity.TeamRoleOwner {
+		if err := s.checkOwnersLeft(ctx, teamID, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	member := &entity.TeamMember{TeamID: teamID, UserID: user.ID, Username: user.Username, Role: role}
+	if err := s.teamRepo.SetMember(ctx, member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveMember removes a member from a team. Owners and admins remove any member, other members
+// can only leave the team themselves. The last owner of a team cannot leave it. The links the
+// member shared files of the team with are revoked.
+func (s *TeamService) RemoveMember(ctx context.Context, caller Caller, teamID int64, userID int64) error {
+	if err := s.authorizeTeam(ctx, caller, teamID, userID != caller.UserID); err != nil {
+		return err
+	}
+	if err := s.checkOwnersLeft(ctx, teamID, userID); err != nil {
+		return err
+	}
+
+	removed, err := s.teamRepo.RemoveMember(ctx, teamID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.UserNotFound.New("user is not a member of the team")
+	}
+	// Downloads check that the creator of a link is still a member, so links left behind by a
+	// failure here cannot be used either.
+	if err := s.shareRepo.RevokeByMember(ctx, teamID, userID); err != nil {
+		log.Printf("failed to revoke the share links of user %d in team %d: %v", userID, teamID, err)
+	}
+	return nil
+}
+
+// checkOwnersLeft fails with errors.LastTeamOwner if the user is the only owner of the team, as the
+// team would be left without anyone but admins to manage it. The repository checks this again
+// while it changes the members, against concurrent changes.
+func (s *TeamService) checkOwnersLeft(ctx context.Context, teamID int64, userID int64) error {
+	members, err := s.teamRepo.ListMembers(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	isOwner := false
+	for _, member := range members {
+		if member.Role != entity.TeamRoleOwner {
+			continue
+		}
+		if member.UserID != userID {
+			return nil
+		}
+		isOwner = true
+	}
+	if isOwner {
+		return errors.LastTeamOwner
+	}
+	return nil
+}
+
+// authorizeTeam checks that the caller is a member of the team, and an owner if manage is set.
+// Admins may do both for every team. Changing the members of a team requires a role that
+// allows writing.
+func (s *TeamService) authorizeTeam(ctx context.Context, caller Caller, teamID int64, manage bool) error {
+	if manage && !caller.Role.Allows(entity.ActionWrite) {
+		return errors.PermissionDenied
+	}
+	team, err := s.teamRepo.Get(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	if team == nil {
+		return errors.TeamNotFound
+	}
+	if caller.Role == entity.RoleAdmin {
+		return nil
+	}
+
+	member, err := s.teamRepo.GetMember(ctx, teamID, caller.UserID)
+	if err != nil {
+		return err
+	}
+	if member == nil || (manage && member.Role != entity.TeamRoleOwner) {
+		return errors.PermissionDenied
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	internalErrors "container-manager/internal/errors"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestTeamService(t *testing.T, ctrl *gomock.Controller) (*TeamService, *mocks.MockTeamRepository, *mocks.MockUserRepository, *mocks.MockFileShareRepository) {
+	idNode, err := snowflake.NewNode(1)
+	require.NoError(t, err)
+	mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockShareRepo := mocks.NewMockFileShareRepository(ctrl)
+	return NewTeamService(mockTeamRepo, mockUserRepo, mockShareRepo, idNode), mockTeamRepo, mockUserRepo, mockShareRepo
+}
+
+func TestTeamService_CreateTeam(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service, mockTeamRepo, _, _ := newTestTeamService(t, ctrl)
+	ctx := context.Background()
+
+	t.Run("the caller becomes owner", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Create(ctx, gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, team *entity.Team, owner *entity.TeamMember) error {
+			assert.Equal(t, &entity.TeamMember{TeamID: team.ID, UserID: 1, Role: entity.TeamRoleOwner}, owner)
+			return nil
+		})
+
+		team, err := service.CreateTeam(ctx, member(1), "platform")
+		assert.NoError(t, err)
+		assert.Equal(t, "platform", team.Name)
+		assert.NotZero(t, team.ID)
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		_, err := service.CreateTeam(ctx, member(1), "")
+		assert.Equal(t, internalErrors.InvalidTeamName, err)
+	})
+
+	t.Run("read-only users", func(t *testing.T) {
+		_, err := service.CreateTeam(ctx, Caller{UserID: 1, Role: entity.RoleReadOnly}, "platform")
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+}
+
+func TestTeamService_SetMember(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service, mockTeamRepo, mockUserRepo, _ := newTestTeamService(t, ctrl)
+	ctx := context.Background()
+	team := &entity.Team{ID: 7, Name: "platform"}
+	// The members of the team, with user 1 as its only owner.
+	owners := []*entity.TeamMember{{TeamID: 7, UserID: 1, Role: entity.TeamRoleOwner}, {TeamID: 7, UserID: 2, Role: entity.TeamRoleMember}}
+
+	t.Run("owners add members", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(ctx, int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(1)).Return(&entity.TeamMember{TeamID: 7, UserID: 1, Role: entity.TeamRoleOwner}, nil)
+		mockUserRepo.EXPECT().FindByUsername(ctx, "bob").Return(&entity.User{ID: 2, Username: "bob"}, nil)
+		mockTeamRepo.EXPECT().ListMembers(ctx, int64(7)).Return(owners, nil)
+		mockTeamRepo.EXPECT().SetMember(ctx, &entity.TeamMember{TeamID: 7, UserID: 2, Username: "bob", Role: entity.TeamRoleViewer}).Return(nil)
+
+		added, err := service.SetMember(ctx, member(1), 7, "bob", entity.TeamRoleViewer)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), added.UserID)
+	})
+
+	t.Run("members cannot manage the team", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(ctx, int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(2)).Return(&entity.TeamMember{TeamID: 7, UserID: 2, Role: entity.TeamRoleMember}, nil)
+
+		_, err := service.SetMember(ctx, member(2), 7, "bob", entity.TeamRoleOwner)
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+
+	t.Run("admins manage every team", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(ctx, int64(7)).Return(team, nil)
+		mockUserRepo.EXPECT().FindByUsername(ctx, "bob").Return(&entity.User{ID: 2, Username: "bob"}, nil)
+		mockTeamRepo.EXPECT().SetMember(ctx, gomock.Any()).Return(nil)
+
+		_, err := service.SetMember(ctx, Caller{UserID: 9, Role: entity.RoleAdmin}, 7, "bob", entity.TeamRoleOwner)
+		assert.NoError(t, err)
+	})
+
+	t.Run("the last owner cannot be demoted", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(ctx, int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(1)).Return(&entity.TeamMember{TeamID: 7, UserID: 1, Role: entity.TeamRoleOwner}, nil)
+		mockUserRepo.EXPECT().FindByUsername(ctx, "alice").Return(&entity.User{ID: 1, Username: "alice"}, nil)
+		mockTeamRepo.EXPECT().ListMembers(ctx, int64(7)).Return(owners, nil)
+
+		_, err := service.SetMember(ctx, member(1), 7, "alice", entity.TeamRoleMember)
+		assert.Equal(t, internalErrors.LastTeamOwner, err)
+	})
+
+	t.Run("owners are demoted while another owner is left", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(ctx, int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(1)).Return(&entity.TeamMember{TeamID: 7, UserID: 1, Role: entity.TeamRoleOwner}, nil)
+		mockUserRepo.EXPECT().FindByUsername(ctx, "alice").Return(&entity.User{ID: 1, Username: "alice"}, nil)
+		mockTeamRepo.EXPECT().ListMembers(ctx, int64(7)).Return(append(owners, &entity.TeamMember{TeamID: 7, UserID: 3, Role: entity.TeamRoleOwner}), nil)
+		mockTeamRepo.EXPECT().SetMember(ctx, &entity.TeamMember{TeamID: 7, UserID: 1, Username: "alice", Role: entity.TeamRoleMember}).Return(nil)
+
+		_, err := service.SetMember(ctx, member(1), 7, "alice", entity.TeamRoleMember)
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(ctx, int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(1)).Return(&entity.TeamMember{TeamID: 7, UserID: 1, Role: entity.TeamRoleOwner}, nil)
+		mockUserRepo.EXPECT().FindByUsername(ctx, "nobody").Return(nil, nil)
+
+		_, err := service.SetMember(ctx, member(1), 7, "nobody", entity.TeamRoleMember)
+		assert.Equal(t, internalErrors.UserNotFound, err)
+	})
+
+	t.Run("unknown team", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(ctx, int64(8)).Return(nil, nil)
+
+		_, err := service.SetMember(ctx, member(1), 8, "bob", entity.TeamRoleMember)
+		assert.Equal(t, internalErrors.TeamNotFound, err)
+	})
+
+	t.Run("invalid role", func(t *testing.T) {
+		_, err := service.SetMember(ctx, member(1), 7, "bob", "admin")
+		assert.Equal(t, internalErrors.InvalidTeamRole, err)
+	})
+}
+
+func TestTeamService_ListMembers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service, mockTeamRepo, _, _ := newTestTeamService(t, ctrl)
+	ctx := context.Background()
+	team := &entity.Team{ID: 7, Name: "platform"}
+
+	t.Run("viewers see the members", func(t *testing.T) {
+		members := []*entity.TeamMember{{TeamID: 7, UserID: 1, Role: entity.TeamRoleOwner}, {TeamID: 7, UserID: 3, Role: entity.TeamRoleViewer}}
+		mockTeamRepo.EXPECT().Get(ctx, int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(3)).Return(members[1], nil)
+		mockTeamRepo.EXPECT().ListMembers(ctx, int64(7)).Return(members, nil)
+
+		result, err := service.ListMembers(ctx, member(3), 7)
+		assert.NoError(t, err)
+		assert.Equal(t, members, result)
+	})
+
+	t.Run("non-members", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(ctx, int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(4)).Return(nil, nil)
+
+		_, err := service.ListMembers(ctx, member(4), 7)
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+}
+
+func TestTeamService_RemoveMember(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service, mockTeamRepo, _, mockShareRepo := newTestTeamService(t, ctrl)
+	ctx := context.Background()
+	team := &entity.Team{ID: 7, Name: "platform"}
+	// The members of the team, with user 1 as its only owner.
+	owners := []*entity.TeamMember{{TeamID: 7, UserID: 1, Role: entity.TeamRoleOwner}, {TeamID: 7, UserID: 2, Role: entity.TeamRoleMember}}
+
+	t.Run("members leave the team", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(ctx, int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(2)).Return(&entity.TeamMember{TeamID: 7, UserID: 2, Role: entity.TeamRoleMember}, nil)
+		mockTeamRepo.EXPECT().ListMembers(ctx, int64(7)).Return(owners, nil)
+		mockTeamRepo.EXPECT().RemoveMember(ctx, int64(7), int64(2)).Return(true, nil)
+		// The links the member shared team files with stop working.
+		mockShareRepo.EXPECT().RevokeByMember(ctx, int64(7), int64(2)).Return(nil)
+
+		err := service.RemoveMember(ctx, member(2), 7, 2)
+		assert.NoError(t, err)
+	})
+
+	t.Run("owners remove members", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(ctx, int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(1)).Return(&entity.TeamMember{TeamID: 7, UserID: 1, Role: entity.TeamRoleOwner}, nil)
+		mockTeamRepo.EXPECT().ListMembers(ctx, int64(7)).Return(owners, nil)
+		mockTeamRepo.EXPECT().RemoveMember(ctx, int64(7), int64(2)).Return(true, nil)
+		mockShareRepo.EXPECT().RevokeByMember(ctx, int64(7), int64(2)).Return(nil)
+
+		err := service.RemoveMember(ctx, member(1), 7, 2)
+		assert.NoError(t, err)
+	})
+
+	t.Run("members cannot remove others", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(ctx, int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(2)).Return(&entity.TeamMember{TeamID: 7, UserID: 2, Role: entity.TeamRoleMember}, nil)
+
+		err := service.RemoveMember(ctx, member(2), 7, 3)
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+
+	t.Run("the last owner cannot leave", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(ctx, int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(1)).Return(&entity.TeamMember{TeamID: 7, UserID: 1, Role: entity.TeamRoleOwner}, nil)
+		mockTeamRepo.EXPECT().ListMembers(ctx, int64(7)).Return(owners, nil)
+
+		err := service.RemoveMember(ctx, member(1), 7, 1)
+		assert.Equal(t, internalErrors.LastTeamOwner, err)
+	})
+
+	t.Run("admins cannot remove the last owner", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(ctx, int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().ListMembers(ctx, int64(7)).Return(owners, nil)
+
+		err := service.RemoveMember(ctx, Caller{UserID: 9, Role: entity.RoleAdmin}, 7, 1)
+		assert.Equal(t, internalErrors.LastTeamOwner, err)
+	})
+
+	t.Run("a concurrent change leaves no other owner", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(ctx, int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(1)).Return(&entity.TeamMember{TeamID: 7, UserID: 1, Role: entity.TeamRoleOwner}, nil)
+		mockTeamRepo.EXPECT().ListMembers(ctx, int64(7)).Return(append(owners, &entity.TeamMember{TeamID: 7, UserID: 3, Role: entity.TeamRoleOwner}), nil)
+		mockTeamRepo.EXPECT().RemoveMember(ctx, int64(7), int64(1)).Return(false, internalErrors.LastTeamOwner)
+
+		err := service.RemoveMember(ctx, member(1), 7, 1)
+		assert.Equal(t, internalErrors.LastTeamOwner, err)
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(ctx, int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(ctx, int64(7), int64(1)).Return(&entity.TeamMember{TeamID: 7, UserID: 1, Role: entity.TeamRoleOwner}, nil)
+		mockTeamRepo.EXPECT().ListMembers(ctx, int64(7)).Return(owners, nil)
+		mockTeamRepo.EXPECT().RemoveMember(ctx, int64(7), int64(5)).Return(false, nil)
+
+		err := service.RemoveMember(ctx, member(1), 7, 5)
+		var customErr *internalErrors.CustomError
+		assert.ErrorAs(t, err, &customErr)
+		assert.Equal(t, internalErrors.UserNotFound.Message, customErr.Message)
+	})
+}
@@ -0,0 +1,321 @@
+package application
+
+import (
+	"bufio"
+	"bytes"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// expiredUploadBatch is the number of expired uploads ExpireUploads removes per query.
+const expiredUploadBatch = 100
+
+// UploadChecksum is the checksum a chunk of an upload is verified with, by one of the
+// entity.UploadChecksumAlgorithms.
+type UploadChecksum struct {
+	Algorithm string
+	Sum       []byte
+}
+
+// UploadService implements resumable uploads: a file is uploaded in chunks, possibly over several
+// connections, and only appears among the owner's files once all of it arrived. The length of the
+// file is reserved against the storage quota when the upload starts, and given back if the upload
+// is cancelled or expires.
+type UploadService struct {
+	uploadRepo   infrastructure.UploadRepository
+	fileStorage  infrastructure.FileStorage
+	fileRepo     infrastructure.FileRepository
+	quotaService *QuotaService
+	authorizer   *Authorizer
+	policy       entity.UploadPolicy
+	// ttl is how long an upload is kept after its last chunk.
+	ttl time.Duration
+
+	mu sync.Mutex
+	// inUse holds the uploads a request is writing to, which no other request may touch meanwhile.
+	inUse map[string]bool
+}
+
+func NewUploadService(uploadRepo infrastructure.UploadRepository, fileStorage infrastructure.FileStorage, fileRepo infrastructure.FileRepository, quotaService *QuotaService, authorizer *Authorizer, policy entity.UploadPolicy, ttl time.Duration) *UploadService {
+	return &UploadService{
+		uploadRepo:   uploadRepo,
+		fileStorage:  fileStorage,
+		fileRepo:     fileRepo,
+		quotaService: quotaService,
+		authorizer:   authorizer,
+		policy:       policy,
+		ttl:          ttl,
+		inUse:        map[string]bool{},
+	}
+}
+
+// CreateUpload starts the upload of a file of the given length for the caller, or for a team of
+// the caller when teamID is not zero. metadata is kept to be returned with the upload. An empty
+// file is complete at once.
+func (s *UploadService) CreateUpload(ctx context.Context, caller Caller, teamID int64, filename string, length int64, metadata string) (*entity.Upload, error) {
+	filename, err := entity.CleanFilePath(filename)
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 {
+		return nil, errors.BadRequest.New("upload length must not be negative")
+	}
+	if err := s.policy.CheckSize(length); err != nil {
+		return nil, err
+	}
+	owner := entity.Owner{UserID: caller.UserID, TeamID: teamID}
+	if err := s.authorizer.authorize(ctx, caller, entity.ActionWrite, owner); err != nil {
+		return nil, err
+	}
+	// Team files are not replaced, as in FileService.UploadFile. This is checked again once the
+	// upload is complete.
+	if teamID != 0 {
+		if size, err := s.fileStorage.FileSize(owner, filename); err != nil {
+			return nil, err
+		} else if size > 0 {
+			return nil, errors.FileExists
+		}
+	}
+
+	upload, err := entity.NewUpload(owner, filename, length, metadata, s.ttl)
+	if err != nil {
+		return nil, err
+	}
+	reservation := entity.QuotaResources{StorageBytes: length}
+	if err := s.quotaService.reserve(ctx, caller.UserID, reservation); err != nil {
+		return nil, err
+	}
+	// Writing nothing creates the upload in the storage.
+	if _, err := s.fileStorage.WriteUpload(owner, upload.ID, 0, bytes.NewReader(nil)); err != nil {
+		s.quotaService.release(ctx, caller.UserID, reservation)
+		return nil, err
+	}
+	if err := s.uploadRepo.Create(ctx, upload); err != nil {
+		s.deleteStorage(upload)
+		s.quotaService.release(ctx, caller.UserID, reservation)
+		return nil, err
+	}
+
+	if length == 0 {
+		if err := s.complete(ctx, caller, upload); err != nil {
+			if removeErr := s.remove(ctx, upload); removeErr != nil {
+				log.Printf("failed to remove upload %s: %v", upload.ID, removeErr)
+			}
+			return nil, err
+		}
+	}
+	return upload, nil
+}
+
+// GetUpload returns an upload the caller started.
+func (s *UploadService) GetUpload(ctx context.Context, caller Caller, id string) (*entity.Upload, error) {
+	upload, err := s.uploadRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Uploads of other users are not revealed, not even to admins, as they count against the
+	// quota of the user who started them.
+	if upload == nil || upload.UserID != caller.UserID || time.Now().After(upload.ExpiresAt) {
+		return nil, errors.UploadNotFound
+	}
+	return upload, nil
+}
+
+// WriteUpload writes a chunk of an upload at offset, which has to be where the previous chunk
+// ended, and returns the upload with its new offset. The type of the file is sniffed from the
+// first chunk and checked against the policy. contentLength is the size of the chunk, or
+// -1 if it is not known in advance. If a checksum is given, a chunk that does not match is
+// discarded. Otherwise whatever is received counts, so that a client can resume after a dropped
+// connection. The file is committed once the upload is complete.
+func (s *UploadService) WriteUpload(ctx context.Context, caller Caller, id string, offset, contentLength int64, checksum *UploadChecksum, content io.Reader) (*entity.Upload, error) {
+	if !s.lock(id) {
+		return nil, errors.UploadLocked
+	}
+	defer s.unlock(id)
+
+	upload, err := s.GetUpload(ctx, caller, id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != upload.Offset {
+		return nil, errors.UploadOffsetMismatch
+	}
+	remaining := upload.Length - upload.Offset
+	if contentLength > remaining {
+		return nil, errors.UploadTooLarge
+	}
+	content = io.LimitReader(content, remaining)
+	// The type of the file is checked against the policy by its first bytes, so if the policy
+	// limits the types, the first chunk has to hold all of them.
+	if offset == 0 && remaining > 0 && s.policy.ChecksTypes() {
+		sniffer := bufio.NewReaderSize(content, sniffLength)
+		head, _ := sniffer.Peek(sniffLength)
+		if int64(len(head)) < min(sniffLength, remaining) {
+			return nil, errors.BadRequest.New("the first chunk must hold at least the first 512 bytes of the file")
+		}
+		if err := s.policy.CheckContentType(http.DetectContentType(head)); err != nil {
+			return nil, err
+		}
+		content = sniffer
+	}
+	var verify func() bool
+	if checksum != nil {
+		hash := entity.NewUploadHash(checksum.Algorithm)
+		if hash == nil {
+			return nil, errors.BadRequest.New("unsupported checksum algorithm " + checksum.Algorithm)
+		}
+		content = io.TeeReader(content, hash)
+		verify = func() bool { return bytes.Equal(hash.Sum(nil), checksum.Sum) }
+	}
+
+	n, writeErr := s.fileStorage.WriteUpload(upload.Owner(), id, offset, content)
+	// The chunk is recorded even if the client went away while sending it.
+	ctx = context.WithoutCancel(ctx)
+	if verify != nil {
+		if writeErr != nil {
+			return nil, writeErr
+		}
+		if !verify() {
+			return nil, errors.UploadChecksumMismatch
+		}
+	}
+	if n > 0 {
+		expiresAt := time.Now().Add(s.ttl)
+		updated, err := s.uploadRepo.UpdateOffset(ctx, id, offset, offset+n, expiresAt)
+		if err != nil {
+			return nil, err
+		}
+		if !updated {
+			return nil, errors.UploadOffsetMismatch
+		}
+		upload.Offset += n
+		upload.ExpiresAt = expiresAt
+	}
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	if upload.Offset == upload.Length {
+		if err := s.complete(ctx, caller, upload); err != nil {
+			return nil, err
+		}
+	}
+	return upload, nil
+}
+
+// complete commits a complete upload to its file. The reservation of the upload then counts for
+// the file, and the size of a replaced personal file is given back. If committing fails, the
+// upload is kept, and writing an empty chunk at its end tries again.
+func (s *UploadService) complete(ctx context.Context, caller Caller, upload *entity.Upload) error {
+	owner := upload.Owner()
+	// The caller may have left the team since the upload started.
+	if err := s.authorizer.authorize(ctx, caller, entity.ActionWrite, owner); err != nil {
+		return err
+	}
+	unlock := fileLocks.lock(owner, upload.Filename)
+	defer unlock()
+	previousSize, err := s.fileStorage.FileSize(owner, upload.Filename)
+	if err != nil {
+		return err
+	}
+	if owner.TeamID != 0 && previousSize > 0 {
+		return errors.FileExists
+	}
+
+	info, err := s.fileStorage.CommitUpload(owner, upload.ID, upload.Filename)
+	if err != nil {
+		return err
+	}
+	recordFile(ctx, s.fileRepo, owner, upload.UserID, info)
+	if previousSize > 0 {
+		s.quotaService.release(ctx, upload.UserID, entity.QuotaResources{StorageBytes: previousSize})
+	}
+	if _, err := s.uploadRepo.Delete(ctx, upload.ID); err != nil {
+		log.Printf("failed to delete completed upload %s: %v", upload.ID, err)
+	}
+	return nil
+}
+
+// DeleteUpload cancels an upload of the caller and gives back its reservation.
+func (s *UploadService) DeleteUpload(ctx context.Context, caller Caller, id string) error {
+	if !s.lock(id) {
+		return errors.UploadLocked
+	}
+	defer s.unlock(id)
+
+	upload, err := s.GetUpload(ctx, caller, id)
+	if err != nil {
+		return err
+	}
+	return s.remove(ctx, upload)
+}
+
+// ExpireUploads removes the uploads that were not completed in time and gives back their
+// reservations. Uploads a request is writing to are left for the next run.
+func (s *UploadService) ExpireUploads(ctx context.Context) error {
+	for {
+		uploads, err := s.uploadRepo.ListExpired(ctx, time.Now(), expiredUploadBatch)
+		if err != nil {
+			return err
+		}
+		removed := 0
+		for _, upload := range uploads {
+			if !s.lock(upload.ID) {
+				continue
+			}
+			err := s.remove(ctx, upload)
+			s.unlock(upload.ID)
+			if err != nil {
+				return err
+			}
+			removed++
+		}
+		if len(uploads) < expiredUploadBatch || removed == 0 {
+			return nil
+		}
+	}
+}
+
+// remove deletes an upload with what was received of it, and gives back its reservation.
+func (s *UploadService) remove(ctx context.Context, upload *entity.Upload) error {
+	deleted, err := s.uploadRepo.Delete(ctx, upload.ID)
+	if err != nil || !deleted {
+		return err
+	}
+	s.deleteStorage(upload)
+	s.quotaService.release(ctx, upload.UserID, entity.QuotaResources{StorageBytes: upload.Length})
+	return nil
+}
+
+// deleteStorage deletes what was received of an upload. A failure is only logged, as the upload
+// is gone already and removing the owner's files removes it as well.
+func (s *UploadService) deleteStorage(upload *entity.Upload) {
+	if err := s.fileStorage.DeleteUpload(upload.Owner(), upload.ID); err != nil {
+		log.Printf("failed to delete the data of upload %s: %v", upload.ID, err)
+	}
+}
+
+// lock marks an upload as in use, and returns false if another request is using it. Uploads are
+// only locked within this process.
+func (s *UploadService) lock(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inUse[id] {
+		return false
+	}
+	s.inUse[id] = true
+	return true
+}
+
+func (s *UploadService) unlock(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inUse, id)
+}
@@ -0,0 +1,347 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	internalErrors "container-manager/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type uploadServiceMocks struct {
+	uploadRepo  *mocks.MockUploadRepository
+	fileStorage *mocks.MockFileStorage
+	fileRepo    *mocks.MockFileRepository
+	quotaRepo   *mocks.MockQuotaRepository
+	teamRepo    *mocks.MockTeamRepository
+}
+
+func newTestUploadService(t *testing.T) (*UploadService, uploadServiceMocks) {
+	ctrl := gomock.NewController(t)
+	m := uploadServiceMocks{
+		uploadRepo:  mocks.NewMockUploadRepository(ctrl),
+		fileStorage: mocks.NewMockFileStorage(ctrl),
+		fileRepo:    mocks.NewMockFileRepository(ctrl),
+		quotaRepo:   mocks.NewMockQuotaRepository(ctrl),
+		teamRepo:    mocks.NewMockTeamRepository(ctrl),
+	}
+	quotaService := NewQuotaService(m.quotaRepo, QuotaOptions{DefaultLimits: entity.QuotaResources{StorageBytes: 1000}})
+	return NewUploadService(m.uploadRepo, m.fileStorage, m.fileRepo, quotaService, NewAuthorizer(m.teamRepo), entity.UploadPolicy{}, time.Hour), m
+}
+
+func assertCustomError(t *testing.T, want *internalErrors.CustomError, err error) {
+	t.Helper()
+	var customErr *internalErrors.CustomError
+	if assert.ErrorAs(t, err, &customErr) {
+		assert.Equal(t, want.Message, customErr.Message)
+	}
+}
+
+func TestUploadService_CreateUpload(t *testing.T) {
+	ctx := context.Background()
+	userID := int64(1000)
+	owner := entity.Owner{UserID: userID}
+	limits := entity.QuotaResources{StorageBytes: 1000}
+
+	t.Run("success", func(t *testing.T) {
+		service, m := newTestUploadService(t)
+		m.quotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil)
+		m.quotaRepo.EXPECT().Reserve(ctx, userID, entity.QuotaResources{StorageBytes: 100}, limits).Return(nil)
+		m.fileStorage.EXPECT().WriteUpload(owner, gomock.Any(), int64(0), gomock.Any()).Return(int64(0), nil)
+		m.uploadRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+
+		upload, err := service.CreateUpload(ctx, member(userID), 0, "./data/big.csv", 100, "filename YmlnLmNzdg==")
+		require.NoError(t, err)
+		assert.True(t, entity.IsUploadID(upload.ID))
+		assert.Equal(t, "data/big.csv", upload.Filename)
+		assert.Equal(t, int64(100), upload.Length)
+		assert.Equal(t, int64(0), upload.Offset)
+		assert.Equal(t, "filename YmlnLmNzdg==", upload.Metadata)
+		assert.True(t, upload.ExpiresAt.After(time.Now()))
+	})
+
+	t.Run("empty file is committed at once", func(t *testing.T) {
+		service, m := newTestUploadService(t)
+		m.quotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil)
+		m.quotaRepo.EXPECT().Reserve(ctx, userID, entity.QuotaResources{}, limits).Return(nil)
+		m.fileStorage.EXPECT().WriteUpload(owner, gomock.Any(), int64(0), gomock.Any()).Return(int64(0), nil)
+		m.uploadRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+		m.fileStorage.EXPECT().FileSize(owner, "empty.txt").Return(int64(0), nil)
+		m.fileStorage.EXPECT().CommitUpload(owner, gomock.Any(), "empty.txt").Return(&entity.FileInfo{Name: "empty.txt"}, nil)
+		m.fileRepo.EXPECT().Save(ctx, gomock.Any()).Return(nil)
+		m.uploadRepo.EXPECT().Delete(ctx, gomock.Any()).Return(true, nil)
+
+		_, err := service.CreateUpload(ctx, member(userID), 0, "empty.txt", 0, "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid filename", func(t *testing.T) {
+		service, _ := newTestUploadService(t)
+		_, err := service.CreateUpload(ctx, member(userID), 0, "../x", 100, "")
+		assertCustomError(t, internalErrors.InvalidFilename, err)
+		_, err = service.CreateUpload(ctx, member(userID), 0, ".uploads/x", 100, "")
+		assertCustomError(t, internalErrors.InvalidFilename, err)
+	})
+
+	t.Run("negative length", func(t *testing.T) {
+		service, _ := newTestUploadService(t)
+		_, err := service.CreateUpload(ctx, member(userID), 0, "a.txt", -1, "")
+		assertCustomError(t, internalErrors.BadRequest, err)
+	})
+
+	t.Run("larger than the policy allows", func(t *testing.T) {
+		service, _ := newTestUploadService(t)
+		service.policy = entity.UploadPolicy{MaxFileSize: 1000}
+		_, err := service.CreateUpload(ctx, member(userID), 0, "a.txt", 1001, "")
+		assert.Equal(t, internalErrors.FileTooLarge, err)
+	})
+
+	t.Run("quota exceeded", func(t *testing.T) {
+		service, m := newTestUploadService(t)
+		m.quotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil)
+		m.quotaRepo.EXPECT().Reserve(ctx, userID, entity.QuotaResources{StorageBytes: 2000}, limits).Return(internalErrors.QuotaExceeded)
+		m.quotaRepo.EXPECT().GetUsage(ctx, userID).Return(&entity.QuotaResources{}, nil)
+
+		_, err := service.CreateUpload(ctx, member(userID), 0, "a.txt", 2000, "")
+		assertCustomError(t, internalErrors.QuotaExceeded, err)
+	})
+
+	t.Run("existing team file", func(t *testing.T) {
+		service, m := newTestUploadService(t)
+		m.teamRepo.EXPECT().GetMember(ctx, int64(7), userID).Return(&entity.TeamMember{TeamID: 7, UserID: userID, Role: entity.TeamRoleMember}, nil)
+		m.fileStorage.EXPECT().FileSize(entity.Owner{UserID: userID, TeamID: 7}, "a.txt").Return(int64(5), nil)
+
+		_, err := service.CreateUpload(ctx, member(userID), 7, "a.txt", 100, "")
+		assert.Equal(t, internalErrors.FileExists, err)
+	})
+
+	t.Run("not a team member", func(t *testing.T) {
+		service, m := newTestUploadService(t)
+		m.teamRepo.EXPECT().GetMember(ctx, int64(7), userID).Return(nil, nil)
+
+		_, err := service.CreateUpload(ctx, member(userID), 7, "a.txt", 100, "")
+		assert.Equal(t, internalErrors.PermissionDenied, err)
+	})
+
+	t.Run("database error gives back the reservation", func(t *testing.T) {
+		service, m := newTestUploadService(t)
+		m.quotaRepo.EXPECT().GetLimits(ctx, userID).Return(nil, nil)
+		m.quotaRepo.EXPECT().Reserve(ctx, userID, entity.QuotaResources{StorageBytes: 100}, limits).Return(nil)
+		m.fileStorage.EXPECT().WriteUpload(owner, gomock.Any(), int64(0), gomock.Any()).Return(int64(0), nil)
+		m.uploadRepo.EXPECT().Create(ctx, gomock.Any()).Return(errors.New("database error"))
+		m.fileStorage.EXPECT().DeleteUpload(owner, gomock.Any()).Return(nil)
+		m.quotaRepo.EXPECT().Release(ctx, userID, entity.QuotaResources{StorageBytes: 100}).Return(nil)
+
+		_, err := service.CreateUpload(ctx, member(userID), 0, "a.txt", 100, "")
+		assert.EqualError(t, err, "database error")
+	})
+}
+
+func TestUploadService_GetUpload(t *testing.T) {
+	ctx := context.Background()
+	service, m := newTestUploadService(t)
+	upload := &entity.Upload{ID: "upload-id", UserID: 1000, Length: 10, ExpiresAt: time.Now().Add(time.Hour)}
+
+	m.uploadRepo.EXPECT().GetByID(ctx, "upload-id").Return(upload, nil).Times(2)
+	got, err := service.GetUpload(ctx, member(1000), "upload-id")
+	assert.NoError(t, err)
+	assert.Equal(t, upload, got)
+
+	// Uploads of other users are not found
+	_, err = service.GetUpload(ctx, Caller{UserID: 1, Role: entity.RoleAdmin}, "upload-id")
+	assert.Equal(t, internalErrors.UploadNotFound, err)
+
+	m.uploadRepo.EXPECT().GetByID(ctx, "expired").Return(&entity.Upload{ID: "expired", UserID: 1000, ExpiresAt: time.Now().Add(-time.Second)}, nil)
+	_, err = service.GetUpload(ctx, member(1000), "expired")
+	assert.Equal(t, internalErrors.UploadNotFound, err)
+
+	m.uploadRepo.EXPECT().GetByID(ctx, "missing").Return(nil, nil)
+	_, err = service.GetUpload(ctx, member(1000), "missing")
+	assert.Equal(t, internalErrors.UploadNotFound, err)
+}
+
+func TestUploadService_WriteUpload(t *testing.T) {
+	ctx := context.Background()
+	userID := int64(1000)
+	owner := entity.Owner{UserID: userID}
+	newUpload := func(offset int64) *entity.Upload {
+		return &entity.Upload{ID: "upload-id", UserID: userID, Filename: "big.csv", Length: 10, Offset: offset, ExpiresAt: time.Now().Add(time.Hour)}
+	}
+	readAll := func(_ entity.Owner, _ string, _ int64, r io.Reader) (int64, error) {
+		return io.Copy(io.Discard, r)
+	}
+
+	t.Run("chunk", func(t *testing.T) {
+		service, m := newTestUploadService(t)
+		m.uploadRepo.EXPECT().GetByID(ctx, "upload-id").Return(newUpload(2), nil)
+		m.fileStorage.EXPECT().WriteUpload(owner, "upload-id", int64(2), gomock.Any()).DoAndReturn(readAll)
+		m.uploadRepo.EXPECT().UpdateOffset(gomock.Any(), "upload-id", int64(2), int64(6), gomock.Any()).Return(true, nil)
+
+		upload, err := service.WriteUpload(ctx, member(userID), "upload-id", 2, 4, nil, strings.NewReader("2345"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), upload.Offset)
+	})
+
+	t.Run("last chunk commits the file", func(t *testing.T) {
+		service, m := newTestUploadService(t)
+		m.uploadRepo.EXPECT().GetByID(ctx, "upload-id").Return(newUpload(6), nil)
+		m.fileStorage.EXPECT().WriteUpload(owner, "upload-id", int64(6), gomock.Any()).DoAndReturn(readAll)
+		m.uploadRepo.EXPECT().UpdateOffset(gomock.Any(), "upload-id", int64(6), int64(10), gomock.Any()).Return(true, nil)
+		m.fileStorage.EXPECT().FileSize(owner, "big.csv").Return(int64(3), nil)
+		info := &entity.FileInfo{Name: "big.csv", Size: 10, ContentType: "text/csv; charset=utf-8", SHA256: "84d89877f0d4041efb6bf91a16f0248f2fd573e6af05c19f96bedb9f882f7882"}
+		m.fileStorage.EXPECT().CommitUpload(owner, "upload-id", "big.csv").Return(info, nil)
+		m.fileRepo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, record *entity.FileRecord) error {
+			assert.Equal(t, owner, record.Owner)
+			assert.Equal(t, userID, record.UploadedBy)
+			assert.Equal(t, "big.csv", record.Path)
+			assert.Equal(t, info.SHA256, record.SHA256)
+			assert.Equal(t, int64(10), record.Size)
+			return nil
+		})
+		// The replaced file is given back
+		m.quotaRepo.EXPECT().Release(gomock.Any(), userID, entity.QuotaResources{StorageBytes: 3}).Return(nil)
+		m.uploadRepo.EXPECT().Delete(gomock.Any(), "upload-id").Return(true, nil)
+
+		// Bytes past the length of the upload are not read
+		upload, err := service.WriteUpload(ctx, member(userID), "upload-id", 6, -1, nil, strings.NewReader("6789extra"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), upload.Offset)
+	})
+
+	t.Run("failed commit is retried with an empty chunk", func(t *testing.T) {
+		service, m := newTestUploadService(t)
+		m.uploadRepo.EXPECT().GetByID(ctx, "upload-id").Return(newUpload(10), nil)
+		m.fileStorage.EXPECT().WriteUpload(owner, "upload-id", int64(10), gomock.Any()).DoAndReturn(readAll)
+		m.fileStorage.EXPECT().FileSize(owner, "big.csv").Return(int64(0), nil)
+		m.fileStorage.EXPECT().CommitUpload(owner, "upload-id", "big.csv").Return(nil, internalErrors.NotRegularFile)
+
+		_, err := service.WriteUpload(ctx, member(userID), "upload-id", 10, 0, nil, strings.NewReader(""))
+		assert.Equal(t, internalErrors.NotRegularFile, err)
+	})
+
+	t.Run("offset mismatch", func(t *testing.T) {
+		service, m := newTestUploadService(t)
+		m.uploadRepo.EXPECT().GetByID(ctx, "upload-id").Return(newUpload(2), nil)
+
+		_, err := service.WriteUpload(ctx, member(userID), "upload-id", 0, 4, nil, strings.NewReader("0123"))
+		assert.Equal(t, internalErrors.UploadOffsetMismatch, err)
+	})
+
+	t.Run("chunk larger than the rest", func(t *testing.T) {
+		service, m := newTestUploadService(t)
+		m.uploadRepo.EXPECT().GetByID(ctx, "upload-id").Return(newUpload(8), nil)
+
+		_, err := service.WriteUpload(ctx, member(userID), "upload-id", 8, 4, nil, strings.NewReader("89ab"))
+		assert.Equal(t, internalErrors.UploadTooLarge, err)
+	})
+
+	t.Run("checksum", func(t *testing.T) {
+		service, m := newTestUploadService(t)
+		sum := sha1.Sum([]byte("0123"))
+		checksum := &UploadChecksum{Algorithm: "sha1", Sum: sum[:]}
+
+		m.uploadRepo.EXPECT().GetByID(ctx, "upload-id").DoAndReturn(func(context.Context, string) (*entity.Upload, error) {
+			return newUpload(0), nil
+		}).Times(3)
+		m.fileStorage.EXPECT().WriteUpload(owner, "upload-id", int64(0), gomock.Any()).DoAndReturn(readAll).Times(2)
+		m.uploadRepo.EXPECT().UpdateOffset(gomock.Any(), "upload-id", int64(0), int64(4), gomock.Any()).Return(true, nil)
+
+		upload, err := service.WriteUpload(ctx, member(userID), "upload-id", 0, 4, checksum, strings.NewReader("0123"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), upload.Offset)
+
+		// A chunk that does not match is not recorded
+		_, err = service.WriteUpload(ctx, member(userID), "upload-id", 0, 4, checksum, strings.NewReader("0124"))
+		assert.Equal(t, internalErrors.UploadChecksumMismatch, err)
+
+		_, err = service.WriteUpload(ctx, member(userID), "upload-id", 0, 4, &UploadChecksum{Algorithm: "crc32"}, strings.NewReader("0123"))
+		assertCustomError(t, internalErrors.BadRequest, err)
+	})
+
+	t.Run("interrupted chunk keeps what was written", func(t *testing.T) {
+		service, m := newTestUploadService(t)
+		m.uploadRepo.EXPECT().GetByID(ctx, "upload-id").Return(newUpload(0), nil)
+		m.fileStorage.EXPECT().WriteUpload(owner, "upload-id", int64(0), gomock.Any()).Return(int64(3), io.ErrUnexpectedEOF)
+		m.uploadRepo.EXPECT().UpdateOffset(gomock.Any(), "upload-id", int64(0), int64(3), gomock.Any()).Return(true, nil)
+
+		_, err := service.WriteUpload(ctx, member(userID), "upload-id", 0, 10, nil, strings.NewReader("012"))
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+	})
+
+	t.Run("type of the first chunk", func(t *testing.T) {
+		service, m := newTestUploadService(t)
+		service.policy = entity.UploadPolicy{AllowedTypes: []string{"text/*"}}
+		m.uploadRepo.EXPECT().GetByID(ctx, "upload-id").Return(newUpload(0), nil).Times(3)
+		m.fileStorage.EXPECT().WriteUpload(owner, "upload-id", int64(0), gomock.Any()).DoAndReturn(readAll)
+		m.uploadRepo.EXPECT().UpdateOffset(gomock.Any(), "upload-id", int64(0), int64(10), gomock.Any()).Return(true, nil)
+		m.fileStorage.EXPECT().FileSize(owner, "big.csv").Return(int64(0), nil)
+		m.fileStorage.EXPECT().CommitUpload(owner, "upload-id", "big.csv").Return(&entity.FileInfo{Name: "big.csv", Size: 10}, nil)
+		m.fileRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+		m.uploadRepo.EXPECT().Delete(gomock.Any(), "upload-id").Return(true, nil)
+
+		_, err := service.WriteUpload(ctx, member(userID), "upload-id", 0, 10, nil, strings.NewReader("\x00\x00\x01\x00\x01\x00\x10\x10\x00\x00"))
+		assertCustomError(t, internalErrors.FileTypeNotAllowed, err)
+
+		// The first bytes are needed to tell the type
+		_, err = service.WriteUpload(ctx, member(userID), "upload-id", 0, -1, nil, strings.NewReader("012"))
+		assertCustomError(t, internalErrors.BadRequest, err)
+
+		upload, err := service.WriteUpload(ctx, member(userID), "upload-id", 0, 10, nil, strings.NewReader("0123456789"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), upload.Offset)
+	})
+
+	t.Run("upload in use", func(t *testing.T) {
+		service, _ := newTestUploadService(t)
+		require.True(t, service.lock("upload-id"))
+		defer service.unlock("upload-id")
+
+		_, err := service.WriteUpload(ctx, member(userID), "upload-id", 0, 4, nil, bytes.NewBufferString("0123"))
+		assert.Equal(t, internalErrors.UploadLocked, err)
+		assert.Equal(t, internalErrors.UploadLocked, service.DeleteUpload(ctx, member(userID), "upload-id"))
+	})
+}
+
+func TestUploadService_DeleteUpload(t *testing.T) {
+	ctx := context.Background()
+	service, m := newTestUploadService(t)
+	upload := &entity.Upload{ID: "upload-id", UserID: 1000, TeamID: 7, Length: 10, ExpiresAt: time.Now().Add(time.Hour)}
+
+	m.uploadRepo.EXPECT().GetByID(ctx, "upload-id").Return(upload, nil)
+	m.uploadRepo.EXPECT().Delete(ctx, "upload-id").Return(true, nil)
+	m.fileStorage.EXPECT().DeleteUpload(entity.Owner{UserID: 1000, TeamID: 7}, "upload-id").Return(nil)
+	m.quotaRepo.EXPECT().Release(ctx, int64(1000), entity.QuotaResources{StorageBytes: 10}).Return(nil)
+
+	assert.NoError(t, service.DeleteUpload(ctx, member(1000), "upload-id"))
+}
+
+func TestUploadService_ExpireUploads(t *testing.T) {
+	ctx := context.Background()
+	service, m := newTestUploadService(t)
+	uploads := []*entity.Upload{
+		{ID: "upload-1", UserID: 1000, Length: 10},
+		{ID: "upload-2", UserID: 2000, Length: 20},
+		{ID: "upload-3", UserID: 3000, Length: 30},
+	}
+	require.True(t, service.lock("upload-3"))
+
+	m.uploadRepo.EXPECT().ListExpired(ctx, gomock.Any(), expiredUploadBatch).Return(uploads, nil)
+	m.uploadRepo.EXPECT().Delete(ctx, "upload-1").Return(true, nil)
+	m.fileStorage.EXPECT().DeleteUpload(entity.Owner{UserID: 1000}, "upload-1").Return(nil)
+	m.quotaRepo.EXPECT().Release(ctx, int64(1000), entity.QuotaResources{StorageBytes: 10}).Return(nil)
+	// Removed by another instance meanwhile
+	m.uploadRepo.EXPECT().Delete(ctx, "upload-2").Return(false, nil)
+
+	assert.NoError(t, service.ExpireUploads(ctx))
+}
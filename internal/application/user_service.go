@@ -5,24 +5,97 @@ import (
 	"container-manager/internal/domain/infrastructure"
 	"container-manager/internal/errors"
 	"context"
+	"log"
 	"strconv"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	// mfaTokenTTL is how long a user has to enter the second factor after the password.
+	mfaTokenTTL = 5 * time.Minute
+)
+
+// The typ claim tells access tokens apart from the MFA challenge tokens that are signed with the
+// same keys. Access tokens issued before the claim was introduced have none.
+const (
+	AccessTokenType = "access"
+	MFATokenType    = "mfa"
+)
+
+// TokenOptions configures the tokens issued by the UserService.
+type TokenOptions struct {
+	// AccessTokenTTL and RefreshTokenTTL default to 15 minutes and 30 days when zero.
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// TokenPair is what a client receives on login or refresh.
+type TokenPair struct {
+	AccessToken          string
+	AccessTokenExpiresAt time.Time
+	RefreshToken         string
+}
+
+// LoginResult is the outcome of the password step of a login. Users with two-factor authentication
+// get an MFA challenge token instead of tokens, which VerifyMFA exchanges for tokens.
+type LoginResult struct {
+	User              *entity.User
+	Tokens            *TokenPair
+	MFAToken          string
+	MFATokenExpiresAt time.Time
+}
+
+// MFARequired reports whether the login has to be completed with VerifyMFA.
+func (r *LoginResult) MFARequired() bool {
+	return r.MFAToken != ""
+}
+
 type UserService struct {
-	userRepo  infrastructure.UserRepository
-	idNode    *snowflake.Node
-	jwtSecret string
+	userRepo         infrastructure.UserRepository
+	refreshTokenRepo infrastructure.RefreshTokenRepository
+	revokedTokenRepo infrastructure.RevokedTokenRepository
+	mfaRepo          infrastructure.MFARepository
+	keyManager       infrastructure.KeyManager
+	idNode           *snowflake.Node
+	tokenOptions     TokenOptions
+	passwordPolicy   entity.PasswordPolicy
+	loginThrottle    *LoginThrottle
 }
 
-func NewUserService(userRepo infrastructure.UserRepository, idNode *snowflake.Node, jwtSecret string) *UserService {
-	return &UserService{userRepo: userRepo, idNode: idNode, jwtSecret: jwtSecret}
+func NewUserService(userRepo infrastructure.UserRepository, refreshTokenRepo infrastructure.RefreshTokenRepository, revokedTokenRepo infrastructure.RevokedTokenRepository, mfaRepo infrastructure.MFARepository, keyManager infrastructure.KeyManager, idNode *snowflake.Node, tokenOptions TokenOptions, passwordPolicy entity.PasswordPolicy, loginThrottle *LoginThrottle) *UserService {
+	if tokenOptions.AccessTokenTTL == 0 {
+		tokenOptions.AccessTokenTTL = defaultAccessTokenTTL
+	}
+	if tokenOptions.RefreshTokenTTL == 0 {
+		tokenOptions.RefreshTokenTTL = defaultRefreshTokenTTL
+	}
+	return &UserService{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		revokedTokenRepo: revokedTokenRepo,
+		mfaRepo:          mfaRepo,
+		keyManager:       keyManager,
+		idNode:           idNode,
+		tokenOptions:     tokenOptions,
+		passwordPolicy:   passwordPolicy,
+		loginThrottle:    loginThrottle,
+	}
 }
 
+// CreateUser registers a user with a password that satisfies the password policy.
 func (s *UserService) CreateUser(ctx context.Context, username, plainPassword string) (*entity.User, error) {
+	if err := entity.ValidateUsername(username); err != nil {
+		return nil, err
+	}
+	if err := s.passwordPolicy.Validate(plainPassword); err != nil {
+		return nil, err
+	}
 	id := s.idNode.Generate().Int64()
 
 	user, err := entity.NewUser(id, username, plainPassword)
@@ -38,30 +111,303 @@ func (s *UserService) CreateUser(ctx context.Context, username, plainPassword st
 	return user, nil
 }
 
-func (s *UserService) Login(ctx context.Context, username, password string) (*entity.User, string, error) {
+// Login checks the credentials of a user and starts a new refresh token family, unless the user
+// has two-factor authentication enabled. Then the result carries an MFA challenge token instead.
+// Failed logins are throttled per account and per client IP, an empty clientIP is not throttled.
+func (s *UserService) Login(ctx context.Context, username, password, clientIP string) (*LoginResult, error) {
+	// The attempt counts as failed until the password turns out to be right.
+	if err := s.loginThrottle.reserve(ctx, username, clientIP); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.FindByUsername(ctx, username)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
 	if user == nil {
-		return nil, "", errors.UserNotFound
+		return nil, errors.UserNotFound
 	}
 
 	err = user.ValidatePassword(password)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+	if err := s.loginThrottle.recordSuccess(ctx, username, clientIP); err != nil {
+		return nil, err
+	}
+
+	mfa, err := s.mfaRepo.Get(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa != nil && mfa.Enabled() {
+		expiresAt := time.Now().Add(mfaTokenTTL)
+		mfaToken, err := s.keyManager.Sign(jwt.MapClaims{
+			"sub": strconv.FormatInt(user.ID, 10),
+			"jti": uuid.NewString(),
+			"typ": MFATokenType,
+			"iat": time.Now().Unix(),
+			"exp": expiresAt.Unix(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{User: user, MFAToken: mfaToken, MFATokenExpiresAt: expiresAt}, nil
+	}
+
+	tokens, err := s.StartSession(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{User: user, Tokens: tokens}, nil
+}
+
+// VerifyMFA completes a login with the MFA challenge token returned by Login and a TOTP or
+// recovery code. A challenge token is used up once it succeeds.
+func (s *UserService) VerifyMFA(ctx context.Context, mfaToken, code string) (*entity.User, *TokenPair, error) {
+	claims, err := s.keyManager.Parse(mfaToken)
+	if err != nil {
+		return nil, nil, errors.InvalidMFAToken
+	}
+	jti, _ := claims["jti"].(string)
+	subject, _ := claims["sub"].(string)
+	expiresAt, err := claims.GetExpirationTime()
+	if claims["typ"] != MFATokenType || jti == "" || err != nil || expiresAt == nil {
+		return nil, nil, errors.InvalidMFAToken
+	}
+	userID, err := strconv.ParseInt(subject, 10, 64)
+	if err != nil {
+		return nil, nil, errors.InvalidMFAToken
+	}
+
+	revoked, err := s.revokedTokenRepo.IsRevoked(ctx, jti)
+	if err != nil {
+		return nil, nil, err
+	}
+	if revoked {
+		return nil, nil, errors.InvalidMFAToken
+	}
+
+	mfa, err := s.mfaRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if mfa == nil || !mfa.Enabled() {
+		// Two-factor authentication was disabled after the password step.
+		return nil, nil, errors.InvalidMFAToken
+	}
+	if err := verifyMFACode(ctx, s.mfaRepo, mfa, code); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.revokedTokenRepo.Revoke(ctx, jti, expiresAt.Time); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, errors.UserNotFound
+	}
+
+	tokens, err := s.StartSession(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// StartSession issues the tokens of a user that has signed in, starting a new refresh token family.
+func (s *UserService) StartSession(ctx context.Context, user *entity.User) (*TokenPair, error) {
+	return s.issueTokens(ctx, user, uuid.NewString())
+}
+
+// Refresh exchanges a refresh token for a new token pair. The refresh token can only be used
+// once: presenting it again means it leaked, and the whole family is revoked.
+func (s *UserService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	token, err := s.refreshTokenRepo.GetByHash(ctx, entity.HashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, errors.InvalidRefreshToken
+	}
+
+	if token.RotatedAt != nil {
+		s.revokeFamily(ctx, token)
+		return nil, errors.InvalidRefreshToken
+	}
+	if !token.Usable(time.Now()) {
+		return nil, errors.InvalidRefreshToken
+	}
+
+	rotated, err := s.refreshTokenRepo.MarkRotated(ctx, token.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Another request exchanged the same token in the meantime.
+		s.revokeFamily(ctx, token)
+		return nil, errors.InvalidRefreshToken
+	}
+
+	// The user is loaded again, so that the new access token carries the current role.
+	user, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.InvalidRefreshToken
+	}
+
+	return s.issueTokens(ctx, user, token.FamilyID)
+}
+
+// Logout revokes the access token immediately, together with the refresh token family it was issued with.
+func (s *UserService) Logout(ctx context.Context, jti string, expiresAt time.Time, familyID string) error {
+	if err := s.revokedTokenRepo.Revoke(ctx, jti, expiresAt); err != nil {
+		return err
+	}
+	if familyID == "" {
+		return nil
+	}
+	return s.refreshTokenRepo.RevokeFamily(ctx, familyID)
+}
+
+// ListUsers lists all users, for admins.
+func (s *UserService) ListUsers(ctx context.Context, caller Caller) ([]*entity.User, error) {
+	if caller.Role != entity.RoleAdmin {
+		return nil, errors.PermissionDenied
+	}
+	return s.userRepo.List(ctx)
+}
+
+// SetRole changes the role of a user, for admins. Access tokens carrying the previous role are
+// rejected by the AuthMiddleware, and the user gets the new role on the next refresh.
+func (s *UserService) SetRole(ctx context.Context, caller Caller, userID int64, role entity.Role) error {
+	if caller.Role != entity.RoleAdmin {
+		return errors.PermissionDenied
+	}
+	if !entity.ValidRole(string(role)) {
+		return errors.InvalidRole
+	}
+	updated, err := s.userRepo.UpdateRole(ctx, userID, role)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errors.UserNotFound
+	}
+	return nil
+}
+
+// Profile is what a user sees about their own account.
+type Profile struct {
+	User       *entity.User
+	MFAEnabled bool
+}
+
+// GetProfile returns the account of the authenticated user.
+func (s *UserService) GetProfile(ctx context.Context, userID int64) (*Profile, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.UserNotFound
+	}
+	mfa, err := s.mfaRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &Profile{User: user, MFAEnabled: mfa != nil && mfa.Enabled()}, nil
+}
+
+// ChangePassword replaces the password of a user after checking the old one. Every session of the
+// user ends: refresh tokens are revoked and access tokens issued before now are rejected by the
+// AuthMiddleware. The caller gets a fresh session in return.
+func (s *UserService) ChangePassword(ctx context.Context, userID int64, oldPassword, newPassword string) (*TokenPair, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.UserNotFound
+	}
+	if err := user.ValidatePassword(oldPassword); err != nil {
+		return nil, errors.InvalidPassword
+	}
+	if err := s.passwordPolicy.Validate(newPassword); err != nil {
+		return nil, err
+	}
+	if err := user.SetPassword(newPassword); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	updated, err := s.userRepo.UpdatePassword(ctx, userID, user.Password, now)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, errors.UserNotFound
+	}
+	user.SessionsValidAfter = now
+	if err := s.refreshTokenRepo.RevokeByUserID(ctx, userID); err != nil {
+		return nil, err
 	}
 
-	claims := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": strconv.FormatInt(user.ID, 10),
-		"exp": time.Now().Add(time.Hour * 72).Unix(),
+	return s.StartSession(ctx, user)
+}
+
+func (s *UserService) issueTokens(ctx context.Context, user *entity.User, familyID string) (*TokenPair, error) {
+	userID := user.ID
+	now := time.Now()
+	expiresAt := now.Add(s.tokenOptions.AccessTokenTTL)
+
+	accessToken, err := s.keyManager.Sign(jwt.MapClaims{
+		"sub":  strconv.FormatInt(userID, 10),
+		"jti":  uuid.NewString(),
+		"fid":  familyID,
+		"typ":  AccessTokenType,
+		"role": string(user.Role),
+		"iat":  now.Unix(),
+		"exp":  expiresAt.Unix(),
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	token, err := claims.SignedString([]byte(s.jwtSecret))
+	refreshToken, tokenHash, err := entity.NewRefreshTokenValue()
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
+	err = s.refreshTokenRepo.Create(ctx, &entity.RefreshToken{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: tokenHash,
+		ExpiresAt: now.Add(s.tokenOptions.RefreshTokenTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: expiresAt,
+		RefreshToken:         refreshToken,
+	}, nil
+}
 
-	return user, token, nil
+func (s *UserService) revokeFamily(ctx context.Context, token *entity.RefreshToken) {
+	log.Printf("refresh token %s of user %d was reused, revoking family %s", token.ID, token.UserID, token.FamilyID)
+	if err := s.refreshTokenRepo.RevokeFamily(ctx, token.FamilyID); err != nil {
+		log.Printf("failed to revoke refresh token family %s: %v", token.FamilyID, err)
+	}
 }
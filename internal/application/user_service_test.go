@@ -3,6 +3,8 @@ package application
 import (
 	"container-manager/internal/application/mocks"
 	"container-manager/internal/domain/entity"
+	internalErrors "container-manager/internal/errors"
+	keymanager "container-manager/internal/infrastructure/key_manager"
 	"context"
 	"errors"
 	"strconv"
@@ -23,7 +25,8 @@ func TestUserService_CreateUser(t *testing.T) {
 		defer ctrl.Finish()
 		mockUserRepo := mocks.NewMockUserRepository(ctrl)
 		idNode, _ := snowflake.NewNode(1)
-		userService := NewUserService(mockUserRepo, idNode, "test_secret")
+		keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "test_secret"})
+		userService := NewUserService(mockUserRepo, nil, nil, nil, keyManager, idNode, TokenOptions{}, entity.PasswordPolicy{}, nil)
 
 		mockUserRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).Times(1)
 
@@ -44,7 +47,8 @@ func TestUserService_CreateUser(t *testing.T) {
 		defer ctrl.Finish()
 		mockUserRepo := mocks.NewMockUserRepository(ctrl)
 		idNode, _ := snowflake.NewNode(1)
-		userService := NewUserService(mockUserRepo, idNode, "test_secret")
+		keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "test_secret"})
+		userService := NewUserService(mockUserRepo, nil, nil, nil, keyManager, idNode, TokenOptions{}, entity.PasswordPolicy{}, nil)
 
 		expectedErr := errors.New("database error")
 		mockUserRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(expectedErr).Times(1)
@@ -64,8 +68,11 @@ func TestUserService_Login(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
 	idNode, _ := snowflake.NewNode(1)
-	userService := NewUserService(mockUserRepo, idNode, "test_secret")
+	keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "test_secret"})
+	userService := NewUserService(mockUserRepo, mockRefreshTokenRepo, nil, mockMFARepo, keyManager, idNode, TokenOptions{}, entity.PasswordPolicy{}, nil)
 
 	username := "testuser"
 	plainPassword := "testpassword"
@@ -73,23 +80,33 @@ func TestUserService_Login(t *testing.T) {
 	t.Run("successful login", func(t *testing.T) {
 		user, _ := entity.NewUser(idNode.Generate().Int64(), username, plainPassword)
 		mockUserRepo.EXPECT().FindByUsername(gomock.Any(), username).Return(user, nil).Times(1)
+		mockMFARepo.EXPECT().Get(gomock.Any(), user.ID).Return(nil, nil).Times(1)
+		var storedToken *entity.RefreshToken
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, token *entity.RefreshToken) error {
+			storedToken = token
+			return nil
+		}).Times(1)
 
-		loggedInUser, token, err := userService.Login(context.Background(), username, plainPassword)
+		result, err := userService.Login(context.Background(), username, plainPassword, "")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
+		loggedInUser, tokens := result.User, result.Tokens
 		if loggedInUser == nil {
 			t.Fatal("expected loggedInUser, got nil")
 		}
 		if loggedInUser.Username != username {
 			t.Errorf("expected username %s, got %s", username, loggedInUser.Username)
 		}
-		if token == "" {
-			t.Error("expected token, got empty string")
+		if tokens == nil || tokens.AccessToken == "" {
+			t.Fatal("expected token, got empty string")
+		}
+		if storedToken == nil || storedToken.TokenHash != entity.HashRefreshToken(tokens.RefreshToken) {
+			t.Error("expected the hash of the refresh token to be stored")
 		}
 
 		// Verify JWT token
-		parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		parsedToken, err := jwt.Parse(tokens.AccessToken, func(token *jwt.Token) (interface{}, error) {
 			return []byte("test_secret"), nil
 		})
 		if err != nil {
@@ -112,23 +129,29 @@ func TestUserService_Login(t *testing.T) {
 		if int64(exp) <= time.Now().Unix() {
 			t.Error("token expired")
 		}
+		if claims["jti"] == "" || claims["jti"] == nil {
+			t.Error("expected jti claim")
+		}
+		if claims["fid"] != storedToken.FamilyID {
+			t.Errorf("expected family %s, got %v", storedToken.FamilyID, claims["fid"])
+		}
+		if claims["role"] != string(entity.RoleMember) {
+			t.Errorf("expected role %s, got %v", entity.RoleMember, claims["role"])
+		}
 	})
 
 	t.Run("login user not found", func(t *testing.T) {
 		mockUserRepo.EXPECT().FindByUsername(gomock.Any(), username).Return(nil, nil).Times(1)
 
-		loggedInUser, token, err := userService.Login(context.Background(), username, plainPassword)
+		result, err := userService.Login(context.Background(), username, plainPassword, "")
 		if err == nil {
 			t.Fatal("expected error, got nil")
 		}
 		if err.Error() != "user not found" {
 			t.Errorf("expected 'user not found' error, got %v", err)
 		}
-		if loggedInUser != nil {
-			t.Errorf("expected nil user, got %v", loggedInUser)
-		}
-		if token != "" {
-			t.Errorf("expected empty token, got %s", token)
+		if result != nil {
+			t.Errorf("expected nil result, got %v", result)
 		}
 	})
 
@@ -136,18 +159,15 @@ func TestUserService_Login(t *testing.T) {
 		user, _ := entity.NewUser(idNode.Generate().Int64(), username, plainPassword)
 		mockUserRepo.EXPECT().FindByUsername(gomock.Any(), username).Return(user, nil).Times(1)
 
-		loggedInUser, token, err := userService.Login(context.Background(), username, "wrongpassword")
+		result, err := userService.Login(context.Background(), username, "wrongpassword", "")
 		if err == nil {
 			t.Fatal("expected error, got nil")
 		}
 		if err.Error() != "crypto/bcrypt: hashedPassword is not the hash of the given password" {
 			t.Errorf("expected 'crypto/bcrypt: hashedPassword is not the hash of the given password' error, got %v", err)
 		}
-		if loggedInUser != nil {
-			t.Errorf("expected nil user, got %v", loggedInUser)
-		}
-		if token != "" {
-			t.Errorf("expected empty token, got %s", token)
+		if result != nil {
+			t.Errorf("expected nil result, got %v", result)
 		}
 	})
 
@@ -155,15 +175,517 @@ func TestUserService_Login(t *testing.T) {
 		expectedErr := errors.New("database error")
 		mockUserRepo.EXPECT().FindByUsername(gomock.Any(), username).Return(nil, expectedErr).Times(1)
 
-		loggedInUser, token, err := userService.Login(context.Background(), username, plainPassword)
+		result, err := userService.Login(context.Background(), username, plainPassword, "")
 		if err != expectedErr {
 			t.Errorf("expected error %v, got %v", expectedErr, err)
 		}
-		if loggedInUser != nil {
-			t.Errorf("expected nil user, got %v", loggedInUser)
+		if result != nil {
+			t.Errorf("expected nil result, got %v", result)
+		}
+	})
+}
+
+func TestUserService_LoginThrottle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockLoginFailureRepo := mocks.NewMockLoginFailureRepository(ctrl)
+	idNode, _ := snowflake.NewNode(1)
+	keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "test_secret"})
+	throttle := NewLoginThrottle(mockLoginFailureRepo, LoginThrottleOptions{
+		Account: entity.LoginThrottlePolicy{FreeFailures: 3, BaseDelay: time.Minute, ResetAfter: time.Hour},
+		IP:      entity.LoginThrottlePolicy{LockoutFailures: 100, Lockout: time.Hour},
+	})
+	userService := NewUserService(mockUserRepo, mockRefreshTokenRepo, nil, mockMFARepo, keyManager, idNode, TokenOptions{}, entity.PasswordPolicy{}, throttle)
+
+	ctx := context.Background()
+	user, _ := entity.NewUser(1, "alice", "password123")
+
+	t.Run("wrong password is counted for the account and the ip", func(t *testing.T) {
+		seen := &entity.LoginFailures{Key: "account:alice", Failures: 2, LastFailedAt: time.Now()}
+		mockLoginFailureRepo.EXPECT().Get(ctx, "account:alice").Return(seen, nil)
+		mockLoginFailureRepo.EXPECT().Get(ctx, "ip:10.0.0.1").Return(nil, nil)
+		mockLoginFailureRepo.EXPECT().Reserve(ctx, "account:alice", seen, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ *entity.LoginFailures, resetBefore time.Time) (bool, error) {
+			if time.Since(resetBefore) < time.Hour {
+				t.Errorf("expected failures to be kept for an hour, got %v", resetBefore)
+			}
+			return true, nil
+		})
+		mockLoginFailureRepo.EXPECT().Reserve(ctx, "ip:10.0.0.1", nil, time.Time{}).Return(true, nil)
+		mockUserRepo.EXPECT().FindByUsername(ctx, "Alice").Return(user, nil)
+
+		_, err := userService.Login(ctx, "Alice", "wrong", "10.0.0.1")
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+
+	t.Run("unknown users are counted too", func(t *testing.T) {
+		mockLoginFailureRepo.EXPECT().Get(ctx, "account:bob").Return(nil, nil)
+		mockLoginFailureRepo.EXPECT().Reserve(ctx, "account:bob", nil, gomock.Any()).Return(true, nil)
+		mockUserRepo.EXPECT().FindByUsername(ctx, "bob").Return(nil, nil)
+
+		if _, err := userService.Login(ctx, "bob", "password123", ""); err != internalErrors.UserNotFound {
+			t.Errorf("expected %v, got %v", internalErrors.UserNotFound, err)
+		}
+	})
+
+	t.Run("throttled account rejects the right password", func(t *testing.T) {
+		mockLoginFailureRepo.EXPECT().Get(ctx, "account:alice").Return(&entity.LoginFailures{Key: "account:alice", Failures: 3, LastFailedAt: time.Now()}, nil)
+
+		_, err := userService.Login(ctx, "alice", "password123", "10.0.0.1")
+		if err != internalErrors.TooManyLoginAttempts {
+			t.Errorf("expected %v, got %v", internalErrors.TooManyLoginAttempts, err)
+		}
+	})
+
+	t.Run("locked ip", func(t *testing.T) {
+		mockLoginFailureRepo.EXPECT().Get(ctx, "account:alice").Return(nil, nil)
+		mockLoginFailureRepo.EXPECT().Get(ctx, "ip:10.0.0.1").Return(&entity.LoginFailures{Key: "ip:10.0.0.1", Failures: 100, LastFailedAt: time.Now()}, nil)
+
+		_, err := userService.Login(ctx, "alice", "password123", "10.0.0.1")
+		if err != internalErrors.TooManyLoginAttempts {
+			t.Errorf("expected %v, got %v", internalErrors.TooManyLoginAttempts, err)
+		}
+	})
+
+	t.Run("concurrent guess counted first", func(t *testing.T) {
+		seen := &entity.LoginFailures{Key: "account:alice", Failures: 2, LastFailedAt: time.Now()}
+		mockLoginFailureRepo.EXPECT().Get(ctx, "account:alice").Return(seen, nil)
+		mockLoginFailureRepo.EXPECT().Reserve(ctx, "account:alice", seen, gomock.Any()).Return(false, nil)
+		// The other guess used the last free failure.
+		mockLoginFailureRepo.EXPECT().Get(ctx, "account:alice").Return(&entity.LoginFailures{Key: "account:alice", Failures: 3, LastFailedAt: time.Now()}, nil)
+
+		_, err := userService.Login(ctx, "alice", "password123", "")
+		if err != internalErrors.TooManyLoginAttempts {
+			t.Errorf("expected %v, got %v", internalErrors.TooManyLoginAttempts, err)
+		}
+	})
+
+	t.Run("success resets the account", func(t *testing.T) {
+		mockLoginFailureRepo.EXPECT().Get(ctx, "account:alice").Return(&entity.LoginFailures{Key: "account:alice", Failures: 3, LastFailedAt: time.Now().Add(-2 * time.Minute)}, nil)
+		mockLoginFailureRepo.EXPECT().Get(ctx, "ip:10.0.0.1").Return(nil, nil)
+		mockLoginFailureRepo.EXPECT().Reserve(ctx, "account:alice", gomock.Any(), gomock.Any()).Return(true, nil)
+		mockLoginFailureRepo.EXPECT().Reserve(ctx, "ip:10.0.0.1", nil, gomock.Any()).Return(true, nil)
+		mockUserRepo.EXPECT().FindByUsername(ctx, "alice").Return(user, nil)
+		mockLoginFailureRepo.EXPECT().Reset(ctx, "account:alice").Return(nil)
+		mockLoginFailureRepo.EXPECT().Forgive(ctx, "ip:10.0.0.1").Return(nil)
+		mockMFARepo.EXPECT().Get(ctx, int64(1)).Return(nil, nil)
+		mockRefreshTokenRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+
+		result, err := userService.Login(ctx, "alice", "password123", "10.0.0.1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Tokens == nil {
+			t.Error("expected tokens")
+		}
+	})
+}
+
+func TestUserService_CreateUser_Policy(t *testing.T) {
+	idNode, _ := snowflake.NewNode(1)
+	policy := entity.PasswordPolicy{MinLength: 8, MinCharacterClasses: 2, Breached: map[string]struct{}{"password1": {}}}
+	userService := NewUserService(nil, nil, nil, nil, nil, idNode, TokenOptions{}, policy, nil)
+
+	tests := []struct {
+		username string
+		password string
+		want     error
+	}{
+		{"a b", "password123", internalErrors.InvalidUsername},
+		{"alice", "short1", internalErrors.PasswordTooShort},
+		{"alice", "onlyletters", internalErrors.PasswordTooSimple},
+		{"alice", "Password1", internalErrors.PasswordBreached},
+	}
+	for _, tt := range tests {
+		if _, err := userService.CreateUser(context.Background(), tt.username, tt.password); err != tt.want {
+			t.Errorf("%q/%q: expected %v, got %v", tt.username, tt.password, tt.want, err)
+		}
+	}
+}
+
+func TestUserService_Refresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	idNode, _ := snowflake.NewNode(1)
+	keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "test_secret"})
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	userService := NewUserService(mockUserRepo, mockRefreshTokenRepo, nil, nil, keyManager, idNode, TokenOptions{}, entity.PasswordPolicy{}, nil)
+
+	ctx := context.Background()
+	refreshToken := "refresh-token"
+	tokenHash := entity.HashRefreshToken(refreshToken)
+	newToken := func() *entity.RefreshToken {
+		return &entity.RefreshToken{
+			ID:        "token-id",
+			UserID:    1234,
+			FamilyID:  "family-id",
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	t.Run("rotates the token", func(t *testing.T) {
+		mockRefreshTokenRepo.EXPECT().GetByHash(ctx, tokenHash).Return(newToken(), nil)
+		mockRefreshTokenRepo.EXPECT().MarkRotated(ctx, "token-id").Return(true, nil)
+		mockUserRepo.EXPECT().FindByID(ctx, int64(1234)).Return(&entity.User{ID: 1234, Role: entity.RoleReadOnly}, nil)
+		mockRefreshTokenRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, token *entity.RefreshToken) error {
+			if token.FamilyID != "family-id" || token.UserID != 1234 {
+				t.Errorf("expected the new token to stay in the family, got %+v", token)
+			}
+			return nil
+		})
+
+		tokens, err := userService.Refresh(ctx, refreshToken)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tokens.AccessToken == "" || tokens.RefreshToken == "" || tokens.RefreshToken == refreshToken {
+			t.Errorf("expected a new token pair, got %+v", tokens)
+		}
+		// The access token carries the role the user has now.
+		claims, _ := keyManager.Parse(tokens.AccessToken)
+		if claims["role"] != string(entity.RoleReadOnly) {
+			t.Errorf("expected role %s, got %v", entity.RoleReadOnly, claims["role"])
+		}
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		mockRefreshTokenRepo.EXPECT().GetByHash(ctx, tokenHash).Return(newToken(), nil)
+		mockRefreshTokenRepo.EXPECT().MarkRotated(ctx, "token-id").Return(true, nil)
+		mockUserRepo.EXPECT().FindByID(ctx, int64(1234)).Return(nil, nil)
+
+		_, err := userService.Refresh(ctx, refreshToken)
+		if err != internalErrors.InvalidRefreshToken {
+			t.Errorf("expected %v, got %v", internalErrors.InvalidRefreshToken, err)
+		}
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		mockRefreshTokenRepo.EXPECT().GetByHash(ctx, tokenHash).Return(nil, nil)
+
+		_, err := userService.Refresh(ctx, refreshToken)
+		if err != internalErrors.InvalidRefreshToken {
+			t.Errorf("expected %v, got %v", internalErrors.InvalidRefreshToken, err)
+		}
+	})
+
+	t.Run("reused token revokes the family", func(t *testing.T) {
+		token := newToken()
+		rotatedAt := time.Now().Add(-time.Minute)
+		token.RotatedAt = &rotatedAt
+		mockRefreshTokenRepo.EXPECT().GetByHash(ctx, tokenHash).Return(token, nil)
+		mockRefreshTokenRepo.EXPECT().RevokeFamily(ctx, "family-id").Return(nil)
+
+		_, err := userService.Refresh(ctx, refreshToken)
+		if err != internalErrors.InvalidRefreshToken {
+			t.Errorf("expected %v, got %v", internalErrors.InvalidRefreshToken, err)
+		}
+	})
+
+	t.Run("concurrent rotation revokes the family", func(t *testing.T) {
+		mockRefreshTokenRepo.EXPECT().GetByHash(ctx, tokenHash).Return(newToken(), nil)
+		mockRefreshTokenRepo.EXPECT().MarkRotated(ctx, "token-id").Return(false, nil)
+		mockRefreshTokenRepo.EXPECT().RevokeFamily(ctx, "family-id").Return(nil)
+
+		_, err := userService.Refresh(ctx, refreshToken)
+		if err != internalErrors.InvalidRefreshToken {
+			t.Errorf("expected %v, got %v", internalErrors.InvalidRefreshToken, err)
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		token := newToken()
+		token.ExpiresAt = time.Now().Add(-time.Minute)
+		mockRefreshTokenRepo.EXPECT().GetByHash(ctx, tokenHash).Return(token, nil)
+
+		_, err := userService.Refresh(ctx, refreshToken)
+		if err != internalErrors.InvalidRefreshToken {
+			t.Errorf("expected %v, got %v", internalErrors.InvalidRefreshToken, err)
+		}
+	})
+}
+
+func TestUserService_Logout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockRevokedTokenRepo := mocks.NewMockRevokedTokenRepository(ctrl)
+	idNode, _ := snowflake.NewNode(1)
+	keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "test_secret"})
+	userService := NewUserService(nil, mockRefreshTokenRepo, mockRevokedTokenRepo, nil, keyManager, idNode, TokenOptions{}, entity.PasswordPolicy{}, nil)
+
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Minute)
+
+	t.Run("revokes the access token and the family", func(t *testing.T) {
+		mockRevokedTokenRepo.EXPECT().Revoke(ctx, "jti", expiresAt).Return(nil)
+		mockRefreshTokenRepo.EXPECT().RevokeFamily(ctx, "family-id").Return(nil)
+
+		if err := userService.Logout(ctx, "jti", expiresAt, "family-id"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("revoke error", func(t *testing.T) {
+		expectedErr := errors.New("database error")
+		mockRevokedTokenRepo.EXPECT().Revoke(ctx, "jti", expiresAt).Return(expectedErr)
+
+		if err := userService.Logout(ctx, "jti", expiresAt, "family-id"); err != expectedErr {
+			t.Errorf("expected error %v, got %v", expectedErr, err)
+		}
+	})
+}
+
+func TestUserService_LoginWithMFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockRevokedTokenRepo := mocks.NewMockRevokedTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	idNode, _ := snowflake.NewNode(1)
+	keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "test_secret"})
+	userService := NewUserService(mockUserRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockMFARepo, keyManager, idNode, TokenOptions{}, entity.PasswordPolicy{}, nil)
+
+	ctx := context.Background()
+	user, _ := entity.NewUser(idNode.Generate().Int64(), "testuser", "testpassword")
+	secret, _ := entity.NewTOTPSecret()
+	now := time.Now()
+	mfa := &entity.UserMFA{UserID: user.ID, Secret: secret, EnabledAt: &now}
+
+	login := func(t *testing.T) string {
+		mockUserRepo.EXPECT().FindByUsername(ctx, "testuser").Return(user, nil)
+		mockMFARepo.EXPECT().Get(ctx, user.ID).Return(mfa, nil)
+
+		result, err := userService.Login(ctx, "testuser", "testpassword", "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.MFARequired() || result.Tokens != nil {
+			t.Fatalf("expected an mfa challenge, got %+v", result)
+		}
+		return result.MFAToken
+	}
+
+	t.Run("challenge token is not an access token", func(t *testing.T) {
+		claims, err := keyManager.Parse(login(t))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if claims["typ"] != MFATokenType {
+			t.Errorf("expected typ %s, got %v", MFATokenType, claims["typ"])
+		}
+	})
+
+	t.Run("verify with totp code", func(t *testing.T) {
+		mfaToken := login(t)
+		code, _ := entity.TOTPCode(secret, entity.TOTPStep(time.Now()))
+		mockRevokedTokenRepo.EXPECT().IsRevoked(ctx, gomock.Any()).Return(false, nil)
+		mockMFARepo.EXPECT().Get(ctx, user.ID).Return(mfa, nil)
+		mockMFARepo.EXPECT().UseStep(ctx, user.ID, gomock.Any()).Return(true, nil)
+		mockRevokedTokenRepo.EXPECT().Revoke(ctx, gomock.Any(), gomock.Any()).Return(nil)
+		mockUserRepo.EXPECT().FindByID(ctx, user.ID).Return(user, nil)
+		mockRefreshTokenRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+
+		verifiedUser, tokens, err := userService.VerifyMFA(ctx, mfaToken, code)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if verifiedUser.ID != user.ID || tokens == nil || tokens.AccessToken == "" {
+			t.Errorf("expected tokens for user %d", user.ID)
+		}
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		mfaToken := login(t)
+		mockRevokedTokenRepo.EXPECT().IsRevoked(ctx, gomock.Any()).Return(false, nil)
+		mockMFARepo.EXPECT().Get(ctx, user.ID).Return(mfa, nil)
+		mockMFARepo.EXPECT().RecordFailure(ctx, user.ID).Return(nil)
+		expired, _ := entity.TOTPCode(secret, entity.TOTPStep(time.Now())-10)
+
+		_, _, err := userService.VerifyMFA(ctx, mfaToken, expired)
+		if !errors.Is(err, internalErrors.InvalidMFACode) {
+			t.Errorf("expected %v, got %v", internalErrors.InvalidMFACode, err)
+		}
+	})
+
+	t.Run("used challenge token", func(t *testing.T) {
+		mfaToken := login(t)
+		mockRevokedTokenRepo.EXPECT().IsRevoked(ctx, gomock.Any()).Return(true, nil)
+
+		_, _, err := userService.VerifyMFA(ctx, mfaToken, "123456")
+		if !errors.Is(err, internalErrors.InvalidMFAToken) {
+			t.Errorf("expected %v, got %v", internalErrors.InvalidMFAToken, err)
+		}
+	})
+
+	t.Run("access token is not a challenge token", func(t *testing.T) {
+		mockRefreshTokenRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+		tokens, err := userService.StartSession(ctx, user)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		_, _, err = userService.VerifyMFA(ctx, tokens.AccessToken, "123456")
+		if !errors.Is(err, internalErrors.InvalidMFAToken) {
+			t.Errorf("expected %v, got %v", internalErrors.InvalidMFAToken, err)
+		}
+	})
+}
+
+func TestUserService_SetRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	idNode, _ := snowflake.NewNode(1)
+	keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "test_secret"})
+	userService := NewUserService(mockUserRepo, nil, nil, nil, keyManager, idNode, TokenOptions{}, entity.PasswordPolicy{}, nil)
+
+	ctx := context.Background()
+	admin := Caller{UserID: 1, Role: entity.RoleAdmin}
+
+	t.Run("admin", func(t *testing.T) {
+		mockUserRepo.EXPECT().UpdateRole(ctx, int64(2), entity.RoleReadOnly).Return(true, nil)
+
+		if err := userService.SetRole(ctx, admin, 2, entity.RoleReadOnly); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockUserRepo.EXPECT().UpdateRole(ctx, int64(3), entity.RoleMember).Return(false, nil)
+
+		if err := userService.SetRole(ctx, admin, 3, entity.RoleMember); err != internalErrors.UserNotFound {
+			t.Errorf("expected %v, got %v", internalErrors.UserNotFound, err)
+		}
+	})
+
+	t.Run("invalid role", func(t *testing.T) {
+		if err := userService.SetRole(ctx, admin, 2, "owner"); err != internalErrors.InvalidRole {
+			t.Errorf("expected %v, got %v", internalErrors.InvalidRole, err)
+		}
+	})
+
+	t.Run("member", func(t *testing.T) {
+		err := userService.SetRole(ctx, Caller{UserID: 2, Role: entity.RoleMember}, 2, entity.RoleAdmin)
+		if err != internalErrors.PermissionDenied {
+			t.Errorf("expected %v, got %v", internalErrors.PermissionDenied, err)
+		}
+	})
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	idNode, _ := snowflake.NewNode(1)
+	keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "test_secret"})
+	userService := NewUserService(mockUserRepo, mockRefreshTokenRepo, nil, nil, keyManager, idNode, TokenOptions{}, entity.PasswordPolicy{}, nil)
+
+	ctx := context.Background()
+	newUser := func() *entity.User {
+		user, _ := entity.NewUser(1, "alice", "old-password")
+		return user
+	}
+
+	t.Run("ends the other sessions", func(t *testing.T) {
+		before := time.Now()
+		mockUserRepo.EXPECT().FindByID(ctx, int64(1)).Return(newUser(), nil)
+		mockUserRepo.EXPECT().UpdatePassword(ctx, int64(1), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ int64, password string, sessionsValidAfter time.Time) (bool, error) {
+				user := &entity.User{Password: password}
+				if user.ValidatePassword("new-password") != nil {
+					t.Error("expected the hash of the new password")
+				}
+				if sessionsValidAfter.Before(before) {
+					t.Errorf("expected sessions to end now, got %v", sessionsValidAfter)
+				}
+				return true, nil
+			})
+		revoke := mockRefreshTokenRepo.EXPECT().RevokeByUserID(ctx, int64(1)).Return(nil)
+		mockRefreshTokenRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil).After(revoke)
+
+		tokens, err := userService.ChangePassword(ctx, 1, "old-password", "new-password")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+			t.Error("expected a new session")
+		}
+	})
+
+	t.Run("wrong old password", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(ctx, int64(1)).Return(newUser(), nil)
+
+		_, err := userService.ChangePassword(ctx, 1, "wrong", "new-password")
+		if err != internalErrors.InvalidPassword {
+			t.Errorf("expected %v, got %v", internalErrors.InvalidPassword, err)
+		}
+	})
+
+	t.Run("empty new password", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(ctx, int64(1)).Return(newUser(), nil)
+
+		_, err := userService.ChangePassword(ctx, 1, "old-password", "")
+		if err != internalErrors.EmptyPassword {
+			t.Errorf("expected %v, got %v", internalErrors.EmptyPassword, err)
+		}
+	})
+
+	t.Run("new password against the policy", func(t *testing.T) {
+		strictService := NewUserService(mockUserRepo, mockRefreshTokenRepo, nil, nil, keyManager, idNode, TokenOptions{}, entity.PasswordPolicy{MinLength: 20}, nil)
+		mockUserRepo.EXPECT().FindByID(ctx, int64(1)).Return(newUser(), nil)
+
+		_, err := strictService.ChangePassword(ctx, 1, "old-password", "new-password")
+		if err != internalErrors.PasswordTooShort {
+			t.Errorf("expected %v, got %v", internalErrors.PasswordTooShort, err)
 		}
-		if token != "" {
-			t.Errorf("expected empty token, got %s", token)
+	})
+}
+
+func TestUserService_GetProfile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	idNode, _ := snowflake.NewNode(1)
+	keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "test_secret"})
+	userService := NewUserService(mockUserRepo, nil, nil, mockMFARepo, keyManager, idNode, TokenOptions{}, entity.PasswordPolicy{}, nil)
+
+	ctx := context.Background()
+
+	t.Run("with mfa", func(t *testing.T) {
+		enabledAt := time.Now()
+		mockUserRepo.EXPECT().FindByID(ctx, int64(1)).Return(&entity.User{ID: 1, Username: "alice", Role: entity.RoleMember}, nil)
+		mockMFARepo.EXPECT().Get(ctx, int64(1)).Return(&entity.UserMFA{UserID: 1, EnabledAt: &enabledAt}, nil)
+
+		profile, err := userService.GetProfile(ctx, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if profile.User.Username != "alice" || !profile.MFAEnabled {
+			t.Errorf("unexpected profile: %+v", profile)
+		}
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(ctx, int64(2)).Return(nil, nil)
+
+		if _, err := userService.GetProfile(ctx, 2); err != internalErrors.UserNotFound {
+			t.Errorf("expected %v, got %v", internalErrors.UserNotFound, err)
 		}
 	})
 }
@@ -0,0 +1,75 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix starts every API key, which tells them apart from JWTs in the Authorization header.
+const APIKeyPrefix = "cm_"
+
+const (
+	ScopeContainersRead  = "containers:read"
+	ScopeContainersWrite = "containers:write"
+	ScopeFilesRead       = "files:read"
+	ScopeFilesWrite      = "files:write"
+	ScopeJobsRead        = "jobs:read"
+)
+
+// Scopes lists every scope an API key can be granted.
+var Scopes = []string{ScopeContainersRead, ScopeContainersWrite, ScopeFilesRead, ScopeFilesWrite, ScopeJobsRead}
+
+// APIKey lets scripts call the API on behalf of a user without a password. Only the hash
+// of the key is stored; Prefix keeps its first characters so that users can tell keys apart.
+type APIKey struct {
+	ID         string
+	UserID     int64
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// NewAPIKeyValue returns a new random API key together with its display prefix and hash.
+func NewAPIKeyValue() (key, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	key = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:len(APIKeyPrefix)+8], HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hash under which an API key is stored.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIKey reports whether a bearer token is an API key rather than a JWT.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// ValidScope reports whether scope is one of Scopes.
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// Usable reports whether the key can still be used to authenticate.
+func (k *APIKey) Usable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"container-manager/internal/errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// archiveExtensions are the extensions of the archives that can be extracted, longest first.
+var archiveExtensions = []string{".tar.gz", ".tgz", ".tar", ".zip"}
+
+// ExtractLimits bound what extracting an archive may write, against archives crafted to fill the
+// storage when expanded. Zero fields are unlimited.
+type ExtractLimits struct {
+	// MaxFiles is the number of files and folders an archive may hold.
+	MaxFiles int
+	// MaxSize is the total size of the extracted files in bytes.
+	MaxSize int64
+	// MaxRatio is how many times larger than the archive the extracted files may be together.
+	MaxRatio int64
+}
+
+// CheckFiles checks the number of files and folders of an archive.
+func (l ExtractLimits) CheckFiles(count int) error {
+	if l.MaxFiles > 0 && count > l.MaxFiles {
+		return errors.ArchiveTooManyFiles
+	}
+	return nil
+}
+
+// CheckSize checks the total size of the files of an archive of archiveSize bytes.
+func (l ExtractLimits) CheckSize(total, archiveSize int64) error {
+	if l.MaxSize > 0 && total > l.MaxSize {
+		return errors.ArchiveTooLarge
+	}
+	if l.MaxRatio > 0 && archiveSize <= math.MaxInt64/l.MaxRatio && total > archiveSize*l.MaxRatio {
+		return errors.ArchiveTooLarge.New("archive expands to more than " + strconv.FormatInt(l.MaxRatio, 10) + " times its size")
+	}
+	return nil
+}
+
+// ArchiveFolder returns the folder an archive is extracted to unless told otherwise: the path of
+// the archive without its extension, as "input/bundle" for "input/bundle.tar.gz".
+func ArchiveFolder(name string) string {
+	lower := strings.ToLower(name)
+	for _, extension := range archiveExtensions {
+		if strings.HasSuffix(lower, extension) && len(name) > len(extension) && !strings.HasSuffix(name[:len(name)-len(extension)], "/") {
+			return name[:len(name)-len(extension)]
+		}
+	}
+	return name + ".d"
+}
@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"container-manager/internal/errors"
+	"testing"
+)
+
+func TestExtractLimits(t *testing.T) {
+	limits := ExtractLimits{MaxFiles: 3, MaxSize: 1000, MaxRatio: 10}
+
+	if err := limits.CheckFiles(3); err != nil {
+		t.Errorf("expected 3 files to be allowed, got %v", err)
+	}
+	if err := limits.CheckFiles(4); err != errors.ArchiveTooManyFiles {
+		t.Errorf("expected %v for 4 files, got %v", errors.ArchiveTooManyFiles, err)
+	}
+
+	for _, test := range []struct {
+		total, archiveSize int64
+		allowed            bool
+	}{
+		{1000, 100, true},
+		{1001, 200, false}, // larger than MaxSize
+		{501, 50, false},   // expands more than 10 times
+		{500, 50, true},
+	} {
+		err := limits.CheckSize(test.total, test.archiveSize)
+		if test.allowed && err != nil {
+			t.Errorf("CheckSize(%d, %d): expected no error, got %v", test.total, test.archiveSize, err)
+		}
+		if customErr, ok := err.(*errors.CustomError); !test.allowed && (!ok || customErr.Message != errors.ArchiveTooLarge.Message) {
+			t.Errorf("CheckSize(%d, %d): expected %v, got %v", test.total, test.archiveSize, errors.ArchiveTooLarge, err)
+		}
+	}
+
+	var unlimited ExtractLimits
+	if unlimited.CheckFiles(1<<20) != nil || unlimited.CheckSize(1<<40, 1) != nil {
+		t.Error("expected the zero value to be unlimited")
+	}
+}
+
+func TestArchiveFolder(t *testing.T) {
+	for name, want := range map[string]string{
+		"bundle.tar.gz":       "bundle",
+		"input/bundle.TGZ":    "input/bundle",
+		"bundle.tar":          "bundle",
+		"site.v2.zip":         "site.v2",
+		"bundle":              "bundle.d",
+		"input/.zip":          "input/.zip.d",
+		"backup.tar.gz.part1": "backup.tar.gz.part1.d",
+	} {
+		if got := ArchiveFolder(name); got != want {
+			t.Errorf("ArchiveFolder(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
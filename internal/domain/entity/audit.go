@@ -0,0 +1,69 @@
+package entity
+
+import "time"
+
+// The actions recorded in the audit log.
+const (
+	AuditActionUserCreate       = "user.create"
+	AuditActionUserLogin        = "user.login"
+	AuditActionUserLoginMFA     = "user.login.mfa"
+	AuditActionUserLoginOIDC    = "user.login.oidc"
+	AuditActionUserRefresh      = "user.refresh"
+	AuditActionUserLogout       = "user.logout"
+	AuditActionUserPassword     = "user.password"
+	AuditActionUserDelete       = "user.delete"
+	AuditActionUserRole         = "user.role"
+	AuditActionAPIKeyCreate     = "api_key.create"
+	AuditActionAPIKeyRevoke     = "api_key.revoke"
+	AuditActionMFAEnroll        = "mfa.enroll"
+	AuditActionMFAConfirm       = "mfa.confirm"
+	AuditActionMFADisable       = "mfa.disable"
+	AuditActionTeamCreate       = "team.create"
+	AuditActionTeamMemberSet    = "team.member.set"
+	AuditActionTeamMemberRemove = "team.member.remove"
+	AuditActionContainerCreate  = "container.create"
+	AuditActionContainerStart   = "container.start"
+	AuditActionContainerStop    = "container.stop"
+	AuditActionContainerRename  = "container.rename"
+	AuditActionContainerRemove  = "container.remove"
+	AuditActionContainerCopyIn  = "container.copy_in"
+	AuditActionContainerExport  = "container.export"
+	AuditActionFileUpload       = "file.upload"
+	AuditActionFileDelete       = "file.delete"
+	AuditActionFileMove         = "file.move"
+	AuditActionFileExtract      = "file.extract"
+	AuditActionFileShare        = "file.share"
+	AuditActionShareRevoke      = "share.revoke"
+	AuditActionFolderCreate     = "folder.create"
+	AuditActionUploadCreate     = "upload.create"
+	AuditActionUploadWrite      = "upload.write"
+	AuditActionUploadCancel     = "upload.cancel"
+)
+
+type AuditOutcome string
+
+const (
+	AuditOutcomeSuccess AuditOutcome = "success"
+	AuditOutcomeFailure AuditOutcome = "failure"
+)
+
+// AuditEntry records who attempted an action on what, and how it ended. Entries are never
+// changed once written.
+type AuditEntry struct {
+	ID        int64
+	CreatedAt time.Time
+	// ActorID is the authenticated user, or 0 for requests without one such as logins.
+	ActorID int64
+	// APIKeyID is set when the actor authenticated with an API key.
+	APIKeyID string
+	Action   string
+	// TargetType and TargetID name the resource acted on, e.g. "container" and its ID. TargetID
+	// is empty when the request failed before the resource was known.
+	TargetType string
+	TargetID   string
+	SourceIP   string
+	Outcome    AuditOutcome
+	// Error is the message returned to the client when the action failed.
+	Error     string
+	RequestID string
+}
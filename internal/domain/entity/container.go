@@ -1,12 +1,33 @@
 package entity
 
-import "github.com/moby/moby/api/types/container"
+import (
+	"container-manager/internal/errors"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+// LabelPrefix is the label namespace reserved for labels managed by the system.
+const LabelPrefix = "container-manager."
+
+const (
+	LabelOwner = LabelPrefix + "owner"
+	LabelTeam  = LabelPrefix + "team"
+	LabelJobID = LabelPrefix + "job-id"
+)
+
+// containerNamePattern limits names to 63 characters so that a name can never be mistaken for a 64 character container ID.
+var containerNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,62}$`)
 
 type Container struct {
 	ID     string
+	Name   string
 	Image  string
 	Cmd    []string
 	Env    []string
+	Labels map[string]string
 	Status container.ContainerState
 }
 
@@ -19,3 +40,34 @@ func NewContainer(id string, userId int64, image string, cmd []string, env []str
 		Status: status,
 	}
 }
+
+// ValidateLabels checks user supplied labels. Keys must be non-empty and must not
+// use the reserved system namespace.
+func ValidateLabels(labels map[string]string) error {
+	for key := range labels {
+		if key == "" {
+			return errors.InvalidLabel.New("label key cannot be empty")
+		}
+		if strings.HasPrefix(key, LabelPrefix) {
+			return errors.InvalidLabel.New("label key " + key + " uses the reserved " + LabelPrefix + " prefix")
+		}
+	}
+	return nil
+}
+
+// ValidateContainerName checks a user supplied container name.
+func ValidateContainerName(name string) error {
+	if !containerNamePattern.MatchString(name) {
+		return errors.InvalidContainerName
+	}
+	return nil
+}
+
+// ValidateContainerPath checks a user supplied path of a file or folder in a container. Paths are
+// resolved by the runtime, inside the container, so they only have to be absolute.
+func ValidateContainerPath(p string) error {
+	if !path.IsAbs(p) || strings.ContainsRune(p, 0) {
+		return errors.InvalidContainerPath
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"container-manager/internal/errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateLabels(t *testing.T) {
+	t.Run("valid labels", func(t *testing.T) {
+		if err := ValidateLabels(map[string]string{"env": "prod", "team": ""}); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("reserved prefix", func(t *testing.T) {
+		if err := ValidateLabels(map[string]string{LabelOwner: "1"}); err == nil {
+			t.Error("expected error for reserved label, got nil")
+		}
+	})
+
+	t.Run("empty key", func(t *testing.T) {
+		if err := ValidateLabels(map[string]string{"": "value"}); err == nil {
+			t.Error("expected error for empty label key, got nil")
+		}
+	})
+}
+
+func TestValidateContainerName(t *testing.T) {
+	valid := []string{"web", "web-1", "my_app.v2", strings.Repeat("a", 63)}
+	for _, name := range valid {
+		if err := ValidateContainerName(name); err != nil {
+			t.Errorf("expected %q to be valid, got %v", name, err)
+		}
+	}
+
+	invalid := []string{"", "-web", "../web", "web app", strings.Repeat("a", 64)}
+	for _, name := range invalid {
+		if err := ValidateContainerName(name); err != errors.InvalidContainerName {
+			t.Errorf("expected %q to be invalid, got %v", name, err)
+		}
+	}
+}
+
+func TestValidateContainerPath(t *testing.T) {
+	valid := []string{"/", "/app", "/app/data/", "/app/../tmp"}
+	for _, p := range valid {
+		if err := ValidateContainerPath(p); err != nil {
+			t.Errorf("expected %q to be valid, got %v", p, err)
+		}
+	}
+
+	invalid := []string{"", "app", "./app", "/app\x00"}
+	for _, p := range invalid {
+		if err := ValidateContainerPath(p); err != errors.InvalidContainerPath {
+			t.Errorf("expected %q to be invalid, got %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// ContainerUser records which user owns a container and the name the user gave it.
+// Containers shared with a team also record the team, see Owner.
+type ContainerUser struct {
+	ContainerID string
+	UserID      int64
+	// TeamID is the team owning the container, or zero for a personal container.
+	TeamID    int64
+	Name      string
+	CreatedAt time.Time
+	// MemoryBytes and NanoCPUs are the resources reserved against the user's quota for the container.
+	MemoryBytes int64
+	NanoCPUs    int64
+	// Running records whether the container is counted as running in the user's quota.
+	Running bool
+}
+
+func (c *ContainerUser) Owner() Owner {
+	return Owner{UserID: c.UserID, TeamID: c.TeamID}
+}
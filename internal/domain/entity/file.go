@@ -0,0 +1,153 @@
+package entity
+
+import (
+	"container-manager/internal/errors"
+	"mime"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// MaxFilePathLength and MaxFileNameLength bound file paths and each of their elements, in bytes.
+	MaxFilePathLength = 1024
+	MaxFileNameLength = 255
+	// UploadsFolder is the folder, in the top folder of each owner, that keeps incomplete uploads
+	// and files being written. BlobsFolder keeps the content of files by its checksum, where the
+	// storage deduplicates it. Both are reserved, so that clients can neither reach them nor see
+	// them.
+	UploadsFolder = ".uploads"
+	BlobsFolder   = ".blobs"
+)
+
+// FileInfo describes a stored file or folder.
+type FileInfo struct {
+	// Name is the path of the file in the folder of its owner, with "/" as the separator.
+	Name string
+	// IsDir is set for folders, which have no size, content type or ETag.
+	IsDir       bool
+	Size        int64
+	ModTime     time.Time
+	ContentType string
+	// ETag identifies the content of the file, and changes whenever the file is written.
+	ETag string
+	// SHA256 is the hex encoded checksum of the content. It is only known for a file that was
+	// just written.
+	SHA256 string
+}
+
+// FileRecord is what is recorded about a stored file, so that its checksum is known without
+// reading it.
+type FileRecord struct {
+	Owner Owner
+	// UploadedBy is the user who uploaded the file, whose storage quota it counts against. It is
+	// zero for team files of users who deleted their account.
+	UploadedBy  int64
+	Path        string
+	SHA256      string
+	Size        int64
+	ContentType string
+	UploadedAt  time.Time
+}
+
+// UploadPolicy limits the files that can be uploaded. The zero value allows any file.
+type UploadPolicy struct {
+	// MaxFileSize is the size of the largest file in bytes, unlimited if zero.
+	MaxFileSize int64
+	// AllowedTypes are the media types files may have, as sniffed from their content, where
+	// "image/*" stands for every image type. Any type is allowed if it is empty.
+	AllowedTypes []string
+	// DeniedTypes are media types files may not have, even if they are allowed.
+	DeniedTypes []string
+}
+
+// ChecksTypes reports whether the policy limits the types of files.
+func (p UploadPolicy) ChecksTypes() bool {
+	return len(p.AllowedTypes) > 0 || len(p.DeniedTypes) > 0
+}
+
+// CheckSize checks the size of a file to upload.
+func (p UploadPolicy) CheckSize(size int64) error {
+	if p.MaxFileSize > 0 && size > p.MaxFileSize {
+		return errors.FileTooLarge
+	}
+	return nil
+}
+
+// CheckContentType checks the type of a file to upload, such as "text/plain; charset=utf-8".
+// Parameters are ignored, and types that cannot be parsed count as application/octet-stream.
+func (p UploadPolicy) CheckContentType(contentType string) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "application/octet-stream"
+	}
+	if matchMediaType(p.DeniedTypes, mediaType) || (len(p.AllowedTypes) > 0 && !matchMediaType(p.AllowedTypes, mediaType)) {
+		return errors.FileTypeNotAllowed.New("file type " + mediaType + " is not allowed")
+	}
+	return nil
+}
+
+// matchMediaType reports whether a lowercase media type is one of patterns.
+func matchMediaType(patterns []string, mediaType string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == mediaType || (strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, pattern[:len(pattern)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanFilePath normalises a client supplied path of a file in the folder of its owner, and
+// rejects paths that could name anything outside of it. The result is relative, uses "/" as the
+// separator and is in Unicode NFC, so that the same name typed on different systems names the
+// same file. Empty and "." elements are dropped, while ".." elements, backslashes, control
+// characters and bidirectional overrides, which can disguise a name, are rejected, and so are the
+// reserved UploadsFolder and BlobsFolder.
+func CleanFilePath(name string) (string, error) {
+	if !utf8.ValidString(name) {
+		return "", errors.InvalidFilename.New("filename is not valid UTF-8")
+	}
+	name = norm.NFC.String(name)
+	if len(name) > MaxFilePathLength {
+		return "", errors.InvalidFilename.New("path is too long")
+	}
+	if strings.HasPrefix(name, "/") {
+		return "", errors.InvalidFilename.New("path must be relative")
+	}
+	for _, r := range name {
+		if r == '\\' || unicode.IsControl(r) || isBidiControl(r) {
+			return "", errors.InvalidFilename.New("filename contains a forbidden character")
+		}
+	}
+
+	elements := make([]string, 0, strings.Count(name, "/")+1)
+	for _, element := range strings.Split(name, "/") {
+		switch element {
+		case "", ".":
+			continue
+		case "..":
+			return "", errors.InvalidFilename.New("path must not contain ..")
+		}
+		if len(element) > MaxFileNameLength {
+			return "", errors.InvalidFilename.New("filename is too long")
+		}
+		elements = append(elements, element)
+	}
+	if len(elements) == 0 {
+		return "", errors.InvalidFilename.New("filename cannot be empty")
+	}
+	if elements[0] == UploadsFolder || elements[0] == BlobsFolder {
+		return "", errors.InvalidFilename.New("path is reserved for the storage")
+	}
+	return strings.Join(elements, "/"), nil
+}
+
+// isBidiControl reports whether r changes the direction of the text around it, which can make a
+// name like "invoice<U+202E>fdp.exe" display as "invoiceexe.pdf".
+func isBidiControl(r rune) bool {
+	return (r >= '\u202a' && r <= '\u202e') || (r >= '\u2066' && r <= '\u2069') || r == '\u200e' || r == '\u200f' || r == '\u061c'
+}
@@ -0,0 +1,118 @@
+package entity
+
+import (
+	"container-manager/internal/errors"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// FileShare is a link that lets anyone who has it download a file without logging in, until it
+// expires, is revoked or has been used MaxDownloads times.
+type FileShare struct {
+	ID string
+	// UserID is the user who created the link, and who can revoke it.
+	UserID int64
+	// TeamID is set if the file is a file of a team.
+	TeamID int64
+	Path   string
+	// MaxDownloads is how often the file can be downloaded with the link, unlimited if zero.
+	MaxDownloads int
+	Downloads    int
+	ExpiresAt    time.Time
+	RevokedAt    *time.Time
+	CreatedAt    time.Time
+}
+
+// Owner is the owner of the shared file.
+func (s *FileShare) Owner() Owner {
+	return Owner{UserID: s.UserID, TeamID: s.TeamID}
+}
+
+// Usable reports whether the file can still be downloaded with the link.
+func (s *FileShare) Usable(now time.Time) error {
+	switch {
+	case s.RevokedAt != nil:
+		return errors.ShareLinkRevoked
+	case !now.Before(s.ExpiresAt):
+		return errors.ShareLinkExpired
+	case s.MaxDownloads > 0 && s.Downloads >= s.MaxDownloads:
+		return errors.ShareLinkExhausted
+	}
+	return nil
+}
+
+// ShareToken is what the token of a share link carries. The token is signed, so that a forged or
+// altered link is rejected before the share is looked up.
+type ShareToken struct {
+	ID           string `json:"id"`
+	Path         string `json:"path"`
+	UserID       int64  `json:"uid"`
+	TeamID       int64  `json:"tid,omitempty"`
+	ExpiresAt    int64  `json:"exp"`
+	MaxDownloads int    `json:"max,omitempty"`
+}
+
+// NewShareToken returns the claims of the token of a share.
+func NewShareToken(share *FileShare) *ShareToken {
+	return &ShareToken{
+		ID:           share.ID,
+		Path:         share.Path,
+		UserID:       share.UserID,
+		TeamID:       share.TeamID,
+		ExpiresAt:    share.ExpiresAt.Unix(),
+		MaxDownloads: share.MaxDownloads,
+	}
+}
+
+// Sign returns the token, the base64url encoded claims and their HMAC-SHA256 under secret,
+// separated by a dot.
+func (t *ShareToken) Sign(secret []byte) (string, error) {
+	claims, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signShareToken(payload, secret)), nil
+}
+
+// Matches reports whether the token was issued for share, so that what is served is always what
+// the token names.
+func (t *ShareToken) Matches(share *FileShare) bool {
+	return *t == *NewShareToken(share)
+}
+
+// ParseShareToken verifies a token signed with secret and returns its claims. It fails with
+// errors.ShareLinkNotFound for a token that is malformed or signed otherwise, and with
+// errors.ShareLinkExpired once the token expired.
+func ParseShareToken(token string, secret []byte, now time.Time) (*ShareToken, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, errors.ShareLinkNotFound
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, signShareToken(payload, secret)) {
+		return nil, errors.ShareLinkNotFound
+	}
+	claims, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, errors.ShareLinkNotFound
+	}
+	var t ShareToken
+	if err := json.Unmarshal(claims, &t); err != nil {
+		return nil, errors.ShareLinkNotFound
+	}
+	if now.Unix() >= t.ExpiresAt {
+		return nil, errors.ShareLinkExpired
+	}
+	return &t, nil
+}
+
+func signShareToken(payload string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,77 @@
+package entity
+
+import (
+	"container-manager/internal/errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileShare_Usable(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		share FileShare
+		want  error
+	}{
+		{name: "usable", share: FileShare{ExpiresAt: now.Add(time.Hour)}},
+		{name: "downloads left", share: FileShare{ExpiresAt: now.Add(time.Hour), MaxDownloads: 3, Downloads: 2}},
+		{name: "expired", share: FileShare{ExpiresAt: now}, want: errors.ShareLinkExpired},
+		{name: "revoked", share: FileShare{ExpiresAt: now.Add(time.Hour), RevokedAt: &now}, want: errors.ShareLinkRevoked},
+		{name: "exhausted", share: FileShare{ExpiresAt: now.Add(time.Hour), MaxDownloads: 3, Downloads: 3}, want: errors.ShareLinkExhausted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.share.Usable(now); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestShareToken(t *testing.T) {
+	now := time.Now()
+	secret := []byte("secret")
+	share := &FileShare{ID: "share-id", UserID: 1, TeamID: 2, Path: "reports/q1.csv", MaxDownloads: 5, ExpiresAt: now.Add(time.Hour)}
+
+	token, err := NewShareToken(share).Sign(secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	claims, err := ParseShareToken(token, secret, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !claims.Matches(share) {
+		t.Errorf("expected the claims of the share, got %+v", claims)
+	}
+	other := *share
+	other.Path = "reports/q2.csv"
+	if claims.Matches(&other) {
+		t.Error("expected the claims not to match another path")
+	}
+
+	if _, err := ParseShareToken(token, secret, now.Add(time.Hour)); err != errors.ShareLinkExpired {
+		t.Errorf("expected %v, got %v", errors.ShareLinkExpired, err)
+	}
+
+	payload, signature, _ := strings.Cut(token, ".")
+	otherToken, _ := NewShareToken(&other).Sign(secret)
+	otherPayload, _, _ := strings.Cut(otherToken, ".")
+	for name, invalid := range map[string]string{
+		"other secret": token,
+		// Another path with the signature of the original one.
+		"altered":   otherPayload + "." + signature,
+		"unsigned":  payload,
+		"malformed": "not.a-token",
+	} {
+		key := secret
+		if name == "other secret" {
+			key = []byte("other")
+		}
+		if _, err := ParseShareToken(invalid, key, now); err != errors.ShareLinkNotFound {
+			t.Errorf("%s: expected %v, got %v", name, errors.ShareLinkNotFound, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package entity
+
+import (
+	"container-manager/internal/errors"
+	stderrors "errors"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestCleanFilePath(t *testing.T) {
+	valid := []struct {
+		name, want string
+	}{
+		{"report.txt", "report.txt"},
+		{".env", ".env"},
+		{"...", "..."},
+		{"a b (1).txt", "a b (1).txt"},
+		{"data/2024/report.csv", "data/2024/report.csv"},
+		{"./data//report.csv/", "data/report.csv"},
+		{"..report", "..report"},
+		{"docs/.uploads", "docs/.uploads"},
+		{"docs/.blobs/x", "docs/.blobs/x"},
+		{"cafe\u0301.txt", "caf\u00e9.txt"},
+		{"日本語.md", "日本語.md"},
+		{strings.Repeat("a", MaxFileNameLength), strings.Repeat("a", MaxFileNameLength)},
+	}
+	for _, tt := range valid {
+		got, err := CleanFilePath(tt.name)
+		if err != nil {
+			t.Errorf("CleanFilePath(%q): expected no error, got %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("CleanFilePath(%q): expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+
+	invalid := []string{
+		"",
+		".",
+		"./",
+		"..",
+		"../other/x",
+		"../../other/x",
+		"data/../../x",
+		"data/..",
+		"/etc/passwd",
+		`..\..\other\x`,
+		`C:\fakepath\x.txt`,
+		"a\x00b",
+		"a\nb",
+		"a\x7fb",
+		"invoice\u202efdp.exe",
+		"\xff\xfe",
+		strings.Repeat("a", MaxFileNameLength+1),
+		strings.Repeat("a/", MaxFilePathLength/2+1),
+		".uploads",
+		"./.uploads/x",
+		".blobs/ab",
+	}
+	for _, name := range invalid {
+		_, err := CleanFilePath(name)
+		var customErr *errors.CustomError
+		if !stderrors.As(err, &customErr) || customErr.Message != errors.InvalidFilename.Message {
+			t.Errorf("CleanFilePath(%q): expected %v, got %v", name, errors.InvalidFilename, err)
+		}
+	}
+}
+
+func TestUploadPolicy(t *testing.T) {
+	policy := UploadPolicy{
+		MaxFileSize:  100,
+		AllowedTypes: []string{"text/*", " Image/PNG ", "application/pdf"},
+		DeniedTypes:  []string{"text/html"},
+	}
+
+	if !policy.ChecksTypes() || (UploadPolicy{MaxFileSize: 100}).ChecksTypes() {
+		t.Error("ChecksTypes should only be set with allowed or denied types")
+	}
+	if err := policy.CheckSize(100); err != nil {
+		t.Errorf("CheckSize(100): expected no error, got %v", err)
+	}
+	if err := policy.CheckSize(101); err != errors.FileTooLarge {
+		t.Errorf("CheckSize(101): expected %v, got %v", errors.FileTooLarge, err)
+	}
+
+	for _, contentType := range []string{"text/plain; charset=utf-8", "text/csv", "image/png", "application/pdf"} {
+		if err := policy.CheckContentType(contentType); err != nil {
+			t.Errorf("CheckContentType(%q): expected no error, got %v", contentType, err)
+		}
+	}
+	for _, contentType := range []string{"text/html; charset=utf-8", "image/jpeg", "application/zip", "textual/plain", "not a type"} {
+		err := policy.CheckContentType(contentType)
+		var customErr *errors.CustomError
+		if !stderrors.As(err, &customErr) || customErr.Message != errors.FileTypeNotAllowed.Message {
+			t.Errorf("CheckContentType(%q): expected %v, got %v", contentType, errors.FileTypeNotAllowed, err)
+		}
+	}
+}
+
+func TestUploadPolicy_ZeroValue(t *testing.T) {
+	if err := (UploadPolicy{}).CheckSize(1 << 40); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := (UploadPolicy{}).CheckContentType("application/x-msdownload"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func FuzzCleanFilePath(f *testing.F) {
+	for _, seed := range []string{"report.txt", "data/report.csv", "../x", "a/../../b", "./a//b/", `a\b`, "/etc/passwd", "a\x00", "cafe\u0301", "\u202e"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		cleaned, err := CleanFilePath(name)
+		if err != nil {
+			return
+		}
+		if cleaned == "" || !filepath.IsLocal(cleaned) || path.IsAbs(cleaned) {
+			t.Fatalf("CleanFilePath(%q) = %q is not a local path", name, cleaned)
+		}
+		if path.Clean(cleaned) != cleaned {
+			t.Fatalf("CleanFilePath(%q) = %q is not clean", name, cleaned)
+		}
+		if !utf8.ValidString(cleaned) || !norm.NFC.IsNormalString(cleaned) {
+			t.Fatalf("CleanFilePath(%q) = %q is not NFC", name, cleaned)
+		}
+		for _, element := range strings.Split(cleaned, "/") {
+			if element == "." || element == ".." || len(element) > MaxFileNameLength {
+				t.Fatalf("CleanFilePath(%q) = %q has element %q", name, cleaned, element)
+			}
+		}
+		if first := strings.Split(cleaned, "/")[0]; first == UploadsFolder || first == BlobsFolder {
+			t.Fatalf("CleanFilePath(%q) = %q is in a reserved folder", name, cleaned)
+		}
+		if strings.ContainsFunc(cleaned, func(r rune) bool { return r == '\\' || unicode.IsControl(r) || isBidiControl(r) }) {
+			t.Fatalf("CleanFilePath(%q) = %q contains a forbidden character", name, cleaned)
+		}
+		again, err := CleanFilePath(cleaned)
+		if err != nil || again != cleaned {
+			t.Fatalf("CleanFilePath is not idempotent for %q: %q, %v", cleaned, again, err)
+		}
+	})
+}
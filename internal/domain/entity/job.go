@@ -14,14 +14,36 @@ const (
 	JobStatusFailed    JobStatus = "failed"
 )
 
+const (
+	JobTypeContainerCreation = "container_creation"
+	JobTypeAccountDeletion   = "account_deletion"
+	JobTypeArchiveExtraction = "archive_extraction"
+)
+
+// JobProgress tells how far a running job got, for jobs that work through several items.
+type JobProgress struct {
+	// Step names the current stage of the job, e.g. "containers".
+	Step  string `json:"step"`
+	Done  int    `json:"done"`
+	Total int    `json:"total"`
+}
+
 type Job struct {
-	ID        string          `json:"id"`
-	Type      string          `json:"type"`
-	Status    JobStatus       `json:"status"`
-	Payload   json.RawMessage `json:"payload"`
-	Result    json.RawMessage `json:"result"`
-	Error     string          `json:"error,omitempty"`
-	UserID    int64           `json:"user_id"`
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Status  JobStatus       `json:"status"`
+	Payload json.RawMessage `json:"payload"`
+	Result  json.RawMessage `json:"result"`
+	Error   string          `json:"error,omitempty"`
+	// Progress is nil for jobs that do not report progress.
+	Progress *JobProgress `json:"progress,omitempty"`
+	UserID   int64        `json:"user_id"`
+	// TeamID is set for jobs acting on a container of a team.
+	TeamID    int64     `json:"team_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (j *Job) Owner() Owner {
+	return Owner{UserID: j.UserID, TeamID: j.TeamID}
 }
@@ -0,0 +1,57 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// LoginFailures counts the consecutive failed logins of an account or of a client IP.
+type LoginFailures struct {
+	Key          string
+	Failures     int
+	LastFailedAt time.Time
+}
+
+// AccountLoginKey and IPLoginKey return the keys failed logins are counted under.
+func AccountLoginKey(username string) string {
+	return "account:" + strings.ToLower(username)
+}
+
+func IPLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginThrottlePolicy slows down password guessing. After FreeFailures failed logins each further
+// failure doubles the wait for the next attempt, starting at BaseDelay and capped at MaxDelay.
+// LockoutFailures failed logins lock the key for Lockout. Failures are forgotten after ResetAfter
+// without a failure. The zero value never throttles.
+type LoginThrottlePolicy struct {
+	FreeFailures    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutFailures int
+	Lockout         time.Duration
+	ResetAfter      time.Duration
+}
+
+// RetryAt returns when the next login attempt is accepted. It is not after now if logins are not
+// throttled.
+func (p LoginThrottlePolicy) RetryAt(failures *LoginFailures, now time.Time) time.Time {
+	if failures == nil || failures.Failures == 0 || (p.ResetAfter > 0 && now.Sub(failures.LastFailedAt) >= p.ResetAfter) {
+		return now
+	}
+	if p.LockoutFailures > 0 && failures.Failures >= p.LockoutFailures {
+		return failures.LastFailedAt.Add(p.Lockout)
+	}
+	if failures.Failures < p.FreeFailures || p.BaseDelay <= 0 {
+		return now
+	}
+	delay := p.BaseDelay
+	for i := p.FreeFailures; i < failures.Failures && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return failures.LastFailedAt.Add(delay)
+}
@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginThrottlePolicy_RetryAt(t *testing.T) {
+	policy := LoginThrottlePolicy{
+		FreeFailures:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutFailures: 10,
+		Lockout:         15 * time.Minute,
+		ResetAfter:      time.Hour,
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		failures *LoginFailures
+		want     time.Time
+	}{
+		{"no failures", nil, now},
+		{"failures taken back", &LoginFailures{Failures: 0, LastFailedAt: now}, now},
+		{"free failures", &LoginFailures{Failures: 2, LastFailedAt: now}, now},
+		{"first delay", &LoginFailures{Failures: 3, LastFailedAt: now}, now.Add(time.Second)},
+		{"doubling delay", &LoginFailures{Failures: 5, LastFailedAt: now}, now.Add(4 * time.Second)},
+		{"capped delay", &LoginFailures{Failures: 9, LastFailedAt: now}, now.Add(time.Minute)},
+		{"lockout", &LoginFailures{Failures: 10, LastFailedAt: now}, now.Add(15 * time.Minute)},
+		{"failures forgotten", &LoginFailures{Failures: 10, LastFailedAt: now.Add(-time.Hour)}, now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.RetryAt(tt.failures, now); !got.Equal(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if got := (LoginThrottlePolicy{BaseDelay: time.Second}).RetryAt(&LoginFailures{LastFailedAt: now}, now); !got.Equal(now) {
+		t.Errorf("expected no delay without failures, got %v", got)
+	}
+	if got := (LoginThrottlePolicy{}).RetryAt(&LoginFailures{Failures: 100, LastFailedAt: now}, now); !got.Equal(now) {
+		t.Errorf("expected the zero policy not to throttle, got %v", got)
+	}
+}
+
+func TestLoginKeys(t *testing.T) {
+	if got := AccountLoginKey("Alice"); got != "account:alice" {
+		t.Errorf("expected account:alice, got %s", got)
+	}
+	if got := IPLoginKey("10.0.0.1"); got != "ip:10.0.0.1" {
+		t.Errorf("expected ip:10.0.0.1, got %s", got)
+	}
+}
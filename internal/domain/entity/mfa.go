@@ -0,0 +1,153 @@
+package entity
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// TOTPPeriod, TOTPDigits and the SHA-1 algorithm are the defaults of RFC 6238, which every
+	// authenticator app supports.
+	TOTPPeriod = 30 * time.Second
+	TOTPDigits = 6
+	// totpSkew is the number of periods a code may lag behind or run ahead, for clock drift.
+	totpSkew = 1
+
+	RecoveryCodeCount = 10
+
+	// MaxMFAFailures consecutive wrong codes lock TOTP verification for MFALockout.
+	// Recovery codes keep working while locked.
+	MaxMFAFailures = 5
+	MFALockout     = 15 * time.Minute
+)
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// UserMFA is the TOTP second factor of a user. It is pending until the user proves
+// the authenticator was set up by entering a first code.
+type UserMFA struct {
+	UserID int64
+	// Secret is the base32 encoded TOTP key.
+	Secret    string
+	EnabledAt *time.Time
+	// LastUsedStep is the time step of the last accepted code, so that a code cannot be used twice.
+	LastUsedStep   int64
+	FailedAttempts int
+	LastFailedAt   *time.Time
+	CreatedAt      time.Time
+}
+
+// NewTOTPSecret returns a random 160 bit TOTP secret, base32 encoded.
+func NewTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32NoPadding.EncodeToString(b), nil
+}
+
+// TOTPStep returns the time step a TOTP code is computed for.
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod/time.Second)
+}
+
+// TOTPCode computes the code of a base32 encoded secret for a time step.
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := base32NoPadding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", TOTPDigits, value%1000000), nil
+}
+
+// OTPAuthURI returns the otpauth:// URI authenticator apps import, usually as a QR code.
+func OTPAuthURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(int(TOTPPeriod/time.Second)))
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}).String()
+}
+
+// Enabled reports whether the second factor has been confirmed.
+func (m *UserMFA) Enabled() bool {
+	return m.EnabledAt != nil
+}
+
+// Locked reports whether TOTP verification is refused after too many wrong codes.
+func (m *UserMFA) Locked(now time.Time) bool {
+	return m.FailedAttempts >= MaxMFAFailures && m.LastFailedAt != nil && now.Before(m.LastFailedAt.Add(MFALockout))
+}
+
+// MatchTOTP returns the time step the code is valid for, allowing for clock drift. Codes of
+// steps at or before LastUsedStep are rejected, so each code is accepted once.
+func (m *UserMFA) MatchTOTP(code string, now time.Time) (int64, bool) {
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+	current := TOTPStep(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= m.LastUsedStep {
+			continue
+		}
+		expected, err := TOTPCode(m.Secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// NewRecoveryCodes returns RecoveryCodeCount random single-use codes of the form xxxxx-xxxxx,
+// together with their hashes.
+func NewRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		value := strings.ToLower(base32NoPadding.EncodeToString(b))[:10]
+		codes[i] = value[:5] + "-" + value[5:]
+		hashes[i] = HashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode returns the hash under which a recovery code is stored. Case, dashes and
+// spaces are ignored, as users copy the codes by hand.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,130 @@
+package entity
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors, "12345678901234567890", base32 encoded.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// The RFC lists 8 digit codes, of which the last 6 are the 6 digit code.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("at %d: expected %s, got %s", tt.unix, tt.want, got)
+		}
+	}
+}
+
+func TestUserMFA_MatchTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	step := TOTPStep(now)
+	code := func(step int64) string {
+		c, _ := TOTPCode(rfc6238Secret, step)
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		mfa      UserMFA
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current step", mfa: UserMFA{Secret: rfc6238Secret}, code: code(step), wantStep: step, wantOK: true},
+		{name: "previous step", mfa: UserMFA{Secret: rfc6238Secret}, code: code(step - 1), wantStep: step - 1, wantOK: true},
+		{name: "next step", mfa: UserMFA{Secret: rfc6238Secret}, code: code(step + 1), wantStep: step + 1, wantOK: true},
+		{name: "too old", mfa: UserMFA{Secret: rfc6238Secret}, code: code(step - 2)},
+		{name: "already used", mfa: UserMFA{Secret: rfc6238Secret, LastUsedStep: step}, code: code(step)},
+		{name: "wrong length", mfa: UserMFA{Secret: rfc6238Secret}, code: code(step)[:5]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := tt.mfa.MatchTOTP(tt.code, now)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("expected (%d, %v), got (%d, %v)", tt.wantStep, tt.wantOK, gotStep, ok)
+			}
+		})
+	}
+}
+
+func TestUserMFA_Locked(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-time.Minute)
+	old := now.Add(-MFALockout - time.Minute)
+
+	tests := []struct {
+		name string
+		mfa  UserMFA
+		want bool
+	}{
+		{name: "no failures", mfa: UserMFA{}},
+		{name: "below limit", mfa: UserMFA{FailedAttempts: MaxMFAFailures - 1, LastFailedAt: &recent}},
+		{name: "locked", mfa: UserMFA{FailedAttempts: MaxMFAFailures, LastFailedAt: &recent}, want: true},
+		{name: "lockout over", mfa: UserMFA{FailedAttempts: MaxMFAFailures, LastFailedAt: &old}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mfa.Locked(now); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOTPAuthURI(t *testing.T) {
+	uri, err := url.Parse(OTPAuthURI("Container Manager", "alice", rfc6238Secret))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Container Manager:alice" {
+		t.Errorf("unexpected uri %s", uri)
+	}
+	if uri.Query().Get("secret") != rfc6238Secret || uri.Query().Get("issuer") != "Container Manager" {
+		t.Errorf("unexpected query %s", uri.RawQuery)
+	}
+}
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(codes) != RecoveryCodeCount || len(hashes) != RecoveryCodeCount {
+		t.Fatalf("expected %d codes, got %d", RecoveryCodeCount, len(codes))
+	}
+
+	seen := map[string]bool{}
+	for i, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("unexpected code format %q", code)
+		}
+		if hashes[i] != HashRecoveryCode(code) {
+			t.Errorf("expected hash of %q", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+	}
+
+	if HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[0], "-", " "))) != hashes[0] {
+		t.Error("expected the hash to ignore case and separators")
+	}
+}
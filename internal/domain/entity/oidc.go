@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+)
+
+// OIDCLoginState is kept between redirecting a user to an identity provider and the provider
+// redirecting back. State ties the callback to the login, the Nonce ties the ID token to it, and
+// the CodeVerifier proves to the provider that the code is redeemed by whoever started the login (PKCE).
+type OIDCLoginState struct {
+	State        string
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+}
+
+// UserIdentity links an account at an identity provider to a local user.
+type UserIdentity struct {
+	Provider  string
+	Subject   string
+	UserID    int64
+	CreatedAt time.Time
+}
+
+// NewOIDCLoginState starts a login at the given provider that must complete within ttl.
+func NewOIDCLoginState(provider string, ttl time.Duration) (*OIDCLoginState, error) {
+	values := make([]string, 3)
+	for i := range values {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return &OIDCLoginState{
+		State:        values[0],
+		Provider:     provider,
+		CodeVerifier: values[1],
+		Nonce:        values[2],
+		ExpiresAt:    time.Now().Add(ttl),
+	}, nil
+}
+
+// CodeChallenge returns the S256 PKCE challenge of the code verifier.
+func (s *OIDCLoginState) CodeChallenge() string {
+	sum := sha256.Sum256([]byte(s.CodeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
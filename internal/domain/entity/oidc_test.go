@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOIDCLoginState_CodeChallenge(t *testing.T) {
+	// Example from RFC 7636, appendix B.
+	state := &OIDCLoginState{CodeVerifier: "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"}
+	if got, want := state.CodeChallenge(), "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestNewOIDCLoginState(t *testing.T) {
+	state, err := NewOIDCLoginState("company", time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Provider != "company" || !state.ExpiresAt.After(time.Now()) {
+		t.Errorf("unexpected state %+v", state)
+	}
+	// The columns of oidc_login_states hold 43 characters.
+	for _, value := range []string{state.State, state.CodeVerifier, state.Nonce} {
+		if len(value) != 43 {
+			t.Errorf("expected 43 characters, got %q", value)
+		}
+	}
+	if state.State == state.CodeVerifier || state.State == state.Nonce {
+		t.Error("expected independent random values")
+	}
+}
@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"container-manager/internal/errors"
+	"strings"
+	"unicode"
+)
+
+// MaxPasswordBytes is the longest password bcrypt can hash.
+const MaxPasswordBytes = 72
+
+// PasswordPolicy decides which passwords users may choose. The zero value only rejects empty
+// passwords and passwords bcrypt cannot hash.
+type PasswordPolicy struct {
+	MinLength int
+	// MinCharacterClasses is how many of lowercase letters, uppercase letters, digits and other
+	// characters a password has to mix.
+	MinCharacterClasses int
+	// Breached holds known leaked passwords in lowercase. Passwords are compared case-insensitively.
+	Breached map[string]struct{}
+}
+
+// Validate checks a password a user chose.
+func (p PasswordPolicy) Validate(password string) error {
+	if password == "" {
+		return errors.EmptyPassword
+	}
+	if len(password) > MaxPasswordBytes {
+		return errors.PasswordTooLong
+	}
+	if len([]rune(password)) < p.MinLength {
+		return errors.PasswordTooShort
+	}
+	if characterClasses(password) < p.MinCharacterClasses {
+		return errors.PasswordTooSimple
+	}
+	if _, breached := p.Breached[strings.ToLower(password)]; breached {
+		return errors.PasswordBreached
+	}
+	return nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, other int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return lower + upper + digit + other
+}
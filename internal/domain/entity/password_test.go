@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"container-manager/internal/errors"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:           8,
+		MinCharacterClasses: 3,
+		Breached:            map[string]struct{}{"password1!": {}},
+	}
+
+	tests := []struct {
+		password string
+		want     error
+	}{
+		{"Tr0ub4dor", nil},
+		{"correct horse battery staple 7", nil},
+		{"", errors.EmptyPassword},
+		{"Ab1!", errors.PasswordTooShort},
+		{strings.Repeat("Ab1", 25), errors.PasswordTooLong},
+		{"alllowercase", errors.PasswordTooSimple},
+		{"lowercase123", errors.PasswordTooSimple},
+		{"Password1!", errors.PasswordBreached},
+	}
+	for _, tt := range tests {
+		if err := policy.Validate(tt.password); err != tt.want {
+			t.Errorf("%q: expected %v, got %v", tt.password, tt.want, err)
+		}
+	}
+}
+
+func TestPasswordPolicy_ZeroValue(t *testing.T) {
+	if err := (PasswordPolicy{}).Validate("a"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := (PasswordPolicy{}).Validate(strings.Repeat("a", MaxPasswordBytes+1)); err != errors.PasswordTooLong {
+		t.Errorf("expected %v, got %v", errors.PasswordTooLong, err)
+	}
+}
@@ -0,0 +1,40 @@
+package entity
+
+// QuotaResources holds an amount of each resource covered by quotas. It is used both
+// for the limits of a user, where zero means unlimited, and for the usage of a user.
+type QuotaResources struct {
+	Containers        int64
+	RunningContainers int64
+	MemoryBytes       int64
+	NanoCPUs          int64
+	StorageBytes      int64
+}
+
+// Quota is the limits of a user together with the resources currently reserved by the user.
+type Quota struct {
+	Limits QuotaResources
+	Usage  QuotaResources
+}
+
+// Exceeded returns the name of the first resource for which reserving delta on top of
+// usage would go over the limits, or an empty string if delta fits.
+// Resources not requested by delta are not checked, so that lowering a limit below the
+// current usage does not block unrelated operations.
+func (limits QuotaResources) Exceeded(usage QuotaResources, delta QuotaResources) string {
+	checks := []struct {
+		name                string
+		limit, used, amount int64
+	}{
+		{"containers", limits.Containers, usage.Containers, delta.Containers},
+		{"running_containers", limits.RunningContainers, usage.RunningContainers, delta.RunningContainers},
+		{"memory_bytes", limits.MemoryBytes, usage.MemoryBytes, delta.MemoryBytes},
+		{"nano_cpus", limits.NanoCPUs, usage.NanoCPUs, delta.NanoCPUs},
+		{"storage_bytes", limits.StorageBytes, usage.StorageBytes, delta.StorageBytes},
+	}
+	for _, check := range checks {
+		if check.limit > 0 && check.amount > 0 && check.used+check.amount > check.limit {
+			return check.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "testing"
+
+func TestQuotaResources_Exceeded(t *testing.T) {
+	limits := QuotaResources{Containers: 2, MemoryBytes: 1024}
+
+	tests := []struct {
+		name  string
+		usage QuotaResources
+		delta QuotaResources
+		want  string
+	}{
+		{name: "within limits", usage: QuotaResources{Containers: 1}, delta: QuotaResources{Containers: 1}},
+		{name: "containers exceeded", usage: QuotaResources{Containers: 2}, delta: QuotaResources{Containers: 1}, want: "containers"},
+		{name: "memory exceeded", usage: QuotaResources{MemoryBytes: 1000}, delta: QuotaResources{Containers: 1, MemoryBytes: 100}, want: "memory_bytes"},
+		{name: "unlimited resource", delta: QuotaResources{StorageBytes: 1 << 40}},
+		// Lowering a limit below the usage does not block requests for other resources.
+		{name: "resource not requested", usage: QuotaResources{Containers: 5}, delta: QuotaResources{RunningContainers: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := limits.Exceeded(tt.usage, tt.delta); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRefreshTokenValue(t *testing.T) {
+	token, hash, err := NewRefreshTokenValue()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token == "" || hash != HashRefreshToken(token) {
+		t.Errorf("expected hash of %q, got %q", token, hash)
+	}
+
+	other, _, _ := NewRefreshTokenValue()
+	if other == token {
+		t.Error("expected different tokens")
+	}
+}
+
+func TestRefreshToken_Usable(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		token RefreshToken
+		want  bool
+	}{
+		{name: "usable", token: RefreshToken{ExpiresAt: now.Add(time.Hour)}, want: true},
+		{name: "expired", token: RefreshToken{ExpiresAt: now.Add(-time.Second)}},
+		{name: "rotated", token: RefreshToken{ExpiresAt: now.Add(time.Hour), RotatedAt: &now}},
+		{name: "revoked", token: RefreshToken{ExpiresAt: now.Add(time.Hour), RevokedAt: &now}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.token.Usable(now); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package entity
+
+import "slices"
+
+// Role decides what a user may do. Members and read-only users act on their own resources,
+// admins on the resources of every user.
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleMember   Role = "member"
+	RoleReadOnly Role = "read_only"
+)
+
+var Roles = []Role{RoleAdmin, RoleMember, RoleReadOnly}
+
+// Action is what a user does with a resource.
+type Action string
+
+const (
+	// ActionRead lists and views resources.
+	ActionRead Action = "read"
+	// ActionWrite creates, changes, starts, stops and removes resources.
+	ActionWrite Action = "write"
+)
+
+func ValidRole(role string) bool {
+	return slices.Contains(Roles, Role(role))
+}
+
+// Allows reports whether the role permits the action at all.
+func (r Role) Allows(action Action) bool {
+	switch r {
+	case RoleAdmin, RoleMember:
+		return action == ActionRead || action == ActionWrite
+	case RoleReadOnly:
+		return action == ActionRead
+	}
+	return false
+}
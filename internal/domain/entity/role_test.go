@@ -0,0 +1,38 @@
+package entity
+
+import "testing"
+
+func TestRole_Allows(t *testing.T) {
+	tests := []struct {
+		role   Role
+		action Action
+		want   bool
+	}{
+		{role: RoleAdmin, action: ActionRead, want: true},
+		{role: RoleAdmin, action: ActionWrite, want: true},
+		{role: RoleMember, action: ActionRead, want: true},
+		{role: RoleMember, action: ActionWrite, want: true},
+		{role: RoleReadOnly, action: ActionRead, want: true},
+		{role: RoleReadOnly, action: ActionWrite},
+		{role: "", action: ActionRead},
+		{role: "owner", action: ActionRead},
+	}
+	for _, tt := range tests {
+		if got := tt.role.Allows(tt.action); got != tt.want {
+			t.Errorf("%q %s: expected %v, got %v", tt.role, tt.action, tt.want, got)
+		}
+	}
+}
+
+func TestValidRole(t *testing.T) {
+	for _, role := range []string{"admin", "member", "read_only"} {
+		if !ValidRole(role) {
+			t.Errorf("expected %q to be valid", role)
+		}
+	}
+	for _, role := range []string{"", "Admin", "owner"} {
+		if ValidRole(role) {
+			t.Errorf("expected %q to be invalid", role)
+		}
+	}
+}
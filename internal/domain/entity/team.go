@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"container-manager/internal/errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxTeamNameLength is the maximum length of a team name, in characters.
+const MaxTeamNameLength = 63
+
+// TeamRole decides what a member may do with the containers, jobs and files of a team.
+// Only owners manage the members of the team.
+type TeamRole string
+
+const (
+	TeamRoleOwner  TeamRole = "owner"
+	TeamRoleMember TeamRole = "member"
+	TeamRoleViewer TeamRole = "viewer"
+)
+
+var TeamRoles = []TeamRole{TeamRoleOwner, TeamRoleMember, TeamRoleViewer}
+
+func ValidTeamRole(role string) bool {
+	return slices.Contains(TeamRoles, TeamRole(role))
+}
+
+// Allows reports whether the team role permits the action on the resources of the team.
+func (r TeamRole) Allows(action Action) bool {
+	switch r {
+	case TeamRoleOwner, TeamRoleMember:
+		return action == ActionRead || action == ActionWrite
+	case TeamRoleViewer:
+		return action == ActionRead
+	}
+	return false
+}
+
+type Team struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+}
+
+// TeamMember is the membership of a user in a team.
+type TeamMember struct {
+	TeamID   int64
+	UserID   int64
+	Username string
+	Role     TeamRole
+	// CreatedAt is when the user joined the team.
+	CreatedAt time.Time
+}
+
+// TeamMembership is a team as seen by one of its members.
+type TeamMembership struct {
+	Team *Team
+	Role TeamRole
+}
+
+// Owner is who a container, job or file belongs to. Resources owned by a team have TeamID set,
+// UserID is then the member who created them and whose quota they count against.
+type Owner struct {
+	UserID int64
+	TeamID int64
+}
+
+// ValidateTeamName checks a user supplied team name.
+func ValidateTeamName(name string) error {
+	if strings.TrimSpace(name) == "" || utf8.RuneCountInString(name) > MaxTeamNameLength {
+		return errors.InvalidTeamName
+	}
+	return nil
+}
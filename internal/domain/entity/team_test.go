@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTeamRole_Allows(t *testing.T) {
+	tests := []struct {
+		role   TeamRole
+		action Action
+		want   bool
+	}{
+		{role: TeamRoleOwner, action: ActionRead, want: true},
+		{role: TeamRoleOwner, action: ActionWrite, want: true},
+		{role: TeamRoleMember, action: ActionRead, want: true},
+		{role: TeamRoleMember, action: ActionWrite, want: true},
+		{role: TeamRoleViewer, action: ActionRead, want: true},
+		{role: TeamRoleViewer, action: ActionWrite},
+		{role: "admin", action: ActionRead},
+	}
+	for _, tt := range tests {
+		if got := tt.role.Allows(tt.action); got != tt.want {
+			t.Errorf("%q %s: expected %v, got %v", tt.role, tt.action, tt.want, got)
+		}
+	}
+}
+
+func TestValidateTeamName(t *testing.T) {
+	for _, name := range []string{"platform", "Data Science", strings.Repeat("團", MaxTeamNameLength)} {
+		if err := ValidateTeamName(name); err != nil {
+			t.Errorf("expected %q to be valid, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "   ", strings.Repeat("a", MaxTeamNameLength+1)} {
+		if err := ValidateTeamName(name); err == nil {
+			t.Errorf("expected %q to be invalid", name)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"time"
+)
+
+// Upload is a file uploaded in chunks with the tus protocol. The chunks received so far are kept
+// apart from the owner's files until the upload is complete, and then the file is committed to
+// Filename at once.
+type Upload struct {
+	ID string
+	// UserID is the user who started the upload, and whose storage quota the file counts against.
+	UserID int64
+	// TeamID is set if the file is uploaded to the files of a team.
+	TeamID   int64
+	Filename string
+	// Length is the size of the complete file, and Offset how much of it has been received.
+	Length int64
+	Offset int64
+	// Metadata is the Upload-Metadata header the upload was created with, returned as is.
+	Metadata  string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// NewUpload starts an upload of a file of the given length, which expires if it is not completed
+// within ttl.
+func NewUpload(owner Owner, filename string, length int64, metadata string, ttl time.Duration) (*Upload, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &Upload{
+		ID:        hex.EncodeToString(b),
+		UserID:    owner.UserID,
+		TeamID:    owner.TeamID,
+		Filename:  filename,
+		Length:    length,
+		Metadata:  metadata,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}, nil
+}
+
+// Owner is the owner of the file being uploaded.
+func (u *Upload) Owner() Owner {
+	return Owner{UserID: u.UserID, TeamID: u.TeamID}
+}
+
+// IsUploadID reports whether id has the form of the IDs NewUpload generates, so that it can be
+// used as a file name.
+func IsUploadID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// UploadChecksumAlgorithms are the algorithms chunks of an upload can be verified with, by the
+// names of the tus checksum extension.
+var UploadChecksumAlgorithms = []string{"md5", "sha1", "sha256"}
+
+// NewUploadHash returns a hash of one of the UploadChecksumAlgorithms, or nil for any other name.
+func NewUploadHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case "md5":
+		return md5.New()
+	case "sha1":
+		return sha1.New()
+	case "sha256":
+		return sha256.New()
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package entity
 
 import (
 	"container-manager/internal/errors"
+	"regexp"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -10,24 +12,57 @@ type User struct {
 	ID       int64
 	Username string
 	Password string
+	Role     Role
+	// SessionsValidAfter invalidates the access tokens issued before it, e.g. when the password
+	// changed. It is zero if no session was ever revoked this way.
+	SessionsValidAfter time.Time
+	CreatedAt          time.Time
+}
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{2,31}$`)
+
+// ValidateUsername checks the name a user registers with. Users of identity providers keep the
+// name the provider returned.
+func ValidateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return errors.InvalidUsername
+	}
+	return nil
 }
 
 func NewUser(id int64, username, plainPassword string) (*User, error) {
-	if plainPassword == "" {
-		return nil, errors.EmptyPassword
+	user := &User{
+		ID:       id,
+		Username: username,
+		Role:     RoleMember,
+	}
+	if err := user.SetPassword(plainPassword); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetPassword replaces the password hash of the user. It only rejects passwords bcrypt cannot
+// hash, the PasswordPolicy is applied to passwords users choose.
+func (u *User) SetPassword(plainPassword string) error {
+	if err := (PasswordPolicy{}).Validate(plainPassword); err != nil {
+		return err
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(plainPassword), bcrypt.DefaultCost)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return &User{
-		ID:       id,
-		Username: username,
-		Password: string(hashedPassword),
-	}, nil
+	u.Password = string(hashedPassword)
+	return nil
 }
 
 func (u *User) ValidatePassword(password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 }
+
+// SessionValid reports whether an access token issued at issuedAt is still valid. Token times have
+// a resolution of one second, so tokens issued in the second sessions were revoked stay valid.
+func (u *User) SessionValid(issuedAt time.Time) bool {
+	return !issuedAt.Before(u.SessionsValidAfter.Truncate(time.Second))
+}
@@ -2,7 +2,9 @@ package entity
 
 import (
 	"container-manager/internal/errors"
+	"strings"
 	"testing"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -26,6 +28,9 @@ func TestNewUser(t *testing.T) {
 		if user.Username != username {
 			t.Errorf("expected username %s, got %s", username, user.Username)
 		}
+		if user.Role != RoleMember {
+			t.Errorf("expected role %s, got %s", RoleMember, user.Role)
+		}
 
 		// Verify password is hashed and not plain
 		if user.Password == plainPassword {
@@ -94,3 +99,43 @@ func TestUser_ValidatePassword(t *testing.T) {
 		}
 	})
 }
+
+func TestUser_SessionValid(t *testing.T) {
+	changedAt := time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)
+
+	tests := []struct {
+		name     string
+		user     User
+		issuedAt time.Time
+		want     bool
+	}{
+		{"never revoked", User{}, time.Time{}, true},
+		{"issued before", User{SessionsValidAfter: changedAt}, changedAt.Add(-time.Second), false},
+		{"issued in the same second", User{SessionsValidAfter: changedAt}, changedAt.Truncate(time.Second), true},
+		{"issued after", User{SessionsValidAfter: changedAt}, changedAt.Add(time.Minute), true},
+		{"token without iat", User{SessionsValidAfter: changedAt}, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.user.SessionValid(tt.issuedAt); got != tt.want {
+				t.Errorf("SessionValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateUsername(t *testing.T) {
+	valid := []string{"bob", "alice.smith", "user_1", "Team-Lead", strings.Repeat("a", 32)}
+	for _, username := range valid {
+		if err := ValidateUsername(username); err != nil {
+			t.Errorf("expected %q to be valid, got %v", username, err)
+		}
+	}
+
+	invalid := []string{"", "ab", ".alice", "alice smith", "alice@example.com", strings.Repeat("a", 33)}
+	for _, username := range invalid {
+		if err := ValidateUsername(username); err != errors.InvalidUsername {
+			t.Errorf("expected %q to be invalid, got %v", username, err)
+		}
+	}
+}
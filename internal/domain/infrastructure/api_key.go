@@ -0,0 +1,18 @@
+package infrastructure
+
+import (
+	"container-manager/internal/domain/entity"
+	"context"
+	"time"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *entity.APIKey) error
+	// ListByUserID returns the keys of the user that have not been revoked, newest first.
+	ListByUserID(ctx context.Context, userID int64) ([]*entity.APIKey, error)
+	// GetByHash returns the key with the given hash, or nil if there is none.
+	GetByHash(ctx context.Context, keyHash string) (*entity.APIKey, error)
+	// Revoke revokes a key of the user, and reports whether there was such a key left to revoke.
+	Revoke(ctx context.Context, userID int64, id string) (bool, error)
+	UpdateLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error
+}
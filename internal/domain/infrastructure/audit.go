@@ -0,0 +1,30 @@
+package infrastructure
+
+import (
+	"container-manager/internal/domain/entity"
+	"context"
+	"time"
+)
+
+// AuditFilter selects audit entries. Zero fields do not filter.
+type AuditFilter struct {
+	ActorID    int64
+	Action     string
+	TargetType string
+	TargetID   string
+	Outcome    entity.AuditOutcome
+	RequestID  string
+	// Since and Until bound the time of the entries, Since inclusive and Until exclusive.
+	Since time.Time
+	Until time.Time
+	// BeforeID only selects entries older than the entry with this ID, to page through the log.
+	BeforeID int64
+}
+
+// AuditRepository stores the audit log, which can only be appended to.
+type AuditRepository interface {
+	// Append stores the entry and sets its ID and, if zero, its CreatedAt.
+	Append(ctx context.Context, entry *entity.AuditEntry) error
+	// List returns up to limit entries matching the filter, newest first.
+	List(ctx context.Context, filter AuditFilter, limit int) ([]*entity.AuditEntry, error)
+}
@@ -3,12 +3,41 @@ package infrastructure
 import (
 	"container-manager/internal/domain/entity"
 	"context"
+	"io"
+
+	"github.com/moby/moby/api/types/container"
 )
 
 type ContainerCreateOptions struct {
-	Cmd   []string
-	Env   []string
-	Image string
+	Cmd    []string
+	Env    []string
+	Image  string
+	Labels map[string]string
+	// Name is the name the user gives to the container. It is only unique per user,
+	// so it is recorded by the service rather than passed to the runtime.
+	Name string
+	// TeamID is the team the container is created for, or zero for a personal container.
+	// Like Name, it is recorded by the service.
+	TeamID int64
+	// MemoryBytes and NanoCPUs limit the resources of the container. Zero means unlimited.
+	MemoryBytes int64
+	NanoCPUs    int64
+}
+
+// ContainerLogsOptions selects the output of a container to return.
+type ContainerLogsOptions struct {
+	// Tail is the number of lines to return from the end of the logs. Zero returns all lines.
+	Tail int
+	// Timestamps prefixes each line with its RFC 3339 timestamp.
+	Timestamps bool
+}
+
+// ContainerFilter narrows down a container listing. Empty fields are ignored.
+type ContainerFilter struct {
+	IDs    []string
+	Labels map[string]string
+	Status string
+	Image  string
 }
 
 type ContainerRuntime interface {
@@ -17,4 +46,16 @@ type ContainerRuntime interface {
 	Stop(ctx context.Context, id string) error
 	Remove(ctx context.Context, id string) error
 	Inspect(ctx context.Context, id string) (*entity.Container, error)
+	// Logs returns the standard output and standard error of a container, interleaved.
+	Logs(ctx context.Context, id string, options ContainerLogsOptions) ([]byte, error)
+	// ListIDs returns the IDs of the containers matching the filter in a single runtime call.
+	ListIDs(ctx context.Context, filter ContainerFilter) ([]string, error)
+	// ListStatuses returns the status of the containers matching the filter by ID, in a single
+	// runtime call.
+	ListStatuses(ctx context.Context, filter ContainerFilter) (map[string]container.ContainerState, error)
+	// CopyTo extracts a tar archive into a folder of a container, which has to exist. Existing
+	// files are overwritten, but a folder is never replaced by a file or the other way around.
+	CopyTo(ctx context.Context, id string, dir string, archive io.Reader) error
+	// CopyFrom returns a tar archive of a file or folder of a container. The caller has to close it.
+	CopyFrom(ctx context.Context, id string, path string) (io.ReadCloser, error)
 }
@@ -1,12 +1,47 @@
 package infrastructure
 
 import (
+	"container-manager/internal/domain/entity"
 	"context"
 )
 
+// Sort keys of a container listing. Sorting by status is done by the service, since
+// the status is only known by the container runtime.
+const (
+	ContainerSortCreatedAt = "created_at"
+	ContainerSortName      = "name"
+	ContainerSortStatus    = "status"
+)
+
+// ContainerUserPage selects one page of the containers of an owner, using keyset pagination.
+type ContainerUserPage struct {
+	// IDs restricts the page to the given containers when not nil.
+	IDs []string
+	// Sort is either ContainerSortCreatedAt or ContainerSortName.
+	Sort       string
+	Descending bool
+	Limit      int
+	// After is the last row of the previous page, or nil for the first page.
+	After *entity.ContainerUser
+}
+
 type ContainerUserRepository interface {
-	Create(ctx context.Context, containerID string, userID int64) error
+	Create(ctx context.Context, containerUser *entity.ContainerUser) error
 	Delete(ctx context.Context, containerID string) error
 	GetUserIDByContainerID(ctx context.Context, containerID string) (int64, error)
-	GetContainerIDsByUserID(ctx context.Context, userID int64) ([]string, error)
+	// The ByOwner methods select the containers of a team when owner.TeamID is set, and the
+	// personal containers of owner.UserID otherwise.
+	GetContainerIDsByOwner(ctx context.Context, owner entity.Owner) ([]string, error)
+	// GetPageByOwner is the paginated counterpart of GetContainerIDsByOwner.
+	GetPageByOwner(ctx context.Context, owner entity.Owner, page ContainerUserPage) ([]*entity.ContainerUser, error)
+	// CountByOwner counts the owner's containers, restricted to ids when not nil.
+	CountByOwner(ctx context.Context, owner entity.Owner, ids []string) (int, error)
+	GetByOwner(ctx context.Context, owner entity.Owner) ([]*entity.ContainerUser, error)
+	// FindByIDOrName looks up a container by its ID, or by a name given to it by the user.
+	FindByIDOrName(ctx context.Context, userID int64, idOrName string) (*entity.ContainerUser, error)
+	UpdateName(ctx context.Context, containerID string, name string) error
+	// SetRunning records whether the container is running, and reports whether the record changed.
+	SetRunning(ctx context.Context, containerID string, running bool) (bool, error)
+	// GetRunningByUserID returns the containers created by the user that are recorded as running.
+	GetRunningByUserID(ctx context.Context, userID int64) ([]*entity.ContainerUser, error)
 }
@@ -0,0 +1,19 @@
+package infrastructure
+
+import (
+	"container-manager/internal/domain/entity"
+	"context"
+)
+
+// FileRepository records the checksum, size and content type of the files in the FileStorage by
+// their path.
+type FileRepository interface {
+	// Save records a file, replacing the record of a file at the same path.
+	Save(ctx context.Context, file *entity.FileRecord) error
+	// Delete removes the record of a file of the owner, or the records of the files in a folder,
+	// and returns the removed records.
+	Delete(ctx context.Context, owner entity.Owner, path string) ([]*entity.FileRecord, error)
+	// Move changes the path of a file of the owner, or of the files in a folder, replacing any
+	// records at the new path.
+	Move(ctx context.Context, owner entity.Owner, from, to string) error
+}
@@ -0,0 +1,26 @@
+package infrastructure
+
+import (
+	"container-manager/internal/domain/entity"
+	"context"
+	"time"
+)
+
+type FileShareRepository interface {
+	Create(ctx context.Context, share *entity.FileShare) error
+	// GetByID returns the share, or nil if there is none.
+	GetByID(ctx context.Context, id string) (*entity.FileShare, error)
+	// ListByUserID returns the shares the user created that are neither revoked nor expired at
+	// now, newest first.
+	ListByUserID(ctx context.Context, userID int64, now time.Time) ([]*entity.FileShare, error)
+	// ListByTeamID returns the shares of files of the team that are neither revoked nor expired at
+	// now, newest first.
+	ListByTeamID(ctx context.Context, teamID int64, now time.Time) ([]*entity.FileShare, error)
+	// Revoke revokes a share, and reports whether there was such a share left to revoke.
+	Revoke(ctx context.Context, id string) (bool, error)
+	// RevokeByMember revokes the shares of files of the team that the user created.
+	RevokeByMember(ctx context.Context, teamID, userID int64) error
+	// CountDownload counts a download with a share that is usable at now, and reports whether it
+	// was, so that concurrent downloads cannot exceed the maximum.
+	CountDownload(ctx context.Context, id string, now time.Time) (bool, error)
+}
@@ -1,9 +1,57 @@
 package infrastructure
 
 import (
+	"container-manager/internal/domain/entity"
 	"io"
 )
 
+// FileStorage stores the files of each owner separately: the personal files of a user, or the
+// files of a team when owner.TeamID is set. Files can be kept in folders, and every path is
+// relative to the owner's folder with "/" as the separator. Paths that would leave the owner's
+// folder are rejected.
+//
+// Files appear at their path at once, complete, and the storage may keep identical content of an
+// owner only once. Files uploaded in chunks are written to an incomplete upload of the owner, kept
+// in the reserved entity.UploadsFolder, and committed to their path once complete.
 type FileStorage interface {
-	SaveFile(userID int64, filename string, fileContent io.Reader) (string, error)
+	// SaveFile writes a file of the owner, creating the folders of its path as needed, and
+	// describes it along with its checksum. A file at the path is only replaced once all of the
+	// content was written.
+	SaveFile(owner entity.Owner, filename string, fileContent io.Reader) (*entity.FileInfo, error)
+	// FileSize returns the size of a file of the owner, or zero if the file does not exist.
+	FileSize(owner entity.Owner, filename string) (int64, error)
+	// ListFiles returns the files and folders in a folder of the owner sorted by name, or in the
+	// owner's folder if dir is empty. With recursive set, the content of each folder follows the
+	// folder. It returns nil if the folder does not exist.
+	ListFiles(owner entity.Owner, dir string, recursive bool) ([]*entity.FileInfo, error)
+	// StatFile describes a file of the owner, or returns nil if the file does not exist.
+	StatFile(owner entity.Owner, filename string) (*entity.FileInfo, error)
+	// OpenFile opens a file of the owner for reading, or returns nil if the file does not exist.
+	OpenFile(owner entity.Owner, filename string) (io.ReadSeekCloser, *entity.FileInfo, error)
+	// DeleteFile removes a file of the owner. It succeeds if the file does not exist.
+	DeleteFile(owner entity.Owner, filename string) error
+	// MakeDir creates a folder of the owner along with its parents. It succeeds if the folder
+	// already exists.
+	MakeDir(owner entity.Owner, dir string) error
+	// Move renames a file or folder of the owner, creating the folders of the new path as
+	// needed. Nothing is replaced: it fails with errors.FileExists if the new path is taken.
+	Move(owner entity.Owner, from, to string) error
+	// DeleteDir removes a folder of the owner, which has to be empty unless recursive is set.
+	// It succeeds if the folder does not exist.
+	DeleteDir(owner entity.Owner, dir string, recursive bool) error
+	// RemoveAll removes every file of the owner. It succeeds if the owner has no files.
+	RemoveAll(owner entity.Owner) error
+	// WriteUpload writes content at offset of the incomplete upload id of the owner, creating the
+	// upload at offset zero. Whatever was written past offset before is dropped. It returns how
+	// many bytes were written, also when reading content fails partway.
+	WriteUpload(owner entity.Owner, id string, offset int64, content io.Reader) (int64, error)
+	// CommitUpload moves the incomplete upload id to a file of the owner at once, creating the
+	// folders of its path. Like SaveFile, only a regular file is replaced, and the file is
+	// described along with its checksum.
+	CommitUpload(owner entity.Owner, id, filename string) (*entity.FileInfo, error)
+	// DeleteUpload removes the incomplete upload id of the owner. It succeeds if there is none.
+	DeleteUpload(owner entity.Owner, id string) error
+	// CollectGarbage removes stored content that no file refers to anymore, and what interrupted
+	// writes left behind.
+	CollectGarbage() error
 }
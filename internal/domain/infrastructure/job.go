@@ -9,5 +9,10 @@ import (
 type JobRepository interface {
 	Create(ctx context.Context, job *entity.Job) error
 	GetByID(ctx context.Context, id string) (*entity.Job, error)
+	// ListByUserID returns the most recent jobs of a user, newest first.
+	ListByUserID(ctx context.Context, userID int64, limit int) ([]*entity.Job, error)
 	Update(ctx context.Context, job *entity.Job) error
+	// DeleteByUserID deletes the jobs of a user except the one with exceptID, and returns how many
+	// were deleted.
+	DeleteByUserID(ctx context.Context, userID int64, exceptID string) (int64, error)
 }
@@ -0,0 +1,25 @@
+package infrastructure
+
+import "github.com/golang-jwt/jwt/v5"
+
+// JWK is the public part of a signing key, as published in the JSON Web Key Set.
+type JWK struct {
+	KeyType   string
+	KeyID     string
+	Algorithm string
+	// Curve and X are set for Ed25519 keys, N and E for RSA keys. All are base64url encoded.
+	Curve string
+	X     string
+	N     string
+	E     string
+}
+
+// KeyManager signs and verifies access tokens. Several keys can verify tokens at the same time,
+// so that the signing key can be rotated without invalidating the tokens issued before.
+type KeyManager interface {
+	Sign(claims jwt.MapClaims) (string, error)
+	// Parse verifies the signature and expiry of a token and returns its claims.
+	Parse(token string) (jwt.MapClaims, error)
+	// PublicKeys returns the asymmetric keys that tokens are verified with.
+	PublicKeys() []JWK
+}
@@ -0,0 +1,22 @@
+package infrastructure
+
+import (
+	"container-manager/internal/domain/entity"
+	"context"
+	"time"
+)
+
+// LoginFailureRepository counts failed logins per key, see entity.AccountLoginKey and entity.IPLoginKey.
+type LoginFailureRepository interface {
+	// Get returns the failed logins of a key, or nil if there are none.
+	Get(ctx context.Context, key string) (*entity.LoginFailures, error)
+	// Reserve counts a login attempt as failed before its password is checked. It only counts if the
+	// failures of the key are still those seen, nil if there were none, and returns false otherwise,
+	// so that concurrent attempts cannot all pass the same check. Failures before resetBefore are
+	// forgotten first.
+	Reserve(ctx context.Context, key string, seen *entity.LoginFailures, resetBefore time.Time) (bool, error)
+	// Forgive takes back one failed login, reserved for an attempt that succeeded.
+	Forgive(ctx context.Context, key string) error
+	// Reset forgets the failed logins of a key.
+	Reset(ctx context.Context, key string) error
+}
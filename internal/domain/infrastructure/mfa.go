@@ -0,0 +1,26 @@
+package infrastructure
+
+import (
+	"container-manager/internal/domain/entity"
+	"context"
+)
+
+type MFARepository interface {
+	// Get returns the second factor of a user, pending or enabled, or nil if there is none.
+	Get(ctx context.Context, userID int64) (*entity.UserMFA, error)
+	// SavePending stores a new secret for a user whose second factor is not enabled yet,
+	// replacing an earlier pending one.
+	SavePending(ctx context.Context, userID int64, secret string) error
+	// Enable confirms the pending second factor, accepting the code of the given step, and replaces
+	// the recovery codes of the user. It returns false if there is no pending second factor.
+	Enable(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) (bool, error)
+	// UseStep records an accepted code and resets the failed attempts. It returns false if a code of
+	// the same or a later step was accepted already.
+	UseStep(ctx context.Context, userID int64, step int64) (bool, error)
+	// UseRecoveryCode marks an unused recovery code as used and resets the failed attempts.
+	// It returns false if the code does not exist or was used.
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+	RecordFailure(ctx context.Context, userID int64) error
+	// Delete removes the second factor and the recovery codes of a user.
+	Delete(ctx context.Context, userID int64) error
+}
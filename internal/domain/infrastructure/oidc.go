@@ -0,0 +1,27 @@
+package infrastructure
+
+import (
+	"container-manager/internal/domain/entity"
+	"context"
+)
+
+// OIDCProvider is an OpenID Connect identity provider using the authorization code flow with PKCE.
+type OIDCProvider interface {
+	// AuthCodeURL returns the URL the user is redirected to for signing in.
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	// Exchange redeems an authorization code and returns the claims of the verified ID token.
+	// The token must carry the given nonce.
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (map[string]any, error)
+}
+
+type OIDCLoginStateRepository interface {
+	Create(ctx context.Context, state *entity.OIDCLoginState) error
+	// Take returns the login state and deletes it, so that each state is used once. It returns nil if there is none.
+	Take(ctx context.Context, state string) (*entity.OIDCLoginState, error)
+}
+
+type UserIdentityRepository interface {
+	// Find returns the identity of the provider account, or nil if it is not linked to a user.
+	Find(ctx context.Context, provider, subject string) (*entity.UserIdentity, error)
+	Create(ctx context.Context, identity *entity.UserIdentity) error
+}
@@ -0,0 +1,17 @@
+package infrastructure
+
+import (
+	"container-manager/internal/domain/entity"
+	"context"
+)
+
+type QuotaRepository interface {
+	// GetLimits returns the custom limits of the user, or nil if the user has none.
+	GetLimits(ctx context.Context, userID int64) (*entity.QuotaResources, error)
+	GetUsage(ctx context.Context, userID int64) (*entity.QuotaResources, error)
+	// Reserve atomically adds delta to the usage of the user, unless that would go over limits,
+	// in which case it returns errors.QuotaExceeded and leaves the usage untouched.
+	Reserve(ctx context.Context, userID int64, delta entity.QuotaResources, limits entity.QuotaResources) error
+	// Release subtracts delta from the usage of the user.
+	Release(ctx context.Context, userID int64, delta entity.QuotaResources) error
+}
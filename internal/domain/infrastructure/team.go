@@ -0,0 +1,24 @@
+package infrastructure
+
+import (
+	"container-manager/internal/domain/entity"
+	"context"
+)
+
+type TeamRepository interface {
+	// Create stores a new team together with its first owner.
+	Create(ctx context.Context, team *entity.Team, owner *entity.TeamMember) error
+	// Get returns a team, or nil if it does not exist.
+	Get(ctx context.Context, id int64) (*entity.Team, error)
+	// ListByUserID returns the teams a user is a member of, with the role of the user.
+	ListByUserID(ctx context.Context, userID int64) ([]*entity.TeamMembership, error)
+	// GetMember returns the membership of a user in a team, or nil if the user is not a member.
+	GetMember(ctx context.Context, teamID int64, userID int64) (*entity.TeamMember, error)
+	ListMembers(ctx context.Context, teamID int64) ([]*entity.TeamMember, error)
+	// SetMember adds a member or changes the role of a member. Demoting the last owner fails
+	// with errors.LastTeamOwner.
+	SetMember(ctx context.Context, member *entity.TeamMember) error
+	// RemoveMember removes a member and reports whether the user was a member. Removing the last
+	// owner fails with errors.LastTeamOwner.
+	RemoveMember(ctx context.Context, teamID int64, userID int64) (bool, error)
+}
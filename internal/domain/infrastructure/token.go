@@ -0,0 +1,27 @@
+package infrastructure
+
+import (
+	"container-manager/internal/domain/entity"
+	"context"
+	"time"
+)
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *entity.RefreshToken) error
+	// GetByHash returns the refresh token with the given hash, or nil if there is none.
+	GetByHash(ctx context.Context, tokenHash string) (*entity.RefreshToken, error)
+	// MarkRotated marks a usable token as exchanged, and reports whether it was still usable.
+	// Only one of several concurrent calls for the same token succeeds.
+	MarkRotated(ctx context.Context, id string) (bool, error)
+	// RevokeFamily revokes every token of the family.
+	RevokeFamily(ctx context.Context, familyID string) error
+	// RevokeByUserID revokes every token of the user.
+	RevokeByUserID(ctx context.Context, userID int64) error
+}
+
+// RevokedTokenRepository is a denylist of access token IDs (the jti claim).
+type RevokedTokenRepository interface {
+	// Revoke adds the token ID to the denylist until the token would have expired anyway.
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
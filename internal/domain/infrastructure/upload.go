@@ -0,0 +1,23 @@
+package infrastructure
+
+import (
+	"container-manager/internal/domain/entity"
+	"context"
+	"time"
+)
+
+type UploadRepository interface {
+	Create(ctx context.Context, upload *entity.Upload) error
+	// GetByID returns the upload, or nil if there is none.
+	GetByID(ctx context.Context, id string) (*entity.Upload, error)
+	// UpdateOffset advances the offset of an upload from one value to another and sets when it
+	// expires. It returns false if the offset was not from, so that only one request advances it.
+	UpdateOffset(ctx context.Context, id string, from, to int64, expiresAt time.Time) (bool, error)
+	// Delete removes an upload. It returns false if there was none, so that only one request
+	// cleans up after it.
+	Delete(ctx context.Context, id string) (bool, error)
+	// ListExpired returns at most limit uploads that expired before the given time.
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*entity.Upload, error)
+	// ListByUserID returns the uploads started by the user, to personal and team files alike.
+	ListByUserID(ctx context.Context, userID int64) ([]*entity.Upload, error)
+}
@@ -3,9 +3,20 @@ package infrastructure
 import (
 	"container-manager/internal/domain/entity"
 	"context"
+	"time"
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user *entity.User) error
 	FindByUsername(ctx context.Context, username string) (*entity.User, error)
+	FindByID(ctx context.Context, id int64) (*entity.User, error)
+	List(ctx context.Context) ([]*entity.User, error)
+	// UpdateRole returns false if the user does not exist.
+	UpdateRole(ctx context.Context, id int64, role entity.Role) (bool, error)
+	// UpdatePassword stores a new password hash and invalidates the access tokens issued before
+	// sessionsValidAfter. It returns false if the user does not exist.
+	UpdatePassword(ctx context.Context, id int64, password string, sessionsValidAfter time.Time) (bool, error)
+	// Delete removes the user together with its keys, tokens, second factors, identities, quota
+	// and team memberships.
+	Delete(ctx context.Context, id int64) error
 }
@@ -39,7 +39,7 @@ func (e CustomError) Wrap(err error) error {
 }
 
 func (e CustomError) Is(err error) bool {
-	var ae CustomError
+	var ae *CustomError
 	if errors.As(err, &ae) {
 		return e.Message == ae.Message
 	}
@@ -55,5 +55,70 @@ var (
 	JobNotFound                = newCustomError(http.StatusNotFound, "job not found")
 	ContainerNotFound          = newCustomError(http.StatusNotFound, "container not found")
 	ConflictContainerOperation = newCustomError(http.StatusConflict, "conflict container operation")
+	InvalidLabel               = newCustomError(http.StatusBadRequest, "invalid container label")
+	InvalidContainerFilter     = newCustomError(http.StatusBadRequest, "invalid container filter")
+	InvalidContainerName       = newCustomError(http.StatusBadRequest, "invalid container name")
+	ContainerNameConflict      = newCustomError(http.StatusConflict, "container name already in use")
+	InvalidContainerPath       = newCustomError(http.StatusBadRequest, "container path must be absolute")
+	ContainerPathNotFound      = newCustomError(http.StatusNotFound, "path not found in container")
+	InvalidArchiveContentType  = newCustomError(http.StatusUnsupportedMediaType, "content type must be application/x-tar")
+	InvalidCursor              = newCustomError(http.StatusBadRequest, "invalid cursor")
+	QuotaExceeded              = newCustomError(http.StatusForbidden, "quota exceeded")
+	InvalidResourceLimit       = newCustomError(http.StatusBadRequest, "invalid resource limit")
+	InvalidRefreshToken        = newCustomError(http.StatusUnauthorized, "invalid refresh token")
+	InvalidAPIKey              = newCustomError(http.StatusUnauthorized, "invalid api key")
+	APIKeyNotFound             = newCustomError(http.StatusNotFound, "api key not found")
+	InvalidScope               = newCustomError(http.StatusBadRequest, "invalid scope")
+	InvalidExpiry              = newCustomError(http.StatusBadRequest, "invalid expiry")
+	OIDCProviderNotFound       = newCustomError(http.StatusNotFound, "identity provider not found")
+	InvalidOIDCState           = newCustomError(http.StatusBadRequest, "invalid or expired login state")
+	OIDCLoginFailed            = newCustomError(http.StatusUnauthorized, "identity provider login failed")
+	UsernameTaken              = newCustomError(http.StatusConflict, "username already taken")
+	InvalidMFACode             = newCustomError(http.StatusUnauthorized, "invalid verification code")
+	InvalidMFAToken            = newCustomError(http.StatusUnauthorized, "invalid or expired mfa token")
+	TooManyMFAAttempts         = newCustomError(http.StatusTooManyRequests, "too many invalid verification codes, try again later or use a recovery code")
+	MFAAlreadyEnabled          = newCustomError(http.StatusConflict, "two-factor authentication already enabled")
+	MFANotEnabled              = newCustomError(http.StatusBadRequest, "two-factor authentication not enabled")
+	MFANotEnrolled             = newCustomError(http.StatusBadRequest, "two-factor authentication enrollment not started")
+	InvalidRole                = newCustomError(http.StatusBadRequest, "invalid role")
+	TeamNotFound               = newCustomError(http.StatusNotFound, "team not found")
+	InvalidTeamName            = newCustomError(http.StatusBadRequest, "invalid team name")
+	InvalidTeamRole            = newCustomError(http.StatusBadRequest, "invalid team role")
+	LastTeamOwner              = newCustomError(http.StatusConflict, "a team needs at least one owner")
+	FileExists                 = newCustomError(http.StatusConflict, "file already exists")
+	FileNotFound               = newCustomError(http.StatusNotFound, "file not found")
+	InvalidFilename            = newCustomError(http.StatusBadRequest, "invalid filename")
+	NotRegularFile             = newCustomError(http.StatusConflict, "path is not a regular file")
+	FolderNotFound             = newCustomError(http.StatusNotFound, "folder not found")
+	NotAFolder                 = newCustomError(http.StatusConflict, "path is not a folder")
+	FolderNotEmpty             = newCustomError(http.StatusConflict, "folder is not empty")
+	UploadNotFound             = newCustomError(http.StatusNotFound, "upload not found")
+	UploadOffsetMismatch       = newCustomError(http.StatusConflict, "upload offset does not match")
+	UploadLocked               = newCustomError(http.StatusLocked, "upload is in use by another request")
+	UploadTooLarge             = newCustomError(http.StatusRequestEntityTooLarge, "upload exceeds its length")
+	UploadChecksumMismatch     = newCustomError(460, "checksum mismatch") // the status code of the tus checksum extension
+	InvalidUploadContentType   = newCustomError(http.StatusUnsupportedMediaType, "content type must be application/offset+octet-stream")
+	FileTooLarge               = newCustomError(http.StatusRequestEntityTooLarge, "file exceeds the maximum size")
+	FileTypeNotAllowed         = newCustomError(http.StatusUnsupportedMediaType, "file type is not allowed")
+	ContentLengthRequired      = newCustomError(http.StatusLengthRequired, "content length is required")
+	UnsupportedArchive         = newCustomError(http.StatusUnsupportedMediaType, "file is not a tar, tar.gz or zip archive")
+	InvalidArchiveEntry        = newCustomError(http.StatusBadRequest, "invalid archive entry")
+	ArchiveTooLarge            = newCustomError(http.StatusRequestEntityTooLarge, "archive expands beyond the allowed size")
+	ArchiveTooManyFiles        = newCustomError(http.StatusRequestEntityTooLarge, "archive holds too many files")
+	ExtractionInProgress       = newCustomError(http.StatusConflict, "an archive is already being extracted there")
+	ShareLinkNotFound          = newCustomError(http.StatusNotFound, "share link not found")
+	ShareLinkExpired           = newCustomError(http.StatusGone, "share link has expired")
+	ShareLinkRevoked           = newCustomError(http.StatusGone, "share link has been revoked")
+	ShareLinkExhausted         = newCustomError(http.StatusGone, "share link has reached its download limit")
+	InvalidMaxDownloads        = newCustomError(http.StatusBadRequest, "max_downloads cannot be negative")
+	InvalidPassword            = newCustomError(http.StatusUnauthorized, "invalid password")
+	AccountDeletionInProgress  = newCustomError(http.StatusConflict, "account deletion already in progress")
+	InvalidUsername            = newCustomError(http.StatusBadRequest, "invalid username, use 3 to 32 letters, digits, dots, dashes or underscores")
+	PasswordTooShort           = newCustomError(http.StatusBadRequest, "password is too short")
+	PasswordTooLong            = newCustomError(http.StatusBadRequest, "password is too long")
+	PasswordTooSimple          = newCustomError(http.StatusBadRequest, "password needs more kinds of characters")
+	PasswordBreached           = newCustomError(http.StatusBadRequest, "password appears in a list of breached passwords")
+	TooManyLoginAttempts       = newCustomError(http.StatusTooManyRequests, "too many failed logins, try again later")
+	InvalidAuditFilter         = newCustomError(http.StatusBadRequest, "invalid audit filter")
 	InternalServerError        = newCustomError(http.StatusInternalServerError, "internal server error")
 )
@@ -1,11 +1,16 @@
 package containerruntime
 
 import (
+	"bytes"
 	"container-manager/internal/domain/entity"
 	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
 	"context"
+	stderrors "errors"
 	"io"
+	"strconv"
 
+	"github.com/moby/moby/api/pkg/stdcopy"
 	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/client"
 )
@@ -36,9 +41,16 @@ func (d *DockerContainerRuntime) Create(ctx context.Context, options infrastruct
 		ctx,
 		client.ContainerCreateOptions{
 			Config: &container.Config{
-				Cmd:   options.Cmd,
-				Env:   options.Env,
-				Image: options.Image,
+				Cmd:    options.Cmd,
+				Env:    options.Env,
+				Image:  options.Image,
+				Labels: options.Labels,
+			},
+			HostConfig: &container.HostConfig{
+				Resources: container.Resources{
+					Memory:   options.MemoryBytes,
+					NanoCPUs: options.NanoCPUs,
+				},
 			},
 		},
 	)
@@ -87,6 +99,106 @@ func (d *DockerContainerRuntime) Inspect(ctx context.Context, id string) (*entit
 		Image:  resp.Container.Config.Image,
 		Cmd:    resp.Container.Config.Cmd,
 		Env:    resp.Container.Config.Env,
+		Labels: resp.Container.Config.Labels,
 		Status: resp.Container.State.Status,
 	}, nil
 }
+
+func (d *DockerContainerRuntime) ListIDs(ctx context.Context, filter infrastructure.ContainerFilter) ([]string, error) {
+	items, err := d.list(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids, nil
+}
+
+func (d *DockerContainerRuntime) ListStatuses(ctx context.Context, filter infrastructure.ContainerFilter) (map[string]container.ContainerState, error) {
+	items, err := d.list(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[string]container.ContainerState, len(items))
+	for _, item := range items {
+		statuses[item.ID] = item.State
+	}
+	return statuses, nil
+}
+
+// list lists the containers matching the filter, stopped ones included.
+func (d *DockerContainerRuntime) list(ctx context.Context, filter infrastructure.ContainerFilter) ([]container.Summary, error) {
+	filters := make(client.Filters)
+	if len(filter.IDs) > 0 {
+		filters.Add("id", filter.IDs...)
+	}
+	for key, value := range filter.Labels {
+		filters.Add("label", key+"="+value)
+	}
+	if filter.Status != "" {
+		filters.Add("status", filter.Status)
+	}
+	if filter.Image != "" {
+		filters.Add("ancestor", filter.Image)
+	}
+
+	resp, err := d.client.ContainerList(ctx, client.ContainerListOptions{All: true, Filters: filters})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+func (d *DockerContainerRuntime) Logs(ctx context.Context, id string, options infrastructure.ContainerLogsOptions) ([]byte, error) {
+	tail := "all"
+	if options.Tail > 0 {
+		tail = strconv.Itoa(options.Tail)
+	}
+	out, err := d.client.ContainerLogs(ctx, id, client.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: options.Timestamps,
+		Tail:       tail,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+
+	// Containers are created without a TTY, so both streams are multiplexed in one response.
+	var logs bytes.Buffer
+	if _, err := stdcopy.StdCopy(&logs, &logs, out); err != nil {
+		return nil, err
+	}
+	return logs.Bytes(), nil
+}
+
+func (d *DockerContainerRuntime) CopyTo(ctx context.Context, id string, dir string, archive io.Reader) error {
+	_, err := d.client.CopyToContainer(ctx, id, client.CopyToContainerOptions{
+		DestinationPath: dir,
+		Content:         archive,
+	})
+	return copyError(err)
+}
+
+func (d *DockerContainerRuntime) CopyFrom(ctx context.Context, id string, path string) (io.ReadCloser, error) {
+	resp, err := d.client.CopyFromContainer(ctx, id, client.CopyFromContainerOptions{SourcePath: path})
+	if err != nil {
+		return nil, copyError(err)
+	}
+	return resp.Content, nil
+}
+
+// copyError reports a missing path in a container as errors.ContainerPathNotFound. The client marks
+// errors for missing objects with a NotFound method.
+func copyError(err error) error {
+	var notFound interface{ NotFound() }
+	if stderrors.As(err, &notFound) {
+		return errors.ContainerPathNotFound
+	}
+	return err
+}
@@ -0,0 +1,221 @@
+package keymanager
+
+import (
+	"container-manager/internal/domain/infrastructure"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var _ infrastructure.KeyManager = (*KeyManager)(nil)
+
+// KeyFile is a signing key read from PEM files. Keys that are only kept around to verify tokens
+// issued before a rotation need only the public key.
+type KeyFile struct {
+	ID             string
+	PrivateKeyFile string
+	PublicKeyFile  string
+}
+
+// Options configures the KeyManager.
+type Options struct {
+	// SigningKeyID selects the key in Keys that new tokens are signed with.
+	SigningKeyID string
+	Keys         []KeyFile
+	// HMACSecret signs and verifies tokens with HS256 when no asymmetric key is configured.
+	HMACSecret string
+	// AcceptLegacyHS256Until keeps verifying tokens without a kid header with HMACSecret until then
+	// once a signing key is configured, so that the tokens issued before switching to asymmetric
+	// keys stay valid until they expire. Otherwise anyone who knows the old secret could still forge
+	// tokens, so the zero value rejects them right away.
+	AcceptLegacyHS256Until time.Time
+}
+
+type key struct {
+	id         string
+	method     jwt.SigningMethod
+	privateKey crypto.Signer
+	publicKey  crypto.PublicKey
+}
+
+type KeyManager struct {
+	signingKey *key
+	keys       map[string]*key
+	hmacSecret []byte
+	// legacyHS256Until is when tokens signed with hmacSecret stop being accepted if there is a
+	// signing key.
+	legacyHS256Until time.Time
+}
+
+func NewKeyManager(options Options) (*KeyManager, error) {
+	m := &KeyManager{keys: map[string]*key{}, legacyHS256Until: options.AcceptLegacyHS256Until}
+	if options.HMACSecret != "" {
+		m.hmacSecret = []byte(options.HMACSecret)
+	}
+
+	for _, file := range options.Keys {
+		k, err := loadKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key %q: %w", file.ID, err)
+		}
+		if _, ok := m.keys[k.id]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", k.id)
+		}
+		m.keys[k.id] = k
+	}
+
+	if options.SigningKeyID != "" {
+		k, ok := m.keys[options.SigningKeyID]
+		if !ok || k.privateKey == nil {
+			return nil, fmt.Errorf("signing key %q has no private key", options.SigningKeyID)
+		}
+		m.signingKey = k
+	} else if m.hmacSecret == nil {
+		return nil, errors.New("either a signing key or an HMAC secret is required")
+	}
+
+	return m, nil
+}
+
+func (m *KeyManager) Sign(claims jwt.MapClaims) (string, error) {
+	if m.signingKey == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.hmacSecret)
+	}
+	token := jwt.NewWithClaims(m.signingKey.method, claims)
+	token.Header["kid"] = m.signingKey.id
+	return token.SignedString(m.signingKey.privateKey)
+}
+
+func (m *KeyManager) Parse(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, m.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
+// keyFunc picks the verification key by the kid header, and makes sure the token was signed
+// with the algorithm of that key.
+func (m *KeyManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		if _, isHMAC := token.Method.(*jwt.SigningMethodHMAC); !isHMAC || !m.acceptsHMAC() {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return m.hmacSecret, nil
+	}
+
+	k, ok := m.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return k.publicKey, nil
+}
+
+// acceptsHMAC reports whether tokens signed with the HMAC secret are valid: always if they are
+// still signed with it, and only until the configured time after switching to a signing key.
+func (m *KeyManager) acceptsHMAC() bool {
+	if m.hmacSecret == nil {
+		return false
+	}
+	return m.signingKey == nil || time.Now().Before(m.legacyHS256Until)
+}
+
+func (m *KeyManager) PublicKeys() []infrastructure.JWK {
+	jwks := make([]infrastructure.JWK, 0, len(m.keys))
+	for _, k := range m.keys {
+		jwk := infrastructure.JWK{KeyID: k.id, Algorithm: k.method.Alg()}
+		switch publicKey := k.publicKey.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		}
+		jwks = append(jwks, jwk)
+	}
+	slices.SortFunc(jwks, func(a, b infrastructure.JWK) int { return strings.Compare(a.KeyID, b.KeyID) })
+	return jwks
+}
+
+func loadKey(file KeyFile) (*key, error) {
+	if file.ID == "" {
+		return nil, errors.New("key id is required")
+	}
+	k := &key{id: file.ID}
+
+	if file.PrivateKeyFile != "" {
+		block, err := readPEM(file.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+		}
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key")
+		}
+		k.privateKey = signer
+		k.publicKey = signer.Public()
+	} else if file.PublicKeyFile != "" {
+		block, err := readPEM(file.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		k.publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, errors.New("either a private or a public key file is required")
+	}
+
+	switch k.publicKey.(type) {
+	case ed25519.PublicKey:
+		k.method = jwt.SigningMethodEdDSA
+	case *rsa.PublicKey:
+		k.method = jwt.SigningMethodRS256
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+	return k, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not PEM encoded", path)
+	}
+	return block, nil
+}
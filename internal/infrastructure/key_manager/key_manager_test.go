@@ -0,0 +1,220 @@
+package keymanager
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKey writes the private and public key as PEM files and returns their paths.
+func writeKey(t *testing.T, name string, privateKey any, publicKey any) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+
+	privatePath := filepath.Join(dir, name+".pem")
+	publicPath := filepath.Join(dir, name+".pub.pem")
+	require.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600))
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o644))
+	return privatePath, publicPath
+}
+
+func claims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "1234", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func TestKeyManager_Rotation(t *testing.T) {
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	oldPrivatePath, oldPublicPath := writeKey(t, "old", edPrivate, edPublic)
+	newPrivatePath, _ := writeKey(t, "new", rsaPrivate, &rsaPrivate.PublicKey)
+
+	oldManager, err := NewKeyManager(Options{
+		SigningKeyID: "old",
+		Keys:         []KeyFile{{ID: "old", PrivateKeyFile: oldPrivatePath}},
+	})
+	require.NoError(t, err)
+	oldToken, err := oldManager.Sign(claims())
+	require.NoError(t, err)
+
+	// After the rotation the old key only verifies the tokens it signed.
+	manager, err := NewKeyManager(Options{
+		SigningKeyID: "new",
+		Keys: []KeyFile{
+			{ID: "new", PrivateKeyFile: newPrivatePath},
+			{ID: "old", PublicKeyFile: oldPublicPath},
+		},
+	})
+	require.NoError(t, err)
+
+	newToken, err := manager.Sign(claims())
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "new", parsed.Header["kid"])
+	assert.Equal(t, "RS256", parsed.Method.Alg())
+
+	for _, token := range []string{oldToken, newToken} {
+		parsedClaims, err := manager.Parse(token)
+		assert.NoError(t, err)
+		assert.Equal(t, "1234", parsedClaims["sub"])
+	}
+
+	// Once the old key is dropped its tokens are rejected.
+	_, err = oldManager.Parse(newToken)
+	assert.Error(t, err)
+}
+
+func TestKeyManager_Parse(t *testing.T) {
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privatePath, _ := writeKey(t, "key", edPrivate, edPublic)
+
+	manager, err := NewKeyManager(Options{SigningKeyID: "key", Keys: []KeyFile{{ID: "key", PrivateKeyFile: privatePath}}})
+	require.NoError(t, err)
+
+	t.Run("expired token", func(t *testing.T) {
+		token, err := manager.Sign(jwt.MapClaims{"sub": "1234", "exp": time.Now().Add(-time.Minute).Unix()})
+		require.NoError(t, err)
+		_, err = manager.Parse(token)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown kid", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims())
+		token.Header["kid"] = "other"
+		signed, err := token.SignedString(edPrivate)
+		require.NoError(t, err)
+		_, err = manager.Parse(signed)
+		assert.Error(t, err)
+	})
+
+	t.Run("algorithm of another key type", func(t *testing.T) {
+		// Signing with HS256 and the public key as secret must not pass for the Ed25519 key.
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+		token.Header["kid"] = "key"
+		signed, err := token.SignedString([]byte(edPublic))
+		require.NoError(t, err)
+		_, err = manager.Parse(signed)
+		assert.Error(t, err)
+	})
+
+	t.Run("token without kid and no HMAC secret", func(t *testing.T) {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims()).SignedString([]byte("secret"))
+		require.NoError(t, err)
+		_, err = manager.Parse(signed)
+		assert.Error(t, err)
+	})
+}
+
+func TestKeyManager_HMACFallback(t *testing.T) {
+	manager, err := NewKeyManager(Options{HMACSecret: "secret"})
+	require.NoError(t, err)
+
+	token, err := manager.Sign(claims())
+	require.NoError(t, err)
+	parsedClaims, err := manager.Parse(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "1234", parsedClaims["sub"])
+	assert.Empty(t, manager.PublicKeys())
+
+	_, err = NewKeyManager(Options{})
+	assert.Error(t, err)
+}
+
+func TestKeyManager_LegacyHS256(t *testing.T) {
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privatePath, _ := writeKey(t, "key", edPrivate, edPublic)
+
+	legacyManager, err := NewKeyManager(Options{HMACSecret: "secret"})
+	require.NoError(t, err)
+	legacyToken, err := legacyManager.Sign(claims())
+	require.NoError(t, err)
+
+	newManager := func(until time.Time) *KeyManager {
+		manager, err := NewKeyManager(Options{
+			SigningKeyID:           "key",
+			Keys:                   []KeyFile{{ID: "key", PrivateKeyFile: privatePath}},
+			HMACSecret:             "secret",
+			AcceptLegacyHS256Until: until,
+		})
+		require.NoError(t, err)
+		return manager
+	}
+
+	t.Run("rejected once a signing key is configured", func(t *testing.T) {
+		_, err := newManager(time.Time{}).Parse(legacyToken)
+		assert.Error(t, err)
+	})
+
+	t.Run("accepted until the configured time", func(t *testing.T) {
+		parsedClaims, err := newManager(time.Now().Add(time.Hour)).Parse(legacyToken)
+		assert.NoError(t, err)
+		assert.Equal(t, "1234", parsedClaims["sub"])
+	})
+
+	t.Run("rejected after the configured time", func(t *testing.T) {
+		_, err := newManager(time.Now().Add(-time.Second)).Parse(legacyToken)
+		assert.Error(t, err)
+	})
+}
+
+func TestKeyManager_PublicKeys(t *testing.T) {
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPath, _ := writeKey(t, "ed", edPrivate, edPublic)
+	_, rsaPublicPath := writeKey(t, "rsa", rsaPrivate, &rsaPrivate.PublicKey)
+
+	manager, err := NewKeyManager(Options{
+		SigningKeyID: "ed",
+		Keys:         []KeyFile{{ID: "rsa", PublicKeyFile: rsaPublicPath}, {ID: "ed", PrivateKeyFile: edPath}},
+	})
+	require.NoError(t, err)
+
+	keys := manager.PublicKeys()
+	require.Len(t, keys, 2)
+	assert.Equal(t, "ed", keys[0].KeyID)
+	assert.Equal(t, "OKP", keys[0].KeyType)
+	assert.Equal(t, "EdDSA", keys[0].Algorithm)
+	assert.Equal(t, "Ed25519", keys[0].Curve)
+	assert.NotEmpty(t, keys[0].X)
+	assert.Equal(t, "rsa", keys[1].KeyID)
+	assert.Equal(t, "RSA", keys[1].KeyType)
+	assert.Equal(t, "RS256", keys[1].Algorithm)
+	assert.Equal(t, "AQAB", keys[1].E)
+	assert.NotEmpty(t, keys[1].N)
+}
+
+func TestNewKeyManager_InvalidOptions(t *testing.T) {
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, publicPath := writeKey(t, "key", edPrivate, edPublic)
+
+	_, err = NewKeyManager(Options{SigningKeyID: "key", Keys: []KeyFile{{ID: "key", PublicKeyFile: publicPath}}})
+	assert.Error(t, err, "signing key without private key")
+
+	_, err = NewKeyManager(Options{SigningKeyID: "missing", Keys: []KeyFile{{ID: "key", PublicKeyFile: publicPath}}})
+	assert.Error(t, err, "unknown signing key")
+
+	_, err = NewKeyManager(Options{HMACSecret: "secret", Keys: []KeyFile{{ID: "key", PublicKeyFile: publicPath}, {ID: "key", PublicKeyFile: publicPath}}})
+	assert.Error(t, err, "duplicate key id")
+}
@@ -0,0 +1,24 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWKSet_PublicKeys(t *testing.T) {
+	set := jwkSet{Keys: []jwk{
+		// Examples from RFC 7517 appendix A.1 and RFC 8037 appendix A.2.
+		{KeyType: "EC", KeyID: "ec", Curve: "P-256", X: "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4", Y: "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM"},
+		{KeyType: "OKP", KeyID: "okp", Curve: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+		{KeyType: "RSA", KeyID: "enc", Use: "enc", N: "AQAB", E: "AQAB"},
+		{KeyType: "oct", KeyID: "oct"},
+	}}
+
+	keys := set.publicKeys()
+	assert.Len(t, keys, 2)
+	assert.IsType(t, &ecdsa.PublicKey{}, keys["ec"])
+	assert.IsType(t, ed25519.PublicKey{}, keys["okp"])
+}
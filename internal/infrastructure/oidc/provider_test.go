@@ -0,0 +1,106 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/infrastructure/oidc/oidctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Issuer) {
+	t.Helper()
+	issuer, err := oidctest.NewIssuer("container-manager", "secret")
+	require.NoError(t, err)
+	t.Cleanup(issuer.Close)
+
+	provider := NewProvider(Options{
+		Issuer:       issuer.URL,
+		ClientID:     "container-manager",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/users/oidc/company/callback",
+		Scopes:       []string{"profile", "email"},
+	})
+	return provider, issuer
+}
+
+func TestProvider_Login(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+	ctx := context.Background()
+	issuer.SetUser(map[string]any{"sub": "user-1", "preferred_username": "alice"})
+
+	loginState, err := entity.NewOIDCLoginState("company", 0)
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, loginState.State, loginState.Nonce, loginState.CodeChallenge())
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "openid profile email", parsed.Query().Get("scope"))
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+
+	code, state, err := issuer.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, loginState.State, state)
+
+	claims, err := provider.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims["sub"])
+	assert.Equal(t, "alice", claims["preferred_username"])
+
+	// A code can only be redeemed once.
+	_, err = provider.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	assert.Error(t, err)
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+	ctx := context.Background()
+	issuer.SetUser(map[string]any{"sub": "user-1"})
+
+	authorize := func() (*entity.OIDCLoginState, string) {
+		loginState, err := entity.NewOIDCLoginState("company", 0)
+		require.NoError(t, err)
+		authURL, err := provider.AuthCodeURL(ctx, loginState.State, loginState.Nonce, loginState.CodeChallenge())
+		require.NoError(t, err)
+		code, _, err := issuer.Authorize(authURL)
+		require.NoError(t, err)
+		return loginState, code
+	}
+
+	t.Run("wrong code verifier", func(t *testing.T) {
+		loginState, code := authorize()
+		other, err := entity.NewOIDCLoginState("company", 0)
+		require.NoError(t, err)
+
+		_, err = provider.Exchange(ctx, code, other.CodeVerifier, loginState.Nonce)
+		assert.Error(t, err)
+	})
+
+	t.Run("wrong nonce", func(t *testing.T) {
+		loginState, code := authorize()
+
+		_, err := provider.Exchange(ctx, code, loginState.CodeVerifier, "other-nonce")
+		assert.ErrorContains(t, err, "nonce")
+	})
+
+	t.Run("other client", func(t *testing.T) {
+		loginState, code := authorize()
+		otherClient := NewProvider(Options{Issuer: issuer.URL, ClientID: "other", ClientSecret: "secret"})
+
+		_, err := otherClient.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+		assert.Error(t, err)
+	})
+}
+
+func TestProvider_Discovery(t *testing.T) {
+	_, issuer := newTestProvider(t)
+
+	provider := NewProvider(Options{Issuer: issuer.URL + "/other", ClientID: "container-manager"})
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	assert.Error(t, err)
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"container-manager/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var apiKeyColumnNames = []string{"id", "user_id", "name", "prefix", "key_hash", "scopes", "expires_at", "last_used_at", "revoked_at", "created_at"}
+
+func TestAPIKeyRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAPIKeyRepository(db)
+	expiresAt := time.Now().Add(time.Hour)
+	key := &entity.APIKey{
+		ID:        "key-id",
+		UserID:    123,
+		Name:      "ci",
+		Prefix:    "cm_abcdefgh",
+		KeyHash:   "hash",
+		Scopes:    []string{entity.ScopeContainersRead, entity.ScopeJobsRead},
+		ExpiresAt: &expiresAt,
+	}
+
+	mock.ExpectExec("INSERT INTO api_keys \\(id, user_id, name, prefix, key_hash, scopes, expires_at\\)").
+		WithArgs("key-id", int64(123), "ci", "cm_abcdefgh", "hash", "containers:read jobs:read", sql.NullTime{Time: expiresAt.UTC(), Valid: true}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Create(context.Background(), key)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRepository_ListByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAPIKeyRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT (.+) FROM api_keys WHERE user_id = \\$1 AND revoked_at IS NULL ORDER BY created_at DESC").
+		WithArgs(int64(123)).
+		WillReturnRows(sqlmock.NewRows(apiKeyColumnNames).
+			AddRow("key-2", 123, "deploy", "cm_22222222", "hash-2", "containers:write", nil, now, nil, now).
+			AddRow("key-1", 123, "ci", "cm_11111111", "hash-1", "containers:read jobs:read", now, nil, nil, now))
+
+	keys, err := repo.ListByUserID(context.Background(), 123)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+	assert.Equal(t, []string{entity.ScopeContainersWrite}, keys[0].Scopes)
+	assert.Nil(t, keys[0].ExpiresAt)
+	assert.NotNil(t, keys[0].LastUsedAt)
+	assert.Equal(t, []string{entity.ScopeContainersRead, entity.ScopeJobsRead}, keys[1].Scopes)
+	assert.NotNil(t, keys[1].ExpiresAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRepository_GetByHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAPIKeyRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM api_keys WHERE key_hash = \\$1").
+			WithArgs("hash").
+			WillReturnRows(sqlmock.NewRows(apiKeyColumnNames).AddRow("key-id", 123, "ci", "cm_11111111", "hash", "jobs:read", nil, nil, now, now))
+
+		key, err := repo.GetByHash(ctx, "hash")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(123), key.UserID)
+		assert.NotNil(t, key.RevokedAt)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM api_keys").
+			WithArgs("hash").
+			WillReturnError(sql.ErrNoRows)
+
+		key, err := repo.GetByHash(ctx, "hash")
+		assert.NoError(t, err)
+		assert.Nil(t, key)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRepository_Revoke(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAPIKeyRepository(db)
+	ctx := context.Background()
+
+	t.Run("revoked", func(t *testing.T) {
+		mock.ExpectExec("UPDATE api_keys SET revoked_at = NOW\\(\\) WHERE id = \\$1 AND user_id = \\$2 AND revoked_at IS NULL").
+			WithArgs("key-id", int64(123)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		revoked, err := repo.Revoke(ctx, 123, "key-id")
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectExec("UPDATE api_keys SET revoked_at").
+			WithArgs("key-id", int64(123)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		revoked, err := repo.Revoke(ctx, 123, "key-id")
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRepository_UpdateLastUsed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAPIKeyRepository(db)
+	lastUsedAt := time.Now()
+
+	mock.ExpectExec("UPDATE api_keys SET last_used_at = \\$2 WHERE id = \\$1").
+		WithArgs("key-id", lastUsedAt.UTC()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.UpdateLastUsed(context.Background(), "key-id", lastUsedAt)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
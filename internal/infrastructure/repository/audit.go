@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ infrastructure.AuditRepository = (*auditRepository)(nil)
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) infrastructure.AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Append(ctx context.Context, entry *entity.AuditEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+	query := `INSERT INTO audit_logs (created_at, actor_id, api_key_id, action, target_type, target_id, source_ip, outcome, error, request_id)
+		VALUES ($1, NULLIF($2, 0), NULLIF($3, ''), $4, $5, $6, $7, $8, NULLIF($9, ''), $10) RETURNING id`
+	return r.db.QueryRowContext(ctx, query,
+		entry.CreatedAt,
+		entry.ActorID,
+		entry.APIKeyID,
+		entry.Action,
+		entry.TargetType,
+		entry.TargetID,
+		entry.SourceIP,
+		entry.Outcome,
+		entry.Error,
+		entry.RequestID,
+	).Scan(&entry.ID)
+}
+
+func (r *auditRepository) List(ctx context.Context, filter infrastructure.AuditFilter, limit int) ([]*entity.AuditEntry, error) {
+	var conditions []string
+	var args []any
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+	if filter.ActorID != 0 {
+		where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		where("target_id = ?", filter.TargetID)
+	}
+	if filter.Outcome != "" {
+		where("outcome = ?", filter.Outcome)
+	}
+	if filter.RequestID != "" {
+		where("request_id = ?", filter.RequestID)
+	}
+	if !filter.Since.IsZero() {
+		where("created_at >= ?", filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		where("created_at < ?", filter.Until.UTC())
+	}
+	if filter.BeforeID != 0 {
+		where("id < ?", filter.BeforeID)
+	}
+
+	query := "SELECT id, created_at, COALESCE(actor_id, 0), COALESCE(api_key_id, ''), action, target_type, target_id, source_ip, outcome, COALESCE(error, ''), request_id FROM audit_logs"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+	query += " ORDER BY id DESC LIMIT $" + strconv.Itoa(len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*entity.AuditEntry{}
+	for rows.Next() {
+		entry := &entity.AuditEntry{}
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.CreatedAt,
+			&entry.ActorID,
+			&entry.APIKeyID,
+			&entry.Action,
+			&entry.TargetType,
+			&entry.TargetID,
+			&entry.SourceIP,
+			&entry.Outcome,
+			&entry.Error,
+			&entry.RequestID,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditRepository_Append(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAuditRepository(db)
+	entry := &entity.AuditEntry{
+		ActorID:    1,
+		Action:     entity.AuditActionContainerRemove,
+		TargetType: "container",
+		TargetID:   "container-1",
+		SourceIP:   "10.0.0.1",
+		Outcome:    entity.AuditOutcomeSuccess,
+		RequestID:  "request-1",
+	}
+
+	mock.ExpectQuery("INSERT INTO audit_logs \\(created_at, actor_id, api_key_id, action, target_type, target_id, source_ip, outcome, error, request_id\\)\\s+VALUES \\(\\$1, NULLIF\\(\\$2, 0\\), NULLIF\\(\\$3, ''\\), \\$4, \\$5, \\$6, \\$7, \\$8, NULLIF\\(\\$9, ''\\), \\$10\\) RETURNING id").
+		WithArgs(sqlmock.AnyArg(), int64(1), "", entity.AuditActionContainerRemove, "container", "container-1", "10.0.0.1", entity.AuditOutcomeSuccess, "", "request-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+
+	err = repo.Append(context.Background(), entry)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), entry.ID)
+	assert.False(t, entry.CreatedAt.IsZero())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditRepository_List(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAuditRepository(db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	columns := []string{"id", "created_at", "actor_id", "api_key_id", "action", "target_type", "target_id", "source_ip", "outcome", "error", "request_id"}
+
+	t.Run("without filter", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM audit_logs ORDER BY id DESC LIMIT \\$1").
+			WithArgs(50).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(2, now, 0, "", entity.AuditActionUserLogin, "user", "alice", "10.0.0.1", "failure", "unauthorized", "request-2").
+				AddRow(1, now, 1, "key-1", entity.AuditActionFileUpload, "file", "a.txt", "10.0.0.2", "success", "", "request-1"))
+
+		entries, err := repo.List(ctx, infrastructure.AuditFilter{}, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, []*entity.AuditEntry{
+			{ID: 2, CreatedAt: now, Action: entity.AuditActionUserLogin, TargetType: "user", TargetID: "alice", SourceIP: "10.0.0.1", Outcome: entity.AuditOutcomeFailure, Error: "unauthorized", RequestID: "request-2"},
+			{ID: 1, CreatedAt: now, ActorID: 1, APIKeyID: "key-1", Action: entity.AuditActionFileUpload, TargetType: "file", TargetID: "a.txt", SourceIP: "10.0.0.2", Outcome: entity.AuditOutcomeSuccess, RequestID: "request-1"},
+		}, entries)
+	})
+
+	t.Run("with filter", func(t *testing.T) {
+		since := now.Add(-time.Hour)
+		mock.ExpectQuery("SELECT (.+) FROM audit_logs WHERE actor_id = \\$1 AND action = \\$2 AND outcome = \\$3 AND created_at >= \\$4 AND id < \\$5 ORDER BY id DESC LIMIT \\$6").
+			WithArgs(int64(1), entity.AuditActionContainerStop, entity.AuditOutcomeFailure, since, int64(100), 10).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		entries, err := repo.List(ctx, infrastructure.AuditFilter{
+			ActorID:  1,
+			Action:   entity.AuditActionContainerStop,
+			Outcome:  entity.AuditOutcomeFailure,
+			Since:    since,
+			BeforeID: 100,
+		}, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
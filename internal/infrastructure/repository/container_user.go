@@ -1,11 +1,24 @@
 package repository
 
 import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
 	"container-manager/internal/errors"
 	"context"
 	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// uniqueViolation is the Postgres error code for unique constraint violations.
+const uniqueViolation = "23505"
+
+// containerUserColumns are the columns read by scanContainerUser.
+const containerUserColumns = "container_id, user_id, COALESCE(team_id, 0), COALESCE(name, ''), created_at, memory_bytes, nano_cpus, running"
+
 type ContainerUserRepository struct {
 	db *sql.DB
 }
@@ -14,9 +27,12 @@ func NewContainerUserRepository(db *sql.DB) *ContainerUserRepository {
 	return &ContainerUserRepository{db: db}
 }
 
-func (r *ContainerUserRepository) Create(ctx context.Context, containerID string, userID int64) error {
-	query := "INSERT INTO container_user (container_id, user_id) VALUES ($1, $2)"
-	_, err := r.db.ExecContext(ctx, query, containerID, userID)
+func (r *ContainerUserRepository) Create(ctx context.Context, containerUser *entity.ContainerUser) error {
+	query := "INSERT INTO container_user (container_id, user_id, team_id, name, memory_bytes, nano_cpus) VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, ''), $5, $6)"
+	_, err := r.db.ExecContext(ctx, query, containerUser.ContainerID, containerUser.UserID, containerUser.TeamID, containerUser.Name, containerUser.MemoryBytes, containerUser.NanoCPUs)
+	if isUniqueViolation(err) {
+		return errors.ContainerNameConflict
+	}
 	return err
 }
 
@@ -39,9 +55,18 @@ func (r *ContainerUserRepository) GetUserIDByContainerID(ctx context.Context, co
 	return userID, nil
 }
 
-func (r *ContainerUserRepository) GetContainerIDsByUserID(ctx context.Context, userID int64) ([]string, error) {
-	query := "SELECT container_id FROM container_user WHERE user_id = $1"
-	rows, err := r.db.QueryContext(ctx, query, userID)
+// ownerCondition returns the condition selecting the containers of owner, with its argument as $1.
+func ownerCondition(owner entity.Owner) (string, any) {
+	if owner.TeamID != 0 {
+		return "team_id = $1", owner.TeamID
+	}
+	return "user_id = $1 AND team_id IS NULL", owner.UserID
+}
+
+func (r *ContainerUserRepository) GetContainerIDsByOwner(ctx context.Context, owner entity.Owner) ([]string, error) {
+	condition, arg := ownerCondition(owner)
+	query := "SELECT container_id FROM container_user WHERE " + condition
+	rows, err := r.db.QueryContext(ctx, query, arg)
 	if err != nil {
 		return nil, err
 	}
@@ -57,3 +82,150 @@ func (r *ContainerUserRepository) GetContainerIDsByUserID(ctx context.Context, u
 	}
 	return containerIDs, rows.Err()
 }
+
+func (r *ContainerUserRepository) GetByOwner(ctx context.Context, owner entity.Owner) ([]*entity.ContainerUser, error) {
+	condition, arg := ownerCondition(owner)
+	query := "SELECT " + containerUserColumns + " FROM container_user WHERE " + condition
+	rows, err := r.db.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return scanContainerUsers(rows)
+}
+
+// containerUserSortColumns maps the sort keys handled by the database to their sort expression.
+var containerUserSortColumns = map[string]string{
+	infrastructure.ContainerSortCreatedAt: "created_at",
+	infrastructure.ContainerSortName:      "COALESCE(name, '')",
+}
+
+func (r *ContainerUserRepository) GetPageByOwner(ctx context.Context, owner entity.Owner, page infrastructure.ContainerUserPage) ([]*entity.ContainerUser, error) {
+	column, ok := containerUserSortColumns[page.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort key %q", page.Sort)
+	}
+	direction, comparison := "ASC", ">"
+	if page.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	var query strings.Builder
+	condition, arg := ownerCondition(owner)
+	query.WriteString("SELECT " + containerUserColumns + " FROM container_user WHERE " + condition)
+	args := []any{arg}
+	if page.IDs != nil {
+		args = append(args, page.IDs)
+		fmt.Fprintf(&query, " AND container_id = ANY($%d)", len(args))
+	}
+	if page.After != nil {
+		var afterValue any = page.After.CreatedAt
+		if page.Sort == infrastructure.ContainerSortName {
+			afterValue = page.After.Name
+		}
+		args = append(args, afterValue, page.After.ContainerID)
+		fmt.Fprintf(&query, " AND (%s, container_id) %s ($%d, $%d)", column, comparison, len(args)-1, len(args))
+	}
+	args = append(args, page.Limit)
+	fmt.Fprintf(&query, " ORDER BY %s %s, container_id %s LIMIT $%d", column, direction, direction, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanContainerUsers(rows)
+}
+
+func (r *ContainerUserRepository) CountByOwner(ctx context.Context, owner entity.Owner, ids []string) (int, error) {
+	condition, arg := ownerCondition(owner)
+	query := "SELECT COUNT(*) FROM container_user WHERE " + condition
+	args := []any{arg}
+	if ids != nil {
+		query += " AND container_id = ANY($2)"
+		args = append(args, ids)
+	}
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	return count, err
+}
+
+func scanContainerUsers(rows *sql.Rows) ([]*entity.ContainerUser, error) {
+	defer rows.Close()
+
+	var containerUsers []*entity.ContainerUser
+	for rows.Next() {
+		containerUser, err := scanContainerUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		containerUsers = append(containerUsers, containerUser)
+	}
+	return containerUsers, rows.Err()
+}
+
+func (r *ContainerUserRepository) FindByIDOrName(ctx context.Context, userID int64, idOrName string) (*entity.ContainerUser, error) {
+	query := "SELECT " + containerUserColumns + " FROM container_user WHERE container_id = $1 OR (user_id = $2 AND name = $1) LIMIT 1"
+	containerUser, err := scanContainerUser(r.db.QueryRowContext(ctx, query, idOrName, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ContainerNotFound
+		}
+		return nil, err
+	}
+	return containerUser, nil
+}
+
+func (r *ContainerUserRepository) UpdateName(ctx context.Context, containerID string, name string) error {
+	query := "UPDATE container_user SET name = NULLIF($2, '') WHERE container_id = $1"
+	_, err := r.db.ExecContext(ctx, query, containerID, name)
+	if isUniqueViolation(err) {
+		return errors.ContainerNameConflict
+	}
+	return err
+}
+
+// SetRunning records whether the container is running, and reports whether the record changed.
+func (r *ContainerUserRepository) SetRunning(ctx context.Context, containerID string, running bool) (bool, error) {
+	query := "UPDATE container_user SET running = $2 WHERE container_id = $1 AND running <> $2"
+	result, err := r.db.ExecContext(ctx, query, containerID, running)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *ContainerUserRepository) GetRunningByUserID(ctx context.Context, userID int64) ([]*entity.ContainerUser, error) {
+	query := "SELECT " + containerUserColumns + " FROM container_user WHERE user_id = $1 AND running"
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanContainerUsers(rows)
+}
+
+func scanContainerUser(row interface{ Scan(dest ...any) error }) (*entity.ContainerUser, error) {
+	containerUser := &entity.ContainerUser{}
+	err := row.Scan(
+		&containerUser.ContainerID,
+		&containerUser.UserID,
+		&containerUser.TeamID,
+		&containerUser.Name,
+		&containerUser.CreatedAt,
+		&containerUser.MemoryBytes,
+		&containerUser.NanoCPUs,
+		&containerUser.Running,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return containerUser, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return stderrors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
@@ -3,11 +3,16 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"testing"
+	"time"
 
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
 	internalErrors "container-manager/internal/errors"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,27 +24,35 @@ func TestContainerUserRepository_Create(t *testing.T) {
 	repo := NewContainerUserRepository(db)
 	ctx := context.Background()
 
-	containerID := "container-1"
-	userID := int64(123)
+	containerUser := &entity.ContainerUser{ContainerID: "container-1", UserID: 123, Name: "web", MemoryBytes: 1024, NanoCPUs: 500000000}
 
 	t.Run("success", func(t *testing.T) {
 		mock.ExpectExec("INSERT INTO container_user").
-			WithArgs(containerID, userID).
+			WithArgs("container-1", int64(123), int64(0), "web", int64(1024), int64(500000000)).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
-		err := repo.Create(ctx, containerID, userID)
+		err := repo.Create(ctx, containerUser)
 		assert.NoError(t, err)
 	})
 
 	t.Run("failure", func(t *testing.T) {
 		mock.ExpectExec("INSERT INTO container_user").
-			WithArgs(containerID, userID).
+			WithArgs("container-1", int64(123), int64(0), "web", int64(1024), int64(500000000)).
 			WillReturnError(sql.ErrConnDone)
 
-		err := repo.Create(ctx, containerID, userID)
+		err := repo.Create(ctx, containerUser)
 		assert.Error(t, err)
 	})
 
+	t.Run("name conflict", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO container_user").
+			WithArgs("container-1", int64(123), int64(0), "web", int64(1024), int64(500000000)).
+			WillReturnError(&pgconn.PgError{Code: "23505"})
+
+		err := repo.Create(ctx, containerUser)
+		assert.Equal(t, internalErrors.ContainerNameConflict, err)
+	})
+
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -122,7 +135,7 @@ rows := sqlmock.NewRows([]string{"user_id"}).AddRow(userID)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestContainerUserRepository_GetContainerIDsByUserID(t *testing.T) {
+func TestContainerUserRepository_GetContainerIDsByOwner(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
 	defer db.Close()
@@ -140,21 +153,317 @@ rows := sqlmock.NewRows([]string{"container_id"}).
 			AddRow(containerID1).
 			AddRow(containerID2)
 
-		mock.ExpectQuery("SELECT container_id FROM container_user WHERE user_id = \\$1").
+		mock.ExpectQuery("SELECT container_id FROM container_user WHERE user_id = \\$1 AND team_id IS NULL").
 			WithArgs(userID).
 			WillReturnRows(rows)
 
-		result, err := repo.GetContainerIDsByUserID(ctx, userID)
+		result, err := repo.GetContainerIDsByOwner(ctx, entity.Owner{UserID: userID})
 		assert.NoError(t, err)
 		assert.Equal(t, []string{containerID1, containerID2}, result)
 	})
 
 	t.Run("db error", func(t *testing.T) {
-		mock.ExpectQuery("SELECT container_id FROM container_user WHERE user_id = \\$1").
+		mock.ExpectQuery("SELECT container_id FROM container_user WHERE user_id = \\$1 AND team_id IS NULL").
+			WithArgs(userID).
+			WillReturnError(sql.ErrConnDone)
+
+		result, err := repo.GetContainerIDsByOwner(ctx, entity.Owner{UserID: userID})
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContainerUserRepository_GetByOwner(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewContainerUserRepository(db)
+	ctx := context.Background()
+
+	userID := int64(123)
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(containerUserColumnNames).
+			AddRow("container-1", userID, 0, "web", createdAt, 0, 0, false).
+			AddRow("container-2", userID, 0, "", createdAt, 0, 0, false)
+
+		mock.ExpectQuery("SELECT container_id, user_id, COALESCE\\(team_id, 0\\), COALESCE\\(name, ''\\), created_at, memory_bytes, nano_cpus, running FROM container_user WHERE user_id = \\$1 AND team_id IS NULL").
+			WithArgs(userID).
+			WillReturnRows(rows)
+
+		result, err := repo.GetByOwner(ctx, entity.Owner{UserID: userID})
+		assert.NoError(t, err)
+		assert.Equal(t, []*entity.ContainerUser{
+			{ContainerID: "container-1", UserID: userID, Name: "web", CreatedAt: createdAt},
+			{ContainerID: "container-2", UserID: userID, CreatedAt: createdAt},
+		}, result)
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		mock.ExpectQuery("SELECT container_id, user_id, COALESCE\\(team_id, 0\\), COALESCE\\(name, ''\\), created_at, memory_bytes, nano_cpus, running FROM container_user WHERE user_id = \\$1 AND team_id IS NULL").
+			WithArgs(userID).
+			WillReturnError(sql.ErrConnDone)
+
+		result, err := repo.GetByOwner(ctx, entity.Owner{UserID: userID})
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContainerUserRepository_FindByIDOrName(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewContainerUserRepository(db)
+	ctx := context.Background()
+
+	userID := int64(123)
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(containerUserColumnNames).
+			AddRow("container-1", userID, 0, "web", createdAt, 1024, 500000000, true)
+
+		mock.ExpectQuery("SELECT container_id, user_id, COALESCE\\(team_id, 0\\), COALESCE\\(name, ''\\), created_at, memory_bytes, nano_cpus, running FROM container_user WHERE container_id = \\$1 OR \\(user_id = \\$2 AND name = \\$1\\)").
+			WithArgs("web", userID).
+			WillReturnRows(rows)
+
+		result, err := repo.FindByIDOrName(ctx, userID, "web")
+		assert.NoError(t, err)
+		assert.Equal(t, &entity.ContainerUser{ContainerID: "container-1", UserID: userID, Name: "web", CreatedAt: createdAt, MemoryBytes: 1024, NanoCPUs: 500000000, Running: true}, result)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT container_id, user_id, COALESCE\\(team_id, 0\\), COALESCE\\(name, ''\\), created_at, memory_bytes, nano_cpus, running FROM container_user").
+			WithArgs("missing", userID).
+			WillReturnError(sql.ErrNoRows)
+
+		result, err := repo.FindByIDOrName(ctx, userID, "missing")
+		assert.Equal(t, internalErrors.ContainerNotFound, err)
+		assert.Nil(t, result)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContainerUserRepository_UpdateName(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewContainerUserRepository(db)
+	ctx := context.Background()
+
+	containerID := "container-1"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec("UPDATE container_user SET name = NULLIF\\(\\$2, ''\\) WHERE container_id = \\$1").
+			WithArgs(containerID, "api").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.UpdateName(ctx, containerID, "api")
+		assert.NoError(t, err)
+	})
+
+	t.Run("name conflict", func(t *testing.T) {
+		mock.ExpectExec("UPDATE container_user SET name").
+			WithArgs(containerID, "api").
+			WillReturnError(&pgconn.PgError{Code: "23505"})
+
+		err := repo.UpdateName(ctx, containerID, "api")
+		assert.Equal(t, internalErrors.ContainerNameConflict, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+var containerUserColumnNames = []string{"container_id", "user_id", "team_id", "name", "created_at", "memory_bytes", "nano_cpus", "running"}
+
+// arrayConverter lets string slices through as query arguments, the way the pgx driver accepts them.
+type arrayConverter struct{}
+
+func (arrayConverter) ConvertValue(v any) (driver.Value, error) {
+	if ids, ok := v.([]string); ok {
+		return ids, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+func TestContainerUserRepository_GetPageByOwner(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewContainerUserRepository(db)
+	ctx := context.Background()
+
+	userID := int64(123)
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("first page", func(t *testing.T) {
+		rows := sqlmock.NewRows(containerUserColumnNames).
+			AddRow("container-1", userID, 0, "web", createdAt, 0, 0, false)
+
+		mock.ExpectQuery("SELECT container_id, user_id, COALESCE\\(team_id, 0\\), COALESCE\\(name, ''\\), created_at, memory_bytes, nano_cpus, running FROM container_user WHERE user_id = \\$1 AND team_id IS NULL ORDER BY created_at ASC, container_id ASC LIMIT \\$2").
+			WithArgs(userID, 11).
+			WillReturnRows(rows)
+
+		result, err := repo.GetPageByOwner(ctx, entity.Owner{UserID: userID}, infrastructure.ContainerUserPage{Sort: infrastructure.ContainerSortCreatedAt, Limit: 11})
+		assert.NoError(t, err)
+		assert.Equal(t, []*entity.ContainerUser{{ContainerID: "container-1", UserID: userID, Name: "web", CreatedAt: createdAt}}, result)
+	})
+
+	t.Run("filtered page after cursor", func(t *testing.T) {
+		rows := sqlmock.NewRows(containerUserColumnNames).
+			AddRow("container-2", userID, 0, "api", createdAt, 0, 0, false)
+
+		mock.ExpectQuery("SELECT container_id, user_id, COALESCE\\(team_id, 0\\), COALESCE\\(name, ''\\), created_at, memory_bytes, nano_cpus, running FROM container_user WHERE user_id = \\$1 AND team_id IS NULL AND container_id = ANY\\(\\$2\\) AND \\(COALESCE\\(name, ''\\), container_id\\) < \\(\\$3, \\$4\\) ORDER BY COALESCE\\(name, ''\\) DESC, container_id DESC LIMIT \\$5").
+			WithArgs(userID, []string{"container-1", "container-2"}, "web", "container-1", 11).
+			WillReturnRows(rows)
+
+		result, err := repo.GetPageByOwner(ctx, entity.Owner{UserID: userID}, infrastructure.ContainerUserPage{
+			IDs:        []string{"container-1", "container-2"},
+			Sort:       infrastructure.ContainerSortName,
+			Descending: true,
+			Limit:      11,
+			After:      &entity.ContainerUser{ContainerID: "container-1", Name: "web"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []*entity.ContainerUser{{ContainerID: "container-2", UserID: userID, Name: "api", CreatedAt: createdAt}}, result)
+	})
+
+	t.Run("unsupported sort", func(t *testing.T) {
+		result, err := repo.GetPageByOwner(ctx, entity.Owner{UserID: userID}, infrastructure.ContainerUserPage{Sort: infrastructure.ContainerSortStatus, Limit: 11})
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContainerUserRepository_CountByOwner(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewContainerUserRepository(db)
+	ctx := context.Background()
+
+	userID := int64(123)
+
+	t.Run("all", func(t *testing.T) {
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM container_user WHERE user_id = \\$1 AND team_id IS NULL$").
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+		count, err := repo.CountByOwner(ctx, entity.Owner{UserID: userID}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("restricted to ids", func(t *testing.T) {
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM container_user WHERE user_id = \\$1 AND team_id IS NULL AND container_id = ANY\\(\\$2\\)").
+			WithArgs(userID, []string{"container-1"}).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		count, err := repo.CountByOwner(ctx, entity.Owner{UserID: userID}, []string{"container-1"})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("team", func(t *testing.T) {
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM container_user WHERE team_id = \\$1$").
+			WithArgs(int64(77)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+		count, err := repo.CountByOwner(ctx, entity.Owner{UserID: userID, TeamID: 77}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContainerUserRepository_SetRunning(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewContainerUserRepository(db)
+	ctx := context.Background()
+
+	t.Run("changed", func(t *testing.T) {
+		mock.ExpectExec("UPDATE container_user SET running = \\$2 WHERE container_id = \\$1 AND running <> \\$2").
+			WithArgs("container-1", true).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		changed, err := repo.SetRunning(ctx, "container-1", true)
+		assert.NoError(t, err)
+		assert.True(t, changed)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		mock.ExpectExec("UPDATE container_user SET running").
+			WithArgs("container-1", true).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		changed, err := repo.SetRunning(ctx, "container-1", true)
+		assert.NoError(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		mock.ExpectExec("UPDATE container_user SET running").
+			WithArgs("container-1", false).
+			WillReturnError(sql.ErrConnDone)
+
+		changed, err := repo.SetRunning(ctx, "container-1", false)
+		assert.Error(t, err)
+		assert.False(t, changed)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContainerUserRepository_GetRunningByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewContainerUserRepository(db)
+	ctx := context.Background()
+
+	userID := int64(123)
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(containerUserColumnNames).
+			AddRow("container-1", userID, 7, "web", createdAt, 0, 0, true)
+
+		mock.ExpectQuery("SELECT container_id, user_id, COALESCE\\(team_id, 0\\), COALESCE\\(name, ''\\), created_at, memory_bytes, nano_cpus, running FROM container_user WHERE user_id = \\$1 AND running").
+			WithArgs(userID).
+			WillReturnRows(rows)
+
+		result, err := repo.GetRunningByUserID(ctx, userID)
+		assert.NoError(t, err)
+		assert.Equal(t, []*entity.ContainerUser{
+			{ContainerID: "container-1", UserID: userID, TeamID: 7, Name: "web", CreatedAt: createdAt, Running: true},
+		}, result)
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		mock.ExpectQuery("SELECT .* FROM container_user WHERE user_id = \\$1 AND running").
 			WithArgs(userID).
 			WillReturnError(sql.ErrConnDone)
 
-		result, err := repo.GetContainerIDsByUserID(ctx, userID)
+		result, err := repo.GetRunningByUserID(ctx, userID)
 		assert.Error(t, err)
 		assert.Nil(t, result)
 	})
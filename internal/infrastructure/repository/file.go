@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"context"
+	"database/sql"
+)
+
+var _ infrastructure.FileRepository = (*fileRepository)(nil)
+
+type fileRepository struct {
+	db *sql.DB
+}
+
+func NewFileRepository(db *sql.DB) infrastructure.FileRepository {
+	return &fileRepository{db: db}
+}
+
+// fileOwner returns the condition selecting the files of the owner, with $1 for the ID of the
+// owner, and the unique index of their paths to upsert on. Team files have no user, so that
+// they are kept when the user who uploaded them deletes their account.
+func fileOwner(owner entity.Owner) (condition, conflict string, id int64) {
+	if owner.TeamID != 0 {
+		return "team_id = $1", "(team_id, path) WHERE team_id IS NOT NULL", owner.TeamID
+	}
+	return "user_id = $1", "(user_id, path) WHERE team_id IS NULL", owner.UserID
+}
+
+func (r *fileRepository) Save(ctx context.Context, file *entity.FileRecord) error {
+	userID, teamID := file.Owner.UserID, file.Owner.TeamID
+	if teamID != 0 {
+		userID = 0
+	}
+	_, conflict, _ := fileOwner(file.Owner)
+	query := `INSERT INTO files (user_id, team_id, uploaded_by, path, sha256, size, content_type, uploaded_at)
+		VALUES (NULLIF($1, 0), NULLIF($2, 0), NULLIF($3, 0), $4, $5, $6, $7, $8)
+		ON CONFLICT ` + conflict + ` DO UPDATE SET
+			uploaded_by = EXCLUDED.uploaded_by, sha256 = EXCLUDED.sha256, size = EXCLUDED.size, content_type = EXCLUDED.content_type, uploaded_at = EXCLUDED.uploaded_at`
+	_, err := r.db.ExecContext(ctx, query, userID, teamID, file.UploadedBy, file.Path, file.SHA256, file.Size, file.ContentType, file.UploadedAt.UTC())
+	return err
+}
+
+func (r *fileRepository) Delete(ctx context.Context, owner entity.Owner, path string) ([]*entity.FileRecord, error) {
+	condition, _, id := fileOwner(owner)
+	query := "DELETE FROM files WHERE " + condition + ` AND (path = $2 OR starts_with(path, $3))
+		RETURNING COALESCE(uploaded_by, 0), path, sha256, size, content_type, uploaded_at`
+	rows, err := r.db.QueryContext(ctx, query, id, path, path+"/")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*entity.FileRecord
+	for rows.Next() {
+		record := &entity.FileRecord{Owner: owner}
+		if err := rows.Scan(&record.UploadedBy, &record.Path, &record.SHA256, &record.Size, &record.ContentType, &record.UploadedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+func (r *fileRepository) Move(ctx context.Context, owner entity.Owner, from, to string) error {
+	condition, _, id := fileOwner(owner)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Records left at the new path would collide with the moved ones.
+	deleteQuery := "DELETE FROM files WHERE " + condition + " AND (path = $2 OR starts_with(path, $3))"
+	if _, err := tx.ExecContext(ctx, deleteQuery, id, to, to+"/"); err != nil {
+		return err
+	}
+	updateQuery := "UPDATE files SET path = $4 || substr(path, $5) WHERE " + condition + " AND (path = $2 OR starts_with(path, $3))"
+	if _, err := tx.ExecContext(ctx, updateQuery, id, from, from+"/", to, len(from)+1); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
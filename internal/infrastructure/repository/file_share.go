@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var _ infrastructure.FileShareRepository = (*fileShareRepository)(nil)
+
+// fileShareColumns is the column list scanned by scanFileShare.
+const fileShareColumns = "id, user_id, COALESCE(team_id, 0), path, max_downloads, downloads, expires_at, revoked_at, created_at"
+
+type fileShareRepository struct {
+	db *sql.DB
+}
+
+func NewFileShareRepository(db *sql.DB) infrastructure.FileShareRepository {
+	return &fileShareRepository{db: db}
+}
+
+func (r *fileShareRepository) Create(ctx context.Context, share *entity.FileShare) error {
+	query := "INSERT INTO file_shares (id, user_id, team_id, path, max_downloads, expires_at, created_at) VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7)"
+	_, err := r.db.ExecContext(ctx, query, share.ID, share.UserID, share.TeamID, share.Path, share.MaxDownloads, share.ExpiresAt.UTC(), share.CreatedAt.UTC())
+	return err
+}
+
+func (r *fileShareRepository) GetByID(ctx context.Context, id string) (*entity.FileShare, error) {
+	query := "SELECT " + fileShareColumns + " FROM file_shares WHERE id = $1"
+	share, err := scanFileShare(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return share, nil
+}
+
+func (r *fileShareRepository) ListByUserID(ctx context.Context, userID int64, now time.Time) ([]*entity.FileShare, error) {
+	query := "SELECT " + fileShareColumns + " FROM file_shares WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2 ORDER BY created_at DESC"
+	return r.list(ctx, query, userID, now.UTC())
+}
+
+func (r *fileShareRepository) ListByTeamID(ctx context.Context, teamID int64, now time.Time) ([]*entity.FileShare, error) {
+	query := "SELECT " + fileShareColumns + " FROM file_shares WHERE team_id = $1 AND revoked_at IS NULL AND expires_at > $2 ORDER BY created_at DESC"
+	return r.list(ctx, query, teamID, now.UTC())
+}
+
+func (r *fileShareRepository) list(ctx context.Context, query string, args ...any) ([]*entity.FileShare, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shares []*entity.FileShare
+	for rows.Next() {
+		share, err := scanFileShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+func (r *fileShareRepository) Revoke(ctx context.Context, id string) (bool, error) {
+	query := "UPDATE file_shares SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *fileShareRepository) RevokeByMember(ctx context.Context, teamID, userID int64) error {
+	query := "UPDATE file_shares SET revoked_at = NOW() WHERE team_id = $1 AND user_id = $2 AND revoked_at IS NULL"
+	_, err := r.db.ExecContext(ctx, query, teamID, userID)
+	return err
+}
+
+func (r *fileShareRepository) CountDownload(ctx context.Context, id string, now time.Time) (bool, error) {
+	query := "UPDATE file_shares SET downloads = downloads + 1 WHERE id = $1 AND revoked_at IS NULL AND expires_at > $2 AND (max_downloads = 0 OR downloads < max_downloads)"
+	result, err := r.db.ExecContext(ctx, query, id, now.UTC())
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func scanFileShare(row interface{ Scan(dest ...any) error }) (*entity.FileShare, error) {
+	share := &entity.FileShare{}
+	var revokedAt sql.NullTime
+	err := row.Scan(
+		&share.ID,
+		&share.UserID,
+		&share.TeamID,
+		&share.Path,
+		&share.MaxDownloads,
+		&share.Downloads,
+		&share.ExpiresAt,
+		&revokedAt,
+		&share.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		share.RevokedAt = &revokedAt.Time
+	}
+	return share, nil
+}
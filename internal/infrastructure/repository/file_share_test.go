@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"container-manager/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var fileShareColumnNames = []string{"id", "user_id", "team_id", "path", "max_downloads", "downloads", "expires_at", "revoked_at", "created_at"}
+
+func TestFileShareRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFileShareRepository(db)
+	now := time.Now()
+	share := &entity.FileShare{
+		ID:           "share-id",
+		UserID:       123,
+		TeamID:       7,
+		Path:         "reports/q1.csv",
+		MaxDownloads: 3,
+		ExpiresAt:    now.Add(time.Hour),
+		CreatedAt:    now,
+	}
+
+	mock.ExpectExec("INSERT INTO file_shares \\(id, user_id, team_id, path, max_downloads, expires_at, created_at\\) VALUES \\(\\$1, \\$2, NULLIF\\(\\$3, 0\\)").
+		WithArgs("share-id", int64(123), int64(7), "reports/q1.csv", 3, share.ExpiresAt.UTC(), now.UTC()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Create(context.Background(), share)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFileShareRepository_GetByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFileShareRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM file_shares WHERE id = \\$1").
+			WithArgs("share-id").
+			WillReturnRows(sqlmock.NewRows(fileShareColumnNames).AddRow("share-id", 123, 0, "q1.csv", 3, 1, now.Add(time.Hour), now, now))
+
+		share, err := repo.GetByID(ctx, "share-id")
+		assert.NoError(t, err)
+		assert.Equal(t, entity.Owner{UserID: 123}, share.Owner())
+		assert.Equal(t, 3, share.MaxDownloads)
+		assert.Equal(t, 1, share.Downloads)
+		assert.NotNil(t, share.RevokedAt)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM file_shares").
+			WithArgs("share-id").
+			WillReturnError(sql.ErrNoRows)
+
+		share, err := repo.GetByID(ctx, "share-id")
+		assert.NoError(t, err)
+		assert.Nil(t, share)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFileShareRepository_ListByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFileShareRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT (.+) FROM file_shares WHERE user_id = \\$1 AND revoked_at IS NULL AND expires_at > \\$2 ORDER BY created_at DESC").
+		WithArgs(int64(123), now.UTC()).
+		WillReturnRows(sqlmock.NewRows(fileShareColumnNames).
+			AddRow("share-2", 123, 7, "team.csv", 0, 0, now.Add(time.Hour), nil, now).
+			AddRow("share-1", 123, 0, "q1.csv", 3, 1, now.Add(time.Hour), nil, now))
+
+	shares, err := repo.ListByUserID(context.Background(), 123, now)
+	assert.NoError(t, err)
+	assert.Len(t, shares, 2)
+	assert.Equal(t, int64(7), shares[0].TeamID)
+	assert.Nil(t, shares[0].RevokedAt)
+	assert.Equal(t, "q1.csv", shares[1].Path)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFileShareRepository_ListByTeamID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFileShareRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT (.+) FROM file_shares WHERE team_id = \\$1 AND revoked_at IS NULL AND expires_at > \\$2 ORDER BY created_at DESC").
+		WithArgs(int64(7), now.UTC()).
+		WillReturnRows(sqlmock.NewRows(fileShareColumnNames).
+			AddRow("share-2", 456, 7, "b.csv", 0, 0, now.Add(time.Hour), nil, now).
+			AddRow("share-1", 123, 7, "a.csv", 0, 0, now.Add(time.Hour), nil, now))
+
+	shares, err := repo.ListByTeamID(context.Background(), 7, now)
+	assert.NoError(t, err)
+	assert.Len(t, shares, 2)
+	assert.Equal(t, int64(456), shares[0].UserID)
+	assert.Equal(t, entity.Owner{UserID: 123, TeamID: 7}, shares[1].Owner())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFileShareRepository_Revoke(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFileShareRepository(db)
+	ctx := context.Background()
+
+	t.Run("revoked", func(t *testing.T) {
+		mock.ExpectExec("UPDATE file_shares SET revoked_at = NOW\\(\\) WHERE id = \\$1 AND revoked_at IS NULL").
+			WithArgs("share-id").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		revoked, err := repo.Revoke(ctx, "share-id")
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectExec("UPDATE file_shares SET revoked_at").
+			WithArgs("share-id").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		revoked, err := repo.Revoke(ctx, "share-id")
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFileShareRepository_RevokeByMember(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFileShareRepository(db)
+
+	mock.ExpectExec("UPDATE file_shares SET revoked_at = NOW\\(\\) WHERE team_id = \\$1 AND user_id = \\$2 AND revoked_at IS NULL").
+		WithArgs(int64(7), int64(123)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = repo.RevokeByMember(context.Background(), 7, 123)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFileShareRepository_CountDownload(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFileShareRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("counted", func(t *testing.T) {
+		mock.ExpectExec("UPDATE file_shares SET downloads = downloads \\+ 1 WHERE id = \\$1 AND revoked_at IS NULL AND expires_at > \\$2 AND \\(max_downloads = 0 OR downloads < max_downloads\\)").
+			WithArgs("share-id", now.UTC()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		counted, err := repo.CountDownload(ctx, "share-id", now)
+		assert.NoError(t, err)
+		assert.True(t, counted)
+	})
+
+	t.Run("no downloads left", func(t *testing.T) {
+		mock.ExpectExec("UPDATE file_shares SET downloads").
+			WithArgs("share-id", now.UTC()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		counted, err := repo.CountDownload(ctx, "share-id", now)
+		assert.NoError(t, err)
+		assert.False(t, counted)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"container-manager/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileRepository_Save(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFileRepository(db)
+	uploadedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sum := "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+
+	t.Run("user file", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO files \\(user_id, team_id, uploaded_by, path, sha256, size, content_type, uploaded_at\\)\\s+VALUES \\(NULLIF\\(\\$1, 0\\), NULLIF\\(\\$2, 0\\), NULLIF\\(\\$3, 0\\), \\$4, \\$5, \\$6, \\$7, \\$8\\)\\s+ON CONFLICT \\(user_id, path\\) WHERE team_id IS NULL DO UPDATE SET").
+			WithArgs(int64(1), int64(0), int64(1), "docs/hello.txt", sum, int64(11), "text/plain", uploadedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Save(context.Background(), &entity.FileRecord{
+			Owner: entity.Owner{UserID: 1}, UploadedBy: 1, Path: "docs/hello.txt", SHA256: sum, Size: 11, ContentType: "text/plain", UploadedAt: uploadedAt,
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("team file", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO files (.+) ON CONFLICT \\(team_id, path\\) WHERE team_id IS NOT NULL DO UPDATE SET").
+			WithArgs(int64(0), int64(10), int64(1), "docs/hello.txt", sum, int64(11), "text/plain", uploadedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Save(context.Background(), &entity.FileRecord{
+			Owner: entity.Owner{UserID: 1, TeamID: 10}, UploadedBy: 1, Path: "docs/hello.txt", SHA256: sum, Size: 11, ContentType: "text/plain", UploadedAt: uploadedAt,
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFileRepository_Delete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFileRepository(db)
+
+	uploadedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("DELETE FROM files WHERE team_id = \\$1 AND \\(path = \\$2 OR starts_with\\(path, \\$3\\)\\)\\s+RETURNING COALESCE\\(uploaded_by, 0\\), path").
+		WithArgs(int64(10), "docs", "docs/").
+		WillReturnRows(sqlmock.NewRows([]string{"uploaded_by", "path", "sha256", "size", "content_type", "uploaded_at"}).
+			AddRow(1, "docs/a.txt", "sum-a", 11, "text/plain", uploadedAt).
+			AddRow(0, "docs/b.txt", "sum-b", 5, "text/plain", uploadedAt))
+
+	records, err := repo.Delete(context.Background(), entity.Owner{UserID: 1, TeamID: 10}, "docs")
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, int64(1), records[0].UploadedBy)
+	assert.Equal(t, int64(11), records[0].Size)
+	assert.Equal(t, int64(0), records[1].UploadedBy)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFileRepository_Move(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFileRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM files WHERE user_id = \\$1 AND \\(path = \\$2 OR starts_with\\(path, \\$3\\)\\)").
+		WithArgs(int64(1), "archive/docs", "archive/docs/").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE files SET path = \\$4 \\|\\| substr\\(path, \\$5\\) WHERE user_id = \\$1 AND \\(path = \\$2 OR starts_with\\(path, \\$3\\)\\)").
+		WithArgs(int64(1), "docs", "docs/", "archive/docs", 5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	err = repo.Move(context.Background(), entity.Owner{UserID: 1}, "docs", "archive/docs")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 
 	"container-manager/internal/domain/entity"
@@ -18,25 +19,58 @@ func NewJobRepository(db *sql.DB) infrastructure.JobRepository {
 }
 
 func (r *jobRepository) Create(ctx context.Context, job *entity.Job) error {
-	_, err := r.db.ExecContext(ctx, "INSERT INTO jobs (id, type, status, payload, user_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+	_, err := r.db.ExecContext(ctx, "INSERT INTO jobs (id, type, status, payload, user_id, team_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), $7, $8)",
 		job.ID,
 		job.Type,
 		job.Status,
 		job.Payload,
 		job.UserID,
+		job.TeamID,
 		job.CreatedAt,
 		job.UpdatedAt,
 	)
 	return err
 }
 
+const jobColumns = "id, type, status, payload, result, error, progress, user_id, COALESCE(team_id, 0), created_at, updated_at"
+
 func (r *jobRepository) GetByID(ctx context.Context, id string) (*entity.Job, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE id = $1", id)
+	job, err := scanJob(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+func (r *jobRepository) ListByUserID(ctx context.Context, userID int64, limit int) ([]*entity.Job, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE user_id = $1 ORDER BY created_at DESC, id LIMIT $2", userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []*entity.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func scanJob(row interface{ Scan(dest ...any) error }) (*entity.Job, error) {
 	job := &entity.Job{}
 	var result []byte
 	var payload []byte
 	var errStr sql.NullString
+	var progress []byte
 
-	row := r.db.QueryRowContext(ctx, "SELECT id, type, status, payload, result, error, user_id, created_at, updated_at FROM jobs WHERE id = $1", id)
 	err := row.Scan(
 		&job.ID,
 		&job.Type,
@@ -44,14 +78,13 @@ func (r *jobRepository) GetByID(ctx context.Context, id string) (*entity.Job, er
 		&payload,
 		&result,
 		&errStr,
+		&progress,
 		&job.UserID,
+		&job.TeamID,
 		&job.CreatedAt,
 		&job.UpdatedAt,
 	)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
-		}
 		return nil, err
 	}
 
@@ -64,17 +97,39 @@ func (r *jobRepository) GetByID(ctx context.Context, id string) (*entity.Job, er
 	if errStr.Valid {
 		job.Error = errStr.String
 	}
+	if progress != nil {
+		job.Progress = &entity.JobProgress{}
+		if err := json.Unmarshal(progress, job.Progress); err != nil {
+			return nil, err
+		}
+	}
 
 	return job, nil
 }
 
 func (r *jobRepository) Update(ctx context.Context, job *entity.Job) error {
-	_, err := r.db.ExecContext(ctx, "UPDATE jobs SET status = $2, result = $3, error = $4, updated_at = $5 WHERE id = $1",
+	var progress []byte
+	if job.Progress != nil {
+		var err error
+		if progress, err = json.Marshal(job.Progress); err != nil {
+			return err
+		}
+	}
+	_, err := r.db.ExecContext(ctx, "UPDATE jobs SET status = $2, result = $3, error = $4, progress = $5, updated_at = $6 WHERE id = $1",
 		job.ID,
 		job.Status,
 		job.Result,
 		job.Error,
+		progress,
 		job.UpdatedAt,
 	)
 	return err
 }
+
+func (r *jobRepository) DeleteByUserID(ctx context.Context, userID int64, exceptID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM jobs WHERE user_id = $1 AND id <> $2", userID, exceptID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -35,7 +35,7 @@ func TestJobRepository_Create(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		mock.ExpectExec("INSERT INTO jobs").
-			WithArgs(job.ID, job.Type, job.Status, job.Payload, job.UserID, job.CreatedAt, job.UpdatedAt).
+			WithArgs(job.ID, job.Type, job.Status, job.Payload, job.UserID, job.TeamID, job.CreatedAt, job.UpdatedAt).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		err := repo.Create(ctx, job)
@@ -44,7 +44,7 @@ func TestJobRepository_Create(t *testing.T) {
 
 	t.Run("failure", func(t *testing.T) {
 		mock.ExpectExec("INSERT INTO jobs").
-			WithArgs(job.ID, job.Type, job.Status, job.Payload, job.UserID, job.CreatedAt, job.UpdatedAt).
+			WithArgs(job.ID, job.Type, job.Status, job.Payload, job.UserID, job.TeamID, job.CreatedAt, job.UpdatedAt).
 			WillReturnError(errors.New("db error"))
 
 		err := repo.Create(ctx, job)
@@ -77,10 +77,10 @@ func TestJobRepository_GetByID(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 	
-rows := sqlmock.NewRows([]string{"id", "type", "status", "payload", "result", "error", "user_id", "created_at", "updated_at"}).
-			AddRow(job.ID, job.Type, job.Status, job.Payload, job.Result, job.Error, job.UserID, job.CreatedAt, job.UpdatedAt)
+rows := sqlmock.NewRows([]string{"id", "type", "status", "payload", "result", "error", "progress", "user_id", "team_id", "created_at", "updated_at"}).
+			AddRow(job.ID, job.Type, job.Status, job.Payload, job.Result, job.Error, nil, job.UserID, job.TeamID, job.CreatedAt, job.UpdatedAt)
 
-		mock.ExpectQuery("SELECT id, type, status, payload, result, error, user_id, created_at, updated_at FROM jobs WHERE id = \\$1").
+		mock.ExpectQuery("SELECT id, type, status, payload, result, error, progress, user_id, COALESCE\\(team_id, 0\\), created_at, updated_at FROM jobs WHERE id = \\$1").
 			WithArgs(job.ID).
 			WillReturnRows(rows)
 
@@ -91,7 +91,7 @@ rows := sqlmock.NewRows([]string{"id", "type", "status", "payload", "result", "e
 	})
 
 	t.Run("not found", func(t *testing.T) {
-		mock.ExpectQuery("SELECT id, type, status, payload, result, error, user_id, created_at, updated_at FROM jobs WHERE id = \\$1").
+		mock.ExpectQuery("SELECT id, type, status, payload, result, error, progress, user_id, COALESCE\\(team_id, 0\\), created_at, updated_at FROM jobs WHERE id = \\$1").
 			WithArgs("non-existent").
 			WillReturnError(sql.ErrNoRows)
 
@@ -101,7 +101,7 @@ rows := sqlmock.NewRows([]string{"id", "type", "status", "payload", "result", "e
 	})
 
 	t.Run("db error", func(t *testing.T) {
-		mock.ExpectQuery("SELECT id, type, status, payload, result, error, user_id, created_at, updated_at FROM jobs WHERE id = \\$1").
+		mock.ExpectQuery("SELECT id, type, status, payload, result, error, progress, user_id, COALESCE\\(team_id, 0\\), created_at, updated_at FROM jobs WHERE id = \\$1").
 			WithArgs(job.ID).
 			WillReturnError(errors.New("db error"))
 
@@ -131,8 +131,8 @@ func TestJobRepository_Update(t *testing.T) {
 	}
 
 	t.Run("success", func(t *testing.T) {
-		mock.ExpectExec("UPDATE jobs SET status = \\$2, result = \\$3, error = \\$4, updated_at = \\$5 WHERE id = \\$1").
-			WithArgs(job.ID, job.Status, job.Result, job.Error, job.UpdatedAt).
+		mock.ExpectExec("UPDATE jobs SET status = \\$2, result = \\$3, error = \\$4, progress = \\$5, updated_at = \\$6 WHERE id = \\$1").
+			WithArgs(job.ID, job.Status, job.Result, job.Error, []byte(nil), job.UpdatedAt).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		err := repo.Update(ctx, job)
@@ -140,8 +140,8 @@ func TestJobRepository_Update(t *testing.T) {
 	})
 
 	t.Run("failure", func(t *testing.T) {
-		mock.ExpectExec("UPDATE jobs SET status = \\$2, result = \\$3, error = \\$4, updated_at = \\$5 WHERE id = \\$1").
-			WithArgs(job.ID, job.Status, job.Result, job.Error, job.UpdatedAt).
+		mock.ExpectExec("UPDATE jobs SET status = \\$2, result = \\$3, error = \\$4, progress = \\$5, updated_at = \\$6 WHERE id = \\$1").
+			WithArgs(job.ID, job.Status, job.Result, job.Error, []byte(nil), job.UpdatedAt).
 			WillReturnError(errors.New("db error"))
 
 		err := repo.Update(ctx, job)
@@ -150,3 +150,67 @@ func TestJobRepository_Update(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestJobRepository_ListByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewJobRepository(db)
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{"id", "type", "status", "payload", "result", "error", "progress", "user_id", "team_id", "created_at", "updated_at"}).
+		AddRow("job-2", "container_creation", "failed", []byte("{}"), nil, "boom", nil, int64(123), 0, now, now).
+		AddRow("job-1", "container_creation", "completed", []byte("{}"), []byte(`{"container_id":"c1"}`), nil, []byte(`{"step":"done","done":1,"total":1}`), int64(123), 0, now, now)
+	mock.ExpectQuery("SELECT id, type, status, payload, result, error, progress, user_id, COALESCE\\(team_id, 0\\), created_at, updated_at FROM jobs WHERE user_id = \\$1 ORDER BY created_at DESC, id LIMIT \\$2").
+		WithArgs(int64(123), 50).
+		WillReturnRows(rows)
+
+	jobs, err := repo.ListByUserID(context.Background(), 123, 50)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+	assert.Equal(t, "job-2", jobs[0].ID)
+	assert.Equal(t, "boom", jobs[0].Error)
+	assert.Equal(t, json.RawMessage(`{"container_id":"c1"}`), jobs[1].Result)
+	assert.Nil(t, jobs[0].Progress)
+	assert.Equal(t, &entity.JobProgress{Step: "done", Done: 1, Total: 1}, jobs[1].Progress)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobRepository_UpdateProgress(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewJobRepository(db)
+	job := &entity.Job{
+		ID:        "job-1",
+		Status:    entity.JobStatusRunning,
+		Progress:  &entity.JobProgress{Step: "containers", Done: 1, Total: 3},
+		UpdatedAt: time.Now(),
+	}
+
+	mock.ExpectExec("UPDATE jobs SET").
+		WithArgs(job.ID, job.Status, job.Result, job.Error, []byte(`{"step":"containers","done":1,"total":3}`), job.UpdatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.Update(context.Background(), job))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobRepository_DeleteByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewJobRepository(db)
+
+	mock.ExpectExec("DELETE FROM jobs WHERE user_id = \\$1 AND id <> \\$2").
+		WithArgs(int64(123), "job-1").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	deleted, err := repo.DeleteByUserID(context.Background(), 123, "job-1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
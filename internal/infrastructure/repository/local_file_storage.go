@@ -1,16 +1,41 @@
 package repository
 
 import (
+	"container-manager/internal/domain/entity"
 	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
 	"io"
+	"io/fs"
+	"mime"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
 var _ infrastructure.FileStorage = (*LocalFileStorage)(nil)
 
+const (
+	// tempFilePrefix names the files written in the UploadsFolder before they are placed, apart
+	// from incomplete uploads, which are named by their ID.
+	tempFilePrefix = "tmp-"
+	// staleTempFileAge is how old a temporary file has to be for CollectGarbage to remove it.
+	staleTempFileAge = 24 * time.Hour
+)
+
 // LocalFileStorage implements the FileStorage interface for local disk storage.
+//
+// Files are resolved with os.Root, which opens every path element relative to the owner's
+// folder like openat(2). Paths that leave the folder, also through a symbolic link, fail
+// instead of reaching other files on the disk.
 type LocalFileStorage struct {
 	basePath string
 }
@@ -22,26 +47,721 @@ func NewLocalFileStorage(basePath string) infrastructure.FileStorage {
 	}
 }
 
-// SaveFile saves the given file content to the local disk within the user's folder.
-func (s *LocalFileStorage) SaveFile(userID int64, filename string, fileContent io.Reader) (string, error) {
-	userDir := filepath.Join(s.basePath, strconv.FormatInt(userID, 10))
+// ownerDir is the folder of the owner: basePath/<user ID> for personal files, and
+// basePath/teams/<team ID> for the files of a team.
+func (s *LocalFileStorage) ownerDir(owner entity.Owner) string {
+	if owner.TeamID != 0 {
+		return filepath.Join(s.basePath, "teams", strconv.FormatInt(owner.TeamID, 10))
+	}
+	return filepath.Join(s.basePath, strconv.FormatInt(owner.UserID, 10))
+}
 
-	// Create user directory if it doesn't exist
-	if err := os.MkdirAll(userDir, 0755); err != nil {
-		return "", err
+// openRoot opens the owner's folder, creating it first if create is set. It returns nil if
+// the folder does not exist.
+func (s *LocalFileStorage) openRoot(owner entity.Owner, create bool) (*os.Root, error) {
+	ownerDir := s.ownerDir(owner)
+	if create {
+		if err := os.MkdirAll(ownerDir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	root, err := os.OpenRoot(ownerDir)
+	if err != nil {
+		if stderrors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
 	}
+	return root, nil
+}
 
-	filePath := filepath.Join(userDir, filename)
-	outFile, err := os.Create(filePath)
+// resolve cleans a client supplied file name into a path within the owner's folder, in the
+// form os.Root expects.
+func resolve(filename string) (string, error) {
+	name, err := entity.CleanFilePath(filename)
 	if err != nil {
 		return "", err
 	}
-	defer outFile.Close()
+	return filepath.FromSlash(name), nil
+}
+
+// SaveFile saves the given file content to the local disk within the owner's folder, creating
+// the folders of its path. Existing regular files are replaced, anything else in the way such as
+// a folder, a symbolic link or a device is not. The content is written to a temporary file first,
+// so that the file is only replaced once the content is complete.
+func (s *LocalFileStorage) SaveFile(owner entity.Owner, filename string, fileContent io.Reader) (*entity.FileInfo, error) {
+	name, err := resolve(filename)
+	if err != nil {
+		return nil, err
+	}
+	root, err := s.openRoot(owner, true)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	if err := checkTarget(root, name); err != nil {
+		return nil, err
+	}
+	tempName, sum, err := writeTemp(root, fileContent)
+	if err != nil {
+		return nil, err
+	}
+	return place(root, tempName, sum, name)
+}
+
+// FileSize returns the size of a file in the owner's folder, or zero if the file does not exist.
+func (s *LocalFileStorage) FileSize(owner entity.Owner, filename string) (int64, error) {
+	info, err := s.StatFile(owner, filename)
+	if err != nil || info == nil {
+		return 0, err
+	}
+	return info.Size, nil
+}
+
+// ListFiles returns the regular files and folders in a folder of the owner. Symbolic links and
+// other special files are left out. fs.WalkDir reads each folder sorted by name, and does not
+// follow links.
+func (s *LocalFileStorage) ListFiles(owner entity.Owner, dir string, recursive bool) ([]*entity.FileInfo, error) {
+	name := "."
+	if dir != "" {
+		var err error
+		if name, err = resolve(dir); err != nil {
+			return nil, err
+		}
+	}
+	root, err := s.openRoot(owner, false)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		// The owner's folder is only created with the first file.
+		if dir == "" {
+			return []*entity.FileInfo{}, nil
+		}
+		return nil, nil
+	}
+	defer root.Close()
+
+	if info, err := root.Lstat(name); err != nil {
+		if notExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, nil
+	}
+
+	start := filepath.ToSlash(name)
+	files := []*entity.FileInfo{}
+	err = fs.WalkDir(root.FS(), start, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// The entry was removed since its folder was read.
+			if filePath != start && notExist(err) {
+				return nil
+			}
+			return err
+		}
+		if filePath == start || !(entry.Type().IsRegular() || entry.IsDir()) {
+			return nil
+		}
+		if filePath == entity.UploadsFolder || filePath == entity.BlobsFolder {
+			return fs.SkipDir
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if notExist(err) {
+				return nil
+			}
+			return err
+		}
+		files = append(files, newFileInfo(filePath, info))
+		if entry.IsDir() && !recursive {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// StatFile describes a regular file in the owner's folder.
+func (s *LocalFileStorage) StatFile(owner entity.Owner, filename string) (*entity.FileInfo, error) {
+	name, err := resolve(filename)
+	if err != nil {
+		return nil, err
+	}
+	root, err := s.openRoot(owner, false)
+	if err != nil || root == nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	info, err := root.Stat(name)
+	if err != nil {
+		if notExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, nil
+	}
+	return newFileInfo(name, info), nil
+}
+
+// OpenFile opens a regular file in the owner's folder. The file is described as it was opened,
+// so the description matches the content read even if the file is replaced meanwhile.
+func (s *LocalFileStorage) OpenFile(owner entity.Owner, filename string) (io.ReadSeekCloser, *entity.FileInfo, error) {
+	name, err := resolve(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	root, err := s.openRoot(owner, false)
+	if err != nil || root == nil {
+		return nil, nil, err
+	}
+	defer root.Close()
+
+	// Opening a named pipe would block, so special files are ruled out before.
+	if info, err := root.Stat(name); err != nil {
+		if notExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	} else if !info.Mode().IsRegular() {
+		return nil, nil, nil
+	}
+
+	file, err := root.Open(name)
+	if err != nil {
+		if notExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		file.Close()
+		return nil, nil, nil
+	}
+	return file, newFileInfo(name, info), nil
+}
+
+// DeleteFile removes a file from the owner's folder. A symbolic link is removed itself, and
+// folders are not removed.
+func (s *LocalFileStorage) DeleteFile(owner entity.Owner, filename string) error {
+	name, err := resolve(filename)
+	if err != nil {
+		return err
+	}
+	root, err := s.openRoot(owner, false)
+	if err != nil || root == nil {
+		return err
+	}
+	defer root.Close()
+
+	info, err := root.Lstat(name)
+	if err != nil {
+		if notExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.IsDir() {
+		return errors.NotRegularFile
+	}
+	err = root.Remove(name)
+	if err != nil && !notExist(err) {
+		return err
+	}
+	return nil
+}
+
+// MakeDir creates a folder in the owner's folder along with its parents.
+func (s *LocalFileStorage) MakeDir(owner entity.Owner, dir string) error {
+	name, err := resolve(dir)
+	if err != nil {
+		return err
+	}
+	root, err := s.openRoot(owner, true)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	return mkdirAll(root, name)
+}
+
+// Move renames a regular file or a folder within the owner's folder. The new path is checked
+// before the rename, as renameat(2) would silently replace a file there.
+func (s *LocalFileStorage) Move(owner entity.Owner, from, to string) error {
+	fromName, err := resolve(from)
+	if err != nil {
+		return err
+	}
+	toName, err := resolve(to)
+	if err != nil {
+		return err
+	}
+	root, err := s.openRoot(owner, false)
+	if err != nil {
+		return err
+	}
+	if root == nil {
+		return errors.FileNotFound
+	}
+	defer root.Close()
+
+	info, err := root.Lstat(fromName)
+	if err != nil {
+		if notExist(err) {
+			return errors.FileNotFound
+		}
+		return err
+	}
+	if !info.Mode().IsRegular() && !info.IsDir() {
+		return errors.NotRegularFile
+	}
+	if _, err := root.Lstat(toName); err == nil {
+		return errors.FileExists
+	} else if !notExist(err) {
+		return err
+	}
+
+	if err := mkdirParent(root, toName); err != nil {
+		return err
+	}
+	err = root.Rename(fromName, toName)
+	if stderrors.Is(err, syscall.EINVAL) {
+		return errors.InvalidFilename.New("the new path is the old one or inside of it")
+	}
+	return err
+}
+
+// DeleteDir removes a folder from the owner's folder. A recursive delete removes symbolic links
+// in the folder, not what they point to.
+func (s *LocalFileStorage) DeleteDir(owner entity.Owner, dir string, recursive bool) error {
+	name, err := resolve(dir)
+	if err != nil {
+		return err
+	}
+	root, err := s.openRoot(owner, false)
+	if err != nil || root == nil {
+		return err
+	}
+	defer root.Close()
+
+	info, err := root.Lstat(name)
+	if err != nil {
+		if notExist(err) {
+			return nil
+		}
+		return err
+	}
+	if !info.IsDir() {
+		return errors.NotAFolder
+	}
+
+	if recursive {
+		return root.RemoveAll(name)
+	}
+	err = root.Remove(name)
+	if stderrors.Is(err, syscall.ENOTEMPTY) || stderrors.Is(err, syscall.EEXIST) {
+		return errors.FolderNotEmpty
+	}
+	if err != nil && !notExist(err) {
+		return err
+	}
+	return nil
+}
+
+// RemoveAll removes the owner's folder with everything in it.
+func (s *LocalFileStorage) RemoveAll(owner entity.Owner) error {
+	return os.RemoveAll(s.ownerDir(owner))
+}
+
+// uploadPath is the path of the incomplete upload id in the owner's folder.
+func uploadPath(id string) (string, error) {
+	if !entity.IsUploadID(id) {
+		return "", errors.UploadNotFound
+	}
+	return filepath.Join(entity.UploadsFolder, id), nil
+}
 
-	_, err = io.Copy(outFile, fileContent)
+// WriteUpload writes to the file of an incomplete upload, which is kept in the owner's folder so
+// that CommitUpload can rename it into place.
+func (s *LocalFileStorage) WriteUpload(owner entity.Owner, id string, offset int64, content io.Reader) (int64, error) {
+	name, err := uploadPath(id)
+	if err != nil {
+		return 0, err
+	}
+	root, err := s.openRoot(owner, true)
+	if err != nil {
+		return 0, err
+	}
+	defer root.Close()
+
+	if err := root.MkdirAll(entity.UploadsFolder, 0755); err != nil {
+		return 0, err
+	}
+	file, err := root.OpenFile(name, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	// Truncating a shorter file would fill the gap with zeros.
+	if info.Size() < offset {
+		return 0, fmt.Errorf("upload %s has %d bytes, expected at least %d", id, info.Size(), offset)
+	}
+	if err := file.Truncate(offset); err != nil {
+		return 0, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(file, content)
+}
+
+// CommitUpload renames the file of a complete upload to its path, which replaces a regular file
+// there at once. The file is read once to compute its checksum.
+func (s *LocalFileStorage) CommitUpload(owner entity.Owner, id, filename string) (*entity.FileInfo, error) {
+	uploadName, err := uploadPath(id)
+	if err != nil {
+		return nil, err
+	}
+	name, err := resolve(filename)
+	if err != nil {
+		return nil, err
+	}
+	root, err := s.openRoot(owner, false)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, errors.UploadNotFound
+	}
+	defer root.Close()
+
+	sum, err := syncAndHash(root, uploadName)
+	if err != nil {
+		if notExist(err) {
+			return nil, errors.UploadNotFound
+		}
+		return nil, err
+	}
+	if err := checkTarget(root, name); err != nil {
+		return nil, err
+	}
+	return place(root, uploadName, sum, name)
+}
+
+// DeleteUpload removes the file of an incomplete upload.
+func (s *LocalFileStorage) DeleteUpload(owner entity.Owner, id string) error {
+	name, err := uploadPath(id)
+	if err != nil {
+		return err
+	}
+	root, err := s.openRoot(owner, false)
+	if err != nil || root == nil {
+		return err
+	}
+	defer root.Close()
+
+	err = root.Remove(name)
+	if err != nil && !notExist(err) {
+		return err
+	}
+	return nil
+}
+
+// CollectGarbage removes, in the folder of every owner, the content in the BlobsFolder that no
+// file links to anymore, and the temporary files of writes that never finished.
+func (s *LocalFileStorage) CollectGarbage() error {
+	dirs, err := ownerDirs(s.basePath)
+	if err != nil {
+		return err
+	}
+	teamDirs, err := ownerDirs(filepath.Join(s.basePath, "teams"))
+	if err != nil {
+		return err
+	}
+	for _, dir := range append(dirs, teamDirs...) {
+		if err := collectGarbage(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ownerDirs returns the folders of the owners in parent, which are named by their IDs.
+func ownerDirs(parent string) ([]string, error) {
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		if stderrors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if _, err := strconv.ParseInt(entry.Name(), 10, 64); err == nil && entry.IsDir() {
+			dirs = append(dirs, filepath.Join(parent, entry.Name()))
+		}
+	}
+	return dirs, nil
+}
+
+// collectGarbage collects the garbage in the folder of one owner. Content is garbage once the
+// BlobsFolder holds its only link. A file may link to it again right after it was checked, which
+// only means that the content is not deduplicated anymore.
+func collectGarbage(dir string) error {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		if stderrors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer root.Close()
+
+	err = fs.WalkDir(root.FS(), entity.BlobsFolder, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if notExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if notExist(err) {
+				return nil
+			}
+			return err
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok && stat.Nlink == 1 {
+			if err := root.Remove(name); err != nil && !notExist(err) {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	entries, err := fs.ReadDir(root.FS(), entity.UploadsFolder)
+	if err != nil {
+		if notExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), tempFilePrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if notExist(err) {
+				continue
+			}
+			return err
+		}
+		if time.Since(info.ModTime()) > staleTempFileAge {
+			if err := root.Remove(filepath.Join(entity.UploadsFolder, entry.Name())); err != nil && !notExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkTarget prepares name to be written: it creates the folders of its path, and fails with
+// errors.NotRegularFile if something other than a regular file is in the way.
+func checkTarget(root *os.Root, name string) error {
+	if err := mkdirParent(root, name); err != nil {
+		return err
+	}
+	if info, err := root.Lstat(name); err == nil && !info.Mode().IsRegular() {
+		return errors.NotRegularFile
+	} else if err != nil && !notExist(err) {
+		return err
+	}
+	return nil
+}
+
+// writeTemp writes content to a new temporary file in the UploadsFolder, and returns its name and
+// the checksum of the content. The file is synced to disk, so that it is complete once renamed.
+func writeTemp(root *os.Root, content io.Reader) (string, string, error) {
+	if err := root.MkdirAll(entity.UploadsFolder, 0755); err != nil {
+		return "", "", err
+	}
+	name := filepath.Join(entity.UploadsFolder, tempFilePrefix+rand.Text())
+	file, err := root.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", "", err
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, hash), content)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = root.Remove(name)
+		return "", "", err
+	}
+	return name, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// syncAndHash syncs a file that was written to disk, and returns the checksum of its content.
+func syncAndHash(root *os.Root, name string) (string, error) {
+	file, err := root.Open(name)
 	if err != nil {
 		return "", err
 	}
+	defer file.Close()
 
-	return filePath, nil
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	if err := file.Sync(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// place renames the complete file src, whose content has the checksum sum, to name, and describes
+// it. The content of each owner is kept once in the BlobsFolder, named by its checksum, and files
+// are hard links to it: src becomes the stored content, or if the owner stores the same content
+// already, name links to it and src is dropped. Files are never written to once placed, only
+// replaced, so that writing one file cannot change the others.
+func place(root *os.Root, src, sum, name string) (*entity.FileInfo, error) {
+	blob := filepath.Join(entity.BlobsFolder, sum[:2], sum)
+	if err := root.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+		_ = root.Remove(src)
+		return nil, err
+	}
+	file := src
+	if err := root.Link(src, blob); stderrors.Is(err, fs.ErrExist) {
+		link := src + ".link"
+		if err := root.Link(blob, link); err == nil {
+			_ = root.Remove(src)
+			file = link
+			// The shared modification time tells when the content was saved last.
+			now := time.Now()
+			if err := root.Chtimes(blob, now, now); err != nil {
+				_ = root.Remove(file)
+				return nil, err
+			}
+		} else if !notExist(err) {
+			_ = root.Remove(src)
+			return nil, err
+		}
+		// Otherwise the stored content was just collected as garbage, and src is placed as is.
+	} else if err != nil {
+		_ = root.Remove(src)
+		return nil, err
+	}
+
+	if err := root.Rename(file, name); err != nil {
+		_ = root.Remove(file)
+		return nil, err
+	}
+	// Renaming a link over another link to the same content does nothing, and leaves it behind.
+	if err := root.Remove(file); err != nil && !notExist(err) {
+		return nil, err
+	}
+	if err := syncDir(root, filepath.Dir(name)); err != nil {
+		return nil, err
+	}
+
+	info, err := root.Lstat(name)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo := newFileInfo(name, info)
+	fileInfo.SHA256 = sum
+	return fileInfo, nil
+}
+
+// syncDir syncs a folder to disk, so that a file renamed into it stays there after a crash.
+func syncDir(root *os.Root, dir string) error {
+	file, err := root.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
+
+// mkdirAll creates a folder and its parents in root. A file in the way is reported as
+// errors.NotAFolder.
+func mkdirAll(root *os.Root, name string) error {
+	err := root.MkdirAll(name, 0755)
+	if stderrors.Is(err, syscall.ENOTDIR) || stderrors.Is(err, fs.ErrExist) {
+		return errors.NotAFolder
+	}
+	return err
+}
+
+// mkdirParent creates the folder a file is to be written to.
+func mkdirParent(root *os.Root, name string) error {
+	parent := filepath.Dir(name)
+	if parent == "." {
+		return nil
+	}
+	return mkdirAll(root, parent)
+}
+
+// notExist reports whether err means that a path does not exist, also when one of its parents
+// is a file rather than a folder.
+func notExist(err error) bool {
+	return stderrors.Is(err, fs.ErrNotExist) || stderrors.Is(err, syscall.ENOTDIR)
+}
+
+// newFileInfo describes a file or folder on disk by its path in the owner's folder. The ETag is
+// derived from the modification time and size, which change whenever the file is written.
+func newFileInfo(name string, info fs.FileInfo) *entity.FileInfo {
+	if info.IsDir() {
+		return &entity.FileInfo{
+			Name:    filepath.ToSlash(name),
+			IsDir:   true,
+			ModTime: info.ModTime(),
+		}
+	}
+	return &entity.FileInfo{
+		Name:        filepath.ToSlash(name),
+		Size:        info.Size(),
+		ModTime:     info.ModTime(),
+		ContentType: contentTypeOf(info.Name()),
+		ETag:        fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()),
+	}
+}
+
+// contentTypeOf guesses the content type of a file from its extension.
+func contentTypeOf(name string) string {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return contentType
 }
@@ -2,11 +2,20 @@ package repository
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
+	"syscall"
 	"testing"
+	"time"
+
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/errors"
 )
 
 func TestLocalFileStorage_SaveFile(t *testing.T) {
@@ -26,16 +35,17 @@ func TestLocalFileStorage_SaveFile(t *testing.T) {
 	reader := bytes.NewBufferString(fileContent)
 
 	// Save the file
-	savedPath, err := storage.SaveFile(userID, filename, reader)
+	info, err := storage.SaveFile(entity.Owner{UserID: userID}, filename, reader)
 	if err != nil {
 		t.Fatalf("SaveFile failed: %v", err)
 	}
-
-	// Verify the saved path
-	expectedPath := filepath.Join(tempDir, strconv.FormatInt(userID, 10), filename)
-	if savedPath != expectedPath {
-		t.Errorf("expected saved path %s, got %s", expectedPath, savedPath)
+	if info.Name != filename || info.Size != int64(len(fileContent)) {
+		t.Errorf("expected %s of %d bytes, got %+v", filename, len(fileContent), info)
 	}
+	if want := fmt.Sprintf("%x", sha256.Sum256([]byte(fileContent))); info.SHA256 != want {
+		t.Errorf("expected checksum %s, got %s", want, info.SHA256)
+	}
+	savedPath := filepath.Join(tempDir, strconv.FormatInt(userID, 10), filename)
 
 	// Verify the file exists
 	if _, err := os.Stat(savedPath); os.IsNotExist(err) {
@@ -57,15 +67,660 @@ func TestLocalFileStorage_SaveFile(t *testing.T) {
 	fileContent2 := "Another test content."
 	reader2 := bytes.NewBufferString(fileContent2)
 
-	savedPath2, err := storage.SaveFile(userID2, filename2, reader2)
-	if err != nil {
+	if _, err := storage.SaveFile(entity.Owner{UserID: userID2}, filename2, reader2); err != nil {
 		t.Fatalf("SaveFile for new user failed: %v", err)
 	}
-	expectedPath2 := filepath.Join(tempDir, strconv.FormatInt(userID2, 10), filename2)
-	if savedPath2 != expectedPath2 {
-		t.Errorf("expected saved path for new user %s, got %s", expectedPath2, savedPath2)
+	if _, err := os.Stat(filepath.Join(tempDir, strconv.FormatInt(userID2, 10), filename2)); err != nil {
+		t.Errorf("saved file for new user does not exist: %v", err)
 	}
 	if _, err := os.Stat(filepath.Join(tempDir, strconv.FormatInt(userID2, 10))); os.IsNotExist(err) {
 		t.Errorf("user directory for %d was not created", userID2)
 	}
+
+	// Files of a team are kept apart from the personal files of its members
+	if _, err := storage.SaveFile(entity.Owner{UserID: userID, TeamID: 77}, filename, bytes.NewBufferString("team")); err != nil {
+		t.Fatalf("SaveFile for a team failed: %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(tempDir, "teams", "77", filename)); err != nil || string(content) != "team" {
+		t.Errorf("expected the team file, got %q, %v", content, err)
+	}
+	readContent, err = os.ReadFile(savedPath)
+	if err != nil || string(readContent) != fileContent {
+		t.Errorf("saving a team file changed the personal file: %q, %v", readContent, err)
+	}
+}
+
+func TestLocalFileStorage_SaveFile_Deduplicates(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := NewLocalFileStorage(tempDir)
+	owner := entity.Owner{UserID: 123}
+
+	for _, name := range []string{"a.txt", "docs/b.txt", "a.txt"} {
+		if _, err := storage.SaveFile(owner, name, bytes.NewBufferString("same content")); err != nil {
+			t.Fatalf("SaveFile(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := storage.SaveFile(entity.Owner{UserID: 456}, "a.txt", bytes.NewBufferString("same content")); err != nil {
+		t.Fatalf("SaveFile for another user failed: %v", err)
+	}
+
+	// Both files of the owner link to the content stored once, that of another owner does not
+	a, _ := os.Stat(filepath.Join(tempDir, "123", "a.txt"))
+	b, _ := os.Stat(filepath.Join(tempDir, "123", "docs", "b.txt"))
+	other, _ := os.Stat(filepath.Join(tempDir, "456", "a.txt"))
+	if !os.SameFile(a, b) || os.SameFile(a, other) {
+		t.Errorf("expected the content to be shared by the files of the owner only")
+	}
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte("same content")))
+	blob, err := os.Stat(filepath.Join(tempDir, "123", entity.BlobsFolder, sum[:2], sum))
+	if err != nil || !os.SameFile(a, blob) {
+		t.Errorf("expected the content to be stored by its checksum: %v", err)
+	}
+	// No temporary files are left behind
+	if entries, err := os.ReadDir(filepath.Join(tempDir, "123", entity.UploadsFolder)); err != nil || len(entries) != 0 {
+		t.Errorf("expected no temporary files, got %v, %v", entries, err)
+	}
+
+	// Replacing a file does not change the others
+	if _, err := storage.SaveFile(owner, "a.txt", bytes.NewBufferString("new content")); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(tempDir, "123", "docs", "b.txt")); err != nil || string(content) != "same content" {
+		t.Errorf("expected the other file to keep its content, got %q, %v", content, err)
+	}
+
+	// Content is collected once no file links to it anymore
+	if err := storage.DeleteFile(owner, "docs/b.txt"); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+	stale := filepath.Join(tempDir, "123", entity.UploadsFolder, tempFilePrefix+"stale")
+	if err := os.WriteFile(stale, []byte("partial"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	old := time.Now().Add(-2 * staleTempFileAge)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+	if err := storage.CollectGarbage(); err != nil {
+		t.Fatalf("CollectGarbage failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "123", entity.BlobsFolder, sum[:2], sum)); !os.IsNotExist(err) {
+		t.Errorf("expected unused content to be collected: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("expected a stale temporary file to be collected: %v", err)
+	}
+	newSum := fmt.Sprintf("%x", sha256.Sum256([]byte("new content")))
+	if _, err := os.Stat(filepath.Join(tempDir, "123", entity.BlobsFolder, newSum[:2], newSum)); err != nil {
+		t.Errorf("expected used content to be kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "456", entity.BlobsFolder, sum[:2], sum)); err != nil {
+		t.Errorf("expected the content of another owner to be kept: %v", err)
+	}
+}
+
+func TestLocalFileStorage_FileSize(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := NewLocalFileStorage(tempDir)
+
+	userID := int64(123)
+	if _, err := storage.SaveFile(entity.Owner{UserID: userID}, "testfile.txt", bytes.NewBufferString("12345")); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+
+	size, err := storage.FileSize(entity.Owner{UserID: userID}, "testfile.txt")
+	if err != nil {
+		t.Fatalf("FileSize failed: %v", err)
+	}
+	if size != 5 {
+		t.Errorf("expected size 5, got %d", size)
+	}
+
+	size, err = storage.FileSize(entity.Owner{UserID: userID}, "missing.txt")
+	if err != nil {
+		t.Fatalf("FileSize failed for a missing file: %v", err)
+	}
+	if size != 0 {
+		t.Errorf("expected size 0 for a missing file, got %d", size)
+	}
+}
+
+func TestLocalFileStorage_RemoveAll(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := NewLocalFileStorage(tempDir)
+	owner := entity.Owner{UserID: 123}
+	teamOwner := entity.Owner{UserID: 123, TeamID: 77}
+
+	for _, o := range []entity.Owner{owner, teamOwner} {
+		if _, err := storage.SaveFile(o, "testfile.txt", bytes.NewBufferString("content")); err != nil {
+			t.Fatalf("SaveFile failed: %v", err)
+		}
+	}
+
+	if err := storage.RemoveAll(owner); err != nil {
+		t.Fatalf("RemoveAll failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "123")); !os.IsNotExist(err) {
+		t.Errorf("user directory still exists")
+	}
+	// The files of the user's teams are kept
+	if size, err := storage.FileSize(teamOwner, "testfile.txt"); err != nil || size == 0 {
+		t.Errorf("team file was removed: size %d, err %v", size, err)
+	}
+
+	// Removing again succeeds
+	if err := storage.RemoveAll(owner); err != nil {
+		t.Errorf("RemoveAll of a missing directory failed: %v", err)
+	}
+}
+
+func TestLocalFileStorage_ListFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := NewLocalFileStorage(tempDir)
+	owner := entity.Owner{UserID: 123}
+
+	files, err := storage.ListFiles(owner, "", false)
+	if err != nil {
+		t.Fatalf("ListFiles of a missing directory failed: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected no files, got %d", len(files))
+	}
+
+	for _, name := range []string{"b.json", "a.txt", "c"} {
+		if _, err := storage.SaveFile(owner, name, bytes.NewBufferString(name)); err != nil {
+			t.Fatalf("SaveFile failed: %v", err)
+		}
+	}
+	// Folders are listed without their content, links are not listed
+	if _, err := storage.SaveFile(owner, "folder/d.txt", bytes.NewBufferString("d")); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	if err := os.Symlink("a.txt", filepath.Join(tempDir, "123", "link")); err != nil {
+		t.Fatalf("Symlink failed: %v", err)
+	}
+
+	files, err = storage.ListFiles(owner, "", false)
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if len(files) != 4 {
+		t.Fatalf("expected 4 files, got %d", len(files))
+	}
+	if files[3].Name != "folder" || !files[3].IsDir || files[3].Size != 0 {
+		t.Errorf("expected the folder last, got %+v", files[3])
+	}
+	expected := []struct {
+		name, contentType string
+		size              int64
+	}{
+		{"a.txt", "text/plain; charset=utf-8", 5},
+		{"b.json", "application/json", 6},
+		{"c", "application/octet-stream", 1},
+	}
+	for i, e := range expected {
+		if files[i].Name != e.name || files[i].ContentType != e.contentType || files[i].Size != e.size {
+			t.Errorf("file %d: expected %s %s %d, got %+v", i, e.name, e.contentType, e.size, files[i])
+		}
+		if files[i].ModTime.IsZero() || files[i].ETag == "" {
+			t.Errorf("file %d has no modification time or ETag: %+v", i, files[i])
+		}
+	}
+}
+
+func TestLocalFileStorage_ListFiles_Folders(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := NewLocalFileStorage(tempDir)
+	owner := entity.Owner{UserID: 123}
+
+	for _, name := range []string{"top.txt", "docs/a.txt", "docs/sub/b.txt", "docs/sub/deeper/c.txt"} {
+		if _, err := storage.SaveFile(owner, name, bytes.NewBufferString(name)); err != nil {
+			t.Fatalf("SaveFile(%q) failed: %v", name, err)
+		}
+	}
+
+	names := func(files []*entity.FileInfo) []string {
+		result := make([]string, 0, len(files))
+		for _, file := range files {
+			result = append(result, file.Name)
+		}
+		return result
+	}
+
+	tests := []struct {
+		dir       string
+		recursive bool
+		want      []string
+	}{
+		{"docs", false, []string{"docs/a.txt", "docs/sub"}},
+		{"docs/sub", false, []string{"docs/sub/b.txt", "docs/sub/deeper"}},
+		{"docs", true, []string{"docs/a.txt", "docs/sub", "docs/sub/b.txt", "docs/sub/deeper", "docs/sub/deeper/c.txt"}},
+		{"", true, []string{"docs", "docs/a.txt", "docs/sub", "docs/sub/b.txt", "docs/sub/deeper", "docs/sub/deeper/c.txt", "top.txt"}},
+	}
+	for _, tt := range tests {
+		files, err := storage.ListFiles(owner, tt.dir, tt.recursive)
+		if err != nil {
+			t.Fatalf("ListFiles(%q, %v) failed: %v", tt.dir, tt.recursive, err)
+		}
+		if got := names(files); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ListFiles(%q, %v) = %v, want %v", tt.dir, tt.recursive, got, tt.want)
+		}
+	}
+
+	// Missing folders, and files, are not listed
+	for _, dir := range []string{"missing", "top.txt", "top.txt/x"} {
+		files, err := storage.ListFiles(owner, dir, false)
+		if err != nil || files != nil {
+			t.Errorf("ListFiles(%q) returned %v, %v", dir, files, err)
+		}
+	}
+	if _, err := storage.ListFiles(owner, "../124", false); err == nil {
+		t.Error("ListFiles of another user's folder succeeded")
+	}
+}
+
+func TestLocalFileStorage_MakeDir(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := NewLocalFileStorage(tempDir)
+	owner := entity.Owner{UserID: 123}
+
+	if err := storage.MakeDir(owner, "reports/2024"); err != nil {
+		t.Fatalf("MakeDir failed: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(tempDir, "123", "reports", "2024")); err != nil || !info.IsDir() {
+		t.Errorf("folder was not created: %v", err)
+	}
+	// Creating it again succeeds
+	if err := storage.MakeDir(owner, "reports/2024"); err != nil {
+		t.Errorf("MakeDir of an existing folder failed: %v", err)
+	}
+
+	if _, err := storage.SaveFile(owner, "a.txt", bytes.NewBufferString("a")); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	for _, dir := range []string{"a.txt", "a.txt/sub"} {
+		if err := storage.MakeDir(owner, dir); err != errors.NotAFolder {
+			t.Errorf("MakeDir(%q): expected %v, got %v", dir, errors.NotAFolder, err)
+		}
+	}
+	// Uploading below a file fails the same way
+	if _, err := storage.SaveFile(owner, "a.txt/b.txt", bytes.NewBufferString("b")); err != errors.NotAFolder {
+		t.Errorf("expected %v, got %v", errors.NotAFolder, err)
+	}
+	if err := storage.MakeDir(owner, "../124"); err == nil {
+		t.Error("MakeDir outside of the owner's folder succeeded")
+	}
+}
+
+func TestLocalFileStorage_Move(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := NewLocalFileStorage(tempDir)
+	owner := entity.Owner{UserID: 123}
+
+	for _, name := range []string{"a.txt", "b.txt", "docs/c.txt"} {
+		if _, err := storage.SaveFile(owner, name, bytes.NewBufferString(name)); err != nil {
+			t.Fatalf("SaveFile(%q) failed: %v", name, err)
+		}
+	}
+
+	t.Run("file into a new folder", func(t *testing.T) {
+		if err := storage.Move(owner, "a.txt", "archive/2024/a.txt"); err != nil {
+			t.Fatalf("Move failed: %v", err)
+		}
+		if size, _ := storage.FileSize(owner, "a.txt"); size != 0 {
+			t.Error("the old path still exists")
+		}
+		if size, _ := storage.FileSize(owner, "archive/2024/a.txt"); size != 5 {
+			t.Errorf("expected the moved file to have 5 bytes, got %d", size)
+		}
+	})
+
+	t.Run("folder", func(t *testing.T) {
+		if err := storage.Move(owner, "docs", "archive/docs"); err != nil {
+			t.Fatalf("Move failed: %v", err)
+		}
+		if size, _ := storage.FileSize(owner, "archive/docs/c.txt"); size != 10 {
+			t.Errorf("the content of the folder was not moved")
+		}
+	})
+
+	t.Run("nothing is replaced", func(t *testing.T) {
+		if err := storage.Move(owner, "b.txt", "archive/2024/a.txt"); err != errors.FileExists {
+			t.Errorf("expected %v, got %v", errors.FileExists, err)
+		}
+		if size, _ := storage.FileSize(owner, "b.txt"); size != 5 {
+			t.Error("the file was moved anyway")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if err := storage.Move(owner, "missing.txt", "x.txt"); err != errors.FileNotFound {
+			t.Errorf("expected %v, got %v", errors.FileNotFound, err)
+		}
+		if err := storage.Move(entity.Owner{UserID: 124}, "a.txt", "x.txt"); err != errors.FileNotFound {
+			t.Errorf("expected %v for an owner without files, got %v", errors.FileNotFound, err)
+		}
+	})
+
+	t.Run("into itself", func(t *testing.T) {
+		if err := storage.Move(owner, "archive", "archive/inner"); err == nil {
+			t.Error("moving a folder into itself succeeded")
+		}
+	})
+
+	t.Run("out of the folder", func(t *testing.T) {
+		if err := storage.Move(owner, "b.txt", "../124/b.txt"); err == nil {
+			t.Error("moving out of the owner's folder succeeded")
+		}
+		if _, err := os.Stat(filepath.Join(tempDir, "124")); !os.IsNotExist(err) {
+			t.Error("the other owner's folder was created")
+		}
+	})
+}
+
+func TestLocalFileStorage_DeleteDir(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := NewLocalFileStorage(tempDir)
+	owner := entity.Owner{UserID: 123}
+	outside := filepath.Join(tempDir, "outside")
+	if err := os.MkdirAll(outside, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "keep.txt"), []byte("keep"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	for _, name := range []string{"a.txt", "docs/b.txt", "docs/sub/c.txt"} {
+		if _, err := storage.SaveFile(owner, name, bytes.NewBufferString(name)); err != nil {
+			t.Fatalf("SaveFile(%q) failed: %v", name, err)
+		}
+	}
+	if err := storage.MakeDir(owner, "empty"); err != nil {
+		t.Fatalf("MakeDir failed: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(tempDir, "123", "docs", "link")); err != nil {
+		t.Fatalf("Symlink failed: %v", err)
+	}
+
+	if err := storage.DeleteDir(owner, "docs", false); err != errors.FolderNotEmpty {
+		t.Errorf("expected %v, got %v", errors.FolderNotEmpty, err)
+	}
+	if err := storage.DeleteDir(owner, "a.txt", true); err != errors.NotAFolder {
+		t.Errorf("expected %v, got %v", errors.NotAFolder, err)
+	}
+	if err := storage.DeleteDir(owner, "empty", false); err != nil {
+		t.Errorf("DeleteDir of an empty folder failed: %v", err)
+	}
+
+	if err := storage.DeleteDir(owner, "docs", true); err != nil {
+		t.Fatalf("DeleteDir failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "123", "docs")); !os.IsNotExist(err) {
+		t.Error("the folder still exists")
+	}
+	// Links are removed, not followed
+	if _, err := os.Stat(filepath.Join(outside, "keep.txt")); err != nil {
+		t.Errorf("the target of a link was removed: %v", err)
+	}
+	if size, _ := storage.FileSize(owner, "a.txt"); size == 0 {
+		t.Error("a file next to the folder was removed")
+	}
+
+	// Deleting again succeeds
+	if err := storage.DeleteDir(owner, "docs", true); err != nil {
+		t.Errorf("DeleteDir of a missing folder failed: %v", err)
+	}
+}
+
+func TestLocalFileStorage_OpenFile(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := NewLocalFileStorage(tempDir)
+	owner := entity.Owner{UserID: 123}
+
+	if _, err := storage.SaveFile(owner, "testfile.txt", bytes.NewBufferString("12345")); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+
+	file, info, err := storage.OpenFile(owner, "testfile.txt")
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil || string(content) != "12345" {
+		t.Errorf("expected content 12345, got %q, %v", content, err)
+	}
+	if info.Size != 5 || info.Name != "testfile.txt" {
+		t.Errorf("unexpected file info %+v", info)
+	}
+
+	statInfo, err := storage.StatFile(owner, "testfile.txt")
+	if err != nil || statInfo.ETag != info.ETag {
+		t.Errorf("StatFile returned %+v, %v, expected ETag %s", statInfo, err, info.ETag)
+	}
+
+	// Missing files and folders are reported as missing
+	if err := os.Mkdir(filepath.Join(tempDir, "123", "folder"), 0755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	for _, name := range []string{"missing.txt", "folder"} {
+		file, info, err := storage.OpenFile(owner, name)
+		if file != nil || info != nil || err != nil {
+			t.Errorf("OpenFile(%s) returned %v, %+v, %v", name, file, info, err)
+		}
+		statInfo, err := storage.StatFile(owner, name)
+		if statInfo != nil || err != nil {
+			t.Errorf("StatFile(%s) returned %+v, %v", name, statInfo, err)
+		}
+	}
+}
+
+func TestLocalFileStorage_DeleteFile(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := NewLocalFileStorage(tempDir)
+	owner := entity.Owner{UserID: 123}
+
+	if _, err := storage.SaveFile(owner, "testfile.txt", bytes.NewBufferString("12345")); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+
+	if err := storage.DeleteFile(owner, "testfile.txt"); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "123", "testfile.txt")); !os.IsNotExist(err) {
+		t.Errorf("file still exists")
+	}
+
+	// Deleting again succeeds
+	if err := storage.DeleteFile(owner, "testfile.txt"); err != nil {
+		t.Errorf("DeleteFile of a missing file failed: %v", err)
+	}
+}
+
+func TestLocalFileStorage_Upload(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := NewLocalFileStorage(tempDir)
+	owner := entity.Owner{UserID: 123}
+	id := "0123456789abcdef0123456789abcdef"
+
+	if n, err := storage.WriteUpload(owner, id, 0, bytes.NewBufferString("01234")); err != nil || n != 5 {
+		t.Fatalf("WriteUpload failed: %d, %v", n, err)
+	}
+	// A chunk written again replaces what was written past its offset
+	if n, err := storage.WriteUpload(owner, id, 3, bytes.NewBufferString("x")); err != nil || n != 1 {
+		t.Fatalf("WriteUpload failed: %d, %v", n, err)
+	}
+	if n, err := storage.WriteUpload(owner, id, 4, bytes.NewBufferString("56789")); err != nil || n != 5 {
+		t.Fatalf("WriteUpload failed: %d, %v", n, err)
+	}
+	if _, err := storage.WriteUpload(owner, id, 20, bytes.NewBufferString("gap")); err == nil {
+		t.Error("expected writing past the end of an upload to fail")
+	}
+
+	// Incomplete uploads are not among the files
+	files, err := storage.ListFiles(owner, "", true)
+	if err != nil || len(files) != 0 {
+		t.Errorf("expected no files, got %v, %v", files, err)
+	}
+	if _, err := storage.StatFile(owner, ".uploads/"+id); err == nil {
+		t.Error("expected the uploads folder to be unreachable")
+	}
+
+	info, err := storage.CommitUpload(owner, id, "data/big.bin")
+	if err != nil {
+		t.Fatalf("CommitUpload failed: %v", err)
+	}
+	if want := fmt.Sprintf("%x", sha256.Sum256([]byte("012x56789"))); info.SHA256 != want || info.Size != 9 {
+		t.Errorf("expected 9 bytes with checksum %s, got %+v", want, info)
+	}
+	content, err := os.ReadFile(filepath.Join(tempDir, "123", "data", "big.bin"))
+	if err != nil || string(content) != "012x56789" {
+		t.Errorf("expected the uploaded content, got %q, %v", content, err)
+	}
+	if _, err := storage.CommitUpload(owner, id, "data/big.bin"); err != errors.UploadNotFound {
+		t.Errorf("expected %v, got %v", errors.UploadNotFound, err)
+	}
+
+	// Not over a folder
+	if _, err := storage.WriteUpload(owner, id, 0, bytes.NewBufferString("x")); err != nil {
+		t.Fatalf("WriteUpload failed: %v", err)
+	}
+	if _, err := storage.CommitUpload(owner, id, "data"); err != errors.NotRegularFile {
+		t.Errorf("expected %v, got %v", errors.NotRegularFile, err)
+	}
+
+	if err := storage.DeleteUpload(owner, id); err != nil {
+		t.Fatalf("DeleteUpload failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "123", ".uploads", id)); !os.IsNotExist(err) {
+		t.Error("upload still exists")
+	}
+	if err := storage.DeleteUpload(owner, id); err != nil {
+		t.Errorf("DeleteUpload of a missing upload failed: %v", err)
+	}
+	if _, err := storage.WriteUpload(owner, "../../etc", 0, bytes.NewBufferString("x")); err != errors.UploadNotFound {
+		t.Errorf("expected an invalid upload ID to be rejected, got %v", err)
+	}
+}
+
+func TestLocalFileStorage_Confinement(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := NewLocalFileStorage(filepath.Join(tempDir, "files"))
+	owner := entity.Owner{UserID: 123}
+	ownerDir := filepath.Join(tempDir, "files", "123")
+	outside := filepath.Join(tempDir, "outside")
+	if err := os.MkdirAll(ownerDir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.MkdirAll(outside, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	// Links planted in the owner's folder, pointing outside of it
+	if err := os.Symlink(outside, filepath.Join(ownerDir, "escape")); err != nil {
+		t.Fatalf("Symlink failed: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(ownerDir, "secret.txt")); err != nil {
+		t.Fatalf("Symlink failed: %v", err)
+	}
+
+	t.Run("dot dot", func(t *testing.T) {
+		for _, name := range []string{"../124/x", "../../outside/x", "/etc/passwd"} {
+			if _, err := storage.SaveFile(owner, name, bytes.NewBufferString("x")); err == nil {
+				t.Errorf("SaveFile(%q) succeeded", name)
+			}
+		}
+	})
+
+	t.Run("links leaving the folder", func(t *testing.T) {
+		if _, err := storage.SaveFile(owner, "escape/x", bytes.NewBufferString("x")); err == nil {
+			t.Error("SaveFile through a link succeeded")
+		}
+		if _, err := os.Stat(filepath.Join(outside, "x")); !os.IsNotExist(err) {
+			t.Error("a file was written outside of the owner's folder")
+		}
+
+		if _, err := storage.SaveFile(owner, "secret.txt", bytes.NewBufferString("overwritten")); err != errors.NotRegularFile {
+			t.Errorf("expected %v when replacing a link, got %v", errors.NotRegularFile, err)
+		}
+		if file, _, err := storage.OpenFile(owner, "secret.txt"); file != nil || err == nil {
+			t.Errorf("OpenFile through a link returned %v, %v", file, err)
+		}
+		if file, _, err := storage.OpenFile(owner, "escape/secret.txt"); file != nil || err == nil {
+			t.Errorf("OpenFile through a link returned %v, %v", file, err)
+		}
+
+		// Deleting removes the link, not its target
+		if err := storage.DeleteFile(owner, "secret.txt"); err != nil {
+			t.Errorf("DeleteFile of a link failed: %v", err)
+		}
+		content, err := os.ReadFile(filepath.Join(outside, "secret.txt"))
+		if err != nil || string(content) != "secret" {
+			t.Errorf("the target of the link changed: %q, %v", content, err)
+		}
+	})
+
+	t.Run("special files", func(t *testing.T) {
+		if err := os.Mkdir(filepath.Join(ownerDir, "folder"), 0755); err != nil {
+			t.Fatalf("Mkdir failed: %v", err)
+		}
+		if _, err := storage.SaveFile(owner, "folder", bytes.NewBufferString("x")); err != errors.NotRegularFile {
+			t.Errorf("expected %v when replacing a folder, got %v", errors.NotRegularFile, err)
+		}
+		if err := storage.DeleteFile(owner, "folder"); err != errors.NotRegularFile {
+			t.Errorf("expected %v when deleting a folder, got %v", errors.NotRegularFile, err)
+		}
+
+		if err := syscall.Mkfifo(filepath.Join(ownerDir, "fifo"), 0644); err != nil {
+			t.Skipf("Mkfifo failed: %v", err)
+		}
+		if _, err := storage.SaveFile(owner, "fifo", bytes.NewBufferString("x")); err != errors.NotRegularFile {
+			t.Errorf("expected %v when writing to a named pipe, got %v", errors.NotRegularFile, err)
+		}
+		if file, info, err := storage.OpenFile(owner, "fifo"); file != nil || info != nil || err != nil {
+			t.Errorf("OpenFile of a named pipe returned %v, %+v, %v", file, info, err)
+		}
+	})
+}
+
+func FuzzLocalFileStorage_SaveFile(f *testing.F) {
+	for _, seed := range []string{"report.txt", "../x", "../../outside/x", "escape/x", "a/../../x", "/tmp/x", `..\x`, "..", ".", ""} {
+		f.Add(seed)
+	}
+
+	tempDir := f.TempDir()
+	storage := NewLocalFileStorage(filepath.Join(tempDir, "files"))
+	owner := entity.Owner{UserID: 123}
+	ownerDir := filepath.Join(tempDir, "files", "123")
+	if err := os.MkdirAll(ownerDir, 0755); err != nil {
+		f.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.Symlink(tempDir, filepath.Join(ownerDir, "escape")); err != nil {
+		f.Fatalf("Symlink failed: %v", err)
+	}
+
+	f.Fuzz(func(t *testing.T, name string) {
+		saved, err := storage.SaveFile(owner, name, bytes.NewBufferString("x"))
+		if err != nil {
+			return
+		}
+		savedPath := filepath.Join(ownerDir, filepath.FromSlash(saved.Name))
+		defer os.Remove(savedPath)
+
+		// Whatever was written is a regular file inside the owner's folder
+		relative, err := filepath.Rel(ownerDir, savedPath)
+		if err != nil || !filepath.IsLocal(relative) {
+			t.Fatalf("SaveFile(%q) wrote %s outside of the owner's folder", name, savedPath)
+		}
+		info, err := os.Lstat(savedPath)
+		if err != nil || !info.Mode().IsRegular() {
+			t.Fatalf("SaveFile(%q) did not write a regular file at %s: %v", name, savedPath, err)
+		}
+		entries, err := os.ReadDir(tempDir)
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("SaveFile(%q) wrote next to the files folder: %v", name, entries)
+		}
+	})
 }
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var _ infrastructure.LoginFailureRepository = (*loginFailureRepository)(nil)
+
+type loginFailureRepository struct {
+	db *sql.DB
+}
+
+func NewLoginFailureRepository(db *sql.DB) infrastructure.LoginFailureRepository {
+	return &loginFailureRepository{db: db}
+}
+
+func (r *loginFailureRepository) Get(ctx context.Context, key string) (*entity.LoginFailures, error) {
+	query := "SELECT key, failures, last_failed_at FROM login_failures WHERE key = $1"
+	failures := &entity.LoginFailures{}
+	err := r.db.QueryRowContext(ctx, query, key).Scan(&failures.Key, &failures.Failures, &failures.LastFailedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return failures, nil
+}
+
+// Reserve compares and counts in one statement, so that of concurrent attempts from several
+// instances seeing the same failures only one counts.
+func (r *loginFailureRepository) Reserve(ctx context.Context, key string, seen *entity.LoginFailures, resetBefore time.Time) (bool, error) {
+	var result sql.Result
+	var err error
+	if seen == nil {
+		query := "INSERT INTO login_failures (key, failures, last_failed_at) VALUES ($1, 1, $2) ON CONFLICT (key) DO NOTHING"
+		result, err = r.db.ExecContext(ctx, query, key, time.Now().UTC())
+	} else {
+		query := `UPDATE login_failures SET
+			failures = CASE WHEN last_failed_at < $3 THEN 1 ELSE failures + 1 END,
+			last_failed_at = $2
+			WHERE key = $1 AND failures = $4 AND last_failed_at = $5`
+		result, err = r.db.ExecContext(ctx, query, key, time.Now().UTC(), resetBefore.UTC(), seen.Failures, seen.LastFailedAt.UTC())
+	}
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *loginFailureRepository) Forgive(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE login_failures SET failures = failures - 1 WHERE key = $1 AND failures > 0", key)
+	return err
+}
+
+func (r *loginFailureRepository) Reset(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM login_failures WHERE key = $1", key)
+	return err
+}
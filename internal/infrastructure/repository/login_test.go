@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"container-manager/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginFailureRepository_Get(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLoginFailureRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT key, failures, last_failed_at FROM login_failures WHERE key = \\$1").
+			WithArgs("account:alice").
+			WillReturnRows(sqlmock.NewRows([]string{"key", "failures", "last_failed_at"}).AddRow("account:alice", 3, now))
+
+		failures, err := repo.Get(ctx, "account:alice")
+		assert.NoError(t, err)
+		assert.Equal(t, &entity.LoginFailures{Key: "account:alice", Failures: 3, LastFailedAt: now}, failures)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM login_failures").
+			WithArgs("ip:10.0.0.1").
+			WillReturnError(sql.ErrNoRows)
+
+		failures, err := repo.Get(ctx, "ip:10.0.0.1")
+		assert.NoError(t, err)
+		assert.Nil(t, failures)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginFailureRepository_Reserve(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLoginFailureRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+	resetBefore := now.Add(-time.Hour)
+
+	t.Run("first failure", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO login_failures \\(key, failures, last_failed_at\\) VALUES \\(\\$1, 1, \\$2\\) ON CONFLICT \\(key\\) DO NOTHING").
+			WithArgs("account:alice", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		reserved, err := repo.Reserve(ctx, "account:alice", nil, resetBefore)
+		assert.NoError(t, err)
+		assert.True(t, reserved)
+	})
+
+	t.Run("counts on the failures seen", func(t *testing.T) {
+		mock.ExpectExec("UPDATE login_failures SET\\s+failures = CASE WHEN last_failed_at < \\$3 THEN 1 ELSE failures \\+ 1 END,\\s+last_failed_at = \\$2\\s+WHERE key = \\$1 AND failures = \\$4 AND last_failed_at = \\$5").
+			WithArgs("account:alice", sqlmock.AnyArg(), resetBefore.UTC(), 2, now.UTC()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		reserved, err := repo.Reserve(ctx, "account:alice", &entity.LoginFailures{Key: "account:alice", Failures: 2, LastFailedAt: now}, resetBefore)
+		assert.NoError(t, err)
+		assert.True(t, reserved)
+	})
+
+	t.Run("counted by another attempt", func(t *testing.T) {
+		mock.ExpectExec("UPDATE login_failures SET").
+			WithArgs("account:alice", sqlmock.AnyArg(), resetBefore.UTC(), 2, now.UTC()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		reserved, err := repo.Reserve(ctx, "account:alice", &entity.LoginFailures{Key: "account:alice", Failures: 2, LastFailedAt: now}, resetBefore)
+		assert.NoError(t, err)
+		assert.False(t, reserved)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginFailureRepository_Forgive(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLoginFailureRepository(db)
+
+	mock.ExpectExec("UPDATE login_failures SET failures = failures - 1 WHERE key = \\$1 AND failures > 0").
+		WithArgs("ip:10.0.0.1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Forgive(context.Background(), "ip:10.0.0.1")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginFailureRepository_Reset(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLoginFailureRepository(db)
+
+	mock.ExpectExec("DELETE FROM login_failures WHERE key = \\$1").
+		WithArgs("account:alice").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Reset(context.Background(), "account:alice")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var _ infrastructure.MFARepository = (*mfaRepository)(nil)
+
+type mfaRepository struct {
+	db *sql.DB
+}
+
+func NewMFARepository(db *sql.DB) infrastructure.MFARepository {
+	return &mfaRepository{db: db}
+}
+
+func (r *mfaRepository) Get(ctx context.Context, userID int64) (*entity.UserMFA, error) {
+	query := "SELECT user_id, secret, enabled_at, last_used_step, failed_attempts, last_failed_at, created_at FROM user_mfa WHERE user_id = $1"
+	mfa := &entity.UserMFA{}
+	var enabledAt, lastFailedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&mfa.UserID,
+		&mfa.Secret,
+		&enabledAt,
+		&mfa.LastUsedStep,
+		&mfa.FailedAttempts,
+		&lastFailedAt,
+		&mfa.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if enabledAt.Valid {
+		mfa.EnabledAt = &enabledAt.Time
+	}
+	if lastFailedAt.Valid {
+		mfa.LastFailedAt = &lastFailedAt.Time
+	}
+	return mfa, nil
+}
+
+func (r *mfaRepository) SavePending(ctx context.Context, userID int64, secret string) error {
+	query := `INSERT INTO user_mfa (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, failed_attempts = 0, last_failed_at = NULL, created_at = NOW()
+		WHERE user_mfa.enabled_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, userID, secret)
+	return err
+}
+
+func (r *mfaRepository) Enable(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "UPDATE user_mfa SET enabled_at = $2, last_used_step = $3, failed_attempts = 0 WHERE user_id = $1 AND enabled_at IS NULL", userID, time.Now().UTC(), step)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return false, err
+	}
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, hash); err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *mfaRepository) UseStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	query := "UPDATE user_mfa SET last_used_step = $2, failed_attempts = 0 WHERE user_id = $1 AND last_used_step < $2"
+	result, err := r.db.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	query := `WITH used AS (
+			UPDATE recovery_codes SET used_at = $3 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL RETURNING user_id
+		)
+		UPDATE user_mfa SET failed_attempts = 0 WHERE user_id IN (SELECT user_id FROM used)`
+	result, err := r.db.ExecContext(ctx, query, userID, codeHash, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *mfaRepository) RecordFailure(ctx context.Context, userID int64) error {
+	query := "UPDATE user_mfa SET failed_attempts = failed_attempts + 1, last_failed_at = $2 WHERE user_id = $1"
+	_, err := r.db.ExecContext(ctx, query, userID, time.Now().UTC())
+	return err
+}
+
+func (r *mfaRepository) Delete(ctx context.Context, userID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_mfa WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"container-manager/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMFARepository_Get(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMFARepository(db)
+	ctx := context.Background()
+	now := time.Now()
+	columns := []string{"user_id", "secret", "enabled_at", "last_used_step", "failed_attempts", "last_failed_at", "created_at"}
+
+	t.Run("enabled", func(t *testing.T) {
+		mock.ExpectQuery("SELECT user_id, secret, enabled_at, last_used_step, failed_attempts, last_failed_at, created_at FROM user_mfa WHERE user_id = \\$1").
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(1), "SECRET", now, int64(42), 2, now, now))
+
+		mfa, err := repo.Get(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, &entity.UserMFA{UserID: 1, Secret: "SECRET", EnabledAt: &now, LastUsedStep: 42, FailedAttempts: 2, LastFailedAt: &now, CreatedAt: now}, mfa)
+	})
+
+	t.Run("pending", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM user_mfa").
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(1), "SECRET", nil, int64(0), 0, nil, now))
+
+		mfa, err := repo.Get(ctx, 1)
+		assert.NoError(t, err)
+		assert.False(t, mfa.Enabled())
+		assert.Nil(t, mfa.LastFailedAt)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM user_mfa").
+			WithArgs(int64(1)).
+			WillReturnError(sql.ErrNoRows)
+
+		mfa, err := repo.Get(ctx, 1)
+		assert.NoError(t, err)
+		assert.Nil(t, mfa)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMFARepository_SavePending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMFARepository(db)
+
+	mock.ExpectExec("INSERT INTO user_mfa \\(user_id, secret\\) VALUES \\(\\$1, \\$2\\)\\s+ON CONFLICT \\(user_id\\) DO UPDATE (.+) WHERE user_mfa.enabled_at IS NULL").
+		WithArgs(int64(1), "SECRET").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.SavePending(context.Background(), 1, "SECRET")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMFARepository_Enable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMFARepository(db)
+	ctx := context.Background()
+
+	t.Run("enabled", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE user_mfa SET enabled_at = \\$2, last_used_step = \\$3, failed_attempts = 0 WHERE user_id = \\$1 AND enabled_at IS NULL").
+			WithArgs(int64(1), sqlmock.AnyArg(), int64(42)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM recovery_codes WHERE user_id = \\$1").
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec("INSERT INTO recovery_codes \\(user_id, code_hash\\)").
+			WithArgs(int64(1), "hash1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO recovery_codes \\(user_id, code_hash\\)").
+			WithArgs(int64(1), "hash2").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		enabled, err := repo.Enable(ctx, 1, 42, []string{"hash1", "hash2"})
+		assert.NoError(t, err)
+		assert.True(t, enabled)
+	})
+
+	t.Run("not pending", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE user_mfa SET enabled_at").
+			WithArgs(int64(1), sqlmock.AnyArg(), int64(42)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		enabled, err := repo.Enable(ctx, 1, 42, []string{"hash1"})
+		assert.NoError(t, err)
+		assert.False(t, enabled)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMFARepository_UseStep(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMFARepository(db)
+	ctx := context.Background()
+
+	mock.ExpectExec("UPDATE user_mfa SET last_used_step = \\$2, failed_attempts = 0 WHERE user_id = \\$1 AND last_used_step < \\$2").
+		WithArgs(int64(1), int64(42)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	used, err := repo.UseStep(ctx, 1, 42)
+	assert.NoError(t, err)
+	assert.True(t, used)
+
+	mock.ExpectExec("UPDATE user_mfa SET last_used_step").
+		WithArgs(int64(1), int64(42)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	used, err = repo.UseStep(ctx, 1, 42)
+	assert.NoError(t, err)
+	assert.False(t, used)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMFARepository_UseRecoveryCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMFARepository(db)
+	ctx := context.Background()
+
+	mock.ExpectExec("UPDATE recovery_codes SET used_at = \\$3 WHERE user_id = \\$1 AND code_hash = \\$2 AND used_at IS NULL").
+		WithArgs(int64(1), "hash", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	used, err := repo.UseRecoveryCode(ctx, 1, "hash")
+	assert.NoError(t, err)
+	assert.True(t, used)
+
+	mock.ExpectExec("UPDATE recovery_codes SET used_at").
+		WithArgs(int64(1), "hash", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	used, err = repo.UseRecoveryCode(ctx, 1, "hash")
+	assert.NoError(t, err)
+	assert.False(t, used)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMFARepository_RecordFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMFARepository(db)
+
+	mock.ExpectExec("UPDATE user_mfa SET failed_attempts = failed_attempts \\+ 1, last_failed_at = \\$2 WHERE user_id = \\$1").
+		WithArgs(int64(1), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.RecordFailure(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMFARepository_Delete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMFARepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM recovery_codes WHERE user_id = \\$1").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 10))
+	mock.ExpectExec("DELETE FROM user_mfa WHERE user_id = \\$1").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.Delete(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
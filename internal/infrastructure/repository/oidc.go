@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var _ infrastructure.OIDCLoginStateRepository = (*oidcLoginStateRepository)(nil)
+var _ infrastructure.UserIdentityRepository = (*userIdentityRepository)(nil)
+
+type oidcLoginStateRepository struct {
+	db *sql.DB
+}
+
+func NewOIDCLoginStateRepository(db *sql.DB) infrastructure.OIDCLoginStateRepository {
+	return &oidcLoginStateRepository{db: db}
+}
+
+// Create also drops expired states, as logins that were abandoned are never taken.
+func (r *oidcLoginStateRepository) Create(ctx context.Context, state *entity.OIDCLoginState) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM oidc_login_states WHERE expires_at < $1", time.Now().UTC()); err != nil {
+		return err
+	}
+	query := "INSERT INTO oidc_login_states (state, provider, code_verifier, nonce, expires_at) VALUES ($1, $2, $3, $4, $5)"
+	_, err := r.db.ExecContext(ctx, query, state.State, state.Provider, state.CodeVerifier, state.Nonce, state.ExpiresAt.UTC())
+	return err
+}
+
+func (r *oidcLoginStateRepository) Take(ctx context.Context, state string) (*entity.OIDCLoginState, error) {
+	query := "DELETE FROM oidc_login_states WHERE state = $1 RETURNING state, provider, code_verifier, nonce, expires_at"
+	loginState := &entity.OIDCLoginState{}
+	err := r.db.QueryRowContext(ctx, query, state).Scan(
+		&loginState.State,
+		&loginState.Provider,
+		&loginState.CodeVerifier,
+		&loginState.Nonce,
+		&loginState.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return loginState, nil
+}
+
+type userIdentityRepository struct {
+	db *sql.DB
+}
+
+func NewUserIdentityRepository(db *sql.DB) infrastructure.UserIdentityRepository {
+	return &userIdentityRepository{db: db}
+}
+
+func (r *userIdentityRepository) Find(ctx context.Context, provider, subject string) (*entity.UserIdentity, error) {
+	query := "SELECT provider, subject, user_id, created_at FROM user_identities WHERE provider = $1 AND subject = $2"
+	identity := &entity.UserIdentity{}
+	err := r.db.QueryRowContext(ctx, query, provider, subject).Scan(&identity.Provider, &identity.Subject, &identity.UserID, &identity.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return identity, nil
+}
+
+func (r *userIdentityRepository) Create(ctx context.Context, identity *entity.UserIdentity) error {
+	query := "INSERT INTO user_identities (provider, subject, user_id) VALUES ($1, $2, $3)"
+	_, err := r.db.ExecContext(ctx, query, identity.Provider, identity.Subject, identity.UserID)
+	return err
+}
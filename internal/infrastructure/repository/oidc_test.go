@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"container-manager/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDCLoginStateRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOIDCLoginStateRepository(db)
+	expiresAt := time.Now().Add(10 * time.Minute)
+	state := &entity.OIDCLoginState{State: "state", Provider: "company", CodeVerifier: "verifier", Nonce: "nonce", ExpiresAt: expiresAt}
+
+	mock.ExpectExec("DELETE FROM oidc_login_states WHERE expires_at < \\$1").
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO oidc_login_states \\(state, provider, code_verifier, nonce, expires_at\\)").
+		WithArgs("state", "company", "verifier", "nonce", expiresAt.UTC()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Create(context.Background(), state)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOIDCLoginStateRepository_Take(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOIDCLoginStateRepository(db)
+	ctx := context.Background()
+	expiresAt := time.Now()
+
+	t.Run("found", func(t *testing.T) {
+		mock.ExpectQuery("DELETE FROM oidc_login_states WHERE state = \\$1 RETURNING state, provider, code_verifier, nonce, expires_at").
+			WithArgs("state").
+			WillReturnRows(sqlmock.NewRows([]string{"state", "provider", "code_verifier", "nonce", "expires_at"}).
+				AddRow("state", "company", "verifier", "nonce", expiresAt))
+
+		state, err := repo.Take(ctx, "state")
+		assert.NoError(t, err)
+		assert.Equal(t, &entity.OIDCLoginState{State: "state", Provider: "company", CodeVerifier: "verifier", Nonce: "nonce", ExpiresAt: expiresAt}, state)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectQuery("DELETE FROM oidc_login_states").
+			WithArgs("state").
+			WillReturnError(sql.ErrNoRows)
+
+		state, err := repo.Take(ctx, "state")
+		assert.NoError(t, err)
+		assert.Nil(t, state)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserIdentityRepository_Find(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserIdentityRepository(db)
+	ctx := context.Background()
+	createdAt := time.Now()
+
+	t.Run("found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT provider, subject, user_id, created_at FROM user_identities WHERE provider = \\$1 AND subject = \\$2").
+			WithArgs("company", "subject").
+			WillReturnRows(sqlmock.NewRows([]string{"provider", "subject", "user_id", "created_at"}).AddRow("company", "subject", 123, createdAt))
+
+		identity, err := repo.Find(ctx, "company", "subject")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(123), identity.UserID)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM user_identities").
+			WithArgs("company", "subject").
+			WillReturnError(sql.ErrNoRows)
+
+		identity, err := repo.Find(ctx, "company", "subject")
+		assert.NoError(t, err)
+		assert.Nil(t, identity)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserIdentityRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserIdentityRepository(db)
+
+	mock.ExpectExec("INSERT INTO user_identities \\(provider, subject, user_id\\) VALUES \\(\\$1, \\$2, \\$3\\)").
+		WithArgs("company", "subject", int64(123)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Create(context.Background(), &entity.UserIdentity{Provider: "company", Subject: "subject", UserID: 123})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// LoadBreachedPasswords reads a list of leaked passwords, one per line, for entity.PasswordPolicy.
+// Blank lines and lines starting with # are skipped, and passwords are lowercased.
+func LoadBreachedPasswords(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	passwords := map[string]struct{}{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return passwords, nil
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadBreachedPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	err := os.WriteFile(path, []byte("# top passwords\r\n123456\r\nPassword1\r\n\r\nqwerty\n"), 0644)
+	assert.NoError(t, err)
+
+	passwords, err := LoadBreachedPasswords(path)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{"123456": {}, "password1": {}, "qwerty": {}}, passwords)
+
+	_, err = LoadBreachedPasswords(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
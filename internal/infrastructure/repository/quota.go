@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	internalErrors "container-manager/internal/errors"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+var _ infrastructure.QuotaRepository = (*quotaRepository)(nil)
+
+type quotaRepository struct {
+	db *sql.DB
+}
+
+func NewQuotaRepository(db *sql.DB) infrastructure.QuotaRepository {
+	return &quotaRepository{db: db}
+}
+
+func (r *quotaRepository) GetLimits(ctx context.Context, userID int64) (*entity.QuotaResources, error) {
+	query := "SELECT containers, running_containers, memory_bytes, nano_cpus, storage_bytes FROM user_quotas WHERE user_id = $1"
+	limits, err := scanQuotaResources(r.db.QueryRowContext(ctx, query, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return limits, err
+}
+
+func (r *quotaRepository) GetUsage(ctx context.Context, userID int64) (*entity.QuotaResources, error) {
+	query := "SELECT containers, running_containers, memory_bytes, nano_cpus, storage_bytes FROM quota_usage WHERE user_id = $1"
+	usage, err := scanQuotaResources(r.db.QueryRowContext(ctx, query, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return &entity.QuotaResources{}, nil
+	}
+	return usage, err
+}
+
+// Reserve relies on the conditional UPDATE being atomic, so that concurrent reservations
+// can never push the usage over the limits together.
+func (r *quotaRepository) Reserve(ctx context.Context, userID int64, delta entity.QuotaResources, limits entity.QuotaResources) error {
+	insert := "INSERT INTO quota_usage (user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING"
+	if _, err := r.db.ExecContext(ctx, insert, userID); err != nil {
+		return err
+	}
+
+	// A resource is only checked when it is requested and limited, see entity.QuotaResources.Exceeded.
+	query := `UPDATE quota_usage SET
+		containers = containers + $2,
+		running_containers = running_containers + $3,
+		memory_bytes = memory_bytes + $4,
+		nano_cpus = nano_cpus + $5,
+		storage_bytes = storage_bytes + $6
+	WHERE user_id = $1
+		AND ($2 = 0 OR $7 = 0 OR containers + $2 <= $7)
+		AND ($3 = 0 OR $8 = 0 OR running_containers + $3 <= $8)
+		AND ($4 = 0 OR $9 = 0 OR memory_bytes + $4 <= $9)
+		AND ($5 = 0 OR $10 = 0 OR nano_cpus + $5 <= $10)
+		AND ($6 = 0 OR $11 = 0 OR storage_bytes + $6 <= $11)`
+	result, err := r.db.ExecContext(ctx, query, userID,
+		delta.Containers, delta.RunningContainers, delta.MemoryBytes, delta.NanoCPUs, delta.StorageBytes,
+		limits.Containers, limits.RunningContainers, limits.MemoryBytes, limits.NanoCPUs, limits.StorageBytes,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return internalErrors.QuotaExceeded
+	}
+	return nil
+}
+
+func (r *quotaRepository) Release(ctx context.Context, userID int64, delta entity.QuotaResources) error {
+	query := `UPDATE quota_usage SET
+		containers = GREATEST(containers - $2, 0),
+		running_containers = GREATEST(running_containers - $3, 0),
+		memory_bytes = GREATEST(memory_bytes - $4, 0),
+		nano_cpus = GREATEST(nano_cpus - $5, 0),
+		storage_bytes = GREATEST(storage_bytes - $6, 0)
+	WHERE user_id = $1`
+	_, err := r.db.ExecContext(ctx, query, userID,
+		delta.Containers, delta.RunningContainers, delta.MemoryBytes, delta.NanoCPUs, delta.StorageBytes,
+	)
+	return err
+}
+
+func scanQuotaResources(row *sql.Row) (*entity.QuotaResources, error) {
+	resources := &entity.QuotaResources{}
+	err := row.Scan(&resources.Containers, &resources.RunningContainers, &resources.MemoryBytes, &resources.NanoCPUs, &resources.StorageBytes)
+	if err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
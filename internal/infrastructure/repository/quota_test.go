@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"container-manager/internal/domain/entity"
+	internalErrors "container-manager/internal/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var quotaColumns = []string{"containers", "running_containers", "memory_bytes", "nano_cpus", "storage_bytes"}
+
+func TestQuotaRepository_GetLimits(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewQuotaRepository(db)
+	ctx := context.Background()
+	userID := int64(123)
+
+	t.Run("custom limits", func(t *testing.T) {
+		mock.ExpectQuery("SELECT containers, running_containers, memory_bytes, nano_cpus, storage_bytes FROM user_quotas WHERE user_id = \\$1").
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows(quotaColumns).AddRow(5, 2, 1024, 1000, 4096))
+
+		limits, err := repo.GetLimits(ctx, userID)
+		assert.NoError(t, err)
+		assert.Equal(t, &entity.QuotaResources{Containers: 5, RunningContainers: 2, MemoryBytes: 1024, NanoCPUs: 1000, StorageBytes: 4096}, limits)
+	})
+
+	t.Run("no custom limits", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM user_quotas").
+			WithArgs(userID).
+			WillReturnError(sql.ErrNoRows)
+
+		limits, err := repo.GetLimits(ctx, userID)
+		assert.NoError(t, err)
+		assert.Nil(t, limits)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQuotaRepository_GetUsage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewQuotaRepository(db)
+	ctx := context.Background()
+	userID := int64(123)
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT containers, running_containers, memory_bytes, nano_cpus, storage_bytes FROM quota_usage WHERE user_id = \\$1").
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows(quotaColumns).AddRow(1, 1, 512, 500, 10))
+
+		usage, err := repo.GetUsage(ctx, userID)
+		assert.NoError(t, err)
+		assert.Equal(t, &entity.QuotaResources{Containers: 1, RunningContainers: 1, MemoryBytes: 512, NanoCPUs: 500, StorageBytes: 10}, usage)
+	})
+
+	t.Run("nothing reserved yet", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM quota_usage").
+			WithArgs(userID).
+			WillReturnError(sql.ErrNoRows)
+
+		usage, err := repo.GetUsage(ctx, userID)
+		assert.NoError(t, err)
+		assert.Equal(t, &entity.QuotaResources{}, usage)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQuotaRepository_Reserve(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewQuotaRepository(db)
+	ctx := context.Background()
+	userID := int64(123)
+	delta := entity.QuotaResources{Containers: 1, MemoryBytes: 512}
+	limits := entity.QuotaResources{Containers: 5, MemoryBytes: 1024}
+
+	expectReserve := func() *sqlmock.ExpectedExec {
+		mock.ExpectExec("INSERT INTO quota_usage \\(user_id\\) VALUES \\(\\$1\\) ON CONFLICT \\(user_id\\) DO NOTHING").
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		return mock.ExpectExec("UPDATE quota_usage SET").
+			WithArgs(userID, int64(1), int64(0), int64(512), int64(0), int64(0), int64(5), int64(0), int64(1024), int64(0), int64(0))
+	}
+
+	t.Run("reserved", func(t *testing.T) {
+		expectReserve().WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Reserve(ctx, userID, delta, limits)
+		assert.NoError(t, err)
+	})
+
+	t.Run("exceeded", func(t *testing.T) {
+		expectReserve().WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.Reserve(ctx, userID, delta, limits)
+		assert.Equal(t, internalErrors.QuotaExceeded, err)
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		expectReserve().WillReturnError(sql.ErrConnDone)
+
+		err := repo.Reserve(ctx, userID, delta, limits)
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQuotaRepository_Release(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewQuotaRepository(db)
+	ctx := context.Background()
+	userID := int64(123)
+
+	mock.ExpectExec("UPDATE quota_usage SET(.+)GREATEST\\(containers - \\$2, 0\\)").
+		WithArgs(userID, int64(1), int64(1), int64(512), int64(0), int64(0)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Release(ctx, userID, entity.QuotaResources{Containers: 1, RunningContainers: 1, MemoryBytes: 512})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,1055 @@
+package repository
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
+	"container-manager/internal/infrastructure/s3"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+var _ infrastructure.FileStorage = (*S3FileStorage)(nil)
+
+const (
+	// DefaultS3PartSize is the size of the parts of multipart uploads. Files up to this size are
+	// uploaded in a single request.
+	DefaultS3PartSize = 16 << 20
+	// minS3PartSize is the smallest part S3 accepts but for the last part of an upload.
+	minS3PartSize = 5 << 20
+	// maxS3Parts is the most parts an upload can have.
+	maxS3Parts = 10000
+	// maxS3CopySize is the largest object CopyObject copies, larger ones are copied in parts.
+	maxS3CopySize = 5 << 30
+	// folderContentType marks the empty objects that stand for folders.
+	folderContentType = "application/x-directory"
+	// blobContentType is the content type of blobs, which are not named after a file.
+	blobContentType = "application/octet-stream"
+	// blobMetadata is the user metadata of a file object that holds the checksum of its blob, and
+	// sizeMetadata the one that holds the size of the content.
+	blobMetadata = "sha256"
+	sizeMetadata = "size"
+	// headConcurrency bounds the number of concurrent HEAD requests of a listing.
+	headConcurrency = 16
+)
+
+// S3FileStorage implements the FileStorage interface on an S3 bucket, so that several instances
+// of the service share the same files.
+//
+// The files of each owner are kept below their own key prefix, users/<user ID>/ for personal
+// files and teams/<team ID>/ for the files of a team. Keys are built from paths cleaned by
+// entity.CleanFilePath, which cannot name anything outside of the prefix. S3 has no folders:
+// a folder exists while keys below it do, and MakeDir stores an empty object named after the
+// folder with a trailing slash so that empty folders can exist as well. Moves copy every object
+// before deleting the old ones, so unlike on a local disk they are not atomic.
+//
+// Like on a local disk, the content of each owner is kept once: it is stored as a blob in the
+// reserved entity.BlobsFolder, at .blobs/<first two characters of the checksum>/<checksum> below
+// the owner's prefix, and the object of a file is empty and refers to its blob with its user
+// metadata. Blobs are never written to once stored, so reading a file keeps reading the content
+// it was opened with. Listings have to look up the size of each file with a HEAD request. Objects
+// without the metadata hold their content themselves, as files stored before blobs were.
+type S3FileStorage struct {
+	client      *s3.Client
+	prefix      string
+	partSize    int
+	maxCopySize int64
+	// garbageAge is how old a blob no file refers to, or a temporary object, has to be for
+	// CollectGarbage to remove it, so that a file being written can still refer to the blob.
+	garbageAge time.Duration
+}
+
+// NewS3FileStorage creates a new instance of S3FileStorage. prefix is put in front of every key,
+// and partSize is the size of the parts of multipart uploads, DefaultS3PartSize if zero.
+func NewS3FileStorage(client *s3.Client, prefix string, partSize int64) (infrastructure.FileStorage, error) {
+	if partSize == 0 {
+		partSize = DefaultS3PartSize
+	}
+	if partSize < minS3PartSize || partSize > maxS3CopySize {
+		return nil, fmt.Errorf("s3 part size must be between %d and %d bytes", minS3PartSize, int64(maxS3CopySize))
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &S3FileStorage{
+		client:      client,
+		prefix:      prefix,
+		partSize:    int(partSize),
+		maxCopySize: maxS3CopySize,
+		garbageAge:  staleTempFileAge,
+	}, nil
+}
+
+// ownerPrefix is the prefix of the keys of the owner's files.
+func (s *S3FileStorage) ownerPrefix(owner entity.Owner) string {
+	if owner.TeamID != 0 {
+		return s.prefix + "teams/" + strconv.FormatInt(owner.TeamID, 10) + "/"
+	}
+	return s.prefix + "users/" + strconv.FormatInt(owner.UserID, 10) + "/"
+}
+
+// blobKey is the key of the blob of the owner with the content of the given checksum.
+func (s *S3FileStorage) blobKey(owner entity.Owner, sum string) string {
+	return s.ownerPrefix(owner) + entity.BlobsFolder + "/" + sum[:2] + "/" + sum
+}
+
+// SaveFile stores the file content as a blob, unless the owner has one with the same content
+// already, and then the object of the file that refers to it. Like on a local disk, a file is not
+// saved where a folder is, nor below another file. S3 replaces an object only once its upload is
+// complete.
+func (s *S3FileStorage) SaveFile(owner entity.Owner, filename string, fileContent io.Reader) (*entity.FileInfo, error) {
+	name, err := entity.CleanFilePath(filename)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+
+	if err := s.checkTarget(ctx, owner, name); err != nil {
+		return nil, err
+	}
+
+	sum, size, err := s.storeBlob(ctx, owner, fileContent)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.putFile(ctx, owner, name, sum, size); err != nil {
+		return nil, err
+	}
+	return s.describe(owner, name, sum)
+}
+
+// storeBlob stores content as a blob of the owner, unless the owner has a blob with the same
+// content, and returns its checksum and size. Content up to the part size is checksummed before it
+// is stored. Larger content is uploaded in parts to a temporary object in the UploadsFolder, and
+// then copied to its blob.
+func (s *S3FileStorage) storeBlob(ctx context.Context, owner entity.Owner, content io.Reader) (string, int64, error) {
+	buf := make([]byte, s.partSize)
+	n, err := io.ReadFull(content, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		checksum := sha256.Sum256(buf[:n])
+		sum := hex.EncodeToString(checksum[:])
+		blob := s.blobKey(owner, sum)
+		if found, err := s.reuseBlob(ctx, blob); err != nil || found {
+			return sum, int64(n), err
+		}
+		return sum, int64(n), s.client.PutObject(ctx, blob, buf[:n], blobContentType)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	hash := sha256.New()
+	hash.Write(buf)
+	rest := &countingReader{reader: io.TeeReader(content, hash)}
+	temp := s.ownerPrefix(owner) + entity.UploadsFolder + "/" + tempFilePrefix + rand.Text()
+	if err := s.uploadParts(ctx, temp, blobContentType, buf, rest); err != nil {
+		return "", 0, err
+	}
+	sum, size := hex.EncodeToString(hash.Sum(nil)), int64(len(buf))+rest.n
+
+	blob := s.blobKey(owner, sum)
+	found, err := s.reuseBlob(ctx, blob)
+	if err == nil && !found {
+		err = s.copy(ctx, temp, blob, size)
+	}
+	// A temporary object that is left behind is removed by CollectGarbage.
+	_ = s.client.DeleteObject(ctx, temp)
+	return sum, size, err
+}
+
+// reuseBlob reports whether the blob exists. A blob that is old enough to be collected as garbage
+// soon is copied onto itself, which refreshes its modification time, so that it is kept for the
+// file that is about to refer to it.
+func (s *S3FileStorage) reuseBlob(ctx context.Context, blob string) (bool, error) {
+	object, err := s.head(ctx, blob)
+	if err != nil || object == nil {
+		return false, err
+	}
+	if time.Since(object.LastModified) > s.garbageAge/2 {
+		if err := s.copy(ctx, blob, blob, object.Size); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// putFile stores the object of a file, which refers to the blob with the given checksum.
+func (s *S3FileStorage) putFile(ctx context.Context, owner entity.Owner, name, sum string, size int64) error {
+	metadata := map[string]string{
+		blobMetadata: sum,
+		sizeMetadata: strconv.FormatInt(size, 10),
+	}
+	return s.client.PutObjectWithMetadata(ctx, s.ownerPrefix(owner)+name, nil, contentTypeOf(name), metadata)
+}
+
+// checkTarget fails if name cannot be written: with errors.NotAFolder if a parent folder is a
+// file, and with errors.NotRegularFile if name is a folder.
+func (s *S3FileStorage) checkTarget(ctx context.Context, owner entity.Owner, name string) error {
+	if err := s.checkParents(ctx, owner, name); err != nil {
+		return err
+	}
+	if isFolder, err := s.exists(ctx, s.ownerPrefix(owner)+name+"/"); err != nil {
+		return err
+	} else if isFolder {
+		return errors.NotRegularFile
+	}
+	return nil
+}
+
+// describe describes a file that was just written, with the checksum of the content it was
+// written with.
+func (s *S3FileStorage) describe(owner entity.Owner, name string, sum string) (*entity.FileInfo, error) {
+	info, err := s.StatFile(owner, name)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, fmt.Errorf("file %s disappeared after it was written", name)
+	}
+	info.SHA256 = sum
+	return info, nil
+}
+
+// uploadParts stores buf, which is full, and the rest of the content as the object key with a
+// multipart upload, which is aborted if it fails.
+func (s *S3FileStorage) uploadParts(ctx context.Context, key, contentType string, buf []byte, content io.Reader) error {
+	uploadID, err := s.client.CreateMultipartUpload(ctx, key, contentType)
+	if err != nil {
+		return err
+	}
+	if err := s.uploadRest(ctx, key, uploadID, buf, content); err != nil {
+		if abortErr := s.client.AbortMultipartUpload(ctx, key, uploadID); abortErr != nil {
+			return fmt.Errorf("%w, and aborting the upload failed: %v", err, abortErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// uploadRest uploads buf, which is full, and the rest of the content as the parts of the
+// multipart upload uploadID.
+func (s *S3FileStorage) uploadRest(ctx context.Context, key, uploadID string, buf []byte, fileContent io.Reader) error {
+	var parts []s3.Part
+	n := len(buf)
+	for {
+		if len(parts) == maxS3Parts {
+			return fmt.Errorf("file is larger than %d parts", maxS3Parts)
+		}
+		part, err := s.client.UploadPart(ctx, key, uploadID, len(parts)+1, buf[:n])
+		if err != nil {
+			return err
+		}
+		parts = append(parts, part)
+
+		n, err = io.ReadFull(fileContent, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+	}
+	return s.client.CompleteMultipartUpload(ctx, key, uploadID, parts)
+}
+
+// FileSize returns the size of a file of the owner, or zero if the file does not exist.
+func (s *S3FileStorage) FileSize(owner entity.Owner, filename string) (int64, error) {
+	info, err := s.StatFile(owner, filename)
+	if err != nil || info == nil {
+		return 0, err
+	}
+	return info.Size, nil
+}
+
+// ListFiles lists the objects and folders below a folder of the owner. Keys that are not clean
+// paths, as other clients may have stored them, are left out. The objects of files that refer to
+// a blob are empty, so the size of empty objects is looked up with a HEAD request.
+func (s *S3FileStorage) ListFiles(owner entity.Owner, dir string, recursive bool) ([]*entity.FileInfo, error) {
+	ctx := context.Background()
+	ownerPrefix := s.ownerPrefix(owner)
+	prefix := ownerPrefix
+	if dir != "" {
+		name, err := entity.CleanFilePath(dir)
+		if err != nil {
+			return nil, err
+		}
+		prefix += name + "/"
+		if exists, err := s.exists(ctx, prefix); err != nil || !exists {
+			return nil, err
+		}
+	}
+
+	delimiter := "/"
+	if recursive {
+		delimiter = ""
+	}
+	files := []*entity.FileInfo{}
+	folders := map[string]bool{}
+	addFolder := func(name string) {
+		if !folders[name] {
+			folders[name] = true
+			files = append(files, &entity.FileInfo{Name: name, IsDir: true})
+		}
+	}
+
+	token := ""
+	for {
+		list, err := s.client.ListObjects(ctx, prefix, delimiter, token, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, commonPrefix := range list.CommonPrefixes {
+			if name, ok := cleanKey(ownerPrefix, strings.TrimSuffix(commonPrefix, "/")); ok {
+				addFolder(name)
+			}
+		}
+		for _, object := range list.Objects {
+			if object.Key == prefix {
+				continue
+			}
+			name, ok := cleanKey(ownerPrefix, strings.TrimSuffix(object.Key, "/"))
+			if !ok {
+				continue
+			}
+			// Recursive listings have no common prefixes, the folders are the parents of the keys.
+			for parent := path.Dir(name); recursive && parent+"/" != strings.TrimPrefix(prefix, ownerPrefix) && parent != "."; parent = path.Dir(parent) {
+				addFolder(parent)
+			}
+			if strings.HasSuffix(object.Key, "/") {
+				addFolder(name)
+				continue
+			}
+			files = append(files, &entity.FileInfo{
+				Name:        name,
+				Size:        object.Size,
+				ModTime:     object.LastModified,
+				ContentType: contentTypeOf(name),
+				ETag:        object.ETag,
+			})
+		}
+		if token = list.NextContinuationToken; token == "" {
+			break
+		}
+	}
+
+	var group errgroup.Group
+	group.SetLimit(headConcurrency)
+	for _, file := range files {
+		if file.IsDir || file.Size != 0 {
+			continue
+		}
+		group.Go(func() error {
+			object, err := s.head(ctx, ownerPrefix+file.Name)
+			if err != nil || object == nil {
+				return err
+			}
+			*file = *fileInfo(file.Name, object)
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(files, func(a, b *entity.FileInfo) int {
+		return slices.Compare(strings.Split(a.Name, "/"), strings.Split(b.Name, "/"))
+	})
+	return files, nil
+}
+
+// StatFile describes a file of the owner, or returns nil if the file does not exist.
+func (s *S3FileStorage) StatFile(owner entity.Owner, filename string) (*entity.FileInfo, error) {
+	name, err := entity.CleanFilePath(filename)
+	if err != nil {
+		return nil, err
+	}
+	object, err := s.head(context.Background(), s.ownerPrefix(owner)+name)
+	if err != nil || object == nil {
+		return nil, err
+	}
+	return fileInfo(name, object), nil
+}
+
+// fileInfo describes the file name stored as object. The checksum of the blob a file refers to
+// serves as its ETag.
+func fileInfo(name string, object *s3.Object) *entity.FileInfo {
+	info := &entity.FileInfo{
+		Name:        name,
+		Size:        object.Size,
+		ModTime:     object.LastModified,
+		ContentType: contentTypeOf(name),
+		ETag:        object.ETag,
+	}
+	if sum, size, ok := blobOf(object); ok {
+		info.Size = size
+		info.ETag = `"` + sum + `"`
+	}
+	return info
+}
+
+// blobOf returns the checksum and size of the blob the object of a file refers to, and false if
+// the object holds the content itself.
+func blobOf(object *s3.Object) (string, int64, bool) {
+	sum := object.Metadata[blobMetadata]
+	if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+		return "", 0, false
+	}
+	size, err := strconv.ParseInt(object.Metadata[sizeMetadata], 10, 64)
+	if err != nil || size < 0 {
+		return "", 0, false
+	}
+	return sum, size, true
+}
+
+// OpenFile opens a file of the owner for reading. The content is fetched when it is read. A file
+// that refers to a blob keeps reading the content it was opened with, while reading an object
+// that holds the content itself fails if it is replaced meanwhile.
+func (s *S3FileStorage) OpenFile(owner entity.Owner, filename string) (io.ReadSeekCloser, *entity.FileInfo, error) {
+	name, err := entity.CleanFilePath(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := s.ownerPrefix(owner) + name
+	object, err := s.head(context.Background(), key)
+	if err != nil || object == nil {
+		return nil, nil, err
+	}
+	info := fileInfo(name, object)
+	reader := &s3ObjectReader{
+		client: s.client,
+		key:    key,
+		etag:   object.ETag,
+		size:   info.Size,
+	}
+	if sum, _, ok := blobOf(object); ok {
+		// Blobs never change, they are only removed once no file refers to them.
+		reader.key, reader.etag = s.blobKey(owner, sum), ""
+	}
+	return reader, info, nil
+}
+
+// DeleteFile removes a file of the owner. Folders are not removed.
+func (s *S3FileStorage) DeleteFile(owner entity.Owner, filename string) error {
+	name, err := entity.CleanFilePath(filename)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	key := s.ownerPrefix(owner) + name
+
+	if isFolder, err := s.exists(ctx, key+"/"); err != nil {
+		return err
+	} else if isFolder {
+		return errors.NotRegularFile
+	}
+	return s.client.DeleteObject(ctx, key)
+}
+
+// MakeDir stores the marker of a folder, unless the folder exists already.
+func (s *S3FileStorage) MakeDir(owner entity.Owner, dir string) error {
+	name, err := entity.CleanFilePath(dir)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	key := s.ownerPrefix(owner) + name
+
+	if err := s.checkParents(ctx, owner, name+"/"); err != nil {
+		return err
+	}
+	if exists, err := s.exists(ctx, key+"/"); err != nil || exists {
+		return err
+	}
+	return s.client.PutObject(ctx, key+"/", nil, folderContentType)
+}
+
+// Move copies a file, or every object below a folder, to the new path and then deletes the old
+// objects. If it fails halfway, objects may exist at both paths.
+func (s *S3FileStorage) Move(owner entity.Owner, from, to string) error {
+	fromName, err := entity.CleanFilePath(from)
+	if err != nil {
+		return err
+	}
+	toName, err := entity.CleanFilePath(to)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	ownerPrefix := s.ownerPrefix(owner)
+	fromKey, toKey := ownerPrefix+fromName, ownerPrefix+toName
+
+	object, err := s.head(ctx, fromKey)
+	if err != nil {
+		return err
+	}
+	if object == nil {
+		if isFolder, err := s.exists(ctx, fromKey+"/"); err != nil {
+			return err
+		} else if !isFolder {
+			return errors.FileNotFound
+		}
+		if strings.HasPrefix(toName+"/", fromName+"/") {
+			return errors.InvalidFilename.New("the new path is the old one or inside of it")
+		}
+	}
+
+	if taken, err := s.head(ctx, toKey); err != nil {
+		return err
+	} else if taken != nil {
+		return errors.FileExists
+	}
+	if taken, err := s.exists(ctx, toKey+"/"); err != nil {
+		return err
+	} else if taken {
+		return errors.FileExists
+	}
+	if err := s.checkParents(ctx, owner, toName); err != nil {
+		return err
+	}
+
+	if object != nil {
+		if err := s.copy(ctx, fromKey, toKey, object.Size); err != nil {
+			return err
+		}
+		return s.client.DeleteObject(ctx, fromKey)
+	}
+
+	var objects []s3.Object
+	err = s.listAll(ctx, fromKey+"/", func(object s3.Object) error {
+		objects = append(objects, object)
+		return s.copy(ctx, object.Key, toKey+strings.TrimPrefix(object.Key, fromKey), object.Size)
+	})
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if err := s.client.DeleteObject(ctx, object.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteDir removes a folder of the owner, with every object below it if recursive is set.
+func (s *S3FileStorage) DeleteDir(owner entity.Owner, dir string, recursive bool) error {
+	name, err := entity.CleanFilePath(dir)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	key := s.ownerPrefix(owner) + name
+	prefix := key + "/"
+
+	if isFolder, err := s.exists(ctx, prefix); err != nil {
+		return err
+	} else if !isFolder {
+		if object, err := s.head(ctx, key); err != nil {
+			return err
+		} else if object != nil {
+			return errors.NotAFolder
+		}
+		return nil
+	}
+
+	if !recursive {
+		list, err := s.client.ListObjects(ctx, prefix, "", "", 2)
+		if err != nil {
+			return err
+		}
+		for _, object := range list.Objects {
+			if object.Key != prefix {
+				return errors.FolderNotEmpty
+			}
+		}
+		return s.client.DeleteObject(ctx, prefix)
+	}
+	return s.deleteAll(ctx, prefix)
+}
+
+// RemoveAll removes every object of the owner.
+func (s *S3FileStorage) RemoveAll(owner entity.Owner) error {
+	return s.deleteAll(context.Background(), s.ownerPrefix(owner))
+}
+
+// deleteAll deletes every object whose key starts with prefix.
+func (s *S3FileStorage) deleteAll(ctx context.Context, prefix string) error {
+	var keys []string
+	err := s.listAll(ctx, prefix, func(object s3.Object) error {
+		keys = append(keys, object.Key)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := s.client.DeleteObject(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadPrefix is the prefix of the chunks of the incomplete upload id. Each chunk is an object
+// named after its offset, zero-padded so that the keys sort by offset.
+func (s *S3FileStorage) uploadPrefix(owner entity.Owner, id string) (string, error) {
+	if !entity.IsUploadID(id) {
+		return "", errors.UploadNotFound
+	}
+	return s.ownerPrefix(owner) + entity.UploadsFolder + "/" + id + "/", nil
+}
+
+// uploadChunk is a chunk of an incomplete upload, an object at an offset of the file.
+type uploadChunk struct {
+	s3.Object
+	offset int64
+}
+
+// uploadChunks lists the chunks of an incomplete upload by offset.
+func (s *S3FileStorage) uploadChunks(ctx context.Context, prefix string) ([]uploadChunk, error) {
+	var chunks []uploadChunk
+	err := s.listAll(ctx, prefix, func(object s3.Object) error {
+		offset, err := strconv.ParseInt(strings.TrimPrefix(object.Key, prefix), 10, 64)
+		if err != nil {
+			return fmt.Errorf("unexpected upload chunk %s", object.Key)
+		}
+		chunks = append(chunks, uploadChunk{Object: object, offset: offset})
+		return nil
+	})
+	return chunks, err
+}
+
+// WriteUpload stores the content as chunks of at most the part size, since objects cannot be
+// appended to. Chunks at or past offset are deleted first, and a chunk across offset is cut.
+func (s *S3FileStorage) WriteUpload(owner entity.Owner, id string, offset int64, content io.Reader) (int64, error) {
+	prefix, err := s.uploadPrefix(owner, id)
+	if err != nil {
+		return 0, err
+	}
+	ctx := context.Background()
+
+	chunks, err := s.uploadChunks(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	var end int64
+	for _, chunk := range chunks {
+		if chunk.offset >= offset {
+			if err := s.client.DeleteObject(ctx, chunk.Key); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		end = chunk.offset + chunk.Size
+		if end > offset {
+			if err := s.truncateChunk(ctx, chunk, offset-chunk.offset); err != nil {
+				return 0, err
+			}
+			end = offset
+		}
+	}
+	if end != offset {
+		return 0, fmt.Errorf("upload %s has %d bytes, expected %d", id, end, offset)
+	}
+
+	var written int64
+	buf := make([]byte, s.partSize)
+	for {
+		n, err := io.ReadFull(content, buf)
+		if n > 0 {
+			key := fmt.Sprintf("%s%020d", prefix, offset+written)
+			if err := s.client.PutObject(ctx, key, buf[:n], "application/octet-stream"); err != nil {
+				return written, err
+			}
+			written += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// truncateChunk stores the first size bytes of a chunk again, as objects cannot be truncated.
+func (s *S3FileStorage) truncateChunk(ctx context.Context, chunk uploadChunk, size int64) error {
+	body, err := s.client.GetObject(ctx, chunk.Key, 0, chunk.ETag)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, size))
+	if err != nil {
+		return err
+	}
+	return s.client.PutObject(ctx, chunk.Key, data, "application/octet-stream")
+}
+
+// CommitUpload joins the chunks of a complete upload into a blob, unless the owner has one with
+// the same content, and stores the object of the file that refers to it. The chunks are read once
+// to compute the checksum, and then copied within S3 if they are large enough to be the parts of
+// a multipart upload. Otherwise they are streamed through, like the content of SaveFile.
+func (s *S3FileStorage) CommitUpload(owner entity.Owner, id, filename string) (*entity.FileInfo, error) {
+	prefix, err := s.uploadPrefix(owner, id)
+	if err != nil {
+		return nil, err
+	}
+	name, err := entity.CleanFilePath(filename)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+
+	chunks, err := s.uploadChunks(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var size int64
+	copyable := true
+	for i, chunk := range chunks {
+		if chunk.offset != size {
+			return nil, fmt.Errorf("upload %s misses the bytes from %d to %d", id, size, chunk.offset)
+		}
+		size += chunk.Size
+		if i < len(chunks)-1 && chunk.Size < minS3PartSize {
+			copyable = false
+		}
+	}
+
+	if err := s.checkTarget(ctx, owner, name); err != nil {
+		return nil, err
+	}
+
+	readers := make([]io.Reader, len(chunks))
+	for i, chunk := range chunks {
+		readers[i] = &s3ObjectReader{client: s.client, key: chunk.Key, etag: chunk.ETag, size: chunk.Size}
+	}
+	defer func() {
+		for _, reader := range readers {
+			reader.(*s3ObjectReader).Close()
+		}
+	}()
+	var sum string
+	if len(chunks) == 1 || (len(chunks) > 1 && copyable && len(chunks) <= maxS3Parts) {
+		sum, err = s.copyBlob(ctx, owner, chunks, io.MultiReader(readers...))
+	} else {
+		sum, _, err = s.storeBlob(ctx, owner, io.MultiReader(readers...))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.putFile(ctx, owner, name, sum, size); err != nil {
+		return nil, err
+	}
+	if err := s.deleteAll(ctx, prefix); err != nil {
+		return nil, err
+	}
+	return s.describe(owner, name, sum)
+}
+
+// copyBlob checksums the chunks of an upload by reading content, and copies them to their blob
+// within S3 unless the owner has the blob already. It returns the checksum.
+func (s *S3FileStorage) copyBlob(ctx context.Context, owner entity.Owner, chunks []uploadChunk, content io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+	blob := s.blobKey(owner, sum)
+	if found, err := s.reuseBlob(ctx, blob); err != nil || found {
+		return sum, err
+	}
+	if len(chunks) == 1 {
+		return sum, s.copy(ctx, chunks[0].Key, blob, chunks[0].Size)
+	}
+	return sum, s.copyChunks(ctx, blob, blobContentType, chunks)
+}
+
+// copyChunks copies the chunks of an upload as the parts of a multipart upload of key.
+func (s *S3FileStorage) copyChunks(ctx context.Context, key, contentType string, chunks []uploadChunk) error {
+	uploadID, err := s.client.CreateMultipartUpload(ctx, key, contentType)
+	if err != nil {
+		return err
+	}
+	parts := make([]s3.Part, 0, len(chunks))
+	for _, chunk := range chunks {
+		part, err := s.client.UploadPartCopy(ctx, key, uploadID, len(parts)+1, chunk.Key, 0, chunk.Size-1)
+		if err != nil {
+			_ = s.client.AbortMultipartUpload(ctx, key, uploadID)
+			return err
+		}
+		parts = append(parts, part)
+	}
+	if err := s.client.CompleteMultipartUpload(ctx, key, uploadID, parts); err != nil {
+		_ = s.client.AbortMultipartUpload(ctx, key, uploadID)
+		return err
+	}
+	return nil
+}
+
+// DeleteUpload deletes the chunks of an incomplete upload.
+func (s *S3FileStorage) DeleteUpload(owner entity.Owner, id string) error {
+	prefix, err := s.uploadPrefix(owner, id)
+	if err != nil {
+		return err
+	}
+	return s.deleteAll(context.Background(), prefix)
+}
+
+// CollectGarbage removes, below the prefix of every owner, the blobs that no file refers to and
+// that are older than the garbage age, and the temporary objects of writes that never
+// finished. Failed multipart uploads are aborted, and incomplete uploads are removed by the
+// service when they expire.
+func (s *S3FileStorage) CollectGarbage() error {
+	ctx := context.Background()
+	for _, kind := range []string{"users/", "teams/"} {
+		token := ""
+		for {
+			list, err := s.client.ListObjects(ctx, s.prefix+kind, "/", token, 0)
+			if err != nil {
+				return err
+			}
+			for _, ownerPrefix := range list.CommonPrefixes {
+				if err := s.collectGarbage(ctx, ownerPrefix); err != nil {
+					return err
+				}
+			}
+			if token = list.NextContinuationToken; token == "" {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// collectGarbage collects the garbage below the prefix of one owner. The files are listed before
+// the blobs, so a file written meanwhile refers to a blob that was stored or refreshed after the
+// files were listed, and that is too recent to be removed. Each blob is looked up once more right
+// before it is removed.
+func (s *S3FileStorage) collectGarbage(ctx context.Context, ownerPrefix string) error {
+	blobs := ownerPrefix + entity.BlobsFolder + "/"
+	uploads := ownerPrefix + entity.UploadsFolder + "/"
+
+	referenced := map[string]bool{}
+	var stale []string
+	err := s.listAll(ctx, ownerPrefix, func(object s3.Object) error {
+		switch {
+		case strings.HasPrefix(object.Key, uploads+tempFilePrefix):
+			if time.Since(object.LastModified) > s.garbageAge {
+				stale = append(stale, object.Key)
+			}
+			return nil
+		case strings.HasPrefix(object.Key, blobs), strings.HasPrefix(object.Key, uploads),
+			strings.HasSuffix(object.Key, "/"), object.Size != 0:
+			return nil
+		}
+		file, err := s.head(ctx, object.Key)
+		if err != nil || file == nil {
+			return err
+		}
+		if sum, _, ok := blobOf(file); ok {
+			referenced[sum] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var unreferenced []string
+	err = s.listAll(ctx, blobs, func(object s3.Object) error {
+		if !referenced[path.Base(object.Key)] && time.Since(object.LastModified) > s.garbageAge {
+			unreferenced = append(unreferenced, object.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range unreferenced {
+		object, err := s.head(ctx, key)
+		if err != nil {
+			return err
+		}
+		if object != nil && time.Since(object.LastModified) > s.garbageAge {
+			if err := s.client.DeleteObject(ctx, key); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, key := range stale {
+		if err := s.client.DeleteObject(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copy copies an object, in parts if it is too large for a single request.
+func (s *S3FileStorage) copy(ctx context.Context, from, to string, size int64) error {
+	if size <= s.maxCopySize {
+		return s.client.CopyObject(ctx, from, to)
+	}
+
+	partSize := max(int64(s.partSize), (size+maxS3Parts-1)/maxS3Parts)
+	uploadID, err := s.client.CreateMultipartUpload(ctx, to, contentTypeOf(to))
+	if err != nil {
+		return err
+	}
+	var parts []s3.Part
+	for first := int64(0); first < size; first += partSize {
+		last := min(first+partSize, size) - 1
+		part, err := s.client.UploadPartCopy(ctx, to, uploadID, len(parts)+1, from, first, last)
+		if err != nil {
+			_ = s.client.AbortMultipartUpload(ctx, to, uploadID)
+			return err
+		}
+		parts = append(parts, part)
+	}
+	if err := s.client.CompleteMultipartUpload(ctx, to, uploadID, parts); err != nil {
+		_ = s.client.AbortMultipartUpload(ctx, to, uploadID)
+		return err
+	}
+	return nil
+}
+
+// checkParents fails with errors.NotAFolder if a parent folder of name is a file.
+func (s *S3FileStorage) checkParents(ctx context.Context, owner entity.Owner, name string) error {
+	for parent := path.Dir(name); parent != "."; parent = path.Dir(parent) {
+		object, err := s.head(ctx, s.ownerPrefix(owner)+parent)
+		if err != nil {
+			return err
+		}
+		if object != nil {
+			return errors.NotAFolder
+		}
+	}
+	return nil
+}
+
+// head describes an object, or returns nil if it does not exist.
+func (s *S3FileStorage) head(ctx context.Context, key string) (*s3.Object, error) {
+	object, err := s.client.HeadObject(ctx, key)
+	if stderrors.Is(err, s3.ErrNotFound) {
+		return nil, nil
+	}
+	return object, err
+}
+
+// exists reports whether there is an object whose key starts with prefix.
+func (s *S3FileStorage) exists(ctx context.Context, prefix string) (bool, error) {
+	list, err := s.client.ListObjects(ctx, prefix, "", "", 1)
+	if err != nil {
+		return false, err
+	}
+	return len(list.Objects) > 0, nil
+}
+
+// listAll calls fn for every object whose key starts with prefix, page by page.
+func (s *S3FileStorage) listAll(ctx context.Context, prefix string, fn func(s3.Object) error) error {
+	token := ""
+	for {
+		list, err := s.client.ListObjects(ctx, prefix, "", token, 0)
+		if err != nil {
+			return err
+		}
+		for _, object := range list.Objects {
+			if err := fn(object); err != nil {
+				return err
+			}
+		}
+		if token = list.NextContinuationToken; token == "" {
+			return nil
+		}
+	}
+}
+
+// cleanKey returns the path of a key below the owner's prefix, and false if the key is not a
+// path entity.CleanFilePath would accept.
+func cleanKey(ownerPrefix, key string) (string, bool) {
+	name := strings.TrimPrefix(key, ownerPrefix)
+	clean, err := entity.CleanFilePath(name)
+	return name, err == nil && clean == name
+}
+
+// s3ObjectReader reads an object with a GET request from the current offset, which is only sent
+// once the content is read. Seeking drops the response, so that serving a Range request fetches
+// just the requested bytes. Every request is conditional on the ETag the object was opened with.
+type s3ObjectReader struct {
+	client *s3.Client
+	key    string
+	etag   string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *s3ObjectReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.client.GetObject(context.Background(), r.key, r.offset, r.etag)
+		if err != nil {
+			if stderrors.Is(err, s3.ErrPreconditionFailed) || stderrors.Is(err, s3.ErrNotFound) {
+				return 0, fmt.Errorf("file changed while reading: %w", err)
+			}
+			return 0, err
+		}
+		r.body = body
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *s3ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, stderrors.New("seek to a negative offset")
+	}
+	if offset != r.offset {
+		r.Close()
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+func (r *s3ObjectReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
+// countingReader counts the bytes read from reader.
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
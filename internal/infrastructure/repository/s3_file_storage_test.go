@@ -0,0 +1,701 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/errors"
+	"container-manager/internal/infrastructure/s3"
+	"container-manager/internal/infrastructure/s3/s3test"
+)
+
+// newTestS3FileStorage runs the tests against the bucket of S3_TEST_ENDPOINT, e.g. a local MinIO,
+// when it is set, and against an in-process fake server otherwise. Each test uses its own prefix.
+func newTestS3FileStorage(t *testing.T) (*S3FileStorage, *s3.Client) {
+	t.Helper()
+	options := s3.Options{
+		Endpoint:        os.Getenv("S3_TEST_ENDPOINT"),
+		Region:          os.Getenv("S3_TEST_REGION"),
+		Bucket:          os.Getenv("S3_TEST_BUCKET"),
+		AccessKeyID:     os.Getenv("S3_TEST_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("S3_TEST_SECRET_ACCESS_KEY"),
+		UsePathStyle:    true,
+	}
+	if options.Endpoint == "" {
+		server := s3test.NewServer("files", "access-key")
+		t.Cleanup(server.Close)
+		options = s3.Options{
+			Endpoint:        server.URL,
+			Bucket:          "files",
+			AccessKeyID:     "access-key",
+			SecretAccessKey: "secret-key",
+			UsePathStyle:    true,
+		}
+	}
+	client, err := s3.NewClient(options)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	prefix := "test/" + t.Name() + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	storage, err := NewS3FileStorage(client, prefix, 0)
+	if err != nil {
+		t.Fatalf("NewS3FileStorage failed: %v", err)
+	}
+	s := storage.(*S3FileStorage)
+	t.Cleanup(func() {
+		_ = s.deleteAll(context.Background(), s.prefix)
+	})
+	return s, client
+}
+
+func expectFileError(t *testing.T, err error, want *errors.CustomError, what string) {
+	t.Helper()
+	var customErr *errors.CustomError
+	if !stderrors.As(err, &customErr) || customErr.Message != want.Message {
+		t.Errorf("%s: expected %v, got %v", what, want, err)
+	}
+}
+
+func TestNewS3FileStorage(t *testing.T) {
+	client, err := s3.NewClient(s3.Options{Endpoint: "http://localhost:9000", Bucket: "files"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	for _, partSize := range []int64{1 << 20, 6 << 30} {
+		if _, err := NewS3FileStorage(client, "", partSize); err == nil {
+			t.Errorf("expected part size %d to be rejected", partSize)
+		}
+	}
+	storage, err := NewS3FileStorage(client, "files", 0)
+	if err != nil {
+		t.Fatalf("NewS3FileStorage failed: %v", err)
+	}
+	s := storage.(*S3FileStorage)
+	if s.partSize != DefaultS3PartSize {
+		t.Errorf("expected the default part size, got %d", s.partSize)
+	}
+	if got := s.ownerPrefix(entity.Owner{UserID: 1}); got != "files/users/1/" {
+		t.Errorf("unexpected personal prefix %q", got)
+	}
+	if got := s.ownerPrefix(entity.Owner{UserID: 1, TeamID: 7}); got != "files/teams/7/" {
+		t.Errorf("unexpected team prefix %q", got)
+	}
+}
+
+func TestS3FileStorage_SaveFile(t *testing.T) {
+	storage, client := newTestS3FileStorage(t)
+	ctx := context.Background()
+	owner := entity.Owner{UserID: 123}
+
+	info, err := storage.SaveFile(owner, "docs/a.txt", bytes.NewBufferString("content"))
+	if err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	if want := fmt.Sprintf("%x", sha256.Sum256([]byte("content"))); info.Name != "docs/a.txt" || info.Size != 7 || info.SHA256 != want {
+		t.Errorf("unexpected file %+v", info)
+	}
+	object, err := client.HeadObject(ctx, storage.prefix+"users/123/docs/a.txt")
+	if err != nil {
+		t.Fatalf("HeadObject failed: %v", err)
+	}
+	// The object of the file refers to the blob with the content
+	if object.Size != 0 || object.ContentType != "text/plain; charset=utf-8" || object.Metadata["sha256"] != info.SHA256 || object.Metadata["size"] != "7" {
+		t.Errorf("unexpected object %+v", object)
+	}
+	if data, err := readObject(client, storage.prefix+"users/123/.blobs/"+info.SHA256[:2]+"/"+info.SHA256); err != nil || string(data) != "content" {
+		t.Errorf("expected the blob to hold the content, got %q, %v", data, err)
+	}
+
+	// Files of other owners are kept apart
+	if _, err := storage.SaveFile(entity.Owner{UserID: 123, TeamID: 7}, "docs/a.txt", bytes.NewBufferString("team")); err != nil {
+		t.Fatalf("SaveFile for a team failed: %v", err)
+	}
+	if size, _ := storage.FileSize(owner, "docs/a.txt"); size != 7 {
+		t.Errorf("expected the personal file to be kept, got size %d", size)
+	}
+	if size, _ := storage.FileSize(entity.Owner{UserID: 456}, "docs/a.txt"); size != 0 {
+		t.Errorf("expected no file of another user, got size %d", size)
+	}
+
+	// Files larger than a part are uploaded in parts
+	large := bytes.Repeat([]byte("0123456789abcdef"), (storage.partSize+100)/16)
+	if _, err := storage.SaveFile(owner, "large.bin", bytes.NewReader(large)); err != nil {
+		t.Fatalf("SaveFile of a large file failed: %v", err)
+	}
+	file, _, err := storage.OpenFile(owner, "large.bin")
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil || !bytes.Equal(data, large) {
+		t.Errorf("large file was not stored as uploaded: %d bytes, %v", len(data), err)
+	}
+
+	// Not where a folder is, nor below a file
+	_, err = storage.SaveFile(owner, "docs", bytes.NewBufferString("x"))
+	expectFileError(t, err, errors.NotRegularFile, "saving over a folder")
+	_, err = storage.SaveFile(owner, "docs/a.txt/b.txt", bytes.NewBufferString("x"))
+	expectFileError(t, err, errors.NotAFolder, "saving below a file")
+	_, err = storage.SaveFile(owner, "../a.txt", bytes.NewBufferString("x"))
+	expectFileError(t, err, errors.InvalidFilename, "saving outside of the prefix")
+}
+
+func TestS3FileStorage_ListFiles(t *testing.T) {
+	storage, client := newTestS3FileStorage(t)
+	owner := entity.Owner{UserID: 1}
+
+	for _, name := range []string{"b.txt", "docs/c/d.txt", "docs/a.txt", "docs.txt"} {
+		if _, err := storage.SaveFile(owner, name, bytes.NewBufferString(name)); err != nil {
+			t.Fatalf("SaveFile %s failed: %v", name, err)
+		}
+	}
+	if err := storage.MakeDir(owner, "docs/empty"); err != nil {
+		t.Fatalf("MakeDir failed: %v", err)
+	}
+	// Keys stored by other clients that are not clean paths are left out
+	if err := client.PutObject(context.Background(), storage.prefix+"users/1/docs/../x.txt", []byte("x"), ""); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	names := func(files []*entity.FileInfo) []string {
+		var names []string
+		for _, file := range files {
+			if file.IsDir {
+				names = append(names, file.Name+"/")
+			} else {
+				names = append(names, file.Name)
+			}
+		}
+		return names
+	}
+
+	files, err := storage.ListFiles(owner, "", false)
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if got, want := names(files), []string{"b.txt", "docs/", "docs.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if files[0].Size != 5 || files[0].ContentType != "text/plain; charset=utf-8" || files[0].ETag == "" || files[0].ModTime.IsZero() {
+		t.Errorf("unexpected file info %+v", files[0])
+	}
+
+	files, err = storage.ListFiles(owner, "", true)
+	if err != nil {
+		t.Fatalf("ListFiles recursive failed: %v", err)
+	}
+	want := []string{"b.txt", "docs/", "docs/a.txt", "docs/c/", "docs/c/d.txt", "docs/empty/", "docs.txt"}
+	if got := names(files); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	files, err = storage.ListFiles(owner, "docs", false)
+	if err != nil {
+		t.Fatalf("ListFiles of a folder failed: %v", err)
+	}
+	if got, want := names(files), []string{"docs/a.txt", "docs/c/", "docs/empty/"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	files, err = storage.ListFiles(owner, "docs/empty", true)
+	if err != nil || files == nil || len(files) != 0 {
+		t.Errorf("expected an empty folder, got %v, %v", files, err)
+	}
+	for _, dir := range []string{"missing", "b.txt"} {
+		if files, err := storage.ListFiles(owner, dir, false); err != nil || files != nil {
+			t.Errorf("expected nil for %s, got %v, %v", dir, files, err)
+		}
+	}
+	if files, err := storage.ListFiles(entity.Owner{UserID: 2}, "", false); err != nil || files == nil || len(files) != 0 {
+		t.Errorf("expected no files of another user, got %v, %v", files, err)
+	}
+}
+
+func TestS3FileStorage_OpenFile(t *testing.T) {
+	storage, _ := newTestS3FileStorage(t)
+	owner := entity.Owner{UserID: 1}
+
+	if _, err := storage.SaveFile(owner, "a.txt", bytes.NewBufferString("0123456789")); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	file, info, err := storage.OpenFile(owner, "a.txt")
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer file.Close()
+	if info.Size != 10 || info.Name != "a.txt" {
+		t.Errorf("unexpected file info %+v", info)
+	}
+
+	if offset, err := file.Seek(-4, io.SeekEnd); err != nil || offset != 6 {
+		t.Fatalf("Seek failed: %d, %v", offset, err)
+	}
+	data, err := io.ReadAll(file)
+	if err != nil || string(data) != "6789" {
+		t.Errorf("expected the end of the file, got %q, %v", data, err)
+	}
+	if _, err := file.Seek(2, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	buf := make([]byte, 3)
+	if _, err := io.ReadFull(file, buf); err != nil || string(buf) != "234" {
+		t.Errorf("expected a range of the file, got %q, %v", buf, err)
+	}
+
+	// Reading keeps the content the file was opened with once it is replaced
+	if _, err := storage.SaveFile(owner, "a.txt", bytes.NewBufferString("replaced")); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if data, err := io.ReadAll(file); err != nil || string(data) != "0123456789" {
+		t.Errorf("expected the content the file was opened with, got %q, %v", data, err)
+	}
+
+	if file, info, err := storage.OpenFile(owner, "missing.txt"); file != nil || info != nil || err != nil {
+		t.Errorf("expected nil for a missing file, got %v, %v, %v", file, info, err)
+	}
+}
+
+func TestS3FileStorage_OpenFile_WithoutBlob(t *testing.T) {
+	storage, client := newTestS3FileStorage(t)
+	ctx := context.Background()
+	owner := entity.Owner{UserID: 1}
+
+	// Objects stored before blobs were hold the content themselves
+	key := storage.prefix + "users/1/old.txt"
+	if err := client.PutObject(ctx, key, []byte("0123456789"), "text/plain"); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if files, err := storage.ListFiles(owner, "", false); err != nil || len(files) != 1 || files[0].Size != 10 {
+		t.Errorf("expected the object to be listed, got %v, %v", files, err)
+	}
+	file, info, err := storage.OpenFile(owner, "old.txt")
+	if err != nil || file == nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer file.Close()
+	if info.Size != 10 {
+		t.Errorf("unexpected file info %+v", info)
+	}
+
+	// Reading fails once the object is replaced
+	if err := client.PutObject(ctx, key, []byte("replaced"), "text/plain"); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if _, err := io.ReadAll(file); err == nil {
+		t.Error("expected reading a replaced object to fail")
+	}
+	if err := storage.CollectGarbage(); err != nil {
+		t.Fatalf("CollectGarbage failed: %v", err)
+	}
+	if size, _ := storage.FileSize(owner, "old.txt"); size != 8 {
+		t.Errorf("expected the object to be kept, got size %d", size)
+	}
+}
+
+func TestS3FileStorage_MakeDir(t *testing.T) {
+	storage, _ := newTestS3FileStorage(t)
+	owner := entity.Owner{UserID: 1}
+
+	if err := storage.MakeDir(owner, "a/b"); err != nil {
+		t.Fatalf("MakeDir failed: %v", err)
+	}
+	// Making an existing folder succeeds
+	if err := storage.MakeDir(owner, "a/b"); err != nil {
+		t.Errorf("MakeDir of an existing folder failed: %v", err)
+	}
+	if files, _ := storage.ListFiles(owner, "a", false); len(files) != 1 || !files[0].IsDir {
+		t.Errorf("expected folder a/b, got %v", files)
+	}
+
+	if _, err := storage.SaveFile(owner, "f.txt", bytes.NewBufferString("x")); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	expectFileError(t, storage.MakeDir(owner, "f.txt"), errors.NotAFolder, "making a folder over a file")
+	expectFileError(t, storage.MakeDir(owner, "f.txt/sub"), errors.NotAFolder, "making a folder below a file")
+}
+
+func TestS3FileStorage_Move(t *testing.T) {
+	storage, _ := newTestS3FileStorage(t)
+	owner := entity.Owner{UserID: 1}
+
+	for _, name := range []string{"a.txt", "docs/b.txt", "docs/c/d.txt", "taken.txt"} {
+		if _, err := storage.SaveFile(owner, name, bytes.NewBufferString(name)); err != nil {
+			t.Fatalf("SaveFile %s failed: %v", name, err)
+		}
+	}
+
+	if err := storage.Move(owner, "a.txt", "new/a.txt"); err != nil {
+		t.Fatalf("Move of a file failed: %v", err)
+	}
+	if size, _ := storage.FileSize(owner, "new/a.txt"); size != 5 {
+		t.Errorf("expected the moved file, got size %d", size)
+	}
+	if info, _ := storage.StatFile(owner, "a.txt"); info != nil {
+		t.Error("expected the old file to be removed")
+	}
+
+	if err := storage.Move(owner, "docs", "new/docs"); err != nil {
+		t.Fatalf("Move of a folder failed: %v", err)
+	}
+	files, _ := storage.ListFiles(owner, "", true)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name)
+	}
+	want := []string{"new", "new/a.txt", "new/docs", "new/docs/b.txt", "new/docs/c", "new/docs/c/d.txt", "taken.txt"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+
+	expectFileError(t, storage.Move(owner, "missing.txt", "b.txt"), errors.FileNotFound, "moving a missing file")
+	expectFileError(t, storage.Move(owner, "new/a.txt", "taken.txt"), errors.FileExists, "moving onto a file")
+	expectFileError(t, storage.Move(owner, "taken.txt", "new/docs"), errors.FileExists, "moving onto a folder")
+	expectFileError(t, storage.Move(owner, "new/a.txt", "taken.txt/a.txt"), errors.NotAFolder, "moving below a file")
+	expectFileError(t, storage.Move(owner, "new", "new/docs/new"), errors.InvalidFilename, "moving a folder into itself")
+}
+
+func TestS3FileStorage_Move_CopyInParts(t *testing.T) {
+	storage, _ := newTestS3FileStorage(t)
+	owner := entity.Owner{UserID: 1}
+	// Copy objects larger than a part in parts, as S3 does for objects over 5 GiB
+	storage.maxCopySize = int64(storage.partSize)
+
+	large := bytes.Repeat([]byte("0123456789abcdef"), (storage.partSize+100)/16)
+	if _, err := storage.SaveFile(owner, "large.bin", bytes.NewReader(large)); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	if err := storage.Move(owner, "large.bin", "moved.bin"); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	file, _, err := storage.OpenFile(owner, "moved.bin")
+	if err != nil || file == nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil || !bytes.Equal(data, large) {
+		t.Errorf("moved file differs: %d bytes, %v", len(data), err)
+	}
+}
+
+func TestS3FileStorage_DeleteDir(t *testing.T) {
+	storage, _ := newTestS3FileStorage(t)
+	owner := entity.Owner{UserID: 1}
+
+	if _, err := storage.SaveFile(owner, "docs/a.txt", bytes.NewBufferString("a")); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	if err := storage.MakeDir(owner, "docs"); err != nil {
+		t.Fatalf("MakeDir failed: %v", err)
+	}
+	if err := storage.MakeDir(owner, "empty"); err != nil {
+		t.Fatalf("MakeDir failed: %v", err)
+	}
+
+	expectFileError(t, storage.DeleteDir(owner, "docs", false), errors.FolderNotEmpty, "deleting a folder with files")
+	expectFileError(t, storage.DeleteDir(owner, "docs/a.txt", false), errors.NotAFolder, "deleting a file as a folder")
+	expectFileError(t, storage.DeleteFile(owner, "docs"), errors.NotRegularFile, "deleting a folder as a file")
+	if err := storage.DeleteDir(owner, "missing", false); err != nil {
+		t.Errorf("DeleteDir of a missing folder failed: %v", err)
+	}
+
+	if err := storage.DeleteDir(owner, "empty", false); err != nil {
+		t.Errorf("DeleteDir of an empty folder failed: %v", err)
+	}
+	if err := storage.DeleteDir(owner, "docs", true); err != nil {
+		t.Errorf("DeleteDir recursive failed: %v", err)
+	}
+	if files, _ := storage.ListFiles(owner, "", true); len(files) != 0 {
+		t.Errorf("expected no files left, got %v", files)
+	}
+}
+
+func TestS3FileStorage_RemoveAll(t *testing.T) {
+	storage, _ := newTestS3FileStorage(t)
+
+	for _, owner := range []entity.Owner{{UserID: 1}, {UserID: 2}} {
+		if _, err := storage.SaveFile(owner, "docs/a.txt", bytes.NewBufferString("a")); err != nil {
+			t.Fatalf("SaveFile failed: %v", err)
+		}
+	}
+	if err := storage.RemoveAll(entity.Owner{UserID: 1}); err != nil {
+		t.Fatalf("RemoveAll failed: %v", err)
+	}
+	if info, _ := storage.StatFile(entity.Owner{UserID: 1}, "docs/a.txt"); info != nil {
+		t.Error("expected the files of the user to be removed")
+	}
+	if info, _ := storage.StatFile(entity.Owner{UserID: 2}, "docs/a.txt"); info == nil {
+		t.Error("expected the files of another user to be kept")
+	}
+}
+
+func TestS3FileStorage_Upload(t *testing.T) {
+	storage, _ := newTestS3FileStorage(t)
+	owner := entity.Owner{UserID: 1}
+	id := "0123456789abcdef0123456789abcdef"
+
+	write := func(offset int64, content []byte) {
+		t.Helper()
+		if n, err := storage.WriteUpload(owner, id, offset, bytes.NewReader(content)); err != nil || n != int64(len(content)) {
+			t.Fatalf("WriteUpload at %d failed: %d, %v", offset, n, err)
+		}
+	}
+	read := func(name string) []byte {
+		t.Helper()
+		file, _, err := storage.OpenFile(owner, name)
+		if err != nil || file == nil {
+			t.Fatalf("OpenFile failed: %v", err)
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		if err != nil {
+			t.Fatalf("reading failed: %v", err)
+		}
+		return data
+	}
+
+	// Small chunks are streamed into the file
+	write(0, []byte("01234"))
+	write(3, []byte("x"))
+	write(4, []byte("56789"))
+	if _, err := storage.WriteUpload(owner, id, 20, bytes.NewBufferString("gap")); err == nil {
+		t.Error("expected writing past the end of an upload to fail")
+	}
+	if files, err := storage.ListFiles(owner, "", true); err != nil || len(files) != 0 {
+		t.Errorf("expected no files, got %v, %v", files, err)
+	}
+	info, err := storage.CommitUpload(owner, id, "small.txt")
+	if err != nil {
+		t.Fatalf("CommitUpload failed: %v", err)
+	}
+	if want := fmt.Sprintf("%x", sha256.Sum256([]byte("012x56789"))); info.SHA256 != want {
+		t.Errorf("expected checksum %s, got %s", want, info.SHA256)
+	}
+	if got := read("small.txt"); string(got) != "012x56789" {
+		t.Errorf("expected the uploaded content, got %q", got)
+	}
+
+	// Chunks large enough to be parts are copied within the bucket
+	large := bytes.Repeat([]byte("0123456789abcdef"), (storage.partSize+100)/16)
+	write(0, large[:storage.partSize])
+	write(int64(storage.partSize), large[storage.partSize:])
+	info, err = storage.CommitUpload(owner, id, "data/large.bin")
+	if err != nil {
+		t.Fatalf("CommitUpload failed: %v", err)
+	}
+	if want := fmt.Sprintf("%x", sha256.Sum256(large)); info.SHA256 != want {
+		t.Errorf("expected checksum %s of the copied chunks, got %s", want, info.SHA256)
+	}
+	if got := read("data/large.bin"); !bytes.Equal(got, large) {
+		t.Errorf("large file differs: %d bytes", len(got))
+	}
+
+	// An empty upload stores nothing until it is committed
+	write(0, nil)
+	if _, err := storage.CommitUpload(owner, id, "empty.txt"); err != nil {
+		t.Fatalf("CommitUpload failed: %v", err)
+	}
+	if size, err := storage.FileSize(owner, "empty.txt"); err != nil || size != 0 {
+		t.Errorf("expected an empty file, got %d, %v", size, err)
+	}
+
+	write(0, []byte("x"))
+	_, err = storage.CommitUpload(owner, id, "data")
+	expectFileError(t, err, errors.NotRegularFile, "committing over a folder")
+	if err := storage.DeleteUpload(owner, id); err != nil {
+		t.Fatalf("DeleteUpload failed: %v", err)
+	}
+	if _, err := storage.CommitUpload(owner, id, "x.txt"); err != nil {
+		t.Fatalf("CommitUpload failed: %v", err)
+	}
+	if size, _ := storage.FileSize(owner, "x.txt"); size != 0 {
+		t.Errorf("expected the deleted chunks to be gone, got size %d", size)
+	}
+	_, err = storage.WriteUpload(owner, "../x", 0, bytes.NewBufferString("x"))
+	expectFileError(t, err, errors.UploadNotFound, "writing an invalid upload ID")
+}
+
+func TestS3FileStorage_Deduplicate(t *testing.T) {
+	storage, client := newTestS3FileStorage(t)
+	owner := entity.Owner{UserID: 1}
+	id := "0123456789abcdef0123456789abcdef"
+	blobs := func(owner string) []string {
+		t.Helper()
+		keys, err := listKeys(client, storage.prefix+owner+"/.blobs/")
+		if err != nil {
+			t.Fatalf("ListObjects failed: %v", err)
+		}
+		return keys
+	}
+
+	// Identical content is stored once, however it is written
+	large := bytes.Repeat([]byte("0123456789abcdef"), (storage.partSize+100)/16)
+	for _, name := range []string{"a.bin", "docs/b.bin"} {
+		if _, err := storage.SaveFile(owner, name, bytes.NewReader(large)); err != nil {
+			t.Fatalf("SaveFile failed: %v", err)
+		}
+	}
+	if _, err := storage.WriteUpload(owner, id, 0, bytes.NewReader(large)); err != nil {
+		t.Fatalf("WriteUpload failed: %v", err)
+	}
+	if _, err := storage.CommitUpload(owner, id, "c.bin"); err != nil {
+		t.Fatalf("CommitUpload failed: %v", err)
+	}
+	sum := fmt.Sprintf("%x", sha256.Sum256(large))
+	if got, want := blobs("users/1"), []string{storage.prefix + "users/1/.blobs/" + sum[:2] + "/" + sum}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the single blob %v, got %v", want, got)
+	}
+	if keys, _ := listKeys(client, storage.prefix+"users/1/.uploads/"); len(keys) != 0 {
+		t.Errorf("expected no temporary objects, got %v", keys)
+	}
+
+	// Small files too, and the blobs of other owners are kept apart
+	for _, name := range []string{"x.txt", "y.txt"} {
+		if _, err := storage.SaveFile(owner, name, bytes.NewBufferString("same")); err != nil {
+			t.Fatalf("SaveFile failed: %v", err)
+		}
+	}
+	if _, err := storage.SaveFile(entity.Owner{UserID: 1, TeamID: 7}, "x.txt", bytes.NewBufferString("same")); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	if got := blobs("users/1"); len(got) != 2 {
+		t.Errorf("expected two blobs, got %v", got)
+	}
+	if got := blobs("teams/7"); len(got) != 1 {
+		t.Errorf("expected a blob of the team, got %v", got)
+	}
+
+	// Moving a file keeps referring to its blob
+	if err := storage.Move(owner, "docs", "moved"); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	if files, err := storage.ListFiles(owner, "moved", false); err != nil || len(files) != 1 || files[0].Size != int64(len(large)) || files[0].ETag != `"`+sum+`"` {
+		t.Errorf("unexpected moved files %v, %v", files, err)
+	}
+	if got := blobs("users/1"); len(got) != 2 {
+		t.Errorf("expected moving to store nothing, got %v", got)
+	}
+}
+
+func TestS3FileStorage_CollectGarbage(t *testing.T) {
+	storage, client := newTestS3FileStorage(t)
+	ctx := context.Background()
+	owner := entity.Owner{UserID: 1}
+	team := entity.Owner{UserID: 1, TeamID: 7}
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if _, err := storage.SaveFile(owner, name, bytes.NewBufferString("shared")); err != nil {
+			t.Fatalf("SaveFile failed: %v", err)
+		}
+	}
+	if _, err := storage.SaveFile(team, "c.txt", bytes.NewBufferString("team")); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	temp := storage.prefix + "users/1/.uploads/" + tempFilePrefix + "interrupted"
+	if err := client.PutObject(ctx, temp, []byte("partial"), ""); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	count := func(prefix string) int {
+		t.Helper()
+		keys, err := listKeys(client, storage.prefix+prefix)
+		if err != nil {
+			t.Fatalf("ListObjects failed: %v", err)
+		}
+		return len(keys)
+	}
+
+	// Recent garbage is kept
+	if err := storage.DeleteFile(owner, "a.txt"); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+	if err := storage.DeleteFile(team, "c.txt"); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+	if err := storage.CollectGarbage(); err != nil {
+		t.Fatalf("CollectGarbage failed: %v", err)
+	}
+	if count("users/1/.blobs/") != 1 || count("teams/7/.blobs/") != 1 || count("users/1/.uploads/") != 1 {
+		t.Error("expected recent garbage to be kept")
+	}
+
+	// Old blobs are kept while a file refers to them
+	storage.garbageAge = 0
+	time.Sleep(time.Second)
+	if err := storage.CollectGarbage(); err != nil {
+		t.Fatalf("CollectGarbage failed: %v", err)
+	}
+	if count("users/1/.blobs/") != 1 || count("teams/7/.blobs/") != 0 || count("users/1/.uploads/") != 0 {
+		t.Error("expected the unreferenced blob and the temporary object to be removed")
+	}
+	if data, err := readFile(storage, owner, "b.txt"); err != nil || string(data) != "shared" {
+		t.Errorf("expected b.txt to be readable, got %q, %v", data, err)
+	}
+
+	// An old blob is refreshed when a file refers to it again
+	if _, err := storage.SaveFile(owner, "d.txt", bytes.NewBufferString("shared")); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	if data, err := readFile(storage, owner, "d.txt"); err != nil || string(data) != "shared" {
+		t.Errorf("expected d.txt to be readable, got %q, %v", data, err)
+	}
+	for _, name := range []string{"b.txt", "d.txt"} {
+		if err := storage.DeleteFile(owner, name); err != nil {
+			t.Fatalf("DeleteFile failed: %v", err)
+		}
+	}
+	time.Sleep(time.Second)
+	if err := storage.CollectGarbage(); err != nil {
+		t.Fatalf("CollectGarbage failed: %v", err)
+	}
+	if count("users/1/.blobs/") != 0 {
+		t.Error("expected the blob to be removed once no file refers to it")
+	}
+}
+
+// listKeys lists the keys of the objects below prefix.
+func listKeys(client *s3.Client, prefix string) ([]string, error) {
+	list, err := client.ListObjects(context.Background(), prefix, "", "", 0)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, object := range list.Objects {
+		keys = append(keys, object.Key)
+	}
+	return keys, nil
+}
+
+// readObject reads the content of an object.
+func readObject(client *s3.Client, key string) ([]byte, error) {
+	body, err := client.GetObject(context.Background(), key, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// readFile reads the content of a file of the owner.
+func readFile(storage *S3FileStorage, owner entity.Owner, name string) ([]byte, error) {
+	file, _, err := storage.OpenFile(owner, name)
+	if err != nil || file == nil {
+		return nil, fmt.Errorf("opening %s: %v", name, err)
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"time"
+)
+
+var _ infrastructure.TeamRepository = (*teamRepository)(nil)
+
+// teamMemberColumns are the columns read by scanTeamMember, from team_members joined with users.
+const teamMemberColumns = "m.team_id, m.user_id, u.username, m.role, m.created_at"
+
+type teamRepository struct {
+	db *sql.DB
+}
+
+func NewTeamRepository(db *sql.DB) infrastructure.TeamRepository {
+	return &teamRepository{db: db}
+}
+
+func (r *teamRepository) Create(ctx context.Context, team *entity.Team, owner *entity.TeamMember) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	if _, err := tx.ExecContext(ctx, "INSERT INTO teams (id, name, created_at) VALUES ($1, $2, $3)", team.ID, team.Name, now); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO team_members (team_id, user_id, role, created_at) VALUES ($1, $2, $3, $4)", team.ID, owner.UserID, owner.Role, now); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	team.CreatedAt = now
+	owner.CreatedAt = now
+	return nil
+}
+
+func (r *teamRepository) Get(ctx context.Context, id int64) (*entity.Team, error) {
+	team := &entity.Team{}
+	err := r.db.QueryRowContext(ctx, "SELECT id, name, created_at FROM teams WHERE id = $1", id).Scan(&team.ID, &team.Name, &team.CreatedAt)
+	if err != nil {
+		if stderrors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return team, nil
+}
+
+func (r *teamRepository) ListByUserID(ctx context.Context, userID int64) ([]*entity.TeamMembership, error) {
+	query := "SELECT t.id, t.name, t.created_at, m.role FROM teams t JOIN team_members m ON m.team_id = t.id WHERE m.user_id = $1 ORDER BY t.name, t.id"
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships := []*entity.TeamMembership{}
+	for rows.Next() {
+		membership := &entity.TeamMembership{Team: &entity.Team{}}
+		if err := rows.Scan(&membership.Team.ID, &membership.Team.Name, &membership.Team.CreatedAt, &membership.Role); err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, membership)
+	}
+	return memberships, rows.Err()
+}
+
+func (r *teamRepository) GetMember(ctx context.Context, teamID int64, userID int64) (*entity.TeamMember, error) {
+	query := "SELECT " + teamMemberColumns + " FROM team_members m JOIN users u ON u.id = m.user_id WHERE m.team_id = $1 AND m.user_id = $2"
+	member, err := scanTeamMember(r.db.QueryRowContext(ctx, query, teamID, userID))
+	if err != nil {
+		if stderrors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+func (r *teamRepository) ListMembers(ctx context.Context, teamID int64) ([]*entity.TeamMember, error) {
+	query := "SELECT " + teamMemberColumns + " FROM team_members m JOIN users u ON u.id = m.user_id WHERE m.team_id = $1 ORDER BY u.username"
+	rows, err := r.db.QueryContext(ctx, query, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []*entity.TeamMember{}
+	for rows.Next() {
+		member, err := scanTeamMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+func (r *teamRepository) SetMember(ctx context.Context, member *entity.TeamMember) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if member.Role != entity.TeamRoleOwner {
+		if err := lockOtherOwners(ctx, tx, member.TeamID, member.UserID); err != nil {
+			return err
+		}
+	}
+
+	query := `INSERT INTO team_members (team_id, user_id, role, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (team_id, user_id) DO UPDATE SET role = EXCLUDED.role`
+	if _, err := tx.ExecContext(ctx, query, member.TeamID, member.UserID, member.Role, time.Now().UTC()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *teamRepository) RemoveMember(ctx context.Context, teamID int64, userID int64) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if err := lockOtherOwners(ctx, tx, teamID, userID); err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM team_members WHERE team_id = $1 AND user_id = $2", teamID, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, tx.Commit()
+}
+
+// lockOtherOwners locks the team, so that concurrent membership changes are serialized, and fails
+// with errors.LastTeamOwner if the team has no owner besides the given user. A team always has an
+// owner, so the user is then its only owner.
+func lockOtherOwners(ctx context.Context, tx *sql.Tx, teamID int64, userID int64) error {
+	var id int64
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM teams WHERE id = $1 FOR UPDATE", teamID).Scan(&id); err != nil {
+		if stderrors.Is(err, sql.ErrNoRows) {
+			return errors.TeamNotFound
+		}
+		return err
+	}
+
+	var owners int
+	query := "SELECT COUNT(*) FROM team_members WHERE team_id = $1 AND role = $2 AND user_id <> $3"
+	if err := tx.QueryRowContext(ctx, query, teamID, entity.TeamRoleOwner, userID).Scan(&owners); err != nil {
+		return err
+	}
+	if owners == 0 {
+		return errors.LastTeamOwner
+	}
+	return nil
+}
+
+func scanTeamMember(row interface{ Scan(dest ...any) error }) (*entity.TeamMember, error) {
+	member := &entity.TeamMember{}
+	err := row.Scan(&member.TeamID, &member.UserID, &member.Username, &member.Role, &member.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
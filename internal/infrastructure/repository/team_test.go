@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"container-manager/internal/domain/entity"
+	internalErrors "container-manager/internal/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewTeamRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO teams \\(id, name, created_at\\) VALUES \\(\\$1, \\$2, \\$3\\)").
+		WithArgs(int64(10), "platform", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO team_members \\(team_id, user_id, role, created_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\)").
+		WithArgs(int64(10), int64(1), "owner", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	team := &entity.Team{ID: 10, Name: "platform"}
+	owner := &entity.TeamMember{TeamID: 10, UserID: 1, Role: entity.TeamRoleOwner}
+	err = repo.Create(context.Background(), team, owner)
+	assert.NoError(t, err)
+	assert.False(t, team.CreatedAt.IsZero())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTeamRepository_ListByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewTeamRepository(db)
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT t.id, t.name, t.created_at, m.role FROM teams t JOIN team_members m ON m.team_id = t.id WHERE m.user_id = \\$1").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "role"}).
+			AddRow(int64(10), "platform", createdAt, "owner").
+			AddRow(int64(11), "web", createdAt, "viewer"))
+
+	memberships, err := repo.ListByUserID(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.TeamMembership{
+		{Team: &entity.Team{ID: 10, Name: "platform", CreatedAt: createdAt}, Role: entity.TeamRoleOwner},
+		{Team: &entity.Team{ID: 11, Name: "web", CreatedAt: createdAt}, Role: entity.TeamRoleViewer},
+	}, memberships)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTeamRepository_GetMember(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewTeamRepository(db)
+	ctx := context.Background()
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("member", func(t *testing.T) {
+		mock.ExpectQuery("SELECT m.team_id, m.user_id, u.username, m.role, m.created_at FROM team_members m JOIN users u ON u.id = m.user_id WHERE m.team_id = \\$1 AND m.user_id = \\$2").
+			WithArgs(int64(10), int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"team_id", "user_id", "username", "role", "created_at"}).
+				AddRow(int64(10), int64(2), "bob", "member", createdAt))
+
+		member, err := repo.GetMember(ctx, 10, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, &entity.TeamMember{TeamID: 10, UserID: 2, Username: "bob", Role: entity.TeamRoleMember, CreatedAt: createdAt}, member)
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		mock.ExpectQuery("SELECT m.team_id").
+			WithArgs(int64(10), int64(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"team_id", "user_id", "username", "role", "created_at"}))
+
+		member, err := repo.GetMember(ctx, 10, 3)
+		assert.NoError(t, err)
+		assert.Nil(t, member)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTeamRepository_SetMember(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewTeamRepository(db)
+	ctx := context.Background()
+
+	expectLock := func(otherOwners int) {
+		mock.ExpectQuery("SELECT id FROM teams WHERE id = \\$1 FOR UPDATE").
+			WithArgs(int64(10)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(10)))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM team_members WHERE team_id = \\$1 AND role = \\$2 AND user_id <> \\$3").
+			WithArgs(int64(10), "owner", int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(otherOwners))
+	}
+
+	t.Run("add owner", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO team_members \\(team_id, user_id, role, created_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\)\\s+ON CONFLICT \\(team_id, user_id\\) DO UPDATE SET role = EXCLUDED.role").
+			WithArgs(int64(10), int64(2), "owner", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.SetMember(ctx, &entity.TeamMember{TeamID: 10, UserID: 2, Role: entity.TeamRoleOwner})
+		assert.NoError(t, err)
+	})
+
+	t.Run("demote an owner", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock(1)
+		mock.ExpectExec("INSERT INTO team_members").
+			WithArgs(int64(10), int64(2), "viewer", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.SetMember(ctx, &entity.TeamMember{TeamID: 10, UserID: 2, Role: entity.TeamRoleViewer})
+		assert.NoError(t, err)
+	})
+
+	t.Run("demote the last owner", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock(0)
+		mock.ExpectRollback()
+
+		err := repo.SetMember(ctx, &entity.TeamMember{TeamID: 10, UserID: 2, Role: entity.TeamRoleMember})
+		assert.Equal(t, internalErrors.LastTeamOwner, err)
+	})
+
+	t.Run("unknown team", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT id FROM teams WHERE id = \\$1 FOR UPDATE").
+			WithArgs(int64(10)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		err := repo.SetMember(ctx, &entity.TeamMember{TeamID: 10, UserID: 2, Role: entity.TeamRoleMember})
+		assert.Equal(t, internalErrors.TeamNotFound, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTeamRepository_RemoveMember(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewTeamRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM teams WHERE id = \\$1 FOR UPDATE").
+		WithArgs(int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(10)))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM team_members").
+		WithArgs(int64(10), "owner", int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec("DELETE FROM team_members WHERE team_id = \\$1 AND user_id = \\$2").
+		WithArgs(int64(10), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	removed, err := repo.RemoveMember(ctx, 10, 2)
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"container-manager/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewRefreshTokenRepository(db)
+	expiresAt := time.Now().Add(time.Hour)
+	token := &entity.RefreshToken{ID: "token-id", UserID: 123, FamilyID: "family-id", TokenHash: "hash", ExpiresAt: expiresAt}
+
+	mock.ExpectExec("INSERT INTO refresh_tokens").
+		WithArgs("token-id", int64(123), "family-id", "hash", expiresAt.UTC()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Create(context.Background(), token)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshTokenRepository_GetByHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewRefreshTokenRepository(db)
+	ctx := context.Background()
+	columns := []string{"id", "user_id", "family_id", "token_hash", "expires_at", "rotated_at", "revoked_at", "created_at"}
+	now := time.Now()
+
+	t.Run("found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM refresh_tokens WHERE token_hash = \\$1").
+			WithArgs("hash").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("token-id", 123, "family-id", "hash", now, now, nil, now))
+
+		token, err := repo.GetByHash(ctx, "hash")
+		assert.NoError(t, err)
+		assert.Equal(t, "family-id", token.FamilyID)
+		assert.NotNil(t, token.RotatedAt)
+		assert.Nil(t, token.RevokedAt)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM refresh_tokens").
+			WithArgs("hash").
+			WillReturnError(sql.ErrNoRows)
+
+		token, err := repo.GetByHash(ctx, "hash")
+		assert.NoError(t, err)
+		assert.Nil(t, token)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshTokenRepository_MarkRotated(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewRefreshTokenRepository(db)
+	ctx := context.Background()
+
+	t.Run("rotated", func(t *testing.T) {
+		mock.ExpectExec("UPDATE refresh_tokens SET rotated_at = NOW\\(\\) WHERE id = \\$1 AND rotated_at IS NULL").
+			WithArgs("token-id").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		rotated, err := repo.MarkRotated(ctx, "token-id")
+		assert.NoError(t, err)
+		assert.True(t, rotated)
+	})
+
+	t.Run("already rotated", func(t *testing.T) {
+		mock.ExpectExec("UPDATE refresh_tokens SET rotated_at").
+			WithArgs("token-id").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		rotated, err := repo.MarkRotated(ctx, "token-id")
+		assert.NoError(t, err)
+		assert.False(t, rotated)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshTokenRepository_RevokeFamily(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewRefreshTokenRepository(db)
+
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW\\(\\) WHERE family_id = \\$1").
+		WithArgs("family-id").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	err = repo.RevokeFamily(context.Background(), "family-id")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshTokenRepository_RevokeByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewRefreshTokenRepository(db)
+
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW\\(\\) WHERE user_id = \\$1 AND revoked_at IS NULL").
+		WithArgs(int64(1234)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = repo.RevokeByUserID(context.Background(), 1234)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokedTokenRepository_Revoke(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewRevokedTokenRepository(db)
+	expiresAt := time.Now().Add(time.Minute)
+
+	mock.ExpectExec("DELETE FROM revoked_tokens WHERE expires_at < \\$1").
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO revoked_tokens \\(jti, expires_at\\) VALUES \\(\\$1, \\$2\\) ON CONFLICT \\(jti\\) DO NOTHING").
+		WithArgs("jti", expiresAt.UTC()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Revoke(context.Background(), "jti", expiresAt)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokedTokenRepository_IsRevoked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewRevokedTokenRepository(db)
+
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM revoked_tokens WHERE jti = \\$1\\)").
+		WithArgs("jti").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	revoked, err := repo.IsRevoked(context.Background(), "jti")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
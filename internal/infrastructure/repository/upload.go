@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var _ infrastructure.UploadRepository = (*uploadRepository)(nil)
+
+// uploadColumns is the column list scanned by scanUpload. OFFSET is a keyword in SQL, so the
+// offset is stored as upload_offset.
+const uploadColumns = "id, user_id, COALESCE(team_id, 0), filename, length, upload_offset, metadata, expires_at, created_at"
+
+type uploadRepository struct {
+	db *sql.DB
+}
+
+func NewUploadRepository(db *sql.DB) infrastructure.UploadRepository {
+	return &uploadRepository{db: db}
+}
+
+func (r *uploadRepository) Create(ctx context.Context, upload *entity.Upload) error {
+	query := "INSERT INTO uploads (id, user_id, team_id, filename, length, upload_offset, metadata, expires_at, created_at) VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7, $8, $9)"
+	_, err := r.db.ExecContext(ctx, query, upload.ID, upload.UserID, upload.TeamID, upload.Filename, upload.Length, upload.Offset, upload.Metadata, upload.ExpiresAt.UTC(), upload.CreatedAt.UTC())
+	return err
+}
+
+func (r *uploadRepository) GetByID(ctx context.Context, id string) (*entity.Upload, error) {
+	query := "SELECT " + uploadColumns + " FROM uploads WHERE id = $1"
+	upload, err := scanUpload(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return upload, nil
+}
+
+func (r *uploadRepository) UpdateOffset(ctx context.Context, id string, from, to int64, expiresAt time.Time) (bool, error) {
+	query := "UPDATE uploads SET upload_offset = $1, expires_at = $2 WHERE id = $3 AND upload_offset = $4"
+	result, err := r.db.ExecContext(ctx, query, to, expiresAt.UTC(), id, from)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *uploadRepository) Delete(ctx context.Context, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM uploads WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *uploadRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*entity.Upload, error) {
+	query := "SELECT " + uploadColumns + " FROM uploads WHERE expires_at < $1 ORDER BY expires_at LIMIT $2"
+	return r.list(ctx, query, before.UTC(), limit)
+}
+
+func (r *uploadRepository) ListByUserID(ctx context.Context, userID int64) ([]*entity.Upload, error) {
+	query := "SELECT " + uploadColumns + " FROM uploads WHERE user_id = $1 ORDER BY created_at"
+	return r.list(ctx, query, userID)
+}
+
+func (r *uploadRepository) list(ctx context.Context, query string, args ...any) ([]*entity.Upload, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var uploads []*entity.Upload
+	for rows.Next() {
+		upload, err := scanUpload(rows)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+	return uploads, rows.Err()
+}
+
+func scanUpload(row interface{ Scan(dest ...any) error }) (*entity.Upload, error) {
+	upload := &entity.Upload{}
+	err := row.Scan(
+		&upload.ID,
+		&upload.UserID,
+		&upload.TeamID,
+		&upload.Filename,
+		&upload.Length,
+		&upload.Offset,
+		&upload.Metadata,
+		&upload.ExpiresAt,
+		&upload.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"container-manager/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var uploadColumnNames = []string{"id", "user_id", "team_id", "filename", "length", "upload_offset", "metadata", "expires_at", "created_at"}
+
+func TestUploadRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUploadRepository(db)
+	now := time.Now()
+	upload := &entity.Upload{
+		ID:        "0123456789abcdef0123456789abcdef",
+		UserID:    123,
+		Filename:  "data/big.csv",
+		Length:    1000,
+		Metadata:  "filename YmlnLmNzdg==",
+		ExpiresAt: now.Add(time.Hour),
+		CreatedAt: now,
+	}
+
+	mock.ExpectExec("INSERT INTO uploads \\(id, user_id, team_id, filename, length, upload_offset, metadata, expires_at, created_at\\) VALUES \\(\\$1, \\$2, NULLIF\\(\\$3, 0\\)").
+		WithArgs(upload.ID, int64(123), int64(0), "data/big.csv", int64(1000), int64(0), "filename YmlnLmNzdg==", upload.ExpiresAt.UTC(), now.UTC()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Create(context.Background(), upload)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUploadRepository_GetByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUploadRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM uploads WHERE id = \\$1").
+			WithArgs("upload-id").
+			WillReturnRows(sqlmock.NewRows(uploadColumnNames).AddRow("upload-id", 123, 7, "big.csv", 1000, 400, "", now, now))
+
+		upload, err := repo.GetByID(ctx, "upload-id")
+		assert.NoError(t, err)
+		assert.Equal(t, entity.Owner{UserID: 123, TeamID: 7}, upload.Owner())
+		assert.Equal(t, int64(1000), upload.Length)
+		assert.Equal(t, int64(400), upload.Offset)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM uploads").
+			WithArgs("upload-id").
+			WillReturnError(sql.ErrNoRows)
+
+		upload, err := repo.GetByID(ctx, "upload-id")
+		assert.NoError(t, err)
+		assert.Nil(t, upload)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUploadRepository_UpdateOffset(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUploadRepository(db)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	t.Run("updated", func(t *testing.T) {
+		mock.ExpectExec("UPDATE uploads SET upload_offset = \\$1, expires_at = \\$2 WHERE id = \\$3 AND upload_offset = \\$4").
+			WithArgs(int64(500), expiresAt.UTC(), "upload-id", int64(100)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		updated, err := repo.UpdateOffset(ctx, "upload-id", 100, 500, expiresAt)
+		assert.NoError(t, err)
+		assert.True(t, updated)
+	})
+
+	t.Run("offset changed meanwhile", func(t *testing.T) {
+		mock.ExpectExec("UPDATE uploads SET upload_offset").
+			WithArgs(int64(500), expiresAt.UTC(), "upload-id", int64(100)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		updated, err := repo.UpdateOffset(ctx, "upload-id", 100, 500, expiresAt)
+		assert.NoError(t, err)
+		assert.False(t, updated)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUploadRepository_Delete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUploadRepository(db)
+
+	mock.ExpectExec("DELETE FROM uploads WHERE id = \\$1").
+		WithArgs("upload-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM uploads WHERE id = \\$1").
+		WithArgs("upload-id").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	deleted, err := repo.Delete(context.Background(), "upload-id")
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = repo.Delete(context.Background(), "upload-id")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUploadRepository_ListExpired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUploadRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT (.+) FROM uploads WHERE expires_at < \\$1 ORDER BY expires_at LIMIT \\$2").
+		WithArgs(now.UTC(), 100).
+		WillReturnRows(sqlmock.NewRows(uploadColumnNames).
+			AddRow("upload-1", 123, 0, "a.csv", 10, 0, "", now, now).
+			AddRow("upload-2", 456, 7, "b.csv", 20, 5, "", now, now))
+
+	uploads, err := repo.ListExpired(context.Background(), now, 100)
+	assert.NoError(t, err)
+	assert.Len(t, uploads, 2)
+	assert.Equal(t, "upload-2", uploads[1].ID)
+	assert.Equal(t, int64(7), uploads[1].TeamID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUploadRepository_ListByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUploadRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT (.+) FROM uploads WHERE user_id = \\$1 ORDER BY created_at").
+		WithArgs(int64(123)).
+		WillReturnRows(sqlmock.NewRows(uploadColumnNames).
+			AddRow("upload-1", 123, 0, "a.csv", 10, 0, "", now, now).
+			AddRow("upload-2", 123, 7, "b.csv", 20, 5, "", now, now))
+
+	uploads, err := repo.ListByUserID(context.Background(), 123)
+	assert.NoError(t, err)
+	assert.Len(t, uploads, 2)
+	assert.Equal(t, entity.Owner{UserID: 123, TeamID: 7}, uploads[1].Owner())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 )
 
 var _ infrastructure.UserRepository = (*userRepository)(nil)
@@ -22,17 +23,93 @@ func NewUserRepository(
 	}
 }
 
+const userColumns = "id, username, password, role, sessions_valid_after, created_at"
+
 func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
-	query := "INSERT INTO users (id, username, password) VALUES ($1, $2, $3)"
-	_, err := r.db.ExecContext(ctx, query, user.ID, user.Username, user.Password)
+	query := "INSERT INTO users (id, username, password, role) VALUES ($1, $2, $3, $4)"
+	_, err := r.db.ExecContext(ctx, query, user.ID, user.Username, user.Password, user.Role)
 	return err
 }
 
 func (r *userRepository) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
-	query := "SELECT id, username, password FROM users WHERE username = $1"
-	row := r.db.QueryRowContext(ctx, query, username)
-	user := &entity.User{}
-	err := row.Scan(&user.ID, &user.Username, &user.Password)
+	query := "SELECT " + userColumns + " FROM users WHERE username = $1"
+	return r.findOne(ctx, query, username)
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id int64) (*entity.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE id = $1"
+	return r.findOne(ctx, query, id)
+}
+
+func (r *userRepository) List(ctx context.Context) ([]*entity.User, error) {
+	query := "SELECT " + userColumns + " FROM users ORDER BY id"
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*entity.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id int64, role entity.Role) (bool, error) {
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET role = $2 WHERE id = $1", id, role)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id int64, password string, sessionsValidAfter time.Time) (bool, error) {
+	query := "UPDATE users SET password = $2, sessions_valid_after = $3 WHERE id = $1"
+	result, err := r.db.ExecContext(ctx, query, id, password, sessionsValidAfter.UTC())
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// userTables are the tables holding data of a user besides the users table, keyed by user_id.
+// Containers and jobs are not listed, they are cleaned up by the services owning them, and neither
+// is the stored data of files and uploads.
+var userTables = []string{"api_keys", "refresh_tokens", "recovery_codes", "user_mfa", "user_identities", "user_quotas", "quota_usage", "team_members", "files", "uploads", "file_shares"}
+
+func (r *userRepository) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range userTables {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE user_id = $1", id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *userRepository) findOne(ctx context.Context, query string, arg any) (*entity.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, arg))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -41,3 +118,13 @@ func (r *userRepository) FindByUsername(ctx context.Context, username string) (*
 	}
 	return user, nil
 }
+
+func scanUser(row interface{ Scan(dest ...any) error }) (*entity.User, error) {
+	user := &entity.User{}
+	var sessionsValidAfter sql.NullTime
+	if err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Role, &sessionsValidAfter, &user.CreatedAt); err != nil {
+		return nil, err
+	}
+	user.SessionsValidAfter = sessionsValidAfter.Time
+	return user, nil
+}
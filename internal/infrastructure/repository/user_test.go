@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"testing"
+	"time"
 
 	"container-manager/internal/domain/entity"
 
@@ -24,11 +25,12 @@ func TestUserRepository_Create(t *testing.T) {
 		ID:       1,
 		Username: "testuser",
 		Password: "hashedpassword",
+		Role:     entity.RoleMember,
 	}
 
 	t.Run("success", func(t *testing.T) {
 		mock.ExpectExec("INSERT INTO users").
-			WithArgs(user.ID, user.Username, user.Password).
+			WithArgs(user.ID, user.Username, user.Password, user.Role).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		err := repo.Create(ctx, user)
@@ -37,7 +39,7 @@ func TestUserRepository_Create(t *testing.T) {
 
 	t.Run("failure", func(t *testing.T) {
 		mock.ExpectExec("INSERT INTO users").
-			WithArgs(user.ID, user.Username, user.Password).
+			WithArgs(user.ID, user.Username, user.Password, user.Role).
 			WillReturnError(errors.New("db error"))
 
 		err := repo.Create(ctx, user)
@@ -59,14 +61,16 @@ func TestUserRepository_FindByUsername(t *testing.T) {
 		ID:       1,
 		Username: "testuser",
 		Password: "hashedpassword",
+		Role:     entity.RoleMember,
 	}
+	now := time.Now()
 
 	t.Run("success", func(t *testing.T) {
 	
-rows := sqlmock.NewRows([]string{"id", "username", "password"}).
-			AddRow(user.ID, user.Username, user.Password)
+rows := sqlmock.NewRows([]string{"id", "username", "password", "role", "sessions_valid_after", "created_at"}).
+			AddRow(user.ID, user.Username, user.Password, user.Role, nil, now)
 
-		mock.ExpectQuery("SELECT id, username, password FROM users WHERE username = \\$1").
+		mock.ExpectQuery("SELECT id, username, password, role, sessions_valid_after, created_at FROM users WHERE username = \\$1").
 			WithArgs(user.Username).
 			WillReturnRows(rows)
 
@@ -74,10 +78,12 @@ rows := sqlmock.NewRows([]string{"id", "username", "password"}).
 		assert.NoError(t, err)
 		assert.Equal(t, user.ID, result.ID)
 		assert.Equal(t, user.Username, result.Username)
+		assert.True(t, result.SessionsValidAfter.IsZero())
+		assert.Equal(t, now, result.CreatedAt)
 	})
 
 	t.Run("not found", func(t *testing.T) {
-		mock.ExpectQuery("SELECT id, username, password FROM users WHERE username = \\$1").
+		mock.ExpectQuery("SELECT id, username, password, role, sessions_valid_after, created_at FROM users WHERE username = \\$1").
 			WithArgs("non-existent").
 			WillReturnError(sql.ErrNoRows)
 
@@ -87,7 +93,7 @@ rows := sqlmock.NewRows([]string{"id", "username", "password"}).
 	})
 
 	t.Run("db error", func(t *testing.T) {
-		mock.ExpectQuery("SELECT id, username, password FROM users WHERE username = \\$1").
+		mock.ExpectQuery("SELECT id, username, password, role, sessions_valid_after, created_at FROM users WHERE username = \\$1").
 			WithArgs(user.Username).
 			WillReturnError(errors.New("db error"))
 
@@ -98,3 +104,142 @@ rows := sqlmock.NewRows([]string{"id", "username", "password"}).
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestUserRepository_FindByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+	validAfter := now.Add(-time.Hour)
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "username", "password", "role", "sessions_valid_after", "created_at"}).
+			AddRow(1, "testuser", "hashedpassword", "admin", validAfter, now)
+
+		mock.ExpectQuery("SELECT id, username, password, role, sessions_valid_after, created_at FROM users WHERE id = \\$1").
+			WithArgs(int64(1)).
+			WillReturnRows(rows)
+
+		result, err := repo.FindByID(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "testuser", result.Username)
+		assert.Equal(t, entity.RoleAdmin, result.Role)
+		assert.Equal(t, validAfter, result.SessionsValidAfter)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, username, password, role, sessions_valid_after, created_at FROM users WHERE id = \\$1").
+			WithArgs(int64(1)).
+			WillReturnError(sql.ErrNoRows)
+
+		result, err := repo.FindByID(ctx, 1)
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_List(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db)
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{"id", "username", "password", "role", "sessions_valid_after", "created_at"}).
+		AddRow(1, "admin", "hash", "admin", nil, now).
+		AddRow(2, "viewer", "hash", "read_only", nil, now)
+	mock.ExpectQuery("SELECT id, username, password, role, sessions_valid_after, created_at FROM users ORDER BY id").
+		WillReturnRows(rows)
+
+	users, err := repo.List(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.User{
+		{ID: 1, Username: "admin", Password: "hash", Role: entity.RoleAdmin, CreatedAt: now},
+		{ID: 2, Username: "viewer", Password: "hash", Role: entity.RoleReadOnly, CreatedAt: now},
+	}, users)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_UpdateRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectExec("UPDATE users SET role = \\$2 WHERE id = \\$1").
+		WithArgs(int64(1), entity.RoleAdmin).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	updated, err := repo.UpdateRole(ctx, 1, entity.RoleAdmin)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+
+	mock.ExpectExec("UPDATE users SET role").
+		WithArgs(int64(2), entity.RoleAdmin).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	updated, err = repo.UpdateRole(ctx, 2, entity.RoleAdmin)
+	assert.NoError(t, err)
+	assert.False(t, updated)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_UpdatePassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db)
+	validAfter := time.Now()
+
+	mock.ExpectExec("UPDATE users SET password = \\$2, sessions_valid_after = \\$3 WHERE id = \\$1").
+		WithArgs(int64(1), "hash", validAfter.UTC()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	updated, err := repo.UpdatePassword(context.Background(), 1, "hash", validAfter)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_Delete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db)
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		for _, table := range userTables {
+			mock.ExpectExec("DELETE FROM " + table + " WHERE user_id = \\$1").
+				WithArgs(int64(1)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectExec("DELETE FROM users WHERE id = \\$1").
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.Delete(context.Background(), 1))
+	})
+
+	t.Run("rolls back on failure", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM api_keys").
+			WithArgs(int64(1)).
+			WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		assert.Error(t, repo.Delete(context.Background(), 1))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
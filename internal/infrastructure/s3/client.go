@@ -0,0 +1,427 @@
+// Package s3 is a client for the parts of the Amazon S3 API that file storage needs. It works
+// with S3 itself and with compatible servers such as MinIO.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned for objects, and multipart uploads, that do not exist.
+var ErrNotFound = errors.New("s3: not found")
+
+// ErrPreconditionFailed is returned when an object no longer has the ETag a request expects.
+var ErrPreconditionFailed = errors.New("s3: precondition failed")
+
+// emptyPayloadHash is the SHA-256 hash of an empty body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// metadataPrefix starts the headers that carry the user metadata of an object.
+const metadataPrefix = "X-Amz-Meta-"
+
+// Options configures a Client.
+type Options struct {
+	// Endpoint is the URL of the S3 service, such as https://s3.eu-central-1.amazonaws.com or
+	// http://localhost:9000 for MinIO.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// UsePathStyle addresses the bucket as the first element of the path rather than as a
+	// subdomain of the endpoint. MinIO and most other S3 compatible servers need it.
+	UsePathStyle bool
+	HTTPClient   *http.Client
+}
+
+// Error is an error response of S3.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("s3: %s (%d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// Object describes a stored object.
+type Object struct {
+	Key          string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+	ContentType  string
+	// Metadata is the user metadata of the object, by lowercase name. Only HeadObject reads it.
+	Metadata map[string]string
+}
+
+// ListResult is one page of ListObjects.
+type ListResult struct {
+	Objects []Object
+	// CommonPrefixes are the prefixes up to the next delimiter of the keys that were grouped
+	// instead of listed.
+	CommonPrefixes []string
+	// NextContinuationToken is passed to ListObjects for the next page, and is empty on the
+	// last page.
+	NextContinuationToken string
+}
+
+// Part is an uploaded part of a multipart upload.
+type Part struct {
+	Number int
+	ETag   string
+}
+
+// Client talks to one bucket of an S3 service.
+type Client struct {
+	options  Options
+	endpoint *url.URL
+	now      func() time.Time
+}
+
+func NewClient(options Options) (*Client, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(options.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", options.Endpoint)
+	}
+	if options.Bucket == "" {
+		return nil, errors.New("s3 bucket is not set")
+	}
+	if options.Region == "" {
+		options.Region = "us-east-1"
+	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: 5 * time.Minute}
+	}
+	return &Client{options: options, endpoint: endpoint, now: time.Now}, nil
+}
+
+// PutObject stores an object in a single request.
+func (c *Client) PutObject(ctx context.Context, key string, body []byte, contentType string) error {
+	return c.PutObjectWithMetadata(ctx, key, body, contentType, nil)
+}
+
+// PutObjectWithMetadata stores an object in a single request, along with user metadata. Metadata
+// names are case-insensitive.
+func (c *Client) PutObjectWithMetadata(ctx context.Context, key string, body []byte, contentType string, metadata map[string]string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	for name, value := range metadata {
+		header.Set(metadataPrefix+name, value)
+	}
+	resp, err := c.do(ctx, http.MethodPut, key, nil, header, body)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// HeadObject describes an object, or returns ErrNotFound.
+func (c *Client) HeadObject(ctx context.Context, key string) (*Object, error) {
+	resp, err := c.do(ctx, http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	var metadata map[string]string
+	for name := range resp.Header {
+		if len(name) > len(metadataPrefix) && strings.EqualFold(name[:len(metadataPrefix)], metadataPrefix) {
+			if metadata == nil {
+				metadata = map[string]string{}
+			}
+			metadata[strings.ToLower(name[len(metadataPrefix):])] = resp.Header.Get(name)
+		}
+	}
+	return &Object{
+		Key:          key,
+		Size:         resp.ContentLength,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: lastModified,
+		ContentType:  resp.Header.Get("Content-Type"),
+		Metadata:     metadata,
+	}, nil
+}
+
+// GetObject reads an object from offset on. With ifMatch set, it fails with ErrPreconditionFailed
+// once the object has been replaced. The caller has to close the returned body.
+func (c *Client) GetObject(ctx context.Context, key string, offset int64, ifMatch string) (io.ReadCloser, error) {
+	header := http.Header{}
+	if offset > 0 {
+		header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+	if ifMatch != "" {
+		header.Set("If-Match", ifMatch)
+	}
+	resp, err := c.do(ctx, http.MethodGet, key, nil, header, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// DeleteObject removes an object. It succeeds if the object does not exist.
+func (c *Client) DeleteObject(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// CopyObject copies an object of up to 5 GiB within the bucket, along with its user metadata.
+// Larger objects have to be copied with UploadPartCopy. Copying an object onto itself refreshes
+// its modification time, and drops its content type and user metadata, as S3 only allows such a
+// copy when it replaces them.
+func (c *Client) CopyObject(ctx context.Context, from, to string) error {
+	header := http.Header{}
+	header.Set("X-Amz-Copy-Source", c.copySource(from))
+	if from == to {
+		header.Set("X-Amz-Metadata-Directive", "REPLACE")
+	}
+	resp, err := c.do(ctx, http.MethodPut, to, nil, header, nil)
+	if err != nil {
+		return err
+	}
+	// S3 can report a failed copy in the body of a 200 response.
+	return readResult(resp, &struct {
+		XMLName xml.Name `xml:"CopyObjectResult"`
+	}{})
+}
+
+// ListObjects lists the objects whose keys start with prefix, sorted by key. With a delimiter,
+// keys that contain it after the prefix are grouped into CommonPrefixes.
+func (c *Client) ListObjects(ctx context.Context, prefix, delimiter, continuationToken string, maxKeys int) (*ListResult, error) {
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	if delimiter != "" {
+		query.Set("delimiter", delimiter)
+	}
+	if continuationToken != "" {
+		query.Set("continuation-token", continuationToken)
+	}
+	if maxKeys > 0 {
+		query.Set("max-keys", strconv.Itoa(maxKeys))
+	}
+	resp, err := c.do(ctx, http.MethodGet, "", query, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		IsTruncated bool `xml:"IsTruncated"`
+		Contents    []struct {
+			Key          string    `xml:"Key"`
+			Size         int64     `xml:"Size"`
+			ETag         string    `xml:"ETag"`
+			LastModified time.Time `xml:"LastModified"`
+		} `xml:"Contents"`
+		CommonPrefixes []struct {
+			Prefix string `xml:"Prefix"`
+		} `xml:"CommonPrefixes"`
+		NextContinuationToken string `xml:"NextContinuationToken"`
+	}
+	if err := readResult(resp, &result); err != nil {
+		return nil, err
+	}
+
+	list := &ListResult{}
+	for _, content := range result.Contents {
+		list.Objects = append(list.Objects, Object{
+			Key:          content.Key,
+			Size:         content.Size,
+			ETag:         content.ETag,
+			LastModified: content.LastModified,
+		})
+	}
+	for _, commonPrefix := range result.CommonPrefixes {
+		list.CommonPrefixes = append(list.CommonPrefixes, commonPrefix.Prefix)
+	}
+	if result.IsTruncated {
+		list.NextContinuationToken = result.NextContinuationToken
+	}
+	return list, nil
+}
+
+// CreateMultipartUpload starts a multipart upload and returns its ID. It has to be completed or
+// aborted, or its parts are kept, and billed, until a lifecycle rule removes them.
+func (c *Client) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, header, nil)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := readResult(resp, &result); err != nil {
+		return "", err
+	}
+	if result.UploadID == "" {
+		return "", errors.New("s3: no upload id in response")
+	}
+	return result.UploadID, nil
+}
+
+// UploadPart uploads a part of a multipart upload. Parts are numbered from 1, and all but the
+// last have to be at least 5 MiB.
+func (c *Client) UploadPart(ctx context.Context, key, uploadID string, partNumber int, body []byte) (Part, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(partNumber)}, "uploadId": {uploadID}}
+	resp, err := c.do(ctx, http.MethodPut, key, query, nil, body)
+	if err != nil {
+		return Part{}, err
+	}
+	resp.Body.Close()
+	return Part{Number: partNumber, ETag: resp.Header.Get("ETag")}, nil
+}
+
+// UploadPartCopy copies the bytes first to last of another object as a part of a multipart
+// upload.
+func (c *Client) UploadPartCopy(ctx context.Context, key, uploadID string, partNumber int, source string, first, last int64) (Part, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(partNumber)}, "uploadId": {uploadID}}
+	header := http.Header{}
+	header.Set("X-Amz-Copy-Source", c.copySource(source))
+	header.Set("X-Amz-Copy-Source-Range", fmt.Sprintf("bytes=%d-%d", first, last))
+	resp, err := c.do(ctx, http.MethodPut, key, query, header, nil)
+	if err != nil {
+		return Part{}, err
+	}
+	var result struct {
+		ETag string `xml:"ETag"`
+	}
+	if err := readResult(resp, &result); err != nil {
+		return Part{}, err
+	}
+	return Part{Number: partNumber, ETag: result.ETag}, nil
+}
+
+// CompleteMultipartUpload joins the parts, in order, into the object.
+func (c *Client) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []Part) error {
+	type completedPart struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	}
+	request := struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{}
+	for _, part := range parts {
+		request.Parts = append(request.Parts, completedPart{PartNumber: part.Number, ETag: part.ETag})
+	}
+	body, err := xml.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/xml")
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, header, body)
+	if err != nil {
+		return err
+	}
+	// Like a copy, completing can fail after S3 has answered with 200.
+	return readResult(resp, &struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+	}{})
+}
+
+// AbortMultipartUpload discards a multipart upload and its parts.
+func (c *Client) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	resp, err := c.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// do sends a signed request for an object, or for the bucket if key is empty. Error responses
+// are returned as errors, and the caller has to close the body of other responses.
+func (c *Client) do(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	requestURL := *c.endpoint
+	path, rawPath := "/"+key, "/"+escape(key, false)
+	if c.options.UsePathStyle {
+		path, rawPath = "/"+c.options.Bucket+path, "/"+c.options.Bucket+rawPath
+	} else {
+		requestURL.Host = c.options.Bucket + "." + requestURL.Host
+	}
+	requestURL.Path = c.endpoint.Path + path
+	requestURL.RawPath = c.endpoint.EscapedPath() + rawPath
+	requestURL.RawQuery = strings.ReplaceAll(query.Encode(), "+", "%20")
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	payloadHash := emptyPayloadHash
+	if len(body) > 0 {
+		payloadHash = hashHex(body)
+	}
+	c.sign(req, payloadHash, c.now())
+
+	resp, err := c.options.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	case http.StatusPreconditionFailed:
+		return nil, ErrPreconditionFailed
+	}
+	err = readResult(resp, &struct{}{})
+	var s3Err *Error
+	if errors.As(err, &s3Err) {
+		return nil, s3Err
+	}
+	// HEAD responses, and some proxies, have no error document.
+	return nil, &Error{StatusCode: resp.StatusCode, Code: http.StatusText(resp.StatusCode)}
+}
+
+// copySource names an object of the bucket as the source of a copy.
+func (c *Client) copySource(key string) string {
+	return "/" + c.options.Bucket + "/" + escape(key, false)
+}
+
+// readResult decodes the XML body of a response into result, and closes it. An Error document
+// in place of the result is returned as an error.
+func readResult(resp *http.Response, result any) error {
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var errorDocument struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}
+	if xml.Unmarshal(data, &errorDocument) == nil {
+		return &Error{StatusCode: resp.StatusCode, Code: errorDocument.Code, Message: errorDocument.Message}
+	}
+	if err := xml.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("s3: invalid response: %w", err)
+	}
+	return nil
+}
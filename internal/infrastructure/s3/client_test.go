@@ -0,0 +1,195 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"container-manager/internal/infrastructure/s3/s3test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T) (*Client, *s3test.Server) {
+	t.Helper()
+	server := s3test.NewServer("files", "access-key")
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(Options{
+		Endpoint:        server.URL,
+		Bucket:          "files",
+		AccessKeyID:     "access-key",
+		SecretAccessKey: "secret-key",
+		UsePathStyle:    true,
+	})
+	require.NoError(t, err)
+	return client, server
+}
+
+func TestNewClient(t *testing.T) {
+	for _, endpoint := range []string{"", "localhost:9000", "ftp://example.com"} {
+		_, err := NewClient(Options{Endpoint: endpoint, Bucket: "files"})
+		assert.Error(t, err, endpoint)
+	}
+	_, err := NewClient(Options{Endpoint: "http://localhost:9000"})
+	assert.Error(t, err)
+}
+
+func TestClient_Objects(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+	key := "users/1/a b+c.txt"
+
+	require.NoError(t, client.PutObject(ctx, key, []byte("0123456789"), "text/plain"))
+	data, ok := server.Object(key)
+	require.True(t, ok)
+	assert.Equal(t, "0123456789", string(data))
+
+	object, err := client.HeadObject(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), object.Size)
+	assert.Equal(t, "text/plain", object.ContentType)
+	assert.NotEmpty(t, object.ETag)
+	assert.False(t, object.LastModified.IsZero())
+
+	body, err := client.GetObject(ctx, key, 4, object.ETag)
+	require.NoError(t, err)
+	data, err = io.ReadAll(body)
+	body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "456789", string(data))
+
+	_, err = client.GetObject(ctx, key, 0, `"other"`)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+
+	require.NoError(t, client.CopyObject(ctx, key, "users/1/copy.txt"))
+	data, _ = server.Object("users/1/copy.txt")
+	assert.Equal(t, "0123456789", string(data))
+
+	require.NoError(t, client.DeleteObject(ctx, key))
+	_, err = client.HeadObject(ctx, key)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = client.GetObject(ctx, key, 0, "")
+	assert.ErrorIs(t, err, ErrNotFound)
+	// Deleting again succeeds
+	assert.NoError(t, client.DeleteObject(ctx, key))
+
+	err = client.CopyObject(ctx, key, "users/1/other.txt")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestClient_Metadata(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+	key := "users/1/manifest"
+
+	require.NoError(t, client.PutObjectWithMetadata(ctx, key, nil, "text/plain", map[string]string{"Sha256": "abc", "size": "10"}))
+	object, err := client.HeadObject(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"sha256": "abc", "size": "10"}, object.Metadata)
+
+	// Copies keep the metadata
+	require.NoError(t, client.CopyObject(ctx, key, "users/1/copy"))
+	object, err = client.HeadObject(ctx, "users/1/copy")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", object.Metadata["sha256"])
+
+	// Copying an object onto itself replaces its metadata
+	require.NoError(t, client.CopyObject(ctx, key, key))
+	object, err = client.HeadObject(ctx, key)
+	require.NoError(t, err)
+	assert.Empty(t, object.Metadata)
+}
+
+func TestClient_ListObjects(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"users/1/a.txt", "users/1/docs/b.txt", "users/1/docs/c/d.txt", "users/1/e.txt", "users/2/f.txt"} {
+		require.NoError(t, client.PutObject(ctx, key, []byte(key), ""))
+	}
+
+	list, err := client.ListObjects(ctx, "users/1/", "/", "", 0)
+	require.NoError(t, err)
+	require.Len(t, list.Objects, 2)
+	assert.Equal(t, "users/1/a.txt", list.Objects[0].Key)
+	assert.Equal(t, int64(len("users/1/a.txt")), list.Objects[0].Size)
+	assert.Equal(t, []string{"users/1/docs/"}, list.CommonPrefixes)
+	assert.Empty(t, list.NextContinuationToken)
+
+	// Page through every key below the prefix
+	var keys []string
+	token := ""
+	for {
+		list, err := client.ListObjects(ctx, "users/1/", "", token, 2)
+		require.NoError(t, err)
+		for _, object := range list.Objects {
+			keys = append(keys, object.Key)
+		}
+		if token = list.NextContinuationToken; token == "" {
+			break
+		}
+	}
+	assert.Equal(t, []string{"users/1/a.txt", "users/1/docs/b.txt", "users/1/docs/c/d.txt", "users/1/e.txt"}, keys)
+}
+
+func TestClient_MultipartUpload(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+	first := bytes.Repeat([]byte("a"), s3test.DefaultMinPartSize)
+
+	uploadID, err := client.CreateMultipartUpload(ctx, "big.bin", "application/octet-stream")
+	require.NoError(t, err)
+	part1, err := client.UploadPart(ctx, "big.bin", uploadID, 1, first)
+	require.NoError(t, err)
+	part2, err := client.UploadPart(ctx, "big.bin", uploadID, 2, []byte("tail"))
+	require.NoError(t, err)
+	require.NoError(t, client.CompleteMultipartUpload(ctx, "big.bin", uploadID, []Part{part1, part2}))
+
+	data, ok := server.Object("big.bin")
+	require.True(t, ok)
+	assert.Equal(t, len(first)+4, len(data))
+	assert.Equal(t, 0, server.Uploads())
+
+	// Copy in parts
+	uploadID, err = client.CreateMultipartUpload(ctx, "copy.bin", "")
+	require.NoError(t, err)
+	part1, err = client.UploadPartCopy(ctx, "copy.bin", uploadID, 1, "big.bin", 0, int64(len(first))-1)
+	require.NoError(t, err)
+	part2, err = client.UploadPartCopy(ctx, "copy.bin", uploadID, 2, "big.bin", int64(len(first)), int64(len(data))-1)
+	require.NoError(t, err)
+	require.NoError(t, client.CompleteMultipartUpload(ctx, "copy.bin", uploadID, []Part{part1, part2}))
+	copied, _ := server.Object("copy.bin")
+	assert.Equal(t, data, copied)
+
+	// Parts but the last one have a minimum size
+	uploadID, err = client.CreateMultipartUpload(ctx, "small.bin", "")
+	require.NoError(t, err)
+	part1, err = client.UploadPart(ctx, "small.bin", uploadID, 1, []byte("small"))
+	require.NoError(t, err)
+	part2, err = client.UploadPart(ctx, "small.bin", uploadID, 2, []byte("tail"))
+	require.NoError(t, err)
+	err = client.CompleteMultipartUpload(ctx, "small.bin", uploadID, []Part{part1, part2})
+	var s3Err *Error
+	require.True(t, errors.As(err, &s3Err), "expected an S3 error, got %v", err)
+	assert.Equal(t, "EntityTooSmall", s3Err.Code)
+
+	require.NoError(t, client.AbortMultipartUpload(ctx, "small.bin", uploadID))
+	assert.Equal(t, 0, server.Uploads())
+}
+
+func TestClient_WrongCredentials(t *testing.T) {
+	server := s3test.NewServer("files", "access-key")
+	defer server.Close()
+	client, err := NewClient(Options{Endpoint: server.URL, Bucket: "files", AccessKeyID: "other", UsePathStyle: true})
+	require.NoError(t, err)
+
+	err = client.PutObject(context.Background(), "a.txt", []byte("a"), "")
+	var s3Err *Error
+	require.True(t, errors.As(err, &s3Err), "expected an S3 error, got %v", err)
+	assert.Equal(t, 403, s3Err.StatusCode)
+	assert.Equal(t, "InvalidAccessKeyId", s3Err.Code)
+}
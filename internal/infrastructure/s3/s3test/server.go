@@ -0,0 +1,415 @@
+// Package s3test provides an in-memory S3 server to test storage against.
+package s3test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMinPartSize is the smallest part S3 accepts but for the last part of an upload.
+const DefaultMinPartSize = 5 << 20
+
+// Server is a minimal S3 service with a single bucket, addressed in path style. It supports
+// the requests of the s3 package: objects, copies, ListObjectsV2 and multipart uploads. Requests
+// have to be signed with AccessKeyID, and the payload hash they sign has to match the body,
+// while the signature itself is not checked. User metadata is kept for objects stored in a
+// single request, and copied along with them.
+type Server struct {
+	*httptest.Server
+	Bucket      string
+	AccessKeyID string
+	// MinPartSize is enforced for all parts but the last when an upload is completed.
+	MinPartSize int
+
+	mu      sync.Mutex
+	objects map[string]*object
+	uploads map[string]*upload
+	nextID  int
+}
+
+type object struct {
+	data        []byte
+	contentType string
+	etag        string
+	modTime     time.Time
+	metadata    http.Header
+}
+
+type upload struct {
+	key         string
+	contentType string
+	parts       map[int][]byte
+}
+
+// NewServer starts a server for the given bucket. Close it when done.
+func NewServer(bucket, accessKeyID string) *Server {
+	s := &Server{
+		Bucket:      bucket,
+		AccessKeyID: accessKeyID,
+		MinPartSize: DefaultMinPartSize,
+		objects:     map[string]*object{},
+		uploads:     map[string]*upload{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Keys returns the keys of the stored objects, sorted.
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Object returns the content of an object, or false if it does not exist.
+func (s *Server) Object(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[key]
+	if !ok {
+		return nil, false
+	}
+	return obj.data, true
+}
+
+// Uploads returns the number of multipart uploads that were neither completed nor aborted.
+func (s *Server) Uploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.uploads)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	if !strings.Contains(r.Header.Get("Authorization"), "Credential="+s.AccessKeyID+"/") {
+		writeError(w, http.StatusForbidden, "InvalidAccessKeyId", "unknown access key")
+		return
+	}
+	sum := sha256.Sum256(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		writeError(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch", "payload hash does not match the body")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	bucket, key, _ := strings.Cut(path, "/")
+	if bucket != s.Bucket {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "no such bucket")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	switch {
+	case key == "" && r.Method == http.MethodGet && query.Get("list-type") == "2":
+		s.list(w, query)
+	case key == "":
+		writeError(w, http.StatusNotImplemented, "NotImplemented", "bucket operation not supported")
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.createUpload(w, r, key)
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		s.completeUpload(w, key, query.Get("uploadId"), body)
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		s.uploadPart(w, r, key, query, body)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(s.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		s.copyObject(w, r, key)
+	case r.Method == http.MethodPut:
+		s.put(key, body, r.Header.Get("Content-Type"), md5ETag(body), userMetadata(r.Header))
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.get(w, r, key)
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "method not allowed")
+	}
+}
+
+func (s *Server) put(key string, data []byte, contentType, etag string, metadata http.Header) {
+	if contentType == "" {
+		contentType = "binary/octet-stream"
+	}
+	s.objects[key] = &object{data: data, contentType: contentType, etag: etag, modTime: time.Now().UTC().Truncate(time.Second), metadata: metadata}
+}
+
+// userMetadata returns the user metadata headers of a request.
+func userMetadata(header http.Header) http.Header {
+	metadata := http.Header{}
+	for name, values := range header {
+		if strings.HasPrefix(name, "X-Amz-Meta-") {
+			metadata[name] = values
+		}
+	}
+	return metadata
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, key string) {
+	obj, ok := s.objects[key]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchKey", "no such key")
+		return
+	}
+	w.Header().Set("ETag", obj.etag)
+	w.Header().Set("Content-Type", obj.contentType)
+	for name, values := range obj.metadata {
+		w.Header()[name] = values
+	}
+	// ServeContent answers Range and If-Match.
+	http.ServeContent(w, r, "", obj.modTime, bytes.NewReader(obj.data))
+}
+
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, key string) {
+	source, ok := s.copySource(w, r)
+	if !ok {
+		return
+	}
+	contentType, metadata := source.contentType, source.metadata
+	if r.Header.Get("X-Amz-Metadata-Directive") == "REPLACE" {
+		contentType, metadata = r.Header.Get("Content-Type"), userMetadata(r.Header)
+	} else if source == s.objects[key] {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", "copying an object onto itself has to replace its metadata")
+		return
+	}
+	s.put(key, source.data, contentType, source.etag, metadata)
+	writeXML(w, struct {
+		XMLName      xml.Name `xml:"CopyObjectResult"`
+		ETag         string   `xml:"ETag"`
+		LastModified string   `xml:"LastModified"`
+	}{ETag: source.etag, LastModified: time.Now().UTC().Format(time.RFC3339)})
+}
+
+func (s *Server) copySource(w http.ResponseWriter, r *http.Request) (*object, bool) {
+	source, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", "invalid copy source")
+		return nil, false
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+	obj, ok := s.objects[key]
+	if bucket != s.Bucket || !ok {
+		writeError(w, http.StatusNotFound, "NoSuchKey", "no such copy source")
+		return nil, false
+	}
+	return obj, true
+}
+
+func (s *Server) createUpload(w http.ResponseWriter, r *http.Request, key string) {
+	s.nextID++
+	uploadID := "upload-" + strconv.Itoa(s.nextID)
+	s.uploads[uploadID] = &upload{key: key, contentType: r.Header.Get("Content-Type"), parts: map[int][]byte{}}
+	writeXML(w, struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		UploadID string   `xml:"UploadId"`
+	}{Bucket: s.Bucket, Key: key, UploadID: uploadID})
+}
+
+func (s *Server) uploadPart(w http.ResponseWriter, r *http.Request, key string, query url.Values, body []byte) {
+	up, ok := s.uploads[query.Get("uploadId")]
+	partNumber, err := strconv.Atoi(query.Get("partNumber"))
+	if !ok || up.key != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "no such upload")
+		return
+	}
+	if err != nil || partNumber < 1 || partNumber > 10000 {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", "invalid part number")
+		return
+	}
+
+	if r.Header.Get("X-Amz-Copy-Source") == "" {
+		up.parts[partNumber] = body
+		w.Header().Set("ETag", md5ETag(body))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	source, ok := s.copySource(w, r)
+	if !ok {
+		return
+	}
+	var first, last int
+	if _, err := fmt.Sscanf(r.Header.Get("X-Amz-Copy-Source-Range"), "bytes=%d-%d", &first, &last); err != nil || first > last || last >= len(source.data) {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", "invalid copy source range")
+		return
+	}
+	data := source.data[first : last+1]
+	up.parts[partNumber] = data
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"CopyPartResult"`
+		ETag    string   `xml:"ETag"`
+	}{ETag: md5ETag(data)})
+}
+
+func (s *Server) completeUpload(w http.ResponseWriter, key, uploadID string, body []byte) {
+	up, ok := s.uploads[uploadID]
+	if !ok || up.key != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "no such upload")
+		return
+	}
+	var request struct {
+		Parts []struct {
+			PartNumber int    `xml:"PartNumber"`
+			ETag       string `xml:"ETag"`
+		} `xml:"Part"`
+	}
+	if err := xml.Unmarshal(body, &request); err != nil || len(request.Parts) == 0 {
+		writeError(w, http.StatusBadRequest, "MalformedXML", "invalid part list")
+		return
+	}
+
+	var data []byte
+	var sums []byte
+	for i, part := range request.Parts {
+		partData, ok := up.parts[part.PartNumber]
+		if !ok || part.ETag != md5ETag(partData) || (i > 0 && part.PartNumber <= request.Parts[i-1].PartNumber) {
+			writeError(w, http.StatusBadRequest, "InvalidPart", "invalid part "+strconv.Itoa(part.PartNumber))
+			return
+		}
+		if i < len(request.Parts)-1 && len(partData) < s.MinPartSize {
+			writeError(w, http.StatusBadRequest, "EntityTooSmall", "part "+strconv.Itoa(part.PartNumber)+" is too small")
+			return
+		}
+		data = append(data, partData...)
+		sum := md5.Sum(partData)
+		sums = append(sums, sum[:]...)
+	}
+
+	sum := md5.Sum(sums)
+	etag := fmt.Sprintf(`"%x-%d"`, sum, len(request.Parts))
+	s.put(key, data, up.contentType, etag, nil)
+	delete(s.uploads, uploadID)
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Bucket  string   `xml:"Bucket"`
+		Key     string   `xml:"Key"`
+		ETag    string   `xml:"ETag"`
+	}{Bucket: s.Bucket, Key: key, ETag: etag})
+}
+
+func (s *Server) list(w http.ResponseWriter, query url.Values) {
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	start := query.Get("continuation-token")
+	maxKeys := 1000
+	if value := query.Get("max-keys"); value != "" {
+		maxKeys, _ = strconv.Atoi(value)
+	}
+
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	type content struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         int    `xml:"Size"`
+	}
+	type commonPrefix struct {
+		Prefix string `xml:"Prefix"`
+	}
+	result := struct {
+		XMLName               xml.Name       `xml:"ListBucketResult"`
+		Name                  string         `xml:"Name"`
+		Prefix                string         `xml:"Prefix"`
+		KeyCount              int            `xml:"KeyCount"`
+		MaxKeys               int            `xml:"MaxKeys"`
+		IsTruncated           bool           `xml:"IsTruncated"`
+		Contents              []content      `xml:"Contents"`
+		CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+		NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	}{Name: s.Bucket, Prefix: prefix, MaxKeys: maxKeys}
+
+	// The continuation token is the last key or common prefix returned.
+	last := ""
+	for _, key := range keys {
+		entry := key
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				entry = key[:len(prefix)+i+len(delimiter)]
+			}
+		}
+		if entry <= start || entry == last {
+			continue
+		}
+		if result.KeyCount == maxKeys {
+			result.IsTruncated = true
+			result.NextContinuationToken = last
+			break
+		}
+		last = entry
+		result.KeyCount++
+		if entry != key {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: entry})
+			continue
+		}
+		obj := s.objects[key]
+		result.Contents = append(result.Contents, content{
+			Key:          key,
+			LastModified: obj.modTime.Format(time.RFC3339),
+			ETag:         obj.etag,
+			Size:         len(obj.data),
+		})
+	}
+	writeXML(w, result)
+}
+
+func md5ETag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(xml.Header))
+	_, _ = w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	data, _ := xml.Marshal(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}{Code: code, Message: message})
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
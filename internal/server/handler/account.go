@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"container-manager/internal/application"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountHandler struct {
+	service *application.AccountService
+}
+
+func NewAccountHandler(service *application.AccountService) *AccountHandler {
+	return &AccountHandler{service: service}
+}
+
+// DeleteAccount godoc
+// @Summary Delete the own account
+// @Description Enqueues the deletion of the account with its containers, files and jobs. The progress can be followed at /jobs/{id} until the account is gone. The sole owner of a team has to hand it over first
+// @Tags Users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} DeleteAccountResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 409 {object} ErrorResponse "Conflict"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /users/me [delete]
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	jobID, err := h.service.DeleteAccount(c.Request.Context(), caller)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Set("auditTarget", jobID)
+
+	c.JSON(http.StatusOK, DeleteAccountResponse{JobID: jobID})
+}
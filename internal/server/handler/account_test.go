@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"container-manager/internal/application"
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/server/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAccountHandler_DeleteAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+	accountService := application.NewAccountService(mockUserRepo, nil, mockTeamRepo, nil, nil, nil, nil)
+	accountHandler := NewAccountHandler(accountService)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.DELETE("/users/me", func(c *gin.Context) {
+		c.Set("userID", "1")
+		c.Set("role", "member")
+	}, accountHandler.DeleteAccount)
+
+	serve := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodDelete, "/users/me", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("sole owner of a team", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(&entity.User{ID: 1, Username: "alice", Role: entity.RoleMember}, nil)
+		mockTeamRepo.EXPECT().ListByUserID(gomock.Any(), int64(1)).Return([]*entity.TeamMembership{
+			{Team: &entity.Team{ID: 7, Name: "platform"}, Role: entity.TeamRoleOwner},
+		}, nil)
+		mockTeamRepo.EXPECT().ListMembers(gomock.Any(), int64(7)).Return([]*entity.TeamMember{
+			{TeamID: 7, UserID: 1, Role: entity.TeamRoleOwner},
+		}, nil)
+
+		w := serve()
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(nil, nil)
+
+		w := serve()
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"container-manager/internal/application"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	service *application.APIKeyService
+}
+
+func NewAPIKeyHandler(service *application.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Creates an API key that can be used in place of a JWT, limited to the given scopes (containers:read, containers:write, files:write, jobs:read). The key is only returned once.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body CreateAPIKeyRequest true "API key creation request"
+// @Success 200 {object} CreateAPIKeyResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Router /users/me/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(errors.BadRequest.Wrap(err))
+		return
+	}
+
+	key, value, err := h.service.CreateAPIKey(c.Request.Context(), userID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Set("auditTarget", key.ID)
+
+	c.JSON(http.StatusOK, CreateAPIKeyResponse{
+		APIKeyResponse: toAPIKeyResponse(key),
+		Key:            value,
+	})
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Description Lists the API keys of the authenticated user that have not been revoked
+// @Tags Users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} ListAPIKeysResponse
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Router /users/me/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	keys, err := h.service.ListAPIKeys(c.Request.Context(), userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	resp := ListAPIKeysResponse{APIKeys: make([]APIKeyResponse, 0, len(keys))}
+	for _, key := range keys {
+		resp.APIKeys = append(resp.APIKeys, toAPIKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revokes an API key of the authenticated user, requests made with it are rejected from then on
+// @Tags Users
+// @Security ApiKeyAuth
+// @Param id path string true "API key ID"
+// @Success 200
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Not Found"
+// @Router /users/me/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	err = h.service.RevokeAPIKey(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func toAPIKeyResponse(key *entity.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"container-manager/internal/application"
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/server/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAPIKeyHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAPIKeyRepo := mocks.NewMockAPIKeyRepository(ctrl)
+	apiKeyHandler := NewAPIKeyHandler(application.NewAPIKeyService(mockAPIKeyRepo))
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "1234")
+		c.Next()
+	})
+	router.POST("/users/me/api-keys", apiKeyHandler.CreateAPIKey)
+	router.GET("/users/me/api-keys", apiKeyHandler.ListAPIKeys)
+	router.DELETE("/users/me/api-keys/:id", apiKeyHandler.RevokeAPIKey)
+
+	t.Run("create", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		body, _ := json.Marshal(CreateAPIKeyRequest{Name: "ci", Scopes: []string{entity.ScopeContainersRead}})
+		req, _ := http.NewRequest(http.MethodPost, "/users/me/api-keys", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp CreateAPIKeyResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.True(t, strings.HasPrefix(resp.Key, resp.Prefix))
+		assert.Equal(t, "ci", resp.Name)
+		assert.Equal(t, []string{entity.ScopeContainersRead}, resp.Scopes)
+	})
+
+	t.Run("create with unknown scope", func(t *testing.T) {
+		body, _ := json.Marshal(CreateAPIKeyRequest{Name: "ci", Scopes: []string{"admin"}})
+		req, _ := http.NewRequest(http.MethodPost, "/users/me/api-keys", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().ListByUserID(gomock.Any(), int64(1234)).Return([]*entity.APIKey{
+			{ID: "key-id", Name: "ci", Prefix: "cm_11111111", Scopes: []string{entity.ScopeJobsRead}, CreatedAt: time.Now()},
+		}, nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/users/me/api-keys", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp ListAPIKeysResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.APIKeys, 1)
+		assert.Equal(t, "key-id", resp.APIKeys[0].ID)
+		assert.NotContains(t, w.Body.String(), "hash")
+	})
+
+	t.Run("revoke unknown key", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().Revoke(gomock.Any(), int64(1234), "key-id").Return(false, nil)
+
+		req, _ := http.NewRequest(http.MethodDelete, "/users/me/api-keys/key-id", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"container-manager/internal/application"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService *application.AuditService
+}
+
+func NewAuditHandler(auditService *application.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// ListAudit godoc
+// @Summary List audit entries
+// @Description Lists the audit log, newest first. Requires the admin role
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param actor_id query int false "User who acted"
+// @Param action query string false "Action, e.g. container.remove"
+// @Param target_type query string false "Type of the target, e.g. container"
+// @Param target_id query string false "ID of the target"
+// @Param outcome query string false "success or failure"
+// @Param request_id query string false "Request ID"
+// @Param since query string false "Earliest time, RFC 3339"
+// @Param until query string false "Time before which entries are listed, RFC 3339"
+// @Param before query string false "next_before of the previous page"
+// @Param limit query int false "Page size, 50 by default and at most 500"
+// @Success 200 {object} ListAuditResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Router /audit [get]
+func (h *AuditHandler) ListAudit(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	limit := application.DefaultAuditListLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			_ = c.Error(errors.InvalidAuditFilter.New("limit must be a positive integer"))
+			return
+		}
+	}
+
+	entries, err := h.auditService.ListAudit(c.Request.Context(), caller, filter, limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	resp := ListAuditResponse{Entries: make([]AuditEntryResponse, 0, len(entries))}
+	for _, entry := range entries {
+		resp.Entries = append(resp.Entries, newAuditEntryResponse(entry))
+	}
+	// A full page may be followed by more entries.
+	if len(entries) > 0 && len(entries) == limit {
+		resp.NextBefore = strconv.FormatInt(entries[len(entries)-1].ID, 10)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ExportAudit godoc
+// @Summary Export audit entries
+// @Description Streams all audit entries matching the filters as JSON lines, newest first. Requires the admin role
+// @Tags Admin
+// @Produce application/x-ndjson
+// @Security ApiKeyAuth
+// @Param actor_id query int false "User who acted"
+// @Param action query string false "Action, e.g. container.remove"
+// @Param target_type query string false "Type of the target, e.g. container"
+// @Param target_id query string false "ID of the target"
+// @Param outcome query string false "success or failure"
+// @Param request_id query string false "Request ID"
+// @Param since query string false "Earliest time, RFC 3339"
+// @Param until query string false "Time before which entries are exported, RFC 3339"
+// @Success 200 {object} AuditEntryResponse "One entry per line"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Router /audit/export [get]
+func (h *AuditHandler) ExportAudit(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// The response starts with the first entry, so that errors before it still get a status.
+	writeHeader := func() {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", `attachment; filename="audit.jsonl"`)
+		c.Status(http.StatusOK)
+	}
+	encoder := json.NewEncoder(c.Writer)
+	err = h.auditService.ExportAudit(c.Request.Context(), caller, filter, func(entry *entity.AuditEntry) error {
+		if !c.Writer.Written() {
+			writeHeader()
+		}
+		return encoder.Encode(newAuditEntryResponse(entry))
+	})
+	if err != nil {
+		if !c.Writer.Written() {
+			_ = c.Error(err)
+			return
+		}
+		log.Printf("audit export aborted: %v", err)
+		return
+	}
+	if !c.Writer.Written() {
+		writeHeader()
+		c.Writer.WriteHeaderNow()
+	}
+}
+
+func parseAuditFilter(c *gin.Context) (infrastructure.AuditFilter, error) {
+	filter := infrastructure.AuditFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		Outcome:    entity.AuditOutcome(c.Query("outcome")),
+		RequestID:  c.Query("request_id"),
+	}
+	switch filter.Outcome {
+	case "", entity.AuditOutcomeSuccess, entity.AuditOutcomeFailure:
+	default:
+		return filter, errors.InvalidAuditFilter.New("outcome must be success or failure")
+	}
+
+	var err error
+	if value := c.Query("actor_id"); value != "" {
+		if filter.ActorID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return filter, errors.InvalidAuditFilter.New("actor_id must be an integer")
+		}
+	}
+	if value := c.Query("before"); value != "" {
+		if filter.BeforeID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return filter, errors.InvalidAuditFilter.New("before must be an integer")
+		}
+	}
+	if value := c.Query("since"); value != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, value); err != nil {
+			return filter, errors.InvalidAuditFilter.New("since must be an RFC 3339 time")
+		}
+	}
+	if value := c.Query("until"); value != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, value); err != nil {
+			return filter, errors.InvalidAuditFilter.New("until must be an RFC 3339 time")
+		}
+	}
+	return filter, nil
+}
+
+func newAuditEntryResponse(entry *entity.AuditEntry) AuditEntryResponse {
+	resp := AuditEntryResponse{
+		ID:         strconv.FormatInt(entry.ID, 10),
+		CreatedAt:  entry.CreatedAt,
+		APIKeyID:   entry.APIKeyID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		SourceIP:   entry.SourceIP,
+		Outcome:    string(entry.Outcome),
+		Error:      entry.Error,
+		RequestID:  entry.RequestID,
+	}
+	if entry.ActorID != 0 {
+		resp.ActorID = strconv.FormatInt(entry.ActorID, 10)
+	}
+	return resp
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"container-manager/internal/application"
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/server/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuditHandler_ListAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuditRepo := mocks.NewMockAuditRepository(ctrl)
+	auditHandler := NewAuditHandler(application.NewAuditService(mockAuditRepo))
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.GET("/audit", func(c *gin.Context) {
+		c.Set("userID", "1")
+		c.Set("role", "admin")
+	}, auditHandler.ListAudit)
+
+	serve := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/audit"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	createdAt := time.Date(2025, 12, 20, 12, 0, 0, 0, time.UTC)
+
+	t.Run("full page", func(t *testing.T) {
+		mockAuditRepo.EXPECT().List(gomock.Any(), infrastructure.AuditFilter{
+			ActorID:  2,
+			Action:   entity.AuditActionContainerRemove,
+			Outcome:  entity.AuditOutcomeFailure,
+			Since:    createdAt,
+			BeforeID: 100,
+		}, 2).Return([]*entity.AuditEntry{
+			{ID: 9, CreatedAt: createdAt, ActorID: 2, Action: entity.AuditActionContainerRemove, TargetType: "container", TargetID: "container-1", SourceIP: "10.0.0.1", Outcome: entity.AuditOutcomeFailure, Error: "permission denied", RequestID: "request-9"},
+			{ID: 7, CreatedAt: createdAt, ActorID: 2, Action: entity.AuditActionContainerRemove, TargetType: "container", TargetID: "container-2", SourceIP: "10.0.0.1", Outcome: entity.AuditOutcomeFailure, Error: "container not found", RequestID: "request-7"},
+		}, nil)
+
+		w := serve("?actor_id=2&action=container.remove&outcome=failure&since=2025-12-20T12:00:00Z&before=100&limit=2")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp ListAuditResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Entries, 2)
+		assert.Equal(t, AuditEntryResponse{
+			ID: "9", CreatedAt: createdAt, ActorID: "2", Action: "container.remove", TargetType: "container", TargetID: "container-1",
+			SourceIP: "10.0.0.1", Outcome: "failure", Error: "permission denied", RequestID: "request-9",
+		}, resp.Entries[0])
+		assert.Equal(t, "7", resp.NextBefore)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		mockAuditRepo.EXPECT().List(gomock.Any(), infrastructure.AuditFilter{}, application.DefaultAuditListLimit).Return([]*entity.AuditEntry{{ID: 1}}, nil)
+
+		w := serve("")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "next_before")
+	})
+
+	t.Run("invalid filters", func(t *testing.T) {
+		for _, query := range []string{"?outcome=maybe", "?actor_id=alice", "?since=yesterday", "?limit=0", "?limit=501"} {
+			w := serve(query)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
+
+func TestAuditHandler_ExportAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuditRepo := mocks.NewMockAuditRepository(ctrl)
+	auditHandler := NewAuditHandler(application.NewAuditService(mockAuditRepo))
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	role := "admin"
+	router.GET("/audit/export", func(c *gin.Context) {
+		c.Set("userID", "1")
+		c.Set("role", role)
+	}, auditHandler.ExportAudit)
+
+	serve := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/audit/export?action=user.login", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	filter := infrastructure.AuditFilter{Action: entity.AuditActionUserLogin}
+
+	t.Run("json lines", func(t *testing.T) {
+		mockAuditRepo.EXPECT().List(gomock.Any(), filter, gomock.Any()).Return([]*entity.AuditEntry{
+			{ID: 2, Action: entity.AuditActionUserLogin, TargetType: "user", TargetID: "alice", Outcome: entity.AuditOutcomeSuccess},
+			{ID: 1, Action: entity.AuditActionUserLogin, TargetType: "user", TargetID: "alice", Outcome: entity.AuditOutcomeFailure, Error: "unauthorized"},
+		}, nil)
+
+		w := serve()
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		assert.Len(t, lines, 2)
+		var entry AuditEntryResponse
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+		assert.Equal(t, "1", entry.ID)
+		assert.Equal(t, "unauthorized", entry.Error)
+	})
+
+	t.Run("no entries", func(t *testing.T) {
+		mockAuditRepo.EXPECT().List(gomock.Any(), filter, gomock.Any()).Return([]*entity.AuditEntry{}, nil)
+
+		w := serve()
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockAuditRepo.EXPECT().List(gomock.Any(), filter, gomock.Any()).Return(nil, errors.New("database error"))
+
+		w := serve()
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("not an admin", func(t *testing.T) {
+		role = "member"
+		defer func() { role = "admin" }()
+
+		w := serve()
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"strconv"
+
+	"container-manager/internal/application"
+	"container-manager/internal/domain/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// callerFromContext returns the user the auth middleware authenticated, with the role it loaded.
+func callerFromContext(c *gin.Context) (application.Caller, error) {
+	userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64)
+	if err != nil {
+		return application.Caller{}, err
+	}
+	return application.Caller{UserID: userID, Role: entity.Role(c.GetString("role"))}, nil
+}
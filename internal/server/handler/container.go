@@ -6,6 +6,7 @@ import (
 	"container-manager/internal/errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,38 +21,201 @@ func NewContainerHandler(service *application.ContainerService) *ContainerHandle
 
 // ListContainers godoc
 // @Summary List containers
-// @Description Lists all containers belonging to the authenticated user
+// @Description Lists the containers belonging to the authenticated user, one page at a time
 // @Tags Containers
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
-// @Success 200 {array} ContainerResponse
+// @Param label query []string false "Label filter in key=value form, can be repeated" collectionFormat(multi)
+// @Param status query string false "Container status, e.g. running or exited"
+// @Param image query string false "Image the container was created from"
+// @Param sort query string false "Sort key" Enums(created_at, name, status) default(created_at)
+// @Param order query string false "Sort order" Enums(asc, desc) default(asc)
+// @Param limit query int false "Page size, at most 200" default(50)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} ListContainersResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
 // @Router /containers [get]
 func (h *ContainerHandler) ListContainers(c *gin.Context) {
-	userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64)
+	caller, err := callerFromContext(c)
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
 
-	containers, err := h.service.ListContainers(c.Request.Context(), userID)
+	query, err := parseContainerListQuery(c)
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
 
-	resp := make([]ContainerResponse, 0, len(containers))
-	for _, ct := range containers {
-		resp = append(resp, ContainerResponse{
+	list, err := h.service.ListContainers(c.Request.Context(), caller, query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newListContainersResponse(list))
+}
+
+// ListUserContainers godoc
+// @Summary List the containers of a user
+// @Description Lists the containers belonging to any user, one page at a time. Requires the admin role
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Param label query []string false "Label filter in key=value form, can be repeated" collectionFormat(multi)
+// @Param status query string false "Container status, e.g. running or exited"
+// @Param image query string false "Image the container was created from"
+// @Param sort query string false "Sort key" Enums(created_at, name, status) default(created_at)
+// @Param order query string false "Sort order" Enums(asc, desc) default(asc)
+// @Param limit query int false "Page size, at most 200" default(50)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} ListContainersResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Router /admin/users/{id}/containers [get]
+func (h *ContainerHandler) ListUserContainers(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(errors.BadRequest.New("user ID must be an integer"))
+		return
+	}
+
+	query, err := parseContainerListQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	list, err := h.service.ListUserContainers(c.Request.Context(), caller, userID, query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newListContainersResponse(list))
+}
+
+// ListTeamContainers godoc
+// @Summary List the containers of a team
+// @Description Lists the containers belonging to a team of the authenticated user, one page at a time
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Team ID"
+// @Param label query []string false "Label filter in key=value form, can be repeated" collectionFormat(multi)
+// @Param status query string false "Container status, e.g. running or exited"
+// @Param image query string false "Image the container was created from"
+// @Param sort query string false "Sort key" Enums(created_at, name, status) default(created_at)
+// @Param order query string false "Sort order" Enums(asc, desc) default(asc)
+// @Param limit query int false "Page size, at most 200" default(50)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} ListContainersResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Router /teams/{id}/containers [get]
+func (h *ContainerHandler) ListTeamContainers(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(errors.BadRequest.New("team ID must be an integer"))
+		return
+	}
+
+	query, err := parseContainerListQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	list, err := h.service.ListTeamContainers(c.Request.Context(), caller, teamID, query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newListContainersResponse(list))
+}
+
+func parseContainerListQuery(c *gin.Context) (application.ContainerListQuery, error) {
+	filter := infrastructure.ContainerFilter{
+		Status: c.Query("status"),
+		Image:  c.Query("image"),
+	}
+	for _, label := range c.QueryArray("label") {
+		key, value, ok := strings.Cut(label, "=")
+		if !ok || key == "" {
+			return application.ContainerListQuery{}, errors.InvalidContainerFilter.New("label filter must be in key=value form")
+		}
+		if filter.Labels == nil {
+			filter.Labels = make(map[string]string)
+		}
+		filter.Labels[key] = value
+	}
+
+	query := application.ContainerListQuery{
+		Filter: filter,
+		Sort:   c.Query("sort"),
+		Cursor: c.Query("cursor"),
+	}
+	switch c.Query("order") {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, errors.InvalidContainerFilter.New("order must be asc or desc")
+	}
+	if limit := c.Query("limit"); limit != "" {
+		var err error
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 1 {
+			return query, errors.InvalidContainerFilter.New("limit must be a positive integer")
+		}
+	}
+	return query, nil
+}
+
+func newListContainersResponse(list *application.ContainerList) ListContainersResponse {
+	resp := ListContainersResponse{
+		Containers: make([]ContainerResponse, 0, len(list.Containers)),
+		Failed:     make([]FailedContainerResponse, 0, len(list.Failed)),
+		Total:      list.Total,
+		NextCursor: list.NextCursor,
+	}
+	for _, ct := range list.Containers {
+		resp.Containers = append(resp.Containers, ContainerResponse{
 			ID:     ct.ID,
+			Name:   ct.Name,
 			Image:  ct.Image,
 			Cmd:    ct.Cmd,
 			Env:    ct.Env,
+			Labels: ct.Labels,
 			Status: string(ct.Status),
 		})
 	}
-
-	c.JSON(http.StatusOK, resp)
+	for _, failure := range list.Failed {
+		resp.Failed = append(resp.Failed, FailedContainerResponse{
+			ID:    failure.ID,
+			Name:  failure.Name,
+			Error: failure.Err.Error(),
+		})
+	}
+	return resp
 }
 
 // CreateContainer godoc
@@ -64,6 +228,7 @@ func (h *ContainerHandler) ListContainers(c *gin.Context) {
 // @Security ApiKeyAuth
 // @Param container body CreateContainerRequest true "Container creation request"
 // @Success 200 {object} CreateContainerResponse
+// @Failure 403 {object} ErrorResponse "Quota exceeded"
 // @Router /containers [post]
 func (h *ContainerHandler) CreateContainer(c *gin.Context) {
 	var req CreateContainerRequest
@@ -72,24 +237,30 @@ func (h *ContainerHandler) CreateContainer(c *gin.Context) {
 		return
 	}
 
-	userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64)
+	caller, err := callerFromContext(c)
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
 
 	opts := infrastructure.ContainerCreateOptions{
-		Cmd:   req.Cmd,
-		Env:   req.Env,
-		Image: req.Image,
+		Cmd:         req.Cmd,
+		Env:         req.Env,
+		Image:       req.Image,
+		Labels:      req.Labels,
+		Name:        req.Name,
+		MemoryBytes: req.MemoryBytes,
+		NanoCPUs:    req.NanoCPUs,
+		TeamID:      req.TeamID,
 	}
 
-	jobID, err := h.service.CreateContainer(c.Request.Context(), userID, opts)
+	jobID, err := h.service.CreateContainer(c.Request.Context(), caller, opts)
 
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
+	c.Set("auditTarget", jobID)
 
 	c.JSON(http.StatusOK, CreateContainerResponse{JobID: jobID})
 
@@ -102,18 +273,19 @@ func (h *ContainerHandler) CreateContainer(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
-// @Param id path string true "Container ID"
+// @Param id path string true "Container ID or name"
 // @Success 200 "OK"
+// @Failure 403 {object} ErrorResponse "Quota exceeded"
 // @Router /containers/{id}/start [patch]
 func (h *ContainerHandler) StartContainer(c *gin.Context) {
 	id := c.Param("id")
-	userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64)
+	caller, err := callerFromContext(c)
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
 
-	err = h.service.StartContainer(c.Request.Context(), userID, id)
+	err = h.service.StartContainer(c.Request.Context(), caller, id)
 	if err != nil {
 		_ = c.Error(err)
 		return
@@ -129,18 +301,18 @@ func (h *ContainerHandler) StartContainer(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
-// @Param id path string true "Container ID"
+// @Param id path string true "Container ID or name"
 // @Success 200 "OK"
 // @Router /containers/{id}/stop [patch]
 func (h *ContainerHandler) StopContainer(c *gin.Context) {
 	id := c.Param("id")
-	userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64)
+	caller, err := callerFromContext(c)
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
 
-	err = h.service.StopContainer(c.Request.Context(), userID, id)
+	err = h.service.StopContainer(c.Request.Context(), caller, id)
 	if err != nil {
 		_ = c.Error(err)
 		return
@@ -156,18 +328,54 @@ func (h *ContainerHandler) StopContainer(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
-// @Param id path string true "Container ID"
+// @Param id path string true "Container ID or name"
 // @Success 200 "OK"
 // @Router /containers/{id} [delete]
 func (h *ContainerHandler) RemoveContainer(c *gin.Context) {
 	id := c.Param("id")
-	userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64)
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	err = h.service.RemoveContainer(c.Request.Context(), caller, id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// RenameContainer godoc
+// @Summary Rename a container
+// @Description Changes the name of a specific container for the authenticated user. Names are unique per user.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Container ID or name"
+// @Param container body RenameContainerRequest true "Container rename request"
+// @Success 200 "OK"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 409 {object} ErrorResponse "Conflict"
+// @Router /containers/{id} [patch]
+func (h *ContainerHandler) RenameContainer(c *gin.Context) {
+	var req RenameContainerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(errors.BadRequest.Wrap(err))
+		return
+	}
+
+	id := c.Param("id")
+	caller, err := callerFromContext(c)
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
 
-	err = h.service.RemoveContainer(c.Request.Context(), userID, id)
+	err = h.service.RenameContainer(c.Request.Context(), caller, id, req.Name)
 	if err != nil {
 		_ = c.Error(err)
 		return
@@ -175,3 +383,50 @@ func (h *ContainerHandler) RemoveContainer(c *gin.Context) {
 
 	c.Status(http.StatusOK)
 }
+
+// ContainerLogs godoc
+// @Summary Get the logs of a container
+// @Description Returns the last lines of the stdout and stderr output of a container
+// @Tags Containers
+// @Produce plain
+// @Security ApiKeyAuth
+// @Param id path string true "Container ID or name"
+// @Param tail query int false "Number of lines from the end of the logs, at most 10000" default(100)
+// @Param timestamps query bool false "Prefix every line with its timestamp"
+// @Success 200 {string} string "Container logs"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Not Found"
+// @Router /containers/{id}/logs [get]
+func (h *ContainerHandler) ContainerLogs(c *gin.Context) {
+	id := c.Param("id")
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var options infrastructure.ContainerLogsOptions
+	if tail := c.Query("tail"); tail != "" {
+		options.Tail, err = strconv.Atoi(tail)
+		if err != nil {
+			_ = c.Error(errors.BadRequest.New("tail must be an integer"))
+			return
+		}
+	}
+	if timestamps := c.Query("timestamps"); timestamps != "" {
+		options.Timestamps, err = strconv.ParseBool(timestamps)
+		if err != nil {
+			_ = c.Error(errors.BadRequest.New("timestamps must be a boolean"))
+			return
+		}
+	}
+
+	logs, err := h.service.ContainerLogs(c.Request.Context(), caller, id, options)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", logs)
+}
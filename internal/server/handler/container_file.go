@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"container-manager/internal/application"
+	"container-manager/internal/errors"
+	"mime"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+)
+
+const archiveContentType = "application/x-tar"
+
+// ContainerFileHandler copies files into and out of containers, as tar archives or from and to
+// the file storage.
+type ContainerFileHandler struct {
+	containerService     *application.ContainerService
+	containerFileService *application.ContainerFileService
+}
+
+// NewContainerFileHandler creates a new instance of ContainerFileHandler.
+func NewContainerFileHandler(containerService *application.ContainerService, containerFileService *application.ContainerFileService) *ContainerFileHandler {
+	return &ContainerFileHandler{
+		containerService:     containerService,
+		containerFileService: containerFileService,
+	}
+}
+
+// PutArchive godoc
+// @Summary Copy files into a container
+// @Description Extracts a tar archive into a folder of a container, which has to exist. The container may also be stopped. Existing files are overwritten, but a folder is never replaced by a file or the other way around.
+// @Tags Containers
+// @Accept application/x-tar
+// @Security ApiKeyAuth
+// @Param id path string true "Container ID or name"
+// @Param path query string true "Absolute path of the folder in the container"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse "Invalid path"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Container or folder not found"
+// @Failure 415 {object} ErrorResponse "Content type is not application/x-tar"
+// @Router /containers/{id}/archive [put]
+func (h *ContainerFileHandler) PutArchive(c *gin.Context) {
+	id := c.Param("id")
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type")); mediaType != archiveContentType {
+		_ = c.Error(errors.InvalidArchiveContentType)
+		return
+	}
+
+	if err := h.containerService.CopyToContainer(c.Request.Context(), caller, id, c.Query("path"), c.Request.Body); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetArchive godoc
+// @Summary Copy files out of a container
+// @Description Returns a file or a folder of a container as a tar archive, which is streamed as it is read from the container.
+// @Tags Containers
+// @Produce application/x-tar
+// @Security ApiKeyAuth
+// @Param id path string true "Container ID or name"
+// @Param path query string true "Absolute path of the file or folder in the container"
+// @Success 200 {file} file "Tar archive"
+// @Failure 400 {object} ErrorResponse "Invalid path"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Container or path not found"
+// @Router /containers/{id}/archive [get]
+func (h *ContainerFileHandler) GetArchive(c *gin.Context) {
+	id := c.Param("id")
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	containerPath := c.Query("path")
+	archive, err := h.containerService.CopyFromContainer(c.Request.Context(), caller, id, containerPath)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	defer archive.Close()
+
+	name := path.Base(containerPath)
+	if name == "/" {
+		name = "root"
+	}
+	c.DataFromReader(http.StatusOK, -1, archiveContentType, archive, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": name + ".tar"}),
+	})
+}
+
+// ImportFile godoc
+// @Summary Copy a stored file into a container
+// @Description Copies a file of the authenticated user, or of a team if team_id is set, into a folder of a container. The file keeps its name, and replaces a file of that name in the folder.
+// @Tags Containers
+// @Accept json
+// @Security ApiKeyAuth
+// @Param id path string true "Container ID or name"
+// @Param request body ImportContainerFileRequest true "Stored file and folder in the container"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Container, file or folder not found"
+// @Router /containers/{id}/files/import [post]
+func (h *ContainerFileHandler) ImportFile(c *gin.Context) {
+	id := c.Param("id")
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var req ImportContainerFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(errors.BadRequest.Wrap(err))
+		return
+	}
+
+	if err := h.containerFileService.CopyFileToContainer(c.Request.Context(), caller, id, req.TeamID, req.File, req.Path); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ExportFile godoc
+// @Summary Save a file of a container
+// @Description Saves a file of a container to the files of the authenticated user, or of a team if team_id is set. The file is stored like an upload: it counts against the storage quota, and the upload limits on size and type apply.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Container ID or name"
+// @Param request body ExportContainerFileRequest true "File in the container and path to store it at"
+// @Success 201 {object} UploadFileResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden or storage quota exceeded"
+// @Failure 404 {object} ErrorResponse "Container or path not found"
+// @Failure 409 {object} ErrorResponse "The path in the container is not a regular file, or the team file already exists"
+// @Failure 413 {object} ErrorResponse "File too large"
+// @Failure 415 {object} ErrorResponse "File type not allowed"
+// @Router /containers/{id}/files/export [post]
+func (h *ContainerFileHandler) ExportFile(c *gin.Context) {
+	id := c.Param("id")
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var req ExportContainerFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(errors.BadRequest.Wrap(err))
+		return
+	}
+	c.Set("auditTarget", req.File)
+
+	record, err := h.containerFileService.SaveFileFromContainer(c.Request.Context(), caller, id, req.Path, req.TeamID, req.File)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, UploadFileResponse{
+		Path:        record.Path,
+		Size:        record.Size,
+		SHA256:      record.SHA256,
+		ContentType: record.ContentType,
+	})
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"container-manager/internal/application"
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	internalErrors "container-manager/internal/errors"
+	"container-manager/internal/infrastructure/repository"
+	"container-manager/internal/server/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestContainerFileHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tempDir := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockFileRepo := mocks.NewMockFileRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	authorizer := application.NewAuthorizer(nil)
+	quotaService := application.NewQuotaService(mockQuotaRepo, application.QuotaOptions{})
+	containerService := application.NewContainerService(mockRuntime, mockContainerUserRepo, nil, quotaService, authorizer)
+	fileService := application.NewFileService(repository.NewLocalFileStorage(tempDir), mockFileRepo, quotaService, authorizer, entity.UploadPolicy{})
+	containerFileHandler := NewContainerFileHandler(containerService, application.NewContainerFileService(containerService, fileService))
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "123")
+		c.Set("role", "member")
+		c.Next()
+	})
+	router.PUT("/containers/:id/archive", containerFileHandler.PutArchive)
+	router.GET("/containers/:id/archive", containerFileHandler.GetArchive)
+	router.POST("/containers/:id/files/import", containerFileHandler.ImportFile)
+	router.POST("/containers/:id/files/export", containerFileHandler.ExportFile)
+
+	serve := func(method, path, contentType string, body io.Reader) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, body)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	expectContainer := func() {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(gomock.Any(), int64(123), "c1").Return(&entity.ContainerUser{ContainerID: "c1", UserID: 123}, nil)
+	}
+	archiveOf := func(name, content string) []byte {
+		var buf bytes.Buffer
+		archive := tar.NewWriter(&buf)
+		require.NoError(t, archive.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: int64(len(content)), Mode: 0o644}))
+		_, err := archive.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, archive.Close())
+		return buf.Bytes()
+	}
+
+	t.Run("put archive", func(t *testing.T) {
+		expectContainer()
+		mockRuntime.EXPECT().CopyTo(gomock.Any(), "c1", "/app", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ string, archive io.Reader) error {
+			content, err := io.ReadAll(archive)
+			assert.NoError(t, err)
+			assert.Equal(t, "archive", string(content))
+			return nil
+		})
+
+		w := serve(http.MethodPut, "/containers/c1/archive?path=/app", "application/x-tar", strings.NewReader("archive"))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("put archive of another type", func(t *testing.T) {
+		w := serve(http.MethodPut, "/containers/c1/archive?path=/app", "application/zip", strings.NewReader("archive"))
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("put archive without a path", func(t *testing.T) {
+		w := serve(http.MethodPut, "/containers/c1/archive", "application/x-tar", strings.NewReader("archive"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("get archive", func(t *testing.T) {
+		expectContainer()
+		mockRuntime.EXPECT().CopyFrom(gomock.Any(), "c1", "/app/out.csv").Return(io.NopCloser(strings.NewReader("archive")), nil)
+
+		w := serve(http.MethodGet, "/containers/c1/archive?path=/app/out.csv", "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-tar", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=out.csv.tar`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "archive", w.Body.String())
+	})
+
+	t.Run("get archive of a missing path", func(t *testing.T) {
+		expectContainer()
+		mockRuntime.EXPECT().CopyFrom(gomock.Any(), "c1", "/missing").Return(nil, internalErrors.ContainerPathNotFound)
+
+		w := serve(http.MethodGet, "/containers/c1/archive?path=/missing", "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("import file", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "123", "input"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "123", "input", "data.csv"), []byte("a,b\n"), 0o644))
+		expectContainer()
+		mockRuntime.EXPECT().CopyTo(gomock.Any(), "c1", "/app", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ string, archive io.Reader) error {
+			content := tar.NewReader(archive)
+			header, err := content.Next()
+			require.NoError(t, err)
+			assert.Equal(t, "data.csv", header.Name)
+			data, _ := io.ReadAll(content)
+			assert.Equal(t, "a,b\n", string(data))
+			return nil
+		})
+
+		w := serve(http.MethodPost, "/containers/c1/files/import", "application/json", strings.NewReader(`{"file": "input/data.csv", "path": "/app"}`))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("import missing file", func(t *testing.T) {
+		w := serve(http.MethodPost, "/containers/c1/files/import", "application/json", strings.NewReader(`{"file": "missing.csv", "path": "/app"}`))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("export file", func(t *testing.T) {
+		expectContainer()
+		mockRuntime.EXPECT().CopyFrom(gomock.Any(), "c1", "/app/out.csv").Return(io.NopCloser(bytes.NewReader(archiveOf("out.csv", "x,y\n"))), nil)
+		mockQuotaRepo.EXPECT().GetLimits(gomock.Any(), int64(123)).Return(nil, nil)
+		mockQuotaRepo.EXPECT().Reserve(gomock.Any(), int64(123), entity.QuotaResources{StorageBytes: 4}, entity.QuotaResources{}).Return(nil)
+		mockFileRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+
+		w := serve(http.MethodPost, "/containers/c1/files/export", "application/json", strings.NewReader(`{"path": "/app/out.csv", "file": "results/out.csv"}`))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp UploadFileResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "results/out.csv", resp.Path)
+		assert.Equal(t, int64(4), resp.Size)
+
+		content, err := os.ReadFile(filepath.Join(tempDir, "123", "results", "out.csv"))
+		assert.NoError(t, err)
+		assert.Equal(t, "x,y\n", string(content))
+	})
+
+	t.Run("export without a file", func(t *testing.T) {
+		w := serve(http.MethodPost, "/containers/c1/files/export", "application/json", strings.NewReader(`{"path": "/app/out.csv"}`))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -5,8 +5,11 @@ import (
 	"container-manager/internal/application"
 	"container-manager/internal/application/mocks"
 	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"container-manager/internal/errors"
 	"container-manager/internal/server/middleware"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -24,21 +27,27 @@ func TestContainerHandler_ListContainers(t *testing.T) {
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 	mockJobRepo := mocks.NewMockJobRepository(ctrl)
 
-	containerService := application.NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo)
+	containerService := application.NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo, application.NewQuotaService(mockQuotaRepo, application.QuotaOptions{}), application.NewAuthorizer(nil))
 	containerHandler := NewContainerHandler(containerService)
 
 	router := gin.Default()
 	router.Use(middleware.ErrorHandler())
 	router.Use(func(c *gin.Context) {
 		c.Set("userID", "123")
+		c.Set("role", "member")
 		c.Next()
 	})
 	router.GET("/containers", containerHandler.ListContainers)
 
 	t.Run("success", func(t *testing.T) {
-		mockContainerUserRepo.EXPECT().GetContainerIDsByUserID(gomock.Any(), int64(123)).Return([]string{"c1", "c2"}, nil)
+		mockContainerUserRepo.EXPECT().CountByOwner(gomock.Any(), entity.Owner{UserID: int64(123)}, nil).Return(2, nil)
+		mockContainerUserRepo.EXPECT().GetPageByOwner(gomock.Any(), entity.Owner{UserID: int64(123)}, infrastructure.ContainerUserPage{
+			Sort:  infrastructure.ContainerSortCreatedAt,
+			Limit: application.DefaultContainerListLimit + 1,
+		}).Return([]*entity.ContainerUser{{ContainerID: "c1", UserID: 123}, {ContainerID: "c2", UserID: 123}}, nil)
 		mockRuntime.EXPECT().Inspect(gomock.Any(), "c1").Return(&entity.Container{ID: "c1", Image: "img1", Status: "running"}, nil)
 		mockRuntime.EXPECT().Inspect(gomock.Any(), "c2").Return(&entity.Container{ID: "c2", Image: "img2", Status: "stopped"}, nil)
 
@@ -47,13 +56,90 @@ func TestContainerHandler_ListContainers(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		var resp []ContainerResponse
+		var resp ListContainersResponse
 		err := json.Unmarshal(w.Body.Bytes(), &resp)
 		assert.NoError(t, err)
-		assert.Len(t, resp, 2)
-		assert.Equal(t, "c1", resp[0].ID)
-		assert.Equal(t, "c2", resp[1].ID)
+		assert.Len(t, resp.Containers, 2)
+		assert.Equal(t, "c1", resp.Containers[0].ID)
+		assert.Equal(t, "c2", resp.Containers[1].ID)
+		assert.Empty(t, resp.Failed)
+		assert.Equal(t, 2, resp.Total)
+		assert.Empty(t, resp.NextCursor)
 	})
+
+	t.Run("filtered", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().GetContainerIDsByOwner(gomock.Any(), entity.Owner{UserID: int64(123)}).Return([]string{"c2", "c3"}, nil)
+		mockRuntime.EXPECT().ListIDs(gomock.Any(), infrastructure.ContainerFilter{
+			IDs:    []string{"c2", "c3"},
+			Labels: map[string]string{"env": "prod"},
+			Status: "running",
+			Image:  "img1",
+		}).Return([]string{"c3"}, nil)
+		mockContainerUserRepo.EXPECT().CountByOwner(gomock.Any(), entity.Owner{UserID: int64(123)}, []string{"c3"}).Return(1, nil)
+		mockContainerUserRepo.EXPECT().GetPageByOwner(gomock.Any(), entity.Owner{UserID: int64(123)}, gomock.Any()).Return([]*entity.ContainerUser{{ContainerID: "c3", UserID: 123}}, nil)
+		mockRuntime.EXPECT().Inspect(gomock.Any(), "c3").Return(&entity.Container{ID: "c3", Image: "img1", Status: "running", Labels: map[string]string{"env": "prod"}}, nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/containers?label=env=prod&status=running&image=img1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp ListContainersResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Len(t, resp.Containers, 1)
+		assert.Equal(t, "prod", resp.Containers[0].Labels["env"])
+	})
+
+	t.Run("inspect failure reported", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().CountByOwner(gomock.Any(), entity.Owner{UserID: int64(123)}, nil).Return(1, nil)
+		mockContainerUserRepo.EXPECT().GetPageByOwner(gomock.Any(), entity.Owner{UserID: int64(123)}, gomock.Any()).Return([]*entity.ContainerUser{{ContainerID: "c4", UserID: 123, Name: "gone"}}, nil)
+		mockRuntime.EXPECT().Inspect(gomock.Any(), "c4").Return(nil, fmt.Errorf("no such container: c4"))
+
+		req, _ := http.NewRequest(http.MethodGet, "/containers", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp ListContainersResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Empty(t, resp.Containers)
+		assert.Equal(t, []FailedContainerResponse{{ID: "c4", Name: "gone", Error: "no such container: c4"}}, resp.Failed)
+	})
+
+	t.Run("paginated", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().CountByOwner(gomock.Any(), entity.Owner{UserID: int64(123)}, nil).Return(3, nil)
+		mockContainerUserRepo.EXPECT().GetPageByOwner(gomock.Any(), entity.Owner{UserID: int64(123)}, infrastructure.ContainerUserPage{
+			Sort:       infrastructure.ContainerSortName,
+			Descending: true,
+			Limit:      2,
+		}).Return([]*entity.ContainerUser{{ContainerID: "c5", UserID: 123, Name: "web"}, {ContainerID: "c6", UserID: 123, Name: "db"}}, nil)
+		mockRuntime.EXPECT().Inspect(gomock.Any(), "c5").Return(&entity.Container{ID: "c5", Status: "running"}, nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/containers?sort=name&order=desc&limit=1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp ListContainersResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.Len(t, resp.Containers, 1)
+		assert.Equal(t, "web", resp.Containers[0].Name)
+		assert.Equal(t, 3, resp.Total)
+		assert.NotEmpty(t, resp.NextCursor)
+	})
+
+	for _, query := range []string{"label=env", "limit=abc", "limit=500", "order=up", "sort=image", "cursor=bogus"} {
+		t.Run("invalid query "+query, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/containers?"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
 }
 
 func TestContainerHandler_CreateContainer(t *testing.T) {
@@ -64,15 +150,17 @@ func TestContainerHandler_CreateContainer(t *testing.T) {
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 	mockJobRepo := mocks.NewMockJobRepository(ctrl)
 
-	containerService := application.NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo)
+	containerService := application.NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo, application.NewQuotaService(mockQuotaRepo, application.QuotaOptions{}), application.NewAuthorizer(nil))
 	containerHandler := NewContainerHandler(containerService)
 
 	router := gin.Default()
 	router.Use(middleware.ErrorHandler())
 	router.Use(func(c *gin.Context) {
 		c.Set("userID", "123")
+		c.Set("role", "member")
 		c.Next()
 	})
 	router.POST("/containers", containerHandler.CreateContainer)
@@ -85,6 +173,8 @@ func TestContainerHandler_CreateContainer(t *testing.T) {
 		}
 		body, _ := json.Marshal(reqBody)
 
+		mockQuotaRepo.EXPECT().GetLimits(gomock.Any(), int64(123)).Return(nil, nil)
+		mockQuotaRepo.EXPECT().Reserve(gomock.Any(), int64(123), entity.QuotaResources{Containers: 1}, entity.QuotaResources{}).Return(nil)
 		mockJobRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
 		// The service also starts a goroutine to run the job. 
 		// We can't easily assert on that unless we wait or mock the async part, 
@@ -111,7 +201,7 @@ func TestContainerHandler_CreateContainer(t *testing.T) {
 		// Let's allow subsequent calls just in case.
 		mockJobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 		mockRuntime.EXPECT().Create(gomock.Any(), gomock.Any()).Return("cid", nil).AnyTimes()
-		mockContainerUserRepo.EXPECT().Create(gomock.Any(), "cid", int64(123)).Return(nil).AnyTimes()
+		mockContainerUserRepo.EXPECT().Create(gomock.Any(), &entity.ContainerUser{ContainerID: "cid", UserID: 123}).Return(nil).AnyTimes()
 
 		req, _ := http.NewRequest(http.MethodPost, "/containers", bytes.NewBuffer(body))
 		w := httptest.NewRecorder()
@@ -123,6 +213,31 @@ func TestContainerHandler_CreateContainer(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotEmpty(t, resp.JobID)
 	})
+
+	t.Run("quota exceeded", func(t *testing.T) {
+		body, _ := json.Marshal(CreateContainerRequest{Image: "nginx"})
+
+		mockQuotaRepo.EXPECT().GetLimits(gomock.Any(), int64(123)).Return(&entity.QuotaResources{Containers: 1}, nil)
+		mockQuotaRepo.EXPECT().Reserve(gomock.Any(), int64(123), entity.QuotaResources{Containers: 1}, entity.QuotaResources{Containers: 1}).Return(errors.QuotaExceeded)
+		mockQuotaRepo.EXPECT().GetUsage(gomock.Any(), int64(123)).Return(&entity.QuotaResources{Containers: 1}, nil)
+
+		req, _ := http.NewRequest(http.MethodPost, "/containers", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.JSONEq(t, `{"error":"quota exceeded"}`, w.Body.String())
+	})
+
+	t.Run("negative memory limit", func(t *testing.T) {
+		body, _ := json.Marshal(CreateContainerRequest{Image: "nginx", MemoryBytes: -1})
+
+		req, _ := http.NewRequest(http.MethodPost, "/containers", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
 }
 
 func TestContainerHandler_StartContainer(t *testing.T) {
@@ -133,22 +248,27 @@ func TestContainerHandler_StartContainer(t *testing.T) {
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 	mockJobRepo := mocks.NewMockJobRepository(ctrl)
 
-	containerService := application.NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo)
+	containerService := application.NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo, application.NewQuotaService(mockQuotaRepo, application.QuotaOptions{}), application.NewAuthorizer(nil))
 	containerHandler := NewContainerHandler(containerService)
 
 	router := gin.Default()
 	router.Use(middleware.ErrorHandler())
 	router.Use(func(c *gin.Context) {
 		c.Set("userID", "123")
+		c.Set("role", "member")
 		c.Next()
 	})
 	router.PATCH("/containers/:id/start", containerHandler.StartContainer)
 
 	t.Run("success", func(t *testing.T) {
 		containerID := "c1"
-		mockContainerUserRepo.EXPECT().GetUserIDByContainerID(gomock.Any(), containerID).Return(int64(123), nil)
+		mockContainerUserRepo.EXPECT().FindByIDOrName(gomock.Any(), int64(123), containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: 123}, nil)
+		mockContainerUserRepo.EXPECT().SetRunning(gomock.Any(), containerID, true).Return(true, nil)
+		mockQuotaRepo.EXPECT().GetLimits(gomock.Any(), int64(123)).Return(nil, nil)
+		mockQuotaRepo.EXPECT().Reserve(gomock.Any(), int64(123), entity.QuotaResources{RunningContainers: 1}, entity.QuotaResources{}).Return(nil)
 		mockRuntime.EXPECT().Start(gomock.Any(), containerID).Return(nil)
 
 		req, _ := http.NewRequest(http.MethodPatch, "/containers/c1/start", nil)
@@ -160,7 +280,7 @@ func TestContainerHandler_StartContainer(t *testing.T) {
 
 	t.Run("permission denied", func(t *testing.T) {
 		containerID := "c2"
-		mockContainerUserRepo.EXPECT().GetUserIDByContainerID(gomock.Any(), containerID).Return(int64(456), nil)
+		mockContainerUserRepo.EXPECT().FindByIDOrName(gomock.Any(), int64(123), containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: 456}, nil)
 
 		req, _ := http.NewRequest(http.MethodPatch, "/containers/c2/start", nil)
 		w := httptest.NewRecorder()
@@ -178,23 +298,27 @@ func TestContainerHandler_StopContainer(t *testing.T) {
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 	mockJobRepo := mocks.NewMockJobRepository(ctrl)
 
-	containerService := application.NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo)
+	containerService := application.NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo, application.NewQuotaService(mockQuotaRepo, application.QuotaOptions{}), application.NewAuthorizer(nil))
 	containerHandler := NewContainerHandler(containerService)
 
 	router := gin.Default()
 	router.Use(middleware.ErrorHandler())
 	router.Use(func(c *gin.Context) {
 		c.Set("userID", "123")
+		c.Set("role", "member")
 		c.Next()
 	})
 	router.PATCH("/containers/:id/stop", containerHandler.StopContainer)
 
 	t.Run("success", func(t *testing.T) {
 		containerID := "c1"
-		mockContainerUserRepo.EXPECT().GetUserIDByContainerID(gomock.Any(), containerID).Return(int64(123), nil)
+		mockContainerUserRepo.EXPECT().FindByIDOrName(gomock.Any(), int64(123), containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: 123}, nil)
 		mockRuntime.EXPECT().Stop(gomock.Any(), containerID).Return(nil)
+		mockContainerUserRepo.EXPECT().SetRunning(gomock.Any(), containerID, false).Return(true, nil)
+		mockQuotaRepo.EXPECT().Release(gomock.Any(), int64(123), entity.QuotaResources{RunningContainers: 1}).Return(nil)
 
 		req, _ := http.NewRequest(http.MethodPatch, "/containers/c1/stop", nil)
 		w := httptest.NewRecorder()
@@ -212,23 +336,27 @@ func TestContainerHandler_RemoveContainer(t *testing.T) {
 
 	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
 	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
 	mockJobRepo := mocks.NewMockJobRepository(ctrl)
 
-	containerService := application.NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo)
+	containerService := application.NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo, application.NewQuotaService(mockQuotaRepo, application.QuotaOptions{}), application.NewAuthorizer(nil))
 	containerHandler := NewContainerHandler(containerService)
 
 	router := gin.Default()
 	router.Use(middleware.ErrorHandler())
 	router.Use(func(c *gin.Context) {
 		c.Set("userID", "123")
+		c.Set("role", "member")
 		c.Next()
 	})
 	router.DELETE("/containers/:id", containerHandler.RemoveContainer)
 
 	t.Run("success", func(t *testing.T) {
 		containerID := "c1"
-		mockContainerUserRepo.EXPECT().GetUserIDByContainerID(gomock.Any(), containerID).Return(int64(123), nil)
+		mockContainerUserRepo.EXPECT().FindByIDOrName(gomock.Any(), int64(123), containerID).Return(&entity.ContainerUser{ContainerID: containerID, UserID: 123}, nil)
 		mockRuntime.EXPECT().Remove(gomock.Any(), containerID).Return(nil)
+		mockContainerUserRepo.EXPECT().SetRunning(gomock.Any(), containerID, false).Return(false, nil)
+		mockQuotaRepo.EXPECT().Release(gomock.Any(), int64(123), entity.QuotaResources{Containers: 1}).Return(nil)
 		mockContainerUserRepo.EXPECT().Delete(gomock.Any(), containerID).Return(nil)
 
 		req, _ := http.NewRequest(http.MethodDelete, "/containers/c1", nil)
@@ -238,3 +366,102 @@ func TestContainerHandler_RemoveContainer(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 	})
 }
+
+func TestContainerHandler_RenameContainer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	mockJobRepo := mocks.NewMockJobRepository(ctrl)
+
+	containerService := application.NewContainerService(mockRuntime, mockContainerUserRepo, mockJobRepo, application.NewQuotaService(mockQuotaRepo, application.QuotaOptions{}), application.NewAuthorizer(nil))
+	containerHandler := NewContainerHandler(containerService)
+
+	router := gin.Default()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "123")
+		c.Set("role", "member")
+		c.Next()
+	})
+	router.PATCH("/containers/:id", containerHandler.RenameContainer)
+
+	t.Run("success", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(gomock.Any(), int64(123), "web").Return(&entity.ContainerUser{ContainerID: "c1", UserID: 123, Name: "web"}, nil)
+		mockContainerUserRepo.EXPECT().UpdateName(gomock.Any(), "c1", "api").Return(nil)
+
+		body, _ := json.Marshal(RenameContainerRequest{Name: "api"})
+		req, _ := http.NewRequest(http.MethodPatch, "/containers/web", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(gomock.Any(), int64(123), "c1").Return(&entity.ContainerUser{ContainerID: "c1", UserID: 123}, nil)
+		mockContainerUserRepo.EXPECT().UpdateName(gomock.Any(), "c1", "api").Return(errors.ContainerNameConflict)
+
+		body, _ := json.Marshal(RenameContainerRequest{Name: "api"})
+		req, _ := http.NewRequest(http.MethodPatch, "/containers/c1", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("missing name", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPatch, "/containers/c1", bytes.NewBufferString("{}"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestContainerHandler_ContainerLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRuntime := mocks.NewMockContainerRuntime(ctrl)
+	mockContainerUserRepo := mocks.NewMockContainerUserRepository(ctrl)
+
+	containerService := application.NewContainerService(mockRuntime, mockContainerUserRepo, nil, nil, application.NewAuthorizer(nil))
+	containerHandler := NewContainerHandler(containerService)
+
+	router := gin.Default()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "123")
+		c.Set("role", "member")
+		c.Next()
+	})
+	router.GET("/containers/:id/logs", containerHandler.ContainerLogs)
+
+	t.Run("success", func(t *testing.T) {
+		mockContainerUserRepo.EXPECT().FindByIDOrName(gomock.Any(), int64(123), "c1").Return(&entity.ContainerUser{ContainerID: "c1", UserID: 123}, nil)
+		mockRuntime.EXPECT().Logs(gomock.Any(), "c1", infrastructure.ContainerLogsOptions{Tail: 20, Timestamps: true}).Return([]byte("line 1\nline 2\n"), nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/containers/c1/logs?tail=20&timestamps=true", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "line 1\nline 2\n", w.Body.String())
+	})
+
+	t.Run("invalid tail", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/containers/c1/logs?tail=all", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -3,64 +3,464 @@ package handler
 import (
 	"container-manager/internal/application"
 	"container-manager/internal/errors"
+	stderrors "errors"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"path"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 // FileHandler handles file-related HTTP requests.
 type FileHandler struct {
-	fileService *application.FileService
+	fileService    *application.FileService
+	extractService *application.ExtractService
 }
 
 // NewFileHandler creates a new instance of FileHandler.
-func NewFileHandler(fs *application.FileService) *FileHandler {
+func NewFileHandler(fs *application.FileService, extractService *application.ExtractService) *FileHandler {
 	return &FileHandler{
-		fileService: fs,
+		fileService:    fs,
+		extractService: extractService,
 	}
 }
 
+// maxFormFieldSize bounds the form fields of an upload, which are read into memory.
+const maxFormFieldSize = 4096
+
+// maxUploadFormOverhead is what an upload request may hold besides the file: the form fields, the
+// headers of the parts and the boundaries between them.
+const maxUploadFormOverhead = 64 << 10
+
 // UploadFile handles file upload requests.
 // @Summary Upload file
-// @Description Uploads a file to the user's dedicated storage folder.
+// @Description Uploads a file to the user's dedicated storage folder, or to the folder of a team if team_id is set. The file is put into the folder given by path, which is created as needed. The file replaces an existing one only once all of it is stored, and the response holds the SHA-256 checksum of what was stored. The file is stored as it is received, so team_id and path have to come before it in the form. Files larger than the configured maximum size, or of a type that is not allowed, as sniffed from their content, are rejected. With extract set, the file has to be a tar, tar.gz or zip archive, which a job extracts into a folder named after it, next to it; the archive is removed once extracted, and the response is 202 with the ID of the job.
 // @Tags Files
 // @Accept multipart/form-data
 // @Produce json
 // @Security ApiKeyAuth
+// @Param team_id formData int false "Team that owns the file"
+// @Param path formData string false "Folder to upload the file into"
+// @Param extract formData bool false "Extract the uploaded archive into a folder"
 // @Param file formData file true "File to upload"
-// @Success 200 "OK"
+// @Success 200 {object} UploadFileResponse
+// @Success 202 {object} UploadFileResponse "Archive uploaded, and being extracted by the job"
+// @Failure 403 {object} ErrorResponse "Storage quota exceeded"
+// @Failure 409 {object} ErrorResponse "Team file or the folder to extract into already exists"
+// @Failure 411 {object} ErrorResponse "Request without a length, if there is no maximum size"
+// @Failure 413 {object} ErrorResponse "File too large"
+// @Failure 415 {object} ErrorResponse "File type not allowed, or not an archive to extract"
 // @Router /files [post]
 func (h *FileHandler) UploadFile(c *gin.Context) {
-	userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64)
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// The file is limited while it is stored, and the request around it as well, so that neither
+	// the form fields nor a request without a length can go on without end.
+	if maxFileSize := h.fileService.MaxFileSize(); maxFileSize > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxFileSize+maxUploadFormOverhead)
+	}
+
+	form, err := readUploadForm(c.Request)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	// The folder is not joined with path.Join, which would resolve ".." elements before the
+	// file service gets to reject them.
+	filename := form.file.FileName()
+	if form.dir != "" {
+		filename = form.dir + "/" + filename
+	}
+	c.Set("auditTarget", filename)
+
+	// The file is no larger than the request it is part of.
+	record, err := h.fileService.UploadFile(c.Request.Context(), caller, form.teamID, filename, c.Request.ContentLength, form.file)
+	if err != nil {
+		_ = c.Error(requestTooLarge(err))
+		return
+	}
+
+	resp := UploadFileResponse{
+		Path:        record.Path,
+		Size:        record.Size,
+		SHA256:      record.SHA256,
+		ContentType: record.ContentType,
+	}
+	if !form.extract {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	resp.JobID, err = h.extractService.ExtractUpload(c.Request.Context(), caller, form.teamID, record.Path)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// uploadForm is the multipart form of UploadFile, read up to the file.
+type uploadForm struct {
+	teamID  int64
+	dir     string
+	extract bool
+	file    *multipart.Part
+}
+
+// requestTooLarge reports a request cut off by http.MaxBytesReader as errors.FileTooLarge.
+func requestTooLarge(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if stderrors.As(err, &maxBytesErr) {
+		return errors.FileTooLarge
+	}
+	return err
+}
+
+// uploadFormError reports an upload form that cannot be read as a bad request, unless the request
+// was too large.
+func uploadFormError(err error) error {
+	if err := requestTooLarge(err); err == errors.FileTooLarge {
+		return err
+	}
+	return errors.BadRequest.Wrap(err)
+}
+
+// readUploadForm reads the fields of an upload form up to the file, which is left to be streamed
+// to the storage instead of being buffered. Fields after the file are ignored.
+func readUploadForm(r *http.Request) (*uploadForm, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, uploadFormError(err)
+	}
+	form := &uploadForm{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, errors.BadRequest.New("file is required")
+		}
+		if err != nil {
+			return nil, uploadFormError(err)
+		}
+		name := part.FormName()
+		if name == "file" {
+			if part.FileName() == "" {
+				return nil, errors.BadRequest.New("filename cannot be empty")
+			}
+			form.file = part
+			return form, nil
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, maxFormFieldSize+1))
+		if err != nil {
+			return nil, uploadFormError(err)
+		}
+		if len(value) > maxFormFieldSize {
+			return nil, errors.BadRequest.New("form field " + name + " is too long")
+		}
+		switch name {
+		case "team_id":
+			if len(value) == 0 {
+				continue
+			}
+			form.teamID, err = strconv.ParseInt(string(value), 10, 64)
+			if err != nil {
+				return nil, errors.BadRequest.New("team ID must be an integer")
+			}
+		case "path":
+			form.dir = string(value)
+		case "extract":
+			if len(value) == 0 {
+				continue
+			}
+			form.extract, err = strconv.ParseBool(string(value))
+			if err != nil {
+				return nil, errors.BadRequest.New("extract must be a boolean")
+			}
+		}
+	}
+}
+
+// ListFiles godoc
+// @Summary List files
+// @Description Lists the files and folders of the authenticated user, or of a team if team_id is set. Only the top folder is listed unless path names another folder, and recursive lists the content of every folder below as well.
+// @Tags Files
+// @Produce json
+// @Security ApiKeyAuth
+// @Param team_id query int false "Team that owns the files"
+// @Param path query string false "Folder to list"
+// @Param recursive query bool false "List the content of folders as well"
+// @Success 200 {object} ListFilesResponse
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Folder not found"
+// @Router /files [get]
+func (h *FileHandler) ListFiles(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	teamID, err := teamIDFromQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	recursive, err := boolFromQuery(c, "recursive")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	files, err := h.fileService.ListFiles(c.Request.Context(), caller, teamID, c.Query("path"), recursive)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	resp := ListFilesResponse{Files: make([]FileResponse, 0, len(files))}
+	for _, file := range files {
+		fileType := "file"
+		if file.IsDir {
+			fileType = "folder"
+		}
+		resp.Files = append(resp.Files, FileResponse{
+			Name:        file.Name,
+			Type:        fileType,
+			Size:        file.Size,
+			ModifiedAt:  file.ModTime,
+			ContentType: file.ContentType,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DownloadFile godoc
+// @Summary Download file
+// @Description Downloads a file of the authenticated user, or of a team if team_id is set. Supports Range requests, and If-None-Match with the returned ETag.
+// @Tags Files
+// @Produce octet-stream
+// @Security ApiKeyAuth
+// @Param path path string true "Path of the file"
+// @Param team_id query int false "Team that owns the file"
+// @Success 200 {file} file
+// @Success 206 {file} file "Partial Content"
+// @Success 304 "Not Modified"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Not Found"
+// @Failure 416 "Range Not Satisfiable"
+// @Router /files/{path} [get]
+func (h *FileHandler) DownloadFile(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	teamID, err := teamIDFromQuery(c)
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
 
-	file, err := c.FormFile("file")
+	file, info, err := h.fileService.OpenFile(c.Request.Context(), caller, teamID, filePathParam(c))
 	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	defer file.Close()
+
+	c.Header("ETag", info.ETag)
+	c.Header("Content-Type", info.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(info.Name)}))
+	// ServeContent answers Range, If-None-Match and If-Modified-Since requests.
+	http.ServeContent(c.Writer, c.Request, info.Name, info.ModTime, file)
+}
+
+// DeleteFile godoc
+// @Summary Delete file
+// @Description Deletes a file or a folder of the authenticated user, or of a team if team_id is set. Folders have to be empty unless recursive is set. The size of personal files is given back to the storage quota.
+// @Tags Files
+// @Security ApiKeyAuth
+// @Param path path string true "Path of the file or folder"
+// @Param team_id query int false "Team that owns the file"
+// @Param recursive query bool false "Delete a folder with everything in it"
+// @Success 204 "No Content"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Not Found"
+// @Failure 409 {object} ErrorResponse "Folder not empty"
+// @Router /files/{path} [delete]
+func (h *FileHandler) DeleteFile(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	teamID, err := teamIDFromQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	recursive, err := boolFromQuery(c, "recursive")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	filename := filePathParam(c)
+	c.Set("auditTarget", filename)
+	if err := h.fileService.DeleteFile(c.Request.Context(), caller, teamID, filename, recursive); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateFolder godoc
+// @Summary Create folder
+// @Description Creates a folder, along with its parents, for the authenticated user or for a team if team_id is set. Creating an existing folder succeeds.
+// @Tags Files
+// @Accept json
+// @Security ApiKeyAuth
+// @Param request body CreateFolderRequest true "Folder to create"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 409 {object} ErrorResponse "A file is in the way"
+// @Router /files/folders [post]
+func (h *FileHandler) CreateFolder(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var req CreateFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(errors.BadRequest.Wrap(err))
+		return
+	}
+	c.Set("auditTarget", req.Path)
+
+	if err := h.fileService.CreateFolder(c.Request.Context(), caller, req.TeamID, req.Path); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// MoveFile godoc
+// @Summary Move file
+// @Description Moves or renames a file or a folder of the authenticated user, or of a team if team_id is set. The folders of the new path are created as needed, and nothing is replaced.
+// @Tags Files
+// @Accept json
+// @Security ApiKeyAuth
+// @Param request body MoveFileRequest true "Old and new path"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Not Found"
+// @Failure 409 {object} ErrorResponse "New path already taken"
+// @Router /files/move [post]
+func (h *FileHandler) MoveFile(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var req MoveFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		_ = c.Error(errors.BadRequest.Wrap(err))
 		return
 	}
+	c.Set("auditTarget", req.From)
+
+	if err := h.fileService.MoveFile(c.Request.Context(), caller, req.TeamID, req.From, req.To); err != nil {
+		_ = c.Error(err)
+		return
+	}
 
-	openedFile, err := file.Open()
+	c.Status(http.StatusNoContent)
+}
+
+// ExtractFile godoc
+// @Summary Extract archive
+// @Description Starts a job extracting a stored tar, tar.gz or zip archive of the authenticated user, or of a team if team_id is set, into a folder. The folder must not exist yet, and is named after the archive, next to it, if to is not set. The archive is scanned before anything is written, and the job fails if an entry would leave the folder or is a link, or if the archive holds too many files or expands too much. Other special entries, like named pipes, are skipped. The files count against the storage quota, and the upload limits on size and type apply to each of them. If the job fails, the folder is removed again.
+// @Tags Files
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body ExtractFileRequest true "Archive and folder to extract it into"
+// @Success 202 {object} ExtractFileResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Archive not found"
+// @Failure 409 {object} ErrorResponse "The folder already exists, or the archive is already being extracted into it"
+// @Failure 415 {object} ErrorResponse "Not a tar, tar.gz or zip archive"
+// @Router /files/extract [post]
+func (h *FileHandler) ExtractFile(c *gin.Context) {
+	caller, err := callerFromContext(c)
 	if err != nil {
-		_ = c.Error(errors.InternalServerError.Wrap(err))
+		_ = c.Error(err)
 		return
 	}
-	defer openedFile.Close()
 
-	if file.Filename == "" {
-		_ = c.Error(errors.BadRequest.New("filename cannot be empty"))
+	var req ExtractFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(errors.BadRequest.Wrap(err))
 		return
 	}
+	c.Set("auditTarget", req.Path)
 
-	err = h.fileService.UploadFile(userID, file.Filename, openedFile)
+	jobID, err := h.extractService.ExtractArchive(c.Request.Context(), caller, req.TeamID, req.Path, req.To, false)
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
 
-	c.Status(http.StatusOK)
+	c.JSON(http.StatusAccepted, ExtractFileResponse{JobID: jobID})
+}
+
+// filePathParam returns the path of the file a request names, without the leading slash of the
+// catch-all parameter.
+func filePathParam(c *gin.Context) string {
+	return strings.TrimPrefix(c.Param("path"), "/")
+}
+
+func boolFromQuery(c *gin.Context, key string) (bool, error) {
+	value := c.Query(key)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.BadRequest.New(key + " must be true or false")
+	}
+	return b, nil
+}
+
+func teamIDFromQuery(c *gin.Context) (int64, error) {
+	value := c.Query("team_id")
+	if value == "" {
+		return 0, nil
+	}
+	teamID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, errors.BadRequest.New("team ID must be an integer")
+	}
+	return teamID, nil
 }
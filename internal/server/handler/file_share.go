@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"container-manager/internal/application"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/errors"
+	stderrors "errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FileShareHandler handles share links, which let anyone download a file without logging in.
+type FileShareHandler struct {
+	service *application.FileShareService
+	// baseURL is what the URLs of links start with, or empty to use the host of the request.
+	baseURL string
+}
+
+// NewFileShareHandler creates a new instance of FileShareHandler.
+func NewFileShareHandler(service *application.FileShareService, baseURL string) *FileShareHandler {
+	return &FileShareHandler{
+		service: service,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// CreateShare godoc
+// @Summary Share a file
+// @Description Creates a link that lets anyone who has it download a file of the authenticated user, or of a team if team_id is set, without logging in. The link expires at expires_at, or after the configured default time, and can be used max_downloads times if it is set. Every request with the link counts as a download, including Range requests. Folders cannot be shared, and read-only users and team viewers cannot share files.
+// @Tags Files
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param path path string true "Path of the file"
+// @Param request body CreateFileShareRequest false "Expiry and download limit of the link"
+// @Success 201 {object} CreateFileShareResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "File not found"
+// @Router /files/share/{path} [post]
+func (h *FileShareHandler) CreateShare(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// The body is optional, every field has a default.
+	var req CreateFileShareRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil && !stderrors.Is(err, io.EOF) {
+			_ = c.Error(errors.BadRequest.Wrap(err))
+			return
+		}
+	}
+	filename := filePathParam(c)
+	c.Set("auditTarget", filename)
+
+	share, token, err := h.service.CreateShare(c.Request.Context(), caller, req.TeamID, filename, req.ExpiresAt, req.MaxDownloads)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateFileShareResponse{
+		FileShareResponse: toFileShareResponse(share),
+		URL:               h.shareURL(c, token),
+		Token:             token,
+	})
+}
+
+// ListShares godoc
+// @Summary List share links
+// @Description Lists the share links the authenticated user created that are neither revoked nor expired, newest first, or those to the files of a team if team_id is set, for those who may share its files. The links themselves are only returned when they are created.
+// @Tags Files
+// @Produce json
+// @Security ApiKeyAuth
+// @Param team_id query int false "Team that owns the shared files"
+// @Success 200 {object} ListFileSharesResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Router /shares [get]
+func (h *FileShareHandler) ListShares(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	teamID, err := teamIDFromQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	shares, err := h.service.ListShares(c.Request.Context(), caller, teamID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	resp := ListFileSharesResponse{Shares: make([]FileShareResponse, 0, len(shares))}
+	for _, share := range shares {
+		resp.Shares = append(resp.Shares, toFileShareResponse(share))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RevokeShare godoc
+// @Summary Revoke a share link
+// @Description Revokes a share link, downloads with it are rejected from then on. Users revoke the links they created, and those who may share the files of a team, like its owners, revoke every link to them. Admins revoke any link.
+// @Tags Files
+// @Security ApiKeyAuth
+// @Param id path string true "Share link ID"
+// @Success 204 "No Content"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Not Found"
+// @Router /shares/{id} [delete]
+func (h *FileShareHandler) RevokeShare(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if err := h.service.RevokeShare(c.Request.Context(), caller, c.Param("id")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DownloadSharedFile godoc
+// @Summary Download a shared file
+// @Description Downloads the file a share link names, without logging in. Supports Range requests, and If-None-Match with the returned ETag.
+// @Tags Files
+// @Produce octet-stream
+// @Param token path string true "Token of the share link"
+// @Success 200 {file} file
+// @Success 206 {file} file "Partial Content"
+// @Success 304 "Not Modified"
+// @Failure 404 {object} ErrorResponse "Unknown link, or the file no longer exists"
+// @Failure 410 {object} ErrorResponse "Link expired, revoked or used up"
+// @Failure 416 "Range Not Satisfiable"
+// @Router /shared/{token} [get]
+func (h *FileShareHandler) DownloadSharedFile(c *gin.Context) {
+	// The token is in the URL, which the file should not pass on to the sites it links to.
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("Cache-Control", "private, no-store")
+
+	file, info, err := h.service.OpenSharedFile(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	defer file.Close()
+
+	c.Header("ETag", info.ETag)
+	c.Header("Content-Type", info.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(info.Name)}))
+	http.ServeContent(c.Writer, c.Request, info.Name, info.ModTime, file)
+}
+
+// shareURL returns the URL of the link with the given token.
+func (h *FileShareHandler) shareURL(c *gin.Context, token string) string {
+	baseURL := h.baseURL
+	if baseURL == "" {
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		baseURL = scheme + "://" + c.Request.Host
+	}
+	return baseURL + "/shared/" + token
+}
+
+func toFileShareResponse(share *entity.FileShare) FileShareResponse {
+	return FileShareResponse{
+		ID:           share.ID,
+		UserID:       share.UserID,
+		Path:         share.Path,
+		TeamID:       share.TeamID,
+		MaxDownloads: share.MaxDownloads,
+		Downloads:    share.Downloads,
+		ExpiresAt:    share.ExpiresAt,
+		CreatedAt:    share.CreatedAt,
+	}
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"container-manager/internal/application"
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/infrastructure/repository"
+	"container-manager/internal/server/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFileShareHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tempDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "1234", "reports"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "1234", "reports", "q1.csv"), []byte("a,b\n"), 0o644))
+
+	mockShareRepo := mocks.NewMockFileShareRepository(ctrl)
+	mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(1234)).Return(&entity.User{ID: 1234, Role: entity.RoleMember}, nil).AnyTimes()
+	fileService := application.NewFileService(repository.NewLocalFileStorage(tempDir), nil, nil, application.NewAuthorizer(mockTeamRepo), entity.UploadPolicy{})
+	shareService := application.NewFileShareService(fileService, mockShareRepo, mockUserRepo, application.FileShareOptions{Secret: []byte("secret"), DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour})
+	fileShareHandler := NewFileShareHandler(shareService, "https://files.example.com/")
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	// Share links are used without logging in.
+	router.GET("/shared/:token", fileShareHandler.DownloadSharedFile)
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "1234")
+		c.Set("role", "member")
+		c.Next()
+	})
+	router.POST("/files/share/*path", fileShareHandler.CreateShare)
+	router.GET("/shares", fileShareHandler.ListShares)
+	router.DELETE("/shares/:id", fileShareHandler.RevokeShare)
+
+	// create shares reports/q1.csv and returns the stored share with the response.
+	create := func(t *testing.T, body string) (*entity.FileShare, CreateFileShareResponse) {
+		var stored *entity.FileShare
+		mockShareRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, share *entity.FileShare) error {
+			stored = share
+			return nil
+		})
+
+		req, _ := http.NewRequest(http.MethodPost, "/files/share/reports/q1.csv", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var resp CreateFileShareResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return stored, resp
+	}
+
+	t.Run("create", func(t *testing.T) {
+		expiresAt := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+		body, _ := json.Marshal(CreateFileShareRequest{ExpiresAt: &expiresAt, MaxDownloads: 2})
+		share, resp := create(t, string(body))
+
+		assert.Equal(t, share.ID, resp.ID)
+		assert.Equal(t, "reports/q1.csv", resp.Path)
+		assert.Equal(t, 2, resp.MaxDownloads)
+		assert.True(t, expiresAt.Equal(resp.ExpiresAt))
+		assert.Equal(t, "https://files.example.com/shared/"+resp.Token, resp.URL)
+	})
+
+	t.Run("create without a body", func(t *testing.T) {
+		share, resp := create(t, "")
+
+		assert.Equal(t, 0, share.MaxDownloads)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), resp.ExpiresAt, 2*time.Second)
+	})
+
+	t.Run("create with an invalid body", func(t *testing.T) {
+		for _, body := range []string{`{"max_downloads": -1}`, `{"expires_at": "2000-01-01T00:00:00Z"}`, `{`} {
+			req, _ := http.NewRequest(http.MethodPost, "/files/share/reports/q1.csv", strings.NewReader(body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+	})
+
+	t.Run("create for a folder", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/files/share/reports", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("download", func(t *testing.T) {
+		share, resp := create(t, "")
+		mockShareRepo.EXPECT().GetByID(gomock.Any(), share.ID).Return(share, nil)
+		mockShareRepo.EXPECT().CountDownload(gomock.Any(), share.ID, gomock.Any()).Return(true, nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/shared/"+resp.Token, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "a,b\n", w.Body.String())
+		assert.Equal(t, `attachment; filename=q1.csv`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+		assert.NotEmpty(t, w.Header().Get("ETag"))
+	})
+
+	t.Run("download with a revoked link", func(t *testing.T) {
+		share, resp := create(t, "")
+		revoked := *share
+		now := time.Now()
+		revoked.RevokedAt = &now
+		mockShareRepo.EXPECT().GetByID(gomock.Any(), share.ID).Return(&revoked, nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/shared/"+resp.Token, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusGone, w.Code)
+	})
+
+	t.Run("download with a used up link", func(t *testing.T) {
+		share, resp := create(t, `{"max_downloads": 1}`)
+		used := *share
+		used.Downloads = 1
+		mockShareRepo.EXPECT().GetByID(gomock.Any(), share.ID).Return(&used, nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/shared/"+resp.Token, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusGone, w.Code)
+	})
+
+	t.Run("download with an invalid token", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/shared/not.a-token", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		mockShareRepo.EXPECT().ListByUserID(gomock.Any(), int64(1234), gomock.Any()).Return([]*entity.FileShare{
+			{ID: "share-id", UserID: 1234, TeamID: 7, Path: "q1.csv", ExpiresAt: time.Now().Add(time.Hour), CreatedAt: time.Now()},
+		}, nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/shares", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp ListFileSharesResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Shares, 1)
+		assert.Equal(t, int64(7), resp.Shares[0].TeamID)
+		assert.Equal(t, int64(1234), resp.Shares[0].UserID)
+		assert.NotContains(t, w.Body.String(), "token")
+	})
+
+	t.Run("list for a team", func(t *testing.T) {
+		mockTeamRepo.EXPECT().GetMember(gomock.Any(), int64(7), int64(1234)).Return(&entity.TeamMember{TeamID: 7, UserID: 1234, Role: entity.TeamRoleOwner}, nil)
+		mockShareRepo.EXPECT().ListByTeamID(gomock.Any(), int64(7), gomock.Any()).Return([]*entity.FileShare{
+			{ID: "team-share-id", UserID: 5678, TeamID: 7, Path: "plan.csv", ExpiresAt: time.Now().Add(time.Hour), CreatedAt: time.Now()},
+		}, nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/shares?team_id=7", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp ListFileSharesResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Shares, 1)
+		assert.Equal(t, int64(5678), resp.Shares[0].UserID)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		mockShareRepo.EXPECT().GetByID(gomock.Any(), "share-id").Return(&entity.FileShare{ID: "share-id", UserID: 1234}, nil)
+		mockShareRepo.EXPECT().Revoke(gomock.Any(), "share-id").Return(true, nil)
+
+		req, _ := http.NewRequest(http.MethodDelete, "/shares/share-id", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("revoke unknown link", func(t *testing.T) {
+		mockShareRepo.EXPECT().GetByID(gomock.Any(), "other-id").Return(nil, nil)
+
+		req, _ := http.NewRequest(http.MethodDelete, "/shares/other-id", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("revoke a link of another user", func(t *testing.T) {
+		mockShareRepo.EXPECT().GetByID(gomock.Any(), "share-id").Return(&entity.FileShare{ID: "share-id", UserID: 5678}, nil)
+
+		req, _ := http.NewRequest(http.MethodDelete, "/shares/share-id", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -1,20 +1,32 @@
 package handler
 
 import (
+	"archive/zip"
 	"bytes"
 	"container-manager/internal/server/middleware"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"container-manager/internal/application"
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/errors"
 	"container-manager/internal/infrastructure/repository"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 )
 
 func TestFileHandler_UploadFile(t *testing.T) {
@@ -27,9 +39,15 @@ func TestFileHandler_UploadFile(t *testing.T) {
 	defer os.RemoveAll(tempDir) // Clean up the temporary directory
 
 	// Initialize dependencies
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
 	localFileStorage := repository.NewLocalFileStorage(tempDir)
-	fileService := application.NewFileService(localFileStorage)
-	fileHandler := NewFileHandler(fileService)
+	mockFileRepo := mocks.NewMockFileRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	limits := entity.QuotaResources{StorageBytes: 1024}
+	fileService := application.NewFileService(localFileStorage, mockFileRepo, application.NewQuotaService(mockQuotaRepo, application.QuotaOptions{DefaultLimits: limits}), application.NewAuthorizer(nil), entity.UploadPolicy{})
+	fileHandler := NewFileHandler(fileService, nil)
 
 	// Setup Gin router
 	router := gin.Default()
@@ -37,6 +55,7 @@ func TestFileHandler_UploadFile(t *testing.T) {
 	// Mock authentication middleware to set userID in context
 	router.Use(func(c *gin.Context) {
 		c.Set("userID", "1234")
+		c.Set("role", "member")
 		c.Next()
 	})
 	router.POST("/upload", fileHandler.UploadFile)
@@ -55,6 +74,13 @@ func TestFileHandler_UploadFile(t *testing.T) {
 		assert.NoError(t, err)
 		writer.Close()
 
+		// The length of the request is reserved, and what the file does not use given back.
+		requestSize := int64(body.Len())
+		mockQuotaRepo.EXPECT().GetLimits(gomock.Any(), int64(1234)).Return(nil, nil)
+		mockQuotaRepo.EXPECT().Reserve(gomock.Any(), int64(1234), entity.QuotaResources{StorageBytes: requestSize}, limits).Return(nil)
+		mockQuotaRepo.EXPECT().Release(gomock.Any(), int64(1234), entity.QuotaResources{StorageBytes: requestSize - int64(len(fileContent))}).Return(nil)
+		mockFileRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+
 		// Create a request
 		req, _ := http.NewRequest(http.MethodPost, "/upload", body)
 		req.Header.Set("Content-Type", writer.FormDataContentType())
@@ -65,6 +91,8 @@ func TestFileHandler_UploadFile(t *testing.T) {
 
 		// Assertions
 		assert.Equal(t, http.StatusOK, w.Code)
+		sum := sha256.Sum256([]byte(fileContent))
+		assert.JSONEq(t, `{"path":"testfile.txt","size":28,"sha256":"`+hex.EncodeToString(sum[:])+`","content_type":"text/plain; charset=utf-8"}`, w.Body.String())
 
 		// Verify the file was actually saved
 		savedFilePath := filepath.Join(tempDir, "1234", filename)
@@ -83,4 +111,514 @@ func TestFileHandler_UploadFile(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		assert.Contains(t, w.Body.String(), "bad request")
 	})
+
+	t.Run("storage quota exceeded", func(t *testing.T) {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("file", "large.txt")
+		assert.NoError(t, err)
+		_, err = part.Write(bytes.Repeat([]byte("a"), 2048))
+		assert.NoError(t, err)
+		writer.Close()
+
+		mockQuotaRepo.EXPECT().GetLimits(gomock.Any(), int64(1234)).Return(nil, nil)
+		mockQuotaRepo.EXPECT().Reserve(gomock.Any(), int64(1234), entity.QuotaResources{StorageBytes: int64(body.Len())}, limits).Return(errors.QuotaExceeded)
+		mockQuotaRepo.EXPECT().GetUsage(gomock.Any(), int64(1234)).Return(&entity.QuotaResources{}, nil)
+
+		req, _ := http.NewRequest(http.MethodPost, "/upload", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		_, err = os.Stat(filepath.Join(tempDir, "1234", "large.txt"))
+		assert.True(t, os.IsNotExist(err), "file over the quota should not be saved")
+	})
+}
+
+func TestFileHandler_UploadFile_Policy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tempDir := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	localFileStorage := repository.NewLocalFileStorage(tempDir)
+	policy := entity.UploadPolicy{MaxFileSize: 64, DeniedTypes: []string{"text/html"}}
+	fileService := application.NewFileService(localFileStorage, nil, nil, application.NewAuthorizer(nil), policy)
+	fileHandler := NewFileHandler(fileService, nil)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "1234")
+		c.Set("role", "member")
+		c.Next()
+	})
+	router.POST("/files", fileHandler.UploadFile)
+
+	upload := func(fields map[string]string, filename, content string) *httptest.ResponseRecorder {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		for key, value := range fields {
+			assert.NoError(t, writer.WriteField(key, value))
+		}
+		if filename != "" {
+			part, err := writer.CreateFormFile("file", filename)
+			assert.NoError(t, err)
+			_, err = part.Write([]byte(content))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, writer.Close())
+		req, _ := http.NewRequest(http.MethodPost, "/files", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("file too large", func(t *testing.T) {
+		w := upload(nil, "large.txt", strings.Repeat("a", 65))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.JSONEq(t, `{"error":"file exceeds the maximum size"}`, w.Body.String())
+		_, err := os.Stat(filepath.Join(tempDir, "1234", "large.txt"))
+		assert.True(t, os.IsNotExist(err), "file over the maximum size should not be saved")
+	})
+
+	t.Run("type not allowed", func(t *testing.T) {
+		// Sniffed from the content, whatever the name says
+		w := upload(nil, "page.txt", "<html><body>hi</body></html>")
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.JSONEq(t, `{"error":"file type is not allowed"}`, w.Body.String())
+	})
+
+	t.Run("no file", func(t *testing.T) {
+		w := upload(map[string]string{"path": "docs"}, "", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("form field too long", func(t *testing.T) {
+		w := upload(map[string]string{"path": strings.Repeat("a", maxFormFieldSize+1)}, "a.txt", "a")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid team ID", func(t *testing.T) {
+		w := upload(map[string]string{"team_id": "platform"}, "a.txt", "a")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("request too large", func(t *testing.T) {
+		fields := map[string]string{}
+		for i := range 20 {
+			fields["field"+strconv.Itoa(i)] = strings.Repeat("a", maxFormFieldSize)
+		}
+		w := upload(fields, "a.txt", "a")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+	})
+}
+
+func TestFileHandler_UploadFile_WithoutLength(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tempDir := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFileRepo := mocks.NewMockFileRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	limits := entity.QuotaResources{StorageBytes: 1024}
+	policy := entity.UploadPolicy{MaxFileSize: 1024}
+	fileService := application.NewFileService(repository.NewLocalFileStorage(tempDir), mockFileRepo, application.NewQuotaService(mockQuotaRepo, application.QuotaOptions{DefaultLimits: limits}), application.NewAuthorizer(nil), policy)
+	fileHandler := NewFileHandler(fileService, nil)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "1234")
+		c.Set("role", "member")
+		c.Next()
+	})
+	router.POST("/files", fileHandler.UploadFile)
+
+	// upload sends a file in a request without a length, as a chunked request would.
+	upload := func(filename, content string) *httptest.ResponseRecorder {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("file", filename)
+		assert.NoError(t, err)
+		_, _ = part.Write([]byte(content))
+		assert.NoError(t, writer.Close())
+		req, _ := http.NewRequest(http.MethodPost, "/files", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.ContentLength = -1
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("reserves what the quota has left", func(t *testing.T) {
+		// The largest file would not fit any more, but what is left does.
+		mockQuotaRepo.EXPECT().GetLimits(gomock.Any(), int64(1234)).Return(nil, nil)
+		mockQuotaRepo.EXPECT().GetUsage(gomock.Any(), int64(1234)).Return(&entity.QuotaResources{StorageBytes: 1000}, nil)
+		mockQuotaRepo.EXPECT().Reserve(gomock.Any(), int64(1234), entity.QuotaResources{StorageBytes: 24}, limits).Return(nil)
+		mockQuotaRepo.EXPECT().Release(gomock.Any(), int64(1234), entity.QuotaResources{StorageBytes: 14}).Return(nil)
+		mockFileRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+
+		w := upload("small.txt", "0123456789")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("larger than the quota has left", func(t *testing.T) {
+		mockQuotaRepo.EXPECT().GetLimits(gomock.Any(), int64(1234)).Return(nil, nil)
+		mockQuotaRepo.EXPECT().GetUsage(gomock.Any(), int64(1234)).Return(&entity.QuotaResources{StorageBytes: 1000}, nil)
+		mockQuotaRepo.EXPECT().Reserve(gomock.Any(), int64(1234), entity.QuotaResources{StorageBytes: 24}, limits).Return(nil)
+		mockQuotaRepo.EXPECT().Release(gomock.Any(), int64(1234), entity.QuotaResources{StorageBytes: 24}).Return(nil)
+
+		w := upload("large.txt", strings.Repeat("a", 100))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.JSONEq(t, `{"error":"quota exceeded"}`, w.Body.String())
+		_, err := os.Stat(filepath.Join(tempDir, "1234", "large.txt"))
+		assert.True(t, os.IsNotExist(err), "file over the quota should not be saved")
+	})
+}
+
+func TestFileHandler_DownloadFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tempDir := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	localFileStorage := repository.NewLocalFileStorage(tempDir)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	// The records of the files are tested with the file service.
+	mockFileRepo := mocks.NewMockFileRepository(ctrl)
+	mockFileRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockFileRepo.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	mockFileRepo.EXPECT().Move(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	fileService := application.NewFileService(localFileStorage, mockFileRepo, application.NewQuotaService(mockQuotaRepo, application.QuotaOptions{}), application.NewAuthorizer(nil), entity.UploadPolicy{})
+	fileHandler := NewFileHandler(fileService, nil)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "1234")
+		c.Set("role", "member")
+		c.Next()
+	})
+	router.GET("/files", fileHandler.ListFiles)
+	router.GET("/files/*path", fileHandler.DownloadFile)
+	router.DELETE("/files/*path", fileHandler.DeleteFile)
+
+	_, err := localFileStorage.SaveFile(entity.Owner{UserID: 1234}, "report.txt", bytes.NewBufferString("0123456789"))
+	assert.NoError(t, err)
+
+	serve := func(method, path string, header http.Header) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("list", func(t *testing.T) {
+		w := serve(http.MethodGet, "/files", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"name":"report.txt","type":"file","size":10`)
+		assert.Contains(t, w.Body.String(), `"content_type":"text/plain; charset=utf-8"`)
+	})
+
+	t.Run("download", func(t *testing.T) {
+		w := serve(http.MethodGet, "/files/report.txt", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0123456789", w.Body.String())
+		assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=report.txt`, w.Header().Get("Content-Disposition"))
+		etag := w.Header().Get("ETag")
+		assert.NotEmpty(t, etag)
+
+		w = serve(http.MethodGet, "/files/report.txt", http.Header{"If-None-Match": {etag}})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("range", func(t *testing.T) {
+		w := serve(http.MethodGet, "/files/report.txt", http.Header{"Range": {"bytes=2-5"}})
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, "2345", w.Body.String())
+		assert.Equal(t, "bytes 2-5/10", w.Header().Get("Content-Range"))
+
+		w = serve(http.MethodGet, "/files/report.txt", http.Header{"Range": {"bytes=20-"}})
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		w := serve(http.MethodGet, "/files/missing.txt", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("escaping the folder", func(t *testing.T) {
+		w := serve(http.MethodGet, "/files/..%2F1235%2Fsecret.txt", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		mockQuotaRepo.EXPECT().Release(gomock.Any(), int64(1234), entity.QuotaResources{StorageBytes: 10}).Return(nil)
+
+		w := serve(http.MethodDelete, "/files/report.txt", nil)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		_, err := os.Stat(filepath.Join(tempDir, "1234", "report.txt"))
+		assert.True(t, os.IsNotExist(err))
+
+		w = serve(http.MethodDelete, "/files/report.txt", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestFileHandler_Folders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tempDir := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	localFileStorage := repository.NewLocalFileStorage(tempDir)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	// The records of the files are tested with the file service.
+	mockFileRepo := mocks.NewMockFileRepository(ctrl)
+	mockFileRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockFileRepo.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	mockFileRepo.EXPECT().Move(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	fileService := application.NewFileService(localFileStorage, mockFileRepo, application.NewQuotaService(mockQuotaRepo, application.QuotaOptions{}), application.NewAuthorizer(nil), entity.UploadPolicy{})
+	fileHandler := NewFileHandler(fileService, nil)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "1234")
+		c.Set("role", "member")
+		c.Next()
+	})
+	router.POST("/files", fileHandler.UploadFile)
+	router.POST("/files/folders", fileHandler.CreateFolder)
+	router.POST("/files/move", fileHandler.MoveFile)
+	router.GET("/files", fileHandler.ListFiles)
+	router.GET("/files/*path", fileHandler.DownloadFile)
+	router.DELETE("/files/*path", fileHandler.DeleteFile)
+
+	serve := func(method, path, contentType string, body *bytes.Buffer) *httptest.ResponseRecorder {
+		if body == nil {
+			body = new(bytes.Buffer)
+		}
+		req, _ := http.NewRequest(method, path, body)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	upload := func(dir, filename, content string) *httptest.ResponseRecorder {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		assert.NoError(t, writer.WriteField("path", dir))
+		part, err := writer.CreateFormFile("file", filename)
+		assert.NoError(t, err)
+		_, _ = part.Write([]byte(content))
+		assert.NoError(t, writer.Close())
+		return serve(http.MethodPost, "/files", writer.FormDataContentType(), body)
+	}
+
+	t.Run("create folder", func(t *testing.T) {
+		w := serve(http.MethodPost, "/files/folders", "application/json", bytes.NewBufferString(`{"path":"reports/2024"}`))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		info, err := os.Stat(filepath.Join(tempDir, "1234", "reports", "2024"))
+		assert.NoError(t, err)
+		assert.True(t, info.IsDir())
+	})
+
+	t.Run("upload into a folder", func(t *testing.T) {
+		mockQuotaRepo.EXPECT().GetLimits(gomock.Any(), int64(1234)).Return(nil, nil)
+		// The length of the request is reserved, and what the file does not use given back.
+		mockQuotaRepo.EXPECT().Reserve(gomock.Any(), int64(1234), gomock.Any(), gomock.Any()).Return(nil)
+		mockQuotaRepo.EXPECT().Release(gomock.Any(), int64(1234), gomock.Any()).Return(nil)
+
+		w := upload("reports/2024", "q1.csv", "a,b,c")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = serve(http.MethodGet, "/files/reports/2024/q1.csv", "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "a,b,c", w.Body.String())
+		assert.Equal(t, `attachment; filename=q1.csv`, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("upload out of the folder", func(t *testing.T) {
+		w := upload("../1235", "q1.csv", "a,b,c")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		_, err := os.Stat(filepath.Join(tempDir, "1235"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		w := serve(http.MethodGet, "/files", "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"name":"reports","type":"folder"`)
+		assert.NotContains(t, w.Body.String(), "q1.csv")
+
+		w = serve(http.MethodGet, "/files?path=reports&recursive=true", "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"name":"reports/2024","type":"folder"`)
+		assert.Contains(t, w.Body.String(), `"name":"reports/2024/q1.csv","type":"file","size":5`)
+
+		w = serve(http.MethodGet, "/files?path=missing", "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = serve(http.MethodGet, "/files?recursive=maybe", "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("move", func(t *testing.T) {
+		w := serve(http.MethodPost, "/files/move", "application/json", bytes.NewBufferString(`{"from":"reports/2024","to":"archive/2024"}`))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		_, err := os.Stat(filepath.Join(tempDir, "1234", "archive", "2024", "q1.csv"))
+		assert.NoError(t, err)
+
+		w = serve(http.MethodPost, "/files/move", "application/json", bytes.NewBufferString(`{"from":"archive","to":"reports"}`))
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = serve(http.MethodPost, "/files/move", "application/json", bytes.NewBufferString(`{"from":"archive","to":"archive/inner"}`))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = serve(http.MethodPost, "/files/move", "application/json", bytes.NewBufferString(`{"from":"missing","to":"other"}`))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		w := serve(http.MethodDelete, "/files/archive", "", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		mockQuotaRepo.EXPECT().Release(gomock.Any(), int64(1234), entity.QuotaResources{StorageBytes: 5}).Return(nil)
+		w = serve(http.MethodDelete, "/files/archive?recursive=true", "", nil)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		_, err := os.Stat(filepath.Join(tempDir, "1234", "archive"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestFileHandler_Extract(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tempDir := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	mockFileRepo := mocks.NewMockFileRepository(ctrl)
+	mockJobRepo := mocks.NewMockJobRepository(ctrl)
+	// The bookkeeping of the files is tested with the file service.
+	mockQuotaRepo.EXPECT().GetLimits(gomock.Any(), int64(1234)).Return(nil, nil).AnyTimes()
+	mockQuotaRepo.EXPECT().Reserve(gomock.Any(), int64(1234), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockQuotaRepo.EXPECT().Release(gomock.Any(), int64(1234), gomock.Any()).Return(nil).AnyTimes()
+	mockFileRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockFileRepo.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	fileService := application.NewFileService(repository.NewLocalFileStorage(tempDir), mockFileRepo, application.NewQuotaService(mockQuotaRepo, application.QuotaOptions{}), application.NewAuthorizer(nil), entity.UploadPolicy{})
+	extractService := application.NewExtractService(fileService, mockJobRepo, entity.ExtractLimits{})
+	fileHandler := NewFileHandler(fileService, extractService)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "1234")
+		c.Set("role", "member")
+		c.Next()
+	})
+	router.POST("/files", fileHandler.UploadFile)
+	router.POST("/files/extract", fileHandler.ExtractFile)
+
+	var archive bytes.Buffer
+	archiveWriter := zip.NewWriter(&archive)
+	file, err := archiveWriter.Create("docs/index.html")
+	require.NoError(t, err)
+	_, _ = file.Write([]byte("<html></html>"))
+	require.NoError(t, archiveWriter.Close())
+
+	upload := func(extract string) *httptest.ResponseRecorder {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		assert.NoError(t, writer.WriteField("extract", extract))
+		part, err := writer.CreateFormFile("file", "site.zip")
+		assert.NoError(t, err)
+		_, _ = part.Write(archive.Bytes())
+		assert.NoError(t, writer.Close())
+		req, _ := http.NewRequest(http.MethodPost, "/files", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	// expectJob expects a job to be created, and returns a channel that receives it once it finished.
+	var done chan *entity.Job
+	mockJobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, job *entity.Job) error {
+		if job.Status == entity.JobStatusCompleted || job.Status == entity.JobStatusFailed {
+			done <- job
+		}
+		return nil
+	}).AnyTimes()
+	expectJob := func() chan *entity.Job {
+		done = make(chan *entity.Job, 1)
+		mockJobRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		return done
+	}
+
+	t.Run("upload and extract", func(t *testing.T) {
+		done := expectJob()
+
+		w := upload("true")
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		var resp UploadFileResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "site.zip", resp.Path)
+		assert.NotEmpty(t, resp.JobID)
+
+		job := <-done
+		assert.Equal(t, resp.JobID, job.ID)
+		assert.Equal(t, entity.JobStatusCompleted, job.Status, job.Error)
+		content, err := os.ReadFile(filepath.Join(tempDir, "1234", "site", "docs", "index.html"))
+		assert.NoError(t, err)
+		assert.Equal(t, "<html></html>", string(content))
+		assert.NoFileExists(t, filepath.Join(tempDir, "1234", "site.zip"))
+	})
+
+	t.Run("upload with an invalid extract field", func(t *testing.T) {
+		w := upload("maybe")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("extract a stored archive", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, upload("false").Code)
+		done := expectJob()
+
+		req, _ := http.NewRequest(http.MethodPost, "/files/extract", strings.NewReader(`{"path":"site.zip","to":"copy"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Contains(t, w.Body.String(), `"job_id":`)
+
+		job := <-done
+		assert.Equal(t, entity.JobStatusCompleted, job.Status, job.Error)
+		assert.FileExists(t, filepath.Join(tempDir, "1234", "copy", "docs", "index.html"))
+		// The archive is kept unless it was uploaded to be extracted.
+		assert.FileExists(t, filepath.Join(tempDir, "1234", "site.zip"))
+	})
+
+	t.Run("extract into an existing folder", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/files/extract", strings.NewReader(`{"path":"site.zip","to":"copy"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
 }
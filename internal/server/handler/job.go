@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"container-manager/internal/application"
+	"container-manager/internal/domain/entity"
 	"container-manager/internal/errors"
 
 	"github.com/gin-gonic/gin"
@@ -37,19 +38,83 @@ func (h *JobHandler) GetJob(c *gin.Context) {
 		return
 	}
 
-	userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64)
+	caller, err := callerFromContext(c)
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
 
-	job, err := h.jobService.GetJob(c.Request.Context(), int64(userID), jobID)
+	job, err := h.jobService.GetJob(c.Request.Context(), caller, jobID)
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
 
-	response := GetJobResponse{
+	c.JSON(http.StatusOK, newGetJobResponse(job))
+}
+
+// ListJobs godoc
+// @Summary List jobs
+// @Description Lists the most recent jobs of the authenticated user, newest first
+// @Tags Jobs
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} ListJobsResponse
+// @Router /jobs [get]
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	h.listJobs(c, caller, caller.UserID)
+}
+
+// ListUserJobs godoc
+// @Summary List the jobs of a user
+// @Description Lists the most recent jobs of any user, newest first. Requires the admin role
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} ListJobsResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Router /admin/users/{id}/jobs [get]
+func (h *JobHandler) ListUserJobs(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(errors.BadRequest.New("user ID must be an integer"))
+		return
+	}
+
+	h.listJobs(c, caller, userID)
+}
+
+func (h *JobHandler) listJobs(c *gin.Context, caller application.Caller, userID int64) {
+	jobs, err := h.jobService.ListJobs(c.Request.Context(), caller, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	resp := ListJobsResponse{Jobs: make([]GetJobResponse, 0, len(jobs))}
+	for _, job := range jobs {
+		resp.Jobs = append(resp.Jobs, newGetJobResponse(job))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func newGetJobResponse(job *entity.Job) GetJobResponse {
+	resp := GetJobResponse{
 		ID:        job.ID,
 		Type:      job.Type,
 		Status:    string(job.Status),
@@ -58,6 +123,12 @@ func (h *JobHandler) GetJob(c *gin.Context) {
 		CreatedAt: job.CreatedAt,
 		UpdatedAt: job.UpdatedAt,
 	}
-
-	c.JSON(http.StatusOK, response)
+	if job.Progress != nil {
+		resp.Progress = &JobProgressResponse{
+			Step:  job.Progress.Step,
+			Done:  job.Progress.Done,
+			Total: job.Progress.Total,
+		}
+	}
+	return resp
 }
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"container-manager/internal/application"
 	"container-manager/internal/domain/entity"
 	"container-manager/internal/errors"
 	"container-manager/internal/server/middleware"
@@ -15,12 +16,20 @@ import (
 )
 
 type MockJobService struct {
-	GetJobFunc func(ctx context.Context, userID int64, id string) (*entity.Job, error)
+	GetJobFunc   func(ctx context.Context, caller application.Caller, id string) (*entity.Job, error)
+	ListJobsFunc func(ctx context.Context, caller application.Caller, userID int64) ([]*entity.Job, error)
 }
 
-func (m *MockJobService) GetJob(ctx context.Context, userID int64, id string) (*entity.Job, error) {
+func (m *MockJobService) GetJob(ctx context.Context, caller application.Caller, id string) (*entity.Job, error) {
 	if m.GetJobFunc != nil {
-		return m.GetJobFunc(ctx, userID, id)
+		return m.GetJobFunc(ctx, caller, id)
+	}
+	return nil, nil
+}
+
+func (m *MockJobService) ListJobs(ctx context.Context, caller application.Caller, userID int64) ([]*entity.Job, error) {
+	if m.ListJobsFunc != nil {
+		return m.ListJobsFunc(ctx, caller, userID)
 	}
 	return nil, nil
 }
@@ -37,6 +46,7 @@ func TestJobHandler_GetJob(t *testing.T) {
 		// Middleware to set userID
 		router.Use(func(c *gin.Context) {
 			c.Set("userID", "123")
+			c.Set("role", "member")
 			c.Next()
 		})
 		router.GET("/jobs/:id", jobHandler.GetJob)
@@ -46,13 +56,14 @@ func TestJobHandler_GetJob(t *testing.T) {
 			Type:      "test",
 			Status:    entity.JobStatusCompleted,
 			Result:    []byte(`{"foo":"bar"}`),
+			Progress:  &entity.JobProgress{Step: "user", Done: 1, Total: 1},
 			UserID:    123,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
 
-		mockService.GetJobFunc = func(ctx context.Context, userID int64, id string) (*entity.Job, error) {
-			assert.Equal(t, int64(123), userID)
+		mockService.GetJobFunc = func(ctx context.Context, caller application.Caller, id string) (*entity.Job, error) {
+			assert.Equal(t, application.Caller{UserID: 123, Role: entity.RoleMember}, caller)
 			assert.Equal(t, "job-1", id)
 			return expectedJob, nil
 		}
@@ -64,6 +75,7 @@ func TestJobHandler_GetJob(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Contains(t, w.Body.String(), "job-1")
 		assert.Contains(t, w.Body.String(), "completed")
+		assert.Contains(t, w.Body.String(), `"progress":{"step":"user","done":1,"total":1}`)
 	})
 
 	t.Run("job not found", func(t *testing.T) {
@@ -74,11 +86,12 @@ func TestJobHandler_GetJob(t *testing.T) {
 		router.Use(middleware.ErrorHandler())
 		router.Use(func(c *gin.Context) {
 			c.Set("userID", "123")
+			c.Set("role", "member")
 			c.Next()
 		})
 		router.GET("/jobs/:id", jobHandler.GetJob)
 
-		mockService.GetJobFunc = func(ctx context.Context, userID int64, id string) (*entity.Job, error) {
+		mockService.GetJobFunc = func(ctx context.Context, caller application.Caller, id string) (*entity.Job, error) {
 			return nil, errors.JobNotFound
 		}
 
@@ -97,11 +110,12 @@ func TestJobHandler_GetJob(t *testing.T) {
 		router.Use(middleware.ErrorHandler())
 		router.Use(func(c *gin.Context) {
 			c.Set("userID", "123")
+			c.Set("role", "member")
 			c.Next()
 		})
 		router.GET("/jobs/:id", jobHandler.GetJob)
 
-		mockService.GetJobFunc = func(ctx context.Context, userID int64, id string) (*entity.Job, error) {
+		mockService.GetJobFunc = func(ctx context.Context, caller application.Caller, id string) (*entity.Job, error) {
 			return nil, errors.PermissionDenied
 		}
 
@@ -112,3 +126,56 @@ func TestJobHandler_GetJob(t *testing.T) {
 		assert.Equal(t, http.StatusForbidden, w.Code)
 	})
 }
+
+func TestJobHandler_ListJobs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := &MockJobService{}
+	jobHandler := NewJobHandler(mockService)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "123")
+		c.Set("role", c.GetHeader("X-Role"))
+		c.Next()
+	})
+	router.GET("/jobs", jobHandler.ListJobs)
+	router.GET("/admin/users/:id/jobs", jobHandler.ListUserJobs)
+
+	serve := func(path, role string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Role", role)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("own jobs", func(t *testing.T) {
+		mockService.ListJobsFunc = func(ctx context.Context, caller application.Caller, userID int64) ([]*entity.Job, error) {
+			assert.Equal(t, int64(123), userID)
+			return []*entity.Job{{ID: "job-1", Status: entity.JobStatusPending, UserID: 123}}, nil
+		}
+
+		w := serve("/jobs", "member")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"id":"job-1"`)
+	})
+
+	t.Run("jobs of another user", func(t *testing.T) {
+		mockService.ListJobsFunc = func(ctx context.Context, caller application.Caller, userID int64) ([]*entity.Job, error) {
+			assert.Equal(t, application.Caller{UserID: 123, Role: entity.RoleAdmin}, caller)
+			assert.Equal(t, int64(456), userID)
+			return []*entity.Job{}, nil
+		}
+
+		w := serve("/admin/users/456/jobs", "admin")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"jobs":[]}`, w.Body.String())
+	})
+
+	t.Run("invalid user ID", func(t *testing.T) {
+		w := serve("/admin/users/abc/jobs", "admin")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"container-manager/internal/domain/infrastructure"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	keyManager infrastructure.KeyManager
+}
+
+func NewJWKSHandler(keyManager infrastructure.KeyManager) *JWKSHandler {
+	return &JWKSHandler{keyManager: keyManager}
+}
+
+// GetJWKS godoc
+// @Summary Get the JSON Web Key Set
+// @Description Returns the public keys access tokens are verified with, so that other services can verify them
+// @Tags Users
+// @Produce json
+// @Success 200 {object} JWKSResponse
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	keys := h.keyManager.PublicKeys()
+	resp := JWKSResponse{Keys: make([]JWKResponse, 0, len(keys))}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, JWKResponse{
+			KeyType:   key.KeyType,
+			KeyID:     key.KeyID,
+			Use:       "sig",
+			Algorithm: key.Algorithm,
+			Curve:     key.Curve,
+			X:         key.X,
+			N:         key.N,
+			E:         key.E,
+		})
+	}
+
+	// Verifiers cache the keys for a while, new keys should be published before they sign tokens.
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"container-manager/internal/domain/infrastructure"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticKeyManager struct {
+	infrastructure.KeyManager
+	keys []infrastructure.JWK
+}
+
+func (m staticKeyManager) PublicKeys() []infrastructure.JWK {
+	return m.keys
+}
+
+func TestJWKSHandler_GetJWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jwksHandler := NewJWKSHandler(staticKeyManager{keys: []infrastructure.JWK{
+		{KeyType: "OKP", KeyID: "2026-10", Algorithm: "EdDSA", Curve: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+	}})
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
+	req, _ := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Cache-Control"))
+	var resp map[string][]map[string]string
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []map[string]string{{
+		"kty": "OKP",
+		"kid": "2026-10",
+		"use": "sig",
+		"alg": "EdDSA",
+		"crv": "Ed25519",
+		"x":   "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",
+	}}, resp["keys"])
+}
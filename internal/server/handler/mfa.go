@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"container-manager/internal/application"
+	"container-manager/internal/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	service *application.MFAService
+}
+
+func NewMFAHandler(service *application.MFAService) *MFAHandler {
+	return &MFAHandler{service: service}
+}
+
+// EnrollTOTP godoc
+// @Summary Start TOTP enrollment
+// @Description Generates a TOTP secret and its otpauth URI for an authenticator app. Two-factor authentication is enabled once a first code is confirmed
+// @Tags Users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} TOTPEnrollmentResponse
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 409 {object} ErrorResponse "Conflict"
+// @Router /users/me/mfa/totp [post]
+func (h *MFAHandler) EnrollTOTP(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	enrollment, err := h.service.EnrollTOTP(c.Request.Context(), userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, TOTPEnrollmentResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURI: enrollment.OTPAuthURI,
+	})
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm TOTP enrollment
+// @Description Enables two-factor authentication with a first code of the authenticator app and returns the recovery codes. They are only shown once
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body MFACodeRequest true "Verification code"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 409 {object} ErrorResponse "Conflict"
+// @Router /users/me/mfa/totp/confirm [post]
+func (h *MFAHandler) ConfirmTOTP(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(errors.BadRequest.Wrap(err))
+		return
+	}
+
+	codes, err := h.service.ConfirmTOTP(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP godoc
+// @Summary Disable two-factor authentication
+// @Description Removes the TOTP secret and the recovery codes. Requires a code of the authenticator app or a recovery code
+// @Tags Users
+// @Accept json
+// @Security ApiKeyAuth
+// @Param request body MFACodeRequest true "Verification code"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 429 {object} ErrorResponse "Too Many Requests"
+// @Router /users/me/mfa/totp [delete]
+func (h *MFAHandler) DisableTOTP(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(errors.BadRequest.Wrap(err))
+		return
+	}
+
+	if err := h.service.DisableTOTP(c.Request.Context(), userID, req.Code); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
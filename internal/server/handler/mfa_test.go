@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"container-manager/internal/application"
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/server/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMFAHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mfaHandler := NewMFAHandler(application.NewMFAService(mockMFARepo, mockUserRepo, "Container Manager"))
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "1234")
+		c.Next()
+	})
+	router.POST("/users/me/mfa/totp", mfaHandler.EnrollTOTP)
+	router.POST("/users/me/mfa/totp/confirm", mfaHandler.ConfirmTOTP)
+	router.DELETE("/users/me/mfa/totp", mfaHandler.DisableTOTP)
+
+	secret, _ := entity.NewTOTPSecret()
+	code, _ := entity.TOTPCode(secret, entity.TOTPStep(time.Now()))
+	now := time.Now()
+
+	t.Run("enroll", func(t *testing.T) {
+		mockMFARepo.EXPECT().Get(gomock.Any(), int64(1234)).Return(nil, nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(1234)).Return(&entity.User{ID: 1234, Username: "alice"}, nil)
+		mockMFARepo.EXPECT().SavePending(gomock.Any(), int64(1234), gomock.Any()).Return(nil)
+
+		req, _ := http.NewRequest(http.MethodPost, "/users/me/mfa/totp", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp TOTPEnrollmentResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.NotEmpty(t, resp.Secret)
+		assert.Contains(t, resp.OTPAuthURI, "secret="+resp.Secret)
+	})
+
+	t.Run("enroll when enabled", func(t *testing.T) {
+		mockMFARepo.EXPECT().Get(gomock.Any(), int64(1234)).Return(&entity.UserMFA{UserID: 1234, EnabledAt: &now}, nil)
+
+		req, _ := http.NewRequest(http.MethodPost, "/users/me/mfa/totp", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("confirm", func(t *testing.T) {
+		mockMFARepo.EXPECT().Get(gomock.Any(), int64(1234)).Return(&entity.UserMFA{UserID: 1234, Secret: secret}, nil)
+		mockMFARepo.EXPECT().Enable(gomock.Any(), int64(1234), gomock.Any(), gomock.Any()).Return(true, nil)
+
+		body, _ := json.Marshal(MFACodeRequest{Code: code})
+		req, _ := http.NewRequest(http.MethodPost, "/users/me/mfa/totp/confirm", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp RecoveryCodesResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.RecoveryCodes, entity.RecoveryCodeCount)
+	})
+
+	t.Run("confirm without code", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/users/me/mfa/totp/confirm", bytes.NewBufferString("{}"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("disable with wrong code", func(t *testing.T) {
+		mockMFARepo.EXPECT().Get(gomock.Any(), int64(1234)).Return(&entity.UserMFA{UserID: 1234, Secret: secret, EnabledAt: &now}, nil)
+		mockMFARepo.EXPECT().UseRecoveryCode(gomock.Any(), int64(1234), gomock.Any()).Return(false, nil)
+		mockMFARepo.EXPECT().RecordFailure(gomock.Any(), int64(1234)).Return(nil)
+
+		body, _ := json.Marshal(MFACodeRequest{Code: "abcde-fghij"})
+		req, _ := http.NewRequest(http.MethodDelete, "/users/me/mfa/totp", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("disable", func(t *testing.T) {
+		mockMFARepo.EXPECT().Get(gomock.Any(), int64(1234)).Return(&entity.UserMFA{UserID: 1234, Secret: secret, EnabledAt: &now}, nil)
+		mockMFARepo.EXPECT().UseRecoveryCode(gomock.Any(), int64(1234), gomock.Any()).Return(true, nil)
+		mockMFARepo.EXPECT().Delete(gomock.Any(), int64(1234)).Return(nil)
+
+		body, _ := json.Marshal(MFACodeRequest{Code: "abcde-fghij"})
+		req, _ := http.NewRequest(http.MethodDelete, "/users/me/mfa/totp", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
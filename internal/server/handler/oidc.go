@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"container-manager/internal/application"
+	"container-manager/internal/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCHandler struct {
+	service *application.OIDCService
+}
+
+func NewOIDCHandler(service *application.OIDCService) *OIDCHandler {
+	return &OIDCHandler{service: service}
+}
+
+// Login godoc
+// @Summary Sign in with an identity provider
+// @Description Redirects to the identity provider to sign in with OpenID Connect
+// @Tags Users
+// @Param provider path string true "Identity provider name"
+// @Success 302
+// @Failure 404 {object} ErrorResponse "Not Found"
+// @Router /users/oidc/{provider}/login [get]
+func (h *OIDCHandler) Login(c *gin.Context) {
+	authURL, err := h.service.AuthorizationURL(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback godoc
+// @Summary Complete a login at an identity provider
+// @Description The identity provider redirects here after signing in. The first login creates a local user, or links an existing one if the provider is configured to.
+// @Tags Users
+// @Produce json
+// @Param provider path string true "Identity provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "Login state"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 409 {object} ErrorResponse "Conflict"
+// @Router /users/oidc/{provider}/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if providerError := c.Query("error"); providerError != "" {
+		_ = c.Error(errors.OIDCLoginFailed.New(providerError + ": " + c.Query("error_description")))
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		_ = c.Error(errors.BadRequest.New("code and state are required"))
+		return
+	}
+
+	user, tokens, err := h.service.Callback(c.Request.Context(), c.Param("provider"), code, state)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Set("auditTarget", user.Username)
+
+	c.JSON(http.StatusOK, LoginResponse{
+		ID:           strconv.FormatInt(user.ID, 10),
+		Username:     user.Username,
+		Role:         string(user.Role),
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    expiresIn(tokens),
+	})
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"container-manager/internal/application"
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	keymanager "container-manager/internal/infrastructure/key_manager"
+	"container-manager/internal/infrastructure/oidc"
+	"container-manager/internal/infrastructure/oidc/oidctest"
+	"container-manager/internal/server/middleware"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOIDCHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	issuer, err := oidctest.NewIssuer("container-manager", "secret")
+	require.NoError(t, err)
+	defer issuer.Close()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockLoginStateRepo := mocks.NewMockOIDCLoginStateRepository(ctrl)
+	mockIdentityRepo := mocks.NewMockUserIdentityRepository(ctrl)
+	idNode, _ := snowflake.NewNode(1)
+	keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "secret"})
+	userService := application.NewUserService(mockUserRepo, mockRefreshTokenRepo, nil, nil, keyManager, idNode, application.TokenOptions{}, entity.PasswordPolicy{}, nil)
+	provider := oidc.NewProvider(oidc.Options{
+		Issuer:       issuer.URL,
+		ClientID:     "container-manager",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/users/oidc/company/callback",
+	})
+	oidcService := application.NewOIDCService(map[string]application.OIDCProviderOptions{
+		"company": {Provider: provider, UsernameClaims: []string{"preferred_username"}},
+	}, mockLoginStateRepo, mockIdentityRepo, mockUserRepo, userService, idNode)
+	oidcHandler := NewOIDCHandler(oidcService)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.GET("/users/oidc/:provider/login", oidcHandler.Login)
+	router.GET("/users/oidc/:provider/callback", oidcHandler.Callback)
+
+	t.Run("login", func(t *testing.T) {
+		issuer.SetUser(map[string]any{"sub": "subject", "preferred_username": "alice"})
+
+		var loginState *entity.OIDCLoginState
+		mockLoginStateRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, state *entity.OIDCLoginState) error {
+			loginState = state
+			return nil
+		})
+
+		req, _ := http.NewRequest(http.MethodGet, "/users/oidc/company/login", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusFound, w.Code)
+
+		code, state, err := issuer.Authorize(w.Header().Get("Location"))
+		require.NoError(t, err)
+
+		mockLoginStateRepo.EXPECT().Take(gomock.Any(), state).Return(loginState, nil)
+		mockIdentityRepo.EXPECT().Find(gomock.Any(), "company", "subject").Return(nil, nil)
+		mockUserRepo.EXPECT().FindByUsername(gomock.Any(), "alice").Return(nil, nil)
+		mockUserRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockIdentityRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		req, _ = http.NewRequest(http.MethodGet, "/users/oidc/company/callback?"+url.Values{"code": {code}, "state": {state}}.Encode(), nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp LoginResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "alice", resp.Username)
+		assert.NotEmpty(t, resp.Token)
+		assert.NotEmpty(t, resp.RefreshToken)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/users/oidc/other/login", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("provider returned an error", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/users/oidc/company/callback?error=access_denied", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("missing code", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/users/oidc/company/callback?state=state", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"container-manager/internal/application"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QuotaHandler struct {
+	quotaService *application.QuotaService
+}
+
+func NewQuotaHandler(quotaService *application.QuotaService) *QuotaHandler {
+	return &QuotaHandler{quotaService: quotaService}
+}
+
+// GetQuota godoc
+// @Summary Get quota
+// @Description Returns the resource limits of the authenticated user together with the current usage. A zero limit means unlimited.
+// @Tags Users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} QuotaResponse
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Router /users/me/quota [get]
+func (h *QuotaHandler) GetQuota(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	quota, err := h.quotaService.GetQuota(c.Request.Context(), userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, QuotaResponse{
+		Containers:        QuotaItem{Used: quota.Usage.Containers, Limit: quota.Limits.Containers},
+		RunningContainers: QuotaItem{Used: quota.Usage.RunningContainers, Limit: quota.Limits.RunningContainers},
+		MemoryBytes:       QuotaItem{Used: quota.Usage.MemoryBytes, Limit: quota.Limits.MemoryBytes},
+		NanoCPUs:          QuotaItem{Used: quota.Usage.NanoCPUs, Limit: quota.Limits.NanoCPUs},
+		StorageBytes:      QuotaItem{Used: quota.Usage.StorageBytes, Limit: quota.Limits.StorageBytes},
+	})
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"container-manager/internal/application"
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/server/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestQuotaHandler_GetQuota(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	quotaService := application.NewQuotaService(mockQuotaRepo, application.QuotaOptions{
+		DefaultLimits: entity.QuotaResources{Containers: 20, RunningContainers: 10, StorageBytes: 1024},
+	})
+	quotaHandler := NewQuotaHandler(quotaService)
+
+	router := gin.Default()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "123")
+		c.Next()
+	})
+	router.GET("/users/me/quota", quotaHandler.GetQuota)
+
+	mockQuotaRepo.EXPECT().GetLimits(gomock.Any(), int64(123)).Return(nil, nil)
+	mockQuotaRepo.EXPECT().GetUsage(gomock.Any(), int64(123)).Return(&entity.QuotaResources{Containers: 3, RunningContainers: 1, MemoryBytes: 512, StorageBytes: 100}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/me/quota", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp QuotaResponse
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, QuotaResponse{
+		Containers:        QuotaItem{Used: 3, Limit: 20},
+		RunningContainers: QuotaItem{Used: 1, Limit: 10},
+		MemoryBytes:       QuotaItem{Used: 512},
+		StorageBytes:      QuotaItem{Used: 100, Limit: 1024},
+	}, resp)
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"container-manager/internal/application"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TeamHandler struct {
+	service *application.TeamService
+}
+
+func NewTeamHandler(service *application.TeamService) *TeamHandler {
+	return &TeamHandler{service: service}
+}
+
+// CreateTeam godoc
+// @Summary Create a team
+// @Description Creates a team with the authenticated user as its owner
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body CreateTeamRequest true "Team creation request"
+// @Success 200 {object} TeamResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Router /teams [post]
+func (h *TeamHandler) CreateTeam(c *gin.Context) {
+	var req CreateTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(errors.BadRequest.Wrap(err))
+		return
+	}
+
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	team, err := h.service.CreateTeam(c.Request.Context(), caller, req.Name)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Set("auditTarget", strconv.FormatInt(team.ID, 10))
+
+	c.JSON(http.StatusOK, toTeamResponse(&entity.TeamMembership{Team: team, Role: entity.TeamRoleOwner}))
+}
+
+// ListTeams godoc
+// @Summary List teams
+// @Description Lists the teams of the authenticated user with their role in each team
+// @Tags Teams
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} ListTeamsResponse
+// @Router /teams [get]
+func (h *TeamHandler) ListTeams(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	memberships, err := h.service.ListTeams(c.Request.Context(), caller)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	resp := ListTeamsResponse{Teams: make([]TeamResponse, 0, len(memberships))}
+	for _, membership := range memberships {
+		resp.Teams = append(resp.Teams, toTeamResponse(membership))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListMembers godoc
+// @Summary List the members of a team
+// @Description Lists the members of a team the authenticated user belongs to
+// @Tags Teams
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Team ID"
+// @Success 200 {object} ListTeamMembersResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Not Found"
+// @Router /teams/{id}/members [get]
+func (h *TeamHandler) ListMembers(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(errors.BadRequest.New("team ID must be an integer"))
+		return
+	}
+
+	members, err := h.service.ListMembers(c.Request.Context(), caller, teamID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	resp := ListTeamMembersResponse{Members: make([]TeamMemberResponse, 0, len(members))}
+	for _, member := range members {
+		resp.Members = append(resp.Members, toTeamMemberResponse(member))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// SetMember godoc
+// @Summary Add or update a team member
+// @Description Adds a user to a team, or changes the role of a member (owner, member or viewer). Requires the owner role in the team
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Team ID"
+// @Param request body SetTeamMemberRequest true "Team member request"
+// @Success 200 {object} TeamMemberResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Not Found"
+// @Failure 409 {object} ErrorResponse "Conflict"
+// @Router /teams/{id}/members [post]
+func (h *TeamHandler) SetMember(c *gin.Context) {
+	var req SetTeamMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(errors.BadRequest.Wrap(err))
+		return
+	}
+
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(errors.BadRequest.New("team ID must be an integer"))
+		return
+	}
+
+	member, err := h.service.SetMember(c.Request.Context(), caller, teamID, req.Username, entity.TeamRole(req.Role))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toTeamMemberResponse(member))
+}
+
+// RemoveMember godoc
+// @Summary Remove a team member
+// @Description Removes a member from a team. Owners remove any member, other members can only leave the team. The share links the member created to files of the team are revoked
+// @Tags Teams
+// @Security ApiKeyAuth
+// @Param id path int true "Team ID"
+// @Param userId path int true "User ID"
+// @Success 200
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Not Found"
+// @Failure 409 {object} ErrorResponse "Conflict"
+// @Router /teams/{id}/members/{userId} [delete]
+func (h *TeamHandler) RemoveMember(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(errors.BadRequest.New("team ID must be an integer"))
+		return
+	}
+	userID, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		_ = c.Error(errors.BadRequest.New("user ID must be an integer"))
+		return
+	}
+
+	err = h.service.RemoveMember(c.Request.Context(), caller, teamID, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func toTeamResponse(membership *entity.TeamMembership) TeamResponse {
+	return TeamResponse{
+		ID:        strconv.FormatInt(membership.Team.ID, 10),
+		Name:      membership.Team.Name,
+		Role:      string(membership.Role),
+		CreatedAt: membership.Team.CreatedAt,
+	}
+}
+
+func toTeamMemberResponse(member *entity.TeamMember) TeamMemberResponse {
+	return TeamMemberResponse{
+		UserID:   strconv.FormatInt(member.UserID, 10),
+		Username: member.Username,
+		Role:     string(member.Role),
+	}
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"container-manager/internal/application"
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/server/middleware"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTeamHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	idNode, _ := snowflake.NewNode(1)
+	mockTeamRepo := mocks.NewMockTeamRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockShareRepo := mocks.NewMockFileShareRepository(ctrl)
+	teamHandler := NewTeamHandler(application.NewTeamService(mockTeamRepo, mockUserRepo, mockShareRepo, idNode))
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "1234")
+		c.Set("role", "member")
+		c.Next()
+	})
+	router.POST("/teams", teamHandler.CreateTeam)
+	router.GET("/teams", teamHandler.ListTeams)
+	router.GET("/teams/:id/members", teamHandler.ListMembers)
+	router.POST("/teams/:id/members", teamHandler.SetMember)
+	router.DELETE("/teams/:id/members/:userId", teamHandler.RemoveMember)
+
+	team := &entity.Team{ID: 7, Name: "platform"}
+	owner := &entity.TeamMember{TeamID: 7, UserID: 1234, Username: "alice", Role: entity.TeamRoleOwner}
+
+	t.Run("create", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		body, _ := json.Marshal(CreateTeamRequest{Name: "platform"})
+		req, _ := http.NewRequest(http.MethodPost, "/teams", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp TeamResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "platform", resp.Name)
+		assert.Equal(t, "owner", resp.Role)
+		assert.NotEmpty(t, resp.ID)
+	})
+
+	t.Run("create with invalid name", func(t *testing.T) {
+		body, _ := json.Marshal(CreateTeamRequest{Name: "   "})
+		req, _ := http.NewRequest(http.MethodPost, "/teams", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		mockTeamRepo.EXPECT().ListByUserID(gomock.Any(), int64(1234)).Return([]*entity.TeamMembership{{Team: team, Role: entity.TeamRoleViewer}}, nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/teams", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp ListTeamsResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, []TeamResponse{{ID: "7", Name: "platform", Role: "viewer"}}, resp.Teams)
+	})
+
+	t.Run("list members", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(gomock.Any(), int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(gomock.Any(), int64(7), int64(1234)).Return(owner, nil)
+		mockTeamRepo.EXPECT().ListMembers(gomock.Any(), int64(7)).Return([]*entity.TeamMember{owner}, nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/teams/7/members", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp ListTeamMembersResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, []TeamMemberResponse{{UserID: "1234", Username: "alice", Role: "owner"}}, resp.Members)
+	})
+
+	t.Run("set member", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(gomock.Any(), int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(gomock.Any(), int64(7), int64(1234)).Return(owner, nil)
+		mockUserRepo.EXPECT().FindByUsername(gomock.Any(), "bob").Return(&entity.User{ID: 5678, Username: "bob"}, nil)
+		mockTeamRepo.EXPECT().ListMembers(gomock.Any(), int64(7)).Return([]*entity.TeamMember{owner}, nil)
+		mockTeamRepo.EXPECT().SetMember(gomock.Any(), &entity.TeamMember{TeamID: 7, UserID: 5678, Username: "bob", Role: entity.TeamRoleMember}).Return(nil)
+
+		body, _ := json.Marshal(SetTeamMemberRequest{Username: "bob", Role: "member"})
+		req, _ := http.NewRequest(http.MethodPost, "/teams/7/members", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp TeamMemberResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, TeamMemberResponse{UserID: "5678", Username: "bob", Role: "member"}, resp)
+	})
+
+	t.Run("demote the last owner", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(gomock.Any(), int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(gomock.Any(), int64(7), int64(1234)).Return(owner, nil)
+		mockUserRepo.EXPECT().FindByUsername(gomock.Any(), "alice").Return(&entity.User{ID: 1234, Username: "alice"}, nil)
+		mockTeamRepo.EXPECT().ListMembers(gomock.Any(), int64(7)).Return([]*entity.TeamMember{owner}, nil)
+
+		body, _ := json.Marshal(SetTeamMemberRequest{Username: "alice", Role: "viewer"})
+		req, _ := http.NewRequest(http.MethodPost, "/teams/7/members", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("remove member", func(t *testing.T) {
+		mockTeamRepo.EXPECT().Get(gomock.Any(), int64(7)).Return(team, nil)
+		mockTeamRepo.EXPECT().GetMember(gomock.Any(), int64(7), int64(1234)).Return(owner, nil)
+		mockTeamRepo.EXPECT().ListMembers(gomock.Any(), int64(7)).Return([]*entity.TeamMember{owner}, nil)
+		mockTeamRepo.EXPECT().RemoveMember(gomock.Any(), int64(7), int64(5678)).Return(true, nil)
+		mockShareRepo.EXPECT().RevokeByMember(gomock.Any(), int64(7), int64(5678)).Return(nil)
+
+		req, _ := http.NewRequest(http.MethodDelete, "/teams/7/members/5678", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("invalid team ID", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/teams/abc/members", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
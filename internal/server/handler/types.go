@@ -21,9 +21,36 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	ID       string `json:"id"`
-	Username string `json:"username"`
-	Token    string `json:"token"`
+	ID           string `json:"id"`
+	Username     string `json:"username"`
+	Role         string `json:"role"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// MFAChallengeResponse is returned by login instead of LoginResponse when the user has two-factor
+// authentication enabled. The login is completed at /users/login/mfa.
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	// Code is a code of the authenticator app or a recovery code.
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type RefreshTokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
 }
 
 type CreateContainerResponse struct {
@@ -35,25 +62,309 @@ type ErrorResponse struct {
 }
 
 type CreateContainerRequest struct {
-	Cmd   []string `json:"cmd" example:"tail,-f,/dev/null"`
-	Env   []string `json:"env" example:"FOO=BAR"`
-	Image string   `json:"image" binding:"required" example:"alpine"`
+	Cmd    []string          `json:"cmd" example:"tail,-f,/dev/null"`
+	Env    []string          `json:"env" example:"FOO=BAR"`
+	Image  string            `json:"image" binding:"required" example:"alpine"`
+	Labels map[string]string `json:"labels"`
+	Name   string            `json:"name" example:"web"`
+	// MemoryBytes and NanoCPUs limit the resources of the container, the configured defaults apply when omitted.
+	MemoryBytes int64 `json:"memory_bytes" example:"536870912"`
+	NanoCPUs    int64 `json:"nano_cpus" example:"1000000000"`
+	// TeamID makes the container owned by a team the user is an owner or member of.
+	TeamID int64 `json:"team_id,string" example:"1"`
+}
+
+type RenameContainerRequest struct {
+	Name string `json:"name" binding:"required" example:"web"`
 }
 
 type GetJobResponse struct {
-	ID        string          `json:"id"`
-	Type      string          `json:"type"`
-	Status    string          `json:"status"`
-	Result    json.RawMessage `json:"result,omitempty"`
-	Error     string          `json:"error,omitempty"`
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	Status string          `json:"status"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	// Progress is only reported by jobs that work through several items.
+	Progress  *JobProgressResponse `json:"progress,omitempty"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+type JobProgressResponse struct {
+	Step  string `json:"step" example:"containers"`
+	Done  int    `json:"done"`
+	Total int    `json:"total"`
+}
+
+type ListJobsResponse struct {
+	Jobs []GetJobResponse `json:"jobs"`
+}
+
+type UserResponse struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+type ProfileResponse struct {
+	ID         string    `json:"id"`
+	Username   string    `json:"username"`
+	Role       string    `json:"role"`
+	MFAEnabled bool      `json:"mfa_enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type DeleteAccountResponse struct {
+	JobID string `json:"job_id"`
+}
+
+type ListUsersResponse struct {
+	Users []UserResponse `json:"users"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required"`
 }
 
 type ContainerResponse struct {
-	ID     string   `json:"id"`
-	Image  string   `json:"image"`
-	Cmd    []string `json:"cmd"`
-	Env    []string `json:"env"`
-	Status string   `json:"status"`
+	ID     string            `json:"id"`
+	Name   string            `json:"name,omitempty"`
+	Image  string            `json:"image"`
+	Cmd    []string          `json:"cmd"`
+	Env    []string          `json:"env"`
+	Labels map[string]string `json:"labels"`
+	Status string            `json:"status"`
+}
+
+type FailedContainerResponse struct {
+	ID    string `json:"id"`
+	Name  string `json:"name,omitempty"`
+	Error string `json:"error"`
+}
+
+type ListContainersResponse struct {
+	Containers []ContainerResponse       `json:"containers"`
+	Failed     []FailedContainerResponse `json:"failed"`
+	Total      int                       `json:"total"`
+	NextCursor string                    `json:"next_cursor,omitempty"`
+}
+
+// QuotaItem is the usage and limit of one resource. A zero limit means unlimited.
+type QuotaItem struct {
+	Used  int64 `json:"used"`
+	Limit int64 `json:"limit"`
+}
+
+type QuotaResponse struct {
+	Containers        QuotaItem `json:"containers"`
+	RunningContainers QuotaItem `json:"running_containers"`
+	MemoryBytes       QuotaItem `json:"memory_bytes"`
+	NanoCPUs          QuotaItem `json:"nano_cpus"`
+	StorageBytes      QuotaItem `json:"storage_bytes"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required" example:"ci"`
+	Scopes []string `json:"scopes" binding:"required" example:"containers:read,jobs:read"`
+	// ExpiresAt is optional, keys without it do not expire.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type APIKeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateAPIKeyResponse is the only response that contains the key itself.
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+type ListAPIKeysResponse struct {
+	APIKeys []APIKeyResponse `json:"api_keys"`
+}
+
+// JWKResponse is a public key in the JSON Web Key format.
+type JWKResponse struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type JWKSResponse struct {
+	Keys []JWKResponse `json:"keys"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type CreateTeamRequest struct {
+	Name string `json:"name" binding:"required" example:"platform"`
+}
+
+type TeamResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Role is the team role of the authenticated user.
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ListTeamsResponse struct {
+	Teams []TeamResponse `json:"teams"`
+}
+
+type SetTeamMemberRequest struct {
+	Username string `json:"username" binding:"required" example:"alice"`
+	Role     string `json:"role" binding:"required" example:"member"`
+}
+
+type TeamMemberResponse struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+type ListTeamMembersResponse struct {
+	Members []TeamMemberResponse `json:"members"`
+}
+
+type AuditEntryResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	// ActorID is empty for requests without an authenticated user, such as logins.
+	ActorID    string `json:"actor_id,omitempty"`
+	APIKeyID   string `json:"api_key_id,omitempty"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	SourceIP   string `json:"source_ip"`
+	Outcome    string `json:"outcome"`
+	Error      string `json:"error,omitempty"`
+	RequestID  string `json:"request_id"`
+}
+
+type ListAuditResponse struct {
+	Entries []AuditEntryResponse `json:"entries"`
+	// NextBefore is passed as before to get the next page, and is empty on the last page.
+	NextBefore string `json:"next_before,omitempty"`
+}
+
+// FileResponse describes a file, or a folder without size and content type. Type is file or
+// folder.
+type FileResponse struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Size        int64     `json:"size"`
+	ModifiedAt  time.Time `json:"modified_at"`
+	ContentType string    `json:"content_type"`
+}
+
+// UploadFileResponse describes an uploaded file. SHA256 is the hex encoded checksum of what was
+// stored, to verify the upload with. JobID is the job extracting the file, if it is an archive
+// uploaded to be extracted.
+type UploadFileResponse struct {
+	Path        string `json:"path" example:"reports/2024/report.csv"`
+	Size        int64  `json:"size" example:"1024"`
+	SHA256      string `json:"sha256" example:"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"`
+	ContentType string `json:"content_type" example:"text/csv; charset=utf-8"`
+	JobID       string `json:"job_id,omitempty" example:"4f1c2a9e-8d3b-4c5e-9a7f-2b6d1e0c3a58"`
+}
+
+type ListFilesResponse struct {
+	Files []FileResponse `json:"files"`
+}
+
+type CreateFolderRequest struct {
+	Path   string `json:"path" binding:"required" example:"reports/2024"`
+	TeamID int64  `json:"team_id,string" example:"1"`
+}
+
+type MoveFileRequest struct {
+	From   string `json:"from" binding:"required" example:"report.csv"`
+	To     string `json:"to" binding:"required" example:"reports/2024/report.csv"`
+	TeamID int64  `json:"team_id,string" example:"1"`
+}
+
+// ExtractFileRequest names a stored archive and the folder to extract it into, which is named after
+// the archive if To is empty.
+type ExtractFileRequest struct {
+	Path   string `json:"path" binding:"required" example:"uploads/site.zip"`
+	To     string `json:"to" example:"www/site"`
+	TeamID int64  `json:"team_id,string" example:"1"`
+}
+
+type ExtractFileResponse struct {
+	JobID string `json:"job_id"`
+}
+
+// CreateFileShareRequest sets the expiry and download limit of a share link. Without ExpiresAt
+// the link expires after the configured default time, and without MaxDownloads it can be used
+// any number of times.
+type CreateFileShareRequest struct {
+	TeamID       int64      `json:"team_id,string" example:"1"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	MaxDownloads int        `json:"max_downloads" example:"10"`
+}
+
+type FileShareResponse struct {
+	ID           string    `json:"id"`
+	UserID       int64     `json:"user_id,string" example:"1234"`
+	Path         string    `json:"path" example:"reports/2024/report.csv"`
+	TeamID       int64     `json:"team_id,omitempty,string" example:"1"`
+	MaxDownloads int       `json:"max_downloads,omitempty" example:"10"`
+	Downloads    int       `json:"downloads" example:"2"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CreateFileShareResponse is the only response that contains the link itself.
+type CreateFileShareResponse struct {
+	FileShareResponse
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+type ListFileSharesResponse struct {
+	Shares []FileShareResponse `json:"shares"`
+}
+
+// ImportContainerFileRequest names a stored file and the folder of a container to copy it into.
+type ImportContainerFileRequest struct {
+	File   string `json:"file" binding:"required" example:"input/data.csv"`
+	Path   string `json:"path" binding:"required" example:"/app/input"`
+	TeamID int64  `json:"team_id,string" example:"1"`
+}
+
+// ExportContainerFileRequest names a file of a container and the path to store it at.
+type ExportContainerFileRequest struct {
+	Path   string `json:"path" binding:"required" example:"/app/output/results.csv"`
+	File   string `json:"file" binding:"required" example:"results/results.csv"`
+	TeamID int64  `json:"team_id,string" example:"1"`
 }
@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"container-manager/internal/application"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/errors"
+	"encoding/base64"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	tusVersion        = "1.0.0"
+	tusExtensions     = "creation,expiration,checksum,termination"
+	uploadContentType = "application/offset+octet-stream"
+)
+
+// UploadHandler handles resumable uploads with the tus protocol (https://tus.io/protocols/resumable-upload).
+// The Tus-Resumable header is checked by middleware.TusResumable.
+type UploadHandler struct {
+	uploadService *application.UploadService
+}
+
+// NewUploadHandler creates a new instance of UploadHandler.
+func NewUploadHandler(us *application.UploadService) *UploadHandler {
+	return &UploadHandler{
+		uploadService: us,
+	}
+}
+
+// Options godoc
+// @Summary Describe the upload server
+// @Description Returns the tus version, extensions and checksum algorithms the server supports.
+// @Tags Uploads
+// @Success 204 "No Content"
+// @Header 204 {string} Tus-Version "Supported protocol versions"
+// @Header 204 {string} Tus-Extension "Supported protocol extensions"
+// @Header 204 {string} Tus-Checksum-Algorithm "Supported checksum algorithms"
+// @Router /uploads [options]
+func (h *UploadHandler) Options(c *gin.Context) {
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Header("Tus-Checksum-Algorithm", strings.Join(entity.UploadChecksumAlgorithms, ","))
+	c.Status(http.StatusNoContent)
+}
+
+// CreateUpload godoc
+// @Summary Start a resumable upload
+// @Description Starts uploading a file of Upload-Length bytes to the user's files, or to the files of a team if the team_id metadata is set. Upload-Metadata holds comma separated pairs of a key and a base64 encoded value: filename is required, and path names the folder to put the file into. The length is reserved against the storage quota until the upload is completed, cancelled or expires.
+// @Tags Uploads
+// @Security ApiKeyAuth
+// @Param Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Param Upload-Length header int true "Size of the file"
+// @Param Upload-Metadata header string true "Metadata of the file, e.g. filename ZGF0YS5jc3Y=,path aW5wdXQ="
+// @Success 201 "Created"
+// @Header 201 {string} Location "URL of the upload"
+// @Header 201 {string} Upload-Expires "When the upload expires unless continued"
+// @Failure 400 {object} ErrorResponse "Invalid headers"
+// @Failure 403 {object} ErrorResponse "Storage quota exceeded"
+// @Failure 409 {object} ErrorResponse "Team file already exists"
+// @Failure 412 {object} ErrorResponse "Unsupported protocol version"
+// @Failure 413 {object} ErrorResponse "File too large"
+// @Router /uploads [post]
+func (h *UploadHandler) CreateUpload(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	length, err := int64FromHeader(c, "Upload-Length")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	metadata := c.GetHeader("Upload-Metadata")
+	values, err := parseUploadMetadata(metadata)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	filename := values["filename"]
+	if filename == "" {
+		_ = c.Error(errors.BadRequest.New("filename metadata is required"))
+		return
+	}
+	// As in FileHandler.UploadFile, the folder is not joined with path.Join.
+	if dir := values["path"]; dir != "" {
+		filename = dir + "/" + filename
+	}
+	var teamID int64
+	if value := values["team_id"]; value != "" {
+		teamID, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			_ = c.Error(errors.BadRequest.New("team ID must be an integer"))
+			return
+		}
+	}
+	c.Set("auditTarget", filename)
+
+	upload, err := h.uploadService.CreateUpload(c.Request.Context(), caller, teamID, filename, length, metadata)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("Location", "/uploads/"+upload.ID)
+	if upload.Offset < upload.Length {
+		c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+	c.Status(http.StatusCreated)
+}
+
+// GetUpload godoc
+// @Summary Get the state of an upload
+// @Description Returns how much of an upload of the authenticated user has been received, to resume it from there.
+// @Tags Uploads
+// @Security ApiKeyAuth
+// @Param id path string true "Upload ID"
+// @Param Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Success 200 "OK"
+// @Header 200 {int} Upload-Offset "Bytes received"
+// @Header 200 {int} Upload-Length "Size of the file"
+// @Header 200 {string} Upload-Metadata "Metadata the upload was created with"
+// @Header 200 {string} Upload-Expires "When the upload expires unless continued"
+// @Failure 404 "Upload not found or expired"
+// @Router /uploads/{id} [head]
+func (h *UploadHandler) GetUpload(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// The offset changes with every chunk and must not be cached.
+	c.Header("Cache-Control", "no-store")
+	upload, err := h.uploadService.GetUpload(c.Request.Context(), caller, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	if upload.Metadata != "" {
+		c.Header("Upload-Metadata", upload.Metadata)
+	}
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusOK)
+}
+
+// WriteUpload godoc
+// @Summary Upload a chunk
+// @Description Appends the request body to an upload at Upload-Offset, which has to be the offset the upload is at. With Upload-Checksum, a chunk that does not match is discarded. The file is saved once all of it has been received. If the types of files are limited, the type is sniffed from the first chunk, which has to hold at least the first 512 bytes of the file.
+// @Tags Uploads
+// @Accept application/offset+octet-stream
+// @Security ApiKeyAuth
+// @Param id path string true "Upload ID"
+// @Param Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Param Upload-Offset header int true "Offset of the chunk"
+// @Param Upload-Checksum header string false "Checksum algorithm and base64 encoded checksum of the chunk, e.g. sha1 Kq5sNclPz7QV2+lfQIuc6R7oRu0="
+// @Success 204 "No Content"
+// @Header 204 {int} Upload-Offset "Bytes received"
+// @Header 204 {string} Upload-Expires "When the upload expires unless continued"
+// @Failure 400 {object} ErrorResponse "Invalid headers"
+// @Failure 404 {object} ErrorResponse "Upload not found or expired"
+// @Failure 409 {object} ErrorResponse "Offset does not match"
+// @Failure 413 {object} ErrorResponse "Chunk exceeds the upload length"
+// @Failure 415 {object} ErrorResponse "Unsupported content type or file type not allowed"
+// @Failure 423 {object} ErrorResponse "Upload is in use"
+// @Failure 460 {object} ErrorResponse "Checksum mismatch"
+// @Router /uploads/{id} [patch]
+func (h *UploadHandler) WriteUpload(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type")); mediaType != uploadContentType {
+		_ = c.Error(errors.InvalidUploadContentType)
+		return
+	}
+	offset, err := int64FromHeader(c, "Upload-Offset")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	checksum, err := parseUploadChecksum(c.GetHeader("Upload-Checksum"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	upload, err := h.uploadService.WriteUpload(c.Request.Context(), caller, c.Param("id"), offset, c.Request.ContentLength, checksum, c.Request.Body)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	if upload.Offset < upload.Length {
+		c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteUpload godoc
+// @Summary Cancel an upload
+// @Description Cancels an upload of the authenticated user, discarding what was received and giving back its reservation.
+// @Tags Uploads
+// @Security ApiKeyAuth
+// @Param id path string true "Upload ID"
+// @Param Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Success 204 "No Content"
+// @Failure 404 {object} ErrorResponse "Upload not found or expired"
+// @Failure 423 {object} ErrorResponse "Upload is in use"
+// @Router /uploads/{id} [delete]
+func (h *UploadHandler) DeleteUpload(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if err := h.uploadService.DeleteUpload(c.Request.Context(), caller, c.Param("id")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// int64FromHeader parses a required header holding a size or an offset.
+func int64FromHeader(c *gin.Context, key string) (int64, error) {
+	value, err := strconv.ParseInt(c.GetHeader(key), 10, 64)
+	if err != nil || value < 0 {
+		return 0, errors.BadRequest.New(key + " must be a non-negative integer")
+	}
+	return value, nil
+}
+
+// parseUploadMetadata decodes an Upload-Metadata header: comma separated pairs of a key and a
+// base64 encoded value, where the value may be left out.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	values := map[string]string{}
+	if header == "" {
+		return values, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.BadRequest.New("invalid Upload-Metadata")
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.BadRequest.New("invalid Upload-Metadata value of " + key)
+		}
+		values[key] = string(value)
+	}
+	return values, nil
+}
+
+// parseUploadChecksum decodes an Upload-Checksum header, an algorithm and a base64 encoded sum
+// separated by a space. It returns nil if the header is empty.
+func parseUploadChecksum(header string) (*application.UploadChecksum, error) {
+	if header == "" {
+		return nil, nil
+	}
+	algorithm, encoded, _ := strings.Cut(header, " ")
+	sum, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sum) == 0 {
+		return nil, errors.BadRequest.New("invalid Upload-Checksum")
+	}
+	return &application.UploadChecksum{Algorithm: algorithm, Sum: sum}, nil
+}
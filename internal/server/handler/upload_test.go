@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"container-manager/internal/application"
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/infrastructure/repository"
+	"container-manager/internal/server/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestUploadHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tempDir := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fileStorage := repository.NewLocalFileStorage(tempDir)
+	mockUploadRepo := mocks.NewMockUploadRepository(ctrl)
+	mockFileRepo := mocks.NewMockFileRepository(ctrl)
+	mockQuotaRepo := mocks.NewMockQuotaRepository(ctrl)
+	limits := entity.QuotaResources{StorageBytes: 1024}
+	quotaService := application.NewQuotaService(mockQuotaRepo, application.QuotaOptions{DefaultLimits: limits})
+	uploadService := application.NewUploadService(mockUploadRepo, fileStorage, mockFileRepo, quotaService, application.NewAuthorizer(nil), entity.UploadPolicy{}, time.Hour)
+	uploadHandler := NewUploadHandler(uploadService)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	uploadRoutes := router.Group("/uploads", func(c *gin.Context) {
+		c.Set("userID", "1234")
+		c.Set("role", "member")
+	})
+	uploadRoutes.OPTIONS("", uploadHandler.Options)
+	uploadRoutes.POST("", uploadHandler.CreateUpload)
+	uploadRoutes.HEAD("/:id", uploadHandler.GetUpload)
+	uploadRoutes.PATCH("/:id", uploadHandler.WriteUpload)
+	uploadRoutes.DELETE("/:id", uploadHandler.DeleteUpload)
+
+	serve := func(method, path string, headers map[string]string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	owner := entity.Owner{UserID: 1234}
+	// newUpload creates an upload of "hello world" in the storage, of which the first offset
+	// bytes were received.
+	newUpload := func(t *testing.T, offset int64) *entity.Upload {
+		upload, err := entity.NewUpload(owner, "docs/hello.txt", 11, "filename aGVsbG8udHh0", time.Hour)
+		require.NoError(t, err)
+		upload.Offset = offset
+		_, err = fileStorage.WriteUpload(owner, upload.ID, 0, strings.NewReader("hello world"[:offset]))
+		require.NoError(t, err)
+		return upload
+	}
+
+	t.Run("options", func(t *testing.T) {
+		w := serve(http.MethodOptions, "/uploads", nil, "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "1.0.0", w.Header().Get("Tus-Version"))
+		assert.Equal(t, "creation,expiration,checksum,termination", w.Header().Get("Tus-Extension"))
+		assert.Equal(t, "md5,sha1,sha256", w.Header().Get("Tus-Checksum-Algorithm"))
+	})
+
+	t.Run("create", func(t *testing.T) {
+		mockQuotaRepo.EXPECT().GetLimits(gomock.Any(), int64(1234)).Return(nil, nil)
+		mockQuotaRepo.EXPECT().Reserve(gomock.Any(), int64(1234), entity.QuotaResources{StorageBytes: 11}, limits).Return(nil)
+		var created *entity.Upload
+		mockUploadRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, upload *entity.Upload) error {
+			created = upload
+			return nil
+		})
+
+		// path "docs", filename "hello.txt"
+		w := serve(http.MethodPost, "/uploads", map[string]string{
+			"Upload-Length":   "11",
+			"Upload-Metadata": "path ZG9jcw==,filename aGVsbG8udHh0,empty",
+		}, "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		require.NotNil(t, created)
+		assert.Equal(t, "docs/hello.txt", created.Filename)
+		assert.Equal(t, "path ZG9jcw==,filename aGVsbG8udHh0,empty", created.Metadata)
+		assert.Equal(t, "/uploads/"+created.ID, w.Header().Get("Location"))
+		assert.Equal(t, created.ExpiresAt.UTC().Format(http.TimeFormat), w.Header().Get("Upload-Expires"))
+	})
+
+	t.Run("create with invalid headers", func(t *testing.T) {
+		for _, headers := range []map[string]string{
+			{"Upload-Metadata": "filename aGVsbG8udHh0"},
+			{"Upload-Length": "-1", "Upload-Metadata": "filename aGVsbG8udHh0"},
+			{"Upload-Length": "11"},
+			{"Upload-Length": "11", "Upload-Metadata": "filename not-base64!"},
+			{"Upload-Length": "11", "Upload-Metadata": "filename aGVsbG8udHh0,team_id YWxpY2U="},
+		} {
+			w := serve(http.MethodPost, "/uploads", headers, "")
+			assert.Equal(t, http.StatusBadRequest, w.Code, headers)
+		}
+	})
+
+	t.Run("head", func(t *testing.T) {
+		upload := newUpload(t, 5)
+		mockUploadRepo.EXPECT().GetByID(gomock.Any(), upload.ID).Return(upload, nil)
+
+		w := serve(http.MethodHead, "/uploads/"+upload.ID, nil, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "5", w.Header().Get("Upload-Offset"))
+		assert.Equal(t, "11", w.Header().Get("Upload-Length"))
+		assert.Equal(t, "filename aGVsbG8udHh0", w.Header().Get("Upload-Metadata"))
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("head of an unknown upload", func(t *testing.T) {
+		mockUploadRepo.EXPECT().GetByID(gomock.Any(), "unknown").Return(nil, nil)
+
+		w := serve(http.MethodHead, "/uploads/unknown", nil, "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("patch completes the upload", func(t *testing.T) {
+		upload := newUpload(t, 5)
+		mockUploadRepo.EXPECT().GetByID(gomock.Any(), upload.ID).Return(upload, nil)
+		mockUploadRepo.EXPECT().UpdateOffset(gomock.Any(), upload.ID, int64(5), int64(11), gomock.Any()).Return(true, nil)
+		mockUploadRepo.EXPECT().Delete(gomock.Any(), upload.ID).Return(true, nil)
+		mockFileRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+
+		w := serve(http.MethodPatch, "/uploads/"+upload.ID, map[string]string{
+			"Content-Type":    "application/offset+octet-stream",
+			"Upload-Offset":   "5",
+			"Upload-Checksum": "sha1 P4InJqDJ+1VmGOnLl/tkL372LW8=", // sha1(" world")
+		}, " world")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "11", w.Header().Get("Upload-Offset"))
+		assert.Empty(t, w.Header().Get("Upload-Expires"))
+
+		content, err := os.ReadFile(filepath.Join(tempDir, "1234", "docs", "hello.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, "hello world", string(content))
+	})
+
+	t.Run("patch with a checksum mismatch", func(t *testing.T) {
+		upload := newUpload(t, 5)
+		mockUploadRepo.EXPECT().GetByID(gomock.Any(), upload.ID).Return(upload, nil)
+
+		w := serve(http.MethodPatch, "/uploads/"+upload.ID, map[string]string{
+			"Content-Type":    "application/offset+octet-stream",
+			"Upload-Offset":   "5",
+			"Upload-Checksum": "sha1 P4InJqDJ+1VmGOnLl/tkL372LW8=",
+		}, " World")
+		assert.Equal(t, 460, w.Code)
+	})
+
+	t.Run("patch with invalid headers", func(t *testing.T) {
+		for _, test := range []struct {
+			headers map[string]string
+			want    int
+		}{
+			{map[string]string{"Content-Type": "application/octet-stream", "Upload-Offset": "0"}, http.StatusUnsupportedMediaType},
+			{map[string]string{"Content-Type": "application/offset+octet-stream"}, http.StatusBadRequest},
+			{map[string]string{"Content-Type": "application/offset+octet-stream", "Upload-Offset": "0", "Upload-Checksum": "sha1"}, http.StatusBadRequest},
+		} {
+			w := serve(http.MethodPatch, "/uploads/0123456789abcdef0123456789abcdef", test.headers, "data")
+			assert.Equal(t, test.want, w.Code, test.headers)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		upload := newUpload(t, 5)
+		mockUploadRepo.EXPECT().GetByID(gomock.Any(), upload.ID).Return(upload, nil)
+		mockUploadRepo.EXPECT().Delete(gomock.Any(), upload.ID).Return(true, nil)
+		mockQuotaRepo.EXPECT().Release(gomock.Any(), int64(1234), entity.QuotaResources{StorageBytes: 11}).Return(nil)
+
+		w := serve(http.MethodDelete, "/uploads/"+upload.ID, nil, "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		_, err := os.Stat(filepath.Join(tempDir, "1234", entity.UploadsFolder, upload.ID))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
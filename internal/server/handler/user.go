@@ -4,9 +4,11 @@ package handler
 
 import (
 	"container-manager/internal/application"
+	"container-manager/internal/domain/entity"
 	"container-manager/internal/errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -36,6 +38,7 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		_ = c.Error(errors.BadRequest.Wrap(err))
 		return
 	}
+	c.Set("auditTarget", req.Username)
 
 	user, err := h.service.CreateUser(c.Request.Context(), req.Username, req.Password)
 	if err != nil {
@@ -51,12 +54,13 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 
 // Login godoc
 // @Summary User login
-// @Description Authenticates a user and returns an authentication token
+// @Description Authenticates a user and returns an authentication token. Users with two-factor authentication get an MFAChallengeResponse instead, to be completed at /users/login/mfa
 // @Tags Users
 // @Accept json
 // @Produce json
 // @Param user body LoginRequest true "User login request"
 // @Success 200 {object} LoginResponse
+// @Success 202 {object} MFAChallengeResponse
 // @Failure 400 {object} ErrorResponse "Bad Request"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
@@ -67,8 +71,9 @@ func (h *UserHandler) Login(c *gin.Context) {
 		_ = c.Error(errors.BadRequest.Wrap(err))
 		return
 	}
+	c.Set("auditTarget", req.Username)
 
-	user, token, err := h.service.Login(c.Request.Context(), req.Username, req.Password)
+	result, err := h.service.Login(c.Request.Context(), req.Username, req.Password, c.ClientIP())
 	if err != nil {
 		if err.Error() == "crypto/bcrypt: hashedPassword is not the hash of the given password" || err.Error() == "user not found" {
 			_ = c.Error(errors.Unauthorized)
@@ -78,9 +83,259 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if result.MFARequired() {
+		c.JSON(http.StatusAccepted, MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+			ExpiresIn:   int64(time.Until(result.MFATokenExpiresAt).Seconds()),
+		})
+		return
+	}
+
+	user, tokens := result.User, result.Tokens
 	c.JSON(http.StatusOK, LoginResponse{
-		ID:       strconv.FormatInt(user.ID, 10),
-		Username: user.Username,
-		Token:    token,
+		ID:           strconv.FormatInt(user.ID, 10),
+		Username:     user.Username,
+		Role:         string(user.Role),
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    expiresIn(tokens),
+	})
+}
+
+// VerifyMFA godoc
+// @Summary Complete a two-factor login
+// @Description Exchanges the mfa_token returned by login and a code of the authenticator app or a recovery code for an authentication token
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body MFALoginRequest true "MFA login request"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 429 {object} ErrorResponse "Too Many Requests"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /users/login/mfa [post]
+func (h *UserHandler) VerifyMFA(c *gin.Context) {
+	var req MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(errors.BadRequest.Wrap(err))
+		return
+	}
+
+	user, tokens, err := h.service.VerifyMFA(c.Request.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Set("auditTarget", user.Username)
+
+	c.JSON(http.StatusOK, LoginResponse{
+		ID:           strconv.FormatInt(user.ID, 10),
+		Username:     user.Username,
+		Role:         string(user.Role),
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    expiresIn(tokens),
+	})
+}
+
+// Refresh godoc
+// @Summary Refresh tokens
+// @Description Exchanges a refresh token for a new access token and refresh token. Each refresh token can only be used once
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body RefreshTokenRequest true "Refresh token request"
+// @Success 200 {object} RefreshTokenResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /users/refresh [post]
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(errors.BadRequest.Wrap(err))
+		return
+	}
+
+	tokens, err := h.service.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RefreshTokenResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    expiresIn(tokens),
 	})
 }
+
+// Logout godoc
+// @Summary User logout
+// @Description Revokes the current access token and its refresh tokens
+// @Tags Users
+// @Security ApiKeyAuth
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /users/logout [post]
+func (h *UserHandler) Logout(c *gin.Context) {
+	err := h.service.Logout(c.Request.Context(), c.GetString("tokenID"), c.GetTime("tokenExpiresAt"), c.GetString("tokenFamilyID"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetProfile godoc
+// @Summary Get the own account
+// @Description Returns the account of the authenticated user
+// @Tags Users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} ProfileResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /users/me [get]
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	profile, err := h.service.GetProfile(c.Request.Context(), caller.UserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ProfileResponse{
+		ID:         strconv.FormatInt(profile.User.ID, 10),
+		Username:   profile.User.Username,
+		Role:       string(profile.User.Role),
+		MFAEnabled: profile.MFAEnabled,
+		CreatedAt:  profile.User.CreatedAt,
+	})
+}
+
+// ChangePassword godoc
+// @Summary Change the password
+// @Description Replaces the password after checking the old one. Every session of the user ends, and a new one is returned
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body ChangePasswordRequest true "Old and new password"
+// @Success 200 {object} RefreshTokenResponse
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /users/me/password [patch]
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(errors.BadRequest.Wrap(err))
+		return
+	}
+
+	tokens, err := h.service.ChangePassword(c.Request.Context(), caller.UserID, req.OldPassword, req.NewPassword)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RefreshTokenResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    expiresIn(tokens),
+	})
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description Lists all users with their roles. Requires the admin role
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} ListUsersResponse
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Router /admin/users [get]
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	users, err := h.service.ListUsers(c.Request.Context(), caller)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	resp := ListUsersResponse{Users: make([]UserResponse, 0, len(users))}
+	for _, user := range users {
+		resp.Users = append(resp.Users, UserResponse{
+			ID:       strconv.FormatInt(user.ID, 10),
+			Username: user.Username,
+			Role:     string(user.Role),
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// SetUserRole godoc
+// @Summary Change the role of a user
+// @Description Sets the role of a user to admin, member or read_only. Tokens issued with the previous role stop working. Requires the admin role
+// @Tags Admin
+// @Accept json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Param request body SetRoleRequest true "New role"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Not Found"
+// @Router /admin/users/{id}/role [patch]
+func (h *UserHandler) SetUserRole(c *gin.Context) {
+	caller, err := callerFromContext(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(errors.BadRequest.New("user ID must be an integer"))
+		return
+	}
+
+	var req SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(errors.BadRequest.Wrap(err))
+		return
+	}
+
+	if err := h.service.SetRole(c.Request.Context(), caller, userID, entity.Role(req.Role)); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func expiresIn(tokens *application.TokenPair) int64 {
+	return int64(time.Until(tokens.AccessTokenExpiresAt).Seconds())
+}
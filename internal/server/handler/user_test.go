@@ -5,12 +5,14 @@ import (
 	"container-manager/internal/application"
 	"container-manager/internal/application/mocks"
 	"container-manager/internal/domain/entity"
+	keymanager "container-manager/internal/infrastructure/key_manager"
 	"container-manager/internal/errors"
 	"container-manager/internal/server/middleware"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/bwmarrin/snowflake"
 	"github.com/gin-gonic/gin"
@@ -26,7 +28,8 @@ func TestUserHandler_CreateUser(t *testing.T) {
 
 	mockUserRepo := mocks.NewMockUserRepository(ctrl)
 	idNode, _ := snowflake.NewNode(1)
-	userService := application.NewUserService(mockUserRepo, idNode, "secret")
+	keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "secret"})
+	userService := application.NewUserService(mockUserRepo, nil, nil, nil, keyManager, idNode, application.TokenOptions{}, entity.PasswordPolicy{}, nil)
 	userHandler := NewUserHandler(userService)
 
 	router := gin.Default()
@@ -61,6 +64,16 @@ func TestUserHandler_CreateUser(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
+
+	t.Run("invalid username", func(t *testing.T) {
+		body, _ := json.Marshal(CreateUserRequest{Username: "../admin", Password: "password123"})
+		req, _ := http.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), errors.InvalidUsername.Message)
+	})
 }
 
 func TestUserHandler_Login(t *testing.T) {
@@ -70,13 +83,18 @@ func TestUserHandler_Login(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockRevokedTokenRepo := mocks.NewMockRevokedTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
 	idNode, _ := snowflake.NewNode(1)
-	userService := application.NewUserService(mockUserRepo, idNode, "secret")
+	keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "secret"})
+	userService := application.NewUserService(mockUserRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockMFARepo, keyManager, idNode, application.TokenOptions{}, entity.PasswordPolicy{}, nil)
 	userHandler := NewUserHandler(userService)
 
 	router := gin.Default()
 	router.Use(middleware.ErrorHandler())
 	router.POST("/users/login", userHandler.Login)
+	router.POST("/users/login/mfa", userHandler.VerifyMFA)
 
 	t.Run("success", func(t *testing.T) {
 		username := "testuser"
@@ -92,6 +110,8 @@ func TestUserHandler_Login(t *testing.T) {
 		body, _ := json.Marshal(reqBody)
 
 		mockUserRepo.EXPECT().FindByUsername(gomock.Any(), username).Return(user, nil)
+		mockMFARepo.EXPECT().Get(gomock.Any(), user.ID).Return(nil, nil)
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
 
 		req, _ := http.NewRequest(http.MethodPost, "/users/login", bytes.NewBuffer(body))
 		w := httptest.NewRecorder()
@@ -103,6 +123,8 @@ func TestUserHandler_Login(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, username, resp.Username)
 		assert.NotEmpty(t, resp.Token)
+		assert.NotEmpty(t, resp.RefreshToken)
+		assert.InDelta(t, 15*60, resp.ExpiresIn, 1)
 	})
 
 	t.Run("user not found", func(t *testing.T) {
@@ -121,4 +143,301 @@ func TestUserHandler_Login(t *testing.T) {
 		// Handler returns Unauthorized (401) when user not found or password mismatch
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
-}
\ No newline at end of file
+
+	t.Run("two-factor authentication", func(t *testing.T) {
+		user, _ := entity.NewUser(12345, "mfauser", "password123")
+		secret, _ := entity.NewTOTPSecret()
+		now := time.Now()
+		mfa := &entity.UserMFA{UserID: user.ID, Secret: secret, EnabledAt: &now}
+
+		mockUserRepo.EXPECT().FindByUsername(gomock.Any(), "mfauser").Return(user, nil)
+		mockMFARepo.EXPECT().Get(gomock.Any(), user.ID).Return(mfa, nil)
+
+		body, _ := json.Marshal(LoginRequest{Username: "mfauser", Password: "password123"})
+		req, _ := http.NewRequest(http.MethodPost, "/users/login", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		var challenge MFAChallengeResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &challenge))
+		assert.True(t, challenge.MFARequired)
+		assert.NotEmpty(t, challenge.MFAToken)
+		assert.NotContains(t, w.Body.String(), `"token"`)
+
+		code, _ := entity.TOTPCode(secret, entity.TOTPStep(time.Now()))
+		mockRevokedTokenRepo.EXPECT().IsRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+		mockMFARepo.EXPECT().Get(gomock.Any(), user.ID).Return(mfa, nil)
+		mockMFARepo.EXPECT().UseStep(gomock.Any(), user.ID, gomock.Any()).Return(true, nil)
+		mockRevokedTokenRepo.EXPECT().Revoke(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), user.ID).Return(user, nil)
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		body, _ = json.Marshal(MFALoginRequest{MFAToken: challenge.MFAToken, Code: code})
+		req, _ = http.NewRequest(http.MethodPost, "/users/login/mfa", bytes.NewBuffer(body))
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp LoginResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "mfauser", resp.Username)
+		assert.NotEmpty(t, resp.Token)
+	})
+
+	t.Run("invalid mfa token", func(t *testing.T) {
+		body, _ := json.Marshal(MFALoginRequest{MFAToken: "invalid", Code: "123456"})
+		req, _ := http.NewRequest(http.MethodPost, "/users/login/mfa", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+func TestUserHandler_Refresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	idNode, _ := snowflake.NewNode(1)
+	keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "secret"})
+	userService := application.NewUserService(mockUserRepo, mockRefreshTokenRepo, nil, nil, keyManager, idNode, application.TokenOptions{}, entity.PasswordPolicy{}, nil)
+	userHandler := NewUserHandler(userService)
+
+	router := gin.Default()
+	router.Use(middleware.ErrorHandler())
+	router.POST("/users/refresh", userHandler.Refresh)
+
+	refreshToken := "refresh-token"
+	tokenHash := entity.HashRefreshToken(refreshToken)
+	body, _ := json.Marshal(RefreshTokenRequest{RefreshToken: refreshToken})
+
+	t.Run("success", func(t *testing.T) {
+		mockRefreshTokenRepo.EXPECT().GetByHash(gomock.Any(), tokenHash).Return(&entity.RefreshToken{
+			ID:        "token-id",
+			UserID:    12345,
+			FamilyID:  "family-id",
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}, nil)
+		mockRefreshTokenRepo.EXPECT().MarkRotated(gomock.Any(), "token-id").Return(true, nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(12345)).Return(&entity.User{ID: 12345, Role: entity.RoleMember}, nil)
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		req, _ := http.NewRequest(http.MethodPost, "/users/refresh", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp RefreshTokenResponse
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, resp.Token)
+		assert.NotEqual(t, refreshToken, resp.RefreshToken)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		mockRefreshTokenRepo.EXPECT().GetByHash(gomock.Any(), tokenHash).Return(nil, nil)
+
+		req, _ := http.NewRequest(http.MethodPost, "/users/refresh", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), errors.InvalidRefreshToken.Message)
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/users/refresh", bytes.NewBufferString("{}"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestUserHandler_Logout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockRevokedTokenRepo := mocks.NewMockRevokedTokenRepository(ctrl)
+	idNode, _ := snowflake.NewNode(1)
+	keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "secret"})
+	userService := application.NewUserService(nil, mockRefreshTokenRepo, mockRevokedTokenRepo, nil, keyManager, idNode, application.TokenOptions{}, entity.PasswordPolicy{}, nil)
+	userHandler := NewUserHandler(userService)
+
+	expiresAt := time.Now().Add(time.Minute)
+	router := gin.Default()
+	router.Use(middleware.ErrorHandler())
+	router.POST("/users/logout", func(c *gin.Context) {
+		c.Set("userID", "12345")
+		c.Set("tokenID", "jti")
+		c.Set("tokenExpiresAt", expiresAt)
+		c.Set("tokenFamilyID", "family-id")
+	}, userHandler.Logout)
+
+	mockRevokedTokenRepo.EXPECT().Revoke(gomock.Any(), "jti", expiresAt).Return(nil)
+	mockRefreshTokenRepo.EXPECT().RevokeFamily(gomock.Any(), "family-id").Return(nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/users/logout", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestUserHandler_Admin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	idNode, _ := snowflake.NewNode(1)
+	userService := application.NewUserService(mockUserRepo, nil, nil, nil, nil, idNode, application.TokenOptions{}, entity.PasswordPolicy{}, nil)
+	userHandler := NewUserHandler(userService)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "1")
+		c.Set("role", c.GetHeader("X-Role"))
+		c.Next()
+	})
+	router.GET("/admin/users", userHandler.ListUsers)
+	router.PATCH("/admin/users/:id/role", userHandler.SetUserRole)
+
+	serve := func(method, path, role string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			_ = json.NewEncoder(&body).Encode(payload)
+		}
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("X-Role", role)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("list users", func(t *testing.T) {
+		mockUserRepo.EXPECT().List(gomock.Any()).Return([]*entity.User{
+			{ID: 1, Username: "root", Role: entity.RoleAdmin},
+			{ID: 2, Username: "alice", Role: entity.RoleMember},
+		}, nil)
+
+		w := serve(http.MethodGet, "/admin/users", "admin", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp ListUsersResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, []UserResponse{
+			{ID: "1", Username: "root", Role: "admin"},
+			{ID: "2", Username: "alice", Role: "member"},
+		}, resp.Users)
+	})
+
+	t.Run("list users as member", func(t *testing.T) {
+		w := serve(http.MethodGet, "/admin/users", "member", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("set role", func(t *testing.T) {
+		mockUserRepo.EXPECT().UpdateRole(gomock.Any(), int64(2), entity.RoleReadOnly).Return(true, nil)
+
+		w := serve(http.MethodPatch, "/admin/users/2/role", "admin", SetRoleRequest{Role: "read_only"})
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("set unknown role", func(t *testing.T) {
+		w := serve(http.MethodPatch, "/admin/users/2/role", "admin", SetRoleRequest{Role: "owner"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("set role of unknown user", func(t *testing.T) {
+		mockUserRepo.EXPECT().UpdateRole(gomock.Any(), int64(3), entity.RoleAdmin).Return(false, nil)
+
+		w := serve(http.MethodPatch, "/admin/users/3/role", "admin", SetRoleRequest{Role: "admin"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestUserHandler_Me(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	idNode, _ := snowflake.NewNode(1)
+	keyManager, _ := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "secret"})
+	userService := application.NewUserService(mockUserRepo, mockRefreshTokenRepo, nil, mockMFARepo, keyManager, idNode, application.TokenOptions{}, entity.PasswordPolicy{}, nil)
+	userHandler := NewUserHandler(userService)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "1")
+		c.Set("role", "member")
+		c.Next()
+	})
+	router.GET("/users/me", userHandler.GetProfile)
+	router.PATCH("/users/me/password", userHandler.ChangePassword)
+
+	serve := func(method, path string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			_ = json.NewEncoder(&body).Encode(payload)
+		}
+		req, _ := http.NewRequest(method, path, &body)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("profile", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(&entity.User{ID: 1, Username: "alice", Role: entity.RoleMember, CreatedAt: createdAt}, nil)
+		mockMFARepo.EXPECT().Get(gomock.Any(), int64(1)).Return(nil, nil)
+
+		w := serve(http.MethodGet, "/users/me", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp ProfileResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, ProfileResponse{ID: "1", Username: "alice", Role: "member", CreatedAt: createdAt}, resp)
+	})
+
+	t.Run("change password", func(t *testing.T) {
+		user, _ := entity.NewUser(1, "alice", "old-password")
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(user, nil)
+		mockUserRepo.EXPECT().UpdatePassword(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).Return(true, nil)
+		mockRefreshTokenRepo.EXPECT().RevokeByUserID(gomock.Any(), int64(1)).Return(nil)
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		w := serve(http.MethodPatch, "/users/me/password", ChangePasswordRequest{OldPassword: "old-password", NewPassword: "new-password"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp RefreshTokenResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.NotEmpty(t, resp.Token)
+		assert.NotEmpty(t, resp.RefreshToken)
+	})
+
+	t.Run("change password with the wrong old password", func(t *testing.T) {
+		user, _ := entity.NewUser(1, "alice", "old-password")
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(user, nil)
+
+		w := serve(http.MethodPatch, "/users/me/password", ChangePasswordRequest{OldPassword: "wrong", NewPassword: "new-password"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("change password without the new password", func(t *testing.T) {
+		w := serve(http.MethodPatch, "/users/me/password", ChangePasswordRequest{OldPassword: "old-password"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
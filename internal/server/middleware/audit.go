@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"container-manager/internal/application"
+	"container-manager/internal/domain/entity"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	customErr "container-manager/internal/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditMiddleware records mutating requests in the audit log.
+type AuditMiddleware struct {
+	auditService *application.AuditService
+}
+
+func NewAuditMiddleware(auditService *application.AuditService) *AuditMiddleware {
+	return &AuditMiddleware{auditService: auditService}
+}
+
+// Handle adds an audit entry once the handler is done, for every request that may change something,
+// that is every method but GET, HEAD and OPTIONS, and for any other request whose route names an
+// action. Routes name their action with Action. Requests of routes without one, and those rejected
+// before reaching the route such as without a login, are recorded under their method and route,
+// e.g. "POST /containers", so that no attempted change goes unrecorded. The target
+// is identified by the "auditTarget" the handler set, or else by the id path parameter. A request
+// fails if the handler reported an error or responded with a status of 400 or above.
+func (m *AuditMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		action := c.GetString("auditAction")
+		if action == "" {
+			// Requests matching no route change nothing.
+			switch c.Request.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return
+			}
+			if c.FullPath() == "" {
+				return
+			}
+			action = c.Request.Method + " " + c.FullPath()
+		}
+		targetID := c.GetString("auditTarget")
+		if targetID == "" {
+			targetID = c.Param("id")
+		}
+		entry := &entity.AuditEntry{
+			APIKeyID:   c.GetString("apiKeyID"),
+			Action:     action,
+			TargetType: c.GetString("auditTargetType"),
+			TargetID:   targetID,
+			SourceIP:   c.ClientIP(),
+			Outcome:    entity.AuditOutcomeSuccess,
+			RequestID:  c.GetString("requestID"),
+		}
+		if userID, err := strconv.ParseInt(c.GetString("userID"), 10, 64); err == nil {
+			entry.ActorID = userID
+		}
+		if len(c.Errors) > 0 {
+			entry.Outcome = entity.AuditOutcomeFailure
+			// The message the ErrorHandler returns, internal errors are not spelled out.
+			entry.Error = "internal server error"
+			var customError *customErr.CustomError
+			if errors.As(c.Errors.Last().Err, &customError) {
+				entry.Error = customError.Message
+			}
+		} else if c.Writer.Status() >= http.StatusBadRequest {
+			entry.Outcome = entity.AuditOutcomeFailure
+			entry.Error = http.StatusText(c.Writer.Status())
+		}
+
+		// The entry is written even if the client went away in the meantime.
+		m.auditService.Record(context.WithoutCancel(c.Request.Context()), entry)
+	}
+}
+
+// Action names the action and the type of target Handle records for the requests of a route.
+func (m *AuditMiddleware) Action(action, targetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("auditAction", action)
+		c.Set("auditTargetType", targetType)
+		c.Next()
+	}
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"container-manager/internal/application"
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	customErr "container-manager/internal/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuditMiddleware_Handle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuditRepo := mocks.NewMockAuditRepository(ctrl)
+	auditMiddleware := NewAuditMiddleware(application.NewAuditService(mockAuditRepo))
+
+	router := gin.New()
+	router.Use(RequestID(), ErrorHandler(), auditMiddleware.Handle())
+	authenticated := func(c *gin.Context) {
+		c.Set("userID", "1")
+		c.Set("apiKeyID", "key-1")
+	}
+	router.DELETE("/containers/:id", authenticated, auditMiddleware.Action(entity.AuditActionContainerRemove, "container"), func(c *gin.Context) {
+		if c.Param("id") == "missing" {
+			_ = c.Error(customErr.ContainerNotFound)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	router.POST("/users/login", auditMiddleware.Action(entity.AuditActionUserLogin, "user"), func(c *gin.Context) {
+		c.Set("auditTarget", "alice")
+		c.AbortWithStatus(http.StatusTooManyRequests)
+	})
+	router.GET("/users/oidc/callback", auditMiddleware.Action(entity.AuditActionUserLoginOIDC, "user"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/containers/:id", authenticated, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.PATCH("/containers/:id", authenticated, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("X-Request-ID", "request-1")
+		req.RemoteAddr = "10.0.0.1:1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockAuditRepo.EXPECT().Append(gomock.Any(), &entity.AuditEntry{
+			ActorID:    1,
+			APIKeyID:   "key-1",
+			Action:     entity.AuditActionContainerRemove,
+			TargetType: "container",
+			TargetID:   "container-1",
+			SourceIP:   "10.0.0.1",
+			Outcome:    entity.AuditOutcomeSuccess,
+			RequestID:  "request-1",
+		}).Return(nil)
+
+		w := serve(http.MethodDelete, "/containers/container-1")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		mockAuditRepo.EXPECT().Append(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, entry *entity.AuditEntry) error {
+			assert.Equal(t, entity.AuditOutcomeFailure, entry.Outcome)
+			assert.Equal(t, "container not found", entry.Error)
+			assert.Equal(t, "missing", entry.TargetID)
+			return nil
+		})
+
+		w := serve(http.MethodDelete, "/containers/missing")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("target set by the handler", func(t *testing.T) {
+		mockAuditRepo.EXPECT().Append(gomock.Any(), &entity.AuditEntry{
+			Action:     entity.AuditActionUserLogin,
+			TargetType: "user",
+			TargetID:   "alice",
+			SourceIP:   "10.0.0.1",
+			Outcome:    entity.AuditOutcomeFailure,
+			Error:      "Too Many Requests",
+			RequestID:  "request-1",
+		}).Return(nil)
+
+		w := serve(http.MethodPost, "/users/login")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("route without an action", func(t *testing.T) {
+		mockAuditRepo.EXPECT().Append(gomock.Any(), &entity.AuditEntry{
+			ActorID:   1,
+			APIKeyID:  "key-1",
+			Action:    "PATCH /containers/:id",
+			TargetID:  "container-1",
+			SourceIP:  "10.0.0.1",
+			Outcome:   entity.AuditOutcomeSuccess,
+			RequestID: "request-1",
+		}).Return(nil)
+
+		w := serve(http.MethodPatch, "/containers/container-1")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("read with an action", func(t *testing.T) {
+		mockAuditRepo.EXPECT().Append(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, entry *entity.AuditEntry) error {
+			assert.Equal(t, entity.AuditActionUserLoginOIDC, entry.Action)
+			return nil
+		})
+
+		w := serve(http.MethodGet, "/users/oidc/callback")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("not recorded", func(t *testing.T) {
+		// Reads without an action, and requests matching no route.
+		w := serve(http.MethodGet, "/containers/container-1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = serve(http.MethodPost, "/unknown")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
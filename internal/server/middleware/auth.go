@@ -1,19 +1,31 @@
 package middleware
 
 import (
+	"container-manager/internal/application"
+	"container-manager/internal/domain/entity"
+	"container-manager/internal/domain/infrastructure"
+	"errors"
+	"log"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+
+	customErr "container-manager/internal/errors"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type AuthMiddleware struct {
-	jwtSecret string
+	keyManager       infrastructure.KeyManager
+	revokedTokenRepo infrastructure.RevokedTokenRepository
+	apiKeyService    *application.APIKeyService
+	userRepo         infrastructure.UserRepository
 }
 
-func NewAuthMiddleware(jwtSecret string) *AuthMiddleware {
-	return &AuthMiddleware{jwtSecret: jwtSecret}
+func NewAuthMiddleware(keyManager infrastructure.KeyManager, revokedTokenRepo infrastructure.RevokedTokenRepository, apiKeyService *application.APIKeyService, userRepo infrastructure.UserRepository) *AuthMiddleware {
+	return &AuthMiddleware{keyManager: keyManager, revokedTokenRepo: revokedTokenRepo, apiKeyService: apiKeyService, userRepo: userRepo}
 }
 
 func (m *AuthMiddleware) Handle() gin.HandlerFunc {
@@ -32,25 +44,147 @@ func (m *AuthMiddleware) Handle() gin.HandlerFunc {
 
 		tokenString := parts[1]
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(m.jwtSecret), nil
-		})
+		if entity.IsAPIKey(tokenString) {
+			m.handleAPIKey(c, tokenString)
+			return
+		}
 
+		claims, err := m.keyManager.Parse(tokenString)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			c.Set("userID", claims["sub"])
-		} else {
+		jti, _ := claims["jti"].(string)
+		expiresAt, err := claims.GetExpirationTime()
+		// MFA challenge tokens are signed with the same keys but must not authenticate requests.
+		tokenType, hasType := claims["typ"]
+		if jti == "" || err != nil || expiresAt == nil || (hasType && tokenType != application.AccessTokenType) {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
 
+		revoked, err := m.revokedTokenRepo.IsRevoked(c.Request.Context(), jti)
+		if err != nil {
+			log.Printf("failed to check token revocation: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+			return
+		}
+
+		// The role claim is re-validated against the database, so that a token issued before
+		// the role of the user changed stops working. Tokens issued before roles existed have no claim.
+		subject, _ := claims["sub"].(string)
+		user, ok := m.loadUser(c, subject)
+		if !ok {
+			return
+		}
+		if role, hasRole := claims["role"]; hasRole && role != string(user.Role) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "role changed, refresh the token"})
+			return
+		}
+		// Changing the password ends the sessions started before it.
+		var issuedAt time.Time
+		if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+			issuedAt = iat.Time
+		}
+		if !user.SessionValid(issuedAt) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session ended, sign in again"})
+			return
+		}
+
+		c.Set("userID", subject)
+		c.Set("role", string(user.Role))
+		c.Set("tokenID", jti)
+		c.Set("tokenExpiresAt", expiresAt.Time)
+		familyID, _ := claims["fid"].(string)
+		c.Set("tokenFamilyID", familyID)
+
+		c.Next()
+	}
+}
+
+// handleAPIKey authenticates a request made with an API key. Such requests are limited to the
+// scopes of the key, see RequireScope.
+func (m *AuthMiddleware) handleAPIKey(c *gin.Context, value string) {
+	key, err := m.apiKeyService.Authenticate(c.Request.Context(), value)
+	if err != nil {
+		var customError *customErr.CustomError
+		if errors.As(err, &customError) {
+			c.AbortWithStatusJSON(customError.Status, gin.H{"error": customError.Message})
+			return
+		}
+		log.Printf("failed to authenticate api key: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	user, ok := m.loadUser(c, strconv.FormatInt(key.UserID, 10))
+	if !ok {
+		return
+	}
+
+	c.Set("userID", strconv.FormatInt(key.UserID, 10))
+	c.Set("role", string(user.Role))
+	c.Set("apiKeyID", key.ID)
+	c.Set("apiKeyScopes", key.Scopes)
+
+	c.Next()
+}
+
+// loadUser looks up the authenticated user, aborting the request if the user no longer exists.
+func (m *AuthMiddleware) loadUser(c *gin.Context, subject string) (*entity.User, bool) {
+	userID, err := strconv.ParseInt(subject, 10, 64)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return nil, false
+	}
+	user, err := m.userRepo.FindByID(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("failed to load user %d: %v", userID, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return nil, false
+	}
+	if user == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return nil, false
+	}
+	return user, true
+}
+
+// RequireRole rejects requests of users without the role.
+func RequireRole(role entity.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("role") != string(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "requires role " + string(role)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope rejects requests made with an API key that was not granted the scope.
+// Requests authenticated with a JWT are not limited.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if scopes, ok := c.Get("apiKeyScopes"); ok && !slices.Contains(scopes.([]string), scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "api key lacks scope " + scope})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession rejects requests made with an API key, for endpoints that manage the account itself.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("apiKeyID") != "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "api keys cannot be used for this endpoint"})
+			return
+		}
 		c.Next()
 	}
 }
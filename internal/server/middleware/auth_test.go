@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"container-manager/internal/application"
+	"container-manager/internal/application/mocks"
+	"container-manager/internal/domain/entity"
+	keymanager "container-manager/internal/infrastructure/key_manager"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuthMiddleware_Handle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRevokedTokenRepo := mocks.NewMockRevokedTokenRepository(ctrl)
+	keyManager, err := keymanager.NewKeyManager(keymanager.Options{HMACSecret: "secret"})
+	assert.NoError(t, err)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	authMiddleware := NewAuthMiddleware(keyManager, mockRevokedTokenRepo, nil, mockUserRepo)
+
+	router := gin.New()
+	router.GET("/", authMiddleware.Handle(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userID")+" "+c.GetString("tokenFamilyID")+" "+c.GetString("role"))
+	})
+	router.GET("/admin", authMiddleware.Handle(), RequireRole(entity.RoleAdmin), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+		assert.NoError(t, err)
+		return token
+	}
+	serve := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	exp := time.Now().Add(time.Minute).Unix()
+	member := &entity.User{ID: 1234, Username: "alice", Role: entity.RoleMember}
+	admin := &entity.User{ID: 1234, Username: "alice", Role: entity.RoleAdmin}
+
+	t.Run("valid token", func(t *testing.T) {
+		mockRevokedTokenRepo.EXPECT().IsRevoked(gomock.Any(), "jti").Return(false, nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(1234)).Return(member, nil)
+
+		w := serve(sign(jwt.MapClaims{"sub": "1234", "jti": "jti", "fid": "family-id", "role": "member", "exp": exp}))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1234 family-id member", w.Body.String())
+	})
+
+	t.Run("role changed since the token was issued", func(t *testing.T) {
+		mockRevokedTokenRepo.EXPECT().IsRevoked(gomock.Any(), "jti").Return(false, nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(1234)).Return(member, nil)
+
+		w := serve(sign(jwt.MapClaims{"sub": "1234", "jti": "jti", "role": "admin", "exp": exp}))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("password changed since the token was issued", func(t *testing.T) {
+		changedAt := time.Now().Add(-time.Minute)
+		user := &entity.User{ID: 1234, Username: "alice", Role: entity.RoleMember, SessionsValidAfter: changedAt}
+		mockRevokedTokenRepo.EXPECT().IsRevoked(gomock.Any(), "jti").Return(false, nil).Times(2)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(1234)).Return(user, nil).Times(2)
+
+		w := serve(sign(jwt.MapClaims{"sub": "1234", "jti": "jti", "iat": changedAt.Add(-time.Second).Unix(), "exp": exp}))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = serve(sign(jwt.MapClaims{"sub": "1234", "jti": "jti", "iat": changedAt.Unix(), "exp": exp}))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		mockRevokedTokenRepo.EXPECT().IsRevoked(gomock.Any(), "jti").Return(false, nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(1234)).Return(nil, nil)
+
+		w := serve(sign(jwt.MapClaims{"sub": "1234", "jti": "jti", "exp": exp}))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("admin route", func(t *testing.T) {
+		mockRevokedTokenRepo.EXPECT().IsRevoked(gomock.Any(), "jti").Return(false, nil).Times(2)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(1234)).Return(admin, nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(1234)).Return(member, nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+sign(jwt.MapClaims{"sub": "1234", "jti": "jti", "role": "admin", "exp": exp}))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		req, _ = http.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+sign(jwt.MapClaims{"sub": "1234", "jti": "jti", "role": "member", "exp": exp}))
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		mockRevokedTokenRepo.EXPECT().IsRevoked(gomock.Any(), "jti").Return(true, nil)
+
+		w := serve(sign(jwt.MapClaims{"sub": "1234", "jti": "jti", "exp": exp}))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("access token type", func(t *testing.T) {
+		mockRevokedTokenRepo.EXPECT().IsRevoked(gomock.Any(), "jti").Return(false, nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(1234)).Return(member, nil)
+
+		w := serve(sign(jwt.MapClaims{"sub": "1234", "jti": "jti", "typ": application.AccessTokenType, "exp": exp}))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("mfa challenge token", func(t *testing.T) {
+		w := serve(sign(jwt.MapClaims{"sub": "1234", "jti": "jti", "typ": application.MFATokenType, "exp": exp}))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("token without jti", func(t *testing.T) {
+		w := serve(sign(jwt.MapClaims{"sub": "1234", "exp": exp}))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("denylist unavailable", func(t *testing.T) {
+		mockRevokedTokenRepo.EXPECT().IsRevoked(gomock.Any(), "jti").Return(false, errors.New("database error"))
+
+		w := serve(sign(jwt.MapClaims{"sub": "1234", "jti": "jti", "exp": exp}))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		w := serve(sign(jwt.MapClaims{"sub": "1234", "jti": "jti", "exp": time.Now().Add(-time.Minute).Unix()}))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestAuthMiddleware_APIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAPIKeyRepo := mocks.NewMockAPIKeyRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(1234)).Return(&entity.User{ID: 1234, Role: entity.RoleMember}, nil).AnyTimes()
+	authMiddleware := NewAuthMiddleware(nil, nil, application.NewAPIKeyService(mockAPIKeyRepo), mockUserRepo)
+
+	router := gin.New()
+	ok := func(c *gin.Context) { c.String(http.StatusOK, c.GetString("userID")) }
+	router.GET("/containers", authMiddleware.Handle(), RequireScope(entity.ScopeContainersRead), ok)
+	router.POST("/containers", authMiddleware.Handle(), RequireScope(entity.ScopeContainersWrite), ok)
+	router.GET("/api-keys", authMiddleware.Handle(), RequireSession(), ok)
+
+	value := "cm_abcdefgh"
+	keyHash := entity.HashAPIKey(value)
+	lastUsedAt := time.Now()
+	key := &entity.APIKey{ID: "key-id", UserID: 1234, Scopes: []string{entity.ScopeContainersRead}, LastUsedAt: &lastUsedAt}
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+value)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("granted scope", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().GetByHash(gomock.Any(), keyHash).Return(key, nil)
+
+		w := serve(http.MethodGet, "/containers")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1234", w.Body.String())
+	})
+
+	t.Run("missing scope", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().GetByHash(gomock.Any(), keyHash).Return(key, nil)
+
+		w := serve(http.MethodPost, "/containers")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("session only endpoint", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().GetByHash(gomock.Any(), keyHash).Return(key, nil)
+
+		w := serve(http.MethodGet, "/api-keys")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().GetByHash(gomock.Any(), keyHash).Return(nil, nil)
+
+		w := serve(http.MethodGet, "/containers")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid api key")
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().GetByHash(gomock.Any(), keyHash).Return(nil, errors.New("database error"))
+
+		w := serve(http.MethodGet, "/containers")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("requestID"))
+	})
+
+	serve := func(requestID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		if requestID != "" {
+			req.Header.Set("X-Request-ID", requestID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("generated", func(t *testing.T) {
+		w := serve("")
+		assert.Len(t, w.Body.String(), 36)
+		assert.Equal(t, w.Body.String(), w.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("from the client", func(t *testing.T) {
+		w := serve("trace-abc-123")
+		assert.Equal(t, "trace-abc-123", w.Body.String())
+		assert.Equal(t, "trace-abc-123", w.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, requestID := range []string{"with space", strings.Repeat("a", 129)} {
+			w := serve(requestID)
+			assert.NotEqual(t, requestID, w.Body.String())
+			assert.Len(t, w.Body.String(), 36)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTusResumable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(TusResumable())
+	router.Handle(http.MethodOptions, "/uploads", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	router.POST("/uploads", func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	serve := func(method, version string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/uploads", nil)
+		if version != "" {
+			req.Header.Set("Tus-Resumable", version)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("supported version", func(t *testing.T) {
+		w := serve(http.MethodPost, "1.0.0")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "1.0.0", w.Header().Get("Tus-Resumable"))
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		for _, version := range []string{"", "0.2.2"} {
+			w := serve(http.MethodPost, version)
+			assert.Equal(t, http.StatusPreconditionFailed, w.Code, version)
+			assert.Equal(t, "1.0.0", w.Header().Get("Tus-Version"))
+		}
+	})
+
+	t.Run("options without version", func(t *testing.T) {
+		w := serve(http.MethodOptions, "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "1.0.0", w.Header().Get("Tus-Resumable"))
+	})
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"container-manager/docs"
+	"container-manager/internal/domain/entity"
 	"container-manager/internal/server/handler"
 	"container-manager/internal/server/middleware"
 
@@ -16,40 +17,158 @@ func RegisterRoutes(
 	router *gin.Engine,
 	userHandler *handler.UserHandler,
 	containerHandler *handler.ContainerHandler,
+	containerFileHandler *handler.ContainerFileHandler,
 	fileHandler *handler.FileHandler,
+	fileShareHandler *handler.FileShareHandler,
 	jobHandler *handler.JobHandler,
+	quotaHandler *handler.QuotaHandler,
+	apiKeyHandler *handler.APIKeyHandler,
+	jwksHandler *handler.JWKSHandler,
+	oidcHandler *handler.OIDCHandler,
+	mfaHandler *handler.MFAHandler,
+	teamHandler *handler.TeamHandler,
+	accountHandler *handler.AccountHandler,
+	auditHandler *handler.AuditHandler,
+	uploadHandler *handler.UploadHandler,
 	authMiddleware *middleware.AuthMiddleware,
+	auditMiddleware *middleware.AuditMiddleware,
 ) {
-	router.Use(middleware.ErrorHandler())
+	// Every request that may change something is audited, see AuditMiddleware.Handle.
+	router.Use(middleware.RequestID(), middleware.ErrorHandler(), auditMiddleware.Handle())
 
 	docs.SwaggerInfo.BasePath = "/"
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
 
 	userRoutes := router.Group("/users")
 	{
-		userRoutes.POST("", userHandler.CreateUser)
-		userRoutes.POST("/login", userHandler.Login)
+		userRoutes.POST("", auditMiddleware.Action(entity.AuditActionUserCreate, "user"), userHandler.CreateUser)
+		userRoutes.POST("/login", auditMiddleware.Action(entity.AuditActionUserLogin, "user"), userHandler.Login)
+		userRoutes.POST("/login/mfa", auditMiddleware.Action(entity.AuditActionUserLoginMFA, "user"), userHandler.VerifyMFA)
+		userRoutes.POST("/refresh", auditMiddleware.Action(entity.AuditActionUserRefresh, "user"), userHandler.Refresh)
+		userRoutes.GET("/oidc/:provider/login", oidcHandler.Login)
+		userRoutes.GET("/oidc/:provider/callback", auditMiddleware.Action(entity.AuditActionUserLoginOIDC, "user"), oidcHandler.Callback)
+		userRoutes.POST("/logout", authMiddleware.Handle(), auditMiddleware.Action(entity.AuditActionUserLogout, "user"), middleware.RequireSession(), userHandler.Logout)
+	}
+
+	meRoutes := router.Group("/users/me")
+	meRoutes.Use(authMiddleware.Handle())
+	{
+		meRoutes.GET("", middleware.RequireSession(), userHandler.GetProfile)
+		meRoutes.PATCH("/password", auditMiddleware.Action(entity.AuditActionUserPassword, "user"), middleware.RequireSession(), userHandler.ChangePassword)
+		meRoutes.DELETE("", auditMiddleware.Action(entity.AuditActionUserDelete, "job"), middleware.RequireSession(), accountHandler.DeleteAccount)
+		meRoutes.GET("/quota", middleware.RequireSession(), quotaHandler.GetQuota)
+	}
+
+	apiKeyRoutes := meRoutes.Group("/api-keys")
+	apiKeyRoutes.Use(middleware.RequireSession())
+	{
+		apiKeyRoutes.POST("", auditMiddleware.Action(entity.AuditActionAPIKeyCreate, "api_key"), apiKeyHandler.CreateAPIKey)
+		apiKeyRoutes.GET("", apiKeyHandler.ListAPIKeys)
+		apiKeyRoutes.DELETE("/:id", auditMiddleware.Action(entity.AuditActionAPIKeyRevoke, "api_key"), apiKeyHandler.RevokeAPIKey)
+	}
+
+	mfaRoutes := meRoutes.Group("/mfa")
+	mfaRoutes.Use(middleware.RequireSession())
+	{
+		mfaRoutes.POST("/totp", auditMiddleware.Action(entity.AuditActionMFAEnroll, "user"), mfaHandler.EnrollTOTP)
+		mfaRoutes.POST("/totp/confirm", auditMiddleware.Action(entity.AuditActionMFAConfirm, "user"), mfaHandler.ConfirmTOTP)
+		mfaRoutes.DELETE("/totp", auditMiddleware.Action(entity.AuditActionMFADisable, "user"), mfaHandler.DisableTOTP)
 	}
 
 	containerRoutes := router.Group("/containers")
 	containerRoutes.Use(authMiddleware.Handle())
 	{
-		containerRoutes.GET("", containerHandler.ListContainers)
-		containerRoutes.POST("", containerHandler.CreateContainer)
-		containerRoutes.PATCH("/:id/start", containerHandler.StartContainer)
-		containerRoutes.PATCH("/:id/stop", containerHandler.StopContainer)
-		containerRoutes.DELETE("/:id", containerHandler.RemoveContainer)
+		containerRoutes.GET("", middleware.RequireScope(entity.ScopeContainersRead), containerHandler.ListContainers)
+		containerRoutes.POST("", auditMiddleware.Action(entity.AuditActionContainerCreate, "job"), middleware.RequireScope(entity.ScopeContainersWrite), containerHandler.CreateContainer)
+		containerRoutes.GET("/:id/logs", middleware.RequireScope(entity.ScopeContainersRead), containerHandler.ContainerLogs)
+		containerRoutes.PUT("/:id/archive", auditMiddleware.Action(entity.AuditActionContainerCopyIn, "container"), middleware.RequireScope(entity.ScopeContainersWrite), containerFileHandler.PutArchive)
+		containerRoutes.GET("/:id/archive", middleware.RequireScope(entity.ScopeContainersRead), containerFileHandler.GetArchive)
+		containerRoutes.POST("/:id/files/import", auditMiddleware.Action(entity.AuditActionContainerCopyIn, "container"), middleware.RequireScope(entity.ScopeContainersWrite), middleware.RequireScope(entity.ScopeFilesRead), containerFileHandler.ImportFile)
+		containerRoutes.POST("/:id/files/export", auditMiddleware.Action(entity.AuditActionContainerExport, "file"), middleware.RequireScope(entity.ScopeContainersRead), middleware.RequireScope(entity.ScopeFilesWrite), containerFileHandler.ExportFile)
+		containerRoutes.PATCH("/:id/start", auditMiddleware.Action(entity.AuditActionContainerStart, "container"), middleware.RequireScope(entity.ScopeContainersWrite), containerHandler.StartContainer)
+		containerRoutes.PATCH("/:id/stop", auditMiddleware.Action(entity.AuditActionContainerStop, "container"), middleware.RequireScope(entity.ScopeContainersWrite), containerHandler.StopContainer)
+		containerRoutes.PATCH("/:id", auditMiddleware.Action(entity.AuditActionContainerRename, "container"), middleware.RequireScope(entity.ScopeContainersWrite), containerHandler.RenameContainer)
+		containerRoutes.DELETE("/:id", auditMiddleware.Action(entity.AuditActionContainerRemove, "container"), middleware.RequireScope(entity.ScopeContainersWrite), containerHandler.RemoveContainer)
 	}
 
 	fileRoutes := router.Group("/files")
 	fileRoutes.Use(authMiddleware.Handle())
 	{
-		fileRoutes.POST("", fileHandler.UploadFile)
+		fileRoutes.POST("", auditMiddleware.Action(entity.AuditActionFileUpload, "file"), middleware.RequireScope(entity.ScopeFilesWrite), fileHandler.UploadFile)
+		fileRoutes.GET("", middleware.RequireScope(entity.ScopeFilesRead), fileHandler.ListFiles)
+		fileRoutes.POST("/folders", auditMiddleware.Action(entity.AuditActionFolderCreate, "file"), middleware.RequireScope(entity.ScopeFilesWrite), fileHandler.CreateFolder)
+		fileRoutes.POST("/move", auditMiddleware.Action(entity.AuditActionFileMove, "file"), middleware.RequireScope(entity.ScopeFilesWrite), fileHandler.MoveFile)
+		fileRoutes.POST("/extract", auditMiddleware.Action(entity.AuditActionFileExtract, "file"), middleware.RequireScope(entity.ScopeFilesWrite), fileHandler.ExtractFile)
+		// Gin cannot route a suffix after the catch-all, so the path of the file comes after /share.
+		fileRoutes.POST("/share/*path", auditMiddleware.Action(entity.AuditActionFileShare, "file"), middleware.RequireScope(entity.ScopeFilesWrite), fileShareHandler.CreateShare)
+		fileRoutes.GET("/*path", middleware.RequireScope(entity.ScopeFilesRead), fileHandler.DownloadFile)
+		fileRoutes.DELETE("/*path", auditMiddleware.Action(entity.AuditActionFileDelete, "file"), middleware.RequireScope(entity.ScopeFilesWrite), fileHandler.DeleteFile)
+	}
+
+	shareRoutes := router.Group("/shares")
+	shareRoutes.Use(authMiddleware.Handle())
+	{
+		shareRoutes.GET("", middleware.RequireScope(entity.ScopeFilesRead), fileShareHandler.ListShares)
+		shareRoutes.DELETE("/:id", auditMiddleware.Action(entity.AuditActionShareRevoke, "share"), middleware.RequireScope(entity.ScopeFilesWrite), fileShareHandler.RevokeShare)
+	}
+	// Share links are used without logging in, the token is all it takes.
+	router.GET("/shared/:token", fileShareHandler.DownloadSharedFile)
+
+	// Resumable uploads with the tus protocol. OPTIONS describes the server and needs no login.
+	uploadRoutes := router.Group("/uploads")
+	uploadRoutes.Use(middleware.TusResumable())
+	{
+		uploadRoutes.OPTIONS("", uploadHandler.Options)
+	}
+
+	uploadFileRoutes := uploadRoutes.Group("")
+	uploadFileRoutes.Use(authMiddleware.Handle(), middleware.RequireScope(entity.ScopeFilesWrite))
+	{
+		uploadFileRoutes.POST("", auditMiddleware.Action(entity.AuditActionUploadCreate, "file"), uploadHandler.CreateUpload)
+		uploadFileRoutes.HEAD("/:id", uploadHandler.GetUpload)
+		uploadFileRoutes.PATCH("/:id", auditMiddleware.Action(entity.AuditActionUploadWrite, "upload"), uploadHandler.WriteUpload)
+		uploadFileRoutes.DELETE("/:id", auditMiddleware.Action(entity.AuditActionUploadCancel, "upload"), uploadHandler.DeleteUpload)
+	}
+
+	teamRoutes := router.Group("/teams")
+	teamRoutes.Use(authMiddleware.Handle())
+	{
+		teamRoutes.POST("", auditMiddleware.Action(entity.AuditActionTeamCreate, "team"), middleware.RequireSession(), teamHandler.CreateTeam)
+		teamRoutes.GET("", middleware.RequireSession(), teamHandler.ListTeams)
+		teamRoutes.GET("/:id/members", middleware.RequireSession(), teamHandler.ListMembers)
+		teamRoutes.POST("/:id/members", auditMiddleware.Action(entity.AuditActionTeamMemberSet, "team"), middleware.RequireSession(), teamHandler.SetMember)
+		teamRoutes.DELETE("/:id/members/:userId", auditMiddleware.Action(entity.AuditActionTeamMemberRemove, "team"), middleware.RequireSession(), teamHandler.RemoveMember)
+		teamRoutes.GET("/:id/containers", middleware.RequireScope(entity.ScopeContainersRead), containerHandler.ListTeamContainers)
 	}
 
 	jobRoutes := router.Group("/jobs")
 	jobRoutes.Use(authMiddleware.Handle())
 	{
-		jobRoutes.GET("/:id", jobHandler.GetJob)
+		jobRoutes.GET("", middleware.RequireScope(entity.ScopeJobsRead), jobHandler.ListJobs)
+		jobRoutes.GET("/:id", middleware.RequireScope(entity.ScopeJobsRead), jobHandler.GetJob)
+	}
+
+	// Admin routes reuse the handlers above, the services let admins act on resources of any user.
+	adminRoutes := router.Group("/admin")
+	adminRoutes.Use(authMiddleware.Handle(), middleware.RequireSession(), middleware.RequireRole(entity.RoleAdmin))
+	{
+		adminRoutes.GET("/users", userHandler.ListUsers)
+		adminRoutes.PATCH("/users/:id/role", auditMiddleware.Action(entity.AuditActionUserRole, "user"), userHandler.SetUserRole)
+		adminRoutes.GET("/users/:id/containers", containerHandler.ListUserContainers)
+		adminRoutes.GET("/users/:id/jobs", jobHandler.ListUserJobs)
+		adminRoutes.GET("/containers/:id/logs", containerHandler.ContainerLogs)
+		adminRoutes.PATCH("/containers/:id/start", auditMiddleware.Action(entity.AuditActionContainerStart, "container"), containerHandler.StartContainer)
+		adminRoutes.PATCH("/containers/:id/stop", auditMiddleware.Action(entity.AuditActionContainerStop, "container"), containerHandler.StopContainer)
+		adminRoutes.PATCH("/containers/:id", auditMiddleware.Action(entity.AuditActionContainerRename, "container"), containerHandler.RenameContainer)
+		adminRoutes.DELETE("/containers/:id", auditMiddleware.Action(entity.AuditActionContainerRemove, "container"), containerHandler.RemoveContainer)
+		adminRoutes.GET("/jobs/:id", jobHandler.GetJob)
+	}
+
+	auditRoutes := router.Group("/audit")
+	auditRoutes.Use(authMiddleware.Handle(), middleware.RequireSession(), middleware.RequireRole(entity.RoleAdmin))
+	{
+		auditRoutes.GET("", auditHandler.ListAudit)
+		auditRoutes.GET("/export", auditHandler.ExportAudit)
 	}
 }
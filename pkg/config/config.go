@@ -2,6 +2,7 @@ package config
 
 import (
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,17 +12,163 @@ import (
 type Config struct {
 	Server    ServerConfig
 	Snowflake SnowflakeConfig
-	DB        DBConfig      `mapstructure:"db"`
-	Storage   StorageConfig `mapstructure:"storage"`
+	DB        DBConfig       `mapstructure:"db"`
+	Storage   StorageConfig  `mapstructure:"storage"`
+	Upload    UploadConfig   `mapstructure:"upload"`
+	Share     ShareConfig    `mapstructure:"share"`
+	Quota     QuotaConfig    `mapstructure:"quota"`
+	JWT       JWTConfig      `mapstructure:"jwt"`
+	OIDC      OIDCConfig     `mapstructure:"oidc"`
+	MFA       MFAConfig      `mapstructure:"mfa"`
+	Password  PasswordConfig `mapstructure:"password"`
+	Login     LoginConfig    `mapstructure:"login"`
 }
 
+// PasswordConfig is the policy for passwords users choose.
+type PasswordConfig struct {
+	MinLength int `mapstructure:"min_length"`
+	// MinCharacterClasses is how many of lowercase, uppercase, digits and symbols a password mixes.
+	MinCharacterClasses int `mapstructure:"min_character_classes"`
+	// BreachedListFile is a file of leaked passwords, one per line, that users cannot choose.
+	BreachedListFile string `mapstructure:"breached_list_file"`
+}
+
+// LoginConfig throttles failed logins per account and per client IP.
+type LoginConfig struct {
+	Account LoginThrottleConfig `mapstructure:"account"`
+	IP      LoginThrottleConfig `mapstructure:"ip"`
+}
+
+// LoginThrottleConfig is an entity.LoginThrottlePolicy, zero values disable the respective limit.
+type LoginThrottleConfig struct {
+	FreeFailures    int           `mapstructure:"free_failures"`
+	BaseDelay       time.Duration `mapstructure:"base_delay"`
+	MaxDelay        time.Duration `mapstructure:"max_delay"`
+	LockoutFailures int           `mapstructure:"lockout_failures"`
+	Lockout         time.Duration `mapstructure:"lockout"`
+	ResetAfter      time.Duration `mapstructure:"reset_after"`
+}
+
+// MFAConfig configures two-factor authentication.
+type MFAConfig struct {
+	// Issuer names the service in authenticator apps.
+	Issuer string `mapstructure:"issuer"`
+}
+
+// OIDCConfig holds the OpenID Connect identity providers users can sign in with.
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig `mapstructure:"providers"`
+}
+
+type OIDCProviderConfig struct {
+	// Name is used in the login and callback URLs, /users/oidc/{name}/login.
+	Name         string   `mapstructure:"name"`
+	Issuer       string   `mapstructure:"issuer"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"`
+	Scopes       []string `mapstructure:"scopes"`
+	// UsernameClaims are tried in order to name the user created on the first login.
+	UsernameClaims    []string `mapstructure:"username_claims"`
+	LinkExistingUsers bool     `mapstructure:"link_existing_users"`
+}
+
+// JWTConfig holds the asymmetric keys access tokens are signed with. Without keys,
+// tokens are signed with server.jwt_secret using HS256.
+type JWTConfig struct {
+	SigningKeyID string         `mapstructure:"signing_key_id"`
+	Keys         []JWTKeyConfig `mapstructure:"keys"`
+	// AcceptLegacyHS256Until is an RFC 3339 time until which tokens signed with server.jwt_secret
+	// stay valid after switching to a signing key. Empty rejects them right away.
+	AcceptLegacyHS256Until string `mapstructure:"accept_legacy_hs256_until"`
+}
+
+// JWTKeyConfig is a PEM encoded RSA or Ed25519 key. Keys that only verify tokens issued
+// before a rotation need only the public key file.
+type JWTKeyConfig struct {
+	ID             string `mapstructure:"id"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+}
+
+// QuotaConfig holds the default quota of users without custom limits. Zero means unlimited.
+type QuotaConfig struct {
+	Containers        int64 `mapstructure:"containers"`
+	RunningContainers int64 `mapstructure:"running_containers"`
+	MemoryBytes       int64 `mapstructure:"memory_bytes"`
+	NanoCPUs          int64 `mapstructure:"nano_cpus"`
+	StorageBytes      int64 `mapstructure:"storage_bytes"`
+	// ContainerMemoryBytes and ContainerNanoCPUs are applied to containers created without explicit limits.
+	ContainerMemoryBytes int64 `mapstructure:"container_memory_bytes"`
+	ContainerNanoCPUs    int64 `mapstructure:"container_nano_cpus"`
+}
+
+// StorageConfig selects where uploaded files are kept, "local" (the default) for the BasePath folder
+// or "s3" for an S3 compatible bucket.
 type StorageConfig struct {
-	BasePath string `mapstructure:"base_path"`
+	Backend  string   `mapstructure:"backend"`
+	BasePath string   `mapstructure:"base_path"`
+	S3       S3Config `mapstructure:"s3"`
+	// GCInterval is how often stored content no file uses any more is removed, never if zero.
+	GCInterval time.Duration `mapstructure:"gc_interval"`
+}
+
+// S3Config holds the bucket of the s3 storage backend.
+type S3Config struct {
+	Endpoint        string `mapstructure:"endpoint"`
+	Region          string `mapstructure:"region"`
+	Bucket          string `mapstructure:"bucket"`
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+	// UsePathStyle puts the bucket in the path rather than the host name, as MinIO expects.
+	UsePathStyle bool `mapstructure:"use_path_style"`
+	// Prefix is put in front of every key, so that the files can share a bucket.
+	Prefix string `mapstructure:"prefix"`
+	// PartSize is the size of the parts of multipart uploads, at least 5 MiB.
+	PartSize int64 `mapstructure:"part_size"`
+}
+
+// UploadConfig configures file uploads and resumable uploads.
+type UploadConfig struct {
+	// Expiration is how long an incomplete upload is kept after its last chunk.
+	Expiration time.Duration `mapstructure:"expiration"`
+	// CleanupInterval is how often expired uploads are removed.
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
+	// MaxFileSize is the size of the largest file in bytes, unlimited if zero.
+	MaxFileSize int64 `mapstructure:"max_file_size"`
+	// AllowedTypes and DeniedTypes limit the media types of files, as sniffed from their content,
+	// with "image/*" for every image type. Any type that is not denied is allowed if AllowedTypes
+	// is empty.
+	AllowedTypes []string `mapstructure:"allowed_types"`
+	DeniedTypes  []string `mapstructure:"denied_types"`
+	// ExtractMaxFiles, ExtractMaxSize and ExtractMaxRatio limit what an archive may expand to: the
+	// number of entries, the total size of its files in bytes, and that size divided by the size
+	// of the archive. Zero means unlimited.
+	ExtractMaxFiles int   `mapstructure:"extract_max_files"`
+	ExtractMaxSize  int64 `mapstructure:"extract_max_size"`
+	ExtractMaxRatio int64 `mapstructure:"extract_max_ratio"`
+}
+
+// ShareConfig configures the links that let anyone download a file without logging in.
+type ShareConfig struct {
+	// Secret signs the tokens of the links, which stop working when it changes.
+	Secret string `mapstructure:"secret"`
+	// BaseURL is what the URLs of links start with, like https://files.example.com. The host of
+	// the request that creates a link is used if it is empty.
+	BaseURL string `mapstructure:"base_url"`
+	// DefaultTTL is how long a link is valid if no expiry is given, MaxTTL how long it can be valid
+	// at most, unlimited if zero.
+	DefaultTTL time.Duration `mapstructure:"default_ttl"`
+	MaxTTL     time.Duration `mapstructure:"max_ttl"`
 }
 
 type ServerConfig struct {
-	Port      string `mapstructure:"port"`
-	JWTSecret string `mapstructure:"jwt_secret"`
+	Port            string        `mapstructure:"port"`
+	JWTSecret       string        `mapstructure:"jwt_secret"`
+	AccessTokenTTL  time.Duration `mapstructure:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl"`
+	// TrustedProxies may set X-Forwarded-For, which then decides the client IP of login throttling.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 type SnowflakeConfig struct {